@@ -17,6 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,8 +32,57 @@ var (
 	ThreeScaleAccountRequested ProvisioningStatus = "3scale account requested"
 )
 
+// TenantPhase is the lifecycle phase of an APIManagementTenant
+type TenantPhase string
+
+const (
+	TenantPhaseProvisioning   TenantPhase = "Provisioning"
+	TenantPhaseReady          TenantPhase = "Ready"
+	TenantPhaseDeprovisioning TenantPhase = "Deprovisioning"
+)
+
 // APIManagementTenantSpec defines the desired state of APIManagementTenant
 type APIManagementTenantSpec struct {
+	// Billing configures the invoicing and charging of the developer accounts of the tenant
+	Billing *TenantBillingSpec `json:"billing,omitempty"`
+	// RateLimit overrides the rate limit per tenant of the installation
+	RateLimit *TenantRateLimitSpec `json:"rateLimit,omitempty"`
+	// Offboarding configures the export of the tenant before it is deleted
+	Offboarding *TenantOffboardingSpec `json:"offboarding,omitempty"`
+}
+
+// TenantRateLimitSpec defines the limits of the requests to the gateways of a tenant
+type TenantRateLimitSpec struct {
+	// RequestsPerSecond is the number of requests allowed per second. The
+	// rate limit per tenant of the installation applies when unset
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerSecond uint32 `json:"requestsPerSecond,omitempty"`
+	// DailyQuota is the number of requests allowed per day
+	// +kubebuilder:validation:Minimum=1
+	DailyQuota uint32 `json:"dailyQuota,omitempty"`
+}
+
+// TenantBillingSpec defines the 3scale billing settings of a tenant
+type TenantBillingSpec struct {
+	// InvoicingEnabled enables the billing features of the tenant
+	InvoicingEnabled bool `json:"invoicingEnabled"`
+	// ChargingEnabled enables charging the invoices through the payment gateway
+	ChargingEnabled bool `json:"chargingEnabled,omitempty"`
+	// Currency is the ISO 4217 code of the currency used in the invoices
+	// +kubebuilder:validation:Pattern=`^[A-Z]{3}$`
+	Currency string `json:"currency,omitempty"`
+	// PaymentGatewaySecretRef references a Secret in the namespace of the
+	// APIManagementTenant with the payment gateway configuration. The "type"
+	// key contains the gateway type, any other key is passed as a gateway option
+	PaymentGatewaySecretRef *corev1.LocalObjectReference `json:"paymentGatewaySecretRef,omitempty"`
+}
+
+// TenantOffboardingSpec defines the export of the 3scale configuration and
+// the usage of a tenant when it is deleted
+type TenantOffboardingSpec struct {
+	// Export is the bucket the tenant is exported to. The credentials secret
+	// is read from the namespace of the APIManagementTenant
+	Export S3CompatibleStorageSpec `json:"export"`
 }
 
 // APIManagementTenantStatus defines the observed state of APIManagementTenant
@@ -38,6 +90,16 @@ type APIManagementTenantStatus struct {
 	LastError          string             `json:"lastError"`
 	ProvisioningStatus ProvisioningStatus `json:"provisioningStatus"`
 	TenantUrl          string             `json:"tenantUrl,omitempty"`
+	// Phase is the lifecycle phase of the tenant, the artifacts of the tenant
+	// are removed during the Deprovisioning phase
+	Phase TenantPhase `json:"phase,omitempty"`
+	// OffboardingExport is the key of the export of the tenant in the bucket
+	// of the offboarding spec
+	OffboardingExport string `json:"offboardingExport,omitempty"`
+	// BillingConfigHash is the hash of the last billing configuration applied to the tenant account
+	BillingConfigHash string `json:"billingConfigHash,omitempty"`
+	// ApplicationPlansHash is the hash of the last application plan templates applied to the tenant account
+	ApplicationPlansHash string `json:"applicationPlansHash,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -61,6 +123,13 @@ type APIManagementTenantList struct {
 	Items           []APIManagementTenant `json:"items"`
 }
 
+// GetUsername returns the name of the user the tenant belongs to, derived
+// from the dev or stage namespace of the user
+func (t *APIManagementTenant) GetUsername() string {
+	username := strings.TrimSuffix(t.Namespace, "-dev")
+	return strings.TrimSuffix(username, "-stage")
+}
+
 func init() {
 	SchemeBuilder.Register(&APIManagementTenant{}, &APIManagementTenantList{})
 }
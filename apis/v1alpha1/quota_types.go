@@ -0,0 +1,150 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// QuotaValidConditionType reports whether the quota can be selected by
+	// the installations
+	QuotaValidConditionType = "Valid"
+)
+
+// QuotaSpec defines a quota tier the installations can select through the
+// quota addon parameter. It takes precedence over the quota of the same
+// parameter in the quota config map
+type QuotaSpec struct {
+	// DisplayName is the name of the quota reported in the installation
+	// status
+	// +kubebuilder:validation:MinLength=1
+	DisplayName string `json:"displayName"`
+
+	// Param is the value of the quota addon parameter selecting the quota
+	// +kubebuilder:validation:MinLength=1
+	Param string `json:"param"`
+
+	// RateLimit is the rate limit applied to the 3scale API requests
+	RateLimit QuotaRateLimitSpec `json:"rateLimit"`
+
+	// Resources are the replicas and resources of the product workloads
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Resources []QuotaResourceSpec `json:"resources,omitempty"`
+}
+
+// QuotaRateLimitSpec defines the number of requests allowed per unit of time
+type QuotaRateLimitSpec struct {
+	// +kubebuilder:validation:Enum=second;minute;hour;day
+	Unit string `json:"unit"`
+
+	// +kubebuilder:validation:Minimum=1
+	RequestsPerUnit uint32 `json:"requestsPerUnit"`
+}
+
+// QuotaResourceSpec defines the replicas and resources of a product workload
+type QuotaResourceSpec struct {
+	// Name is the name of the workload
+	// +kubebuilder:validation:Enum=apicast_production;apicast_staging;backend_listener;backend_worker;rhssouser;grafana;ratelimit;noobaa-core
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// QuotaStatus defines the observed state of Quota
+type QuotaStatus struct {
+	// Active is true when the quota is the one selected by the installation
+	// +optional
+	Active bool `json:"active,omitempty"`
+
+	// Installation is the installation the quota is applied to
+	// +optional
+	Installation string `json:"installation,omitempty"`
+
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Param",type=string,JSONPath=`.spec.param`
+//+kubebuilder:printcolumn:name="Display Name",type=string,JSONPath=`.spec.displayName`
+//+kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.status.active`
+
+// Quota is the Schema for the quotas API
+type Quota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   QuotaSpec   `json:"spec,omitempty"`
+	Status QuotaStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// QuotaList contains a list of Quota
+type QuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Quota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Quota{}, &QuotaList{})
+}
+
+// ValidateCreate rejects the quotas with invalid settings the CRD schema
+// can't express. It's called by the validating webhook
+func (q *Quota) ValidateCreate() error {
+	return q.validate()
+}
+
+// ValidateUpdate rejects the updates leaving the quota with invalid settings
+func (q *Quota) ValidateUpdate(_ runtime.Object) error {
+	return q.validate()
+}
+
+// ValidateDelete allows every deletion, the installations using the quota
+// fall back to the quota config map
+func (q *Quota) ValidateDelete() error {
+	return nil
+}
+
+func (q *Quota) validate() error {
+	for _, resource := range q.Spec.Resources {
+		for name, request := range resource.Resources.Requests {
+			limit, ok := resource.Resources.Limits[name]
+			if ok && request.Cmp(limit) > 0 {
+				return fmt.Errorf("the %s request of %s can't be greater than its limit", name, resource.Name)
+			}
+		}
+	}
+	return nil
+}
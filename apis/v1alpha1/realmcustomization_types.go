@@ -0,0 +1,242 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// RealmCustomizationAppliedConditionType reports whether the
+	// customization is merged into the realm of its product
+	RealmCustomizationAppliedConditionType = "Applied"
+)
+
+// RealmCustomizationSpec defines customizations merged by the operator into
+// the realm it manages for a product: the openshift realm of rhsso or the
+// master realm of rhssouser. The customizations of the product are merged in
+// the order of their names, the ones conflicting with a previous one or with
+// the settings of the operator are rejected
+type RealmCustomizationSpec struct {
+	// Product is the product whose realm is customized
+	// +kubebuilder:validation:Enum=rhsso;rhssouser
+	Product ProductName `json:"product"`
+
+	// IdentityProviders are added to the realm, and removed once no
+	// customization defines them anymore
+	// +optional
+	// +listType=map
+	// +listMapKey=alias
+	IdentityProviders []RealmIdentityProviderSpec `json:"identityProviders,omitempty"`
+
+	// Clients are added to the realm, and removed once no customization
+	// defines them anymore
+	// +optional
+	// +listType=map
+	// +listMapKey=clientId
+	Clients []RealmClientSpec `json:"clients,omitempty"`
+
+	// RequiredActions are enabled in the realm. They're left as they are
+	// when the customization is removed
+	// +optional
+	// +listType=map
+	// +listMapKey=alias
+	RequiredActions []RealmRequiredActionSpec `json:"requiredActions,omitempty"`
+
+	// PasswordPolicy is the password policy of the realm in the Keycloak
+	// format, e.g. "length(12) and notUsername(undefined)". It can't be set
+	// for rhssouser along with the user SSO password policy of the
+	// installation
+	// +optional
+	PasswordPolicy string `json:"passwordPolicy,omitempty"`
+}
+
+// RealmIdentityProviderSpec defines an identity provider of the realm
+type RealmIdentityProviderSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	Alias string `json:"alias"`
+
+	// ProviderID is the type of the identity provider, e.g. oidc, saml or
+	// github
+	// +kubebuilder:validation:MinLength=1
+	ProviderID string `json:"providerId"`
+
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// +optional
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// +optional
+	TrustEmail bool `json:"trustEmail,omitempty"`
+
+	// +optional
+	FirstBrokerLoginFlowAlias string `json:"firstBrokerLoginFlowAlias,omitempty"`
+
+	// Config is the configuration of the identity provider, e.g. its
+	// clientId and authorizationUrl
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
+
+	// ClientSecretRef is the key of the secret, in the namespace of the
+	// installation, holding the client secret of the identity provider
+	// +optional
+	ClientSecretRef *corev1.SecretKeySelector `json:"clientSecretRef,omitempty"`
+}
+
+// RealmClientSpec defines an OpenID Connect client of the realm
+type RealmClientSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientId"`
+
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// +optional
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// +optional
+	PublicClient bool `json:"publicClient,omitempty"`
+
+	// +optional
+	RedirectURIs []string `json:"redirectUris,omitempty"`
+
+	// +optional
+	WebOrigins []string `json:"webOrigins,omitempty"`
+
+	// +optional
+	// +kubebuilder:default=true
+	StandardFlowEnabled *bool `json:"standardFlowEnabled,omitempty"`
+
+	// +optional
+	DirectAccessGrantsEnabled bool `json:"directAccessGrantsEnabled,omitempty"`
+
+	// +optional
+	ServiceAccountsEnabled bool `json:"serviceAccountsEnabled,omitempty"`
+}
+
+// RealmRequiredActionSpec enables a required action of the realm
+type RealmRequiredActionSpec struct {
+	// Alias of the required action, e.g. CONFIGURE_TOTP or VERIFY_EMAIL
+	// +kubebuilder:validation:MinLength=1
+	Alias string `json:"alias"`
+
+	// DefaultAction requires the action from every new user
+	// +optional
+	DefaultAction bool `json:"defaultAction,omitempty"`
+}
+
+// RealmCustomizationStatus defines the observed state of RealmCustomization
+type RealmCustomizationStatus struct {
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Product",type=string,JSONPath=`.spec.product`
+//+kubebuilder:printcolumn:name="Applied",type=string,JSONPath=`.status.conditions[?(@.type=="Applied")].status`
+
+// RealmCustomization is the Schema for the realmcustomizations API
+type RealmCustomization struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RealmCustomizationSpec   `json:"spec,omitempty"`
+	Status RealmCustomizationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RealmCustomizationList contains a list of RealmCustomization
+type RealmCustomizationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RealmCustomization `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RealmCustomization{}, &RealmCustomizationList{})
+}
+
+// ValidateCreate rejects the customizations the CRD schema can't check. It's
+// called by the validating webhook
+func (c *RealmCustomization) ValidateCreate() error {
+	return c.validate()
+}
+
+// ValidateUpdate rejects the updates leaving the customization invalid
+func (c *RealmCustomization) ValidateUpdate(_ runtime.Object) error {
+	return c.validate()
+}
+
+// ValidateDelete allows every deletion, the identity providers and clients
+// of the customization are removed from the realm
+func (c *RealmCustomization) ValidateDelete() error {
+	return nil
+}
+
+func (c *RealmCustomization) validate() error {
+	if c.Spec.Product != ProductRHSSO && c.Spec.Product != ProductRHSSOUser {
+		return fmt.Errorf("the realm of %s can't be customized, only rhsso and rhssouser", c.Spec.Product)
+	}
+
+	aliases := map[string]bool{}
+	for _, idp := range c.Spec.IdentityProviders {
+		if idp.Alias == "" || idp.ProviderID == "" {
+			return fmt.Errorf("the identity providers require an alias and a providerId")
+		}
+		if aliases[idp.Alias] {
+			return fmt.Errorf("the %s identity provider is defined twice", idp.Alias)
+		}
+		aliases[idp.Alias] = true
+		if _, ok := idp.Config["clientSecret"]; ok && idp.ClientSecretRef != nil {
+			return fmt.Errorf("the client secret of the %s identity provider is set by both its config and clientSecretRef", idp.Alias)
+		}
+	}
+
+	clientIDs := map[string]bool{}
+	for _, client := range c.Spec.Clients {
+		if client.ClientID == "" {
+			return fmt.Errorf("the clients require a clientId")
+		}
+		if clientIDs[client.ClientID] {
+			return fmt.Errorf("the %s client is defined twice", client.ClientID)
+		}
+		clientIDs[client.ClientID] = true
+	}
+
+	actions := map[string]bool{}
+	for _, action := range c.Spec.RequiredActions {
+		if action.Alias == "" {
+			return fmt.Errorf("the required actions require an alias")
+		}
+		if actions[action.Alias] {
+			return fmt.Errorf("the %s required action is defined twice", action.Alias)
+		}
+		actions[action.Alias] = true
+	}
+	return nil
+}
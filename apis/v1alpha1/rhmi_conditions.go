@@ -19,9 +19,11 @@ package v1alpha1
 import (
 	"fmt"
 	"path"
+	"time"
 
 	addonv1alpha1 "github.com/openshift/addon-operator/apis/addons/v1alpha1"
 	addoninstance "github.com/openshift/addon-operator/pkg/client"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,7 +33,35 @@ func (c RHMIConditionType) String() string {
 	return path.Join(group, string(c))
 }
 
-const HealthyConditionType RHMIConditionType = "Healthy"
+const (
+	HealthyConditionType                     RHMIConditionType = "Healthy"
+	VersionSkewConditionType                 RHMIConditionType = "VersionSkew"
+	NamespaceTerminationBlockedConditionType RHMIConditionType = "NamespaceTerminationBlocked"
+	MaintenanceModeConditionType             RHMIConditionType = "MaintenanceMode"
+	RoutesVerifiedConditionType              RHMIConditionType = "RoutesVerified"
+	EnvoyConfigRolloutConditionType          RHMIConditionType = "EnvoyConfigRollout"
+	ReadOnlyModeConditionType                RHMIConditionType = "ReadOnlyMode"
+	JobsStuckConditionType                   RHMIConditionType = "JobsStuck"
+	PausedConditionType                      RHMIConditionType = "Paused"
+	DryRunConditionType                      RHMIConditionType = "DryRun"
+	AvailableConditionType                   RHMIConditionType = "Available"
+	ProgressingConditionType                 RHMIConditionType = "Progressing"
+	DegradedConditionType                    RHMIConditionType = "Degraded"
+)
+
+// phaseReasons are the reasons of the phase conditions, the phase can be read
+// back from them
+var phaseReasons = map[StatusPhase]string{
+	PhaseNone:                   "NotStarted",
+	PhaseAwaitingOperator:       "AwaitingOperator",
+	PhaseAwaitingCloudResources: "AwaitingCloudResources",
+	PhaseCreatingComponents:     "CreatingComponents",
+	PhaseAwaitingComponents:     "AwaitingComponents",
+	PhaseInProgress:             "InProgress",
+	PhaseCompleted:              "Completed",
+	PhaseFailed:                 "Failed",
+	PhaseSkipped:                "Skipped",
+}
 
 func (i *RHMI) InstalledCondition() metav1.Condition {
 	return addoninstance.NewAddonInstanceConditionInstalled(
@@ -98,6 +128,184 @@ func (i *RHMI) ReadyToBeDeletedCondition() metav1.Condition {
 	}
 }
 
+func (i *RHMI) VersionSkewBlockedCondition(msg string) metav1.Condition {
+	return newRHMICondition(VersionSkewConditionType, metav1.ConditionTrue, "UnsupportedOperatorVersion", msg)
+}
+
+func (i *RHMI) VersionSkewSupportedCondition() metav1.Condition {
+	return newRHMICondition(VersionSkewConditionType, metav1.ConditionFalse, "SupportedOperatorVersion", "All product operators within supported version skew")
+}
+
+// IsVersionSkewBlocked when a product operator upgrade is blocked for being outside the supported version skew
+func (i *RHMI) IsVersionSkewBlocked() bool {
+	return meta.IsStatusConditionTrue(i.Status.Conditions, VersionSkewConditionType.String())
+}
+
+func (i *RHMI) NamespaceTerminationBlockedCondition(msg string) metav1.Condition {
+	return newRHMICondition(NamespaceTerminationBlockedConditionType, metav1.ConditionTrue, "UnsafeFinalizerCleanup", msg)
+}
+
+func (i *RHMI) NamespaceTerminationUnblockedCondition() metav1.Condition {
+	return newRHMICondition(NamespaceTerminationBlockedConditionType, metav1.ConditionFalse, "NamespacesTerminated", "No namespaces blocked in Terminating")
+}
+
+// IsNamespaceTerminationBlocked when a namespace is stuck Terminating on resources that can't be safely cleaned up
+func (i *RHMI) IsNamespaceTerminationBlocked() bool {
+	return meta.IsStatusConditionTrue(i.Status.Conditions, NamespaceTerminationBlockedConditionType.String())
+}
+
+func (i *RHMI) JobsStuckCondition(msg string) metav1.Condition {
+	return newRHMICondition(JobsStuckConditionType, metav1.ConditionTrue, "RetriesExhausted", msg)
+}
+
+func (i *RHMI) JobsRecoveredCondition() metav1.Condition {
+	return newRHMICondition(JobsStuckConditionType, metav1.ConditionFalse, "NoStuckJobs", "No Jobs stuck in the product namespaces")
+}
+
+func (i *RHMI) MaintenanceModeActiveCondition() metav1.Condition {
+	return newRHMICondition(MaintenanceModeConditionType, metav1.ConditionTrue, "MaintenanceScheduled", fmt.Sprintf("Customer APIs unavailable until %s", i.Spec.MaintenanceMode.Until.UTC().Format(time.RFC3339)))
+}
+
+func (i *RHMI) MaintenanceModeInactiveCondition() metav1.Condition {
+	return newRHMICondition(MaintenanceModeConditionType, metav1.ConditionFalse, "MaintenanceEnded", "Customer APIs available")
+}
+
+// IsMaintenanceModeActive when the managed gateways reply to customer API requests with a 503
+func (i *RHMI) IsMaintenanceModeActive(now time.Time) bool {
+	return i.Spec.MaintenanceMode != nil && i.Spec.MaintenanceMode.Enabled && now.Before(i.Spec.MaintenanceMode.Until.Time)
+}
+
+func (i *RHMI) RoutesVerifiedCondition() metav1.Condition {
+	return newRHMICondition(RoutesVerifiedConditionType, metav1.ConditionTrue, "RoutesReachable", "All routes resolvable and serving matching certificates")
+}
+
+func (i *RHMI) RoutesUnverifiedCondition(msg string) metav1.Condition {
+	return newRHMICondition(RoutesVerifiedConditionType, metav1.ConditionFalse, "RoutesUnreachable", msg)
+}
+
+func (i *RHMI) EnvoyConfigRolledOutCondition() metav1.Condition {
+	return newRHMICondition(EnvoyConfigRolloutConditionType, metav1.ConditionTrue, "CanaryValidated", "Envoy configuration validated on the canary gateway and rolled out")
+}
+
+func (i *RHMI) EnvoyConfigRejectedCondition(msg string) metav1.Condition {
+	return newRHMICondition(EnvoyConfigRolloutConditionType, metav1.ConditionFalse, "CanaryFailed", msg)
+}
+
+func (i *RHMI) ReadOnlyModeActiveCondition(reason, msg string) metav1.Condition {
+	return newRHMICondition(ReadOnlyModeConditionType, metav1.ConditionTrue, reason, msg)
+}
+
+func (i *RHMI) ReadOnlyModeInactiveCondition() metav1.Condition {
+	return newRHMICondition(ReadOnlyModeConditionType, metav1.ConditionFalse, "ReconcilesResumed", "Product reconciles running")
+}
+
+func (i *RHMI) PausedCondition() metav1.Condition {
+	return newRHMICondition(PausedConditionType, metav1.ConditionTrue, "PausedByAnnotation",
+		fmt.Sprintf("Product and cloud resource reconciles halted until the %s annotation is removed", PausedAnnotation))
+}
+
+func (i *RHMI) DryRunPlannedCondition(msg string) metav1.Condition {
+	return newRHMICondition(DryRunConditionType, metav1.ConditionTrue, "PlanReady", msg)
+}
+
+func (i *RHMI) DryRunFailedCondition(msg string) metav1.Condition {
+	return newRHMICondition(DryRunConditionType, metav1.ConditionFalse, "PlanFailed", msg)
+}
+
+func (i *RHMI) ResumedCondition() metav1.Condition {
+	return newRHMICondition(PausedConditionType, metav1.ConditionFalse, "ReconcilesResumed", "Product and cloud resource reconciles running")
+}
+
+// IsReadOnlyModeRequested when the product reconciles should be suspended, and why
+func (i *RHMI) IsReadOnlyModeRequested(clusterUpgrading bool) (bool, string) {
+	switch i.Spec.ReadOnlyMode {
+	case ReadOnlyModeEnabled:
+		return true, "ManuallyEnabled"
+	case ReadOnlyModeDisabled:
+		return false, ""
+	default:
+		return clusterUpgrading, "ClusterUpgrading"
+	}
+}
+
+// PhaseConditions returns the Available, Progressing and Degraded conditions
+// of a phase
+func PhaseConditions(phase StatusPhase) []metav1.Condition {
+	reason, ok := phaseReasons[phase]
+	if !ok {
+		reason = "Unknown"
+	}
+	available, progressing, degraded := metav1.ConditionFalse, metav1.ConditionFalse, metav1.ConditionFalse
+	switch phase {
+	case PhaseCompleted:
+		available = metav1.ConditionTrue
+	case PhaseFailed:
+		degraded = metav1.ConditionTrue
+	case PhaseNone, PhaseSkipped:
+	default:
+		progressing = metav1.ConditionTrue
+	}
+
+	message := fmt.Sprintf("Phase %q", phase)
+	return []metav1.Condition{
+		newRHMICondition(AvailableConditionType, available, reason, message),
+		newRHMICondition(ProgressingConditionType, progressing, reason, message),
+		newRHMICondition(DegradedConditionType, degraded, reason, message),
+	}
+}
+
+// PhaseFromConditions returns the phase the conditions were set from by
+// PhaseConditions
+func PhaseFromConditions(conditions []metav1.Condition) StatusPhase {
+	condition := meta.FindStatusCondition(conditions, AvailableConditionType.String())
+	if condition == nil {
+		return PhaseNone
+	}
+	for phase, reason := range phaseReasons {
+		if reason == condition.Reason {
+			return phase
+		}
+	}
+	return PhaseNone
+}
+
+// SetPhaseConditions sets the phase conditions of the installation and of the
+// products from their phases. The products are rebuilt on every reconcile so
+// their conditions are carried over from the previous status, keeping the
+// transition times
+func (i *RHMI) SetPhaseConditions(previous RHMIStatus) {
+	phase := PhaseInProgress
+	if i.Status.Stage == CompleteStage {
+		phase = PhaseCompleted
+	}
+
+	for stageName, stage := range i.Status.Stages {
+		for productName, product := range stage.Products {
+			if len(product.Conditions) == 0 {
+				if previousStage, ok := previous.Stages[stageName]; ok {
+					product.Conditions = append([]metav1.Condition{}, previousStage.Products[productName].Conditions...)
+				}
+			}
+			for _, condition := range PhaseConditions(product.Phase) {
+				meta.SetStatusCondition(&product.Conditions, condition)
+			}
+			stage.Products[productName] = product
+		}
+		if stage.Phase == PhaseFailed && phase != PhaseCompleted {
+			phase = PhaseFailed
+		}
+	}
+
+	for _, condition := range PhaseConditions(phase) {
+		meta.SetStatusCondition(&i.Status.Conditions, condition)
+	}
+}
+
+// GetCondition returns the condition of the given type from the status, or nil if it is not set
+func (i *RHMI) GetCondition(conditionType RHMIConditionType) *metav1.Condition {
+	return meta.FindStatusCondition(i.Status.Conditions, conditionType.String())
+}
+
 func newRHMICondition(conditionType RHMIConditionType, conditionStatus metav1.ConditionStatus, reason, msg string) metav1.Condition {
 	return metav1.Condition{
 		Type:    conditionType.String(),
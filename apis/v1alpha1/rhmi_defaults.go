@@ -0,0 +1,93 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPriorityClassName is the priority class of the product pods when
+// the installation doesn't set one
+const DefaultPriorityClassName = "rhoam-pod-priority"
+
+// Default sets the effective defaults of the spec, so they're visible on the
+// CR instead of being applied by the reconcilers. It's called by the mutating
+// webhook and at the start of every reconcile for the installations created
+// while the webhook isn't running
+func (i *RHMI) Default() {
+	if i.Spec.Type == "" {
+		i.Spec.Type = string(InstallationTypeManagedApi)
+	}
+	if i.Spec.NamespacePrefix == "" {
+		i.Spec.NamespacePrefix = namespacePrefixFor(i.Namespace)
+	}
+	if i.Spec.NamespaceTopology == "" {
+		i.Spec.NamespaceTopology = NamespaceTopologyPerProduct
+	}
+	if prefix := i.Spec.NamespacePrefix; prefix != "" {
+		if i.Spec.SMTPSecret == "" {
+			i.Spec.SMTPSecret = prefix + "smtp"
+		}
+		if i.Spec.DeadMansSnitchSecret == "" {
+			i.Spec.DeadMansSnitchSecret = prefix + "deadmanssnitch"
+		}
+		if i.Spec.PagerDutySecret == "" {
+			i.Spec.PagerDutySecret = prefix + "pagerduty"
+		}
+	}
+	if i.Spec.PriorityClassName == "" {
+		i.Spec.PriorityClassName = DefaultPriorityClassName
+	}
+	if i.Spec.DefaultQuota == "" {
+		i.Spec.DefaultQuota = os.Getenv(EnvKeyQuota)
+	}
+	i.defaultReconcile()
+}
+
+// defaultReconcile sets the intervals the installation is reconciled with,
+// after the type they depend on is defaulted
+func (i *RHMI) defaultReconcile() {
+	if i.Spec.Reconcile == nil {
+		i.Spec.Reconcile = &ReconcileSpec{}
+	}
+	if i.Spec.Reconcile.Interval == nil {
+		i.Spec.Reconcile.Interval = &metav1.Duration{Duration: i.ReconcileInterval()}
+	}
+	if i.Spec.Reconcile.RetryInterval == nil {
+		i.Spec.Reconcile.RetryInterval = &metav1.Duration{Duration: i.RetryInterval()}
+	}
+	if i.Spec.Reconcile.MaxErrorBackoff == nil {
+		i.Spec.Reconcile.MaxErrorBackoff = &metav1.Duration{Duration: i.MaxErrorBackoff()}
+	}
+	if i.Spec.Reconcile.MaxConcurrentProducts == nil {
+		maxConcurrentProducts := int32(i.MaxConcurrentProducts())
+		i.Spec.Reconcile.MaxConcurrentProducts = &maxConcurrentProducts
+	}
+}
+
+// namespacePrefixFor returns the prefix of the operator namespace, e.g.
+// redhat-rhoam- for redhat-rhoam-operator
+func namespacePrefixFor(namespace string) string {
+	segments := strings.Split(namespace, "-")
+	if len(segments) < 3 {
+		return ""
+	}
+	return strings.Join(segments[0:2], "-") + "-"
+}
@@ -0,0 +1,92 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRHMI_Default(t *testing.T) {
+	tests := []struct {
+		name         string
+		installation *RHMI
+		quotaEnv     string
+		want         RHMISpec
+	}{
+		{
+			name:         "defaults are derived from the operator namespace",
+			installation: &RHMI{ObjectMeta: v1.ObjectMeta{Namespace: "redhat-rhoam-operator"}},
+			quotaEnv:     "1 Million",
+			want: RHMISpec{
+				Type:                 string(InstallationTypeManagedApi),
+				NamespacePrefix:      "redhat-rhoam-",
+				SMTPSecret:           "redhat-rhoam-smtp",
+				DeadMansSnitchSecret: "redhat-rhoam-deadmanssnitch",
+				PagerDutySecret:      "redhat-rhoam-pagerduty",
+				PriorityClassName:    DefaultPriorityClassName,
+				NamespaceTopology:    NamespaceTopologyPerProduct,
+				DefaultQuota:         "1 Million",
+				Reconcile:            reconcileSpec(DefaultReconcileInterval, DefaultRetryInterval),
+			},
+		},
+		{
+			name: "values set on the spec are kept",
+			installation: &RHMI{
+				ObjectMeta: v1.ObjectMeta{Namespace: "redhat-rhoam-operator"},
+				Spec: RHMISpec{
+					Type:              string(InstallationTypeMultitenantManagedApi),
+					NamespacePrefix:   "sandbox-",
+					SMTPSecret:        "custom-smtp",
+					PriorityClassName: "custom-priority",
+					DefaultQuota:      "100K",
+					Reconcile: &ReconcileSpec{
+						RetryInterval: &v1.Duration{Duration: 30 * time.Second},
+					},
+				},
+			},
+			quotaEnv: "1 Million",
+			want: RHMISpec{
+				Type:                 string(InstallationTypeMultitenantManagedApi),
+				NamespacePrefix:      "sandbox-",
+				SMTPSecret:           "custom-smtp",
+				DeadMansSnitchSecret: "sandbox-deadmanssnitch",
+				PagerDutySecret:      "sandbox-pagerduty",
+				PriorityClassName:    "custom-priority",
+				NamespaceTopology:    NamespaceTopologyPerProduct,
+				DefaultQuota:         "100K",
+				Reconcile:            reconcileSpec(DefaultMultitenantReconcileInterval, 30*time.Second),
+			},
+		},
+		{
+			name:         "no namespace prefix without an operator namespace",
+			installation: &RHMI{ObjectMeta: v1.ObjectMeta{Namespace: "rhoam"}},
+			want: RHMISpec{
+				Type:              string(InstallationTypeManagedApi),
+				PriorityClassName: DefaultPriorityClassName,
+				NamespaceTopology: NamespaceTopologyPerProduct,
+				Reconcile:         reconcileSpec(DefaultReconcileInterval, DefaultRetryInterval),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvKeyQuota, tt.quotaEnv)
+			tt.installation.Default()
+			if !reflect.DeepEqual(tt.installation.Spec, tt.want) {
+				t.Errorf("Default() = %+v, want %+v", tt.installation.Spec, tt.want)
+			}
+		})
+	}
+}
+
+func reconcileSpec(interval, retryInterval time.Duration) *ReconcileSpec {
+	maxConcurrentProducts := int32(DefaultMaxConcurrentProducts)
+	return &ReconcileSpec{
+		Interval:              &v1.Duration{Duration: interval},
+		RetryInterval:         &v1.Duration{Duration: retryInterval},
+		MaxErrorBackoff:       &v1.Duration{Duration: DefaultMaxErrorBackoff},
+		MaxConcurrentProducts: &maxConcurrentProducts,
+	}
+}
@@ -0,0 +1,95 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ConfigurationSecrets returns the names of the secrets of the installation
+// namespace referenced by the spec for the SMTP, alerting, custom domain,
+// identity provider and LDAP credentials
+func (i *RHMI) ConfigurationSecrets() []string {
+	names := map[string]bool{
+		i.Spec.SMTPSecret:           true,
+		i.Spec.PagerDutySecret:      true,
+		i.Spec.DeadMansSnitchSecret: true,
+	}
+	if i.Spec.SMTPProvider != nil {
+		names[i.Spec.SMTPProvider.CredentialsSecret] = true
+	}
+	if i.Spec.CustomDomainDNS != nil {
+		names[i.Spec.CustomDomainDNS.CredentialsSecret] = true
+	}
+	if i.Spec.CustomDomain != nil {
+		for _, route := range i.Spec.CustomDomain.Routes {
+			names[route.TLSSecret] = true
+		}
+	}
+	if i.Spec.UserSSOIdentityProvider != nil {
+		names[i.Spec.UserSSOIdentityProvider.ConfigSecret] = true
+	}
+	if i.Spec.UserSSOLDAPFederation != nil {
+		names[i.Spec.UserSSOLDAPFederation.BindCredentialsSecret] = true
+	}
+	if alerting := i.Spec.Alerting; alerting != nil {
+		if alerting.Heartbeat != nil {
+			names[alerting.Heartbeat.URLSecret.Name] = true
+		}
+		for _, receiver := range alerting.Receivers {
+			if receiver.PagerDuty != nil {
+				names[receiver.PagerDuty.RoutingKeySecret.Name] = true
+			}
+			if receiver.Slack != nil {
+				names[receiver.Slack.WebhookURLSecret.Name] = true
+			}
+			if receiver.OpsGenie != nil {
+				names[receiver.OpsGenie.APIKeySecret.Name] = true
+			}
+			if receiver.Webhook != nil {
+				names[receiver.Webhook.URLSecret.Name] = true
+			}
+		}
+	}
+	delete(names, "")
+
+	secrets := make([]string, 0, len(names))
+	for name := range names {
+		secrets = append(secrets, name)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// validateExternalSecrets rejects the external secrets that aren't referenced
+// by the spec, so the secrets of the operator can't be overwritten
+func (i *RHMI) validateExternalSecrets() error {
+	if i.Spec.ExternalSecrets == nil {
+		return nil
+	}
+	referenced := map[string]bool{}
+	for _, name := range i.ConfigurationSecrets() {
+		referenced[name] = true
+	}
+	for _, secret := range i.Spec.ExternalSecrets.Secrets {
+		if !referenced[secret.Name] {
+			return fmt.Errorf("spec.externalSecrets.secrets %s is not a SMTP, alerting or custom domain secret of the spec", secret.Name)
+		}
+	}
+	return nil
+}
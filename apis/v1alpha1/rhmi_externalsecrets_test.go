@@ -0,0 +1,60 @@
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRHMI_ConfigurationSecrets(t *testing.T) {
+	i := &RHMI{Spec: RHMISpec{
+		SMTPSecret:      "redhat-rhoam-smtp",
+		PagerDutySecret: "redhat-rhoam-pagerduty",
+		SMTPProvider:    &SMTPProviderSpec{CredentialsSecret: "sendgrid"},
+		CustomDomain: &CustomDomainSpec{Routes: []CustomRouteSpec{
+			{Route: CustomRouteThreeScaleAdmin, TLSSecret: "custom-tls"},
+			{Route: CustomRouteRHSSO, TLSSecret: "custom-tls"},
+		}},
+		Alerting: &AlertingSpec{Receivers: []AlertReceiverSpec{{
+			Name:  "slack",
+			Slack: &SlackReceiverSpec{WebhookURLSecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "slack-webhook"}, Key: "url"}},
+		}}},
+	}}
+	want := []string{"custom-tls", "redhat-rhoam-pagerduty", "redhat-rhoam-smtp", "sendgrid", "slack-webhook"}
+	if got := i.ConfigurationSecrets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigurationSecrets() = %v, want %v", got, want)
+	}
+}
+
+func TestRHMI_validateExternalSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{
+			name:   "secret of the spec",
+			secret: "redhat-rhoam-smtp",
+		},
+		{
+			name:    "secret of the operator",
+			secret:  "rhmi-oauth-client-secrets",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{
+				SMTPSecret: "redhat-rhoam-smtp",
+				ExternalSecrets: &ExternalSecretsSpec{
+					SecretStore: ExternalSecretStoreRef{Name: "vault"},
+					Secrets:     []ExternalSecretSpec{{Name: tt.secret, Key: "rhoam/smtp"}},
+				},
+			}}
+			if err := i.validateExternalSecrets(); (err != nil) != tt.wantErr {
+				t.Errorf("validateExternalSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
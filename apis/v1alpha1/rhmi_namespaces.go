@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type NamespaceTopology string
+
+const (
+	NamespaceTopologyPerProduct   NamespaceTopology = "PerProduct"
+	NamespaceTopologyConsolidated NamespaceTopology = "Consolidated"
+)
+
+// maxNamespacePrefixLength keeps the longest product namespace,
+// <prefix>customer-monitoring-operator, within the 63 characters of a
+// namespace name
+const maxNamespacePrefixLength = 35
+
+var namespacePrefixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?-$`)
+
+// ProductNamespace returns the namespace of a product, the name of the
+// product namespace prefixed by the namespace prefix of the installation
+func (i *RHMI) ProductNamespace(name string) string {
+	return i.Spec.NamespacePrefix + name
+}
+
+// OperatorNamespace returns the namespace of the operator of the product
+// installed in productNamespace
+func (i *RHMI) OperatorNamespace(productNamespace string) string {
+	if i.Spec.OperatorsInProductNamespace {
+		return productNamespace
+	}
+	return productNamespace + "-operator"
+}
+
+// validateNamespaces rejects the namespace prefixes that can't prefix a
+// namespace name, and the Consolidated topology until the products can be
+// installed in a single namespace
+func (i *RHMI) validateNamespaces() error {
+	if i.Spec.NamespaceTopology == NamespaceTopologyConsolidated {
+		return fmt.Errorf("spec.namespaceTopology %s isn't supported yet", NamespaceTopologyConsolidated)
+	}
+	prefix := i.Spec.NamespacePrefix
+	if prefix == "" {
+		return nil
+	}
+	if len(prefix) > maxNamespacePrefixLength {
+		return fmt.Errorf("spec.namespacePrefix %q is longer than %d characters", prefix, maxNamespacePrefixLength)
+	}
+	if !namespacePrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("spec.namespacePrefix %q must be lowercase alphanumeric characters or '-', and end with '-'", prefix)
+	}
+	return nil
+}
+
+// validateNamespacesUpdate rejects the changes of the namespaces of an
+// installed installation, its products would be installed again in the new
+// namespaces
+func (i *RHMI) validateNamespacesUpdate(old *RHMI) error {
+	if old == nil || !old.IsInstalled() {
+		return nil
+	}
+	if old.Spec.NamespacePrefix != "" && i.Spec.NamespacePrefix != old.Spec.NamespacePrefix {
+		return fmt.Errorf("spec.namespacePrefix can't be changed once installed")
+	}
+	if old.namespaceTopology() != i.namespaceTopology() {
+		return fmt.Errorf("spec.namespaceTopology can't be changed once installed")
+	}
+	return nil
+}
+
+func (i *RHMI) namespaceTopology() NamespaceTopology {
+	if i.Spec.NamespaceTopology == "" {
+		return NamespaceTopologyPerProduct
+	}
+	return i.Spec.NamespaceTopology
+}
@@ -0,0 +1,110 @@
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func TestRHMI_OperatorNamespace(t *testing.T) {
+	tests := []struct {
+		name string
+		spec RHMISpec
+		want string
+	}{
+		{
+			name: "operators in their own namespace by default",
+			spec: RHMISpec{NamespacePrefix: "redhat-rhoam-"},
+			want: "redhat-rhoam-3scale-operator",
+		},
+		{
+			name: "operators in their own namespace with the per product topology",
+			spec: RHMISpec{NamespacePrefix: "redhat-rhoam-", NamespaceTopology: NamespaceTopologyPerProduct},
+			want: "redhat-rhoam-3scale-operator",
+		},
+		{
+			name: "operators in the product namespace when requested",
+			spec: RHMISpec{NamespacePrefix: "small-", OperatorsInProductNamespace: true},
+			want: "small-3scale",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: tt.spec}
+			if got := i.OperatorNamespace(i.ProductNamespace("3scale")); got != tt.want {
+				t.Errorf("OperatorNamespace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRHMI_validateNamespaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		topology NamespaceTopology
+		wantErr  bool
+	}{
+		{name: "no prefix", prefix: ""},
+		{name: "default prefix", prefix: "redhat-rhoam-"},
+		{name: "single segment prefix", prefix: "rhoam-"},
+		{name: "prefix not ending with a dash", prefix: "redhat-rhoam", wantErr: true},
+		{name: "uppercase prefix", prefix: "RHOAM-", wantErr: true},
+		{name: "prefix starting with a dash", prefix: "-rhoam-", wantErr: true},
+		{name: "prefix too long", prefix: "a-very-long-namespace-prefix-for-rhoam-", wantErr: true},
+		{name: "per product topology", prefix: "redhat-rhoam-", topology: NamespaceTopologyPerProduct},
+		{name: "consolidated topology not supported", prefix: "redhat-rhoam-", topology: NamespaceTopologyConsolidated, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{NamespacePrefix: tt.prefix, NamespaceTopology: tt.topology}}
+			if err := i.validateNamespaces(); (err != nil) != tt.wantErr {
+				t.Errorf("validateNamespaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_validateNamespacesUpdate(t *testing.T) {
+	installed := RHMIStatus{Version: "1.40.0"}
+	tests := []struct {
+		name    string
+		old     *RHMI
+		spec    RHMISpec
+		wantErr bool
+	}{
+		{
+			name: "prefix set before the install",
+			old:  &RHMI{},
+			spec: RHMISpec{NamespacePrefix: "rhoam-"},
+		},
+		{
+			name: "prefix defaulted once installed",
+			old:  &RHMI{Status: installed},
+			spec: RHMISpec{NamespacePrefix: "redhat-rhoam-"},
+		},
+		{
+			name:    "prefix changed once installed",
+			old:     &RHMI{Spec: RHMISpec{NamespacePrefix: "redhat-rhoam-"}, Status: installed},
+			spec:    RHMISpec{NamespacePrefix: "rhoam-"},
+			wantErr: true,
+		},
+		{
+			name: "topology defaulted once installed",
+			old:  &RHMI{Spec: RHMISpec{NamespacePrefix: "redhat-rhoam-"}, Status: installed},
+			spec: RHMISpec{NamespacePrefix: "redhat-rhoam-", NamespaceTopology: NamespaceTopologyPerProduct},
+		},
+		{
+			name:    "topology changed once installed",
+			old:     &RHMI{Spec: RHMISpec{NamespacePrefix: "redhat-rhoam-"}, Status: installed},
+			spec:    RHMISpec{NamespacePrefix: "redhat-rhoam-", NamespaceTopology: NamespaceTopologyConsolidated},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: tt.spec}
+			if err := i.ValidateUpdate(tt.old); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultReconcileInterval between the reconciles of a complete installation
+	DefaultReconcileInterval = 5 * time.Minute
+	// DefaultMultitenantReconcileInterval between the reconciles of a complete
+	// multitenant installation, so new tenants are picked up quickly
+	DefaultMultitenantReconcileInterval = 30 * time.Second
+	// DefaultRetryInterval between the reconciles of an installation in progress
+	DefaultRetryInterval = 10 * time.Second
+	// DefaultMaxErrorBackoff caps the backoff of the failed reconciles, it's
+	// the cap of the controller-runtime default rate limiter
+	DefaultMaxErrorBackoff = 1000 * time.Second
+	// DefaultMaxConcurrentProducts reconciled at once
+	DefaultMaxConcurrentProducts = 4
+
+	// minReconcileInterval keeps the reconciles from hammering the API server
+	minReconcileInterval = time.Second
+)
+
+// ReconcileInterval returns the interval between the reconciles of a complete
+// installation
+func (i *RHMI) ReconcileInterval() time.Duration {
+	if i.Spec.Reconcile != nil && i.Spec.Reconcile.Interval != nil {
+		return i.Spec.Reconcile.Interval.Duration
+	}
+	if IsRHOAMMultitenant(InstallationType(i.Spec.Type)) {
+		return DefaultMultitenantReconcileInterval
+	}
+	return DefaultReconcileInterval
+}
+
+// RetryInterval returns the interval between the reconciles of an installation
+// in progress
+func (i *RHMI) RetryInterval() time.Duration {
+	if i.Spec.Reconcile != nil && i.Spec.Reconcile.RetryInterval != nil {
+		return i.Spec.Reconcile.RetryInterval.Duration
+	}
+	return DefaultRetryInterval
+}
+
+// MaxErrorBackoff returns the cap of the backoff of the failed reconciles
+func (i *RHMI) MaxErrorBackoff() time.Duration {
+	if i.Spec.Reconcile != nil && i.Spec.Reconcile.MaxErrorBackoff != nil {
+		return i.Spec.Reconcile.MaxErrorBackoff.Duration
+	}
+	return DefaultMaxErrorBackoff
+}
+
+// MaxConcurrentProducts returns the number of products reconciled at once
+func (i *RHMI) MaxConcurrentProducts() int {
+	if i.Spec.Reconcile != nil && i.Spec.Reconcile.MaxConcurrentProducts != nil {
+		return int(*i.Spec.Reconcile.MaxConcurrentProducts)
+	}
+	return DefaultMaxConcurrentProducts
+}
+
+// ProductReconcileInterval returns the interval between the reconciles of an
+// installed product, or 0 when it's reconciled along with the installation
+func (i *RHMI) ProductReconcileInterval(productName ProductName) time.Duration {
+	if i.Spec.Reconcile == nil {
+		return 0
+	}
+	for _, product := range i.Spec.Reconcile.Products {
+		if product.Name == productName {
+			return product.Interval.Duration
+		}
+	}
+	return 0
+}
+
+func (i *RHMI) validateReconcile() error {
+	if i.Spec.Reconcile == nil {
+		return nil
+	}
+	durations := []struct {
+		field    string
+		duration time.Duration
+	}{
+		{"interval", i.ReconcileInterval()},
+		{"retryInterval", i.RetryInterval()},
+		{"maxErrorBackoff", i.MaxErrorBackoff()},
+	}
+	for _, d := range durations {
+		if d.duration < minReconcileInterval {
+			return fmt.Errorf("spec.reconcile.%s must be at least %s, got %s", d.field, minReconcileInterval, d.duration)
+		}
+	}
+
+	interval := i.ReconcileInterval()
+	for _, product := range i.Spec.Reconcile.Products {
+		if product.Interval.Duration < interval {
+			return fmt.Errorf("spec.reconcile.products interval of %s must be at least the installation interval %s, got %s", product.Name, interval, product.Interval.Duration)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRHMI_ReconcileIntervals(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration {
+		return &metav1.Duration{Duration: d}
+	}
+
+	tests := []struct {
+		name              string
+		spec              RHMISpec
+		wantInterval      time.Duration
+		wantRetryInterval time.Duration
+		wantMaxBackoff    time.Duration
+		wantMaxConcurrent int
+	}{
+		{
+			name:              "test defaults",
+			spec:              RHMISpec{Type: string(InstallationTypeManagedApi)},
+			wantInterval:      DefaultReconcileInterval,
+			wantRetryInterval: DefaultRetryInterval,
+			wantMaxBackoff:    DefaultMaxErrorBackoff,
+			wantMaxConcurrent: DefaultMaxConcurrentProducts,
+		},
+		{
+			name:              "test multitenant default interval",
+			spec:              RHMISpec{Type: string(InstallationTypeMultitenantManagedApi)},
+			wantInterval:      DefaultMultitenantReconcileInterval,
+			wantRetryInterval: DefaultRetryInterval,
+			wantMaxBackoff:    DefaultMaxErrorBackoff,
+			wantMaxConcurrent: DefaultMaxConcurrentProducts,
+		},
+		{
+			name: "test overrides",
+			spec: RHMISpec{
+				Type: string(InstallationTypeMultitenantManagedApi),
+				Reconcile: &ReconcileSpec{
+					Interval:              duration(time.Hour),
+					RetryInterval:         duration(time.Second),
+					MaxErrorBackoff:       duration(time.Minute),
+					MaxConcurrentProducts: func(i int32) *int32 { return &i }(1),
+				},
+			},
+			wantInterval:      time.Hour,
+			wantRetryInterval: time.Second,
+			wantMaxBackoff:    time.Minute,
+			wantMaxConcurrent: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: tt.spec}
+			if got := i.ReconcileInterval(); got != tt.wantInterval {
+				t.Errorf("ReconcileInterval() = %v, want %v", got, tt.wantInterval)
+			}
+			if got := i.RetryInterval(); got != tt.wantRetryInterval {
+				t.Errorf("RetryInterval() = %v, want %v", got, tt.wantRetryInterval)
+			}
+			if got := i.MaxErrorBackoff(); got != tt.wantMaxBackoff {
+				t.Errorf("MaxErrorBackoff() = %v, want %v", got, tt.wantMaxBackoff)
+			}
+			if got := i.MaxConcurrentProducts(); got != tt.wantMaxConcurrent {
+				t.Errorf("MaxConcurrentProducts() = %v, want %v", got, tt.wantMaxConcurrent)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateReconcile(t *testing.T) {
+	tests := []struct {
+		name      string
+		reconcile *ReconcileSpec
+		wantErr   bool
+	}{
+		{
+			name: "test valid without reconcile settings",
+		},
+		{
+			name: "test valid settings",
+			reconcile: &ReconcileSpec{
+				Interval:        &metav1.Duration{Duration: time.Minute},
+				RetryInterval:   &metav1.Duration{Duration: time.Second},
+				MaxErrorBackoff: &metav1.Duration{Duration: 5 * time.Minute},
+				Products: []ProductReconcileSpec{
+					{Name: Product3Scale, Interval: metav1.Duration{Duration: time.Minute}},
+					{Name: ProductGrafana, Interval: metav1.Duration{Duration: time.Hour}},
+				},
+			},
+		},
+		{
+			name:      "test interval too short",
+			reconcile: &ReconcileSpec{Interval: &metav1.Duration{Duration: time.Millisecond}},
+			wantErr:   true,
+		},
+		{
+			name:      "test retry interval too short",
+			reconcile: &ReconcileSpec{RetryInterval: &metav1.Duration{}},
+			wantErr:   true,
+		},
+		{
+			name:      "test error backoff too short",
+			reconcile: &ReconcileSpec{MaxErrorBackoff: &metav1.Duration{Duration: -time.Second}},
+			wantErr:   true,
+		},
+		{
+			name: "test product interval shorter than the installation one",
+			reconcile: &ReconcileSpec{
+				Products: []ProductReconcileSpec{
+					{Name: ProductGrafana, Interval: metav1.Duration{Duration: time.Minute}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{Type: string(InstallationTypeManagedApi), Reconcile: tt.reconcile}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := i.ValidateUpdate(&RHMI{}); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -40,6 +41,7 @@ var (
 	PhaseInProgress StatusPhase = "in progress"
 	PhaseCompleted  StatusPhase = "completed"
 	PhaseFailed     StatusPhase = "failed"
+	PhaseSkipped    StatusPhase = "skipped"
 
 	InstallationTypeManagedApi            InstallationType = "managed-api"
 	InstallationTypeMultitenantManagedApi InstallationType = "multitenant-managed-api"
@@ -93,6 +95,37 @@ var (
 	EventInstallationCompleted = "InstallationCompleted"
 	EventPreflightCheckPassed  = "PreflightCheckPassed"
 	EventUpgradeApproved       = "UpgradeApproved"
+	EventMaintenanceModeOn     = "MaintenanceModeOn"
+	EventMaintenanceModeOff    = "MaintenanceModeOff"
+	EventEnvoyConfigRolledOut  = "EnvoyConfigRolledOut"
+	EventEnvoyConfigRejected   = "EnvoyConfigRejected"
+	EventReadOnlyModeOn        = "ReadOnlyModeOn"
+	EventReadOnlyModeOff       = "ReadOnlyModeOff"
+	EventResourcesAdopted      = "ResourcesAdopted"
+	EventPaused                = "Paused"
+	EventResumed               = "Resumed"
+	EventCertificateRotated    = "CertificateRotated"
+	EventStageStarted          = "StageStarted"
+	EventProductFailed         = "ProductFailed"
+	EventInstallationStarted   = "InstallationStarted"
+	EventUpgradeStarted        = "UpgradeStarted"
+	EventUpgradeCompleted      = "UpgradeCompleted"
+	EventCloudResourceCreated  = "CloudResourceCreated"
+	EventCloudResourceReady    = "CloudResourceReady"
+	EventCloudResourceFailed   = "CloudResourceFailed"
+	EventDryRunPlanned         = "DryRunPlanned"
+	EventUpgradeHalted         = "UpgradeHalted"
+	EventUninstallVerified     = "UninstallVerified"
+	EventUninstallIncomplete   = "UninstallIncomplete"
+
+	// PausedAnnotation set to "true" on the installation halts the product
+	// and cloud resource reconciles, status keeps being reported
+	PausedAnnotation = "integreatly.org/paused"
+
+	// DryRunAnnotation set to "true" on the installation replaces the install
+	// stages with a walk of the reconcilers that records the writes they would
+	// make in a plan, without sending them
+	DryRunAnnotation = "integreatly.org/dry-run"
 
 	DefaultOriginPullSecretName      = "pull-secret"
 	DefaultOriginPullSecretNamespace = "openshift-config" // #nosec G101 -- This is a false positive
@@ -101,6 +134,12 @@ var (
 	EnvKeyQuota         = "QUOTA"
 )
 
+// OptionalProducts can be disabled in the spec of the installation
+var OptionalProducts = map[ProductName]bool{
+	ProductGrafana:   true,
+	ProductRHSSOUser: true,
+}
+
 // RHMISpec defines the desired state of RHMI
 type RHMISpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -127,6 +166,15 @@ type RHMISpec struct {
 	// be used only for those operators that support it.
 	OperatorsInProductNamespace bool `json:"operatorsInProductNamespace,omitempty"`
 
+	// NamespaceTopology is the layout of the namespaces of the products.
+	// PerProduct (default) installs every product in its own namespace and
+	// every product operator in its own namespace. Consolidated, installing
+	// the products in a single namespace, isn't supported yet and is
+	// rejected. It can't be changed once the installation is installed
+	// +kubebuilder:validation:Enum=PerProduct;Consolidated
+	// +optional
+	NamespaceTopology NamespaceTopology `json:"namespaceTopology,omitempty"`
+
 	// SMTPSecret is the name of a secret in the installation
 	// namespace containing SMTP connection details. The secret
 	// must contain the following fields:
@@ -152,6 +200,869 @@ type RHMISpec struct {
 	//
 	// url
 	DeadMansSnitchSecret string `json:"deadMansSnitchSecret,omitempty"`
+
+	// DefaultQuota is the quota the installation is sized for
+	// when the addon doesn't set the quota parameter. Defaults
+	// to the QUOTA environment variable of the operator
+	// +optional
+	DefaultQuota string `json:"defaultQuota,omitempty"`
+
+	// MaintenanceMode makes the managed gateways reply to
+	// customer API requests with a 503 and a Retry-After
+	// header until the given time. Admin and developer
+	// portals remain available.
+	MaintenanceMode *MaintenanceModeSpec `json:"maintenanceMode,omitempty"`
+
+	// ReadOnlyMode suspends the product reconciles while status
+	// and alerting keep being reported. Auto, the default, turns
+	// it on while the cluster is upgrading.
+	// +kubebuilder:validation:Enum=Auto;Enabled;Disabled
+	ReadOnlyMode ReadOnlyMode `json:"readOnlyMode,omitempty"`
+
+	// ThreeScaleFileStorage stores the 3scale system assets on
+	// an S3 compatible endpoint, such as ODF/NooBaa or MinIO,
+	// instead of the cloud provider's blob storage
+	ThreeScaleFileStorage *S3CompatibleStorageSpec `json:"threeScaleFileStorage,omitempty"`
+
+	// UserSSOPasswordPolicy is enforced on the user SSO realm,
+	// changes made to these settings in the realm are reverted
+	UserSSOPasswordPolicy *PasswordPolicySpec `json:"userSSOPasswordPolicy,omitempty"`
+
+	// UserSSOIdentityProvider federates the user SSO realm with a
+	// corporate identity provider, e.g. Azure AD or Okta
+	UserSSOIdentityProvider *UserSSOIdentityProviderSpec `json:"userSSOIdentityProvider,omitempty"`
+
+	// UserSSOLDAPFederation imports the users of an LDAP directory
+	// into the user SSO realm, read only
+	UserSSOLDAPFederation *UserSSOLDAPFederationSpec `json:"userSSOLDAPFederation,omitempty"`
+
+	// KeycloakTheme is deployed to the RHSSO and user SSO Keycloak
+	// instances and set as the theme of their realms, it's
+	// redeployed with the instances on upgrades
+	KeycloakTheme *KeycloakThemeSpec `json:"keycloakTheme,omitempty"`
+
+	// JobWatchdog configures the deadlines after which Jobs in
+	// the product namespaces are considered stuck, cleaned up
+	// and retried
+	JobWatchdog *JobWatchdogSpec `json:"jobWatchdog,omitempty"`
+
+	// CloudResourcesKMSKeyARN is the ARN of a customer managed
+	// KMS key used to encrypt the storage of the RDS and
+	// ElastiCache instances provisioned on AWS. The key policy
+	// must allow the cloud resources operator role to use it.
+	// Only applies to instances provisioned after it's set
+	// +kubebuilder:validation:Pattern=`^arn:aws[a-z-]*:kms:`
+	CloudResourcesKMSKeyARN string `json:"cloudResourcesKMSKeyARN,omitempty"`
+
+	// CustomDomainDNS lets the operator manage the DNS records
+	// of the custom domain in the customer's DNS provider
+	CustomDomainDNS *CustomDomainDNSSpec `json:"customDomainDNS,omitempty"`
+
+	// CustomDomain serves the 3scale portals and the SSO
+	// instances on custom hostnames, with their own certificates
+	CustomDomain *CustomDomainSpec `json:"customDomain,omitempty"`
+
+	// SMTPProvider configures the custom SMTP from the account of
+	// an email provider instead of the custom-smtp addon
+	// parameters. The sending domain is verified with the
+	// provider and the SMTP credentials are probed periodically
+	SMTPProvider *SMTPProviderSpec `json:"smtpProvider,omitempty"`
+
+	// SMTPRelay deploys an SMTP relay in the installation
+	// namespace for disconnected clusters without a reachable
+	// mail provider. 3scale and the SSO realms send their emails
+	// through it once it's ready
+	SMTPRelay *SMTPRelaySpec `json:"smtpRelay,omitempty"`
+
+	// Alerting sends the alerts of the installation to the
+	// customer receivers, in addition to the Red Hat SRE ones.
+	// It's compiled into the Alertmanager configuration
+	Alerting *AlertingSpec `json:"alerting,omitempty"`
+
+	// Observability forwards the metrics of the installation to
+	// the customer observability backends, such as Thanos,
+	// Grafana Cloud or Datadog
+	Observability *ObservabilitySpec `json:"observability,omitempty"`
+
+	// LogForwarding ships the logs of the product workloads to
+	// the customer log stores with a ClusterLogForwarder. It
+	// requires the Red Hat OpenShift Logging operator
+	LogForwarding *LogForwardingSpec `json:"logForwarding,omitempty"`
+
+	// ExternalSecrets syncs the secrets of the installation
+	// namespace holding the SMTP, alerting and custom domain
+	// credentials from an external secret manager, such as Vault
+	// or AWS Secrets Manager. It requires the External Secrets
+	// Operator
+	ExternalSecrets *ExternalSecretsSpec `json:"externalSecrets,omitempty"`
+
+	// Tracing deploys an OpenTelemetry collector in the
+	// installation namespace and sends it the spans of APIcast
+	// and of the envoy proxies of APIcast and backend, so the
+	// API latency can be traced through the gateways
+	Tracing *TracingSpec `json:"tracing,omitempty"`
+
+	// NetworkPolicy isolates the namespaces of the products with
+	// default deny NetworkPolicies, and the rules allowing the
+	// traffic the products need at the strictness of the level.
+	// The policies are removed when it's unset
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// SLOs are the availability and latency objectives of the
+	// APIs. The recording rules, the multi-window burn rate
+	// alerts and a Grafana dashboard of each SLO are generated in
+	// the customer monitoring stack
+	// +listType=map
+	// +listMapKey=name
+	SLOs []SLOSpec `json:"slos,omitempty"`
+
+	// Dashboards imports the Grafana dashboards of the customer
+	// into the managed Grafana
+	Dashboards *DashboardsSpec `json:"dashboards,omitempty"`
+
+	// DataSources are added to the managed Grafana next to the
+	// Prometheus of the installation, such as the CloudWatch
+	// metrics of the RDS and ElastiCache instances or the
+	// Prometheus of the customer applications
+	// +listType=map
+	// +listMapKey=name
+	DataSources []DataSourceSpec `json:"dataSources,omitempty"`
+
+	// Metering configures the daily usage reports of the tenants
+	// of a multitenant installation, which are kept in the
+	// rhoam-usage-reports config map of the installation namespace
+	Metering *MeteringSpec `json:"metering,omitempty"`
+
+	// GatewayCORSPolicies are enforced by the managed gateways
+	// on the hosts of the products they apply to. Preflight
+	// requests are answered by the gateway without reaching
+	// APIcast. A host can only be claimed by one policy
+	// +listType=map
+	// +listMapKey=name
+	GatewayCORSPolicies []CORSPolicySpec `json:"gatewayCORSPolicies,omitempty"`
+
+	// GatewayJWTProviders let the managed gateways validate the
+	// JWTs sent to the hosts of the products locally, so APIcast
+	// doesn't have to introspect every token with the issuer.
+	// Requests without a valid token are rejected with a 401.
+	// A host can only be claimed by one provider
+	// +listType=map
+	// +listMapKey=name
+	GatewayJWTProviders []JWTProviderSpec `json:"gatewayJWTProviders,omitempty"`
+
+	// GatewayTrafficSplits shift the traffic of customer APIs
+	// between a stable and a canary backend version. The
+	// managed gateways tag each request with the selected
+	// version so an APIcast routing policy can pick the backend.
+	// A host can only be claimed by one split
+	// +listType=map
+	// +listMapKey=name
+	GatewayTrafficSplits []TrafficSplitSpec `json:"gatewayTrafficSplits,omitempty"`
+
+	// Maintenance is the weekly window the RDS and ElastiCache
+	// engine maintenance is applied in. It overrides the
+	// maintenance-day and maintenance-hour addon parameters
+	Maintenance *MaintenanceWindowSpec `json:"maintenance,omitempty"`
+
+	// Telemetry opts in to reporting anonymised feature usage
+	// to Red Hat. The reported data is published in the
+	// rhoam-telemetry ConfigMap of the installation namespace
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
+
+	// RealmExport periodically exports the realms of the SSO
+	// instances, with their clients, groups and roles but
+	// without users, to an S3 compatible bucket. The realm
+	// configuration can then be recovered without restoring
+	// the SSO databases
+	RealmExport *RealmExportSpec `json:"realmExport,omitempty"`
+
+	// ApplicationPlanTemplates are kept in sync in the 3scale
+	// products of every tenant, so plan changes roll out to
+	// all of them. Only the templates of the installed quota
+	// are applied. Plans that aren't templated are left as is
+	// +listType=map
+	// +listMapKey=name
+	ApplicationPlanTemplates []ApplicationPlanTemplateSpec `json:"applicationPlanTemplates,omitempty"`
+
+	// Products overrides the products of the installation. The
+	// optional products can be disabled, they are then removed
+	// along with their namespaces and reported as skipped
+	// +listType=map
+	// +listMapKey=name
+	Products []ProductOverrideSpec `json:"products,omitempty"`
+
+	// Reconcile tunes how often the installation is reconciled,
+	// validated by the webhook
+	Reconcile *ReconcileSpec `json:"reconcile,omitempty"`
+
+	// ResourceOverrides replace the CPU and memory requests and
+	// limits the quota sets on the product workloads. Unlike the
+	// quota values they're also applied when they're lower than
+	// the current ones
+	// +listType=map
+	// +listMapKey=name
+	ResourceOverrides []ResourceOverrideSpec `json:"resourceOverrides,omitempty"`
+
+	// Placement schedules the workloads of 3scale, the SSO
+	// instances, marin3r and Grafana on the selected nodes, such
+	// as the nodes of a machine pool dedicated to RHOAM
+	Placement *PlacementSpec `json:"placement,omitempty"`
+
+	// PodSecurity runs the workloads of the products with security
+	// contexts compatible with the restricted Pod Security Admission
+	// profile, and enforces the level on the namespaces of the
+	// products
+	PodSecurity *PodSecuritySpec `json:"podSecurity,omitempty"`
+
+	// FIPS runs the products in FIPS mode: the images the operator
+	// deploys are replaced with their FIPS validated variants, the
+	// SSO instances use the FIPS crypto provider of the JDK, and
+	// the TLS of APIcast and envoy is restricted to the FIPS
+	// approved ciphers. The products are only FIPS compliant on
+	// clusters installed in FIPS mode
+	FIPS *FIPSSpec `json:"fips,omitempty"`
+
+	// SecretRotation rotates the credentials the operator generates
+	// on a schedule. A credential is also rotated on demand by
+	// annotating its secret with integreatly.org/rotate
+	SecretRotation *SecretRotationSpec `json:"secretRotation,omitempty"`
+
+	// QuotaTransition moves the workloads to a new quota in steps
+	// instead of all at once, waiting for them to be ready between
+	// the steps
+	QuotaTransition *QuotaTransitionSpec `json:"quotaTransition,omitempty"`
+
+	// ProgressiveUpgrade upgrades the products of an operator
+	// upgrade one at a time, checking the health of each before
+	// moving on to the next
+	ProgressiveUpgrade *ProgressiveUpgradeSpec `json:"progressiveUpgrade,omitempty"`
+
+	// PreUpgradeBackup configures the backups of the products taken
+	// before their operators are upgraded
+	PreUpgradeBackup *PreUpgradeBackupSpec `json:"preUpgradeBackup,omitempty"`
+}
+
+type PreUpgradeBackupSpec struct {
+	// Timeout of the backups of a product, the upgrade of its
+	// operator fails when they don't complete in time. Defaults
+	// to 20m
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+type ProgressiveUpgradeSpec struct {
+	Enabled bool `json:"enabled"`
+	// ProbeTimeout is how long the health probes of an upgraded
+	// product can fail before the upgrade is halted. Defaults to
+	// 15m
+	ProbeTimeout *metav1.Duration `json:"probeTimeout,omitempty"`
+}
+
+type QuotaTransitionSpec struct {
+	// Steps is the number of increments the replicas and the rate
+	// limit take to reach the new quota
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	Steps int32 `json:"steps"`
+}
+
+type FIPSSpec struct {
+	// Images are the FIPS validated variants of the images the
+	// operator deploys, keyed by the image they replace. The
+	// products running images outside of registry.redhat.io
+	// without a variant are reported as not compliant
+	Images map[string]string `json:"images,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=restricted;baseline
+type PodSecurityLevel string
+
+const (
+	PodSecurityRestricted PodSecurityLevel = "restricted"
+	PodSecurityBaseline   PodSecurityLevel = "baseline"
+)
+
+type PodSecuritySpec struct {
+	// Enforce is the Pod Security Admission level enforced on the
+	// namespaces of the products. The violations of the restricted
+	// level are audited and warned whatever the level enforced.
+	// Restricted is only safe to enforce once every workload of the
+	// products runs with the restricted security contexts
+	// +kubebuilder:default=baseline
+	Enforce PodSecurityLevel `json:"enforce,omitempty"`
+}
+
+type PlacementSpec struct {
+	// NodeSelector the nodes of the workloads must match
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of the workloads, to be scheduled on tainted
+	// nodes
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// TopologySpreadConstraints of the workloads. The constraints
+	// without a label selector spread the pods of each workload
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+type ResourceOverrideSpec struct {
+	// Name of the workload, as in the quota configuration
+	// +kubebuilder:validation:Enum=apicast_production;backend_listener;backend_worker;rhssouser;grafana;ratelimit
+	Name string `json:"name"`
+	// Resources of the workload. The CPU and memory requests and
+	// limits that aren't set keep the quota values
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+type ReconcileSpec struct {
+	// Interval between the reconciles of a complete installation.
+	// Defaults to 5m, or 30s for multitenant installations
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// RetryInterval between the reconciles of an installation in
+	// progress. Defaults to 10s
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+	// MaxErrorBackoff caps the exponential backoff of the failed
+	// reconciles. Defaults to 16m40s
+	MaxErrorBackoff *metav1.Duration `json:"maxErrorBackoff,omitempty"`
+	// MaxConcurrentProducts reconciled at once. The products of a
+	// stage are reconciled concurrently once the products they
+	// depend on are complete. Defaults to 4
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8
+	MaxConcurrentProducts *int32 `json:"maxConcurrentProducts,omitempty"`
+	// Products are reconciled less often than the installation
+	// once they're installed. Every product is reconciled while
+	// the installation is upgrading
+	// +listType=map
+	// +listMapKey=name
+	Products []ProductReconcileSpec `json:"products,omitempty"`
+}
+
+type ProductReconcileSpec struct {
+	// Name of the product
+	// +kubebuilder:validation:Enum=rhsso;rhssouser;"3scale";observability;cloud-resources;marin3r;grafana;mcg
+	Name ProductName `json:"name"`
+	// Interval between the reconciles of the product, it can't
+	// be shorter than the interval of the installation
+	Interval metav1.Duration `json:"interval"`
+}
+
+type ProductOverrideSpec struct {
+	// Name of the product
+	// +kubebuilder:validation:Enum=grafana;rhssouser
+	Name ProductName `json:"name"`
+	// Enabled installs the product. Defaults to true
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+type ApplicationPlanTemplateSpec struct {
+	// Name of the template
+	Name string `json:"name"`
+	// Quotas the template applies to, such as "1 Million".
+	// Applies to every quota when empty
+	Quotas []string `json:"quotas,omitempty"`
+	// Product is the system name of the 3scale product the
+	// plan belongs to. Tenants without the product are skipped
+	Product string `json:"product"`
+	// SystemName of the plan, plans are matched on it
+	SystemName string `json:"systemName"`
+	// PlanName is the name of the plan shown in the portals
+	PlanName string `json:"planName"`
+	// +kubebuilder:validation:Minimum=0
+	TrialPeriodDays  int32 `json:"trialPeriodDays,omitempty"`
+	ApprovalRequired bool  `json:"approvalRequired,omitempty"`
+	// Features are the system names of the product features
+	// enabled on the plan, the other features are disabled
+	Features []string `json:"features,omitempty"`
+	// Limits replace the limits of the plan
+	Limits []PlanLimitSpec `json:"limits,omitempty"`
+}
+
+type PlanLimitSpec struct {
+	// Metric is the system name of a metric or method of the
+	// product, such as hits
+	Metric string `json:"metric"`
+	// +kubebuilder:validation:Enum=eternity;year;month;week;day;hour;minute
+	Period string `json:"period"`
+	// +kubebuilder:validation:Minimum=0
+	Value int32 `json:"value"`
+}
+
+type RealmExportSpec struct {
+	// Storage is the bucket the exports are written to, under
+	// realm-exports/<sso instance>/<export id>/
+	Storage S3CompatibleStorageSpec `json:"storage"`
+	// Interval between exports, at least 1h. Defaults to 24h
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Import imports an export into the SSO instances once,
+	// such as the last export of a lost installation. Realms
+	// that don't exist are created, client secrets are
+	// regenerated and the secrets of identity providers have
+	// to be set again
+	Import *RealmImportSpec `json:"import,omitempty"`
+}
+
+type RealmImportSpec struct {
+	// ExportID is the <export id> of the export to import
+	// +kubebuilder:validation:Pattern=`^[0-9]{8}T[0-9]{6}Z$`
+	ExportID string `json:"exportID"`
+	// IfResourceExists decides whether the clients, groups,
+	// roles and identity providers that exist in a realm are
+	// kept or overwritten. Defaults to Skip
+	// +kubebuilder:validation:Enum=Skip;Overwrite
+	IfResourceExists RealmImportPolicy `json:"ifResourceExists,omitempty"`
+}
+
+type RealmImportPolicy string
+
+const (
+	RealmImportSkip      RealmImportPolicy = "Skip"
+	RealmImportOverwrite RealmImportPolicy = "Overwrite"
+)
+
+type MaintenanceWindowSpec struct {
+	// ApplyFrom is the start of the window in UTC, such as
+	// "sun 23:00"
+	// +kubebuilder:validation:Pattern=`^([Mm]on|[Tt]ue|[Ww]ed|[Tt]hu|[Ff]ri|[Ss]at|[Ss]un) ([01][0-9]|2[0-3]):[0-5][0-9]$`
+	ApplyFrom string `json:"applyFrom"`
+	// Duration of the window, between 1h and 24h. Defaults to
+	// 1h. GCP instances always use a 1h window
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+type TelemetrySpec struct {
+	// Enabled reports the feature usage, nothing is reported
+	// by default
+	Enabled bool `json:"enabled"`
+}
+
+type TrafficSplitSpec struct {
+	// Name identifies the split
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Hosts are the gateway hosts of the APIs whose traffic is
+	// split. A leading or trailing * matches any prefix or suffix
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+	// VersionHeader is set to the selected version on every
+	// request, replacing the value sent by the client. Defaults
+	// to X-RHOAM-Backend-Version
+	VersionHeader string `json:"versionHeader,omitempty"`
+	// StableVersion is the version most requests are sent to.
+	// Defaults to stable
+	StableVersion string `json:"stableVersion,omitempty"`
+	// CanaryVersion is the version under release. Defaults to
+	// canary
+	CanaryVersion string `json:"canaryVersion,omitempty"`
+	// CanaryWeight is the percentage of the requests sent to
+	// the canary version
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	CanaryWeight int32 `json:"canaryWeight,omitempty"`
+	// CanaryMatch sends the requests with a header to the
+	// canary version regardless of the weight, so testers can
+	// opt in
+	CanaryMatch *TrafficSplitMatch `json:"canaryMatch,omitempty"`
+}
+
+type TrafficSplitMatch struct {
+	// Header is the name of the request header
+	Header string `json:"header"`
+	// Value the header must be equal to
+	Value string `json:"value"`
+}
+
+type JWTProviderSpec struct {
+	// Name identifies the provider
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Hosts are the gateway hosts of the products whose tokens
+	// are validated. A leading or trailing * matches any prefix
+	// or suffix
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+	// Issuer the tokens must be issued by, such as the URL of
+	// an RHSSO realm
+	// +kubebuilder:validation:Pattern=`^https://`
+	Issuer string `json:"issuer"`
+	// JWKSURI the signing keys are fetched from. Defaults to
+	// the RHSSO certs endpoint of the issuer
+	// +kubebuilder:validation:Pattern=`^https://`
+	JWKSURI string `json:"jwksURI,omitempty"`
+	// Audiences the tokens must be issued for, any audience is
+	// accepted when empty
+	Audiences []string `json:"audiences,omitempty"`
+	// ClockSkewSeconds tolerated when checking the expiry and
+	// not before times of the tokens. Defaults to 60
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=300
+	ClockSkewSeconds *int32 `json:"clockSkewSeconds,omitempty"`
+	// JWKSCacheDurationSeconds the signing keys are cached for
+	// before they're fetched again. Defaults to 600
+	// +kubebuilder:validation:Minimum=30
+	JWKSCacheDurationSeconds int32 `json:"jwksCacheDurationSeconds,omitempty"`
+	// TokenCacheSize is the number of validated tokens cached
+	// by each gateway, caching is disabled when empty
+	// +kubebuilder:validation:Minimum=0
+	TokenCacheSize int32 `json:"tokenCacheSize,omitempty"`
+}
+
+type CORSPolicySpec struct {
+	// Name identifies the policy
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Hosts are the gateway hosts of the products the policy
+	// applies to. A leading or trailing * matches any prefix
+	// or suffix
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+	// AllowOrigins are the origins allowed to call the
+	// products, such as https://app.example.com. A * subdomain
+	// matches any subdomain, and * on its own any origin
+	// +kubebuilder:validation:MinItems=1
+	AllowOrigins []string `json:"allowOrigins"`
+	// AllowMethods returned in Access-Control-Allow-Methods
+	AllowMethods []string `json:"allowMethods,omitempty"`
+	// AllowHeaders returned in Access-Control-Allow-Headers
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+	// ExposeHeaders returned in Access-Control-Expose-Headers
+	ExposeHeaders []string `json:"exposeHeaders,omitempty"`
+	// MaxAgeSeconds browsers can cache the preflight response
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	MaxAgeSeconds int32 `json:"maxAgeSeconds,omitempty"`
+	// AllowCredentials lets browsers send cookies and
+	// authorization headers, it can't be used with any origin
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+}
+
+type DNSProviderType string
+
+const (
+	DNSProviderRoute53 DNSProviderType = "Route53"
+)
+
+type CustomDomainDNSSpec struct {
+	// Provider hosting the custom domain zone
+	// +kubebuilder:validation:Enum=Route53
+	Provider DNSProviderType `json:"provider"`
+	// ZoneID of the hosted zone of the custom domain
+	ZoneID string `json:"zoneID"`
+	// CredentialsSecret is the name of a secret in the
+	// installation namespace with the provider credentials.
+	// For Route53 it must contain accessKeyID and
+	// secretAccessKey
+	CredentialsSecret string `json:"credentialsSecret"`
+	// TXTRecords to publish in the zone, such as the DNS
+	// challenges of the custom domain certificate
+	TXTRecords []DNSTXTRecord `json:"txtRecords,omitempty"`
+}
+
+type SMTPProviderType string
+
+const (
+	SMTPProviderSendGrid SMTPProviderType = "SendGrid"
+	SMTPProviderSES      SMTPProviderType = "SES"
+	SMTPProviderMailgun  SMTPProviderType = "Mailgun"
+)
+
+type SMTPProviderSpec struct {
+	// Provider sending the emails
+	// +kubebuilder:validation:Enum=SendGrid;SES;Mailgun
+	Provider SMTPProviderType `json:"provider"`
+	// CredentialsSecret is the name of a secret in the
+	// installation namespace with the provider credentials.
+	// For SendGrid it must contain apiKey, for Mailgun apiKey
+	// and smtpPassword, and for SES the accessKeyID and
+	// secretAccessKey of an IAM user allowed to send emails,
+	// the SMTP password is derived from them
+	CredentialsSecret string `json:"credentialsSecret"`
+	// Domain the emails are sent from, it must be verified
+	// with the provider before the custom SMTP is enabled
+	// +kubebuilder:validation:Pattern=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z]{2,}$`
+	Domain string `json:"domain"`
+	// FromAddress of the emails, defaults to noreply at the
+	// domain
+	FromAddress string `json:"fromAddress,omitempty"`
+	// Region of the SES endpoint, or eu to use the EU region
+	// of Mailgun
+	Region string `json:"region,omitempty"`
+	// ProbeRecipient receives a test email on every probe.
+	// Without it the probe stops after authenticating
+	ProbeRecipient string `json:"probeRecipient,omitempty"`
+}
+
+type SMTPRelaySpec struct {
+	// Image of the relay, usually mirrored in the disconnected
+	// registry. It must run a Postfix relay listening on port
+	// 587 configured from the RELAYHOST and
+	// ALLOWED_SENDER_DOMAINS environment variables
+	Image string `json:"image"`
+	// FromAddress of the emails sent through the relay
+	FromAddress string `json:"fromAddress"`
+	// SmartHost is the host:port of a mail server reachable from
+	// the cluster the relay forwards the emails to. Without it
+	// the relay delivers the emails to the MX of the recipients
+	SmartHost string `json:"smartHost,omitempty"`
+	// Resources of the relay container
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+type CustomRouteName string
+
+const (
+	CustomRouteThreeScaleMaster    CustomRouteName = "3scale-master"
+	CustomRouteThreeScaleAdmin     CustomRouteName = "3scale-admin"
+	CustomRouteThreeScaleDeveloper CustomRouteName = "3scale-developer"
+	CustomRouteRHSSO               CustomRouteName = "rhsso"
+	CustomRouteRHSSOUser           CustomRouteName = "rhssouser"
+)
+
+type CustomDomainSpec struct {
+	// Routes served on custom hostnames. Each one is served by
+	// a route next to the product route once its hostname
+	// resolves to the router of the product route
+	// +listType=map
+	// +listMapKey=route
+	Routes []CustomRouteSpec `json:"routes"`
+}
+
+type CustomRouteSpec struct {
+	// Route is the product route served on the hostname
+	// +kubebuilder:validation:Enum="3scale-master";"3scale-admin";"3scale-developer";rhsso;rhssouser
+	Route CustomRouteName `json:"route"`
+	// Hostname the route is served on
+	// +kubebuilder:validation:Pattern=`^([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z]{2,}$`
+	Hostname string `json:"hostname"`
+	// TLSSecret is the name of a kubernetes.io/tls secret in
+	// the installation namespace with a certificate valid for
+	// the hostname. A certificate failing the validation isn't
+	// served, the route keeps serving the previous one
+	TLSSecret string `json:"tlsSecret"`
+}
+
+type DNSTXTRecord struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type JobWatchdogSpec struct {
+	// PendingTimeoutSeconds is how long the pods of a Job can
+	// be Pending before it is considered stuck. Defaults to 900
+	// +kubebuilder:validation:Minimum=60
+	PendingTimeoutSeconds int32 `json:"pendingTimeoutSeconds,omitempty"`
+	// ActiveTimeoutSeconds is how long a Job can run before it
+	// is considered stuck. Defaults to 3600
+	// +kubebuilder:validation:Minimum=60
+	ActiveTimeoutSeconds int32 `json:"activeTimeoutSeconds,omitempty"`
+	// MaxRetries is how many times a stuck Job is retried
+	// before it's reported in the JobsStuck condition.
+	// Defaults to 3
+	// +kubebuilder:validation:Minimum=0
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+}
+
+type PasswordPolicySpec struct {
+	// MinLength is the minimum password length
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=128
+	MinLength int32 `json:"minLength,omitempty"`
+	// History prevents reusing the given number of previous
+	// passwords
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=24
+	History int32 `json:"history,omitempty"`
+	// BruteForceDetection locks users out after repeated
+	// login failures
+	BruteForceDetection *BruteForceDetectionSpec `json:"bruteForceDetection,omitempty"`
+	// RequireOTP requires users to log in with a one time
+	// password
+	RequireOTP bool `json:"requireOTP,omitempty"`
+}
+
+type UserSSOIdentityProviderType string
+
+const (
+	UserSSOIdentityProviderOIDC UserSSOIdentityProviderType = "oidc"
+	UserSSOIdentityProviderSAML UserSSOIdentityProviderType = "saml"
+)
+
+type UserSSOIdentityProviderSpec struct {
+	// Alias of the identity provider in the realm, part of its
+	// redirect URI. Defaults to corporate
+	Alias string `json:"alias,omitempty"`
+	// DisplayName of the identity provider on the login page
+	DisplayName string `json:"displayName,omitempty"`
+	// +kubebuilder:validation:Enum=oidc;saml
+	Type UserSSOIdentityProviderType `json:"type"`
+	// IssuerURL of an OpenID Connect provider, its endpoints are
+	// discovered from it
+	IssuerURL string `json:"issuerURL,omitempty"`
+	// ClientID of the realm in the OpenID Connect provider
+	ClientID string `json:"clientID,omitempty"`
+	// MetadataURL of a SAML provider, its single sign-on service
+	// and signing certificate are read from it
+	MetadataURL string `json:"metadataURL,omitempty"`
+	// ConfigSecret is a secret in the namespace of the installation
+	// whose entries are added to the identity provider config, e.g.
+	// the clientSecret of an OpenID Connect provider. They take
+	// precedence over the config from the other fields
+	ConfigSecret string `json:"configSecret,omitempty"`
+	// TrustEmail skips the verification of the emails of the users
+	// from the identity provider
+	TrustEmail bool `json:"trustEmail,omitempty"`
+	// Mappers import the claims or attributes of the identity
+	// provider into the users
+	Mappers []IdentityProviderMapperSpec `json:"mappers,omitempty"`
+}
+
+type IdentityProviderMapperSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Type of the mapper, e.g. oidc-user-attribute-idp-mapper or
+	// saml-user-attribute-idp-mapper
+	// +kubebuilder:validation:MinLength=1
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+type LDAPVendor string
+
+const (
+	LDAPVendorOther           LDAPVendor = "other"
+	LDAPVendorActiveDirectory LDAPVendor = "ad"
+	LDAPVendorRHDS            LDAPVendor = "rhds"
+)
+
+type UserSSOLDAPFederationSpec struct {
+	// ConnectionURL of the LDAP server, e.g. ldaps://ldap.example.com
+	// +kubebuilder:validation:Pattern=`^ldaps?://`
+	ConnectionURL string `json:"connectionURL"`
+	// BindCredentialsSecret is a secret in the namespace of the
+	// installation holding the bindDn and bindCredential of the
+	// user the LDAP server is queried as
+	BindCredentialsSecret string `json:"bindCredentialsSecret"`
+	// UsersDN is the DN of the subtree holding the users
+	UsersDN string `json:"usersDN"`
+	// Vendor of the LDAP server, it sets the defaults of the
+	// attributes and object classes of the users. Defaults to other
+	// +kubebuilder:validation:Enum=other;ad;rhds
+	Vendor LDAPVendor `json:"vendor,omitempty"`
+	// UsernameAttribute is the attribute mapped to the username.
+	// Defaults to uid, or sAMAccountName for Active Directory
+	UsernameAttribute string `json:"usernameAttribute,omitempty"`
+	// UUIDAttribute is the attribute uniquely identifying the users.
+	// Defaults to entryUUID, nsuniqueid for RHDS or objectGUID for
+	// Active Directory
+	UUIDAttribute string `json:"uuidAttribute,omitempty"`
+	// UserObjectClasses of the users. Defaults to inetOrgPerson and
+	// organizationalPerson, or person, organizationalPerson and user
+	// for Active Directory
+	UserObjectClasses []string `json:"userObjectClasses,omitempty"`
+	// UserFilter is an additional LDAP filter of the users, e.g.
+	// (memberOf=cn=rhoam,ou=groups,dc=example,dc=com)
+	UserFilter string `json:"userFilter,omitempty"`
+	// Mappers import the attributes and groups of the users, e.g.
+	// with the user-attribute-ldap-mapper and group-ldap-mapper
+	// types. A mapper named like a default mapper of the realm
+	// replaces it
+	Mappers []LDAPMapperSpec `json:"mappers,omitempty"`
+	// SyncSchedule of the periodic synchronizations of the users
+	SyncSchedule *LDAPSyncScheduleSpec `json:"syncSchedule,omitempty"`
+}
+
+type LDAPMapperSpec struct {
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Type of the mapper, e.g. user-attribute-ldap-mapper or
+	// group-ldap-mapper
+	// +kubebuilder:validation:MinLength=1
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+type LDAPSyncScheduleSpec struct {
+	// FullSyncPeriod between the synchronizations of all the users,
+	// at least 1m. Defaults to 24h
+	FullSyncPeriod *metav1.Duration `json:"fullSyncPeriod,omitempty"`
+	// ChangedUsersSyncPeriod between the synchronizations of the
+	// users changed since the last one, at least 1m. Defaults to 1h
+	ChangedUsersSyncPeriod *metav1.Duration `json:"changedUsersSyncPeriod,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=login;email
+type KeycloakThemeType string
+
+const (
+	KeycloakThemeLogin KeycloakThemeType = "login"
+	KeycloakThemeEmail KeycloakThemeType = "email"
+)
+
+type KeycloakThemeSpec struct {
+	// Name of the theme, it can't be the name of a theme of Keycloak
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// ConfigMap in the namespace of the installation holding the
+	// files of the theme. Its keys are the paths of the files in the
+	// theme with the / written as __, e.g. login__theme.properties,
+	// login__resources__css__login.css or email__html__executeActions.ftl
+	ConfigMap string `json:"configMap,omitempty"`
+	// ArchiveURL of a JAR packaging the theme, deployed as an
+	// extension of the Keycloak instances
+	// +kubebuilder:validation:Pattern=`^https://`
+	ArchiveURL string `json:"archiveURL,omitempty"`
+	// Types of the theme set in the realms, the theme of the login
+	// pages and the templates of the emails. Defaults to both
+	Types []KeycloakThemeType `json:"types,omitempty"`
+}
+
+type BruteForceDetectionSpec struct {
+	// MaxLoginFailures before a user is locked out
+	// +kubebuilder:validation:Minimum=1
+	MaxLoginFailures int32 `json:"maxLoginFailures"`
+	// WaitIncrementSeconds is added to the lockout time each
+	// time MaxLoginFailures is reached. Defaults to 60
+	// +kubebuilder:validation:Minimum=1
+	WaitIncrementSeconds int32 `json:"waitIncrementSeconds,omitempty"`
+	// PermanentLockout disables locked out users until an
+	// admin enables them again
+	PermanentLockout bool `json:"permanentLockout,omitempty"`
+}
+
+type S3CompatibleStorageSpec struct {
+	// CredentialsSecret is the name of a secret in the
+	// installation namespace containing the following fields:
+	//
+	// accessKeyID
+	// secretAccessKey
+	// bucketName
+	// bucketRegion (optional)
+	// ca.crt (optional, CA bundle the endpoint's certificate is signed by)
+	CredentialsSecret string `json:"credentialsSecret"`
+	// Endpoint is the URL of the S3 API, e.g. https://s3.openshift-storage.svc
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+	// PathStyle addresses buckets as <endpoint>/<bucket>
+	// instead of <bucket>.<endpoint>
+	PathStyle bool `json:"pathStyle,omitempty"`
+}
+
+type ReadOnlyMode string
+
+const (
+	ReadOnlyModeAuto     ReadOnlyMode = "Auto"
+	ReadOnlyModeEnabled  ReadOnlyMode = "Enabled"
+	ReadOnlyModeDisabled ReadOnlyMode = "Disabled"
+)
+
+type MaintenanceModeSpec struct {
+	Enabled bool `json:"enabled"`
+	// Until is the time at which maintenance mode ends and
+	// the gateways serve customer APIs again
+	Until metav1.Time `json:"until"`
+	// RetryAfterSeconds is returned in the Retry-After header.
+	// Defaults to the time at which maintenance mode ends
+	// +kubebuilder:validation:Minimum=1
+	RetryAfterSeconds int64 `json:"retryAfterSeconds,omitempty"`
+	// Message is the body of the 503 response
+	Message string `json:"message,omitempty"`
 }
 
 type PullSecretSpec struct {
@@ -159,6 +1070,369 @@ type PullSecretSpec struct {
 	Namespace string `json:"namespace"`
 }
 
+type AlertingSpec struct {
+	// Receivers the alerts are sent to
+	// +listType=map
+	// +listMapKey=name
+	Receivers []AlertReceiverSpec `json:"receivers,omitempty"`
+	// Routes of the alerts to the receivers by severity. The
+	// alerts keep being routed to the Red Hat SRE receivers
+	Routes []AlertRouteSpec `json:"routes,omitempty"`
+	// Heartbeat receives the always firing DeadMansSwitch alert,
+	// so the customer is paged when the monitoring stack goes
+	// silent
+	Heartbeat *HeartbeatSpec `json:"heartbeat,omitempty"`
+}
+
+type HeartbeatSpec struct {
+	// URLSecret is the key of a secret in the installation
+	// namespace holding the URL pinged by each heartbeat, such
+	// as a healthchecks.io check or a PagerDuty heartbeat
+	URLSecret corev1.SecretKeySelector `json:"urlSecret"`
+	// Interval between the heartbeats, it must be shorter than
+	// the period of the heartbeat check
+	// +kubebuilder:validation:Pattern=`^[0-9]+[smh]$`
+	// +kubebuilder:default="5m"
+	Interval string `json:"interval,omitempty"`
+}
+
+type AlertReceiverSpec struct {
+	// Name of the receiver, referenced by the routes
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// PagerDuty sends the alerts to a PagerDuty Events API v2
+	// integration
+	PagerDuty *PagerDutyReceiverSpec `json:"pagerDuty,omitempty"`
+	// Slack posts the alerts to a Slack incoming webhook
+	Slack *SlackReceiverSpec `json:"slack,omitempty"`
+	// OpsGenie creates OpsGenie alerts
+	OpsGenie *OpsGenieReceiverSpec `json:"opsGenie,omitempty"`
+	// Webhook posts the alerts to an HTTP endpoint
+	Webhook *WebhookReceiverSpec `json:"webhook,omitempty"`
+	// Email sends the alerts with the SMTP settings of the
+	// installation
+	Email *EmailReceiverSpec `json:"email,omitempty"`
+}
+
+type PagerDutyReceiverSpec struct {
+	// RoutingKeySecret is the key of a secret in the
+	// installation namespace holding the integration key
+	RoutingKeySecret corev1.SecretKeySelector `json:"routingKeySecret"`
+}
+
+type SlackReceiverSpec struct {
+	// WebhookURLSecret is the key of a secret in the
+	// installation namespace holding the webhook URL
+	WebhookURLSecret corev1.SecretKeySelector `json:"webhookURLSecret"`
+	// Channel overrides the channel of the webhook
+	Channel string `json:"channel,omitempty"`
+}
+
+type OpsGenieReceiverSpec struct {
+	// APIKeySecret is the key of a secret in the installation
+	// namespace holding the API key
+	APIKeySecret corev1.SecretKeySelector `json:"apiKeySecret"`
+	// APIURL of the OpsGenie region, defaults to the US one
+	// +kubebuilder:validation:Pattern=`^https://`
+	APIURL string `json:"apiURL,omitempty"`
+}
+
+type WebhookReceiverSpec struct {
+	// URLSecret is the key of a secret in the installation
+	// namespace holding the URL, as it usually embeds a token
+	URLSecret corev1.SecretKeySelector `json:"urlSecret"`
+}
+
+type EmailReceiverSpec struct {
+	// To are the recipient addresses
+	// +kubebuilder:validation:MinItems=1
+	To []string `json:"to"`
+}
+
+type AlertRouteSpec struct {
+	// Severity of the routed alerts
+	// +kubebuilder:validation:Enum=critical;warning;info
+	Severity string `json:"severity"`
+	// Receiver the alerts are sent to
+	Receiver string `json:"receiver"`
+}
+
+type ObservabilitySpec struct {
+	// RemoteWrite endpoints the Prometheus of the monitoring
+	// stack sends the samples to
+	// +listType=map
+	// +listMapKey=name
+	RemoteWrite []RemoteWriteSpec `json:"remoteWrite,omitempty"`
+}
+
+type RemoteWriteSpec struct {
+	// Name of the endpoint, it's the remote_name label of its
+	// health metrics
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// URL of the remote write endpoint
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+	// BasicAuth authenticates with the username and password
+	// held by secrets in the installation namespace
+	BasicAuth *RemoteWriteBasicAuth `json:"basicAuth,omitempty"`
+	// BearerTokenSecret is the key of a secret in the
+	// installation namespace holding the bearer token
+	BearerTokenSecret *corev1.SecretKeySelector `json:"bearerTokenSecret,omitempty"`
+	// MetricAllowlist are regular expressions of the names of
+	// the metrics sent to the endpoint. Every metric is sent
+	// when empty
+	MetricAllowlist []string `json:"metricAllowlist,omitempty"`
+	// WriteRelabelConfigs are applied to the samples before
+	// they're sent, after the allowlist
+	WriteRelabelConfigs []RemoteWriteRelabelConfig `json:"writeRelabelConfigs,omitempty"`
+}
+
+type RemoteWriteBasicAuth struct {
+	UsernameSecret corev1.SecretKeySelector `json:"usernameSecret"`
+	PasswordSecret corev1.SecretKeySelector `json:"passwordSecret"`
+}
+
+type RemoteWriteRelabelConfig struct {
+	SourceLabels []string `json:"sourceLabels,omitempty"`
+	Separator    string   `json:"separator,omitempty"`
+	TargetLabel  string   `json:"targetLabel,omitempty"`
+	Regex        string   `json:"regex,omitempty"`
+	Replacement  string   `json:"replacement,omitempty"`
+	// +kubebuilder:validation:Enum=replace;keep;drop;labelmap;labeldrop;labelkeep
+	Action string `json:"action,omitempty"`
+}
+
+type ExternalSecretStoreKind string
+
+const (
+	ExternalSecretStore        ExternalSecretStoreKind = "SecretStore"
+	ExternalClusterSecretStore ExternalSecretStoreKind = "ClusterSecretStore"
+)
+
+type ExternalSecretsSpec struct {
+	// SecretStore of the External Secrets Operator connecting to
+	// the external secret manager
+	SecretStore ExternalSecretStoreRef `json:"secretStore"`
+	// RefreshInterval between the syncs of the external values,
+	// the products using a secret are reconciled once it
+	// changes. Defaults to 1h
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+	// Secrets synced from the external secret manager. They
+	// must be secrets referenced by the spec
+	// +listType=map
+	// +listMapKey=name
+	Secrets []ExternalSecretSpec `json:"secrets"`
+}
+
+type ExternalSecretStoreRef struct {
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
+	// +kubebuilder:default=SecretStore
+	Kind ExternalSecretStoreKind `json:"kind,omitempty"`
+}
+
+type ExternalSecretSpec struct {
+	// Name of the secret in the installation namespace, such as
+	// the smtpSecret or the tlsSecret of a custom route
+	Name string `json:"name"`
+	// Key of the secret in the external secret manager, such as
+	// its path in Vault or its name in AWS Secrets Manager
+	Key string `json:"key"`
+	// Properties maps the keys of the secret to the properties of
+	// the external secret. Every property is synced under its own
+	// name when it's empty
+	Properties map[string]string `json:"properties,omitempty"`
+	// Type of the secret, kubernetes.io/tls for the certificates
+	// of the custom routes. Defaults to Opaque
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+type LogForwardingSpec struct {
+	// Outputs the logs are sent to
+	// +listType=map
+	// +listMapKey=name
+	Outputs []LogOutputSpec `json:"outputs"`
+	// Sources are the product logs forwarded and the outputs
+	// they're sent to
+	// +listType=map
+	// +listMapKey=source
+	Sources []LogSourceSpec `json:"sources"`
+}
+
+// +kubebuilder:validation:Enum=CloudWatch;Loki;Splunk
+type LogOutputType string
+
+const (
+	LogOutputCloudWatch LogOutputType = "CloudWatch"
+	LogOutputLoki       LogOutputType = "Loki"
+	LogOutputSplunk     LogOutputType = "Splunk"
+)
+
+type LogOutputSpec struct {
+	// Name of the output, referenced by the sources
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string        `json:"name"`
+	Type LogOutputType `json:"type"`
+	// URL of the Loki or Splunk HEC endpoint
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url,omitempty"`
+	// Secret in the installation namespace holding the
+	// credentials of the output in the format of the
+	// ClusterLogForwarder, such as aws_access_key_id and
+	// aws_secret_access_key for CloudWatch or hecToken for Splunk
+	Secret string `json:"secret,omitempty"`
+	// Region of the CloudWatch log groups
+	Region string `json:"region,omitempty"`
+	// GroupPrefix of the CloudWatch log groups, the log groups
+	// are named after it and the namespace of the logs
+	GroupPrefix string `json:"groupPrefix,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=APIcastAccess;KeycloakAudit;RateLimitDecisions
+type LogSource string
+
+const (
+	LogSourceAPIcastAccess      LogSource = "APIcastAccess"
+	LogSourceKeycloakAudit      LogSource = "KeycloakAudit"
+	LogSourceRateLimitDecisions LogSource = "RateLimitDecisions"
+)
+
+type LogSourceSpec struct {
+	Source LogSource `json:"source"`
+	// Outputs the logs of the source are sent to
+	// +kubebuilder:validation:MinItems=1
+	Outputs []string `json:"outputs"`
+}
+
+type TracingSpec struct {
+	// Endpoint is the OTLP gRPC host:port of the customer tracing
+	// backend the collector exports the spans to
+	Endpoint string `json:"endpoint"`
+	// Insecure exports the spans without TLS
+	Insecure bool `json:"insecure,omitempty"`
+	// SamplingPercentage of the requests traced by the gateways
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=10
+	SamplingPercentage *int32 `json:"samplingPercentage,omitempty"`
+	// CollectorImage overrides the image of the collector
+	CollectorImage string `json:"collectorImage,omitempty"`
+	// Resources of the collector container
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ReconcileSpans exports a span for each reconcile of the
+	// products by the operator
+	ReconcileSpans bool `json:"reconcileSpans,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Permissive;Strict
+type NetworkPolicyLevel string
+
+const (
+	// NetworkPolicyPermissive allows the traffic between every
+	// namespace of the installation
+	NetworkPolicyPermissive NetworkPolicyLevel = "Permissive"
+	// NetworkPolicyStrict only allows the traffic between the products
+	// depending on each other: 3scale and marin3r, the products and
+	// observability, and the ingress controller to the products with
+	// routes
+	NetworkPolicyStrict NetworkPolicyLevel = "Strict"
+)
+
+type NetworkPolicySpec struct {
+	// Level of strictness of the rules allowing the traffic
+	// between the namespaces
+	// +kubebuilder:default=Strict
+	Level NetworkPolicyLevel `json:"level,omitempty"`
+	// AllowedNamespaces are allowed to reach every namespace of the
+	// products, such as the namespace of a customer monitoring stack
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Availability;Latency
+type SLIType string
+
+const (
+	// SLIAvailability counts the requests answered with a 5xx code
+	// by the routes of the product as errors
+	SLIAvailability SLIType = "Availability"
+	// SLILatency counts the requests served slower than the
+	// latency threshold as errors
+	SLILatency SLIType = "Latency"
+)
+
+type SLOSpec struct {
+	// Name of the SLO, the rules and the dashboard are named
+	// after it
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// Product serving the requests of the SLO
+	// +kubebuilder:validation:Enum={"3scale","rhsso","rhssouser"}
+	Product ProductName `json:"product"`
+	// Indicator of the SLO
+	Indicator SLIType `json:"indicator"`
+	// Objective is the percentage of good requests over the
+	// window, such as 99.9
+	// +kubebuilder:validation:Pattern=`^[0-9]{1,2}(\.[0-9]+)?$`
+	Objective string `json:"objective"`
+	// Window the objective is measured over, in days
+	// +kubebuilder:validation:Pattern=`^[0-9]+d$`
+	// +kubebuilder:default="28d"
+	Window string `json:"window,omitempty"`
+	// LatencyThreshold the requests must be served within, required
+	// by the Latency indicator. It must be a bucket of the request
+	// duration histogram of the product
+	LatencyThreshold *metav1.Duration `json:"latencyThreshold,omitempty"`
+	// Route restricts the Availability indicator to the routes
+	// matching this regular expression, such as the routes of a
+	// tenant. Defaults to all the API routes of the product
+	Route string `json:"route,omitempty"`
+}
+
+type DashboardsSpec struct {
+	// Namespaces the GrafanaDashboards and the ConfigMaps labeled
+	// integreatly.org/grafana-dashboard=true are imported from.
+	// Each key of the ConfigMaps ending with .json is a dashboard
+	// +kubebuilder:validation:MinItems=1
+	Namespaces []string `json:"namespaces"`
+}
+
+// +kubebuilder:validation:Enum=CloudWatch;Prometheus
+type DataSourceType string
+
+const (
+	DataSourceCloudWatch DataSourceType = "CloudWatch"
+	DataSourcePrometheus DataSourceType = "Prometheus"
+)
+
+type DataSourceSpec struct {
+	// Name of the data source in Grafana, the dashboards select
+	// their data source by name
+	// +kubebuilder:validation:MinLength=1
+	Name string         `json:"name"`
+	Type DataSourceType `json:"type"`
+	// URL of the Prometheus API
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url,omitempty"`
+	// Region of the CloudWatch metrics
+	Region string `json:"region,omitempty"`
+	// AssumeRoleARN is the role assumed to read the CloudWatch
+	// metrics
+	AssumeRoleARN string `json:"assumeRoleARN,omitempty"`
+	// CredentialsSecret in the installation namespace. For
+	// CloudWatch it holds aws_access_key_id and
+	// aws_secret_access_key, for Prometheus either a username and
+	// a password or a token
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+type MeteringSpec struct {
+	// Export also writes the usage reports to the S3 compatible
+	// bucket, under usage-reports/<date>.json
+	// +optional
+	Export *S3CompatibleStorageSpec `json:"export,omitempty"`
+}
+
 type AlertingEmailAddresses struct {
 	BusinessUnit string `json:"businessUnit"`
 	CSSRE        string `json:"cssre"`
@@ -167,11 +1441,49 @@ type AlertingEmailAddresses struct {
 type CustomSmtpStatus struct {
 	Enabled bool   `json:"enabled"`
 	Error   string `json:"error,omitempty"`
+	// Provider of the custom SMTP when set from the SMTP
+	// provider spec
+	Provider SMTPProviderType `json:"provider,omitempty"`
+	// DomainVerified is whether the provider verified the
+	// sending domain
+	DomainVerified bool `json:"domainVerified,omitempty"`
+	// Probe is the result of the last send test
+	Probe *SMTPProbeStatus `json:"probe,omitempty"`
+}
+
+type SMTPRelayStatus struct {
+	Ready bool `json:"ready"`
+	// Host of the relay service
+	Host    string `json:"host,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type SMTPProbeStatus struct {
+	Time    metav1.Time `json:"time"`
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
 }
 
 type CustomDomainStatus struct {
-	Enabled bool   `json:"enabled"`
-	Error   string `json:"error,omitempty"`
+	Enabled bool              `json:"enabled"`
+	Error   string            `json:"error,omitempty"`
+	Records []DNSRecordStatus `json:"records,omitempty"`
+}
+
+type DNSRecordState string
+
+const (
+	DNSRecordReady   DNSRecordState = "Ready"
+	DNSRecordPending DNSRecordState = "Pending"
+	DNSRecordFailed  DNSRecordState = "Failed"
+)
+
+type DNSRecordStatus struct {
+	Name    string         `json:"name"`
+	Type    string         `json:"type"`
+	Value   string         `json:"value"`
+	State   DNSRecordState `json:"state"`
+	Message string         `json:"message,omitempty"`
 }
 
 // RHMIStatus defines the observed state of RHMI
@@ -190,7 +1502,216 @@ type RHMIStatus struct {
 	Quota              string                        `json:"quota,omitempty"`
 	ToQuota            string                        `json:"toQuota,omitempty"`
 	CustomSmtp         *CustomSmtpStatus             `json:"customSmtp,omitempty"`
+	SMTPRelay          *SMTPRelayStatus              `json:"smtpRelay,omitempty"`
 	CustomDomain       *CustomDomainStatus           `json:"customDomain,omitempty"`
+	Conditions         []metav1.Condition            `json:"conditions,omitempty"`
+
+	// ResourceOverrides are the workloads the resource overrides
+	// were applied to, so their quota values are restored once
+	// the override is removed
+	ResourceOverrides []string `json:"resourceOverrides,omitempty"`
+
+	// QuotaTransition is the progress of the step-wise move to the
+	// new quota
+	QuotaTransition *QuotaTransitionStatus `json:"quotaTransition,omitempty"`
+
+	// Upgrade is the progress of the progressive upgrade of the
+	// products
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// CustomRoutes is the state of the routes of the custom
+	// domain spec
+	CustomRoutes []CustomRouteStatus `json:"customRoutes,omitempty"`
+
+	// Certificates are the customer provided certificates of the
+	// custom domain routes, used to detect their rotation
+	// +listType=map
+	// +listMapKey=secret
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// ExternalSecrets is the state of the secrets synced from the
+	// external secret manager
+	ExternalSecrets []ExternalSecretStatus `json:"externalSecrets,omitempty"`
+
+	// ImportedDashboards is the state of the customer dashboards
+	// of the dashboards spec
+	ImportedDashboards []ImportedDashboardStatus `json:"importedDashboards,omitempty"`
+
+	// EndpointHealth is the result of the synthetic probes of the
+	// customer facing endpoints
+	EndpointHealth []EndpointHealthStatus `json:"endpointHealth,omitempty"`
+
+	// RouteVerification is the last verification of the DNS
+	// records and certificates of the 3scale routes
+	RouteVerification *RouteVerificationStatus `json:"routeVerification,omitempty"`
+
+	// UninstallReport is the verification of the uninstall, the
+	// resources of the installation left behind by the products
+	UninstallReport *UninstallReport `json:"uninstallReport,omitempty"`
+}
+
+type ExternalSecretStatus struct {
+	Name   string `json:"name"`
+	Synced bool   `json:"synced"`
+	// LastSynced is the last refresh of the secret
+	LastSynced *metav1.Time `json:"lastSynced,omitempty"`
+	// Message is why the secret isn't synced
+	Message string `json:"message,omitempty"`
+}
+
+type UninstallReport struct {
+	StartedAt metav1.Time `json:"startedAt"`
+	// VerifiedAt is set once no resources are left to remove, or
+	// the verification timed out
+	VerifiedAt *metav1.Time `json:"verifiedAt,omitempty"`
+	// Removed are the resources left behind that were deleted
+	Removed []OrphanedResource `json:"removed,omitempty"`
+	// Remaining are the resources left behind that couldn't be
+	// deleted, or are deleted by their own operator
+	Remaining []OrphanedResource `json:"remaining,omitempty"`
+}
+
+type OrphanedResource struct {
+	// Kind of the resource, the kubernetes kind or the AWS
+	// resource type
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Location is cluster or aws
+	Location string `json:"location"`
+	// Message is why the resource remains
+	Message string `json:"message,omitempty"`
+}
+
+type EndpointHealthStatus struct {
+	// Name of the endpoint, such as 3scale-admin or apicast-production
+	Name string `json:"name"`
+	// URL probed from inside the cluster
+	URL string `json:"url"`
+	// Up is whether the last probe of the endpoint succeeded
+	Up bool `json:"up"`
+	// Latency is how long the last probe of the endpoint took
+	Latency *metav1.Duration `json:"latency,omitempty"`
+	// Availability is the percentage of successful probes of the
+	// endpoint over the last hour
+	Availability string `json:"availability,omitempty"`
+}
+
+type RouteVerificationStatus struct {
+	// LastVerified is when the routes were last verified
+	LastVerified metav1.Time `json:"lastVerified"`
+	// Problems found resolving or connecting to the routes
+	Problems []string `json:"problems,omitempty"`
+}
+
+type ImportedDashboardStatus struct {
+	Namespace string `json:"namespace"`
+	// Name of the GrafanaDashboard, or of the ConfigMap and its key
+	Name string `json:"name"`
+	// Kind of the source of the dashboard, GrafanaDashboard or
+	// ConfigMap
+	Kind     string `json:"kind"`
+	Imported bool   `json:"imported"`
+	// Message is why the dashboard couldn't be imported
+	Message string `json:"message,omitempty"`
+}
+
+type CertificateStatus struct {
+	// Secret is the name of the TLS secret
+	Secret string `json:"secret"`
+	// Fingerprint is the SHA-256 fingerprint of the certificate
+	Fingerprint string `json:"fingerprint"`
+	// NotAfter is when the certificate expires
+	NotAfter metav1.Time `json:"notAfter"`
+	// RotatedAt is when the operator detected the last rotation
+	// of the certificate
+	RotatedAt *metav1.Time `json:"rotatedAt,omitempty"`
+}
+
+type CustomRouteState string
+
+const (
+	// CustomRoutePending is waiting for the hostname to resolve to
+	// the router
+	CustomRoutePending CustomRouteState = "Pending"
+	// CustomRouteReady is serving the hostname with the certificate
+	// of the spec
+	CustomRouteReady CustomRouteState = "Ready"
+	// CustomRouteRolledBack is serving the hostname with the
+	// previous certificate, as the new one failed the validation
+	CustomRouteRolledBack CustomRouteState = "RolledBack"
+	// CustomRouteFailed can't serve the hostname
+	CustomRouteFailed CustomRouteState = "Failed"
+)
+
+type CustomRouteStatus struct {
+	Route    CustomRouteName  `json:"route"`
+	Hostname string           `json:"hostname"`
+	State    CustomRouteState `json:"state"`
+	Message  string           `json:"message,omitempty"`
+}
+
+type QuotaTransitionStatus struct {
+	// From is the quota the transition started from
+	From string `json:"from"`
+	// To is the quota the transition moves to
+	To string `json:"to"`
+	// Step is the step currently applied to the workloads
+	Step int32 `json:"step"`
+	// Steps is the number of steps of the transition
+	Steps int32 `json:"steps"`
+}
+
+type UpgradePhase string
+
+const (
+	// UpgradeInProgress is upgrading the products one at a time
+	UpgradeInProgress UpgradePhase = "InProgress"
+	// UpgradeHalted stopped after the health probes of a product
+	// failed, the products left keep their previous version
+	UpgradeHalted UpgradePhase = "Halted"
+	// UpgradeCompleted upgraded every product
+	UpgradeCompleted UpgradePhase = "Completed"
+)
+
+type ProductUpgradePhase string
+
+const (
+	// ProductUpgradePending waits for the products before it to be
+	// upgraded, it keeps its previous version
+	ProductUpgradePending ProductUpgradePhase = "Pending"
+	// ProductUpgradeUpgrading is being reconciled to its new version
+	ProductUpgradeUpgrading ProductUpgradePhase = "Upgrading"
+	// ProductUpgradeVerifying is upgraded and waiting for its health
+	// probes to pass
+	ProductUpgradeVerifying ProductUpgradePhase = "Verifying"
+	// ProductUpgradeUpgraded passed its health probes
+	ProductUpgradeUpgraded ProductUpgradePhase = "Upgraded"
+	// ProductUpgradeFailed failed its health probes for longer than
+	// the probe timeout
+	ProductUpgradeFailed ProductUpgradePhase = "Failed"
+)
+
+type UpgradeStatus struct {
+	// FromVersion is the operator version the upgrade started from
+	FromVersion string `json:"fromVersion"`
+	// ToVersion is the operator version the upgrade moves to
+	ToVersion string       `json:"toVersion"`
+	Phase     UpgradePhase `json:"phase"`
+	// Message is why the upgrade halted
+	Message string `json:"message,omitempty"`
+	// Products in the order they're upgraded in
+	Products []ProductUpgradeStatus `json:"products,omitempty"`
+}
+
+type ProductUpgradeStatus struct {
+	Name  ProductName         `json:"name"`
+	Phase ProductUpgradePhase `json:"phase"`
+	// VerifyingSince is when the health probes of the product
+	// started
+	VerifyingSince *metav1.Time `json:"verifyingSince,omitempty"`
+	// Problems found by the last health probes of the product
+	Problems []string `json:"problems,omitempty"`
 }
 
 type RHMIStageStatus struct {
@@ -208,10 +1729,100 @@ type RHMIProductStatus struct {
 	Mobile          bool            `json:"mobile,omitempty"`
 	Phase           StatusPhase     `json:"status"`
 	Uninstall       bool            `json:"uninstall,omitempty"`
+	// Conditions report the phase as Available, Progressing and Degraded
+	// conditions
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// PreUpgradeBackup is the backup taken before the last upgrade
+	// of the operator of the product
+	PreUpgradeBackup *PreUpgradeBackupStatus `json:"preUpgradeBackup,omitempty"`
+	// FIPS is the FIPS compliance of the product, when the
+	// installation runs in FIPS mode
+	FIPS *FIPSStatus `json:"fips,omitempty"`
+	// SecretRotations are the last rotations of the credentials of
+	// the product
+	SecretRotations []SecretRotationStatus `json:"secretRotations,omitempty"`
+	// IdentityProvider is the connectivity of the corporate identity
+	// provider federated with the realm of the product
+	IdentityProvider *IdentityProviderStatus `json:"identityProvider,omitempty"`
+	// LDAPFederation is the health of the LDAP directory federated
+	// with the realm of the product
+	LDAPFederation *LDAPFederationStatus `json:"ldapFederation,omitempty"`
+}
+
+type SecretRotationSpec struct {
+	// Interval between the rotations of each credential, at least
+	// 24h. Defaults to 2160h (90 days)
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+type FIPSStatus struct {
+	Compliant bool `json:"compliant"`
+	// Message is why the product isn't compliant
+	Message string `json:"message,omitempty"`
+}
+
+type IdentityProviderStatus struct {
+	Alias string `json:"alias"`
+	// Connected is true when the configuration of the identity
+	// provider was read from it
+	Connected bool `json:"connected"`
+	// Message is why the identity provider isn't connected
+	Message            string      `json:"message,omitempty"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+type LDAPFederationStatus struct {
+	// Connected is true when the LDAP server accepted the bind
+	// credentials
+	Connected bool `json:"connected"`
+	// Message is why the LDAP server isn't connected
+	Message string `json:"message,omitempty"`
+	// LastSync is the last synchronization of the users, reported
+	// by the realm
+	LastSync           *metav1.Time `json:"lastSync,omitempty"`
+	LastTransitionTime metav1.Time  `json:"lastTransitionTime"`
+}
+
+type SecretRotationStatus struct {
+	// Name of the rotated credential
+	Name string `json:"name"`
+	// Secret holding the credential, as namespace/name
+	Secret      string      `json:"secret"`
+	LastRotated metav1.Time `json:"lastRotated"`
+}
+
+type BackupPhase string
+
+const (
+	BackupCompleted BackupPhase = "Completed"
+	BackupFailed    BackupPhase = "Failed"
+)
+
+type PreUpgradeBackupStatus struct {
+	Phase       BackupPhase  `json:"phase"`
+	StartedAt   metav1.Time  `json:"startedAt"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Message is why the backup failed
+	Message string `json:"message,omitempty"`
+	// Artifacts taken by the backup, the operator is only upgraded
+	// once they're all complete
+	Artifacts []BackupArtifact `json:"artifacts,omitempty"`
+}
+
+type BackupArtifact struct {
+	// Kind of the artifact: PostgresSnapshot, RedisSnapshot,
+	// PostgresDump, RealmExport or Job
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the snapshot or Job, the volume and file of the dump,
+	// or the bucket prefix of the realm export
+	Name        string      `json:"name"`
+	CompletedAt metav1.Time `json:"completedAt"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 
 // RHMI is the Schema for the rhmis API
 type RHMI struct {
@@ -263,9 +1874,34 @@ func (i *RHMI) GetDegradedComponents() []ProductName {
 	return degradedComponents
 }
 
-// IsProductInInstallStagePhaseComplete Helper for checking if a product in the installation stage is in a complete phase
+// IsProductInInstallStagePhaseComplete Helper for checking if a product in the installation stage is in a complete phase,
+// a skipped product counts as complete
 func (i *RHMI) IsProductInInstallStagePhaseComplete(productName ProductName) bool {
-	return i.GetInstallStage().Products[productName].Phase == PhaseCompleted
+	phase := i.GetInstallStage().Products[productName].Phase
+	return phase == PhaseCompleted || phase == PhaseSkipped
+}
+
+// IsProductDisabled when the product is an optional product disabled in the spec
+func (i *RHMI) IsProductDisabled(productName ProductName) bool {
+	if !OptionalProducts[productName] {
+		return false
+	}
+	for _, product := range i.Spec.Products {
+		if product.Name == productName {
+			return product.Enabled != nil && !*product.Enabled
+		}
+	}
+	return false
+}
+
+// IsPaused when the installation is annotated with PausedAnnotation
+func (i *RHMI) IsPaused() bool {
+	return i.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// IsDryRun when the installation is annotated with DryRunAnnotation
+func (i *RHMI) IsDryRun() bool {
+	return i.GetAnnotations()[DryRunAnnotation] == "true"
 }
 
 // IsInstalled when a version has been written to the status version before
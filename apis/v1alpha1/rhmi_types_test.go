@@ -1,9 +1,12 @@
 package v1alpha1
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -238,6 +241,12 @@ func TestRHMI_IsProductPhaseComplete(t *testing.T) {
 			args:   args{productName: productName},
 			want:   false,
 		},
+		{
+			name:   "test true when product is skipped",
+			fields: fields{Status: statusFactory(PhaseSkipped)},
+			args:   args{productName: productName},
+			want:   true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -251,6 +260,60 @@ func TestRHMI_IsProductPhaseComplete(t *testing.T) {
 	}
 }
 
+func TestRHMI_IsProductDisabled(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name        string
+		products    []ProductOverrideSpec
+		productName ProductName
+		want        bool
+	}{
+		{
+			name:        "test false when there are no overrides",
+			productName: ProductGrafana,
+			want:        false,
+		},
+		{
+			name:        "test false when the product is enabled",
+			products:    []ProductOverrideSpec{{Name: ProductGrafana, Enabled: &enabled}},
+			productName: ProductGrafana,
+			want:        false,
+		},
+		{
+			name:        "test false when enabled isn't set",
+			products:    []ProductOverrideSpec{{Name: ProductGrafana}},
+			productName: ProductGrafana,
+			want:        false,
+		},
+		{
+			name:        "test true when the product is disabled",
+			products:    []ProductOverrideSpec{{Name: ProductRHSSOUser, Enabled: &disabled}},
+			productName: ProductRHSSOUser,
+			want:        true,
+		},
+		{
+			name:        "test false when another product is disabled",
+			products:    []ProductOverrideSpec{{Name: ProductRHSSOUser, Enabled: &disabled}},
+			productName: ProductGrafana,
+			want:        false,
+		},
+		{
+			name:        "test false when the product isn't optional",
+			products:    []ProductOverrideSpec{{Name: Product3Scale, Enabled: &disabled}},
+			productName: Product3Scale,
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{Products: tt.products}}
+			if got := i.IsProductDisabled(tt.productName); got != tt.want {
+				t.Errorf("IsProductDisabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRHMI_IsUninstallBlocked(t *testing.T) {
 	type fields struct {
 		ObjectMeta v1.ObjectMeta
@@ -319,3 +382,512 @@ func TestRHMI_IsUninstalling(t *testing.T) {
 		})
 	}
 }
+
+func TestRHMI_IsMaintenanceModeActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name            string
+		maintenanceMode *MaintenanceModeSpec
+		want            bool
+	}{
+		{
+			name: "test false if maintenance mode not configured",
+		},
+		{
+			name:            "test false if maintenance mode disabled",
+			maintenanceMode: &MaintenanceModeSpec{Enabled: false, Until: v1.NewTime(now.Add(time.Hour))},
+		},
+		{
+			name:            "test false if maintenance mode expired",
+			maintenanceMode: &MaintenanceModeSpec{Enabled: true, Until: v1.NewTime(now.Add(-time.Minute))},
+		},
+		{
+			name:            "test true if maintenance mode enabled and not expired",
+			maintenanceMode: &MaintenanceModeSpec{Enabled: true, Until: v1.NewTime(now.Add(time.Hour))},
+			want:            true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{MaintenanceMode: tt.maintenanceMode}}
+			if got := i.IsMaintenanceModeActive(now); got != tt.want {
+				t.Errorf("IsMaintenanceModeActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseConditions(t *testing.T) {
+	tests := []struct {
+		phase                           StatusPhase
+		available, progressing, degrade v1.ConditionStatus
+	}{
+		{phase: PhaseNone, available: v1.ConditionFalse, progressing: v1.ConditionFalse, degrade: v1.ConditionFalse},
+		{phase: PhaseAwaitingOperator, available: v1.ConditionFalse, progressing: v1.ConditionTrue, degrade: v1.ConditionFalse},
+		{phase: PhaseInProgress, available: v1.ConditionFalse, progressing: v1.ConditionTrue, degrade: v1.ConditionFalse},
+		{phase: PhaseCompleted, available: v1.ConditionTrue, progressing: v1.ConditionFalse, degrade: v1.ConditionFalse},
+		{phase: PhaseFailed, available: v1.ConditionFalse, progressing: v1.ConditionFalse, degrade: v1.ConditionTrue},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.phase), func(t *testing.T) {
+			conditions := PhaseConditions(tt.phase)
+			got := map[string]v1.ConditionStatus{}
+			for _, condition := range conditions {
+				got[condition.Type] = condition.Status
+			}
+			want := map[string]v1.ConditionStatus{
+				AvailableConditionType.String():   tt.available,
+				ProgressingConditionType.String(): tt.progressing,
+				DegradedConditionType.String():    tt.degrade,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("PhaseConditions() = %v, want %v", got, want)
+			}
+			if phase := PhaseFromConditions(conditions); phase != tt.phase {
+				t.Errorf("PhaseFromConditions() = %q, want %q", phase, tt.phase)
+			}
+		})
+	}
+}
+
+func TestRHMI_SetPhaseConditions(t *testing.T) {
+	transitioned := v1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	previous := RHMIStatus{Stages: map[StageName]RHMIStageStatus{
+		InstallStage: {
+			Name: InstallStage,
+			Products: map[ProductName]RHMIProductStatus{
+				Product3Scale: {Name: Product3Scale, Phase: PhaseCompleted, Conditions: PhaseConditions(PhaseCompleted)},
+			},
+		},
+	}}
+	for i := range previous.Stages[InstallStage].Products[Product3Scale].Conditions {
+		previous.Stages[InstallStage].Products[Product3Scale].Conditions[i].LastTransitionTime = transitioned
+	}
+
+	installation := &RHMI{Status: RHMIStatus{
+		Stage: InstallStage,
+		Stages: map[StageName]RHMIStageStatus{
+			InstallStage: {
+				Name:  InstallStage,
+				Phase: PhaseFailed,
+				Products: map[ProductName]RHMIProductStatus{
+					Product3Scale:    {Name: Product3Scale, Phase: PhaseCompleted},
+					ProductRHSSOUser: {Name: ProductRHSSOUser, Phase: PhaseFailed},
+				},
+			},
+		},
+	}}
+	installation.SetPhaseConditions(previous)
+
+	threescale := installation.Status.Stages[InstallStage].Products[Product3Scale]
+	available := meta.FindStatusCondition(threescale.Conditions, AvailableConditionType.String())
+	if available == nil || available.Status != v1.ConditionTrue || !available.LastTransitionTime.Equal(&transitioned) {
+		t.Errorf("expected the 3scale Available condition to be carried over, got %+v", available)
+	}
+	rhssoUser := installation.Status.Stages[InstallStage].Products[ProductRHSSOUser]
+	if !meta.IsStatusConditionTrue(rhssoUser.Conditions, DegradedConditionType.String()) {
+		t.Errorf("expected user SSO to be degraded, got %+v", rhssoUser.Conditions)
+	}
+	if !meta.IsStatusConditionTrue(installation.Status.Conditions, DegradedConditionType.String()) {
+		t.Errorf("expected the installation to be degraded, got %+v", installation.Status.Conditions)
+	}
+
+	installation.Status.Stage = CompleteStage
+	installation.SetPhaseConditions(previous)
+	if !meta.IsStatusConditionTrue(installation.Status.Conditions, AvailableConditionType.String()) {
+		t.Errorf("expected the completed installation to be available, got %+v", installation.Status.Conditions)
+	}
+}
+
+func TestRHMI_ValidateCustomDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		routes  []CustomRouteSpec
+		wantErr bool
+	}{
+		{
+			name: "hostnames of different routes",
+			routes: []CustomRouteSpec{
+				{Route: CustomRouteRHSSO, Hostname: "sso.example.com"},
+				{Route: CustomRouteRHSSOUser, Hostname: "user-sso.example.com"},
+			},
+		},
+		{
+			name: "hostname claimed by two routes",
+			routes: []CustomRouteSpec{
+				{Route: CustomRouteThreeScaleAdmin, Hostname: "api.example.com"},
+				{Route: CustomRouteThreeScaleDeveloper, Hostname: "api.example.com"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{CustomDomain: &CustomDomainSpec{Routes: tt.routes}}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateAlerting(t *testing.T) {
+	email := &EmailReceiverSpec{To: []string{"team@example.com"}}
+	tests := []struct {
+		name     string
+		alerting *AlertingSpec
+		wantErr  bool
+	}{
+		{
+			name: "routes to defined receivers",
+			alerting: &AlertingSpec{
+				Receivers: []AlertReceiverSpec{{Name: "team", Email: email}},
+				Routes:    []AlertRouteSpec{{Severity: "warning", Receiver: "team"}},
+			},
+		},
+		{
+			name: "receiver without configuration",
+			alerting: &AlertingSpec{
+				Receivers: []AlertReceiverSpec{{Name: "team"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "receiver named like the heartbeat receiver",
+			alerting: &AlertingSpec{
+				Receivers: []AlertReceiverSpec{{Name: "heartbeat", Email: email}},
+				Heartbeat: &HeartbeatSpec{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "route to an unknown receiver",
+			alerting: &AlertingSpec{
+				Receivers: []AlertReceiverSpec{{Name: "team", Email: email}},
+				Routes:    []AlertRouteSpec{{Severity: "critical", Receiver: "oncall"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{Alerting: tt.alerting}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateObservability(t *testing.T) {
+	token := &corev1.SecretKeySelector{Key: "token"}
+	tests := []struct {
+		name          string
+		observability *ObservabilitySpec
+		wantErr       bool
+	}{
+		{
+			name: "bearer token with allowlist",
+			observability: &ObservabilitySpec{
+				RemoteWrite: []RemoteWriteSpec{{Name: "thanos", URL: "https://thanos.example.com", BearerTokenSecret: token, MetricAllowlist: []string{"threescale_.*"}}},
+			},
+		},
+		{
+			name: "both basic auth and bearer token",
+			observability: &ObservabilitySpec{
+				RemoteWrite: []RemoteWriteSpec{{Name: "thanos", URL: "https://thanos.example.com", BearerTokenSecret: token, BasicAuth: &RemoteWriteBasicAuth{}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid allowlist regex",
+			observability: &ObservabilitySpec{
+				RemoteWrite: []RemoteWriteSpec{{Name: "thanos", URL: "https://thanos.example.com", MetricAllowlist: []string{"threescale_(.*"}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{Observability: tt.observability}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateLogForwarding(t *testing.T) {
+	tests := []struct {
+		name          string
+		logForwarding *LogForwardingSpec
+		wantErr       bool
+	}{
+		{
+			name: "sources sent to defined outputs",
+			logForwarding: &LogForwardingSpec{
+				Outputs: []LogOutputSpec{{Name: "cloudwatch", Type: LogOutputCloudWatch, Region: "eu-west-1"}},
+				Sources: []LogSourceSpec{{Source: LogSourceAPIcastAccess, Outputs: []string{"cloudwatch"}}},
+			},
+		},
+		{
+			name: "cloudwatch output without region",
+			logForwarding: &LogForwardingSpec{
+				Outputs: []LogOutputSpec{{Name: "cloudwatch", Type: LogOutputCloudWatch}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "loki output without url",
+			logForwarding: &LogForwardingSpec{
+				Outputs: []LogOutputSpec{{Name: "loki", Type: LogOutputLoki}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "source sent to an unknown output",
+			logForwarding: &LogForwardingSpec{
+				Outputs: []LogOutputSpec{{Name: "loki", Type: LogOutputLoki, URL: "https://loki.example.com"}},
+				Sources: []LogSourceSpec{{Source: LogSourceKeycloakAudit, Outputs: []string{"splunk"}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{LogForwarding: tt.logForwarding}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateSLOs(t *testing.T) {
+	tests := []struct {
+		name    string
+		slos    []SLOSpec
+		wantErr bool
+	}{
+		{
+			name: "availability and latency SLOs",
+			slos: []SLOSpec{
+				{Name: "gateway-availability", Product: Product3Scale, Indicator: SLIAvailability, Objective: "99.9", Route: "^zync-3scale-api-tenant-a.*"},
+				{Name: "sso-latency", Product: ProductRHSSO, Indicator: SLILatency, Objective: "99", LatencyThreshold: &v1.Duration{Duration: 250 * time.Millisecond}},
+			},
+		},
+		{
+			name:    "latency SLO without threshold",
+			slos:    []SLOSpec{{Name: "sso-latency", Product: ProductRHSSO, Indicator: SLILatency, Objective: "99"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid route",
+			slos:    []SLOSpec{{Name: "gateway-availability", Product: Product3Scale, Indicator: SLIAvailability, Objective: "99.9", Route: "^zync-(3scale"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{SLOs: tt.slos}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateDataSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		dataSources []DataSourceSpec
+		wantErr     bool
+	}{
+		{
+			name: "cloudwatch and prometheus data sources",
+			dataSources: []DataSourceSpec{
+				{Name: "CloudWatch", Type: DataSourceCloudWatch, Region: "eu-west-1", CredentialsSecret: "cloudwatch-credentials"},
+				{Name: "Applications", Type: DataSourcePrometheus, URL: "https://prometheus.example.com"},
+			},
+		},
+		{
+			name:        "cloudwatch data source without region",
+			dataSources: []DataSourceSpec{{Name: "CloudWatch", Type: DataSourceCloudWatch}},
+			wantErr:     true,
+		},
+		{
+			name:        "prometheus data source without url",
+			dataSources: []DataSourceSpec{{Name: "Applications", Type: DataSourcePrometheus}},
+			wantErr:     true,
+		},
+		{
+			name:        "data source named like the prometheus of the installation",
+			dataSources: []DataSourceSpec{{Name: "Prometheus", Type: DataSourcePrometheus, URL: "https://prometheus.example.com"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{DataSources: tt.dataSources}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateMetering(t *testing.T) {
+	tests := []struct {
+		name             string
+		installationType InstallationType
+		wantErr          bool
+	}{
+		{
+			name:             "metering of a multitenant installation",
+			installationType: InstallationTypeMultitenantManagedApi,
+		},
+		{
+			name:             "metering of a single tenant installation",
+			installationType: InstallationTypeManagedApi,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{Type: string(tt.installationType), Metering: &MeteringSpec{}}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateUserSSOIdentityProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		idp     *UserSSOIdentityProviderSpec
+		wantErr bool
+	}{
+		{
+			name: "OpenID Connect provider",
+			idp:  &UserSSOIdentityProviderSpec{Type: UserSSOIdentityProviderOIDC, IssuerURL: "https://login.microsoftonline.com/tenant/v2.0", ClientID: "rhoam"},
+		},
+		{
+			name:    "OpenID Connect provider without issuer",
+			idp:     &UserSSOIdentityProviderSpec{Type: UserSSOIdentityProviderOIDC, ClientID: "rhoam"},
+			wantErr: true,
+		},
+		{
+			name:    "SAML provider without metadata",
+			idp:     &UserSSOIdentityProviderSpec{Type: UserSSOIdentityProviderSAML},
+			wantErr: true,
+		},
+		{
+			name:    "alias of the OpenShift identity provider",
+			idp:     &UserSSOIdentityProviderSpec{Alias: "openshift-v4", Type: UserSSOIdentityProviderSAML, MetadataURL: "https://okta.example.com/metadata"},
+			wantErr: true,
+		},
+		{
+			name: "mapper defined twice",
+			idp: &UserSSOIdentityProviderSpec{Type: UserSSOIdentityProviderSAML, MetadataURL: "https://okta.example.com/metadata", Mappers: []IdentityProviderMapperSpec{
+				{Name: "email", Type: "saml-user-attribute-idp-mapper"},
+				{Name: "email", Type: "saml-user-attribute-idp-mapper"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{UserSSOIdentityProvider: tt.idp}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateUserSSOLDAPFederation(t *testing.T) {
+	tests := []struct {
+		name    string
+		ldap    *UserSSOLDAPFederationSpec
+		wantErr bool
+	}{
+		{
+			name: "LDAP federation",
+			ldap: &UserSSOLDAPFederationSpec{ConnectionURL: "ldaps://ldap.example.com", BindCredentialsSecret: "ldap-bind", UsersDN: "ou=users,dc=example,dc=com"},
+		},
+		{
+			name:    "connection URL that isn't LDAP",
+			ldap:    &UserSSOLDAPFederationSpec{ConnectionURL: "https://ldap.example.com", BindCredentialsSecret: "ldap-bind", UsersDN: "ou=users,dc=example,dc=com"},
+			wantErr: true,
+		},
+		{
+			name:    "LDAP federation without bind credentials",
+			ldap:    &UserSSOLDAPFederationSpec{ConnectionURL: "ldap://ldap.example.com", UsersDN: "ou=users,dc=example,dc=com"},
+			wantErr: true,
+		},
+		{
+			name: "sync period under a minute",
+			ldap: &UserSSOLDAPFederationSpec{ConnectionURL: "ldaps://ldap.example.com", BindCredentialsSecret: "ldap-bind", UsersDN: "ou=users,dc=example,dc=com", SyncSchedule: &LDAPSyncScheduleSpec{
+				ChangedUsersSyncPeriod: &v1.Duration{Duration: 30 * time.Second},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "mapper defined twice",
+			ldap: &UserSSOLDAPFederationSpec{ConnectionURL: "ldaps://ldap.example.com", BindCredentialsSecret: "ldap-bind", UsersDN: "ou=users,dc=example,dc=com", Mappers: []LDAPMapperSpec{
+				{Name: "groups", Type: "group-ldap-mapper"},
+				{Name: "groups", Type: "group-ldap-mapper"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{UserSSOLDAPFederation: tt.ldap}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRHMI_ValidateKeycloakTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   *KeycloakThemeSpec
+		wantErr bool
+	}{
+		{
+			name:  "theme from a ConfigMap",
+			theme: &KeycloakThemeSpec{Name: "acme", ConfigMap: "acme-theme", Types: []KeycloakThemeType{KeycloakThemeLogin}},
+		},
+		{
+			name:  "theme from an archive",
+			theme: &KeycloakThemeSpec{Name: "acme", ArchiveURL: "https://example.com/acme-theme.jar"},
+		},
+		{
+			name:    "theme named like a theme of Keycloak",
+			theme:   &KeycloakThemeSpec{Name: "rh-sso", ConfigMap: "acme-theme"},
+			wantErr: true,
+		},
+		{
+			name:    "theme from both a ConfigMap and an archive",
+			theme:   &KeycloakThemeSpec{Name: "acme", ConfigMap: "acme-theme", ArchiveURL: "https://example.com/acme-theme.jar"},
+			wantErr: true,
+		},
+		{
+			name:    "theme without files",
+			theme:   &KeycloakThemeSpec{Name: "acme"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := &RHMI{Spec: RHMISpec{KeycloakTheme: tt.theme}}
+			if err := i.ValidateCreate(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "time"
+
+const (
+	// DefaultUpgradeProbeTimeout is how long the health probes of an
+	// upgraded product can fail before the progressive upgrade is halted
+	DefaultUpgradeProbeTimeout = 15 * time.Minute
+	// DefaultPreUpgradeBackupTimeout of the backups of a product taken
+	// before its operator is upgraded
+	DefaultPreUpgradeBackupTimeout = 20 * time.Minute
+)
+
+// IsProgressiveUpgradeEnabled returns whether the products of an operator
+// upgrade are upgraded one at a time
+func (i *RHMI) IsProgressiveUpgradeEnabled() bool {
+	return i.Spec.ProgressiveUpgrade != nil && i.Spec.ProgressiveUpgrade.Enabled
+}
+
+// UpgradeProbeTimeout returns how long the health probes of an upgraded
+// product can fail before the progressive upgrade is halted
+func (i *RHMI) UpgradeProbeTimeout() time.Duration {
+	if i.Spec.ProgressiveUpgrade != nil && i.Spec.ProgressiveUpgrade.ProbeTimeout != nil {
+		return i.Spec.ProgressiveUpgrade.ProbeTimeout.Duration
+	}
+	return DefaultUpgradeProbeTimeout
+}
+
+// PreUpgradeBackupTimeout returns how long the upgrade of the operator of a
+// product waits for its backups
+func (i *RHMI) PreUpgradeBackupTimeout() time.Duration {
+	if i.Spec.PreUpgradeBackup != nil && i.Spec.PreUpgradeBackup.Timeout != nil {
+		return i.Spec.PreUpgradeBackup.Timeout.Duration
+	}
+	return DefaultPreUpgradeBackupTimeout
+}
+
+// IsUpgradeHeld returns whether the product keeps its previous version as
+// it's waiting for its turn in the progressive upgrade, or the upgrade
+// halted before reaching it
+func (i *RHMI) IsUpgradeHeld(productName ProductName) bool {
+	if !i.IsProgressiveUpgradeEnabled() || i.Status.Upgrade == nil {
+		return false
+	}
+	product := i.Status.Upgrade.Product(productName)
+	return product != nil && product.Phase == ProductUpgradePending
+}
+
+// Product returns the upgrade status of the product, or nil when it isn't
+// part of the upgrade
+func (s *UpgradeStatus) Product(productName ProductName) *ProductUpgradeStatus {
+	for i := range s.Products {
+		if s.Products[i].Name == productName {
+			return &s.Products[i]
+		}
+	}
+	return nil
+}
+
+// CurrentProduct returns the first product that isn't upgraded yet, or nil
+// when they're all upgraded
+func (s *UpgradeStatus) CurrentProduct() *ProductUpgradeStatus {
+	for i := range s.Products {
+		if s.Products[i].Phase != ProductUpgradeUpgraded {
+			return &s.Products[i]
+		}
+	}
+	return nil
+}
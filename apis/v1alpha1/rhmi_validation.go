@@ -0,0 +1,322 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ValidateCreate rejects the installations with invalid settings the CRD
+// schema can't express. It's called by the validating webhook
+func (i *RHMI) ValidateCreate() error {
+	return i.validate()
+}
+
+// ValidateUpdate rejects the updates leaving the installation with invalid
+// settings
+func (i *RHMI) ValidateUpdate(old runtime.Object) error {
+	if oldInstallation, ok := old.(*RHMI); ok {
+		if err := i.validateNamespacesUpdate(oldInstallation); err != nil {
+			return err
+		}
+	}
+	return i.validate()
+}
+
+// ValidateDelete allows every deletion, they're validated by the rhmi-delete
+// webhook
+func (i *RHMI) ValidateDelete() error {
+	return nil
+}
+
+func (i *RHMI) validate() error {
+	if err := i.validateReconcile(); err != nil {
+		return err
+	}
+	if err := i.validateNamespaces(); err != nil {
+		return err
+	}
+	if err := i.validateCustomDomain(); err != nil {
+		return err
+	}
+	if err := i.validateAlerting(); err != nil {
+		return err
+	}
+	if err := i.validateObservability(); err != nil {
+		return err
+	}
+	if err := i.validateLogForwarding(); err != nil {
+		return err
+	}
+	if err := i.validateSLOs(); err != nil {
+		return err
+	}
+	if err := i.validateDataSources(); err != nil {
+		return err
+	}
+	if err := i.validateSecretRotation(); err != nil {
+		return err
+	}
+	if err := i.validateExternalSecrets(); err != nil {
+		return err
+	}
+	if err := i.validateUserSSOIdentityProvider(); err != nil {
+		return err
+	}
+	if err := i.validateUserSSOLDAPFederation(); err != nil {
+		return err
+	}
+	if err := i.validateKeycloakTheme(); err != nil {
+		return err
+	}
+	return i.validateMetering()
+}
+
+// validateSecretRotation rejects the rotation intervals under a day, the
+// rotation restarts the workloads using the credentials
+func (i *RHMI) validateSecretRotation() error {
+	if i.Spec.SecretRotation == nil || i.Spec.SecretRotation.Interval == nil {
+		return nil
+	}
+	if interval := i.Spec.SecretRotation.Interval.Duration; interval < 24*time.Hour {
+		return fmt.Errorf("spec.secretRotation.interval %s is under 24h", interval)
+	}
+	return nil
+}
+
+// validateUserSSOIdentityProvider rejects the identity providers missing the
+// URL their configuration is read from, and the ones taking the alias of the
+// OpenShift identity provider of the realm
+func (i *RHMI) validateUserSSOIdentityProvider() error {
+	idp := i.Spec.UserSSOIdentityProvider
+	if idp == nil {
+		return nil
+	}
+	if idp.Alias == "openshift-v4" {
+		return fmt.Errorf("spec.userSSOIdentityProvider.alias %s is the alias of the OpenShift identity provider", idp.Alias)
+	}
+	switch idp.Type {
+	case UserSSOIdentityProviderOIDC:
+		if idp.IssuerURL == "" {
+			return fmt.Errorf("spec.userSSOIdentityProvider.issuerURL is required by OpenID Connect providers")
+		}
+	case UserSSOIdentityProviderSAML:
+		if idp.MetadataURL == "" {
+			return fmt.Errorf("spec.userSSOIdentityProvider.metadataURL is required by SAML providers")
+		}
+	default:
+		return fmt.Errorf("spec.userSSOIdentityProvider.type %s isn't oidc or saml", idp.Type)
+	}
+	names := map[string]bool{}
+	for _, mapper := range idp.Mappers {
+		if names[mapper.Name] {
+			return fmt.Errorf("spec.userSSOIdentityProvider.mappers %s is defined twice", mapper.Name)
+		}
+		names[mapper.Name] = true
+	}
+	return nil
+}
+
+// validateUserSSOLDAPFederation rejects the LDAP federations missing their
+// bind credentials or users DN, and the sync periods under a minute
+func (i *RHMI) validateUserSSOLDAPFederation() error {
+	ldap := i.Spec.UserSSOLDAPFederation
+	if ldap == nil {
+		return nil
+	}
+	if !regexp.MustCompile(`^ldaps?://`).MatchString(ldap.ConnectionURL) {
+		return fmt.Errorf("spec.userSSOLDAPFederation.connectionURL %s isn't an ldap:// or ldaps:// URL", ldap.ConnectionURL)
+	}
+	if ldap.BindCredentialsSecret == "" {
+		return fmt.Errorf("spec.userSSOLDAPFederation.bindCredentialsSecret is required")
+	}
+	if ldap.UsersDN == "" {
+		return fmt.Errorf("spec.userSSOLDAPFederation.usersDN is required")
+	}
+	if schedule := ldap.SyncSchedule; schedule != nil {
+		if schedule.FullSyncPeriod != nil && schedule.FullSyncPeriod.Duration < time.Minute {
+			return fmt.Errorf("spec.userSSOLDAPFederation.syncSchedule.fullSyncPeriod %s is under 1m", schedule.FullSyncPeriod.Duration)
+		}
+		if schedule.ChangedUsersSyncPeriod != nil && schedule.ChangedUsersSyncPeriod.Duration < time.Minute {
+			return fmt.Errorf("spec.userSSOLDAPFederation.syncSchedule.changedUsersSyncPeriod %s is under 1m", schedule.ChangedUsersSyncPeriod.Duration)
+		}
+	}
+	names := map[string]bool{}
+	for _, mapper := range ldap.Mappers {
+		if names[mapper.Name] {
+			return fmt.Errorf("spec.userSSOLDAPFederation.mappers %s is defined twice", mapper.Name)
+		}
+		names[mapper.Name] = true
+	}
+	return nil
+}
+
+// validateKeycloakTheme rejects the themes named like a theme of Keycloak, as
+// they would replace it, and the ones without or with both a ConfigMap and an
+// archive
+func (i *RHMI) validateKeycloakTheme() error {
+	theme := i.Spec.KeycloakTheme
+	if theme == nil {
+		return nil
+	}
+	switch theme.Name {
+	case "base", "keycloak", "keycloak.v2", "rh-sso", "rh-sso.v2":
+		return fmt.Errorf("spec.keycloakTheme.name %s is the name of a theme of Keycloak", theme.Name)
+	}
+	if (theme.ConfigMap == "") == (theme.ArchiveURL == "") {
+		return fmt.Errorf("spec.keycloakTheme requires either a configMap or an archiveURL")
+	}
+	for _, themeType := range theme.Types {
+		if themeType != KeycloakThemeLogin && themeType != KeycloakThemeEmail {
+			return fmt.Errorf("spec.keycloakTheme.types %s isn't login or email", themeType)
+		}
+	}
+	return nil
+}
+
+// validateMetering rejects the metering of the installations without tenants
+func (i *RHMI) validateMetering() error {
+	if i.Spec.Metering != nil && !IsRHOAMMultitenant(InstallationType(i.Spec.Type)) {
+		return fmt.Errorf("spec.metering is only supported by multitenant installations")
+	}
+	return nil
+}
+
+// validateDataSources rejects the data sources missing the settings of their
+// type and the ones named like the Prometheus of the installation
+func (i *RHMI) validateDataSources() error {
+	for _, dataSource := range i.Spec.DataSources {
+		if dataSource.Name == "Prometheus" {
+			return fmt.Errorf("spec.dataSources %s is the name of the Prometheus of the installation", dataSource.Name)
+		}
+		if dataSource.Type == DataSourceCloudWatch && dataSource.Region == "" {
+			return fmt.Errorf("spec.dataSources %s requires a region", dataSource.Name)
+		}
+		if dataSource.Type == DataSourcePrometheus && dataSource.URL == "" {
+			return fmt.Errorf("spec.dataSources %s requires a url", dataSource.Name)
+		}
+	}
+	return nil
+}
+
+// validateSLOs rejects the latency SLOs without threshold and the routes
+// with invalid regular expressions
+func (i *RHMI) validateSLOs() error {
+	for _, slo := range i.Spec.SLOs {
+		if slo.Indicator == SLILatency && slo.LatencyThreshold == nil {
+			return fmt.Errorf("spec.slos %s requires a latencyThreshold", slo.Name)
+		}
+		if _, err := regexp.Compile(slo.Route); err != nil {
+			return fmt.Errorf("spec.slos %s route %q is invalid: %w", slo.Name, slo.Route, err)
+		}
+	}
+	return nil
+}
+
+// validateLogForwarding rejects the outputs missing the settings of their
+// type and the sources sent to unknown outputs
+func (i *RHMI) validateLogForwarding() error {
+	if i.Spec.LogForwarding == nil {
+		return nil
+	}
+	outputs := map[string]bool{}
+	for _, output := range i.Spec.LogForwarding.Outputs {
+		if output.Type == LogOutputCloudWatch && output.Region == "" {
+			return fmt.Errorf("spec.logForwarding.outputs %s requires a region", output.Name)
+		}
+		if output.Type != LogOutputCloudWatch && output.URL == "" {
+			return fmt.Errorf("spec.logForwarding.outputs %s requires a url", output.Name)
+		}
+		outputs[output.Name] = true
+	}
+	for _, source := range i.Spec.LogForwarding.Sources {
+		for _, output := range source.Outputs {
+			if !outputs[output] {
+				return fmt.Errorf("spec.logForwarding.sources %s output %s is not defined", source.Source, output)
+			}
+		}
+	}
+	return nil
+}
+
+// validateObservability rejects the remote write endpoints with several
+// authentication methods and the allowlists and relabel configs with invalid
+// regular expressions
+func (i *RHMI) validateObservability() error {
+	if i.Spec.Observability == nil {
+		return nil
+	}
+	for _, remoteWrite := range i.Spec.Observability.RemoteWrite {
+		if remoteWrite.BasicAuth != nil && remoteWrite.BearerTokenSecret != nil {
+			return fmt.Errorf("spec.observability.remoteWrite %s can't set both basicAuth and bearerTokenSecret", remoteWrite.Name)
+		}
+		for _, pattern := range remoteWrite.MetricAllowlist {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("spec.observability.remoteWrite %s metricAllowlist %q is invalid: %w", remoteWrite.Name, pattern, err)
+			}
+		}
+		for _, relabel := range remoteWrite.WriteRelabelConfigs {
+			if _, err := regexp.Compile(relabel.Regex); err != nil {
+				return fmt.Errorf("spec.observability.remoteWrite %s writeRelabelConfigs regex %q is invalid: %w", remoteWrite.Name, relabel.Regex, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateAlerting rejects the receivers without a configuration or clashing
+// with the heartbeat receiver, and the routes to unknown receivers
+func (i *RHMI) validateAlerting() error {
+	if i.Spec.Alerting == nil {
+		return nil
+	}
+	receivers := map[string]bool{}
+	for _, receiver := range i.Spec.Alerting.Receivers {
+		if receiver.PagerDuty == nil && receiver.Slack == nil && receiver.OpsGenie == nil && receiver.Webhook == nil && receiver.Email == nil {
+			return fmt.Errorf("spec.alerting.receivers %s has no configuration", receiver.Name)
+		}
+		receivers[receiver.Name] = true
+	}
+	if i.Spec.Alerting.Heartbeat != nil && receivers["heartbeat"] {
+		return fmt.Errorf("spec.alerting.receivers heartbeat is reserved for the heartbeat receiver")
+	}
+	for _, route := range i.Spec.Alerting.Routes {
+		if !receivers[route.Receiver] {
+			return fmt.Errorf("spec.alerting.routes receiver %s is not defined", route.Receiver)
+		}
+	}
+	return nil
+}
+
+// validateCustomDomain rejects the hostnames claimed by several routes
+func (i *RHMI) validateCustomDomain() error {
+	if i.Spec.CustomDomain == nil {
+		return nil
+	}
+	hostnames := map[string]CustomRouteName{}
+	for _, route := range i.Spec.CustomDomain.Routes {
+		if other, ok := hostnames[route.Hostname]; ok {
+			return fmt.Errorf("spec.customDomain.routes hostname %s is claimed by both %s and %s", route.Hostname, other, route.Route)
+		}
+		hostnames[route.Hostname] = route.Route
+	}
+	return nil
+}
@@ -22,6 +22,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -30,7 +32,7 @@ func (in *APIManagementTenant) DeepCopyInto(out *APIManagementTenant) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -87,6 +89,21 @@ func (in *APIManagementTenantList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIManagementTenantSpec) DeepCopyInto(out *APIManagementTenantSpec) {
 	*out = *in
+	if in.Billing != nil {
+		in, out := &in.Billing, &out.Billing
+		*out = new(TenantBillingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(TenantRateLimitSpec)
+		**out = **in
+	}
+	if in.Offboarding != nil {
+		in, out := &in.Offboarding, &out.Offboarding
+		*out = new(TenantOffboardingSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIManagementTenantSpec.
@@ -114,6 +131,61 @@ func (in *APIManagementTenantStatus) DeepCopy() *APIManagementTenantStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertReceiverSpec) DeepCopyInto(out *AlertReceiverSpec) {
+	*out = *in
+	if in.PagerDuty != nil {
+		in, out := &in.PagerDuty, &out.PagerDuty
+		*out = new(PagerDutyReceiverSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Slack != nil {
+		in, out := &in.Slack, &out.Slack
+		*out = new(SlackReceiverSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OpsGenie != nil {
+		in, out := &in.OpsGenie, &out.OpsGenie
+		*out = new(OpsGenieReceiverSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookReceiverSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = new(EmailReceiverSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertReceiverSpec.
+func (in *AlertReceiverSpec) DeepCopy() *AlertReceiverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertReceiverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertRouteSpec) DeepCopyInto(out *AlertRouteSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertRouteSpec.
+func (in *AlertRouteSpec) DeepCopy() *AlertRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertingEmailAddresses) DeepCopyInto(out *AlertingEmailAddresses) {
 	*out = *in
@@ -129,6 +201,84 @@ func (in *AlertingEmailAddresses) DeepCopy() *AlertingEmailAddresses {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertingSpec) DeepCopyInto(out *AlertingSpec) {
+	*out = *in
+	if in.Receivers != nil {
+		in, out := &in.Receivers, &out.Receivers
+		*out = make([]AlertReceiverSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]AlertRouteSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Heartbeat != nil {
+		in, out := &in.Heartbeat, &out.Heartbeat
+		*out = new(HeartbeatSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertingSpec.
+func (in *AlertingSpec) DeepCopy() *AlertingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationPlanTemplateSpec) DeepCopyInto(out *ApplicationPlanTemplateSpec) {
+	*out = *in
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make([]PlanLimitSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationPlanTemplateSpec.
+func (in *ApplicationPlanTemplateSpec) DeepCopy() *ApplicationPlanTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationPlanTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupArtifact) DeepCopyInto(out *BackupArtifact) {
+	*out = *in
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupArtifact.
+func (in *BackupArtifact) DeepCopy() *BackupArtifact {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupArtifact)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BlackboxTarget) DeepCopyInto(out *BlackboxTarget) {
 	*out = *in
@@ -238,9 +388,129 @@ func (in *BlackboxtargetData) DeepCopy() *BlackboxtargetData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BruteForceDetectionSpec) DeepCopyInto(out *BruteForceDetectionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BruteForceDetectionSpec.
+func (in *BruteForceDetectionSpec) DeepCopy() *BruteForceDetectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BruteForceDetectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CORSPolicySpec) DeepCopyInto(out *CORSPolicySpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowOrigins != nil {
+		in, out := &in.AllowOrigins, &out.AllowOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowMethods != nil {
+		in, out := &in.AllowMethods, &out.AllowMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowHeaders != nil {
+		in, out := &in.AllowHeaders, &out.AllowHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExposeHeaders != nil {
+		in, out := &in.ExposeHeaders, &out.ExposeHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CORSPolicySpec.
+func (in *CORSPolicySpec) DeepCopy() *CORSPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CORSPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+	if in.RotatedAt != nil {
+		in, out := &in.RotatedAt, &out.RotatedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainDNSSpec) DeepCopyInto(out *CustomDomainDNSSpec) {
+	*out = *in
+	if in.TXTRecords != nil {
+		in, out := &in.TXTRecords, &out.TXTRecords
+		*out = make([]DNSTXTRecord, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainDNSSpec.
+func (in *CustomDomainDNSSpec) DeepCopy() *CustomDomainDNSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainDNSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomDomainSpec) DeepCopyInto(out *CustomDomainSpec) {
+	*out = *in
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]CustomRouteSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainSpec.
+func (in *CustomDomainSpec) DeepCopy() *CustomDomainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomDomainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomDomainStatus) DeepCopyInto(out *CustomDomainStatus) {
 	*out = *in
+	if in.Records != nil {
+		in, out := &in.Records, &out.Records
+		*out = make([]DNSRecordStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomDomainStatus.
@@ -254,176 +524,2329 @@ func (in *CustomDomainStatus) DeepCopy() *CustomDomainStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomSmtpStatus) DeepCopyInto(out *CustomSmtpStatus) {
+func (in *CustomRouteSpec) DeepCopyInto(out *CustomRouteSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomSmtpStatus.
-func (in *CustomSmtpStatus) DeepCopy() *CustomSmtpStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomRouteSpec.
+func (in *CustomRouteSpec) DeepCopy() *CustomRouteSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomSmtpStatus)
+	out := new(CustomRouteSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PullSecretSpec) DeepCopyInto(out *PullSecretSpec) {
+func (in *CustomRouteStatus) DeepCopyInto(out *CustomRouteStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullSecretSpec.
-func (in *PullSecretSpec) DeepCopy() *PullSecretSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomRouteStatus.
+func (in *CustomRouteStatus) DeepCopy() *CustomRouteStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PullSecretSpec)
+	out := new(CustomRouteStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMI) DeepCopyInto(out *RHMI) {
+func (in *CustomSmtpStatus) DeepCopyInto(out *CustomSmtpStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Probe != nil {
+		in, out := &in.Probe, &out.Probe
+		*out = new(SMTPProbeStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMI.
-func (in *RHMI) DeepCopy() *RHMI {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomSmtpStatus.
+func (in *CustomSmtpStatus) DeepCopy() *CustomSmtpStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMI)
+	out := new(CustomSmtpStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RHMI) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSRecordStatus) DeepCopyInto(out *DNSRecordStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSRecordStatus.
+func (in *DNSRecordStatus) DeepCopy() *DNSRecordStatus {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(DNSRecordStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMIList) DeepCopyInto(out *RHMIList) {
+func (in *DNSTXTRecord) DeepCopyInto(out *DNSTXTRecord) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]RHMI, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIList.
-func (in *RHMIList) DeepCopy() *RHMIList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSTXTRecord.
+func (in *DNSTXTRecord) DeepCopy() *DNSTXTRecord {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMIList)
+	out := new(DNSTXTRecord)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *RHMIList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardsSpec) DeepCopyInto(out *DashboardsSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	return nil
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardsSpec.
+func (in *DashboardsSpec) DeepCopy() *DashboardsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardsSpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMIProductStatus) DeepCopyInto(out *RHMIProductStatus) {
+func (in *DataSourceSpec) DeepCopyInto(out *DataSourceSpec) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIProductStatus.
-func (in *RHMIProductStatus) DeepCopy() *RHMIProductStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSourceSpec.
+func (in *DataSourceSpec) DeepCopy() *DataSourceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMIProductStatus)
+	out := new(DataSourceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMISpec) DeepCopyInto(out *RHMISpec) {
+func (in *EmailReceiverSpec) DeepCopyInto(out *EmailReceiverSpec) {
 	*out = *in
-	out.PullSecret = in.PullSecret
-	out.AlertingEmailAddresses = in.AlertingEmailAddresses
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMISpec.
-func (in *RHMISpec) DeepCopy() *RHMISpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailReceiverSpec.
+func (in *EmailReceiverSpec) DeepCopy() *EmailReceiverSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMISpec)
+	out := new(EmailReceiverSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMIStageStatus) DeepCopyInto(out *RHMIStageStatus) {
+func (in *EndpointHealthStatus) DeepCopyInto(out *EndpointHealthStatus) {
 	*out = *in
-	if in.Products != nil {
-		in, out := &in.Products, &out.Products
-		*out = make(map[ProductName]RHMIProductStatus, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.Latency != nil {
+		in, out := &in.Latency, &out.Latency
+		*out = new(metav1.Duration)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIStageStatus.
-func (in *RHMIStageStatus) DeepCopy() *RHMIStageStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointHealthStatus.
+func (in *EndpointHealthStatus) DeepCopy() *EndpointHealthStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMIStageStatus)
+	out := new(EndpointHealthStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RHMIStatus) DeepCopyInto(out *RHMIStatus) {
+func (in *ExternalSecretSpec) DeepCopyInto(out *ExternalSecretSpec) {
 	*out = *in
-	if in.Stages != nil {
-		in, out := &in.Stages, &out.Stages
-		*out = make(map[StageName]RHMIStageStatus, len(*in))
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
-			(*out)[key] = *val.DeepCopy()
+			(*out)[key] = val
 		}
 	}
-	if in.CustomSmtp != nil {
-		in, out := &in.CustomSmtp, &out.CustomSmtp
-		*out = new(CustomSmtpStatus)
-		**out = **in
-	}
-	if in.CustomDomain != nil {
-		in, out := &in.CustomDomain, &out.CustomDomain
-		*out = new(CustomDomainStatus)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIStatus.
-func (in *RHMIStatus) DeepCopy() *RHMIStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretSpec.
+func (in *ExternalSecretSpec) DeepCopy() *ExternalSecretSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RHMIStatus)
+	out := new(ExternalSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretStatus) DeepCopyInto(out *ExternalSecretStatus) {
+	*out = *in
+	if in.LastSynced != nil {
+		in, out := &in.LastSynced, &out.LastSynced
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretStatus.
+func (in *ExternalSecretStatus) DeepCopy() *ExternalSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretStoreRef) DeepCopyInto(out *ExternalSecretStoreRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretStoreRef.
+func (in *ExternalSecretStoreRef) DeepCopy() *ExternalSecretStoreRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretStoreRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretsSpec) DeepCopyInto(out *ExternalSecretsSpec) {
+	*out = *in
+	out.SecretStore = in.SecretStore
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]ExternalSecretSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretsSpec.
+func (in *ExternalSecretsSpec) DeepCopy() *ExternalSecretsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FIPSSpec) DeepCopyInto(out *FIPSSpec) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FIPSSpec.
+func (in *FIPSSpec) DeepCopy() *FIPSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FIPSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FIPSStatus) DeepCopyInto(out *FIPSStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FIPSStatus.
+func (in *FIPSStatus) DeepCopy() *FIPSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FIPSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeartbeatSpec) DeepCopyInto(out *HeartbeatSpec) {
+	*out = *in
+	in.URLSecret.DeepCopyInto(&out.URLSecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeartbeatSpec.
+func (in *HeartbeatSpec) DeepCopy() *HeartbeatSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeartbeatSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderMapperSpec) DeepCopyInto(out *IdentityProviderMapperSpec) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderMapperSpec.
+func (in *IdentityProviderMapperSpec) DeepCopy() *IdentityProviderMapperSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderMapperSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IdentityProviderStatus) DeepCopyInto(out *IdentityProviderStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IdentityProviderStatus.
+func (in *IdentityProviderStatus) DeepCopy() *IdentityProviderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IdentityProviderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImportedDashboardStatus) DeepCopyInto(out *ImportedDashboardStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportedDashboardStatus.
+func (in *ImportedDashboardStatus) DeepCopy() *ImportedDashboardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImportedDashboardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWTProviderSpec) DeepCopyInto(out *JWTProviderSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClockSkewSeconds != nil {
+		in, out := &in.ClockSkewSeconds, &out.ClockSkewSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWTProviderSpec.
+func (in *JWTProviderSpec) DeepCopy() *JWTProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JWTProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobWatchdogSpec) DeepCopyInto(out *JobWatchdogSpec) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobWatchdogSpec.
+func (in *JobWatchdogSpec) DeepCopy() *JobWatchdogSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(JobWatchdogSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakThemeSpec) DeepCopyInto(out *KeycloakThemeSpec) {
+	*out = *in
+	if in.Types != nil {
+		in, out := &in.Types, &out.Types
+		*out = make([]KeycloakThemeType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakThemeSpec.
+func (in *KeycloakThemeSpec) DeepCopy() *KeycloakThemeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakThemeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPFederationStatus) DeepCopyInto(out *LDAPFederationStatus) {
+	*out = *in
+	if in.LastSync != nil {
+		in, out := &in.LastSync, &out.LastSync
+		*out = (*in).DeepCopy()
+	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPFederationStatus.
+func (in *LDAPFederationStatus) DeepCopy() *LDAPFederationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPFederationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPMapperSpec) DeepCopyInto(out *LDAPMapperSpec) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPMapperSpec.
+func (in *LDAPMapperSpec) DeepCopy() *LDAPMapperSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPMapperSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPSyncScheduleSpec) DeepCopyInto(out *LDAPSyncScheduleSpec) {
+	*out = *in
+	if in.FullSyncPeriod != nil {
+		in, out := &in.FullSyncPeriod, &out.FullSyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ChangedUsersSyncPeriod != nil {
+		in, out := &in.ChangedUsersSyncPeriod, &out.ChangedUsersSyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPSyncScheduleSpec.
+func (in *LDAPSyncScheduleSpec) DeepCopy() *LDAPSyncScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPSyncScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogForwardingSpec) DeepCopyInto(out *LogForwardingSpec) {
+	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]LogOutputSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]LogSourceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogForwardingSpec.
+func (in *LogForwardingSpec) DeepCopy() *LogForwardingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogForwardingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogOutputSpec) DeepCopyInto(out *LogOutputSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogOutputSpec.
+func (in *LogOutputSpec) DeepCopy() *LogOutputSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogOutputSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSourceSpec) DeepCopyInto(out *LogSourceSpec) {
+	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSourceSpec.
+func (in *LogSourceSpec) DeepCopy() *LogSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceModeSpec) DeepCopyInto(out *MaintenanceModeSpec) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceModeSpec.
+func (in *MaintenanceModeSpec) DeepCopy() *MaintenanceModeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceModeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindowSpec) DeepCopyInto(out *MaintenanceWindowSpec) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindowSpec.
+func (in *MaintenanceWindowSpec) DeepCopy() *MaintenanceWindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeteringSpec) DeepCopyInto(out *MeteringSpec) {
+	*out = *in
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(S3CompatibleStorageSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeteringSpec.
+func (in *MeteringSpec) DeepCopy() *MeteringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MeteringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilitySpec) DeepCopyInto(out *ObservabilitySpec) {
+	*out = *in
+	if in.RemoteWrite != nil {
+		in, out := &in.RemoteWrite, &out.RemoteWrite
+		*out = make([]RemoteWriteSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilitySpec.
+func (in *ObservabilitySpec) DeepCopy() *ObservabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpsGenieReceiverSpec) DeepCopyInto(out *OpsGenieReceiverSpec) {
+	*out = *in
+	in.APIKeySecret.DeepCopyInto(&out.APIKeySecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpsGenieReceiverSpec.
+func (in *OpsGenieReceiverSpec) DeepCopy() *OpsGenieReceiverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OpsGenieReceiverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrphanedResource) DeepCopyInto(out *OrphanedResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrphanedResource.
+func (in *OrphanedResource) DeepCopy() *OrphanedResource {
+	if in == nil {
+		return nil
+	}
+	out := new(OrphanedResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PagerDutyReceiverSpec) DeepCopyInto(out *PagerDutyReceiverSpec) {
+	*out = *in
+	in.RoutingKeySecret.DeepCopyInto(&out.RoutingKeySecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PagerDutyReceiverSpec.
+func (in *PagerDutyReceiverSpec) DeepCopy() *PagerDutyReceiverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PagerDutyReceiverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordPolicySpec) DeepCopyInto(out *PasswordPolicySpec) {
+	*out = *in
+	if in.BruteForceDetection != nil {
+		in, out := &in.BruteForceDetection, &out.BruteForceDetection
+		*out = new(BruteForceDetectionSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordPolicySpec.
+func (in *PasswordPolicySpec) DeepCopy() *PasswordPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpec.
+func (in *PlacementSpec) DeepCopy() *PlacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanLimitSpec) DeepCopyInto(out *PlanLimitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanLimitSpec.
+func (in *PlanLimitSpec) DeepCopy() *PlanLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSecuritySpec) DeepCopyInto(out *PodSecuritySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSecuritySpec.
+func (in *PodSecuritySpec) DeepCopy() *PodSecuritySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSecuritySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeBackupSpec) DeepCopyInto(out *PreUpgradeBackupSpec) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeBackupSpec.
+func (in *PreUpgradeBackupSpec) DeepCopy() *PreUpgradeBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeBackupStatus) DeepCopyInto(out *PreUpgradeBackupStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make([]BackupArtifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeBackupStatus.
+func (in *PreUpgradeBackupStatus) DeepCopy() *PreUpgradeBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProductOverrideSpec) DeepCopyInto(out *ProductOverrideSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProductOverrideSpec.
+func (in *ProductOverrideSpec) DeepCopy() *ProductOverrideSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProductOverrideSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProductReconcileSpec) DeepCopyInto(out *ProductReconcileSpec) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProductReconcileSpec.
+func (in *ProductReconcileSpec) DeepCopy() *ProductReconcileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProductReconcileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProductUpgradeStatus) DeepCopyInto(out *ProductUpgradeStatus) {
+	*out = *in
+	if in.VerifyingSince != nil {
+		in, out := &in.VerifyingSince, &out.VerifyingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Problems != nil {
+		in, out := &in.Problems, &out.Problems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProductUpgradeStatus.
+func (in *ProductUpgradeStatus) DeepCopy() *ProductUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProductUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProgressiveUpgradeSpec) DeepCopyInto(out *ProgressiveUpgradeSpec) {
+	*out = *in
+	if in.ProbeTimeout != nil {
+		in, out := &in.ProbeTimeout, &out.ProbeTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProgressiveUpgradeSpec.
+func (in *ProgressiveUpgradeSpec) DeepCopy() *ProgressiveUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressiveUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PullSecretSpec) DeepCopyInto(out *PullSecretSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PullSecretSpec.
+func (in *PullSecretSpec) DeepCopy() *PullSecretSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PullSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Quota) DeepCopyInto(out *Quota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Quota.
+func (in *Quota) DeepCopy() *Quota {
+	if in == nil {
+		return nil
+	}
+	out := new(Quota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Quota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaList) DeepCopyInto(out *QuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Quota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaList.
+func (in *QuotaList) DeepCopy() *QuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaRateLimitSpec) DeepCopyInto(out *QuotaRateLimitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaRateLimitSpec.
+func (in *QuotaRateLimitSpec) DeepCopy() *QuotaRateLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaRateLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaResourceSpec) DeepCopyInto(out *QuotaResourceSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaResourceSpec.
+func (in *QuotaResourceSpec) DeepCopy() *QuotaResourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaResourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSpec) DeepCopyInto(out *QuotaSpec) {
+	*out = *in
+	out.RateLimit = in.RateLimit
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]QuotaResourceSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSpec.
+func (in *QuotaSpec) DeepCopy() *QuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaStatus) DeepCopyInto(out *QuotaStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaStatus.
+func (in *QuotaStatus) DeepCopy() *QuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaTransitionSpec) DeepCopyInto(out *QuotaTransitionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaTransitionSpec.
+func (in *QuotaTransitionSpec) DeepCopy() *QuotaTransitionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaTransitionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaTransitionStatus) DeepCopyInto(out *QuotaTransitionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaTransitionStatus.
+func (in *QuotaTransitionStatus) DeepCopy() *QuotaTransitionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaTransitionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMI) DeepCopyInto(out *RHMI) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMI.
+func (in *RHMI) DeepCopy() *RHMI {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RHMI) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMIList) DeepCopyInto(out *RHMIList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RHMI, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIList.
+func (in *RHMIList) DeepCopy() *RHMIList {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMIList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RHMIList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMIProductStatus) DeepCopyInto(out *RHMIProductStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreUpgradeBackup != nil {
+		in, out := &in.PreUpgradeBackup, &out.PreUpgradeBackup
+		*out = new(PreUpgradeBackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FIPS != nil {
+		in, out := &in.FIPS, &out.FIPS
+		*out = new(FIPSStatus)
+		**out = **in
+	}
+	if in.SecretRotations != nil {
+		in, out := &in.SecretRotations, &out.SecretRotations
+		*out = make([]SecretRotationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IdentityProvider != nil {
+		in, out := &in.IdentityProvider, &out.IdentityProvider
+		*out = new(IdentityProviderStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LDAPFederation != nil {
+		in, out := &in.LDAPFederation, &out.LDAPFederation
+		*out = new(LDAPFederationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIProductStatus.
+func (in *RHMIProductStatus) DeepCopy() *RHMIProductStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMIProductStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMISpec) DeepCopyInto(out *RHMISpec) {
+	*out = *in
+	out.PullSecret = in.PullSecret
+	out.AlertingEmailAddresses = in.AlertingEmailAddresses
+	if in.MaintenanceMode != nil {
+		in, out := &in.MaintenanceMode, &out.MaintenanceMode
+		*out = new(MaintenanceModeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ThreeScaleFileStorage != nil {
+		in, out := &in.ThreeScaleFileStorage, &out.ThreeScaleFileStorage
+		*out = new(S3CompatibleStorageSpec)
+		**out = **in
+	}
+	if in.UserSSOPasswordPolicy != nil {
+		in, out := &in.UserSSOPasswordPolicy, &out.UserSSOPasswordPolicy
+		*out = new(PasswordPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserSSOIdentityProvider != nil {
+		in, out := &in.UserSSOIdentityProvider, &out.UserSSOIdentityProvider
+		*out = new(UserSSOIdentityProviderSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UserSSOLDAPFederation != nil {
+		in, out := &in.UserSSOLDAPFederation, &out.UserSSOLDAPFederation
+		*out = new(UserSSOLDAPFederationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KeycloakTheme != nil {
+		in, out := &in.KeycloakTheme, &out.KeycloakTheme
+		*out = new(KeycloakThemeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JobWatchdog != nil {
+		in, out := &in.JobWatchdog, &out.JobWatchdog
+		*out = new(JobWatchdogSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomDomainDNS != nil {
+		in, out := &in.CustomDomainDNS, &out.CustomDomainDNS
+		*out = new(CustomDomainDNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomDomain != nil {
+		in, out := &in.CustomDomain, &out.CustomDomain
+		*out = new(CustomDomainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SMTPProvider != nil {
+		in, out := &in.SMTPProvider, &out.SMTPProvider
+		*out = new(SMTPProviderSpec)
+		**out = **in
+	}
+	if in.SMTPRelay != nil {
+		in, out := &in.SMTPRelay, &out.SMTPRelay
+		*out = new(SMTPRelaySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Alerting != nil {
+		in, out := &in.Alerting, &out.Alerting
+		*out = new(AlertingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Observability != nil {
+		in, out := &in.Observability, &out.Observability
+		*out = new(ObservabilitySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogForwarding != nil {
+		in, out := &in.LogForwarding, &out.LogForwarding
+		*out = new(LogForwardingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = new(ExternalSecretsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(TracingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SLOs != nil {
+		in, out := &in.SLOs, &out.SLOs
+		*out = make([]SLOSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Dashboards != nil {
+		in, out := &in.Dashboards, &out.Dashboards
+		*out = new(DashboardsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataSources != nil {
+		in, out := &in.DataSources, &out.DataSources
+		*out = make([]DataSourceSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.Metering != nil {
+		in, out := &in.Metering, &out.Metering
+		*out = new(MeteringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GatewayCORSPolicies != nil {
+		in, out := &in.GatewayCORSPolicies, &out.GatewayCORSPolicies
+		*out = make([]CORSPolicySpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GatewayJWTProviders != nil {
+		in, out := &in.GatewayJWTProviders, &out.GatewayJWTProviders
+		*out = make([]JWTProviderSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GatewayTrafficSplits != nil {
+		in, out := &in.GatewayTrafficSplits, &out.GatewayTrafficSplits
+		*out = make([]TrafficSplitSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceWindowSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Telemetry != nil {
+		in, out := &in.Telemetry, &out.Telemetry
+		*out = new(TelemetrySpec)
+		**out = **in
+	}
+	if in.RealmExport != nil {
+		in, out := &in.RealmExport, &out.RealmExport
+		*out = new(RealmExportSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplicationPlanTemplates != nil {
+		in, out := &in.ApplicationPlanTemplates, &out.ApplicationPlanTemplates
+		*out = make([]ApplicationPlanTemplateSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Products != nil {
+		in, out := &in.Products, &out.Products
+		*out = make([]ProductOverrideSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Reconcile != nil {
+		in, out := &in.Reconcile, &out.Reconcile
+		*out = new(ReconcileSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceOverrides != nil {
+		in, out := &in.ResourceOverrides, &out.ResourceOverrides
+		*out = make([]ResourceOverrideSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(PlacementSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(PodSecuritySpec)
+		**out = **in
+	}
+	if in.FIPS != nil {
+		in, out := &in.FIPS, &out.FIPS
+		*out = new(FIPSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretRotation != nil {
+		in, out := &in.SecretRotation, &out.SecretRotation
+		*out = new(SecretRotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.QuotaTransition != nil {
+		in, out := &in.QuotaTransition, &out.QuotaTransition
+		*out = new(QuotaTransitionSpec)
+		**out = **in
+	}
+	if in.ProgressiveUpgrade != nil {
+		in, out := &in.ProgressiveUpgrade, &out.ProgressiveUpgrade
+		*out = new(ProgressiveUpgradeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreUpgradeBackup != nil {
+		in, out := &in.PreUpgradeBackup, &out.PreUpgradeBackup
+		*out = new(PreUpgradeBackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMISpec.
+func (in *RHMISpec) DeepCopy() *RHMISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMIStageStatus) DeepCopyInto(out *RHMIStageStatus) {
+	*out = *in
+	if in.Products != nil {
+		in, out := &in.Products, &out.Products
+		*out = make(map[ProductName]RHMIProductStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIStageStatus.
+func (in *RHMIStageStatus) DeepCopy() *RHMIStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMIStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RHMIStatus) DeepCopyInto(out *RHMIStatus) {
+	*out = *in
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make(map[StageName]RHMIStageStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.CustomSmtp != nil {
+		in, out := &in.CustomSmtp, &out.CustomSmtp
+		*out = new(CustomSmtpStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SMTPRelay != nil {
+		in, out := &in.SMTPRelay, &out.SMTPRelay
+		*out = new(SMTPRelayStatus)
+		**out = **in
+	}
+	if in.CustomDomain != nil {
+		in, out := &in.CustomDomain, &out.CustomDomain
+		*out = new(CustomDomainStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceOverrides != nil {
+		in, out := &in.ResourceOverrides, &out.ResourceOverrides
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.QuotaTransition != nil {
+		in, out := &in.QuotaTransition, &out.QuotaTransition
+		*out = new(QuotaTransitionStatus)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomRoutes != nil {
+		in, out := &in.CustomRoutes, &out.CustomRoutes
+		*out = make([]CustomRouteStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExternalSecrets != nil {
+		in, out := &in.ExternalSecrets, &out.ExternalSecrets
+		*out = make([]ExternalSecretStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImportedDashboards != nil {
+		in, out := &in.ImportedDashboards, &out.ImportedDashboards
+		*out = make([]ImportedDashboardStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.EndpointHealth != nil {
+		in, out := &in.EndpointHealth, &out.EndpointHealth
+		*out = make([]EndpointHealthStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RouteVerification != nil {
+		in, out := &in.RouteVerification, &out.RouteVerification
+		*out = new(RouteVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UninstallReport != nil {
+		in, out := &in.UninstallReport, &out.UninstallReport
+		*out = new(UninstallReport)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RHMIStatus.
+func (in *RHMIStatus) DeepCopy() *RHMIStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RHMIStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmClientSpec) DeepCopyInto(out *RealmClientSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RedirectURIs != nil {
+		in, out := &in.RedirectURIs, &out.RedirectURIs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WebOrigins != nil {
+		in, out := &in.WebOrigins, &out.WebOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StandardFlowEnabled != nil {
+		in, out := &in.StandardFlowEnabled, &out.StandardFlowEnabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmClientSpec.
+func (in *RealmClientSpec) DeepCopy() *RealmClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmCustomization) DeepCopyInto(out *RealmCustomization) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmCustomization.
+func (in *RealmCustomization) DeepCopy() *RealmCustomization {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmCustomization)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RealmCustomization) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmCustomizationList) DeepCopyInto(out *RealmCustomizationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RealmCustomization, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmCustomizationList.
+func (in *RealmCustomizationList) DeepCopy() *RealmCustomizationList {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmCustomizationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RealmCustomizationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmCustomizationSpec) DeepCopyInto(out *RealmCustomizationSpec) {
+	*out = *in
+	if in.IdentityProviders != nil {
+		in, out := &in.IdentityProviders, &out.IdentityProviders
+		*out = make([]RealmIdentityProviderSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clients != nil {
+		in, out := &in.Clients, &out.Clients
+		*out = make([]RealmClientSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RequiredActions != nil {
+		in, out := &in.RequiredActions, &out.RequiredActions
+		*out = make([]RealmRequiredActionSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmCustomizationSpec.
+func (in *RealmCustomizationSpec) DeepCopy() *RealmCustomizationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmCustomizationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmCustomizationStatus) DeepCopyInto(out *RealmCustomizationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmCustomizationStatus.
+func (in *RealmCustomizationStatus) DeepCopy() *RealmCustomizationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmCustomizationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmExportSpec) DeepCopyInto(out *RealmExportSpec) {
+	*out = *in
+	out.Storage = in.Storage
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = new(RealmImportSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmExportSpec.
+func (in *RealmExportSpec) DeepCopy() *RealmExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmIdentityProviderSpec) DeepCopyInto(out *RealmIdentityProviderSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmIdentityProviderSpec.
+func (in *RealmIdentityProviderSpec) DeepCopy() *RealmIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmImportSpec) DeepCopyInto(out *RealmImportSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmImportSpec.
+func (in *RealmImportSpec) DeepCopy() *RealmImportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmImportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RealmRequiredActionSpec) DeepCopyInto(out *RealmRequiredActionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RealmRequiredActionSpec.
+func (in *RealmRequiredActionSpec) DeepCopy() *RealmRequiredActionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RealmRequiredActionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileSpec) DeepCopyInto(out *ReconcileSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxErrorBackoff != nil {
+		in, out := &in.MaxErrorBackoff, &out.MaxErrorBackoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxConcurrentProducts != nil {
+		in, out := &in.MaxConcurrentProducts, &out.MaxConcurrentProducts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Products != nil {
+		in, out := &in.Products, &out.Products
+		*out = make([]ProductReconcileSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileSpec.
+func (in *ReconcileSpec) DeepCopy() *ReconcileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteBasicAuth) DeepCopyInto(out *RemoteWriteBasicAuth) {
+	*out = *in
+	in.UsernameSecret.DeepCopyInto(&out.UsernameSecret)
+	in.PasswordSecret.DeepCopyInto(&out.PasswordSecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteBasicAuth.
+func (in *RemoteWriteBasicAuth) DeepCopy() *RemoteWriteBasicAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteBasicAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteRelabelConfig) DeepCopyInto(out *RemoteWriteRelabelConfig) {
+	*out = *in
+	if in.SourceLabels != nil {
+		in, out := &in.SourceLabels, &out.SourceLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteRelabelConfig.
+func (in *RemoteWriteRelabelConfig) DeepCopy() *RemoteWriteRelabelConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteRelabelConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteSpec) DeepCopyInto(out *RemoteWriteSpec) {
+	*out = *in
+	if in.BasicAuth != nil {
+		in, out := &in.BasicAuth, &out.BasicAuth
+		*out = new(RemoteWriteBasicAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BearerTokenSecret != nil {
+		in, out := &in.BearerTokenSecret, &out.BearerTokenSecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetricAllowlist != nil {
+		in, out := &in.MetricAllowlist, &out.MetricAllowlist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.WriteRelabelConfigs != nil {
+		in, out := &in.WriteRelabelConfigs, &out.WriteRelabelConfigs
+		*out = make([]RemoteWriteRelabelConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteSpec.
+func (in *RemoteWriteSpec) DeepCopy() *RemoteWriteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceOverrideSpec) DeepCopyInto(out *ResourceOverrideSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceOverrideSpec.
+func (in *ResourceOverrideSpec) DeepCopy() *ResourceOverrideSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceOverrideSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteVerificationStatus) DeepCopyInto(out *RouteVerificationStatus) {
+	*out = *in
+	in.LastVerified.DeepCopyInto(&out.LastVerified)
+	if in.Problems != nil {
+		in, out := &in.Problems, &out.Problems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteVerificationStatus.
+func (in *RouteVerificationStatus) DeepCopy() *RouteVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3CompatibleStorageSpec) DeepCopyInto(out *S3CompatibleStorageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3CompatibleStorageSpec.
+func (in *S3CompatibleStorageSpec) DeepCopy() *S3CompatibleStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3CompatibleStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SLOSpec) DeepCopyInto(out *SLOSpec) {
+	*out = *in
+	if in.LatencyThreshold != nil {
+		in, out := &in.LatencyThreshold, &out.LatencyThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SLOSpec.
+func (in *SLOSpec) DeepCopy() *SLOSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SLOSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPProbeStatus) DeepCopyInto(out *SMTPProbeStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPProbeStatus.
+func (in *SMTPProbeStatus) DeepCopy() *SMTPProbeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPProbeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPProviderSpec) DeepCopyInto(out *SMTPProviderSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPProviderSpec.
+func (in *SMTPProviderSpec) DeepCopy() *SMTPProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPRelaySpec) DeepCopyInto(out *SMTPRelaySpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPRelaySpec.
+func (in *SMTPRelaySpec) DeepCopy() *SMTPRelaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPRelaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SMTPRelayStatus) DeepCopyInto(out *SMTPRelayStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SMTPRelayStatus.
+func (in *SMTPRelayStatus) DeepCopy() *SMTPRelayStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SMTPRelayStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRotationSpec) DeepCopyInto(out *SecretRotationSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRotationSpec.
+func (in *SecretRotationSpec) DeepCopy() *SecretRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRotationStatus) DeepCopyInto(out *SecretRotationStatus) {
+	*out = *in
+	in.LastRotated.DeepCopyInto(&out.LastRotated)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRotationStatus.
+func (in *SecretRotationStatus) DeepCopy() *SecretRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SlackReceiverSpec) DeepCopyInto(out *SlackReceiverSpec) {
+	*out = *in
+	in.WebhookURLSecret.DeepCopyInto(&out.WebhookURLSecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SlackReceiverSpec.
+func (in *SlackReceiverSpec) DeepCopy() *SlackReceiverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SlackReceiverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelemetrySpec) DeepCopyInto(out *TelemetrySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetrySpec.
+func (in *TelemetrySpec) DeepCopy() *TelemetrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TelemetrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantBillingSpec) DeepCopyInto(out *TenantBillingSpec) {
+	*out = *in
+	if in.PaymentGatewaySecretRef != nil {
+		in, out := &in.PaymentGatewaySecretRef, &out.PaymentGatewaySecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantBillingSpec.
+func (in *TenantBillingSpec) DeepCopy() *TenantBillingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantBillingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantOffboardingSpec) DeepCopyInto(out *TenantOffboardingSpec) {
+	*out = *in
+	out.Export = in.Export
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantOffboardingSpec.
+func (in *TenantOffboardingSpec) DeepCopy() *TenantOffboardingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantOffboardingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantRateLimitSpec) DeepCopyInto(out *TenantRateLimitSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantRateLimitSpec.
+func (in *TenantRateLimitSpec) DeepCopy() *TenantRateLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantRateLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracingSpec) DeepCopyInto(out *TracingSpec) {
+	*out = *in
+	if in.SamplingPercentage != nil {
+		in, out := &in.SamplingPercentage, &out.SamplingPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracingSpec.
+func (in *TracingSpec) DeepCopy() *TracingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSplitMatch) DeepCopyInto(out *TrafficSplitMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSplitMatch.
+func (in *TrafficSplitMatch) DeepCopy() *TrafficSplitMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSplitMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSplitSpec) DeepCopyInto(out *TrafficSplitSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CanaryMatch != nil {
+		in, out := &in.CanaryMatch, &out.CanaryMatch
+		*out = new(TrafficSplitMatch)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSplitSpec.
+func (in *TrafficSplitSpec) DeepCopy() *TrafficSplitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSplitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UninstallReport) DeepCopyInto(out *UninstallReport) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.VerifiedAt != nil {
+		in, out := &in.VerifiedAt, &out.VerifiedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]OrphanedResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Remaining != nil {
+		in, out := &in.Remaining, &out.Remaining
+		*out = make([]OrphanedResource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UninstallReport.
+func (in *UninstallReport) DeepCopy() *UninstallReport {
+	if in == nil {
+		return nil
+	}
+	out := new(UninstallReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+	if in.Products != nil {
+		in, out := &in.Products, &out.Products
+		*out = make([]ProductUpgradeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserSSOIdentityProviderSpec) DeepCopyInto(out *UserSSOIdentityProviderSpec) {
+	*out = *in
+	if in.Mappers != nil {
+		in, out := &in.Mappers, &out.Mappers
+		*out = make([]IdentityProviderMapperSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSSOIdentityProviderSpec.
+func (in *UserSSOIdentityProviderSpec) DeepCopy() *UserSSOIdentityProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UserSSOIdentityProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserSSOLDAPFederationSpec) DeepCopyInto(out *UserSSOLDAPFederationSpec) {
+	*out = *in
+	if in.UserObjectClasses != nil {
+		in, out := &in.UserObjectClasses, &out.UserObjectClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mappers != nil {
+		in, out := &in.Mappers, &out.Mappers
+		*out = make([]LDAPMapperSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SyncSchedule != nil {
+		in, out := &in.SyncSchedule, &out.SyncSchedule
+		*out = new(LDAPSyncScheduleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSSOLDAPFederationSpec.
+func (in *UserSSOLDAPFederationSpec) DeepCopy() *UserSSOLDAPFederationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(UserSSOLDAPFederationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookReceiverSpec) DeepCopyInto(out *WebhookReceiverSpec) {
+	*out = *in
+	in.URLSecret.DeepCopyInto(&out.URLSecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookReceiverSpec.
+func (in *WebhookReceiverSpec) DeepCopy() *WebhookReceiverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookReceiverSpec)
 	in.DeepCopyInto(out)
 	return out
 }
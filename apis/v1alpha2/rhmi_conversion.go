@@ -0,0 +1,178 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"sort"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+// ConvertTo converts to the v1alpha1 hub. The phases are read back from the
+// reasons of the Available conditions
+func (src *RHMI) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.RHMI)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = src.Spec
+	dst.Status = v1alpha1.RHMIStatus{
+		Stage:              src.Status.Stage,
+		PreflightStatus:    src.Status.PreflightStatus,
+		PreflightMessage:   src.Status.PreflightMessage,
+		LastError:          src.Status.LastError,
+		GitHubOAuthEnabled: src.Status.GitHubOAuthEnabled,
+		SMTPEnabled:        src.Status.SMTPEnabled,
+		Version:            src.Status.Version,
+		ToVersion:          src.Status.ToVersion,
+		Quota:              src.Status.Quota,
+		ToQuota:            src.Status.ToQuota,
+		ResourceOverrides:  src.Status.ResourceOverrides,
+		QuotaTransition:    src.Status.QuotaTransition,
+		Upgrade:            src.Status.Upgrade,
+		UninstallReport:    src.Status.UninstallReport,
+		CustomRoutes:       src.Status.CustomRoutes,
+		Certificates:       src.Status.Certificates,
+		CustomSmtp:         src.Status.CustomSmtp,
+		SMTPRelay:          src.Status.SMTPRelay,
+		CustomDomain:       src.Status.CustomDomain,
+		ExternalSecrets:    src.Status.ExternalSecrets,
+		ImportedDashboards: src.Status.ImportedDashboards,
+		EndpointHealth:     src.Status.EndpointHealth,
+		RouteVerification:  src.Status.RouteVerification,
+		Conditions:         src.Status.Conditions,
+	}
+
+	if src.Status.Stages != nil {
+		dst.Status.Stages = make(map[v1alpha1.StageName]v1alpha1.RHMIStageStatus, len(src.Status.Stages))
+	}
+	for _, stage := range src.Status.Stages {
+		dstStage := v1alpha1.RHMIStageStatus{
+			Name:  stage.Name,
+			Phase: v1alpha1.PhaseFromConditions(stage.Conditions),
+		}
+		if stage.Products != nil {
+			dstStage.Products = make(map[v1alpha1.ProductName]v1alpha1.RHMIProductStatus, len(stage.Products))
+		}
+		for _, product := range stage.Products {
+			dstStage.Products[product.Name] = v1alpha1.RHMIProductStatus{
+				Name:             product.Name,
+				OperatorVersion:  product.OperatorVersion,
+				Version:          product.Version,
+				Host:             product.Host,
+				Type:             product.Type,
+				Mobile:           product.Mobile,
+				Phase:            v1alpha1.PhaseFromConditions(product.Conditions),
+				Uninstall:        product.Uninstall,
+				Conditions:       product.Conditions,
+				PreUpgradeBackup: product.PreUpgradeBackup,
+				FIPS:             product.FIPS,
+				SecretRotations:  product.SecretRotations,
+				IdentityProvider: product.IdentityProvider,
+				LDAPFederation:   product.LDAPFederation,
+			}
+		}
+		dst.Status.Stages[stage.Name] = dstStage
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the v1alpha1 hub. Stages and products are sorted
+// by name, and their conditions set from their phases when the hub doesn't
+// have them yet
+func (dst *RHMI) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.RHMI)
+
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = src.Spec
+	dst.Status = RHMIStatus{
+		Stage:              src.Status.Stage,
+		PreflightStatus:    src.Status.PreflightStatus,
+		PreflightMessage:   src.Status.PreflightMessage,
+		LastError:          src.Status.LastError,
+		GitHubOAuthEnabled: src.Status.GitHubOAuthEnabled,
+		SMTPEnabled:        src.Status.SMTPEnabled,
+		Version:            src.Status.Version,
+		ToVersion:          src.Status.ToVersion,
+		Quota:              src.Status.Quota,
+		ToQuota:            src.Status.ToQuota,
+		ResourceOverrides:  src.Status.ResourceOverrides,
+		QuotaTransition:    src.Status.QuotaTransition,
+		Upgrade:            src.Status.Upgrade,
+		UninstallReport:    src.Status.UninstallReport,
+		CustomRoutes:       src.Status.CustomRoutes,
+		Certificates:       src.Status.Certificates,
+		CustomSmtp:         src.Status.CustomSmtp,
+		SMTPRelay:          src.Status.SMTPRelay,
+		CustomDomain:       src.Status.CustomDomain,
+		ExternalSecrets:    src.Status.ExternalSecrets,
+		ImportedDashboards: src.Status.ImportedDashboards,
+		EndpointHealth:     src.Status.EndpointHealth,
+		RouteVerification:  src.Status.RouteVerification,
+		Conditions:         src.Status.Conditions,
+	}
+
+	for _, stage := range src.Status.Stages {
+		dstStage := RHMIStageStatus{
+			Name:       stage.Name,
+			Conditions: phaseConditions(stage.Phase, src.CreationTimestamp),
+		}
+		for _, product := range stage.Products {
+			conditions := product.Conditions
+			if v1alpha1.PhaseFromConditions(conditions) != product.Phase {
+				conditions = phaseConditions(product.Phase, src.CreationTimestamp)
+			}
+			dstStage.Products = append(dstStage.Products, RHMIProductStatus{
+				Name:             product.Name,
+				OperatorVersion:  product.OperatorVersion,
+				Version:          product.Version,
+				Host:             product.Host,
+				Type:             product.Type,
+				Mobile:           product.Mobile,
+				Uninstall:        product.Uninstall,
+				Conditions:       conditions,
+				PreUpgradeBackup: product.PreUpgradeBackup,
+				FIPS:             product.FIPS,
+				SecretRotations:  product.SecretRotations,
+				IdentityProvider: product.IdentityProvider,
+				LDAPFederation:   product.LDAPFederation,
+			})
+		}
+		sort.Slice(dstStage.Products, func(i, j int) bool {
+			return dstStage.Products[i].Name < dstStage.Products[j].Name
+		})
+		dst.Status.Stages = append(dst.Status.Stages, dstStage)
+	}
+	sort.Slice(dst.Status.Stages, func(i, j int) bool {
+		return dst.Status.Stages[i].Name < dst.Status.Stages[j].Name
+	})
+
+	return nil
+}
+
+// phaseConditions returns the conditions of a phase that isn't stored with
+// its conditions in the hub. They have to be set with a transition time to be
+// valid, the creation time of the installation keeps the conversion stable
+func phaseConditions(phase v1alpha1.StatusPhase, since metav1.Time) []metav1.Condition {
+	conditions := v1alpha1.PhaseConditions(phase)
+	for i := range conditions {
+		conditions[i].LastTransitionTime = since
+	}
+	return conditions
+}
@@ -0,0 +1,131 @@
+package v1alpha2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fuzz "github.com/google/gofuzz"
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRHMI_ConvertFrom(t *testing.T) {
+	created := metav1.Now().Rfc3339Copy()
+	hub := &v1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator", CreationTimestamp: created},
+		Spec:       v1alpha1.RHMISpec{Type: string(v1alpha1.InstallationTypeManagedApi)},
+		Status: v1alpha1.RHMIStatus{
+			Stage:   v1alpha1.InstallStage,
+			Version: "1.30.0",
+			Quota:   "1 Million",
+			Stages: map[v1alpha1.StageName]v1alpha1.RHMIStageStatus{
+				v1alpha1.BootstrapStage: {
+					Name:  v1alpha1.BootstrapStage,
+					Phase: v1alpha1.PhaseCompleted,
+				},
+				v1alpha1.InstallStage: {
+					Name:  v1alpha1.InstallStage,
+					Phase: v1alpha1.PhaseInProgress,
+					Products: map[v1alpha1.ProductName]v1alpha1.RHMIProductStatus{
+						v1alpha1.ProductRHSSOUser: {
+							Name:  v1alpha1.ProductRHSSOUser,
+							Host:  "https://keycloak.example.com",
+							Phase: v1alpha1.PhaseFailed,
+						},
+						v1alpha1.Product3Scale: {
+							Name:       v1alpha1.Product3Scale,
+							Version:    "2.13",
+							Phase:      v1alpha1.PhaseCreatingComponents,
+							Conditions: v1alpha1.PhaseConditions(v1alpha1.PhaseCreatingComponents),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	spoke := &RHMI{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spoke.Status.Stages) != 2 || spoke.Status.Stages[0].Name != v1alpha1.BootstrapStage || spoke.Status.Stages[1].Name != v1alpha1.InstallStage {
+		t.Fatalf("expected the stages sorted by name, got %+v", spoke.Status.Stages)
+	}
+	install := spoke.Status.Stages[1]
+	if !meta.IsStatusConditionTrue(install.Conditions, v1alpha1.ProgressingConditionType.String()) {
+		t.Errorf("expected the install stage to be progressing, got %+v", install.Conditions)
+	}
+	if len(install.Products) != 2 || install.Products[0].Name != v1alpha1.Product3Scale {
+		t.Fatalf("expected the products sorted by name, got %+v", install.Products)
+	}
+	rhssoUser := install.Products[1]
+	if !meta.IsStatusConditionTrue(rhssoUser.Conditions, v1alpha1.DegradedConditionType.String()) {
+		t.Errorf("expected user SSO to be degraded, got %+v", rhssoUser.Conditions)
+	}
+	for _, condition := range rhssoUser.Conditions {
+		if !condition.LastTransitionTime.Equal(&created) {
+			t.Errorf("expected the conditions set from the phase to transition at the creation time, got %v", condition.LastTransitionTime)
+		}
+	}
+}
+
+// TestRHMI_ConversionRoundTrip converts fuzzed hubs to v1alpha2 and back, so a
+// field of the hub that isn't converted fails the test
+func TestRHMI_ConversionRoundTrip(t *testing.T) {
+	phases := []v1alpha1.StatusPhase{
+		v1alpha1.PhaseNone,
+		v1alpha1.PhaseAwaitingOperator,
+		v1alpha1.PhaseCreatingComponents,
+		v1alpha1.PhaseInProgress,
+		v1alpha1.PhaseCompleted,
+		v1alpha1.PhaseFailed,
+		v1alpha1.PhaseSkipped,
+	}
+
+	for seed := int64(0); seed < 100; seed++ {
+		f := fuzz.NewWithSeed(seed).NilChance(0.2).NumElements(1, 3)
+		hub := &v1alpha1.RHMI{}
+		f.Fuzz(hub)
+		hub.TypeMeta = metav1.TypeMeta{}
+
+		// the stages and products are keyed by name, their phases are stored
+		// as conditions, with the conditions of the products when they match
+		// the phase
+		stages := map[v1alpha1.StageName]v1alpha1.RHMIStageStatus{}
+		for stageName, stage := range hub.Status.Stages {
+			stage.Name = stageName
+			stage.Phase = phases[len(stage.Phase)%len(phases)]
+			products := map[v1alpha1.ProductName]v1alpha1.RHMIProductStatus{}
+			for productName, product := range stage.Products {
+				product.Name = productName
+				product.Phase = phases[len(product.Phase)%len(phases)]
+				product.Conditions = phaseConditions(product.Phase, hub.CreationTimestamp)
+				products[productName] = product
+			}
+			stage.Products = nil
+			if len(products) > 0 {
+				stage.Products = products
+			}
+			stages[stageName] = stage
+		}
+		hub.Status.Stages = nil
+		if len(stages) > 0 {
+			hub.Status.Stages = stages
+		}
+
+		spoke := &RHMI{}
+		if err := spoke.ConvertFrom(hub.DeepCopy()); err != nil {
+			t.Fatal(err)
+		}
+		roundTrip := &v1alpha1.RHMI{}
+		if err := spoke.ConvertTo(roundTrip); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(roundTrip, hub) {
+			t.Fatalf("seed %d: expected the installation to round trip, diff: %s", seed, cmp.Diff(hub, roundTrip))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RHMIStatus reports the progress of the installation, its stages and its
+// products with Available, Progressing and Degraded conditions instead of
+// phases
+type RHMIStatus struct {
+	// +listType=map
+	// +listMapKey=name
+	Stages             []RHMIStageStatus                  `json:"stages,omitempty"`
+	Stage              v1alpha1.StageName                 `json:"stage,omitempty"`
+	PreflightStatus    v1alpha1.PreflightStatus           `json:"preflightStatus,omitempty"`
+	PreflightMessage   string                             `json:"preflightMessage,omitempty"`
+	LastError          string                             `json:"lastError,omitempty"`
+	GitHubOAuthEnabled bool                               `json:"gitHubOAuthEnabled,omitempty"`
+	SMTPEnabled        bool                               `json:"smtpEnabled,omitempty"`
+	Version            string                             `json:"version,omitempty"`
+	ToVersion          string                             `json:"toVersion,omitempty"`
+	Quota              string                             `json:"quota,omitempty"`
+	ToQuota            string                             `json:"toQuota,omitempty"`
+	ResourceOverrides  []string                           `json:"resourceOverrides,omitempty"`
+	QuotaTransition    *v1alpha1.QuotaTransitionStatus    `json:"quotaTransition,omitempty"`
+	Upgrade            *v1alpha1.UpgradeStatus            `json:"upgrade,omitempty"`
+	CustomRoutes       []v1alpha1.CustomRouteStatus       `json:"customRoutes,omitempty"`
+	Certificates       []v1alpha1.CertificateStatus       `json:"certificates,omitempty"`
+	CustomSmtp         *v1alpha1.CustomSmtpStatus         `json:"customSmtp,omitempty"`
+	SMTPRelay          *v1alpha1.SMTPRelayStatus          `json:"smtpRelay,omitempty"`
+	CustomDomain       *v1alpha1.CustomDomainStatus       `json:"customDomain,omitempty"`
+	UninstallReport    *v1alpha1.UninstallReport          `json:"uninstallReport,omitempty"`
+	ExternalSecrets    []v1alpha1.ExternalSecretStatus    `json:"externalSecrets,omitempty"`
+	ImportedDashboards []v1alpha1.ImportedDashboardStatus `json:"importedDashboards,omitempty"`
+	EndpointHealth     []v1alpha1.EndpointHealthStatus    `json:"endpointHealth,omitempty"`
+	RouteVerification  *v1alpha1.RouteVerificationStatus  `json:"routeVerification,omitempty"`
+	Conditions         []metav1.Condition                 `json:"conditions,omitempty"`
+}
+
+type RHMIStageStatus struct {
+	Name       v1alpha1.StageName `json:"name"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// +listType=map
+	// +listMapKey=name
+	Products []RHMIProductStatus `json:"products,omitempty"`
+}
+
+type RHMIProductStatus struct {
+	Name             v1alpha1.ProductName             `json:"name"`
+	OperatorVersion  v1alpha1.OperatorVersion         `json:"operator,omitempty"`
+	Version          v1alpha1.ProductVersion          `json:"version,omitempty"`
+	Host             string                           `json:"host,omitempty"`
+	Type             string                           `json:"type,omitempty"`
+	Mobile           bool                             `json:"mobile,omitempty"`
+	Uninstall        bool                             `json:"uninstall,omitempty"`
+	Conditions       []metav1.Condition               `json:"conditions,omitempty"`
+	PreUpgradeBackup *v1alpha1.PreUpgradeBackupStatus `json:"preUpgradeBackup,omitempty"`
+	FIPS             *v1alpha1.FIPSStatus             `json:"fips,omitempty"`
+	SecretRotations  []v1alpha1.SecretRotationStatus  `json:"secretRotations,omitempty"`
+	IdentityProvider *v1alpha1.IdentityProviderStatus `json:"identityProvider,omitempty"`
+	LDAPFederation   *v1alpha1.LDAPFederationStatus   `json:"ldapFederation,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// RHMI is the Schema for the rhmis API
+type RHMI struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   v1alpha1.RHMISpec `json:"spec,omitempty"`
+	Status RHMIStatus        `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RHMIList contains a list of RHMI
+type RHMIList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RHMI `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RHMI{}, &RHMIList{})
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// errorBackoff is the exponential per item backoff of the failed reconciles.
+// Unlike the workqueue one its cap can be changed while the controller runs,
+// so it follows the spec of the installation
+type errorBackoff struct {
+	mu        sync.Mutex
+	failures  map[interface{}]int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func newErrorBackoff(baseDelay, maxDelay time.Duration) *errorBackoff {
+	return &errorBackoff{
+		failures:  map[interface{}]int{},
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+	}
+}
+
+// newRateLimiter returns the controller-runtime default rate limiter, with the
+// given backoff for the failed reconciles
+func newRateLimiter(backoff *errorBackoff) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		backoff,
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+func (b *errorBackoff) When(item interface{}) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	exp := b.failures[item]
+	b.failures[item]++
+
+	backoff := float64(b.baseDelay.Nanoseconds()) * math.Pow(2, float64(exp))
+	if backoff > float64(b.maxDelay.Nanoseconds()) {
+		return b.maxDelay
+	}
+	return time.Duration(backoff)
+}
+
+func (b *errorBackoff) NumRequeues(item interface{}) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures[item]
+}
+
+func (b *errorBackoff) Forget(item interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, item)
+}
+
+// SetMaxDelay changes the cap of the backoff
+func (b *errorBackoff) SetMaxDelay(maxDelay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.maxDelay = maxDelay
+}
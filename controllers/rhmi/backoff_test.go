@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestErrorBackoff(t *testing.T) {
+	backoff := newErrorBackoff(time.Second, 5*time.Second)
+
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := backoff.When("rhoam"); got != want {
+			t.Fatalf("When() = %v, want %v", got, want)
+		}
+	}
+	if got := backoff.NumRequeues("rhoam"); got != 5 {
+		t.Fatalf("NumRequeues() = %v, want 5", got)
+	}
+	if got := backoff.When("other"); got != time.Second {
+		t.Fatalf("expected the items to back off separately, got %v", got)
+	}
+
+	backoff.SetMaxDelay(2 * time.Second)
+	if got := backoff.When("rhoam"); got != 2*time.Second {
+		t.Fatalf("expected the new cap to apply, got %v", got)
+	}
+
+	backoff.Forget("rhoam")
+	if got := backoff.NumRequeues("rhoam"); got != 0 {
+		t.Fatalf("NumRequeues() after Forget() = %v, want 0", got)
+	}
+	if got := backoff.When("rhoam"); got != time.Second {
+		t.Fatalf("expected the backoff to restart after Forget(), got %v", got)
+	}
+}
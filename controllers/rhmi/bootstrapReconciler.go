@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
 	customDomain "github.com/integr8ly/integreatly-operator/pkg/resources/custom-domain"
@@ -28,9 +30,13 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/products/observability"
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/events"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/externalsecrets"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/logforwarding"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/networkpolicy"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/tracing"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/lib/ownerutil"
 
 	cs "github.com/integr8ly/integreatly-operator/pkg/resources/custom-smtp"
@@ -41,6 +47,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -169,12 +176,37 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 	}
 	metrics.SetQuota(installation.Status.Quota, installation.Status.ToQuota)
 
+	if err := externalsecrets.ReconcileExternalSecrets(ctx, serverClient, installation); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Reconciling external secrets has failed", err)
+		return integreatlyv1alpha1.PhaseFailed, errors.Wrap(err, "reconciling external secrets has failed")
+	}
+
 	phase, err = r.reconcileCustomSMTP(ctx, serverClient)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.recorder, installation, phase, "Reconciling custom SMTP has failed ", err)
 		return phase, errors.Wrap(err, "reconciling custom SMTP has failed ")
 	}
 
+	if err := cs.ReconcileRelay(ctx, serverClient, installation); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Reconciling SMTP relay has failed", err)
+		return integreatlyv1alpha1.PhaseFailed, errors.Wrap(err, "reconciling SMTP relay has failed")
+	}
+
+	if err := logforwarding.ReconcileLogForwarding(ctx, serverClient, installation); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Reconciling log forwarding has failed", err)
+		return integreatlyv1alpha1.PhaseFailed, errors.Wrap(err, "reconciling log forwarding has failed")
+	}
+
+	if err := tracing.ReconcileCollector(ctx, serverClient, installation); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Reconciling tracing collector has failed", err)
+		return integreatlyv1alpha1.PhaseFailed, errors.Wrap(err, "reconciling tracing collector has failed")
+	}
+
+	if err := networkpolicy.ReconcileNetworkPolicies(ctx, serverClient, installation); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Reconciling network policies has failed", err)
+		return integreatlyv1alpha1.PhaseFailed, errors.Wrap(err, "reconciling network policies has failed")
+	}
+
 	if !resources.IsInProw(installation) {
 		// Creates the Alertmanager config secret
 		phase, err = obo.ReconcileAlertManagerSecrets(ctx, serverClient, r.installation)
@@ -187,6 +219,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 			return phase, err
 		}
 
+		if err := obo.ReconcileRemoteWrite(ctx, serverClient, installation); err != nil {
+			events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Failed to reconcile prometheus remote write", err)
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+
 		// Creates an alert to check for the presence of sendgrid smtp secret
 		phase, err = resources.CreateSmtpSecretExists(ctx, serverClient, installation)
 		r.log.Infof("Reconcile SendgridSmtpSecretExists alert", l.Fields{"phase": phase})
@@ -221,7 +258,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 
 	}
 
-	events.HandleStageComplete(r.recorder, installation, integreatlyv1alpha1.BootstrapStage)
+	phase, err = r.reconcileTelemetry(ctx, serverClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile telemetry", err)
+		return phase, errors.Wrap(err, "failed to reconcile telemetry")
+	}
 
 	metrics.SetInfo(installation)
 	r.log.Info("Metric rhmi_info exposed")
@@ -242,6 +283,58 @@ func (r *Reconciler) setTenantMetrics(ctx context.Context, serverClient k8sclien
 	return nil
 }
 
+// reconcileTelemetry exposes the feature usage metric and publishes the
+// reported data to the admins when the installation opts in to telemetry
+func (r *Reconciler) reconcileTelemetry(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	telemetryConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TelemetryConfigMapName,
+			Namespace: r.installation.Namespace,
+		},
+	}
+
+	if r.installation.Spec.Telemetry == nil || !r.installation.Spec.Telemetry.Enabled {
+		metrics.SetFeatureUsage(nil)
+		if err := serverClient.Delete(ctx, telemetryConfig); err != nil && !k8serr.IsNotFound(err) {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete telemetry config map: %w", err)
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	tenantCount := 0
+	if integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(r.installation.Spec.Type)) {
+		tenants := &integreatlyv1alpha1.APIManagementTenantList{}
+		if err := serverClient.List(ctx, tenants); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list tenants: %w", err)
+		}
+		tenantCount = len(tenants.Items)
+	}
+	report := metrics.GetTelemetryReport(r.installation, tenantCount)
+
+	contractJSON, err := json.MarshalIndent(metrics.TelemetryContract, "", "  ")
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	reportJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, telemetryConfig, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(telemetryConfig, r.installation)
+		telemetryConfig.Data = map[string]string{
+			"contract.json": string(contractJSON),
+			"report.json":   string(reportJSON),
+		}
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile telemetry config map: %w", err)
+	}
+
+	metrics.SetFeatureUsage(report)
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
 func (r *Reconciler) reconcilePriorityClass(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
 	priorityClass := &schedulingv1.PriorityClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -664,6 +757,25 @@ func (r *Reconciler) generateSecret(length int) string {
 	return string(buf)
 }
 
+// removedResourceOverrides returns the workloads whose resource override was
+// removed since the installation last completed
+func removedResourceOverrides(installation *integreatlyv1alpha1.RHMI) []string {
+	var removed []string
+	for _, name := range installation.Status.ResourceOverrides {
+		found := false
+		for _, override := range installation.Spec.ResourceOverrides {
+			if override.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, name)
+		}
+	}
+	return removed
+}
+
 func (r *Reconciler) processQuota(installation *integreatlyv1alpha1.RHMI, namespace string,
 	installationQuota *quota.Quota, serverClient k8sclient.Client) error {
 	isQuotaUpdated := false
@@ -680,11 +792,26 @@ func (r *Reconciler) processQuota(installation *integreatlyv1alpha1.RHMI, namesp
 		return fmt.Errorf("error getting quota config map %w", err)
 	}
 
+	// get the quota custom resources defined by the SREs, they take
+	// precedence over the quotas of the config map
+	quotaCRs := &integreatlyv1alpha1.QuotaList{}
+	if err = serverClient.List(context.TODO(), quotaCRs, k8sclient.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("error listing quota custom resources %w", err)
+	}
+	validQuotas, rejectedQuotas := validQuotaCRs(quotaCRs.Items)
+
 	// Updates the installation quota to the quota param if the quota is updated
-	err = quota.GetQuota(context.TODO(), serverClient, quotaParam, configMap, installationQuota)
+	err = quota.GetQuotaWithCustomResources(context.TODO(), serverClient, quotaParam, configMap, validQuotas, installationQuota)
 	if err != nil {
 		return err
 	}
+	if err = updateQuotaCRStatuses(context.TODO(), serverClient, installation, quotaCRs.Items, validQuotas, rejectedQuotas, quotaParam); err != nil {
+		return err
+	}
+	installationQuota.ApplyResourceOverrides(installation.Spec.ResourceOverrides, removedResourceOverrides(installation))
+	if err = r.reconcileQuotaTransition(context.TODO(), serverClient, installation, configMap, validQuotas, installationQuota); err != nil {
+		return err
+	}
 
 	// if both are toQuota and Quota are empty this indicates that it's either
 	// the first reconcile of an installation or it's the first reconcile of an upgrade to 1.6.0
@@ -701,8 +828,91 @@ func (r *Reconciler) processQuota(installation *integreatlyv1alpha1.RHMI, namesp
 	return nil
 }
 
+// validQuotaCRs returns the Quota CRs the installations can select, sorted by
+// name, and the reason each of the others is rejected for. When several
+// quotas share a param the first one by name is selected
+func validQuotaCRs(quotaCRs []integreatlyv1alpha1.Quota) ([]integreatlyv1alpha1.Quota, map[string]string) {
+	sorted := make([]integreatlyv1alpha1.Quota, len(quotaCRs))
+	copy(sorted, quotaCRs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	valid := []integreatlyv1alpha1.Quota{}
+	rejected := map[string]string{}
+	params := map[string]string{}
+	for _, quotaCR := range sorted {
+		if err := quotaCR.ValidateCreate(); err != nil {
+			rejected[quotaCR.Name] = err.Error()
+			continue
+		}
+		if name, ok := params[quotaCR.Spec.Param]; ok {
+			rejected[quotaCR.Name] = fmt.Sprintf("the %s quota already defines the '%s' param", name, quotaCR.Spec.Param)
+			continue
+		}
+		params[quotaCR.Spec.Param] = quotaCR.Name
+		valid = append(valid, quotaCR)
+	}
+	return valid, rejected
+}
+
+// updateQuotaCRStatuses reports on the Quota CRs whether they're valid and
+// which one is active for the installation
+func updateQuotaCRStatuses(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI,
+	quotaCRs, validQuotas []integreatlyv1alpha1.Quota, rejectedQuotas map[string]string, quotaParam string) error {
+	activeQuota := ""
+	for _, quotaCR := range validQuotas {
+		if quotaCR.Spec.Param == quotaParam {
+			activeQuota = quotaCR.Name
+			break
+		}
+	}
+
+	for i := range quotaCRs {
+		quotaCR := &quotaCRs[i]
+		status := quotaCR.Status.DeepCopy()
+
+		condition := metav1.Condition{
+			Type:               integreatlyv1alpha1.QuotaValidConditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Valid",
+			Message:            "The quota can be selected by the installations",
+			ObservedGeneration: quotaCR.Generation,
+		}
+		if reason, ok := rejectedQuotas[quotaCR.Name]; ok {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "Invalid"
+			condition.Message = reason
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+
+		status.Active = quotaCR.Name == activeQuota
+		status.Installation = ""
+		if status.Active {
+			status.Installation = installation.Name
+		}
+
+		if reflect.DeepEqual(status, &quotaCR.Status) {
+			continue
+		}
+		quotaCR.Status = *status
+		if err := serverClient.Status().Update(ctx, quotaCR); err != nil {
+			return fmt.Errorf("error updating the status of the %s quota %w", quotaCR.Name, err)
+		}
+	}
+	return nil
+}
+
 func (r *Reconciler) reconcileCustomSMTP(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
 
+	// the SMTP provider spec takes precedence over the addon parameters
+	if r.installation.Spec.SMTPProvider != nil {
+		if err := cs.ReconcileProvider(ctx, serverClient, r.installation, cs.NewProvider, cs.DefaultProber, time.Now()); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
 	smtp, err := cs.GetCustomAddonValues(serverClient, r.installation.Namespace)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, err
@@ -786,7 +996,7 @@ func getSecretQuotaParam(installation *integreatlyv1alpha1.RHMI, serverClient k8
 
 	// if the param is not found after the installation is 1 minute old it means that it wasn't provided to the installation
 	// in this case check for the trial-quota parameter, and use it instead of quota if it is found
-	// if trial-quota is not found then check for an Environment Variable QUOTA
+	// if trial-quota is not found then use the default quota, set from the QUOTA environment variable
 	// if neither are found then return an error as there is no QUOTA value for the installation to use and it's required by the reconcilers.
 	if isInstallationOlderThan1Minute(installation) {
 		quotaParam, found, err = addon.GetStringParameter(context.TODO(), serverClient, namespace, addon.TrialQuotaParamName)
@@ -798,12 +1008,11 @@ func getSecretQuotaParam(installation *integreatlyv1alpha1.RHMI, serverClient k8
 		}
 
 		if !found {
-			log.Info(fmt.Sprintf("no secret param found after one minute so falling back to env var '%s' for sku value", integreatlyv1alpha1.EnvKeyQuota))
-			quotaValue, exists := os.LookupEnv(integreatlyv1alpha1.EnvKeyQuota)
-			if !exists || quotaValue == "" {
-				return "", fmt.Errorf("no quota value provided by add on parameter '%s' or by env var '%s'", addon.QuotaParamName, integreatlyv1alpha1.EnvKeyQuota)
+			log.Info("no secret param found after one minute so falling back to the default quota of the installation")
+			if installation.Spec.DefaultQuota == "" {
+				return "", fmt.Errorf("no quota value provided by add on parameter '%s' or by spec.defaultQuota", addon.QuotaParamName)
 			}
-			return quotaValue, nil
+			return installation.Spec.DefaultQuota, nil
 		}
 	}
 
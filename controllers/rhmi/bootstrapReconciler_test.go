@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
@@ -12,12 +14,15 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
 	"github.com/integr8ly/integreatly-operator/utils"
 	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -993,3 +998,128 @@ func TestReconciler_checkCloudResourcesConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_reconcileTelemetry(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: v1.ObjectMeta{Name: "rhoam", Namespace: rhoamOperatorNs},
+		Spec: integreatlyv1alpha1.RHMISpec{
+			Type:      string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+			Telemetry: &integreatlyv1alpha1.TelemetrySpec{Enabled: true},
+		},
+	}
+	serverClient := utils.NewTestClient(scheme,
+		&integreatlyv1alpha1.APIManagementTenant{ObjectMeta: v1.ObjectMeta{Name: "tenant", Namespace: "tenant-ns"}},
+	)
+	r := &Reconciler{installation: installation, log: l.NewLogger()}
+
+	phase, err := r.reconcileTelemetry(context.TODO(), serverClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		t.Fatalf("expected telemetry to be reconciled, got %s %v", phase, err)
+	}
+	telemetryConfig := &corev1.ConfigMap{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: TelemetryConfigMapName, Namespace: rhoamOperatorNs}, telemetryConfig); err != nil {
+		t.Fatal(err)
+	}
+	if telemetryConfig.Data["contract.json"] == "" || !strings.Contains(telemetryConfig.Data["report.json"], `"tenants": "1-10"`) {
+		t.Fatalf("expected the contract and report to be published, got %v", telemetryConfig.Data)
+	}
+
+	installation.Spec.Telemetry.Enabled = false
+	phase, err = r.reconcileTelemetry(context.TODO(), serverClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		t.Fatalf("expected telemetry to be disabled, got %s %v", phase, err)
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: TelemetryConfigMapName, Namespace: rhoamOperatorNs}, telemetryConfig); !k8serr.IsNotFound(err) {
+		t.Fatalf("expected the telemetry config map to be deleted, got %v", err)
+	}
+}
+
+func Test_removedResourceOverrides(t *testing.T) {
+	installation := &integreatlyv1alpha1.RHMI{
+		Spec: integreatlyv1alpha1.RHMISpec{
+			ResourceOverrides: []integreatlyv1alpha1.ResourceOverrideSpec{
+				{Name: quota.GrafanaName},
+			},
+		},
+		Status: integreatlyv1alpha1.RHMIStatus{
+			ResourceOverrides: []string{quota.GrafanaName, quota.RateLimitName},
+		},
+	}
+
+	got := removedResourceOverrides(installation)
+	if !reflect.DeepEqual(got, []string{quota.RateLimitName}) {
+		t.Fatalf("removedResourceOverrides() = %v, want [%s]", got, quota.RateLimitName)
+	}
+}
+
+func Test_updateQuotaCRStatuses(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newQuota := func(name, param string) *integreatlyv1alpha1.Quota {
+		return &integreatlyv1alpha1.Quota{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: rhoamOperatorNs},
+			Spec: integreatlyv1alpha1.QuotaSpec{
+				DisplayName: name,
+				Param:       param,
+			},
+		}
+	}
+	invalid := newQuota("c-invalid", "invalid")
+	invalid.Spec.Resources = []integreatlyv1alpha1.QuotaResourceSpec{
+		{
+			Name: quota.GrafanaName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		},
+	}
+	installation := &integreatlyv1alpha1.RHMI{ObjectMeta: v1.ObjectMeta{Name: "rhoam", Namespace: rhoamOperatorNs}}
+	serverClient := utils.NewTestClient(scheme, newQuota("a-active", "1"), newQuota("b-duplicate", "1"), newQuota("d-inactive", "2"), invalid)
+
+	quotaCRs := &integreatlyv1alpha1.QuotaList{}
+	if err := serverClient.List(context.TODO(), quotaCRs); err != nil {
+		t.Fatal(err)
+	}
+	validQuotas, rejectedQuotas := validQuotaCRs(quotaCRs.Items)
+	if len(validQuotas) != 2 || validQuotas[0].Name != "a-active" || validQuotas[1].Name != "d-inactive" {
+		t.Fatalf("expected the a-active and d-inactive quotas to be valid, got %v", validQuotas)
+	}
+	if err := updateQuotaCRStatuses(context.TODO(), serverClient, installation, quotaCRs.Items, validQuotas, rejectedQuotas, "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		wantValid        v1.ConditionStatus
+		wantActive       bool
+		wantInstallation string
+	}{
+		{name: "a-active", wantValid: v1.ConditionTrue, wantActive: true, wantInstallation: "rhoam"},
+		{name: "b-duplicate", wantValid: v1.ConditionFalse},
+		{name: "c-invalid", wantValid: v1.ConditionFalse},
+		{name: "d-inactive", wantValid: v1.ConditionTrue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &integreatlyv1alpha1.Quota{}
+			if err := serverClient.Get(context.TODO(), types.NamespacedName{Name: tt.name, Namespace: rhoamOperatorNs}, got); err != nil {
+				t.Fatal(err)
+			}
+			condition := meta.FindStatusCondition(got.Status.Conditions, integreatlyv1alpha1.QuotaValidConditionType)
+			if condition == nil || condition.Status != tt.wantValid {
+				t.Errorf("expected the Valid condition to be %s, got %v", tt.wantValid, condition)
+			}
+			if got.Status.Active != tt.wantActive || got.Status.Installation != tt.wantInstallation {
+				t.Errorf("expected active %t for installation '%s', got %t for '%s'", tt.wantActive, tt.wantInstallation, got.Status.Active, got.Status.Installation)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	rhmiv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/products"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// reconcileDryRun walks the reconcilers of every install stage with clients
+// that record the writes they would make instead of sending them, and writes
+// the plan to the plan ConfigMap. Only the dry run condition of the
+// installation is updated, the stages keep the status of the last reconcile
+func (r *RHMIReconciler) reconcileDryRun(installation *rhmiv1alpha1.RHMI, installType *Type, configMapName string, request ctrl.Request) (ctrl.Result, error) {
+	result := ctrl.Result{Requeue: true, RequeueAfter: installation.ReconcileInterval()}
+	serverClient, err := k8sclient.New(r.restConfig, k8sclient.Options{
+		Scheme: r.mgr.GetScheme(),
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("could not create server client: %w", err)
+	}
+
+	plan := dryrun.NewPlan()
+	walkErr := r.walkDryRun(installation.DeepCopy(), installType, configMapName, serverClient, plan, request)
+
+	var condition metav1.Condition
+	if walkErr != nil {
+		condition = installation.DryRunFailedCondition(walkErr.Error())
+	} else {
+		if err := writeDryRunPlan(context.TODO(), r.Client, installation, plan); err != nil {
+			return result, err
+		}
+		condition = installation.DryRunPlannedCondition(fmt.Sprintf("%d writes planned, see the %s ConfigMap", plan.Len(), dryrun.PlanConfigMapName))
+	}
+
+	previous := apimeta.FindStatusCondition(installation.Status.Conditions, rhmiv1alpha1.DryRunConditionType.String())
+	if previous == nil || previous.Message != condition.Message {
+		log.Infof("Dry run planned", l.Fields{"writes": plan.Summary()})
+		r.mgr.GetEventRecorderFor("Dry Run").Event(installation, "Normal", rhmiv1alpha1.EventDryRunPlanned, condition.Message)
+	}
+	apimeta.SetStatusCondition(&installation.Status.Conditions, condition)
+	if err := r.Status().Update(context.TODO(), installation); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// walkDryRun reconciles the stages and products of the installation copy
+// through the dry run clients, recording their phase in the plan. The stages
+// are all walked, whether the previous ones complete or not
+func (r *RHMIReconciler) walkDryRun(installation *rhmiv1alpha1.RHMI, installType *Type, configMapName string,
+	serverClient k8sclient.Client, plan *dryrun.Plan, request ctrl.Request) error {
+	configManager, err := config.NewManager(context.TODO(), dryrun.NewClient(serverClient, plan, "config"), installation.Namespace, configMapName, installation)
+	if err != nil {
+		return err
+	}
+	mgr := dryrun.NewManager(r.mgr)
+	installationQuota := &quota.Quota{}
+
+	for _, stage := range installType.GetInstallStages() {
+		if stage.Name == rhmiv1alpha1.BootstrapStage {
+			component := string(stage.Name)
+			stageLog := l.NewLoggerWithContext(l.Fields{l.StageLogContext: stage.Name})
+			reconciler, err := NewBootstrapReconciler(configManager, installation, marketplace.NewManager(), &record.FakeRecorder{}, stageLog)
+			if err != nil {
+				return fmt.Errorf("failed to build a reconciler for Bootstrap: %w", err)
+			}
+			phase, err := reconciler.Reconcile(context.TODO(), installation, dryrun.NewClient(serverClient, plan, component), installationQuota, request)
+			plan.SetResult(component, phase, err)
+			continue
+		}
+
+		productNames := make([]rhmiv1alpha1.ProductName, 0, len(stage.Products))
+		for productName := range stage.Products {
+			productNames = append(productNames, productName)
+		}
+		sort.Slice(productNames, func(i, j int) bool { return productNames[i] < productNames[j] })
+
+		for _, productName := range productNames {
+			component := string(productName)
+			productStatus := stage.Products[productName]
+			if previous, ok := installation.Status.Stages[stage.Name].Products[productName]; ok {
+				productStatus = previous
+			}
+			productLog := l.NewLoggerWithContext(l.Fields{l.ProductLogContext: productName})
+			reconciler, err := products.NewReconciler(productName, dryrun.RestConfig(r.restConfig, plan, component), configManager, installation, mgr, productLog, r.productsInstallationLoader)
+			if err != nil {
+				return fmt.Errorf("failed to build a reconciler for %s: %w", productName, err)
+			}
+			uninstall := productStatus.Uninstall || installation.IsProductDisabled(productName)
+			phase, err := reconciler.Reconcile(context.TODO(), installation, &productStatus, dryrun.NewClient(serverClient, plan, component), installationQuota.GetProduct(productName), uninstall)
+			plan.SetResult(component, phase, err)
+		}
+	}
+	return nil
+}
+
+// writeDryRunPlan replaces the plan ConfigMap of the installation with plan
+func writeDryRunPlan(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI, plan *dryrun.Plan) error {
+	cfgMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dryrun.PlanConfigMapName,
+			Namespace: installation.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, cfgMap, func() error {
+		cfgMap.Data = plan.Data()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write the dry run plan: %w", err)
+	}
+	return nil
+}
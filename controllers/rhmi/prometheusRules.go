@@ -93,6 +93,49 @@ func (r *RHMIReconciler) newAlertsReconciler(installation *integreatlyv1alpha1.R
 				},
 			},
 		},
+		{
+			AlertName: fmt.Sprintf("%s-credential-leak-alerts", installationName),
+			Namespace: observability.OpenshiftMonitoringNamespace,
+			GroupName: fmt.Sprintf("%s-credential-leak.rules", installationName),
+			Rules: []monitoringv1.Rule{
+				{
+					Alert: fmt.Sprintf("%sCredentialLeakBlocked", strings.ToUpper(installationName)),
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "{{ $labels.kind }} writes matching the {{ $labels.pattern }} credential pattern have been blocked, check the operator logs for the resource and move the credential to a Secret",
+					},
+					Expr:   intstr.FromString(fmt.Sprintf(`increase(%s_credential_leak_blocked_total[10m]) > 0`, installationName)),
+					Labels: map[string]string{"severity": "warning", "product": installationName, "addon": getAddonName(installation), "namespace": "openshift-monitoring"},
+				},
+			},
+		},
+		{
+			AlertName: fmt.Sprintf("%s-certificate-alerts", installationName),
+			Namespace: observability.OpenshiftMonitoringNamespace,
+			GroupName: fmt.Sprintf("%s-certificate.rules", installationName),
+			Rules: []monitoringv1.Rule{
+				{
+					Alert: fmt.Sprintf("%sCertificateExpiringSoon", strings.ToUpper(installationName)),
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "The certificate in secret {{ $labels.secret }} expires in less than 30 days, replace it with a renewed certificate",
+					},
+					Expr:   intstr.FromString(fmt.Sprintf(`(%s_certificate_expiry_timestamp_seconds - time()) < 30 * 24 * 3600`, installationName)),
+					For:    "10m",
+					Labels: map[string]string{"severity": "warning", "product": installationName, "addon": getAddonName(installation), "namespace": "openshift-monitoring"},
+				},
+				{
+					Alert: fmt.Sprintf("%sCertificateExpiringCritical", strings.ToUpper(installationName)),
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "The certificate in secret {{ $labels.secret }} expires in less than 7 days, replace it with a renewed certificate",
+					},
+					Expr:   intstr.FromString(fmt.Sprintf(`(%s_certificate_expiry_timestamp_seconds - time()) < 7 * 24 * 3600`, installationName)),
+					For:    "10m",
+					Labels: map[string]string{"severity": "critical", "product": installationName, "addon": getAddonName(installation), "namespace": "openshift-monitoring"},
+				},
+			},
+		},
 		{
 			AlertName: fmt.Sprintf("%s-telemetry", installationName),
 			Namespace: observability.OpenshiftMonitoringNamespace,
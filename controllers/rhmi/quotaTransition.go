@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
+	appsv1 "github.com/openshift/api/apps/v1"
+	k8sappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reconcileQuotaTransition steps the installation quota towards the quota
+// selected for the installation when the spec asks for a step-wise
+// transition. The next step is applied once the workloads of the current one
+// are ready
+func (r *Reconciler) reconcileQuotaTransition(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI,
+	configMap *corev1.ConfigMap, quotaCRs []integreatlyv1alpha1.Quota, installationQuota *quota.Quota) error {
+	steps := int32(1)
+	if installation.Spec.QuotaTransition != nil {
+		steps = installation.Spec.QuotaTransition.Steps
+	}
+
+	// the first quota of an installation is applied at once
+	current, target := installation.Status.Quota, installationQuota.GetName()
+	if steps <= 1 || current == "" || current == target {
+		installation.Status.QuotaTransition = nil
+		return nil
+	}
+
+	transition := installation.Status.QuotaTransition
+	if transition == nil || transition.From != current || transition.To != target || transition.Steps != steps {
+		transition = &integreatlyv1alpha1.QuotaTransitionStatus{
+			From:  current,
+			To:    target,
+			Step:  1,
+			Steps: steps,
+		}
+	} else if transition.Step < transition.Steps {
+		ready, err := quotaWorkloadsReady(ctx, serverClient, installation)
+		if err != nil {
+			return err
+		}
+		if ready {
+			transition.Step++
+		}
+	}
+	installation.Status.QuotaTransition = transition
+	r.log.Infof("Quota transition", l.Fields{"from": transition.From, "to": transition.To, "step": transition.Step, "steps": transition.Steps})
+
+	fromQuota := &quota.Quota{}
+	if err := quota.GetQuotaByName(ctx, serverClient, current, configMap, quotaCRs, fromQuota); err != nil {
+		return fmt.Errorf("error getting the quota the transition started from %w", err)
+	}
+	installationQuota.StepFrom(fromQuota, transition.Step, transition.Steps)
+	return nil
+}
+
+// quotaWorkloadsReady returns true when the stages completed on the previous
+// reconcile and the Deployments, DeploymentConfigs and StatefulSets of the
+// installation namespaces rolled out all their replicas
+func quotaWorkloadsReady(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI) (bool, error) {
+	for _, stage := range installation.Status.Stages {
+		if stage.Phase != integreatlyv1alpha1.PhaseCompleted {
+			return false, nil
+		}
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := serverClient.List(ctx, namespaces, k8sclient.MatchingLabels{resources.OwnerLabelKey: string(installation.GetUID())}); err != nil {
+		return false, fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+
+	for _, ns := range namespaces.Items {
+		deployments := &k8sappsv1.DeploymentList{}
+		if err := serverClient.List(ctx, deployments, k8sclient.InNamespace(ns.Name)); err != nil {
+			return false, fmt.Errorf("failed to list deployments in %s: %w", ns.Name, err)
+		}
+		for _, deployment := range deployments.Items {
+			replicas := int32(1)
+			if deployment.Spec.Replicas != nil {
+				replicas = *deployment.Spec.Replicas
+			}
+			if deployment.Status.ObservedGeneration < deployment.Generation ||
+				deployment.Status.UpdatedReplicas != replicas || deployment.Status.ReadyReplicas != replicas {
+				return false, nil
+			}
+		}
+
+		statefulSets := &k8sappsv1.StatefulSetList{}
+		if err := serverClient.List(ctx, statefulSets, k8sclient.InNamespace(ns.Name)); err != nil {
+			return false, fmt.Errorf("failed to list statefulsets in %s: %w", ns.Name, err)
+		}
+		for _, statefulSet := range statefulSets.Items {
+			replicas := int32(1)
+			if statefulSet.Spec.Replicas != nil {
+				replicas = *statefulSet.Spec.Replicas
+			}
+			if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.ReadyReplicas != replicas {
+				return false, nil
+			}
+		}
+
+		deploymentConfigs := &appsv1.DeploymentConfigList{}
+		if err := serverClient.List(ctx, deploymentConfigs, k8sclient.InNamespace(ns.Name)); err != nil {
+			return false, fmt.Errorf("failed to list deploymentconfigs in %s: %w", ns.Name, err)
+		}
+		for _, deploymentConfig := range deploymentConfigs.Items {
+			if deploymentConfig.Status.ObservedGeneration < deploymentConfig.Generation ||
+				deploymentConfig.Status.UpdatedReplicas != deploymentConfig.Spec.Replicas ||
+				deploymentConfig.Status.ReadyReplicas != deploymentConfig.Spec.Replicas {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
+	"github.com/integr8ly/integreatly-operator/utils"
+	configv1 "github.com/openshift/api/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconciler_reconcileQuotaTransition(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: quota.ConfigMapName, Namespace: rhoamOperatorNs},
+		Data: map[string]string{
+			quota.ConfigMapData: `[
+				{"name": "1 Million", "param": "10", "rate-limiting": {"unit": "minute", "requests_per_unit": 100}, "resources": {"backend_listener": {"replicas": 2}}},
+				{"name": "5 Million", "param": "50", "rate-limiting": {"unit": "minute", "requests_per_unit": 500}, "resources": {"backend_listener": {"replicas": 6}}}
+			]`,
+		},
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: v1.ObjectMeta{Name: "rhoam", Namespace: rhoamOperatorNs, UID: types.UID("rhoam-uid")},
+		Spec: integreatlyv1alpha1.RHMISpec{
+			QuotaTransition: &integreatlyv1alpha1.QuotaTransitionSpec{Steps: 2},
+		},
+		Status: integreatlyv1alpha1.RHMIStatus{
+			Quota: "1 Million",
+			Stages: map[integreatlyv1alpha1.StageName]integreatlyv1alpha1.RHMIStageStatus{
+				integreatlyv1alpha1.InstallStage: {Phase: integreatlyv1alpha1.PhaseCompleted},
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: v1.ObjectMeta{Name: "backend-listener", Namespace: threescaleNs, Generation: 1},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+	serverClient := utils.NewTestClient(scheme,
+		&configv1.Infrastructure{
+			ObjectMeta: v1.ObjectMeta{Name: "cluster"},
+			Status: configv1.InfrastructureStatus{
+				PlatformStatus: &configv1.PlatformStatus{Type: configv1.AWSPlatformType},
+			},
+		},
+		&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: threescaleNs, Labels: map[string]string{resources.OwnerLabelKey: "rhoam-uid"}}},
+		deployment,
+	)
+	r := &Reconciler{installation: installation, log: l.NewLogger()}
+
+	reconcileStep := func() *quota.Quota {
+		installationQuota := &quota.Quota{}
+		if err := quota.GetQuota(context.TODO(), serverClient, "50", configMap, installationQuota); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.reconcileQuotaTransition(context.TODO(), serverClient, installation, configMap, nil, installationQuota); err != nil {
+			t.Fatal(err)
+		}
+		return installationQuota
+	}
+
+	// the transition starts with the first step
+	installationQuota := reconcileStep()
+	if replicas := installationQuota.GetProduct(integreatlyv1alpha1.Product3Scale).GetReplicas(quota.BackendListenerName); replicas != 4 {
+		t.Fatalf("expected the first step to set 4 backend_listener replicas, got %d", replicas)
+	}
+	if !installationQuota.IsTransitioning() || installation.Status.QuotaTransition.Step != 1 {
+		t.Fatalf("expected the transition to be on the first step, got %v", installation.Status.QuotaTransition)
+	}
+
+	// the transition waits for the workloads to be ready
+	deployment.Spec.Replicas = &[]int32{4}[0]
+	if err := serverClient.Update(context.TODO(), deployment); err != nil {
+		t.Fatal(err)
+	}
+	reconcileStep()
+	if installation.Status.QuotaTransition.Step != 1 {
+		t.Fatalf("expected the transition to wait for the workloads, got step %d", installation.Status.QuotaTransition.Step)
+	}
+
+	// the last step applies the quota once the workloads are ready
+	deployment.Status.UpdatedReplicas = 4
+	deployment.Status.ReadyReplicas = 4
+	if err := serverClient.Status().Update(context.TODO(), deployment); err != nil {
+		t.Fatal(err)
+	}
+	installationQuota = reconcileStep()
+	if replicas := installationQuota.GetProduct(integreatlyv1alpha1.Product3Scale).GetReplicas(quota.BackendListenerName); replicas != 6 {
+		t.Fatalf("expected the last step to set 6 backend_listener replicas, got %d", replicas)
+	}
+	if installationQuota.IsTransitioning() || installation.Status.QuotaTransition.Step != 2 {
+		t.Fatalf("expected the transition to be on the last step, got %v", installation.Status.QuotaTransition)
+	}
+
+	// the transition is cleared once the quota is reported
+	installation.Status.Quota = "5 Million"
+	reconcileStep()
+	if installation.Status.QuotaTransition != nil {
+		t.Fatalf("expected the transition to be cleared, got %v", installation.Status.QuotaTransition)
+	}
+}
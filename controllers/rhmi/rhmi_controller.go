@@ -20,17 +20,29 @@ import (
 	"context"
 	"fmt"
 	"github.com/integr8ly/integreatly-operator/pkg/products/obo"
+	"net/http"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/integr8ly/integreatly-operator/pkg/resources/alerthistory"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/buildinfo"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/certificates"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/events"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/externalsecrets"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/metering"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/routeverification"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/secretscan"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/sts"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/tracing"
 
+	"github.com/integr8ly/integreatly-operator/pkg/resources/capacity"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/poddistribution"
 	"github.com/integr8ly/integreatly-operator/pkg/webhooks"
 
@@ -38,6 +50,7 @@ import (
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -46,6 +59,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -55,6 +69,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	configv1 "github.com/openshift/api/config/v1"
 	usersv1 "github.com/openshift/api/user/v1"
 
 	rhmiv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
@@ -75,11 +90,13 @@ const (
 	previousDeletionFinalizer        = "finalizer/configmaps"
 	DefaultInstallationConfigMapName = "installation-config"
 	DefaultCloudResourceConfigName   = "cloud-resource-config"
+	TelemetryConfigMapName           = "rhoam-telemetry"
 	alertingEmailAddressEnvName      = "ALERTING_EMAIL_ADDRESS"
 	buAlertingEmailAddressEnvName    = "BU_ALERTING_EMAIL_ADDRESS"
 	installTypeEnvName               = "INSTALLATION_TYPE"
 	priorityClassNameEnvName         = "PRIORITY_CLASS_NAME"
-	managedServicePriorityClassName  = "rhoam-pod-priority"
+	namespacePrefixEnvName           = "NAMESPACE_PREFIX"
+	namespaceTopologyEnvName         = "NAMESPACE_TOPOLOGY"
 	routeRequestUrl                  = "/apis/route.openshift.io/v1"
 )
 
@@ -98,7 +115,24 @@ type RHMIReconciler struct {
 	restConfig      *rest.Config
 	customInformers map[string]map[string]*cache.Informer
 
+	errorBackoff *errorBackoff
+	// productsReconciled is when the products were last reconciled, so the
+	// products with their own reconcile interval can be skipped until it passes
+	productsReconciled map[rhmiv1alpha1.ProductName]time.Time
+
 	productsInstallationLoader marketplace.ProductsInstallationLoader
+
+	// newRouteVerifier builds the verifier of the routes of the products
+	// probed during a progressive upgrade, the routes aren't probed when
+	// it's nil
+	newRouteVerifier func(externalResolver string) *routeverification.Verifier
+	// routeVerifications runs the route probes in the background
+	routeVerifications *routeverification.Scheduler
+	// buildInfo caches the operand images of the component info metric
+	buildInfo *buildinfo.Cache
+	// newOrphanScanners builds the scanners of the resources outside of the
+	// cluster left behind by the uninstall, nothing is scanned when it's nil
+	newOrphanScanners func(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI) ([]resources.OrphanScanner, error)
 }
 
 func New(mgr ctrl.Manager) *RHMIReconciler {
@@ -112,6 +146,13 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 		restConfig:      restconfig,
 		customInformers: make(map[string]map[string]*cache.Informer),
 
+		errorBackoff:       newErrorBackoff(5*time.Millisecond, rhmiv1alpha1.DefaultMaxErrorBackoff),
+		productsReconciled: map[rhmiv1alpha1.ProductName]time.Time{},
+		newRouteVerifier:   routeverification.NewVerifier,
+		routeVerifications: routeverification.NewScheduler(routeverification.DefaultInterval),
+		buildInfo:          buildinfo.NewCache(mgr.GetAPIReader(), buildinfo.DefaultCacheTTL),
+		newOrphanScanners:  newOrphanScanners,
+
 		productsInstallationLoader: marketplace.NewFSProductInstallationLoader(
 			marketplace.GetProductsInstallationPath(),
 		),
@@ -129,7 +170,7 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update;delete;watch
 
 // We need to create consolelinks which are cluster level objects
-// +kubebuilder:rbac:groups=console.openshift.io,resources=consolelinks,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups=console.openshift.io,resources=consolelinks,verbs=get;list;create;update;delete
 
 // We are using ProjectRequests API to create namespaces where we automatically become admins
 // +kubebuilder:rbac:groups="";project.openshift.io,resources=projectrequests,verbs=create
@@ -156,7 +197,7 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get,resourceNames=grafana-datasources
 
 // OAuthClients are used for login into products with OpenShift User identity
-// +kubebuilder:rbac:groups=oauth.openshift.io,resources=oauthclients,verbs=create;get;update;delete
+// +kubebuilder:rbac:groups=oauth.openshift.io,resources=oauthclients,verbs=create;get;list;update;delete
 
 // Updating the samples operator config cr to ignore fuse imagestreams and templates
 // +kubebuilder:rbac:groups=samples.operator.openshift.io,resources=configs,verbs=get;update,resourceNames=cluster
@@ -173,6 +214,10 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // Monitoring resources not covered by namespace "admin" permissions
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules;servicemonitors;podmonitors;probes,verbs=get;list;create;update;delete
 // +kubebuilder:rbac:groups=monitoring.rhobs,resources=prometheusrules;servicemonitors;podmonitors;probes,verbs=get;list;create;update;delete;watch
+// +kubebuilder:rbac:groups=monitoring.rhobs,resources=prometheuses,verbs=get;list;patch
+
+// Log forwarding of the product workloads
+// +kubebuilder:rbac:groups=logging.openshift.io,resources=clusterlogforwarders,verbs=get;list;create;update;delete
 
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings;roles;rolebindings,verbs=*
 
@@ -189,6 +234,26 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // +kubebuilder:rbac:groups=marin3r.3scale.net,resources=envoyconfigs,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=operator.marin3r.3scale.net,resources=discoveryservices,verbs=get;list;watch;create;update;delete
 
+// Permission for the apicast canary gateway used to validate envoy config changes
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=create;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=delete
+
+// Permission for the SMTP relay of disconnected installations
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=update
+
+// Permission to remove the CloudWatch exporter when the installation doesn't use the cloud storage
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=delete
+
+// Permission to clean up and retry Jobs stuck in the product namespaces
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;create;delete
+
+// Permission to get the AWS credentials of the cloud resources, to scan for the
+// cloud resources left behind by the uninstall
+// +kubebuilder:rbac:groups=cloudcredential.openshift.io,resources=credentialsrequests,verbs=get;create;update
+
+// Permission to remove the 3scale s3 ca bundle when it's no longer configured
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=delete
+
 // +kubebuilder:rbac:groups=scheduling.k8s.io,resources=*,verbs=*
 
 // Permission to list nodes in order to determine if a cluster is multi-az
@@ -198,7 +263,7 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // +kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions;infrastructures;oauths,verbs=get;list
 
 // Permission to remove crd for the marin3r operator upgrade from 0.5.1 to 0.7.0
-// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=delete;get;list
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=delete;get;list;patch
 
 // Observability
 // +kubebuilder:rbac:groups=observability.redhat.com,resources=observabilities,verbs=*
@@ -210,9 +275,18 @@ func New(mgr ctrl.Manager) *RHMIReconciler {
 // +kubebuilder:rbac:groups="",resources=pods,verbs=create;list
 // +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
 
+// PersistentVolumeClaims are listed to export the capacity profile of the installation
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=list
+
 // LimitRanges are used to assign default CPU/Memory requests and limits for containers that don't specify values for compute resources
 // +kubebuilder:rbac:groups="",resources=limitranges,verbs=get;create;update;delete
 
+// ExternalSecrets sync the credentials of the spec from an external secret manager
+// +kubebuilder:rbac:groups=external-secrets.io,resources=externalsecrets,verbs=get;list;create;update;delete
+
+// NetworkPolicies isolate the namespaces of the products
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;create;update;delete
+
 // Role permissions
 
 // +kubebuilder:rbac:groups="",resources=pods;events;configmaps;secrets,verbs=list;get;watch;create;update;patch,namespace=integreatly-operator
@@ -280,9 +354,13 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 
 	originalInstallation := installation.DeepCopy()
 
+	// Write the effective defaults back to the CR
+	installation.Default()
+
+	r.errorBackoff.SetMaxDelay(installation.MaxErrorBackoff())
 	retryRequeue := ctrl.Result{
 		Requeue:      true,
-		RequeueAfter: 10 * time.Second,
+		RequeueAfter: installation.RetryInterval(),
 	}
 
 	installationCfgMap := os.Getenv("INSTALLATION_CONFIG_MAP")
@@ -331,6 +409,14 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	// Preview the writes of the reconcilers without making them, the install
+	// stages are skipped until the dry run annotation is removed
+	if installation.IsDryRun() && installation.DeletionTimestamp == nil {
+		log.Info("dry run requested, planning the install stages")
+		return r.reconcileDryRun(installation, installType, installationCfgMap, request)
+	}
+	apimeta.RemoveStatusCondition(&installation.Status.Conditions, rhmiv1alpha1.DryRunConditionType.String())
+
 	metrics.SetStatus(installation)
 
 	configManager, err := config.NewManager(context.TODO(), r.Client, request.NamespacedName.Namespace, installationCfgMap, installation)
@@ -383,9 +469,11 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 	if upgradeFirstReconcile(installation) || firstInstallFirstReconcile(installation) {
 		installation.Status.ToVersion = version.GetVersionByType(installation.Spec.Type)
 		log.Infof("Setting installation.Status.ToVersion on initial install", l.Fields{"version": version.GetVersionByType(installation.Spec.Type)})
+		startProgressiveUpgrade(installation, installType)
 		if err := r.Status().Update(context.TODO(), installation); err != nil {
 			return retryRequeue, nil
 		}
+		events.HandleUpgradeStarted(r.mgr.GetEventRecorderFor("Upgrade"), installation)
 		metrics.SetVersions(string(installation.Status.Stage), installation.Status.Version, installation.Status.ToVersion, string(externalClusterId), installation.CreationTimestamp.Unix())
 		metrics.SetThreeScalePortals(nil, 0) // expose metric and set default value
 	}
@@ -402,6 +490,9 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 		log.Error("error setting RHOAM cluster metric:", err)
 	}
 
+	log.Info("set component info metric")
+	r.setComponentInfoMetric(installation)
+
 	log.Info("set rhoam status metric")
 	state, err := metrics.GetRhoamState(installation)
 	if err != nil {
@@ -409,6 +500,50 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 	}
 	metrics.SetRhoamState(state)
 
+	log.Info("record alert history")
+	if err := alerthistory.Reconcile(context.TODO(), r.Client, installation); err != nil {
+		log.Warning("failed to record alert history: " + err.Error())
+	}
+
+	log.Info("report tenant usage")
+	if err := metering.Reconcile(context.TODO(), r.Client, installation, time.Now()); err != nil {
+		log.Warning("failed to report tenant usage: " + err.Error())
+	}
+
+	log.Info("track customer certificates")
+	if err := r.reconcileCertificates(installation, r.mgr.GetEventRecorderFor("Certificates")); err != nil {
+		log.Error("failed to track customer certificates", err)
+	}
+
+	// Halt the product and cloud resource reconciles while paused so manual interventions aren't reverted,
+	// alerts, metrics and status keep being reported
+	if reconcilePaused(installation, r.mgr.GetEventRecorderFor("Pause")) {
+		log.Info("installation paused, skipping install stages")
+		retryRequeue.RequeueAfter = time.Minute
+		err = r.updateStatusAndObject(originalInstallation, installation)
+		return retryRequeue, err
+	}
+
+	// Suspend the product reconciles while the cluster is upgrading to avoid racing the upgrade machinery,
+	// alerts, metrics and status keep being reported
+	if reconcileReadOnlyMode(installation, clusterVersionCR, r.mgr.GetEventRecorderFor("Read Only Mode")) {
+		log.Info("read only mode active, skipping install stages")
+		retryRequeue.RequeueAfter = time.Minute
+		err = r.updateStatusAndObject(originalInstallation, installation)
+		return retryRequeue, err
+	}
+
+	// Clean up and retry the Jobs stuck in the product namespaces, and report
+	// the Jobs that are still stuck once their retries are exhausted
+	stuckJobs, err := resources.RemediateStuckJobs(context.TODO(), r.Client, installation, log, time.Now())
+	if err != nil {
+		log.Error("failed to remediate stuck jobs", err)
+	} else if len(stuckJobs) > 0 {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.JobsStuckCondition(strings.Join(stuckJobs, ", ")))
+	} else {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.JobsRecoveredCondition())
+	}
+
 	installationQuota := &quota.Quota{}
 	installStages := installType.GetInstallStages()
 	for i := range installStages {
@@ -420,12 +555,21 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 		if stage.Name == rhmiv1alpha1.BootstrapStage {
 			stagePhase, err = r.bootstrapStage(installation, configManager, stageLog, installationQuota, request)
 		} else {
-			stagePhase, err = r.processStage(installation, &stage, configManager, installationQuota, stageLog)
+			trace := tracing.NewReconcileTrace(installation)
+			stageSpan := trace.StartSpan(fmt.Sprintf("reconcile %s stage", stage.Name), map[string]string{"stage": string(stage.Name)})
+			stagePhase, err = r.processStage(installation, &stage, configManager, installationQuota, stageLog, stageSpan)
+			stageSpan.End(err)
+			exportTrace(installation, trace)
 		}
 
 		if installation.Status.Stages == nil {
 			installation.Status.Stages = make(map[rhmiv1alpha1.StageName]rhmiv1alpha1.RHMIStageStatus)
 		}
+		stageRecorder := r.mgr.GetEventRecorderFor(string(stage.Name))
+		events.HandleStageStarted(stageRecorder, installation, stage.Name, stagePhase)
+		if stagePhase == rhmiv1alpha1.PhaseCompleted {
+			events.HandleStageComplete(stageRecorder, installation, stage.Name)
+		}
 		installation.Status.Stages[stage.Name] = rhmiv1alpha1.RHMIStageStatus{
 			Name:     stage.Name,
 			Phase:    stagePhase,
@@ -447,13 +591,18 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 	}
 
 	// Entered on first reconcile where all stages reported complete after an upgrade / install
-	if installation.Status.ToVersion == version.GetVersionByType(installation.Spec.Type) && !installInProgress && !productVersionMismatchFound {
+	if installation.Status.ToVersion == version.GetVersionByType(installation.Spec.Type) && !installInProgress && !productVersionMismatchFound && isProgressiveUpgradeDone(installation) {
+		previousVersion := installation.Status.Version
 		installation.Status.Version = version.GetVersionByType(installation.Spec.Type)
 		installation.Status.ToVersion = ""
 		metrics.SetVersions(string(installation.Status.Stage), installation.Status.Version, installation.Status.ToVersion, string(externalClusterId), installation.CreationTimestamp.Unix())
-		installation.Status.Quota = installationQuota.GetName()
-		installation.Status.ToQuota = ""
+		if !installationQuota.IsTransitioning() {
+			installation.Status.Quota = installationQuota.GetName()
+			installation.Status.ToQuota = ""
+			installation.Status.QuotaTransition = nil
+		}
 
+		events.HandleUpgradeCompleted(r.mgr.GetEventRecorderFor("Upgrade"), installation, previousVersion)
 		log.Info("installation completed successfully")
 	}
 
@@ -461,23 +610,32 @@ func (r *RHMIReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 	if !installInProgress {
 		installation.Status.Stage = "complete"
 
-		if rhmiv1alpha1.IsRHOAMMultitenant(rhmiv1alpha1.InstallationType(installation.Spec.Type)) {
-			retryRequeue.RequeueAfter = 30 * time.Second
-		} else {
-			retryRequeue.RequeueAfter = 5 * time.Minute
-		}
+		retryRequeue.RequeueAfter = installation.ReconcileInterval()
 
 		if installation.Spec.RebalancePods {
 			r.reconcilePodDistribution(installation)
 		}
 
-		if installationQuota.IsUpdated() {
+		if err := capacity.ReconcileProfileConfigMap(context.TODO(), r.Client, installation); err != nil {
+			log.Error("error reconciling capacity profile", err)
+		}
+
+		// the quota is reported once the last step of its transition is
+		// applied
+		if installationQuota.IsUpdated() && !installationQuota.IsTransitioning() {
 			installation.Status.Quota = installationQuota.GetName()
 			installation.Status.ToQuota = ""
+			installation.Status.QuotaTransition = nil
 			metrics.SetQuota(installation.Status.Quota, installation.Status.ToQuota)
 		}
+
+		installation.Status.ResourceOverrides = nil
+		for _, override := range installation.Spec.ResourceOverrides {
+			installation.Status.ResourceOverrides = append(installation.Status.ResourceOverrides, override.Name)
+		}
 	}
 	metrics.SetStatus(installation)
+	installation.SetPhaseConditions(originalInstallation.Status)
 
 	err = r.updateStatusAndObject(originalInstallation, installation)
 	return retryRequeue, err
@@ -592,6 +750,19 @@ func (r *RHMIReconciler) handleUninstall(installation *rhmiv1alpha1.RHMI, instal
 
 	// Clean up the products which have finalizers associated to them
 	merr := &resources.MultiErr{}
+
+	// Remove orphaned finalizers from namespaces stuck terminating, and report
+	// the namespaces that can't be safely cleaned up
+	blockedNamespaces, err := resources.RemediateStuckNamespaces(context.TODO(), r.Client, installation, log)
+	if err != nil {
+		merr.Add(fmt.Errorf("failed to remediate namespaces stuck terminating: %w", err))
+	}
+	if len(blockedNamespaces) > 0 {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.NamespaceTerminationBlockedCondition(strings.Join(blockedNamespaces, ", ")))
+	} else if installation.IsNamespaceTerminationBlocked() {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.NamespaceTerminationUnblockedCondition())
+	}
+
 	var finalizers []string
 	finalizers = append(finalizers, installation.Finalizers...)
 	for _, stage := range installationType.UninstallStages {
@@ -624,9 +795,30 @@ func (r *RHMIReconciler) handleUninstall(installation *rhmiv1alpha1.RHMI, instal
 		}
 	}
 
+	// don't complete the uninstall until the namespaces stuck terminating are
+	// removed, either automatically or manually using the condition details
+	if len(blockedNamespaces) > 0 {
+		log.Warningf("Uninstall blocked by namespaces stuck terminating", l.Fields{"namespaces": blockedNamespaces})
+		if err := r.Client.Status().Update(context.TODO(), installation); err != nil {
+			return ctrl.Result{}, err
+		}
+		return retryRequeue, nil
+	}
+
 	//all products gone and no errors, tidy up bootstrap stuff
 	if len(installation.Finalizers) == 1 && installation.Finalizers[0] == deletionFinalizer {
 		log.Infof("Finalizers: ", l.Fields{"length": len(installation.Finalizers)})
+
+		// remove what the products left behind before the installation
+		// is gone, and keep the report of what's left
+		verified := r.verifyUninstall(context.TODO(), r.Client, installation, r.mgr.GetEventRecorderFor("Uninstall"))
+		if err := r.Client.Status().Update(context.TODO(), installation); err != nil {
+			return ctrl.Result{}, err
+		}
+		if !verified {
+			return retryRequeue, nil
+		}
+
 		// delete ConfigMap after all product finalizers finished
 		err := r.Client.Delete(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: installationCfgMap, Namespace: installation.Namespace}})
 		if err != nil && !k8serr.IsNotFound(err) {
@@ -696,6 +888,7 @@ func (r *RHMIReconciler) handleUninstallProduct(installation *rhmiv1alpha1.RHMI,
 		if err != nil {
 			merr.Add(fmt.Errorf("failed to create server client for %s: %w", productName, err))
 		}
+		serverClient = secretscan.NewClient(serverClient, log)
 		uninstall := false
 		if productStatus.Uninstall || installation.DeletionTimestamp != nil {
 			uninstall = true
@@ -843,8 +1036,9 @@ func (r *RHMIReconciler) preflightChecks(installation *rhmiv1alpha1.RHMI, instal
 		}
 	}
 
-	// Check if the quota parameter is found from the environment variable
-	quotaEnv, envOk := os.LookupEnv(rhmiv1alpha1.EnvKeyQuota)
+	// The default quota is set from the environment variable when the
+	// installation is defaulted
+	defaultQuota := installation.Spec.DefaultQuota
 
 	// If the quota parameter is not found:
 	if !okParam {
@@ -854,16 +1048,16 @@ func (r *RHMIReconciler) preflightChecks(installation *rhmiv1alpha1.RHMI, instal
 		// preflight check in case it's taking time to be reconciled from the
 		// add-on
 		if !isInstallationOlderThan1Minute(installation) {
-			preflightMessage = "quota parameter not found, waiting 1 minute before defaulting to env var"
+			preflightMessage = "quota parameter not found, waiting 1 minute before using the default quota"
 
 			// * If the installation is older than a minute and the env var is
 			// not set, fail the preflight check
-		} else if !envOk || quotaEnv == "" {
-			preflightMessage = "quota parameter not found from add-on or env var"
+		} else if defaultQuota == "" {
+			preflightMessage = "quota parameter not found from add-on or default quota"
 		}
 		// Informative `else`
 		// } else {
-		// Otherwise, the parameter was not found, but the env var was set,
+		// Otherwise, the parameter was not found, but the default quota is set,
 		// it'll be defaulted from there so the preflight check can pass
 		// }
 
@@ -883,6 +1077,18 @@ func (r *RHMIReconciler) preflightChecks(installation *rhmiv1alpha1.RHMI, instal
 		}
 	}
 
+	// re-bind the namespaces and cloud resources of a deleted installation so
+	// they aren't reported as conflicts below
+	adopted, err := resources.AdoptOrphanedResources(context.TODO(), r.Client, installation, log)
+	if err != nil {
+		log.Warningf("error adopting resources of a deleted installation", l.Fields{"error": err.Error()})
+		return result, err
+	}
+	if len(adopted) > 0 {
+		eventRecorder.Eventf(installation, "Normal", rhmiv1alpha1.EventResourcesAdopted,
+			"adopted namespaces of a deleted installation: %s", strings.Join(adopted, ", "))
+	}
+
 	log.Info("getting namespaces")
 	namespaces := &corev1.NamespaceList{}
 	err = r.List(context.TODO(), namespaces)
@@ -968,6 +1174,10 @@ func (r *RHMIReconciler) checkNamespaceForProducts(ns corev1.Namespace, installa
 	if strings.HasPrefix(ns.Name, "kube-") {
 		return foundProducts, nil
 	}
+	// products in namespaces owned or adopted by the installation aren't conflicts
+	if resources.IsOwnedBy(&ns, installation) {
+		return foundProducts, nil
+	}
 	// new client to avoid caching issues
 	serverClient, err := k8sclient.New(r.restConfig, k8sclient.Options{
 		Scheme: r.mgr.GetScheme(),
@@ -1011,6 +1221,7 @@ func (r *RHMIReconciler) bootstrapStage(installation *rhmiv1alpha1.RHMI, configM
 	if err != nil {
 		return rhmiv1alpha1.PhaseFailed, fmt.Errorf("could not create server client: %w", err)
 	}
+	serverClient = secretscan.NewClient(serverClient, log)
 
 	phase, err := reconciler.Reconcile(context.TODO(), installation, serverClient, quota, request)
 	if err != nil || phase == rhmiv1alpha1.PhaseFailed {
@@ -1021,76 +1232,113 @@ func (r *RHMIReconciler) bootstrapStage(installation *rhmiv1alpha1.RHMI, configM
 }
 
 func (r *RHMIReconciler) processStage(installation *rhmiv1alpha1.RHMI, stage *Stage,
-	configManager config.ConfigReadWriter, quotaconfig *quota.Quota, _ l.Logger) (rhmiv1alpha1.StatusPhase, error) {
+	configManager config.ConfigReadWriter, quotaconfig *quota.Quota, _ l.Logger, stageSpan *tracing.Span) (rhmiv1alpha1.StatusPhase, error) {
 	incompleteStage := false
 	productVersionMismatchFound = false
 
 	var mErr error
+	var skewErrors []string
 	installation.Status.Stage = stage.Name
 
-	for productName := range stage.Products {
-		productStatus := stage.Products[productName]
-		productLog := l.NewLoggerWithContext(l.Fields{l.ProductLogContext: productStatus.Name})
-
-		reconciler, err := products.NewReconciler(productStatus.Name, r.restConfig, configManager, installation, r.mgr, productLog, r.productsInstallationLoader)
-
-		if err != nil {
-			return rhmiv1alpha1.PhaseFailed, fmt.Errorf("failed to build a reconciler for %s: %w", productStatus.Name, err)
-		}
-
-		if !reconciler.VerifyVersion(installation) {
-			productVersionMismatchFound = true
-		}
-
-		serverClient, err := k8sclient.New(r.restConfig, k8sclient.Options{
-			Scheme: r.mgr.GetScheme(),
-		})
-		if err != nil {
-			return rhmiv1alpha1.PhaseFailed, fmt.Errorf("could not create server client: %w", err)
-		}
+	if err := stage.validateDependencies(); err != nil {
+		return rhmiv1alpha1.PhaseFailed, err
+	}
 
-		uninstall := false
-		if productStatus.Uninstall || installation.DeletionTimestamp != nil {
-			uninstall = true
-		}
-		productStatus.Phase, err = reconciler.Reconcile(context.TODO(), installation, &productStatus, serverClient, quotaconfig.GetProduct(productName), uninstall)
+	serverClient, err := k8sclient.New(r.restConfig, k8sclient.Options{
+		Scheme: r.mgr.GetScheme(),
+	})
+	if err != nil {
+		return rhmiv1alpha1.PhaseFailed, fmt.Errorf("could not create server client: %w", err)
+	}
+	serverClient = secretscan.NewClient(serverClient, log)
+	shared := newSharedInstallation(installation)
+
+	// the products are reconciled in waves, each wave reconciles the products
+	// which dependencies completed in the previous ones concurrently
+	reconciled := map[rhmiv1alpha1.ProductName]bool{}
+	versionMismatches := map[rhmiv1alpha1.ProductName]bool{}
+	for ready := stage.readyProducts(reconciled); len(ready) > 0; ready = stage.readyProducts(reconciled) {
+		results := r.reconcileProducts(installation, stage, ready, configManager, quotaconfig, serverClient, shared, stageSpan)
+		shared.apply(installation)
+
+		for _, result := range results {
+			productName := result.productName
+			productStatus := result.status
+			reconciled[productName] = true
+			if result.versionMismatch {
+				productVersionMismatchFound = true
+				versionMismatches[productName] = true
+			}
+			if result.failure != nil {
+				return rhmiv1alpha1.PhaseFailed, result.failure
+			}
+			if result.installation == nil {
+				stage.Products[productName] = productStatus
+				continue
+			}
+			mergeInstallationStatus(installation, result.original, result.installation)
 
-		if err != nil {
-			if mErr == nil {
-				mErr = &resources.MultiErr{}
+			if result.err != nil {
+				if mErr == nil {
+					mErr = &resources.MultiErr{}
+				}
+				mErr.(*resources.MultiErr).Add(fmt.Errorf("failed installation of %s: %w", productStatus.Name, result.err))
+				if resources.IsVersionSkewError(result.err) {
+					skewErrors = append(skewErrors, result.err.Error())
+				}
 			}
-			mErr.(*resources.MultiErr).Add(fmt.Errorf("failed installation of %s: %w", productStatus.Name, err))
-		}
 
-		// Verify that watches for this productStatus CRDs have been created
-		productConfig, err := configManager.ReadProduct(productStatus.Name)
-		if err != nil {
-			return rhmiv1alpha1.PhaseFailed, fmt.Errorf("failed to read productStatus config for %s: %v", string(productStatus.Name), err)
-		}
+			// Verify that watches for this productStatus CRDs have been created
+			productConfig, err := configManager.ReadProduct(productStatus.Name)
+			if err != nil {
+				return rhmiv1alpha1.PhaseFailed, fmt.Errorf("failed to read productStatus config for %s: %v", string(productStatus.Name), err)
+			}
 
-		if productStatus.Phase == rhmiv1alpha1.PhaseCompleted && productName != rhmiv1alpha1.ProductObservability { // TODO MGDAPI-5833 : remove the product name check
-			for _, crd := range productConfig.GetWatchableCRDs() {
-				namespace := productConfig.GetNamespace()
-				gvk := crd.GetObjectKind().GroupVersionKind().String()
-				if r.customInformers[gvk] == nil {
-					r.customInformers[gvk] = make(map[string]*cache.Informer)
-				}
-				if r.customInformers[gvk][productConfig.GetNamespace()] == nil {
-					err = r.addCustomInformer(crd, namespace)
-					if err != nil {
-						return rhmiv1alpha1.PhaseFailed, fmt.Errorf("failed to create a %s CRD watch for %s: %v", gvk, string(productStatus.Name), err)
+			if productStatus.Phase == rhmiv1alpha1.PhaseCompleted && productName != rhmiv1alpha1.ProductObservability { // TODO MGDAPI-5833 : remove the product name check
+				for _, crd := range productConfig.GetWatchableCRDs() {
+					namespace := productConfig.GetNamespace()
+					gvk := crd.GetObjectKind().GroupVersionKind().String()
+					if r.customInformers[gvk] == nil {
+						r.customInformers[gvk] = make(map[string]*cache.Informer)
+					}
+					if r.customInformers[gvk][productConfig.GetNamespace()] == nil {
+						err = r.addCustomInformer(crd, namespace)
+						if err != nil {
+							return rhmiv1alpha1.PhaseFailed, fmt.Errorf("failed to create a %s CRD watch for %s: %v", gvk, string(productStatus.Name), err)
+						}
+					} else if !(*r.customInformers[gvk][productConfig.GetNamespace()]).HasSynced() {
+						return rhmiv1alpha1.PhaseFailed, fmt.Errorf("A %s CRD Informer for %s has not synced", gvk, string(productStatus.Name))
 					}
-				} else if !(*r.customInformers[gvk][productConfig.GetNamespace()]).HasSynced() {
-					return rhmiv1alpha1.PhaseFailed, fmt.Errorf("A %s CRD Informer for %s has not synced", gvk, string(productStatus.Name))
 				}
 			}
+
+			if productStatus.Phase == rhmiv1alpha1.PhaseCompleted {
+				r.productsReconciled[productName] = time.Now()
+			}
+			events.HandleProductFailed(r.mgr.GetEventRecorderFor(string(productName)), installation, stage.Name, productName, productStatus.Phase, result.err)
+			stage.Products[productName] = productStatus
 		}
+	}
 
+	r.advanceProgressiveUpgrade(context.TODO(), serverClient, installation, stage, configManager, versionMismatches, r.mgr.GetEventRecorderFor("Upgrade"))
+
+	for productName, productStatus := range stage.Products {
+		// the products left are waiting for their dependencies to complete
+		if !reconciled[productName] {
+			productStatus.Phase = rhmiv1alpha1.PhaseAwaitingComponents
+			stage.Products[productName] = productStatus
+			metrics.SetProductPhase(productName, stage.Name, productStatus.Phase)
+		}
 		//found an incomplete productStatus
-		if productStatus.Phase != rhmiv1alpha1.PhaseCompleted {
+		if !isProductComplete(stage.Products[productName].Phase) {
 			incompleteStage = true
 		}
-		stage.Products[productName] = productStatus
+	}
+
+	if len(skewErrors) > 0 {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.VersionSkewBlockedCondition(strings.Join(skewErrors, ", ")))
+	} else if installation.IsVersionSkewBlocked() {
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.VersionSkewSupportedCondition())
 	}
 
 	//some products in this stage have not installed successfully yet
@@ -1100,6 +1348,117 @@ func (r *RHMIReconciler) processStage(installation *rhmiv1alpha1.RHMI, stage *St
 	return rhmiv1alpha1.PhaseCompleted, mErr
 }
 
+// exportTrace exports the spans of the reconcile to the tracing collector in
+// the background, so a slow collector doesn't delay the reconciles
+func exportTrace(installation *rhmiv1alpha1.RHMI, trace *tracing.Trace) {
+	if trace == nil {
+		return
+	}
+	endpoint := tracing.SpansEndpoint(installation)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := trace.Export(ctx, &http.Client{}, endpoint); err != nil {
+			log.Error("failed to export the reconcile spans", err)
+		}
+	}()
+}
+
+// productResult is the outcome of the reconcile of a product of a stage
+type productResult struct {
+	productName rhmiv1alpha1.ProductName
+	status      rhmiv1alpha1.RHMIProductStatus
+	// original and installation are the copy of the installation before and
+	// after the reconcile, installation is nil when the product isn't due
+	original     *rhmiv1alpha1.RHMI
+	installation *rhmiv1alpha1.RHMI
+	// err is the reconcile error, failure the error that fails the stage
+	err             error
+	failure         error
+	versionMismatch bool
+}
+
+// reconcileProducts reconciles the products concurrently, up to the max
+// concurrent products of the installation at once, each with its own copy of
+// the installation. The results are in the order of the products
+func (r *RHMIReconciler) reconcileProducts(installation *rhmiv1alpha1.RHMI, stage *Stage, productNames []rhmiv1alpha1.ProductName,
+	configManager config.ConfigReadWriter, quotaconfig *quota.Quota, serverClient k8sclient.Client, shared *sharedInstallation, stageSpan *tracing.Span) []productResult {
+	results := make([]productResult, len(productNames))
+	workers := make(chan struct{}, installation.MaxConcurrentProducts())
+	var wg sync.WaitGroup
+	for i, productName := range productNames {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, productName rhmiv1alpha1.ProductName, productStatus rhmiv1alpha1.RHMIProductStatus) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = r.reconcileProduct(installation.DeepCopy(), stage.Name, productName, productStatus, configManager, quotaconfig, serverClient, shared, stageSpan)
+		}(i, productName, stage.Products[productName])
+	}
+	wg.Wait()
+	return results
+}
+
+// reconcileProduct reconciles the product with a copy of the installation,
+// the updates of the installation go through the shared installation
+func (r *RHMIReconciler) reconcileProduct(installation *rhmiv1alpha1.RHMI, stageName rhmiv1alpha1.StageName, productName rhmiv1alpha1.ProductName,
+	productStatus rhmiv1alpha1.RHMIProductStatus, configManager config.ConfigReadWriter, quotaconfig *quota.Quota,
+	serverClient k8sclient.Client, shared *sharedInstallation, stageSpan *tracing.Span) productResult {
+	result := productResult{productName: productName, status: productStatus}
+	productLog := l.NewLoggerWithContext(l.Fields{l.ProductLogContext: productStatus.Name})
+
+	reconciler, err := products.NewReconciler(productStatus.Name, r.restConfig, configManager, installation, r.mgr, productLog, r.productsInstallationLoader)
+	if err != nil {
+		result.failure = fmt.Errorf("failed to build a reconciler for %s: %w", productStatus.Name, err)
+		return result
+	}
+
+	if !reconciler.VerifyVersion(installation) {
+		result.versionMismatch = true
+	}
+
+	// The products waiting for their turn in the progressive upgrade keep
+	// their previous version and status
+	if previous, ok := installation.Status.Stages[stageName].Products[productName]; ok && installation.IsUpgradeHeld(productName) {
+		result.status = previous
+		result.versionMismatch = true
+		metrics.SetProductPhase(productName, stageName, previous.Phase)
+		return result
+	}
+
+	// Products with their own reconcile interval keep their previous status
+	// until it passes, unless the installation is upgrading
+	if previous, ok := installation.Status.Stages[stageName].Products[productName]; ok && !r.isProductReconcileDue(installation, productName, previous) {
+		result.status = previous
+		metrics.SetProductPhase(productName, stageName, previous.Phase)
+		return result
+	}
+
+	// the last pre-upgrade backup is only recorded when the product upgrades
+	if previous, ok := installation.Status.Stages[stageName].Products[productName]; ok {
+		result.status.PreUpgradeBackup = previous.PreUpgradeBackup
+	}
+
+	// disabled products are uninstalled, removing their namespaces, and
+	// reported as skipped once they're gone
+	disabled := installation.IsProductDisabled(productName)
+	uninstall := false
+	if productStatus.Uninstall || installation.DeletionTimestamp != nil || disabled {
+		uninstall = true
+	}
+	result.original = installation.DeepCopy()
+	span := stageSpan.StartChild(fmt.Sprintf("reconcile %s", productName), map[string]string{"product": string(productName), "stage": string(stageName)})
+	start := time.Now()
+	result.status.Phase, result.err = reconciler.Reconcile(context.TODO(), installation, &result.status, newInstallationClient(serverClient, shared, installation), quotaconfig.GetProduct(productName), uninstall)
+	if disabled && result.status.Phase == rhmiv1alpha1.PhaseCompleted && installation.DeletionTimestamp == nil {
+		result.status.Phase = rhmiv1alpha1.PhaseSkipped
+	}
+	metrics.ObserveProductReconcile(productName, stageName, result.status.Phase, time.Since(start), result.err)
+	span.End(result.err)
+	result.installation = installation
+	return result
+}
+
 // handle the deletion of CRO config map
 func (r *RHMIReconciler) handleCROConfigDeletion(rhmi rhmiv1alpha1.RHMI) error {
 	// get cloud resource config map
@@ -1189,9 +1548,12 @@ func (r *RHMIReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	reconcileController, err := ctrl.NewControllerManagedBy(mgr).
 		For(&rhmiv1alpha1.RHMI{}).
 		Watches(&source.Kind{Type: &usersv1.User{}}, &handler.EnqueueRequestForObject{}).
-		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForSecret)).
 		Watches(&source.Kind{Type: &usersv1.Group{}}, &handler.EnqueueRequestForObject{}).
 		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &rhmiv1alpha1.Quota{}}, handler.EnqueueRequestsFromMapFunc(r.installationsInNamespace)).
+		Watches(&source.Kind{Type: &rhmiv1alpha1.RealmCustomization{}}, handler.EnqueueRequestsFromMapFunc(r.installationsInNamespace)).
+		WithOptions(controller.Options{RateLimiter: newRateLimiter(r.errorBackoff)}).
 		Build(r)
 
 	if err != nil {
@@ -1203,6 +1565,70 @@ func (r *RHMIReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return nil
 }
 
+// installationsInNamespace enqueues the installations of the namespace of a
+// Quota or RealmCustomization CR, so the changes to them are applied without
+// waiting for the next reconcile
+func (r *RHMIReconciler) installationsInNamespace(obj k8sclient.Object) []ctrl.Request {
+	installations := &rhmiv1alpha1.RHMIList{}
+	if err := r.List(context.TODO(), installations, k8sclient.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(fmt.Sprintf("Error listing the installations for %s", obj.GetName()), err)
+		return nil
+	}
+
+	requests := []ctrl.Request{}
+	for _, installation := range installations.Items {
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: installation.Namespace, Name: installation.Name},
+		})
+	}
+	return requests
+}
+
+// requestsForSecret enqueues the secret, as well as the installations of its
+// namespace serving it on a custom domain route, so rotated certificates are
+// picked up without waiting for the next reconcile
+func (r *RHMIReconciler) requestsForSecret(obj k8sclient.Object) []ctrl.Request {
+	requests := []ctrl.Request{{
+		NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()},
+	}}
+
+	installations := &rhmiv1alpha1.RHMIList{}
+	if err := r.List(context.TODO(), installations, k8sclient.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error("Error listing the installations for the secret", err)
+		return requests
+	}
+	for i := range installations.Items {
+		for _, name := range append(certificates.SecretNames(&installations.Items[i]), externalsecrets.SecretNames(&installations.Items[i])...) {
+			if name == obj.GetName() {
+				requests = append(requests, ctrl.Request{
+					NamespacedName: types.NamespacedName{Namespace: installations.Items[i].Namespace, Name: installations.Items[i].Name},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// reconcileCertificates reports the customer provided certificates in the
+// installation status, and reconciles the products serving a rotated
+// certificate on the next stage run instead of waiting for their interval
+func (r *RHMIReconciler) reconcileCertificates(installation *rhmiv1alpha1.RHMI, recorder record.EventRecorder) error {
+	rotated, err := certificates.ReconcileStatus(context.TODO(), r.Client, installation, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(rotated) == 0 {
+		return nil
+	}
+
+	for _, productName := range certificates.Products {
+		delete(r.productsReconciled, productName)
+	}
+	recorder.Event(installation, "Normal", rhmiv1alpha1.EventCertificateRotated, fmt.Sprintf("Certificates rotated in secrets: %s", strings.Join(rotated, ", ")))
+	return nil
+}
+
 func (r *RHMIReconciler) createInstallationCR(ctx context.Context, serverClient k8sclient.Client) (*rhmiv1alpha1.RHMI, error) {
 
 	const managedApiInstallationName = "rhoam"
@@ -1240,7 +1666,7 @@ func (r *RHMIReconciler) createInstallationCR(ctx context.Context, serverClient
 		}
 
 		if priorityClassName == "" {
-			priorityClassName = managedServicePriorityClassName
+			priorityClassName = rhmiv1alpha1.DefaultPriorityClassName
 		}
 
 		customerAlertingEmailAddress, _, err := addon.GetStringParameter(
@@ -1253,8 +1679,17 @@ func (r *RHMIReconciler) createInstallationCR(ctx context.Context, serverClient
 			return nil, fmt.Errorf("failed while retrieving addon parameter: %w", err)
 		}
 
-		namespaceSegments := strings.Split(namespace, "-")
-		namespacePrefix := strings.Join(namespaceSegments[0:2], "-") + "-"
+		// the namespace prefix is derived from the operator namespace
+		// when it isn't set, e.g. redhat-rhoam- for redhat-rhoam-operator
+		namespacePrefix, _ := os.LookupEnv(namespacePrefixEnvName)
+		if namespacePrefix == "" {
+			namespaceSegments := strings.Split(namespace, "-")
+			namespacePrefix = strings.Join(namespaceSegments[0:2], "-") + "-"
+		}
+		namespaceTopology, _ := os.LookupEnv(namespaceTopologyEnvName)
+		if namespaceTopology == "" {
+			namespaceTopology = string(rhmiv1alpha1.NamespaceTopologyPerProduct)
+		}
 
 		installation := &rhmiv1alpha1.RHMI{
 			ObjectMeta: metav1.ObjectMeta{
@@ -1276,6 +1711,7 @@ func (r *RHMIReconciler) createInstallationCR(ctx context.Context, serverClient
 					CSSRE:        cssreAlertingEmailAddress,
 				},
 				OperatorsInProductNamespace: false, // e2e tests and Makefile need to be updated when default is changed
+				NamespaceTopology:           rhmiv1alpha1.NamespaceTopology(namespaceTopology),
 				PriorityClassName:           priorityClassName,
 			},
 		}
@@ -1293,6 +1729,22 @@ func (r *RHMIReconciler) createInstallationCR(ctx context.Context, serverClient
 
 }
 
+// setComponentInfoMetric exposes the operator build and the digests of the
+// running operand images. Pods are listed with the API reader to avoid
+// caching every pod in the cluster, at most once per cache TTL
+func (r *RHMIReconciler) setComponentInfoMetric(installation *rhmiv1alpha1.RHMI) {
+	if r.buildInfo == nil {
+		return
+	}
+
+	info, err := r.buildInfo.Get(context.TODO(), installation)
+	if err != nil {
+		log.Error("error getting component build info:", err)
+		return
+	}
+	metrics.SetComponentInfo(info)
+}
+
 func (r *RHMIReconciler) setRHOAMClusterMetric() error {
 
 	clusterVersionCR, err := cluster.GetClusterVersionCR(context.TODO(), r.Client)
@@ -1452,3 +1904,61 @@ func getInstallation() (*rhmiv1alpha1.RHMI, error) {
 		},
 	}, nil
 }
+
+// isProductReconcileDue when the product must be reconciled, products with their own reconcile interval are
+// only reconciled once it passed since they last completed
+func (r *RHMIReconciler) isProductReconcileDue(installation *rhmiv1alpha1.RHMI, productName rhmiv1alpha1.ProductName, previous rhmiv1alpha1.RHMIProductStatus) bool {
+	interval := installation.ProductReconcileInterval(productName)
+	if interval == 0 || installation.Status.ToVersion != "" || previous.Phase != rhmiv1alpha1.PhaseCompleted {
+		return true
+	}
+	lastReconciled, ok := r.productsReconciled[productName]
+	return !ok || time.Since(lastReconciled) >= interval
+}
+
+// reconcilePaused sets the paused condition on the installation, emitting an event on each transition, and
+// returns true when the product and cloud resource reconciles should be skipped
+func reconcilePaused(installation *rhmiv1alpha1.RHMI, recorder record.EventRecorder) bool {
+	wasPaused := apimeta.IsStatusConditionTrue(installation.Status.Conditions, rhmiv1alpha1.PausedConditionType.String())
+
+	if installation.IsPaused() {
+		condition := installation.PausedCondition()
+		apimeta.SetStatusCondition(&installation.Status.Conditions, condition)
+		if !wasPaused {
+			log.Info("Installation paused")
+			recorder.Event(installation, "Normal", rhmiv1alpha1.EventPaused, condition.Message)
+		}
+		return true
+	}
+
+	if wasPaused {
+		log.Info("Installation resumed")
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.ResumedCondition())
+		recorder.Event(installation, "Normal", rhmiv1alpha1.EventResumed, "Product and cloud resource reconciles resumed")
+	}
+	return false
+}
+
+// reconcileReadOnlyMode sets the read only mode condition on the installation, emitting an event on each
+// transition, and returns true when the product reconciles should be skipped
+func reconcileReadOnlyMode(installation *rhmiv1alpha1.RHMI, clusterVersionCR *configv1.ClusterVersion, recorder record.EventRecorder) bool {
+	readOnly, reason := installation.IsReadOnlyModeRequested(cluster.IsClusterUpgrading(clusterVersionCR))
+	wasReadOnly := apimeta.IsStatusConditionTrue(installation.Status.Conditions, rhmiv1alpha1.ReadOnlyModeConditionType.String())
+
+	if readOnly {
+		condition := installation.ReadOnlyModeActiveCondition(reason, "Product reconciles suspended, status and alerting still reported")
+		apimeta.SetStatusCondition(&installation.Status.Conditions, condition)
+		if !wasReadOnly {
+			log.Infof("Entered read only mode", l.Fields{"reason": reason})
+			recorder.Event(installation, "Normal", rhmiv1alpha1.EventReadOnlyModeOn, fmt.Sprintf("%s: %s", reason, condition.Message))
+		}
+		return true
+	}
+
+	if wasReadOnly {
+		log.Info("Left read only mode")
+		apimeta.SetStatusCondition(&installation.Status.Conditions, installation.ReadOnlyModeInactiveCondition())
+		recorder.Event(installation, "Normal", rhmiv1alpha1.EventReadOnlyModeOff, "Product reconciles resumed")
+	}
+	return false
+}
@@ -11,10 +11,13 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/config"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
 	"github.com/integr8ly/integreatly-operator/utils"
+	configv1 "github.com/openshift/api/config/v1"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	packageOperatorv1alpha1 "package-operator.run/apis/core/v1alpha1"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -562,3 +565,224 @@ func TestRHMIReconciler_checkClusterPackageAvailability(t *testing.T) {
 		})
 	}
 }
+
+func Test_reconcileReadOnlyMode(t *testing.T) {
+	upgrading := &configv1.ClusterVersion{
+		Status: configv1.ClusterVersionStatus{
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue},
+			},
+		},
+	}
+	idle := &configv1.ClusterVersion{}
+
+	tests := []struct {
+		name           string
+		readOnlyMode   rhmiv1alpha1.ReadOnlyMode
+		wasReadOnly    bool
+		clusterVersion *configv1.ClusterVersion
+		want           bool
+		wantReason     string
+		wantEvent      bool
+	}{
+		{
+			name:           "test read only mode entered while the cluster is upgrading",
+			clusterVersion: upgrading,
+			want:           true,
+			wantReason:     "ClusterUpgrading",
+			wantEvent:      true,
+		},
+		{
+			name:           "test no event while read only mode stays active",
+			clusterVersion: upgrading,
+			wasReadOnly:    true,
+			want:           true,
+			wantReason:     "ClusterUpgrading",
+		},
+		{
+			name:           "test read only mode left when the cluster upgrade completes",
+			clusterVersion: idle,
+			wasReadOnly:    true,
+			wantReason:     "ReconcilesResumed",
+			wantEvent:      true,
+		},
+		{
+			name:           "test read only mode manually enabled",
+			readOnlyMode:   rhmiv1alpha1.ReadOnlyModeEnabled,
+			clusterVersion: idle,
+			want:           true,
+			wantReason:     "ManuallyEnabled",
+			wantEvent:      true,
+		},
+		{
+			name:           "test read only mode disabled during cluster upgrades",
+			readOnlyMode:   rhmiv1alpha1.ReadOnlyModeDisabled,
+			clusterVersion: upgrading,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &rhmiv1alpha1.RHMI{Spec: rhmiv1alpha1.RHMISpec{ReadOnlyMode: tt.readOnlyMode}}
+			if tt.wasReadOnly {
+				apimeta.SetStatusCondition(&installation.Status.Conditions, installation.ReadOnlyModeActiveCondition("ClusterUpgrading", ""))
+			}
+			recorder := record.NewFakeRecorder(10)
+
+			if got := reconcileReadOnlyMode(installation, tt.clusterVersion, recorder); got != tt.want {
+				t.Fatalf("reconcileReadOnlyMode() = %v, want %v", got, tt.want)
+			}
+			condition := installation.GetCondition(rhmiv1alpha1.ReadOnlyModeConditionType)
+			if tt.wantReason == "" && condition != nil {
+				t.Fatalf("expected no read only mode condition, got %+v", condition)
+			}
+			if tt.wantReason != "" && (condition == nil || condition.Reason != tt.wantReason) {
+				t.Fatalf("expected read only mode condition with reason %s, got %+v", tt.wantReason, condition)
+			}
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tt.wantEvent {
+				t.Fatalf("expected event %v, got %v", tt.wantEvent, gotEvent)
+			}
+		})
+	}
+}
+
+func Test_reconcilePaused(t *testing.T) {
+	tests := []struct {
+		name       string
+		paused     string
+		wasPaused  bool
+		want       bool
+		wantStatus metav1.ConditionStatus
+		wantEvent  bool
+	}{
+		{
+			name: "test not paused without the annotation",
+		},
+		{
+			name:       "test paused when annotated",
+			paused:     "true",
+			want:       true,
+			wantStatus: metav1.ConditionTrue,
+			wantEvent:  true,
+		},
+		{
+			name:       "test no event while staying paused",
+			paused:     "true",
+			wasPaused:  true,
+			want:       true,
+			wantStatus: metav1.ConditionTrue,
+		},
+		{
+			name:       "test resumed when the annotation is removed",
+			wasPaused:  true,
+			wantStatus: metav1.ConditionFalse,
+			wantEvent:  true,
+		},
+		{
+			name:       "test resumed when the annotation isn't true",
+			paused:     "false",
+			wasPaused:  true,
+			wantStatus: metav1.ConditionFalse,
+			wantEvent:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &rhmiv1alpha1.RHMI{}
+			if tt.paused != "" {
+				installation.SetAnnotations(map[string]string{rhmiv1alpha1.PausedAnnotation: tt.paused})
+			}
+			if tt.wasPaused {
+				apimeta.SetStatusCondition(&installation.Status.Conditions, installation.PausedCondition())
+			}
+			recorder := record.NewFakeRecorder(10)
+
+			if got := reconcilePaused(installation, recorder); got != tt.want {
+				t.Fatalf("reconcilePaused() = %v, want %v", got, tt.want)
+			}
+			condition := installation.GetCondition(rhmiv1alpha1.PausedConditionType)
+			if tt.wantStatus == "" && condition != nil {
+				t.Fatalf("expected no paused condition, got %+v", condition)
+			}
+			if tt.wantStatus != "" && (condition == nil || condition.Status != tt.wantStatus) {
+				t.Fatalf("expected paused condition with status %s, got %+v", tt.wantStatus, condition)
+			}
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tt.wantEvent {
+				t.Fatalf("expected event %v, got %v", tt.wantEvent, gotEvent)
+			}
+		})
+	}
+}
+
+func TestRHMIReconciler_isProductReconcileDue(t *testing.T) {
+	reconcileSpec := &rhmiv1alpha1.ReconcileSpec{
+		Products: []rhmiv1alpha1.ProductReconcileSpec{
+			{Name: rhmiv1alpha1.ProductGrafana, Interval: metav1.Duration{Duration: time.Hour}},
+		},
+	}
+	completed := rhmiv1alpha1.RHMIProductStatus{Name: rhmiv1alpha1.ProductGrafana, Phase: rhmiv1alpha1.PhaseCompleted}
+
+	tests := []struct {
+		name           string
+		reconcile      *rhmiv1alpha1.ReconcileSpec
+		toVersion      string
+		previous       rhmiv1alpha1.RHMIProductStatus
+		lastReconciled time.Duration
+		want           bool
+	}{
+		{
+			name:     "test due without a product interval",
+			previous: completed,
+			want:     true,
+		},
+		{
+			name:           "test not due before the interval passed",
+			reconcile:      reconcileSpec,
+			previous:       completed,
+			lastReconciled: time.Minute,
+			want:           false,
+		},
+		{
+			name:           "test due once the interval passed",
+			reconcile:      reconcileSpec,
+			previous:       completed,
+			lastReconciled: 2 * time.Hour,
+			want:           true,
+		},
+		{
+			name:      "test due when never reconciled by this operator",
+			reconcile: reconcileSpec,
+			previous:  completed,
+			want:      true,
+		},
+		{
+			name:           "test due while upgrading",
+			reconcile:      reconcileSpec,
+			toVersion:      "1.2.3",
+			previous:       completed,
+			lastReconciled: time.Minute,
+			want:           true,
+		},
+		{
+			name:           "test due while the product isn't complete",
+			reconcile:      reconcileSpec,
+			previous:       rhmiv1alpha1.RHMIProductStatus{Name: rhmiv1alpha1.ProductGrafana, Phase: rhmiv1alpha1.PhaseInProgress},
+			lastReconciled: time.Minute,
+			want:           true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RHMIReconciler{productsReconciled: map[rhmiv1alpha1.ProductName]time.Time{}}
+			if tt.lastReconciled != 0 {
+				r.productsReconciled[rhmiv1alpha1.ProductGrafana] = time.Now().Add(-tt.lastReconciled)
+			}
+			installation := &rhmiv1alpha1.RHMI{
+				Spec:   rhmiv1alpha1.RHMISpec{Reconcile: tt.reconcile},
+				Status: rhmiv1alpha1.RHMIStatus{ToVersion: tt.toVersion},
+			}
+			if got := r.isProductReconcileDue(installation, rhmiv1alpha1.ProductGrafana, tt.previous); got != tt.want {
+				t.Errorf("isProductReconcileDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// productDependencies are the products each product needs to be complete
+// before it's reconciled. The products of a stage without dependencies
+// between them are reconciled concurrently, the dependencies on products
+// that aren't part of the stage are ignored
+var productDependencies = map[integreatlyv1alpha1.ProductName][]integreatlyv1alpha1.ProductName{
+	// the SSO instances store their data in the CRO managed databases
+	integreatlyv1alpha1.ProductRHSSO:     {integreatlyv1alpha1.ProductCloudResources},
+	integreatlyv1alpha1.ProductRHSSOUser: {integreatlyv1alpha1.ProductCloudResources},
+	// 3scale stores its data in the CRO managed databases and the MCG
+	// bucket, and its portals log in with the SSO instance
+	integreatlyv1alpha1.Product3Scale: {
+		integreatlyv1alpha1.ProductCloudResources,
+		integreatlyv1alpha1.ProductMCG,
+		integreatlyv1alpha1.ProductRHSSO,
+	},
+	// the discovery service of marin3r is deployed in the 3scale namespace
+	integreatlyv1alpha1.ProductMarin3r: {integreatlyv1alpha1.Product3Scale},
+}
+
+// dependencies returns the products of the stage the product depends on
+func (s *Stage) dependencies(productName integreatlyv1alpha1.ProductName) []integreatlyv1alpha1.ProductName {
+	var dependencies []integreatlyv1alpha1.ProductName
+	for _, dependency := range productDependencies[productName] {
+		if _, ok := s.Products[dependency]; ok {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
+// readyProducts returns the products of the stage that aren't reconciled yet
+// and which dependencies are complete, sorted by name
+func (s *Stage) readyProducts(reconciled map[integreatlyv1alpha1.ProductName]bool) []integreatlyv1alpha1.ProductName {
+	var ready []integreatlyv1alpha1.ProductName
+	for productName := range s.Products {
+		if reconciled[productName] {
+			continue
+		}
+		isReady := true
+		for _, dependency := range s.dependencies(productName) {
+			if !reconciled[dependency] || !isProductComplete(s.Products[dependency].Phase) {
+				isReady = false
+				break
+			}
+		}
+		if isReady {
+			ready = append(ready, productName)
+		}
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+	return ready
+}
+
+// validateDependencies returns an error when the dependencies of the products
+// of the stage form a cycle, as the products in it would never be reconciled
+func (s *Stage) validateDependencies() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[integreatlyv1alpha1.ProductName]int{}
+	var visit func(productName integreatlyv1alpha1.ProductName) error
+	visit = func(productName integreatlyv1alpha1.ProductName) error {
+		switch state[productName] {
+		case visiting:
+			return fmt.Errorf("dependency cycle in stage %s at product %s", s.Name, productName)
+		case visited:
+			return nil
+		}
+		state[productName] = visiting
+		for _, dependency := range s.dependencies(productName) {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[productName] = visited
+		return nil
+	}
+	for productName := range s.Products {
+		if err := visit(productName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isProductComplete(phase integreatlyv1alpha1.StatusPhase) bool {
+	return phase == integreatlyv1alpha1.PhaseCompleted || phase == integreatlyv1alpha1.PhaseSkipped
+}
+
+// mergeStatusList applies the entries a product added, changed and removed in
+// a list of its copy of the installation status, matching them by key
+func mergeStatusList[T any](list *[]T, original, reconciled []T, key func(T) string) {
+	originalEntries := map[string]T{}
+	for _, entry := range original {
+		originalEntries[key(entry)] = entry
+	}
+	reconciledKeys := map[string]bool{}
+	for _, entry := range reconciled {
+		reconciledKeys[key(entry)] = true
+		if previous, ok := originalEntries[key(entry)]; ok && reflect.DeepEqual(previous, entry) {
+			continue
+		}
+		set := false
+		for i := range *list {
+			if key((*list)[i]) == key(entry) {
+				(*list)[i] = entry
+				set = true
+				break
+			}
+		}
+		if !set {
+			*list = append(*list, entry)
+		}
+	}
+
+	var merged []T
+	for _, entry := range *list {
+		if _, removed := originalEntries[key(entry)]; removed && !reconciledKeys[key(entry)] {
+			continue
+		}
+		merged = append(merged, entry)
+	}
+	*list = merged
+}
+
+// sharedInstallation is the metadata of the installation the products
+// reconciled concurrently update. Each product is reconciled with its own
+// copy of the installation, the finalizers they add and remove are applied
+// to the latest version so they don't conflict
+type sharedInstallation struct {
+	mu              sync.Mutex
+	name            string
+	namespace       string
+	finalizers      []string
+	resourceVersion string
+}
+
+func newSharedInstallation(installation *integreatlyv1alpha1.RHMI) *sharedInstallation {
+	return &sharedInstallation{
+		name:            installation.Name,
+		namespace:       installation.Namespace,
+		finalizers:      append([]string{}, installation.Finalizers...),
+		resourceVersion: installation.ResourceVersion,
+	}
+}
+
+// apply sets the finalizers and resource version updated by the products on
+// the installation
+func (s *sharedInstallation) apply(installation *integreatlyv1alpha1.RHMI) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	installation.Finalizers = append([]string{}, s.finalizers...)
+	installation.ResourceVersion = s.resourceVersion
+}
+
+// installationClient serializes the updates of the copy of the installation
+// of a product through the sharedInstallation
+type installationClient struct {
+	k8sclient.Client
+	shared *sharedInstallation
+	// finalizers of the copy of the installation when it was last synced
+	finalizers []string
+}
+
+func newInstallationClient(client k8sclient.Client, shared *sharedInstallation, installation *integreatlyv1alpha1.RHMI) *installationClient {
+	return &installationClient{
+		Client:     client,
+		shared:     shared,
+		finalizers: append([]string{}, installation.Finalizers...),
+	}
+}
+
+func (c *installationClient) Update(ctx context.Context, obj k8sclient.Object, opts ...k8sclient.UpdateOption) error {
+	installation, ok := obj.(*integreatlyv1alpha1.RHMI)
+	if !ok || installation.Name != c.shared.name || installation.Namespace != c.shared.namespace {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+
+	c.shared.mu.Lock()
+	defer c.shared.mu.Unlock()
+
+	finalizers := append([]string{}, c.shared.finalizers...)
+	for _, finalizer := range c.finalizers {
+		if !resources.Contains(installation.Finalizers, finalizer) {
+			finalizers = resources.Remove(finalizers, finalizer)
+		}
+	}
+	for _, finalizer := range installation.Finalizers {
+		if !resources.Contains(finalizers, finalizer) {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	installation.Finalizers = finalizers
+	installation.ResourceVersion = c.shared.resourceVersion
+
+	if err := c.Client.Update(ctx, installation, opts...); err != nil {
+		return err
+	}
+	c.shared.finalizers = append([]string{}, installation.Finalizers...)
+	c.shared.resourceVersion = installation.ResourceVersion
+	c.finalizers = append([]string{}, installation.Finalizers...)
+	return nil
+}
+
+// mergedStatusFields are the fields of the installation status that
+// mergeInstallationStatus merges per entry instead of copying them whole
+var mergedStatusFields = map[string]bool{
+	"Conditions":         true,
+	"ResourceOverrides":  true,
+	"CustomRoutes":       true,
+	"Certificates":       true,
+	"ExternalSecrets":    true,
+	"ImportedDashboards": true,
+	"EndpointHealth":     true,
+}
+
+// mergeInstallationStatus applies the changes a product made to the status of
+// its copy of the installation. The conditions and the keyed lists are merged
+// per entry so the products can set their own entries concurrently
+func mergeInstallationStatus(installation, original, reconciled *integreatlyv1alpha1.RHMI) {
+	status := reflect.ValueOf(&installation.Status).Elem()
+	originalStatus := reflect.ValueOf(original.Status)
+	reconciledStatus := reflect.ValueOf(reconciled.Status)
+	for i := 0; i < status.NumField(); i++ {
+		if mergedStatusFields[status.Type().Field(i).Name] {
+			continue
+		}
+		if !reflect.DeepEqual(originalStatus.Field(i).Interface(), reconciledStatus.Field(i).Interface()) {
+			status.Field(i).Set(reconciledStatus.Field(i))
+		}
+	}
+
+	mergeStatusList(&installation.Status.ResourceOverrides, original.Status.ResourceOverrides, reconciled.Status.ResourceOverrides,
+		func(workload string) string { return workload })
+	mergeStatusList(&installation.Status.CustomRoutes, original.Status.CustomRoutes, reconciled.Status.CustomRoutes,
+		func(route integreatlyv1alpha1.CustomRouteStatus) string { return string(route.Route) })
+	mergeStatusList(&installation.Status.Certificates, original.Status.Certificates, reconciled.Status.Certificates,
+		func(certificate integreatlyv1alpha1.CertificateStatus) string { return certificate.Secret })
+	mergeStatusList(&installation.Status.ExternalSecrets, original.Status.ExternalSecrets, reconciled.Status.ExternalSecrets,
+		func(secret integreatlyv1alpha1.ExternalSecretStatus) string { return secret.Name })
+	mergeStatusList(&installation.Status.ImportedDashboards, original.Status.ImportedDashboards, reconciled.Status.ImportedDashboards,
+		func(dashboard integreatlyv1alpha1.ImportedDashboardStatus) string {
+			return dashboard.Kind + "/" + dashboard.Namespace + "/" + dashboard.Name
+		})
+	mergeStatusList(&installation.Status.EndpointHealth, original.Status.EndpointHealth, reconciled.Status.EndpointHealth,
+		func(endpoint integreatlyv1alpha1.EndpointHealthStatus) string { return endpoint.Name })
+
+	for _, condition := range reconciled.Status.Conditions {
+		previous := apimeta.FindStatusCondition(original.Status.Conditions, condition.Type)
+		if previous == nil || !reflect.DeepEqual(*previous, condition) {
+			apimeta.SetStatusCondition(&installation.Status.Conditions, condition)
+		}
+	}
+	for _, condition := range original.Status.Conditions {
+		if apimeta.FindStatusCondition(reconciled.Status.Conditions, condition.Type) == nil {
+			apimeta.RemoveStatusCondition(&installation.Status.Conditions, condition.Type)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestStage_readyProducts(t *testing.T) {
+	stage := func(phases map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase) *Stage {
+		s := &Stage{Name: integreatlyv1alpha1.InstallStage, Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{}}
+		for productName, phase := range phases {
+			s.Products[productName] = integreatlyv1alpha1.RHMIProductStatus{Name: productName, Phase: phase}
+		}
+		return s
+	}
+	allProducts := map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+		integreatlyv1alpha1.ProductCloudResources: integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.ProductObservability:  integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.ProductRHSSO:          integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.Product3Scale:         integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.ProductRHSSOUser:      integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.ProductMarin3r:        integreatlyv1alpha1.PhaseNone,
+		integreatlyv1alpha1.ProductGrafana:        integreatlyv1alpha1.PhaseNone,
+	}
+	withPhases := func(phases map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase) map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase {
+		merged := map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{}
+		for productName, phase := range allProducts {
+			merged[productName] = phase
+		}
+		for productName, phase := range phases {
+			merged[productName] = phase
+		}
+		return merged
+	}
+
+	tests := []struct {
+		name       string
+		stage      *Stage
+		reconciled map[integreatlyv1alpha1.ProductName]bool
+		want       []integreatlyv1alpha1.ProductName
+	}{
+		{
+			name:  "test products without dependencies are ready first",
+			stage: stage(allProducts),
+			want: []integreatlyv1alpha1.ProductName{
+				integreatlyv1alpha1.ProductCloudResources,
+				integreatlyv1alpha1.ProductGrafana,
+				integreatlyv1alpha1.ProductObservability,
+			},
+		},
+		{
+			name: "test products are ready once their dependencies complete",
+			stage: stage(withPhases(map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+				integreatlyv1alpha1.ProductCloudResources: integreatlyv1alpha1.PhaseCompleted,
+				integreatlyv1alpha1.ProductObservability:  integreatlyv1alpha1.PhaseInProgress,
+				integreatlyv1alpha1.ProductGrafana:        integreatlyv1alpha1.PhaseCompleted,
+			})),
+			reconciled: map[integreatlyv1alpha1.ProductName]bool{
+				integreatlyv1alpha1.ProductCloudResources: true,
+				integreatlyv1alpha1.ProductObservability:  true,
+				integreatlyv1alpha1.ProductGrafana:        true,
+			},
+			want: []integreatlyv1alpha1.ProductName{
+				integreatlyv1alpha1.ProductRHSSO,
+				integreatlyv1alpha1.ProductRHSSOUser,
+			},
+		},
+		{
+			name: "test products wait for their incomplete dependencies",
+			stage: stage(withPhases(map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+				integreatlyv1alpha1.ProductCloudResources: integreatlyv1alpha1.PhaseCompleted,
+				integreatlyv1alpha1.ProductObservability:  integreatlyv1alpha1.PhaseCompleted,
+				integreatlyv1alpha1.ProductGrafana:        integreatlyv1alpha1.PhaseCompleted,
+				integreatlyv1alpha1.ProductRHSSO:          integreatlyv1alpha1.PhaseInProgress,
+				integreatlyv1alpha1.ProductRHSSOUser:      integreatlyv1alpha1.PhaseCompleted,
+			})),
+			reconciled: map[integreatlyv1alpha1.ProductName]bool{
+				integreatlyv1alpha1.ProductCloudResources: true,
+				integreatlyv1alpha1.ProductObservability:  true,
+				integreatlyv1alpha1.ProductGrafana:        true,
+				integreatlyv1alpha1.ProductRHSSO:          true,
+				integreatlyv1alpha1.ProductRHSSOUser:      true,
+			},
+		},
+		{
+			name: "test products wait for their dependencies to be reconciled",
+			stage: stage(withPhases(map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+				integreatlyv1alpha1.ProductCloudResources: integreatlyv1alpha1.PhaseCompleted,
+			})),
+			want: []integreatlyv1alpha1.ProductName{
+				integreatlyv1alpha1.ProductCloudResources,
+				integreatlyv1alpha1.ProductGrafana,
+				integreatlyv1alpha1.ProductObservability,
+			},
+		},
+		{
+			name: "test skipped dependencies are complete",
+			stage: stage(map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+				integreatlyv1alpha1.ProductCloudResources: integreatlyv1alpha1.PhaseSkipped,
+				integreatlyv1alpha1.ProductRHSSOUser:      integreatlyv1alpha1.PhaseNone,
+			}),
+			reconciled: map[integreatlyv1alpha1.ProductName]bool{
+				integreatlyv1alpha1.ProductCloudResources: true,
+			},
+			want: []integreatlyv1alpha1.ProductName{integreatlyv1alpha1.ProductRHSSOUser},
+		},
+		{
+			name: "test dependencies outside of the stage are ignored",
+			stage: stage(map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.StatusPhase{
+				integreatlyv1alpha1.Product3Scale: integreatlyv1alpha1.PhaseNone,
+			}),
+			want: []integreatlyv1alpha1.ProductName{integreatlyv1alpha1.Product3Scale},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stage.readyProducts(tt.reconciled); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readyProducts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStage_validateDependencies(t *testing.T) {
+	for _, installType := range []*Type{allManagedApiStages, allMultitenantManagedApiStages} {
+		for _, stage := range append(installType.InstallStages, installType.UninstallStages...) {
+			if err := stage.validateDependencies(); err != nil {
+				t.Errorf("validateDependencies() of stage %s error = %v", stage.Name, err)
+			}
+		}
+	}
+
+	dependencies := productDependencies
+	defer func() { productDependencies = dependencies }()
+	productDependencies = map[integreatlyv1alpha1.ProductName][]integreatlyv1alpha1.ProductName{
+		integreatlyv1alpha1.Product3Scale:  {integreatlyv1alpha1.ProductMarin3r},
+		integreatlyv1alpha1.ProductMarin3r: {integreatlyv1alpha1.Product3Scale},
+	}
+	stage := &Stage{
+		Name: integreatlyv1alpha1.InstallStage,
+		Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+			integreatlyv1alpha1.Product3Scale:  {Name: integreatlyv1alpha1.Product3Scale},
+			integreatlyv1alpha1.ProductMarin3r: {Name: integreatlyv1alpha1.ProductMarin3r},
+		},
+	}
+	if err := stage.validateDependencies(); err == nil {
+		t.Errorf("validateDependencies() expected an error for a dependency cycle")
+	}
+}
+
+func TestInstallationClient_Update(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "rhoam",
+			Namespace:  "redhat-rhoam-operator",
+			Finalizers: []string{"configmaps.integreatly.org/finalizer", "rhsso.integreatly.org/finalizer"},
+		},
+	}
+	serverClient := utils.NewTestClient(scheme, installation)
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(installation), installation); err != nil {
+		t.Fatal(err)
+	}
+
+	shared := newSharedInstallation(installation)
+	threescale := installation.DeepCopy()
+	rhsso := installation.DeepCopy()
+	threescaleClient := newInstallationClient(serverClient, shared, threescale)
+	rhssoClient := newInstallationClient(serverClient, shared, rhsso)
+
+	threescale.Finalizers = append(threescale.Finalizers, "3scale.integreatly.org/finalizer")
+	if err := threescaleClient.Update(context.TODO(), threescale); err != nil {
+		t.Fatalf("Update() of the 3scale copy error = %v", err)
+	}
+	rhsso.Finalizers = []string{"configmaps.integreatly.org/finalizer"}
+	if err := rhssoClient.Update(context.TODO(), rhsso); err != nil {
+		t.Fatalf("Update() of the rhsso copy error = %v", err)
+	}
+
+	want := []string{"configmaps.integreatly.org/finalizer", "3scale.integreatly.org/finalizer"}
+	updated := &integreatlyv1alpha1.RHMI{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(installation), updated); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated.Finalizers, want) {
+		t.Errorf("finalizers = %v, want %v", updated.Finalizers, want)
+	}
+
+	shared.apply(installation)
+	if !reflect.DeepEqual(installation.Finalizers, want) || installation.ResourceVersion != updated.ResourceVersion {
+		t.Errorf("apply() finalizers = %v, resource version %s, want %v, %s", installation.Finalizers, installation.ResourceVersion, want, updated.ResourceVersion)
+	}
+}
+
+func TestMergeInstallationStatus(t *testing.T) {
+	condition := func(conditionType string, status metav1.ConditionStatus) metav1.Condition {
+		return metav1.Condition{Type: conditionType, Status: status, Reason: conditionType}
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		Status: integreatlyv1alpha1.RHMIStatus{
+			LastError: "error",
+			Conditions: []metav1.Condition{
+				condition("Kept", metav1.ConditionTrue),
+				condition("Updated", metav1.ConditionFalse),
+				condition("Removed", metav1.ConditionTrue),
+			},
+		},
+	}
+	original := installation.DeepCopy()
+
+	// another product set the custom SMTP status and a condition since
+	installation.Status.CustomSmtp = &integreatlyv1alpha1.CustomSmtpStatus{Enabled: true}
+	installation.Status.Conditions = append(installation.Status.Conditions, condition("Other", metav1.ConditionTrue))
+
+	reconciled := original.DeepCopy()
+	reconciled.Status.GitHubOAuthEnabled = true
+	reconciled.Status.Conditions = []metav1.Condition{
+		condition("Kept", metav1.ConditionTrue),
+		condition("Updated", metav1.ConditionTrue),
+		condition("Added", metav1.ConditionTrue),
+	}
+
+	mergeInstallationStatus(installation, original, reconciled)
+
+	if !installation.Status.GitHubOAuthEnabled {
+		t.Errorf("expected the status changed by the product to be merged")
+	}
+	if installation.Status.CustomSmtp == nil || installation.Status.LastError != "error" {
+		t.Errorf("expected the status not changed by the product to be kept, got %v", installation.Status)
+	}
+	var got []string
+	for _, c := range installation.Status.Conditions {
+		got = append(got, c.Type+"="+string(c.Status))
+	}
+	want := []string{"Kept=True", "Updated=True", "Other=True", "Added=True"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("conditions = %v, want %v", got, want)
+	}
+}
+
+func TestMergeInstallationStatus_keyedLists(t *testing.T) {
+	route := func(name integreatlyv1alpha1.CustomRouteName, state integreatlyv1alpha1.CustomRouteState) integreatlyv1alpha1.CustomRouteStatus {
+		return integreatlyv1alpha1.CustomRouteStatus{Route: name, Hostname: string(name) + ".example.com", State: state}
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		Status: integreatlyv1alpha1.RHMIStatus{
+			CustomRoutes: []integreatlyv1alpha1.CustomRouteStatus{
+				route("rhsso-removed", integreatlyv1alpha1.CustomRouteReady),
+				route("rhssouser", integreatlyv1alpha1.CustomRoutePending),
+			},
+		},
+	}
+	original := installation.DeepCopy()
+
+	// rhsso and rhssouser are reconciled in the same wave, each with its
+	// own copy of the installation
+	rhsso := original.DeepCopy()
+	rhsso.Status.CustomRoutes = []integreatlyv1alpha1.CustomRouteStatus{
+		route("rhssouser", integreatlyv1alpha1.CustomRoutePending),
+		route("rhsso", integreatlyv1alpha1.CustomRouteReady),
+	}
+	rhssoUser := original.DeepCopy()
+	rhssoUser.Status.CustomRoutes = []integreatlyv1alpha1.CustomRouteStatus{
+		route("rhsso-removed", integreatlyv1alpha1.CustomRouteReady),
+		route("rhssouser", integreatlyv1alpha1.CustomRouteReady),
+	}
+
+	mergeInstallationStatus(installation, original, rhsso)
+	mergeInstallationStatus(installation, original, rhssoUser)
+
+	var got []string
+	for _, r := range installation.Status.CustomRoutes {
+		got = append(got, string(r.Route)+"="+string(r.State))
+	}
+	want := []string{"rhssouser=Ready", "rhsso=Ready"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("custom routes = %v, want %v", got, want)
+	}
+}
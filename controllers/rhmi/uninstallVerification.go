@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	rhmiv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// uninstallVerificationTimeout bounds the wait for the resources left behind
+// by the uninstall to be removed, the resources still there once it passes
+// are reported and the uninstall completes
+const uninstallVerificationTimeout = 10 * time.Minute
+
+// newOrphanScanners scans AWS for the cloud resources of the installation
+// when it uses AWS storage
+func newOrphanScanners(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI) ([]resources.OrphanScanner, error) {
+	if installation.Spec.UseClusterStorage != "false" {
+		return nil, nil
+	}
+	scanner, err := resources.NewAWSOrphanScanner(ctx, serverClient, installation.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return []resources.OrphanScanner{scanner}, nil
+}
+
+// verifyUninstall removes the resources of the installation left behind once
+// its products are uninstalled and records them in the uninstall report. It
+// returns whether the uninstall can complete: nothing is left in the cluster,
+// or the verification timed out. The resources outside of the cluster are
+// scanned once, when the uninstall completes
+func (r *RHMIReconciler) verifyUninstall(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI, recorder record.EventRecorder) bool {
+	report := installation.Status.UninstallReport
+	if report == nil {
+		report = &rhmiv1alpha1.UninstallReport{StartedAt: metav1.Now()}
+		installation.Status.UninstallReport = report
+	}
+	if report.VerifiedAt != nil {
+		return true
+	}
+
+	removed, remaining, err := resources.ReapOrphanedResources(ctx, serverClient, installation, log)
+	if err != nil {
+		log.Error("failed to remove the resources left behind by the uninstall", err)
+		remaining = append(remaining, rhmiv1alpha1.OrphanedResource{Kind: "Scan", Name: "cluster", Location: resources.OrphanLocationCluster, Message: err.Error()})
+	}
+	report.Removed = appendOrphans(report.Removed, removed...)
+	report.Remaining = remaining
+
+	// the resources removed are verified to be gone on the next reconcile
+	if (len(removed) > 0 || len(remaining) > 0) && time.Since(report.StartedAt.Time) < uninstallVerificationTimeout {
+		return false
+	}
+
+	if r.newOrphanScanners != nil {
+		scanners, err := r.newOrphanScanners(ctx, serverClient, installation)
+		if err != nil {
+			log.Error("failed to build the scanners of the resources left behind by the uninstall", err)
+			report.Remaining = append(report.Remaining, rhmiv1alpha1.OrphanedResource{Kind: "Scan", Name: "aws", Location: resources.OrphanLocationAWS, Message: err.Error()})
+		}
+		for _, scanner := range scanners {
+			found, err := scanner.Scan(ctx)
+			if err != nil {
+				log.Error("failed to scan for the resources left behind by the uninstall", err)
+				report.Remaining = append(report.Remaining, rhmiv1alpha1.OrphanedResource{Kind: "Scan", Name: "aws", Location: resources.OrphanLocationAWS, Message: err.Error()})
+				continue
+			}
+			report.Remaining = append(report.Remaining, found...)
+		}
+	}
+
+	now := metav1.Now()
+	report.VerifiedAt = &now
+	if len(report.Remaining) == 0 {
+		log.Infof("Uninstall verified", l.Fields{"removed": len(report.Removed)})
+		recorder.Eventf(installation, "Normal", rhmiv1alpha1.EventUninstallVerified,
+			"No resources of the installation are left, %d left behind by the products were removed", len(report.Removed))
+		return true
+	}
+
+	var left []string
+	for _, orphan := range report.Remaining {
+		left = append(left, describeOrphan(orphan))
+	}
+	log.Warningf("Uninstall left resources behind", l.Fields{"remaining": left})
+	recorder.Eventf(installation, "Warning", rhmiv1alpha1.EventUninstallIncomplete,
+		"%d resources of the installation are left: %s", len(left), strings.Join(left, ", "))
+	return true
+}
+
+// appendOrphans appends the orphans that aren't in the report yet
+func appendOrphans(report []rhmiv1alpha1.OrphanedResource, orphans ...rhmiv1alpha1.OrphanedResource) []rhmiv1alpha1.OrphanedResource {
+	for _, orphan := range orphans {
+		found := false
+		for _, reported := range report {
+			if reported.Kind == orphan.Kind && reported.Namespace == orphan.Namespace && reported.Name == orphan.Name && reported.Location == orphan.Location {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report = append(report, orphan)
+		}
+	}
+	return report
+}
+
+func describeOrphan(orphan rhmiv1alpha1.OrphanedResource) string {
+	name := orphan.Name
+	if orphan.Namespace != "" {
+		name = orphan.Namespace + "/" + name
+	}
+	description := fmt.Sprintf("%s %s %s", orphan.Location, orphan.Kind, name)
+	if orphan.Message != "" {
+		description += " (" + orphan.Message + ")"
+	}
+	return description
+}
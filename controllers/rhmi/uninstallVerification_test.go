@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type orphanScannerFunc func(ctx context.Context) ([]integreatlyv1alpha1.OrphanedResource, error)
+
+func (f orphanScannerFunc) Scan(ctx context.Context) ([]integreatlyv1alpha1.OrphanedResource, error) {
+	return f(ctx)
+}
+
+func TestVerifyUninstall(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned := map[string]string{resources.OwnerLabelKey: "installation-uid"}
+	awsOrphan := integreatlyv1alpha1.OrphanedResource{Kind: "RDSInstance", Name: "threescale-postgres", Location: resources.OrphanLocationAWS}
+
+	tests := []struct {
+		name          string
+		startedAt     time.Time
+		objects       []runtime.Object
+		scanners      []resources.OrphanScanner
+		scannerErr    error
+		wantVerified  bool
+		wantRemoved   int
+		wantRemaining int
+		wantEvent     string
+	}{
+		{
+			name:         "test uninstall is verified when nothing is left",
+			wantVerified: true,
+			wantEvent:    integreatlyv1alpha1.EventUninstallVerified,
+		},
+		{
+			name:         "test resources left in the cluster are removed and verified on the next reconcile",
+			objects:      []runtime.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-3scale", Labels: owned}}},
+			wantVerified: false,
+			wantRemoved:  1,
+		},
+		{
+			name:      "test resources still left once the verification times out are reported",
+			startedAt: time.Now().Add(-2 * uninstallVerificationTimeout),
+			objects: []runtime.Object{&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name: "redhat-rhoam-3scale", Labels: owned, DeletionTimestamp: &metav1.Time{Time: time.Now()}, Finalizers: []string{"kubernetes"},
+			}}},
+			wantVerified:  true,
+			wantRemaining: 1,
+			wantEvent:     integreatlyv1alpha1.EventUninstallIncomplete,
+		},
+		{
+			name: "test resources found by the scanners are reported",
+			scanners: []resources.OrphanScanner{orphanScannerFunc(func(context.Context) ([]integreatlyv1alpha1.OrphanedResource, error) {
+				return []integreatlyv1alpha1.OrphanedResource{awsOrphan}, nil
+			})},
+			wantVerified:  true,
+			wantRemaining: 1,
+			wantEvent:     integreatlyv1alpha1.EventUninstallIncomplete,
+		},
+		{
+			name:          "test scanners failing to build are reported",
+			scannerErr:    errors.New("no credentials"),
+			wantVerified:  true,
+			wantRemaining: 1,
+			wantEvent:     integreatlyv1alpha1.EventUninstallIncomplete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator", UID: "installation-uid"},
+			}
+			if !tt.startedAt.IsZero() {
+				installation.Status.UninstallReport = &integreatlyv1alpha1.UninstallReport{StartedAt: metav1.NewTime(tt.startedAt)}
+			}
+			r := &RHMIReconciler{
+				newOrphanScanners: func(context.Context, k8sclient.Client, *integreatlyv1alpha1.RHMI) ([]resources.OrphanScanner, error) {
+					return tt.scanners, tt.scannerErr
+				},
+			}
+			recorder := record.NewFakeRecorder(5)
+
+			verified := r.verifyUninstall(context.TODO(), utils.NewTestClient(scheme, tt.objects...), installation, recorder)
+			if verified != tt.wantVerified {
+				t.Fatalf("expected verified %v, got %v", tt.wantVerified, verified)
+			}
+			report := installation.Status.UninstallReport
+			if len(report.Removed) != tt.wantRemoved || len(report.Remaining) != tt.wantRemaining {
+				t.Errorf("expected %d removed and %d remaining, got %+v", tt.wantRemoved, tt.wantRemaining, report)
+			}
+			if (report.VerifiedAt != nil) != tt.wantVerified {
+				t.Errorf("expected verified at to be set %v, got %v", tt.wantVerified, report.VerifiedAt)
+			}
+			select {
+			case event := <-recorder.Events:
+				if tt.wantEvent == "" || !strings.Contains(event, tt.wantEvent) {
+					t.Errorf("expected event %q, got %q", tt.wantEvent, event)
+				}
+			default:
+				if tt.wantEvent != "" {
+					t.Errorf("expected event %s", tt.wantEvent)
+				}
+			}
+		})
+	}
+}
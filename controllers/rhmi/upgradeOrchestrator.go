@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	rhmiv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/addon"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/alerthistory"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/routeverification"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// startProgressiveUpgrade records the products of the install stages in the
+// order they're upgraded in. The first product is upgraded, the others keep
+// their previous version until the products before them pass their health
+// probes
+func startProgressiveUpgrade(installation *rhmiv1alpha1.RHMI, installType *Type) {
+	if !installation.IsProgressiveUpgradeEnabled() || installation.Status.Version == "" {
+		installation.Status.Upgrade = nil
+		return
+	}
+
+	upgrade := &rhmiv1alpha1.UpgradeStatus{
+		FromVersion: installation.Status.Version,
+		ToVersion:   installation.Status.ToVersion,
+		Phase:       rhmiv1alpha1.UpgradeInProgress,
+	}
+	for _, stage := range installType.GetInstallStages() {
+		for _, productName := range stage.upgradeOrder() {
+			upgrade.Products = append(upgrade.Products, rhmiv1alpha1.ProductUpgradeStatus{
+				Name:  productName,
+				Phase: rhmiv1alpha1.ProductUpgradePending,
+			})
+		}
+	}
+	if current := upgrade.CurrentProduct(); current != nil {
+		current.Phase = rhmiv1alpha1.ProductUpgradeUpgrading
+	} else {
+		upgrade.Phase = rhmiv1alpha1.UpgradeCompleted
+	}
+	installation.Status.Upgrade = upgrade
+}
+
+// upgradeOrder returns the products of the stage with each product after the
+// products it depends on, sorted by name otherwise
+func (s *Stage) upgradeOrder() []rhmiv1alpha1.ProductName {
+	ordered := map[rhmiv1alpha1.ProductName]bool{}
+	var order []rhmiv1alpha1.ProductName
+	for len(order) < len(s.Products) {
+		var next []rhmiv1alpha1.ProductName
+		for productName := range s.Products {
+			if ordered[productName] {
+				continue
+			}
+			isNext := true
+			for _, dependency := range s.dependencies(productName) {
+				if !ordered[dependency] {
+					isNext = false
+					break
+				}
+			}
+			if isNext {
+				next = append(next, productName)
+			}
+		}
+		// the products left depend on each other, which fails the
+		// validation of the stage
+		if len(next) == 0 {
+			for productName := range s.Products {
+				if !ordered[productName] {
+					next = append(next, productName)
+				}
+			}
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+		for _, productName := range next {
+			ordered[productName] = true
+		}
+		order = append(order, next...)
+	}
+	return order
+}
+
+// isProgressiveUpgradeDone returns whether the progressive upgrade, if any,
+// upgraded every product
+func isProgressiveUpgradeDone(installation *rhmiv1alpha1.RHMI) bool {
+	return !installation.IsProgressiveUpgradeEnabled() || installation.Status.Upgrade == nil ||
+		installation.Status.Upgrade.Phase == rhmiv1alpha1.UpgradeCompleted
+}
+
+// advanceProgressiveUpgrade moves the progressive upgrade on once the product
+// being upgraded in the stage is complete at its new version and passes its
+// health probes. When the probes keep failing past the probe timeout the
+// upgrade halts, the products left keep their previous version until the
+// product recovers
+func (r *RHMIReconciler) advanceProgressiveUpgrade(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI,
+	stage *Stage, configManager config.ConfigReadWriter, versionMismatches map[rhmiv1alpha1.ProductName]bool, recorder record.EventRecorder) {
+	upgrade := installation.Status.Upgrade
+	if upgrade == nil || upgrade.Phase == rhmiv1alpha1.UpgradeCompleted {
+		return
+	}
+	if !installation.IsProgressiveUpgradeEnabled() {
+		installation.Status.Upgrade = nil
+		return
+	}
+
+	current := upgrade.CurrentProduct()
+	if current == nil {
+		upgrade.Phase = rhmiv1alpha1.UpgradeCompleted
+		return
+	}
+	productStatus, ok := stage.Products[current.Name]
+	if !ok {
+		return
+	}
+
+	now := metav1.Now()
+	if current.Phase == rhmiv1alpha1.ProductUpgradePending || current.Phase == rhmiv1alpha1.ProductUpgradeUpgrading {
+		current.Phase = rhmiv1alpha1.ProductUpgradeUpgrading
+		if !isProductComplete(productStatus.Phase) || versionMismatches[current.Name] {
+			return
+		}
+		current.Phase = rhmiv1alpha1.ProductUpgradeVerifying
+		current.VerifyingSince = &now
+	}
+
+	current.Problems = r.probeProductHealth(ctx, serverClient, installation, current.Name, configManager)
+	if len(current.Problems) == 0 {
+		log.Infof("Product passed its upgrade health probes", l.Fields{"product": current.Name})
+		current.Phase = rhmiv1alpha1.ProductUpgradeUpgraded
+		current.VerifyingSince = nil
+		upgrade.Phase = rhmiv1alpha1.UpgradeInProgress
+		upgrade.Message = ""
+		if next := upgrade.CurrentProduct(); next != nil {
+			next.Phase = rhmiv1alpha1.ProductUpgradeUpgrading
+		} else {
+			upgrade.Phase = rhmiv1alpha1.UpgradeCompleted
+		}
+		return
+	}
+
+	if current.Phase == rhmiv1alpha1.ProductUpgradeVerifying && now.Sub(current.VerifyingSince.Time) > installation.UpgradeProbeTimeout() {
+		current.Phase = rhmiv1alpha1.ProductUpgradeFailed
+		upgrade.Phase = rhmiv1alpha1.UpgradeHalted
+		upgrade.Message = fmt.Sprintf("%s failed its health probes for %s, the products after it keep version %s: %v",
+			current.Name, installation.UpgradeProbeTimeout(), upgrade.FromVersion, current.Problems)
+		log.Warningf("Progressive upgrade halted", l.Fields{"product": current.Name, "problems": current.Problems})
+		recorder.Event(installation, "Warning", rhmiv1alpha1.EventUpgradeHalted, upgrade.Message)
+	}
+}
+
+// probeProductHealth returns the problems found by the health probes of the
+// product: the pods of its namespaces that aren't ready, its routes that
+// aren't reachable and the critical alerts firing in its namespaces
+func (r *RHMIReconciler) probeProductHealth(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI,
+	productName rhmiv1alpha1.ProductName, configManager config.ConfigReadWriter) []string {
+	productConfig, err := configManager.ReadProduct(productName)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to read the config of %s: %v", productName, err)}
+	}
+	var namespaces []string
+	if namespace := productConfig.GetNamespace(); namespace != "" {
+		namespaces = append(namespaces, namespace)
+	}
+	if operatorConfig, ok := productConfig.(interface{ GetOperatorNamespace() string }); ok && operatorConfig.GetOperatorNamespace() != "" {
+		namespaces = append(namespaces, operatorConfig.GetOperatorNamespace())
+	}
+
+	var problems []string
+	for _, namespace := range namespaces {
+		problems = append(problems, probePods(ctx, serverClient, namespace)...)
+	}
+	problems = append(problems, r.probeRoutes(ctx, serverClient, installation, namespaces)...)
+	problems = append(problems, probeAlerts(ctx, serverClient, installation.Namespace, namespaces)...)
+	return problems
+}
+
+// probePods returns the pods of the namespace that aren't ready. The pods of
+// the jobs and the completed pods aren't expected to be
+func probePods(ctx context.Context, serverClient k8sclient.Client, namespace string) []string {
+	pods := &corev1.PodList{}
+	if err := serverClient.List(ctx, pods, k8sclient.InNamespace(namespace)); err != nil {
+		return []string{fmt.Sprintf("failed to list the pods of %s: %v", namespace, err)}
+	}
+
+	var problems []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.DeletionTimestamp != nil || isJobPod(pod) {
+			continue
+		}
+		ready := false
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			problems = append(problems, fmt.Sprintf("pod %s/%s is not ready", namespace, pod.Name))
+		}
+	}
+	return problems
+}
+
+func isJobPod(pod corev1.Pod) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "Job" {
+			return true
+		}
+	}
+	return false
+}
+
+// probeRoutes returns the routes of the namespaces that don't resolve or don't
+// serve a valid certificate, as last verified in the background. The probe is
+// pending until the first verification of the namespaces completes
+func (r *RHMIReconciler) probeRoutes(ctx context.Context, serverClient k8sclient.Client, installation *rhmiv1alpha1.RHMI, namespaces []string) []string {
+	if r.newRouteVerifier == nil || r.routeVerifications == nil || len(namespaces) == 0 {
+		return nil
+	}
+	externalResolver, _, err := addon.GetStringParameter(ctx, serverClient, installation.Namespace, routeverification.ExternalResolverParam)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return []string{fmt.Sprintf("failed to retrieve %s addon parameter: %v", routeverification.ExternalResolverParam, err)}
+	}
+
+	var routes []routev1.Route
+	for _, namespace := range namespaces {
+		routeList := &routev1.RouteList{}
+		if err := serverClient.List(ctx, routeList, k8sclient.InNamespace(namespace)); err != nil {
+			return []string{fmt.Sprintf("failed to list the routes of %s: %v", namespace, err)}
+		}
+		routes = append(routes, routeList.Items...)
+	}
+	verifier := r.newRouteVerifier(externalResolver)
+	result, ok := r.routeVerifications.Verify(strings.Join(namespaces, ","), func(ctx context.Context) []string {
+		return verifier.VerifyRoutes(ctx, routes)
+	})
+	if !ok {
+		return []string{fmt.Sprintf("the routes of %s are being verified", strings.Join(namespaces, ", "))}
+	}
+	return result.Problems
+}
+
+// probeAlerts returns the critical alerts firing in the namespaces, as last
+// recorded in the alert history of the installation
+func probeAlerts(ctx context.Context, serverClient k8sclient.Client, installationNamespace string, namespaces []string) []string {
+	firing, err := alerthistory.Firing(ctx, serverClient, installationNamespace)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to get the firing alerts: %v", err)}
+	}
+
+	var problems []string
+	for _, alert := range firing {
+		if alert.Severity != "critical" {
+			continue
+		}
+		for _, namespace := range namespaces {
+			if alert.Labels["namespace"] == namespace {
+				problems = append(problems, fmt.Sprintf("alert %s is firing in %s", alert.Alert, namespace))
+			}
+		}
+	}
+	return problems
+}
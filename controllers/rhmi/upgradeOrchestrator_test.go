@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/alerthistory"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestStage_upgradeOrder(t *testing.T) {
+	stage := &Stage{Name: integreatlyv1alpha1.InstallStage, Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{}}
+	for _, productName := range []integreatlyv1alpha1.ProductName{
+		integreatlyv1alpha1.ProductMarin3r,
+		integreatlyv1alpha1.Product3Scale,
+		integreatlyv1alpha1.ProductRHSSO,
+		integreatlyv1alpha1.ProductRHSSOUser,
+		integreatlyv1alpha1.ProductCloudResources,
+		integreatlyv1alpha1.ProductGrafana,
+	} {
+		stage.Products[productName] = integreatlyv1alpha1.RHMIProductStatus{Name: productName}
+	}
+
+	want := []integreatlyv1alpha1.ProductName{
+		integreatlyv1alpha1.ProductCloudResources,
+		integreatlyv1alpha1.ProductGrafana,
+		integreatlyv1alpha1.ProductRHSSO,
+		integreatlyv1alpha1.ProductRHSSOUser,
+		integreatlyv1alpha1.Product3Scale,
+		integreatlyv1alpha1.ProductMarin3r,
+	}
+	if got := stage.upgradeOrder(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("upgradeOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestStartProgressiveUpgrade(t *testing.T) {
+	installType := &Type{InstallStages: []Stage{
+		{Name: integreatlyv1alpha1.BootstrapStage},
+		{Name: integreatlyv1alpha1.InstallStage, Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+			integreatlyv1alpha1.Product3Scale:         {Name: integreatlyv1alpha1.Product3Scale},
+			integreatlyv1alpha1.ProductCloudResources: {Name: integreatlyv1alpha1.ProductCloudResources},
+		}},
+	}}
+
+	tests := []struct {
+		name        string
+		spec        integreatlyv1alpha1.RHMISpec
+		version     string
+		wantUpgrade *integreatlyv1alpha1.UpgradeStatus
+	}{
+		{
+			name:    "test upgrade isn't progressive by default",
+			version: "1.0.0",
+		},
+		{
+			name:    "test first install isn't progressive",
+			spec:    integreatlyv1alpha1.RHMISpec{ProgressiveUpgrade: &integreatlyv1alpha1.ProgressiveUpgradeSpec{Enabled: true}},
+			version: "",
+		},
+		{
+			name:    "test upgrade starts with the first product",
+			spec:    integreatlyv1alpha1.RHMISpec{ProgressiveUpgrade: &integreatlyv1alpha1.ProgressiveUpgradeSpec{Enabled: true}},
+			version: "1.0.0",
+			wantUpgrade: &integreatlyv1alpha1.UpgradeStatus{
+				FromVersion: "1.0.0",
+				ToVersion:   "1.1.0",
+				Phase:       integreatlyv1alpha1.UpgradeInProgress,
+				Products: []integreatlyv1alpha1.ProductUpgradeStatus{
+					{Name: integreatlyv1alpha1.ProductCloudResources, Phase: integreatlyv1alpha1.ProductUpgradeUpgrading},
+					{Name: integreatlyv1alpha1.Product3Scale, Phase: integreatlyv1alpha1.ProductUpgradePending},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				Spec:   tt.spec,
+				Status: integreatlyv1alpha1.RHMIStatus{Version: tt.version, ToVersion: "1.1.0"},
+			}
+			startProgressiveUpgrade(installation, installType)
+			if !reflect.DeepEqual(installation.Status.Upgrade, tt.wantUpgrade) {
+				t.Fatalf("expected upgrade %+v, got %+v", tt.wantUpgrade, installation.Status.Upgrade)
+			}
+			if tt.wantUpgrade != nil && !installation.IsUpgradeHeld(integreatlyv1alpha1.Product3Scale) {
+				t.Fatal("expected the pending product to be held")
+			}
+		})
+	}
+}
+
+func TestRHMIReconciler_advanceProgressiveUpgrade(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		installationNs = utils.TestNamespacePrefix + "operator"
+		productNs      = utils.TestNamespacePrefix + "3scale"
+	)
+
+	configManager := &config.ConfigReadWriterMock{
+		ReadProductFunc: func(product integreatlyv1alpha1.ProductName) (config.ConfigReadable, error) {
+			return config.NewThreeScale(config.ProductConfig{"NAMESPACE": productNs, "OPERATOR_NAMESPACE": productNs + "-operator"}), nil
+		},
+	}
+	pod := func(ready bool) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "apicast-production-1", Namespace: productNs},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+	jobPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "backup-1",
+			Namespace:       productNs,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: "backup"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	firingAlert := func(namespace string) *corev1.ConfigMap {
+		firing, err := json.Marshal(map[string]alerthistory.Entry{
+			"0": {Alert: "ThreeScaleApicastDown", Severity: "critical", State: alerthistory.StateFiring, Labels: map[string]string{"namespace": namespace}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: alerthistory.ConfigMapName, Namespace: installationNs},
+			Data:       map[string]string{"history": "[]", "firing": string(firing)},
+		}
+	}
+	upgrade := func(phase integreatlyv1alpha1.UpgradePhase, current integreatlyv1alpha1.ProductUpgradePhase, verifyingFor time.Duration) *integreatlyv1alpha1.UpgradeStatus {
+		status := &integreatlyv1alpha1.UpgradeStatus{
+			FromVersion: "1.0.0",
+			ToVersion:   "1.1.0",
+			Phase:       phase,
+			Products: []integreatlyv1alpha1.ProductUpgradeStatus{
+				{Name: integreatlyv1alpha1.ProductCloudResources, Phase: integreatlyv1alpha1.ProductUpgradeUpgraded},
+				{Name: integreatlyv1alpha1.Product3Scale, Phase: current},
+				{Name: integreatlyv1alpha1.ProductMarin3r, Phase: integreatlyv1alpha1.ProductUpgradePending},
+			},
+		}
+		if verifyingFor != 0 {
+			status.Products[1].VerifyingSince = &metav1.Time{Time: time.Now().Add(-verifyingFor)}
+		}
+		return status
+	}
+
+	tests := []struct {
+		name             string
+		upgrade          *integreatlyv1alpha1.UpgradeStatus
+		productPhase     integreatlyv1alpha1.StatusPhase
+		versionMismatch  bool
+		objects          []runtime.Object
+		wantPhase        integreatlyv1alpha1.UpgradePhase
+		wantProductPhase integreatlyv1alpha1.ProductUpgradePhase
+		wantNextPhase    integreatlyv1alpha1.ProductUpgradePhase
+		wantProblems     int
+		wantEvent        bool
+	}{
+		{
+			name:             "test product is upgrading until it's complete",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeUpgrading, 0),
+			productPhase:     integreatlyv1alpha1.PhaseInProgress,
+			objects:          []runtime.Object{pod(true)},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeUpgrading,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradePending,
+		},
+		{
+			name:             "test product is upgrading until it's at the new version",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeUpgrading, 0),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			versionMismatch:  true,
+			objects:          []runtime.Object{pod(true)},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeUpgrading,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradePending,
+		},
+		{
+			name:             "test healthy product moves the upgrade to the next product",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeUpgrading, 0),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(true), jobPod},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeUpgraded,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradeUpgrading,
+		},
+		{
+			name:             "test product with pods not ready is verified until the probe timeout",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeUpgrading, 0),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(false)},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeVerifying,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradePending,
+			wantProblems:     1,
+		},
+		{
+			name:             "test product with critical alerts firing is verified until the probe timeout",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeVerifying, time.Minute),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(true), firingAlert(productNs)},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeVerifying,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradePending,
+			wantProblems:     1,
+		},
+		{
+			name:             "test alerts of other namespaces are ignored",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeVerifying, time.Minute),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(true), firingAlert(utils.TestNamespacePrefix + "rhsso")},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeUpgraded,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradeUpgrading,
+		},
+		{
+			name:             "test product failing past the probe timeout halts the upgrade",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeInProgress, integreatlyv1alpha1.ProductUpgradeVerifying, time.Hour),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(false)},
+			wantPhase:        integreatlyv1alpha1.UpgradeHalted,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeFailed,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradePending,
+			wantProblems:     1,
+			wantEvent:        true,
+		},
+		{
+			name:             "test halted upgrade resumes once the product recovers",
+			upgrade:          upgrade(integreatlyv1alpha1.UpgradeHalted, integreatlyv1alpha1.ProductUpgradeFailed, time.Hour),
+			productPhase:     integreatlyv1alpha1.PhaseCompleted,
+			objects:          []runtime.Object{pod(true)},
+			wantPhase:        integreatlyv1alpha1.UpgradeInProgress,
+			wantProductPhase: integreatlyv1alpha1.ProductUpgradeUpgraded,
+			wantNextPhase:    integreatlyv1alpha1.ProductUpgradeUpgrading,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: installationNs},
+				Spec:       integreatlyv1alpha1.RHMISpec{ProgressiveUpgrade: &integreatlyv1alpha1.ProgressiveUpgradeSpec{Enabled: true}},
+				Status:     integreatlyv1alpha1.RHMIStatus{Upgrade: tt.upgrade},
+			}
+			stage := &Stage{Name: integreatlyv1alpha1.InstallStage, Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+				integreatlyv1alpha1.Product3Scale: {Name: integreatlyv1alpha1.Product3Scale, Phase: tt.productPhase},
+			}}
+			versionMismatches := map[integreatlyv1alpha1.ProductName]bool{integreatlyv1alpha1.Product3Scale: tt.versionMismatch}
+			recorder := record.NewFakeRecorder(10)
+
+			r := &RHMIReconciler{}
+			r.advanceProgressiveUpgrade(context.TODO(), utils.NewTestClient(scheme, tt.objects...), installation, stage, configManager, versionMismatches, recorder)
+
+			upgrade := installation.Status.Upgrade
+			if upgrade.Phase != tt.wantPhase {
+				t.Errorf("expected upgrade phase %s, got %s: %s", tt.wantPhase, upgrade.Phase, upgrade.Message)
+			}
+			product := upgrade.Product(integreatlyv1alpha1.Product3Scale)
+			if product.Phase != tt.wantProductPhase {
+				t.Errorf("expected product phase %s, got %s", tt.wantProductPhase, product.Phase)
+			}
+			if len(product.Problems) != tt.wantProblems {
+				t.Errorf("expected %d problems, got %v", tt.wantProblems, product.Problems)
+			}
+			if next := upgrade.Product(integreatlyv1alpha1.ProductMarin3r); next.Phase != tt.wantNextPhase {
+				t.Errorf("expected next product phase %s, got %s", tt.wantNextPhase, next.Phase)
+			}
+			if held := installation.IsUpgradeHeld(integreatlyv1alpha1.ProductMarin3r); held != (tt.wantNextPhase == integreatlyv1alpha1.ProductUpgradePending) {
+				t.Errorf("expected the next product to be held %t, got %t", !held, held)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !tt.wantEvent || !strings.Contains(event, integreatlyv1alpha1.EventUpgradeHalted) {
+					t.Errorf("unexpected event %q", event)
+				}
+			default:
+				if tt.wantEvent {
+					t.Error("expected an upgrade halted event")
+				}
+			}
+		})
+	}
+}
+
+func TestRHMIReconciler_advanceProgressiveUpgradeCompletes(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configManager := &config.ConfigReadWriterMock{
+		ReadProductFunc: func(product integreatlyv1alpha1.ProductName) (config.ConfigReadable, error) {
+			return config.NewMarin3r(config.ProductConfig{"NAMESPACE": utils.TestNamespacePrefix + "marin3r"}), nil
+		},
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		Spec: integreatlyv1alpha1.RHMISpec{ProgressiveUpgrade: &integreatlyv1alpha1.ProgressiveUpgradeSpec{Enabled: true}},
+		Status: integreatlyv1alpha1.RHMIStatus{Upgrade: &integreatlyv1alpha1.UpgradeStatus{
+			Phase: integreatlyv1alpha1.UpgradeInProgress,
+			Products: []integreatlyv1alpha1.ProductUpgradeStatus{
+				{Name: integreatlyv1alpha1.Product3Scale, Phase: integreatlyv1alpha1.ProductUpgradeUpgraded},
+				{Name: integreatlyv1alpha1.ProductMarin3r, Phase: integreatlyv1alpha1.ProductUpgradeUpgrading},
+			},
+		}},
+	}
+	stage := &Stage{Name: integreatlyv1alpha1.InstallStage, Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+		integreatlyv1alpha1.ProductMarin3r: {Name: integreatlyv1alpha1.ProductMarin3r, Phase: integreatlyv1alpha1.PhaseCompleted},
+	}}
+
+	if isProgressiveUpgradeDone(installation) {
+		t.Fatal("expected the upgrade to be in progress")
+	}
+	r := &RHMIReconciler{}
+	r.advanceProgressiveUpgrade(context.TODO(), utils.NewTestClient(scheme), installation, stage, configManager, nil, record.NewFakeRecorder(1))
+	if !isProgressiveUpgradeDone(installation) {
+		t.Fatalf("expected the upgrade to be completed, got %+v", installation.Status.Upgrade)
+	}
+}
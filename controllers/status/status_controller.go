@@ -145,6 +145,14 @@ func (r *StatusReconciler) appendInstalledConditions(installation *v1alpha1.RHMI
 		conditions = append(conditions, installation.UninstallBlockedCondition())
 	}
 
+	if installation.IsVersionSkewBlocked() {
+		conditions = append(conditions, *installation.GetCondition(v1alpha1.VersionSkewConditionType))
+	}
+
+	if installation.IsNamespaceTerminationBlocked() {
+		conditions = append(conditions, *installation.GetCondition(v1alpha1.NamespaceTerminationBlockedConditionType))
+	}
+
 	return conditions
 }
 
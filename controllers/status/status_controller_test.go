@@ -80,6 +80,11 @@ func TestStatusReconciler_AppendInstalledConditions(t *testing.T) {
 			args: args{installation: &v1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}}}},
 			want: []metav1.Condition{installation.UninstallBlockedCondition()},
 		},
+		{
+			name: "test version skew condition returned if product operator upgrade is blocked",
+			args: args{installation: &v1alpha1.RHMI{Status: v1alpha1.RHMIStatus{Version: "0.0.0", Conditions: []metav1.Condition{installation.VersionSkewBlockedCondition("blocked")}}}},
+			want: []metav1.Condition{installation.InstalledCondition(), installation.VersionSkewBlockedCondition("blocked")},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -123,7 +123,7 @@ func (r *SubscriptionReconciler) Reconcile(ctx context.Context, request ctrl.Req
 	installation := &integreatlyv1alpha1.RHMI{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "rhoam",
-			Namespace: "redhat-rhoam-operator",
+			Namespace: r.operatorNamespace,
 		},
 	}
 	err := r.Get(context.TODO(), k8sclient.ObjectKey{Name: installation.Name, Namespace: installation.Namespace}, installation)
@@ -141,7 +141,7 @@ func (r *SubscriptionReconciler) Reconcile(ctx context.Context, request ctrl.Req
 			backendRedis := &v1alpha1.Redis{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "threescale-backend-redis-rhoam",
-					Namespace: "redhat-rhoam-operator",
+					Namespace: r.operatorNamespace,
 				},
 			}
 
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	consolev1 "github.com/openshift/api/console/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	usersv1 "github.com/openshift/api/user/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
@@ -24,8 +27,19 @@ import (
 
 var log = l.NewLoggerWithContext(l.Fields{l.ControllerLogContext: "tenant_controller"})
 
-// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenant,verbs=get;list;watch
-// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenant/status,verbs=get;update;patch
+const (
+	// tenantFinalizer keeps the APIManagementTenant until the artifacts of
+	// the tenant are removed
+	tenantFinalizer = "integreatly.org/apimanagementtenant-cleanup"
+	// tenantClientPrefix is the prefix of the SSO clients of the tenants
+	tenantClientPrefix = "rhoam-mt-"
+
+	threeScaleNamespace = "sandbox-rhoam-3scale"
+	rhssoNamespace      = "sandbox-rhoam-rhsso"
+)
+
+// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenants,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenants/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=user.openshift.io,resources=users,verbs=watch;get;list;update
 
 func New(mgr manager.Manager) (*TenantReconciler, error) {
@@ -66,6 +80,10 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	if tenant.DeletionTimestamp != nil {
+		return r.deprovisionTenant(tenant)
+	}
+
 	isTenantVerified, rejectionReason, err := r.verifyAPIManagementTenant(tenant)
 	if err != nil {
 		log.Error("error verifying the APIManagementTenant CR", err)
@@ -87,6 +105,15 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// Only the verified tenants own artifacts to remove on deletion
+	if !controllerutil.ContainsFinalizer(tenant, tenantFinalizer) {
+		controllerutil.AddFinalizer(tenant, tenantFinalizer)
+		if err := r.Update(context.TODO(), tenant); err != nil {
+			log.Error("error adding finalizer to the APIManagementTenant CR", err)
+			return ctrl.Result{}, err
+		}
+	}
+
 	err = r.addAnnotationToUser(tenant)
 	if err != nil {
 		if err1 := r.updateLastError(tenant, err.Error()); err1 != nil {
@@ -107,7 +134,9 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err
 	}
 
-	// Clear out LastError since reconcile finished successfully.
+	// Clear out LastError since reconcile finished successfully. The phase is
+	// set too for the tenants provisioned before it was introduced
+	tenant.Status.Phase = tenantPhase(tenant.Status.ProvisioningStatus)
 	if err1 := r.updateLastError(tenant, ""); err1 != nil {
 		return ctrl.Result{}, err1
 	}
@@ -218,36 +247,19 @@ func (r *TenantReconciler) reconcileTenantUrl(tenant *v1alpha1.APIManagementTena
 		log.Info(fmt.Sprintf("TenantReconciler reconcileTenantUrl: %v", tenant))
 
 		tenantUrlReconciled = false // Reset value because tenant hasn't been reconciled yet
-		selector, err := labels.Parse("zync.3scale.net/route-to=system-provider")
-		if err != nil {
-			return tenantUrlReconciled, err
-		}
-		opts := k8sclient.ListOptions{
-			LabelSelector: selector,
-			Namespace:     "sandbox-rhoam-3scale",
-		}
-
-		routes := routev1.RouteList{}
-		err = r.Client.List(context.TODO(), &routes, &opts)
+		routes, err := r.listSystemProviderRoutes()
 		if err != nil {
 			return tenantUrlReconciled, err
 		}
-		if len(routes.Items) == 0 {
-			return tenantUrlReconciled, fmt.Errorf("failed to find any system-developer routes in namespace %s", opts.Namespace)
+		if len(routes) == 0 {
+			return tenantUrlReconciled, fmt.Errorf("failed to find any system-developer routes in namespace %s", threeScaleNamespace)
 		}
 
-		var foundRoute *routev1.Route
 		user, err := r.getUserByTenantNamespace(tenant.Namespace)
 		if err != nil {
 			return tenantUrlReconciled, err
 		}
-		for i := range routes.Items {
-			rt := routes.Items[i]
-			if strings.Contains(rt.Spec.Host, user.Name) {
-				foundRoute = &rt
-				break
-			}
-		}
+		foundRoute := findTenantRoute(routes, user.Name)
 		if foundRoute == nil {
 			// If no matching route was found, then the account is still being created
 			// Set the provisioningStatus to ThreeScaleAccountRequested
@@ -294,6 +306,151 @@ func (r *TenantReconciler) reconcileTenantUrl(tenant *v1alpha1.APIManagementTena
 	return tenantUrlReconciled, nil
 }
 
+// deprovisionTenant removes the artifacts of a deleted tenant. The 3scale
+// account and the route of the tenant are removed by the 3scale reconciler
+// once the user isn't annotated anymore, so the finalizer is only removed
+// when the route is gone
+func (r *TenantReconciler) deprovisionTenant(tenant *v1alpha1.APIManagementTenant) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(tenant, tenantFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	log.Info(fmt.Sprintf("TenantReconciler deprovisionTenant: %v", tenant))
+
+	if tenant.Status.Phase != v1alpha1.TenantPhaseDeprovisioning {
+		tenant.Status.Phase = v1alpha1.TenantPhaseDeprovisioning
+		if err := r.Client.Status().Update(context.TODO(), tenant); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error updating the phase to %s for tenant %s: %v", v1alpha1.TenantPhaseDeprovisioning, tenant.Name, err)
+		}
+	}
+
+	// The tenant is exported before its 3scale account is deleted
+	if err := r.exportTenant(tenant, time.Now()); err != nil {
+		log.Error("error exporting the tenant", err)
+		if err1 := r.updateLastError(tenant, err.Error()); err1 != nil {
+			return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err1
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err
+	}
+
+	removed, err := r.removeTenantArtifacts(tenant)
+	if err != nil {
+		log.Error("error removing the artifacts of the tenant", err)
+		if err1 := r.updateLastError(tenant, err.Error()); err1 != nil {
+			return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err1
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err
+	}
+	if !removed {
+		message := fmt.Sprintf("waiting for the 3scale account of tenant %s to be deleted", tenant.Name)
+		if tenant.Status.LastError != message {
+			if err := r.updateLastError(tenant, message); err != nil {
+				return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, err
+			}
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: 15 * time.Second}, nil
+	}
+
+	controllerutil.RemoveFinalizer(tenant, tenantFinalizer)
+	if err := r.Update(context.TODO(), tenant); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error removing the finalizer of tenant %s: %v", tenant.Name, err)
+	}
+	log.Info(fmt.Sprintf("TenantReconciler deprovisioned tenant %s in namespace %s", tenant.Name, tenant.Namespace))
+	return ctrl.Result{}, nil
+}
+
+// removeTenantArtifacts removes the tenant annotations of the user, the SSO
+// client and the console link of the tenant, and returns whether the route of
+// the tenant is gone
+func (r *TenantReconciler) removeTenantArtifacts(tenant *v1alpha1.APIManagementTenant) (bool, error) {
+	username := tenant.GetUsername()
+
+	user, err := r.getUserByTenantNamespace(tenant.Namespace)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return false, fmt.Errorf("error getting user for tenant %s: %v", tenant.Name, err)
+	}
+	// The accounts of deleted users are removed by the 3scale reconciler
+	if err == nil {
+		_, isTenant := user.Annotations["tenant"]
+		_, isSSOReady := user.Annotations["ssoReady"]
+		if isTenant || isSSOReady {
+			delete(user.Annotations, "tenant")
+			delete(user.Annotations, "ssoReady")
+			if err := r.Update(context.TODO(), user); err != nil {
+				return false, fmt.Errorf("failed to remove tenant annotations from user %s: %v", user.Name, err)
+			}
+		}
+	}
+
+	tenantName, err := userHelper.SanitiseTenantUserName(username)
+	if err != nil {
+		return false, err
+	}
+	artifacts := []k8sclient.Object{
+		&keycloak.KeycloakClient{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      tenantClientPrefix + tenantName,
+				Namespace: rhssoNamespace,
+			},
+		},
+		&consolev1.ConsoleLink{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: tenantName + "-3scale",
+			},
+		},
+	}
+	for _, artifact := range artifacts {
+		if err := r.Delete(context.TODO(), artifact); err != nil && !k8serr.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete %s of tenant %s: %v", artifact.GetName(), tenant.Name, err)
+		}
+	}
+
+	routes, err := r.listSystemProviderRoutes()
+	if err != nil {
+		return false, err
+	}
+	return findTenantRoute(routes, username) == nil, nil
+}
+
+func (r *TenantReconciler) listSystemProviderRoutes() ([]routev1.Route, error) {
+	selector, err := labels.Parse("zync.3scale.net/route-to=system-provider")
+	if err != nil {
+		return nil, err
+	}
+	opts := k8sclient.ListOptions{
+		LabelSelector: selector,
+		Namespace:     threeScaleNamespace,
+	}
+
+	routes := routev1.RouteList{}
+	err = r.Client.List(context.TODO(), &routes, &opts)
+	if err != nil {
+		return nil, err
+	}
+	return routes.Items, nil
+}
+
+// findTenantRoute returns the system-provider route of the 3scale account of
+// the user, or nil while the account doesn't exist
+func findTenantRoute(routes []routev1.Route, username string) *routev1.Route {
+	for i := range routes {
+		if strings.Contains(routes[i].Spec.Host, username) {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+// tenantPhase returns the lifecycle phase of a tenant being provisioned
+func tenantPhase(status v1alpha1.ProvisioningStatus) v1alpha1.TenantPhase {
+	switch status {
+	case v1alpha1.UserAnnotated, v1alpha1.ThreeScaleAccountRequested:
+		return v1alpha1.TenantPhaseProvisioning
+	case v1alpha1.ThreeScaleAccountReady:
+		return v1alpha1.TenantPhaseReady
+	}
+	return ""
+}
+
 func (r *TenantReconciler) updateLastError(tenant *v1alpha1.APIManagementTenant, message string) error {
 	tenant.Status.LastError = message
 	err := r.Client.Status().Update(context.TODO(), tenant)
@@ -305,6 +462,7 @@ func (r *TenantReconciler) updateLastError(tenant *v1alpha1.APIManagementTenant,
 
 func (r *TenantReconciler) updateProvisioningStatus(tenant *v1alpha1.APIManagementTenant, status v1alpha1.ProvisioningStatus) error {
 	tenant.Status.ProvisioningStatus = status
+	tenant.Status.Phase = tenantPhase(status)
 	err := r.Client.Status().Update(context.TODO(), tenant)
 	if err != nil {
 		return fmt.Errorf("error updating the provisioningStatus to %s for tenant %s: %v", status, tenant.Name, err)
@@ -1,17 +1,23 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	routev1 "github.com/openshift/api/route/v1"
 	usersv1 "github.com/openshift/api/user/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 var (
@@ -167,3 +173,150 @@ func TestTenantReconciler_getUserByTenantNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestTenantReconciler_deprovisionTenant(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletedTenant := func() *integreatlyv1alpha1.APIManagementTenant {
+		return &integreatlyv1alpha1.APIManagementTenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              validTenantName,
+				Namespace:         validNamespace,
+				Finalizers:        []string{tenantFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Status: integreatlyv1alpha1.APIManagementTenantStatus{
+				ProvisioningStatus: integreatlyv1alpha1.ThreeScaleAccountReady,
+				Phase:              integreatlyv1alpha1.TenantPhaseReady,
+			},
+		}
+	}
+	annotatedUser := func() *usersv1.User {
+		return &usersv1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        validUsername,
+				Annotations: map[string]string{"tenant": "yes", "ssoReady": "yes"},
+			},
+		}
+	}
+	tenantClient := &keycloak.KeycloakClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rhoam-mt-test-user01",
+			Namespace: rhssoNamespace,
+		},
+	}
+	tenantRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zync-3scale-provider-test-user01",
+			Namespace: threeScaleNamespace,
+			Labels:    map[string]string{"zync.3scale.net/route-to": "system-provider"},
+		},
+		Spec: routev1.RouteSpec{
+			Host: "test-user01-admin.apps.example.com",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		objects       []runtime.Object
+		wantRequeue   bool
+		wantFinalizer bool
+		wantLastError string
+	}{
+		{
+			name:          "Test waits for the route of the tenant to be deleted",
+			objects:       []runtime.Object{deletedTenant(), annotatedUser(), tenantClient, tenantRoute},
+			wantRequeue:   true,
+			wantFinalizer: true,
+			wantLastError: "waiting for the 3scale account of tenant dev-tenant to be deleted",
+		},
+		{
+			name:          "Test removes the finalizer once the route of the tenant is deleted",
+			objects:       []runtime.Object{deletedTenant(), annotatedUser(), tenantClient},
+			wantRequeue:   false,
+			wantFinalizer: false,
+		},
+		{
+			name:          "Test removes the finalizer when the user is deleted",
+			objects:       []runtime.Object{deletedTenant()},
+			wantRequeue:   false,
+			wantFinalizer: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			r := &TenantReconciler{
+				Client: serverClient,
+				Scheme: scheme,
+				log:    logger.Logger{},
+			}
+			tenant, err := r.getAPIManagementTenant(validTenantName, validNamespace)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := r.deprovisionTenant(tenant)
+			if err != nil {
+				t.Fatalf("deprovisionTenant() error = %v", err)
+			}
+			if result.Requeue != tt.wantRequeue {
+				t.Errorf("deprovisionTenant() requeue = %v, want %v", result.Requeue, tt.wantRequeue)
+			}
+
+			user := &usersv1.User{}
+			if err := serverClient.Get(context.TODO(), client.ObjectKey{Name: validUsername}, user); err == nil {
+				if _, ok := user.Annotations["tenant"]; ok {
+					t.Error("expected the tenant annotation to be removed from the user")
+				}
+			}
+			if err := serverClient.Get(context.TODO(), client.ObjectKeyFromObject(tenantClient), &keycloak.KeycloakClient{}); !k8serr.IsNotFound(err) {
+				t.Errorf("expected the SSO client of the tenant to be deleted, got %v", err)
+			}
+
+			got := &integreatlyv1alpha1.APIManagementTenant{}
+			err = serverClient.Get(context.TODO(), client.ObjectKey{Name: validTenantName, Namespace: validNamespace}, got)
+			if !tt.wantFinalizer {
+				// the tenant is gone once its last finalizer is removed
+				if err == nil && controllerutil.ContainsFinalizer(got, tenantFinalizer) {
+					t.Error("expected the finalizer to be removed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !controllerutil.ContainsFinalizer(got, tenantFinalizer) {
+				t.Error("expected the finalizer to be kept")
+			}
+			if got.Status.Phase != integreatlyv1alpha1.TenantPhaseDeprovisioning {
+				t.Errorf("expected phase %s, got %s", integreatlyv1alpha1.TenantPhaseDeprovisioning, got.Status.Phase)
+			}
+			if got.Status.LastError != tt.wantLastError {
+				t.Errorf("expected last error %q, got %q", tt.wantLastError, got.Status.LastError)
+			}
+		})
+	}
+}
+
+func Test_tenantPhase(t *testing.T) {
+	tests := []struct {
+		status integreatlyv1alpha1.ProvisioningStatus
+		want   integreatlyv1alpha1.TenantPhase
+	}{
+		{status: integreatlyv1alpha1.UserAnnotated, want: integreatlyv1alpha1.TenantPhaseProvisioning},
+		{status: integreatlyv1alpha1.ThreeScaleAccountRequested, want: integreatlyv1alpha1.TenantPhaseProvisioning},
+		{status: integreatlyv1alpha1.ThreeScaleAccountReady, want: integreatlyv1alpha1.TenantPhaseReady},
+		{status: integreatlyv1alpha1.WontProvisionTenant, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tenantPhase(tt.status); got != tt.want {
+				t.Errorf("tenantPhase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
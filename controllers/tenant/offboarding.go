@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	portaClient "github.com/3scale/3scale-porta-go-client/client"
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/metering"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// offboardingKeyPrefix is the prefix of the tenant exports in the
+	// offboarding bucket
+	offboardingKeyPrefix = "tenant-exports"
+
+	operatorNamespace     = "sandbox-rhoam-operator"
+	accessTokenSecretName = "mt-signupaccount-3scale-access-token" // #nosec G101 -- This is a false positive
+)
+
+// tenantExport is the 3scale configuration and the usage of a tenant
+type tenantExport struct {
+	Tenant     string            `json:"tenant"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Products   []exportedProduct `json:"products"`
+	Usage      []exportedUsage   `json:"usage"`
+}
+
+type exportedProduct struct {
+	portaClient.ProductItem
+	ApplicationPlans []portaClient.ApplicationPlanItem `json:"applicationPlans"`
+}
+
+// exportedUsage is the usage of the tenant over the period of a usage report
+type exportedUsage struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	metering.TenantUsage
+}
+
+// productClient is the part of the 3scale account management API of a tenant
+// used to export its products
+type productClient interface {
+	ListProducts() (*portaClient.ProductList, error)
+	ListApplicationPlansByProduct(productID int64) (*portaClient.ApplicationPlanJSONList, error)
+}
+
+// exportTenant exports the products and application plans of the 3scale
+// account of the tenant, and its usage reports, to the offboarding bucket.
+// The key of the export is recorded in the status so the tenant is only
+// exported once
+func (r *TenantReconciler) exportTenant(tenant *v1alpha1.APIManagementTenant, now time.Time) error {
+	if tenant.Spec.Offboarding == nil || tenant.Status.OffboardingExport != "" {
+		return nil
+	}
+	log.Info(fmt.Sprintf("TenantReconciler exportTenant: %v", tenant))
+
+	tenantName, err := userHelper.SanitiseTenantUserName(tenant.GetUsername())
+	if err != nil {
+		return err
+	}
+	export := &tenantExport{
+		Tenant:     tenantName,
+		ExportedAt: now.UTC(),
+		Products:   []exportedProduct{},
+	}
+
+	// The tenants deleted before their 3scale account was ready only have
+	// their usage exported
+	if tenant.Status.TenantUrl != "" {
+		client, err := r.newProductClient(tenant.Status.TenantUrl, tenantName)
+		if err != nil {
+			return err
+		}
+		export.Products, err = exportProducts(client)
+		if err != nil {
+			return fmt.Errorf("failed to export the products of tenant %s: %v", tenant.Name, err)
+		}
+	}
+
+	usageReports := &corev1.ConfigMap{}
+	err = r.Client.Get(context.TODO(), k8sclient.ObjectKey{Name: metering.ConfigMapName, Namespace: operatorNamespace}, usageReports)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return fmt.Errorf("error getting the usage reports: %v", err)
+	}
+	export.Usage, err = exportUsage(usageReports.Data, tenantName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+	store, err := objectstore.NewS3ObjectStore(context.TODO(), r.Client, tenant.Namespace, &tenant.Spec.Offboarding.Export)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s/%s/%s.json", offboardingKeyPrefix, tenantName, now.UTC().Format("20060102T150405Z"))
+	if err := store.Put(context.TODO(), key, data); err != nil {
+		return fmt.Errorf("failed to export tenant %s: %v", tenant.Name, err)
+	}
+
+	tenant.Status.OffboardingExport = key
+	if err := r.Client.Status().Update(context.TODO(), tenant); err != nil {
+		return fmt.Errorf("error updating the offboarding export to %s for tenant %s: %v", key, tenant.Name, err)
+	}
+	return nil
+}
+
+// newProductClient returns the client of the 3scale account of the tenant
+// with the access token created with the account
+func (r *TenantReconciler) newProductClient(adminHost, tenantName string) (productClient, error) {
+	accessTokens := &corev1.Secret{}
+	if err := r.Client.Get(context.TODO(), k8sclient.ObjectKey{Name: accessTokenSecretName, Namespace: threeScaleNamespace}, accessTokens); err != nil {
+		return nil, fmt.Errorf("error getting the 3scale access tokens: %v", err)
+	}
+	accessToken := string(accessTokens.Data[tenantName])
+	if accessToken == "" {
+		return nil, fmt.Errorf("failed to find the 3scale access token of tenant %s", tenantName)
+	}
+
+	adminURL, err := url.Parse("https://" + adminHost)
+	if err != nil || adminURL.Hostname() == "" {
+		return nil, fmt.Errorf("invalid admin host %q of tenant %s", adminHost, tenantName)
+	}
+	adminPortal, err := portaClient.NewAdminPortal("https", adminURL.Hostname(), 443)
+	if err != nil {
+		return nil, fmt.Errorf("could not create admin portal of tenant %s: %v", tenantName, err)
+	}
+	return portaClient.NewThreeScale(adminPortal, accessToken, &http.Client{Timeout: time.Second * 10}), nil
+}
+
+// exportProducts returns the products of the tenant with their application
+// plans
+func exportProducts(client productClient) ([]exportedProduct, error) {
+	products, err := client.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]exportedProduct, 0, len(products.Products))
+	for _, product := range products.Products {
+		plans, err := client.ListApplicationPlansByProduct(product.Element.ID)
+		if err != nil {
+			return nil, err
+		}
+		exportedPlans := make([]portaClient.ApplicationPlanItem, 0, len(plans.Plans))
+		for _, plan := range plans.Plans {
+			exportedPlans = append(exportedPlans, plan.Element)
+		}
+		exported = append(exported, exportedProduct{
+			ProductItem:      product.Element,
+			ApplicationPlans: exportedPlans,
+		})
+	}
+	return exported, nil
+}
+
+// exportUsage returns the usage of the tenant in the usage reports, oldest
+// first
+func exportUsage(usageReports map[string]string, tenantName string) ([]exportedUsage, error) {
+	keys := make([]string, 0, len(usageReports))
+	for key := range usageReports {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	usage := []exportedUsage{}
+	for _, key := range keys {
+		report := &metering.Report{}
+		if err := json.Unmarshal([]byte(usageReports[key]), report); err != nil {
+			return nil, fmt.Errorf("invalid usage report %s: %v", key, err)
+		}
+		for _, tenantUsage := range report.Tenants {
+			if tenantUsage.Tenant == tenantName {
+				usage = append(usage, exportedUsage{Start: report.Start, End: report.End, TenantUsage: tenantUsage})
+			}
+		}
+	}
+	return usage, nil
+}
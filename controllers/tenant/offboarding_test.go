@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	portaClient "github.com/3scale/3scale-porta-go-client/client"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/metering"
+)
+
+type fakeProductClient struct {
+	products *portaClient.ProductList
+	plans    map[int64]*portaClient.ApplicationPlanJSONList
+}
+
+func (f *fakeProductClient) ListProducts() (*portaClient.ProductList, error) {
+	return f.products, nil
+}
+
+func (f *fakeProductClient) ListApplicationPlansByProduct(productID int64) (*portaClient.ApplicationPlanJSONList, error) {
+	plans, ok := f.plans[productID]
+	if !ok {
+		return nil, fmt.Errorf("product %d not found", productID)
+	}
+	return plans, nil
+}
+
+func TestExportProducts(t *testing.T) {
+	client := &fakeProductClient{
+		products: &portaClient.ProductList{Products: []portaClient.Product{
+			{Element: portaClient.ProductItem{ID: 1, Name: "Echo", SystemName: "echo"}},
+			{Element: portaClient.ProductItem{ID: 2, Name: "Empty", SystemName: "empty"}},
+		}},
+		plans: map[int64]*portaClient.ApplicationPlanJSONList{
+			1: {Plans: []portaClient.ApplicationPlan{
+				{Element: portaClient.ApplicationPlanItem{ID: 10, Name: "Basic", SystemName: "basic"}},
+				{Element: portaClient.ApplicationPlanItem{ID: 11, Name: "Premium", SystemName: "premium", CostPerMonth: 10}},
+			}},
+			2: {},
+		},
+	}
+
+	got, err := exportProducts(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []exportedProduct{
+		{
+			ProductItem: portaClient.ProductItem{ID: 1, Name: "Echo", SystemName: "echo"},
+			ApplicationPlans: []portaClient.ApplicationPlanItem{
+				{ID: 10, Name: "Basic", SystemName: "basic"},
+				{ID: 11, Name: "Premium", SystemName: "premium", CostPerMonth: 10},
+			},
+		},
+		{
+			ProductItem:      portaClient.ProductItem{ID: 2, Name: "Empty", SystemName: "empty"},
+			ApplicationPlans: []portaClient.ApplicationPlanItem{},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("exportProducts() = %v, want %v", got, want)
+	}
+}
+
+func TestExportUsage(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	report := func(start time.Time, tenants ...metering.TenantUsage) string {
+		data, err := json.Marshal(metering.Report{Start: start, End: start.Add(metering.Period), Tenants: tenants})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := []struct {
+		name         string
+		usageReports map[string]string
+		want         []exportedUsage
+		wantErr      bool
+	}{
+		{
+			name: "test no usage without usage reports",
+			want: []exportedUsage{},
+		},
+		{
+			name: "test usage of the tenant oldest first",
+			usageReports: map[string]string{
+				"2024-03-05.json": report(day.Add(metering.Period), metering.TenantUsage{Tenant: "tenant-a", APICalls: 20}),
+				"2024-03-04.json": report(day,
+					metering.TenantUsage{Tenant: "tenant-a", APICalls: 10, RejectedCalls: 1, SSOLogins: 2},
+					metering.TenantUsage{Tenant: "tenant-b", APICalls: 30},
+				),
+			},
+			want: []exportedUsage{
+				{Start: day, End: day.Add(metering.Period), TenantUsage: metering.TenantUsage{Tenant: "tenant-a", APICalls: 10, RejectedCalls: 1, SSOLogins: 2}},
+				{Start: day.Add(metering.Period), End: day.Add(2 * metering.Period), TenantUsage: metering.TenantUsage{Tenant: "tenant-a", APICalls: 20}},
+			},
+		},
+		{
+			name:         "test error on invalid usage report",
+			usageReports: map[string]string{"2024-03-04.json": "{"},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := exportUsage(tt.usageReports, "tenant-a")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("exportUsage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("exportUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// RequestTenantAnnotation requests an APIManagementTenant for the user of
+	// the annotated dev or stage namespace when set to "true"
+	RequestTenantAnnotation = "integreatly.org/request-tenant"
+	// onboardedTenantLabel marks the APIManagementTenants created for the
+	// annotation, they're deleted when the annotation is removed
+	onboardedTenantLabel = "integreatly.org/onboarded-tenant"
+	onboardedTenantName  = "tenant"
+)
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenants,verbs=create;delete
+
+// OnboardingReconciler creates the APIManagementTenants requested by the
+// namespace annotation, so the users can be onboarded without access to the
+// APIManagementTenant CRs. The tenants are provisioned by the TenantReconciler
+type OnboardingReconciler struct {
+	k8sclient.Client
+	Scheme *runtime.Scheme
+}
+
+func NewOnboardingReconciler(mgr manager.Manager) *OnboardingReconciler {
+	return &OnboardingReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}
+}
+
+func (r *OnboardingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("tenant-onboarding").
+		For(&corev1.Namespace{}, builder.WithPredicates(onboardingPredicate())).
+		Complete(r)
+}
+
+// onboardingPredicate filters the namespaces requesting a tenant, or which
+// stopped requesting it
+func onboardingPredicate() predicate.Funcs {
+	hasAnnotation := func(obj k8sclient.Object) bool {
+		_, ok := obj.GetAnnotations()[RequestTenantAnnotation]
+		return ok
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasAnnotation(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return hasAnnotation(e.ObjectOld) || hasAnnotation(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func (r *OnboardingReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, request.NamespacedName, namespace); err != nil {
+		return ctrl.Result{}, k8sclient.IgnoreNotFound(err)
+	}
+	if namespace.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	tenants := &v1alpha1.APIManagementTenantList{}
+	if err := r.List(ctx, tenants, k8sclient.InNamespace(namespace.Name)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error listing the tenants of namespace %s: %v", namespace.Name, err)
+	}
+
+	if namespace.Annotations[RequestTenantAnnotation] != "true" {
+		// Only the tenants created for the annotation are removed with it
+		for i := range tenants.Items {
+			if tenants.Items[i].Labels[onboardedTenantLabel] != "true" {
+				continue
+			}
+			if err := r.Delete(ctx, &tenants.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+				return ctrl.Result{}, fmt.Errorf("error deleting tenant %s in namespace %s: %v", tenants.Items[i].Name, namespace.Name, err)
+			}
+			log.Info(fmt.Sprintf("OnboardingReconciler deleted tenant %s in namespace %s", tenants.Items[i].Name, namespace.Name))
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if len(tenants.Items) > 0 {
+		return ctrl.Result{}, nil
+	}
+	tenant := &v1alpha1.APIManagementTenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      onboardedTenantName,
+			Namespace: namespace.Name,
+			Labels: map[string]string{
+				onboardedTenantLabel: "true",
+			},
+		},
+	}
+	if err := r.Create(ctx, tenant); err != nil && !k8serr.IsAlreadyExists(err) {
+		return ctrl.Result{}, fmt.Errorf("error creating tenant in namespace %s: %v", namespace.Name, err)
+	}
+	log.Info(fmt.Sprintf("OnboardingReconciler created tenant %s in namespace %s", tenant.Name, namespace.Name))
+	return ctrl.Result{}, nil
+}
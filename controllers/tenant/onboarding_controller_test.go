@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOnboardingReconciler_Reconcile(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := func(annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        validNamespace,
+				Annotations: annotations,
+			},
+		}
+	}
+	onboardedTenant := &integreatlyv1alpha1.APIManagementTenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      onboardedTenantName,
+			Namespace: validNamespace,
+			Labels:    map[string]string{onboardedTenantLabel: "true"},
+		},
+	}
+	manualTenant := &integreatlyv1alpha1.APIManagementTenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      validTenantName,
+			Namespace: validNamespace,
+		},
+	}
+
+	tests := []struct {
+		name        string
+		objects     []runtime.Object
+		wantTenants []string
+	}{
+		{
+			name:        "Test creates the tenant requested by the annotation",
+			objects:     []runtime.Object{namespace(map[string]string{RequestTenantAnnotation: "true"})},
+			wantTenants: []string{onboardedTenantName},
+		},
+		{
+			name:        "Test keeps the existing tenant of the namespace",
+			objects:     []runtime.Object{namespace(map[string]string{RequestTenantAnnotation: "true"}), manualTenant.DeepCopy()},
+			wantTenants: []string{validTenantName},
+		},
+		{
+			name:        "Test ignores the annotation not set to true",
+			objects:     []runtime.Object{namespace(map[string]string{RequestTenantAnnotation: "false"})},
+			wantTenants: []string{},
+		},
+		{
+			name:        "Test deletes the onboarded tenant when the annotation is removed",
+			objects:     []runtime.Object{namespace(nil), onboardedTenant.DeepCopy()},
+			wantTenants: []string{},
+		},
+		{
+			name:        "Test keeps the tenant not created for the annotation when the annotation is removed",
+			objects:     []runtime.Object{namespace(nil), manualTenant.DeepCopy()},
+			wantTenants: []string{validTenantName},
+		},
+		{
+			name:        "Test ignores the deleted namespace",
+			objects:     []runtime.Object{},
+			wantTenants: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OnboardingReconciler{
+				Client: utils.NewTestClient(scheme, tt.objects...),
+				Scheme: scheme,
+			}
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Name: validNamespace}})
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if result.Requeue {
+				t.Fatalf("Reconcile() requeued, want no requeue")
+			}
+
+			tenants := &integreatlyv1alpha1.APIManagementTenantList{}
+			if err := r.List(context.TODO(), tenants, client.InNamespace(validNamespace)); err != nil {
+				t.Fatal(err)
+			}
+			if len(tenants.Items) != len(tt.wantTenants) {
+				t.Fatalf("got %d tenants, want %v", len(tenants.Items), tt.wantTenants)
+			}
+			for i, tenant := range tenants.Items {
+				if tenant.Name != tt.wantTenants[i] {
+					t.Fatalf("got tenant %s, want %s", tenant.Name, tt.wantTenants[i])
+				}
+			}
+		})
+	}
+}
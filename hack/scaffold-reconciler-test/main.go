@@ -0,0 +1,503 @@
+// scaffold-reconciler-test generates a table driven unit test for a product
+// reconciler function with the fake client, scheme and RHMI fixtures already
+// set up. The Reconciler literal and the call arguments are built from the
+// product package source, so the generated test compiles as is and only needs
+// the objects the function reads added to its cases, e.g.
+//
+//	go run ./hack/scaffold-reconciler-test -product grafana -func reconcileServiceAccount
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const modulePath = "github.com/integr8ly/integreatly-operator"
+
+const testTemplate = `package {{ .Package }}
+
+import (
+{{- range .StdImports }}
+	{{ . }}
+{{- end }}
+{{ range .Imports }}
+	{{ . }}
+{{- end }}
+)
+
+func {{ .Test }}(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		installation *integreatlyv1alpha1.RHMI
+		initObjs     []runtime.Object
+		want         integreatlyv1alpha1.StatusPhase
+		wantErr      bool
+		assert       func(k8sclient.Client) error
+	}{
+		// TODO: add the objects {{ .Func }} reads, e.g. routes, secrets or
+		// subscriptions, to initObjs and the failure cases it handles
+		{
+			name:         "test {{ .Func }} completes for managed-api installations",
+			installation: utils.NewTestManagedApiInstallation(),
+			want:         integreatlyv1alpha1.PhaseCompleted,
+		},
+		{
+			name:         "test {{ .Func }} completes for multitenant installations",
+			installation: utils.NewTestMultitenantInstallation(),
+			want:         integreatlyv1alpha1.PhaseCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, append(tt.initObjs, tt.installation)...)
+			r := {{ .Reconciler }}
+
+			got, err := r.{{ .Func }}({{ .Args }})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("{{ .Func }}() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("{{ .Func }}() got = %v, want %v", got, tt.want)
+			}
+			if tt.assert != nil {
+				if err := tt.assert(client); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+`
+
+// imports used by the generated test whatever the reconciler looks like
+var (
+	baseStdImports = []string{`"context"`, `"testing"`}
+	baseImports    = []string{
+		`integreatlyv1alpha1 "` + modulePath + `/apis/v1alpha1"`,
+		`"` + modulePath + `/utils"`,
+		`"k8s.io/apimachinery/pkg/runtime"`,
+		`k8sclient "sigs.k8s.io/controller-runtime/pkg/client"`,
+	}
+)
+
+type scaffold struct {
+	Package string
+	Func    string
+	Test    string
+}
+
+// testFile is the data the test template is rendered with
+type testFile struct {
+	Package    string
+	Func       string
+	Test       string
+	StdImports []string
+	Imports    []string
+	Reconciler string
+	Args       string
+}
+
+// goPackage is the parsed, non test source of a package
+type goPackage struct {
+	dir   string
+	files []*ast.File
+}
+
+// typeRef is a parameter or field type resolved to the import path of the
+// package declaring it
+type typeRef struct {
+	pointer bool
+	path    string
+	name    string
+}
+
+func main() {
+	s := scaffold{}
+	flag.StringVar(&s.Package, "product", "", "product package under pkg/products, e.g. grafana")
+	flag.StringVar(&s.Func, "func", "", "Reconciler method to test, returning (StatusPhase, error)")
+	flag.StringVar(&s.Test, "test", "", "name of the generated test, defaults to TestReconciler_<func>")
+	out := flag.String("out", "", "output file, defaults to pkg/products/<product>/<func>_test.go")
+	force := flag.Bool("force", false, "overwrite the output file if it exists")
+	flag.Parse()
+
+	if err := run(s, ".", *out, *force); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(s scaffold, root, out string, force bool) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if s.Test == "" {
+		s.Test = "TestReconciler_" + s.Func
+	}
+	dir := filepath.Join(root, "pkg", "products", s.Package)
+	if out == "" {
+		out = filepath.Join(dir, fmt.Sprintf("%s_test.go", s.Func))
+	}
+	if _, err := os.Stat(out); err == nil && !force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", out)
+	}
+
+	existing, err := testNames(dir, out)
+	if err != nil {
+		return err
+	}
+	if existing[s.Test] {
+		return fmt.Errorf("%s is already declared in package %s, use -test to name the generated test", s.Test, s.Package)
+	}
+
+	src, err := render(s, root)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	fmt.Printf("generated %s\n", out)
+
+	return nil
+}
+
+func (s scaffold) validate() error {
+	if s.Package == "" || s.Func == "" {
+		return errors.New("-product and -func are required")
+	}
+	if strings.ContainsAny(s.Package, "/. ") {
+		return fmt.Errorf("invalid product package %s", s.Package)
+	}
+	if s.Test != "" && !strings.HasPrefix(s.Test, "Test") {
+		return fmt.Errorf("test name %s must start with Test", s.Test)
+	}
+	return nil
+}
+
+func render(s scaffold, root string) ([]byte, error) {
+	pkg, err := parsePackage(filepath.Join(root, "pkg", "products", s.Package))
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]bool{}
+	for _, imp := range baseImports {
+		imports[imp] = true
+	}
+	reconciler, err := pkg.reconcilerLiteral(root, s.Package, imports)
+	if err != nil {
+		return nil, err
+	}
+	args, err := pkg.callArgs(s.Func)
+	if err != nil {
+		return nil, err
+	}
+
+	data := testFile{
+		Package:    pkg.files[0].Name.Name,
+		Func:       s.Func,
+		Test:       s.Test,
+		StdImports: baseStdImports,
+		Reconciler: reconciler,
+		Args:       strings.Join(args, ", "),
+	}
+	for imp := range imports {
+		data.Imports = append(data.Imports, imp)
+	}
+	sort.Strings(data.Imports)
+
+	tmpl, err := template.New("test").Parse(testTemplate)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render test: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated test: %w", err)
+	}
+	return src, nil
+}
+
+// testNames returns the test functions already declared in the package in
+// dir, ignoring skip, the file the generated test replaces
+func testNames(dir, skip string) (map[string]bool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, path := range paths {
+		if same, err := samePath(path, skip); err != nil {
+			return nil, err
+		} else if same {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				names[fn.Name.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+func samePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
+
+func parsePackage(dir string) (*goPackage, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &goPackage{dir: dir}
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		pkg.files = append(pkg.files, file)
+	}
+	if len(pkg.files) == 0 {
+		return nil, fmt.Errorf("no go source found in %s", dir)
+	}
+	return pkg, nil
+}
+
+// reconcilerLiteral returns a Reconciler composite literal setting the fields
+// a test can fill in without a cluster, adding the imports it uses
+func (p *goPackage) reconcilerLiteral(root, product string, imports map[string]bool) (string, error) {
+	file, spec := p.reconcilerStruct()
+	if spec == nil {
+		return "", fmt.Errorf("no Reconciler struct found in %s", p.dir)
+	}
+
+	fields := []string{}
+	for _, field := range spec.Fields.List {
+		ref, ok := resolve(file, field.Type)
+		if !ok {
+			continue
+		}
+
+		// embedded reconcilers of other products, e.g. rhssocommon, can only
+		// be set through their own literal
+		if len(field.Names) == 0 {
+			if !ref.pointer || ref.name != "Reconciler" || !strings.HasPrefix(ref.path, modulePath+"/pkg/products/") {
+				continue
+			}
+			embedded, err := parsePackage(filepath.Join(root, strings.TrimPrefix(ref.path, modulePath+"/")))
+			if err != nil {
+				return "", err
+			}
+			value, err := embedded.reconcilerLiteral(root, product, imports)
+			if err != nil {
+				return "", err
+			}
+			pkgName := embedded.files[0].Name.Name
+			imports[strconv.Quote(ref.path)] = true
+			fields = append(fields, fmt.Sprintf("Reconciler: &%s.%s,", pkgName, strings.TrimPrefix(value, "&")))
+			continue
+		}
+
+		value, imp := fieldValue(ref, product)
+		if value == "" {
+			continue
+		}
+		if imp != "" {
+			imports[imp] = true
+		}
+		for _, name := range field.Names {
+			fields = append(fields, fmt.Sprintf("%s: %s,", name.Name, value))
+		}
+	}
+
+	return "&Reconciler{\n" + strings.Join(fields, "\n") + "\n}", nil
+}
+
+func (p *goPackage) reconcilerStruct() (*ast.File, *ast.StructType) {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok && ts.Name.Name == "Reconciler" {
+					return file, st
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// fieldValue returns the test value of a Reconciler field and the import it
+// needs, or an empty value for fields left unset
+func fieldValue(ref typeRef, product string) (string, string) {
+	configImport := strconv.Quote(modulePath + "/pkg/config")
+	switch {
+	case ref == typeRef{path: modulePath + "/pkg/config", name: "ConfigReadWriter"}:
+		return "&config.ConfigReadWriterMock{}", configImport
+	case ref.pointer && ref.path == modulePath+"/pkg/config":
+		return fmt.Sprintf(`config.New%s(config.ProductConfig{
+			"NAMESPACE":          utils.TestNamespacePrefix + %q,
+			"OPERATOR_NAMESPACE": utils.TestNamespacePrefix + %q,
+		})`, ref.name, product, product+"-operator"), configImport
+	case ref == typeRef{pointer: true, path: modulePath + "/apis/v1alpha1", name: "RHMI"}:
+		return "tt.installation", ""
+	case ref == typeRef{path: modulePath + "/pkg/resources/logger", name: "Logger"}:
+		return fmt.Sprintf("l.NewLoggerWithContext(l.Fields{l.ProductLogContext: %q})", product),
+			`l "` + modulePath + `/pkg/resources/logger"`
+	case ref == typeRef{path: "k8s.io/client-go/tools/record", name: "EventRecorder"}:
+		return "record.NewFakeRecorder(50)", `"k8s.io/client-go/tools/record"`
+	}
+	return "", ""
+}
+
+// callArgs returns the arguments the generated test calls fn with
+func (p *goPackage) callArgs(fn string) ([]string, error) {
+	file, decl := p.method(fn)
+	if decl == nil {
+		return nil, fmt.Errorf("no Reconciler method %s found in %s", fn, p.dir)
+	}
+
+	results := decl.Type.Results
+	if results == nil || results.NumFields() != 2 {
+		return nil, fmt.Errorf("%s must return (StatusPhase, error)", fn)
+	}
+	phase, _ := resolve(file, results.List[0].Type)
+	if phase != (typeRef{path: modulePath + "/apis/v1alpha1", name: "StatusPhase"}) {
+		return nil, fmt.Errorf("%s must return (StatusPhase, error)", fn)
+	}
+
+	args := []string{}
+	for _, param := range decl.Type.Params.List {
+		ref, _ := resolve(file, param.Type)
+		var arg string
+		switch ref {
+		case typeRef{path: "context", name: "Context"}:
+			arg = "context.TODO()"
+		case typeRef{path: "sigs.k8s.io/controller-runtime/pkg/client", name: "Client"}:
+			arg = "client"
+		case typeRef{pointer: true, path: modulePath + "/apis/v1alpha1", name: "RHMI"}:
+			arg = "tt.installation"
+		case typeRef{pointer: true, path: modulePath + "/apis/v1alpha1", name: "RHMIProductStatus"}:
+			arg = "&integreatlyv1alpha1.RHMIProductStatus{}"
+		default:
+			return nil, fmt.Errorf("%s has a parameter of unsupported type %s", fn, typeString(param.Type))
+		}
+		// one argument per name, e.g. for func(a, b k8sclient.Client)
+		for range names(param) {
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+func (p *goPackage) method(name string) (*ast.File, *ast.FuncDecl) {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != name {
+				continue
+			}
+			if star, ok := fn.Recv.List[0].Type.(*ast.StarExpr); ok {
+				if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "Reconciler" {
+					return file, fn
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func names(field *ast.Field) []*ast.Ident {
+	if len(field.Names) == 0 {
+		return []*ast.Ident{{Name: "_"}}
+	}
+	return field.Names
+}
+
+// resolve returns the import path and name of a type declared in another
+// package, e.g. *integreatlyv1alpha1.RHMI, using the imports of file
+func resolve(file *ast.File, expr ast.Expr) (typeRef, bool) {
+	ref := typeRef{}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		ref.pointer = true
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return ref, false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ref, false
+	}
+
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == pkg.Name {
+			ref.path = path
+			ref.name = sel.Sel.Name
+			return ref, true
+		}
+	}
+	return ref, false
+}
+
+func typeString(expr ast.Expr) string {
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, token.NewFileSet(), expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return buf.String()
+}
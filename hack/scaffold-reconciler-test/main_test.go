@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repoRoot is the repository root relative to this package
+const repoRoot = "../.."
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		scaffold scaffold
+		want     []string
+	}{
+		{
+			name:     "test grafana test sets the reconciler fields directly",
+			scaffold: scaffold{Package: "grafana", Func: "reconcileHost", Test: "TestReconciler_reconcileHost"},
+			want: []string{
+				"package grafana",
+				"func TestReconciler_reconcileHost(",
+				"ConfigManager: &config.ConfigReadWriterMock{},",
+				"config.NewGrafana(",
+				"installation: tt.installation,",
+				"r.reconcileHost(context.TODO(), client)",
+			},
+		},
+		{
+			name:     "test rhsso test sets the promoted fields on the embedded reconciler",
+			scaffold: scaffold{Package: "rhsso", Func: "reconcileComponents", Test: "TestReconciler_reconcileComponentsScaffold"},
+			want: []string{
+				"package rhsso",
+				"config.NewRHSSO(",
+				"Reconciler: &rhssocommon.Reconciler{",
+				"ConfigManager: &config.ConfigReadWriterMock{},",
+				"Installation:  tt.installation,",
+				"r.reconcileComponents(context.TODO(), tt.installation, client)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := render(tt.scaffold, repoRoot)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+				t.Fatalf("generated test is not valid go: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(src), want) {
+					t.Fatalf("expected generated test to contain %q, got\n%s", want, src)
+				}
+			}
+			if strings.Contains(string(src), "t.Skip(") {
+				t.Fatal("expected generated test not to be skipped")
+			}
+		})
+	}
+}
+
+// TestGeneratedTestsCompile type checks generated tests in their product
+// packages by overlaying them on the source tree
+func TestGeneratedTestsCompile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling product packages is slow")
+	}
+
+	scaffolds := []scaffold{
+		{Package: "grafana", Func: "reconcileServiceAccount"},
+		{Package: "rhsso", Func: "reconcileComponents", Test: "TestReconciler_reconcileComponentsScaffold"},
+		{Package: "rhssouser", Func: "reconcileIdentityProvider", Test: "TestReconciler_reconcileIdentityProviderScaffold"},
+	}
+
+	root, err := filepath.Abs(repoRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	overlay := map[string]map[string]string{"Replace": {}}
+	packages := []string{}
+	for _, s := range scaffolds {
+		out := filepath.Join(tmp, s.Package+"_test.go")
+		if err := run(s, root, out, false); err != nil {
+			t.Fatalf("failed to generate %s test: %v", s.Package, err)
+		}
+		overlay["Replace"][filepath.Join(root, "pkg", "products", s.Package, "zz_scaffold_test.go")] = out
+		packages = append(packages, "./pkg/products/"+s.Package)
+	}
+
+	overlayJSON, err := json.Marshal(overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlayFile := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlayJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", append([]string{"vet", "-overlay", overlayFile}, packages...)...)
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated tests do not compile: %v\n%s", err, out)
+	}
+}
+
+func TestRun(t *testing.T) {
+	valid := scaffold{Package: "grafana", Func: "reconcileHost", Test: "TestReconciler_reconcileHostGenerated"}
+
+	tests := []struct {
+		name     string
+		scaffold scaffold
+		existing bool
+		force    bool
+		wantErr  bool
+	}{
+		{
+			name:     "test test file is generated",
+			scaffold: valid,
+		},
+		{
+			name:     "test existing test file is not overwritten",
+			scaffold: valid,
+			existing: true,
+			wantErr:  true,
+		},
+		{
+			name:     "test existing test file is overwritten with force",
+			scaffold: valid,
+			existing: true,
+			force:    true,
+		},
+		{
+			name:     "test missing flags are rejected",
+			scaffold: scaffold{Package: "grafana"},
+			wantErr:  true,
+		},
+		{
+			name:     "test test name already declared in the package is rejected",
+			scaffold: scaffold{Package: "grafana", Func: "reconcileExtraDataSources"},
+			wantErr:  true,
+		},
+		{
+			name:     "test test name without the Test prefix is rejected",
+			scaffold: scaffold{Package: "grafana", Func: "reconcileHost", Test: "reconcileHost"},
+			wantErr:  true,
+		},
+		{
+			name:     "test unknown reconciler function is rejected",
+			scaffold: scaffold{Package: "grafana", Func: "reconcileNothing"},
+			wantErr:  true,
+		},
+		{
+			name:     "test function with unsupported parameters is rejected",
+			scaffold: scaffold{Package: "rhssouser", Func: "reconcileComponents", Test: "TestReconciler_reconcileComponentsGenerated"},
+			wantErr:  true,
+		},
+		{
+			name:     "test function not returning a phase is rejected",
+			scaffold: scaffold{Package: "grafana", Func: "reconcileConsoleLink"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "generated_test.go")
+			if tt.existing {
+				if err := os.WriteFile(out, []byte("package grafana\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := run(tt.scaffold, repoRoot, out, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			src, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(src), "func "+tt.scaffold.Test+"(") {
+				t.Fatal("expected the generated test to be written")
+			}
+		})
+	}
+}
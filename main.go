@@ -27,6 +27,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/secretscan"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 
@@ -36,7 +38,10 @@ import (
 	integreatlymetrics "github.com/integr8ly/integreatly-operator/pkg/metrics"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	customMetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
@@ -46,12 +51,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	rhmiv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	rhmiv1alpha2 "github.com/integr8ly/integreatly-operator/apis/v1alpha2"
 	namespacecontroller "github.com/integr8ly/integreatly-operator/controllers/namespacelabel"
 	rhmicontroller "github.com/integr8ly/integreatly-operator/controllers/rhmi"
 	subscriptioncontroller "github.com/integr8ly/integreatly-operator/controllers/subscription"
 	tenantcontroller "github.com/integr8ly/integreatly-operator/controllers/tenant"
 	usercontroller "github.com/integr8ly/integreatly-operator/controllers/user"
 	"github.com/integr8ly/integreatly-operator/pkg/addon"
+	"github.com/integr8ly/integreatly-operator/pkg/adminapi"
 	"github.com/integr8ly/integreatly-operator/pkg/webhooks"
 	// +kubebuilder:scaffold:imports
 )
@@ -76,6 +83,15 @@ func init() {
 	customMetrics.Registry.MustRegister(integreatlymetrics.CustomDomain)
 	customMetrics.Registry.MustRegister(integreatlymetrics.ThreeScalePortals)
 	customMetrics.Registry.MustRegister(integreatlymetrics.RhoamStateMetric)
+	customMetrics.Registry.MustRegister(integreatlymetrics.ComponentInfo)
+	customMetrics.Registry.MustRegister(integreatlymetrics.FeatureUsage)
+	customMetrics.Registry.MustRegister(integreatlymetrics.CredentialLeakBlocked)
+	customMetrics.Registry.MustRegister(integreatlymetrics.CertificateExpiry)
+	customMetrics.Registry.MustRegister(integreatlymetrics.ProductReconcileDuration)
+	customMetrics.Registry.MustRegister(integreatlymetrics.ProductReconcileErrors)
+	customMetrics.Registry.MustRegister(integreatlymetrics.ProductPhase)
+	customMetrics.Registry.MustRegister(integreatlymetrics.LDAPFederationUp)
+	customMetrics.Registry.MustRegister(integreatlymetrics.LDAPFederationLastSync)
 
 	integreatlymetrics.OperatorVersion.Add(1)
 	utilruntime.Must(v1.Install(clientgoscheme.Scheme))
@@ -83,6 +99,7 @@ func init() {
 
 	utilruntime.Must(rhmiv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(rhmiv1alpha1.AddToSchemes.AddToScheme(scheme))
+	utilruntime.Must(rhmiv1alpha2.AddToScheme(scheme))
 	utilruntime.Must(apiextensions.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 	// +kubebuilder:rbac:groups=integreatly.org,resources=apimanagementtenant,verbs=watch;get;list
@@ -95,6 +112,7 @@ func main() {
 	var probeAddr string
 	var addonInstanceName string
 	var heartbeatInterval time.Duration
+	var enableTenantOnboarding bool
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8383", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
@@ -102,6 +120,8 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.StringVar(&addonInstanceName, "addon-instance-name", "addon-instance", "The addon instance name the addon is reporting status to.")
 	flag.DurationVar(&heartbeatInterval, "heartbeat-interval", 10*time.Second, "Time between heartbeats sent to addon instance")
+	flag.BoolVar(&enableTenantOnboarding, "enable-tenant-onboarding", false,
+		"Enable creating the tenants requested by the "+tenantcontroller.RequestTenantAnnotation+" namespace annotation in multitenant installations.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -126,6 +146,7 @@ func main() {
 			HealthProbeBindAddress: probeAddr,
 			LeaderElection:         enableLeaderElection,
 			LeaderElectionID:       "28185cee.integreatly.org",
+			NewClient:              newClient,
 		})
 		if err != nil {
 			setupLog.Error(err, "unable to start multitenant manager")
@@ -139,6 +160,7 @@ func main() {
 			HealthProbeBindAddress: probeAddr,
 			LeaderElection:         enableLeaderElection,
 			LeaderElectionID:       "28185cee.integreatly.org",
+			NewClient:              newClient,
 			Namespace:              watchNamespace,
 		})
 		if err != nil {
@@ -175,6 +197,13 @@ func main() {
 			setupLog.Error(err, "unable to setup controller", "controller", "TenantController")
 			os.Exit(1)
 		}
+
+		if enableTenantOnboarding {
+			if err = tenantcontroller.NewOnboardingReconciler(mgr).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to setup controller", "controller", "TenantOnboarding")
+				os.Exit(1)
+			}
+		}
 	}
 
 	subscriptionCtrl, err := subscriptioncontroller.New(mgr)
@@ -225,7 +254,7 @@ func main() {
 
 	// +kubebuilder:scaffold:builder
 
-	if err := setupWebhooks(mgr); err != nil {
+	if err := setupWebhooks(mgr, client, watchNamespace); err != nil {
 		setupLog.Error(err, "Error setting up webhook server")
 	}
 
@@ -245,7 +274,7 @@ func main() {
 	}
 }
 
-func setupWebhooks(mgr ctrl.Manager) error {
+func setupWebhooks(mgr ctrl.Manager, client k8sclient.Client, watchNamespace string) error {
 
 	// Delete webhook for the RHMI CR that uninstalls the operator if there
 	// are no finalizers left
@@ -264,6 +293,63 @@ func setupWebhooks(mgr ctrl.Manager) error {
 		},
 	})
 
+	// Mutating webhook writing the effective defaults to the RHMI CR, and
+	// validating webhook rejecting the settings the CRD schema can't check
+	rhmiWebhooks, err := webhooks.WebhookRegisterFor(&rhmiv1alpha1.RHMI{})
+	if err != nil {
+		return err
+	}
+	webhooks.Config.AddWebhook(webhooks.IntegreatlyWebhook{
+		Name: "rhmi-spec",
+		Rule: webhooks.NewRule().
+			OneResource("integreatly.org", "v1alpha1", "rhmis").
+			ForCreate().
+			ForUpdate().
+			NamespacedScope(),
+		Register: rhmiWebhooks,
+	})
+
+	// Validating webhook rejecting the quotas the CRD schema can't check
+	quotaWebhooks, err := webhooks.WebhookRegisterFor(&rhmiv1alpha1.Quota{})
+	if err != nil {
+		return err
+	}
+	webhooks.Config.AddWebhook(webhooks.IntegreatlyWebhook{
+		Name: "quota-spec",
+		Rule: webhooks.NewRule().
+			OneResource("integreatly.org", "v1alpha1", "quotas").
+			ForCreate().
+			ForUpdate().
+			NamespacedScope(),
+		Register: quotaWebhooks,
+	})
+
+	// Validating webhook rejecting the realm customizations the CRD schema
+	// can't check
+	realmCustomizationWebhooks, err := webhooks.WebhookRegisterFor(&rhmiv1alpha1.RealmCustomization{})
+	if err != nil {
+		return err
+	}
+	webhooks.Config.AddWebhook(webhooks.IntegreatlyWebhook{
+		Name: "realmcustomization-spec",
+		Rule: webhooks.NewRule().
+			OneResource("integreatly.org", "v1alpha1", "realmcustomizations").
+			ForCreate().
+			ForUpdate().
+			NamespacedScope(),
+		Register: realmCustomizationWebhooks,
+	})
+
+	// Conversion webhook serving the RHMI CR in v1alpha2, with conditions
+	// instead of phases, from the v1alpha1 storage version
+	webhooks.Config.AddWebhook(webhooks.IntegreatlyWebhook{
+		Name: "rhmi-conversion",
+		Register: webhooks.ConversionWebhookRegister{
+			CRDName: "rhmis.integreatly.org",
+			Path:    "/convert",
+		},
+	})
+
 	// The webhooks feature can't work when the operator runs locally, as it
 	// needs to be accessible by kubernetes and depends on the TLS certificates
 	// being mounted
@@ -273,5 +359,21 @@ func setupWebhooks(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// The admin API is served with the webhooks as it relies on the same
+	// service and certificates
+	if webhooks.Config.Enabled {
+		mgr.GetWebhookServer().Register(adminapi.Path, adminapi.NewHandler(client, watchNamespace, mgr.GetEventRecorderFor("Admin API")))
+	}
+
 	return nil
 }
+
+// newClient blocks the writes of credentials into resources that aren't
+// Secrets through the manager client
+func newClient(cache cache.Cache, config *rest.Config, options k8sclient.Options, uncachedObjects ...k8sclient.Object) (k8sclient.Client, error) {
+	c, err := cluster.DefaultNewClient(cache, config, options, uncachedObjects...)
+	if err != nil {
+		return nil, err
+	}
+	return secretscan.NewClient(c, l.NewLogger()), nil
+}
@@ -0,0 +1,367 @@
+// Package adminapi serves a small REST API on the operator webhook server so
+// external portals and automation can read the state of the installation and
+// trigger a few guarded actions without reading the CRs directly. Requests are
+// authenticated with a bearer token and authorized against the adminapi
+// subresource of the RHMI CR, so a client only needs RBAC on that subresource
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/alerthistory"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/buildinfo"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/rhmi"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Path is where the API is served on the webhook server
+const Path = "/api/v1/"
+
+// Subresource of the RHMI CR the requests are authorized against, "get" for
+// the read endpoints and "create" for the actions
+const Subresource = "adminapi"
+
+var log = l.NewLoggerWithContext(l.Fields{l.ComponentLogContext: "adminapi"})
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Installation is the state of the installation
+type Installation struct {
+	Name       string             `json:"name"`
+	Type       string             `json:"type"`
+	Stage      string             `json:"stage"`
+	Version    string             `json:"version"`
+	ToVersion  string             `json:"toVersion,omitempty"`
+	LastError  string             `json:"lastError,omitempty"`
+	Products   []Product          `json:"products"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Product is the state of a product of the installation
+type Product struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Phase   string `json:"phase"`
+	Host    string `json:"host,omitempty"`
+}
+
+// Tenant is the state of an APIManagementTenant
+type Tenant struct {
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	ProvisioningStatus string `json:"provisioningStatus"`
+	TenantURL          string `json:"tenantUrl,omitempty"`
+	LastError          string `json:"lastError,omitempty"`
+}
+
+// Quota is the installed quota, and the quota being applied if it's changing
+type Quota struct {
+	Quota   string `json:"quota"`
+	ToQuota string `json:"toQuota,omitempty"`
+}
+
+// Backup lists the snapshots created by the backup action
+type Backup struct {
+	Snapshots []string `json:"snapshots"`
+}
+
+// UpgradeApproval is the install plan approved by the approve upgrade action
+type UpgradeApproval struct {
+	InstallPlan string `json:"installPlan"`
+	CSV         string `json:"csv"`
+}
+
+type handler struct {
+	client    k8sclient.Client
+	namespace string
+	recorder  record.EventRecorder
+	now       func() time.Time
+	buildInfo *buildinfo.Cache
+}
+
+type route struct {
+	method string
+	verb   string
+	serve  func(ctx context.Context, installation *integreatlyv1alpha1.RHMI, query url.Values) (int, interface{}, error)
+}
+
+// NewHandler returns the handler of the API of the installation in namespace
+func NewHandler(client k8sclient.Client, namespace string, recorder record.EventRecorder) http.Handler {
+	return &handler{
+		client:    client,
+		namespace: namespace,
+		recorder:  recorder,
+		now:       time.Now,
+		buildInfo: buildinfo.NewCache(client, buildinfo.DefaultCacheTTL),
+	}
+}
+
+func (h *handler) routes() map[string]route {
+	return map[string]route{
+		"installation":            {method: http.MethodGet, verb: "get", serve: h.getInstallation},
+		"tenants":                 {method: http.MethodGet, verb: "get", serve: h.getTenants},
+		"quota":                   {method: http.MethodGet, verb: "get", serve: h.getQuota},
+		"versions":                {method: http.MethodGet, verb: "get", serve: h.getVersions},
+		"alerts":                  {method: http.MethodGet, verb: "get", serve: h.getAlerts},
+		"actions/backup":          {method: http.MethodPost, verb: "create", serve: h.triggerBackup},
+		"actions/approve-upgrade": {method: http.MethodPost, verb: "create", serve: h.approveUpgrade},
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route, ok := h.routes()[strings.TrimPrefix(req.URL.Path, Path)]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != route.method {
+		w.Header().Set("Allow", route.method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	installation, err := rhmi.GetRhmiCr(h.client, req.Context(), h.namespace, log)
+	if err != nil || installation == nil {
+		log.Error("failed to get installation for the admin api", err)
+		http.Error(w, "failed to get installation", http.StatusServiceUnavailable)
+		return
+	}
+
+	status, err := h.authorize(req, installation, route.verb)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	status, body, err := route.serve(req.Context(), installation, req.URL.Query())
+	if err != nil {
+		log.Error(fmt.Sprintf("admin api request %s %s failed", req.Method, req.URL.Path), err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error("failed to write admin api response", err)
+	}
+}
+
+// authorize authenticates the bearer token of the request with a TokenReview,
+// and checks the user can verb the adminapi subresource of the installation
+func (h *handler) authorize(req *http.Request, installation *integreatlyv1alpha1.RHMI, verb string) (int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return http.StatusUnauthorized, errors.New("bearer token required")
+	}
+
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := h.client.Create(req.Context(), review); err != nil {
+		log.Error("failed to review admin api token", err)
+		return http.StatusInternalServerError, errors.New("failed to authenticate")
+	}
+	if !review.Status.Authenticated {
+		return http.StatusUnauthorized, errors.New("invalid token")
+	}
+
+	user := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	access := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   installation.Namespace,
+				Verb:        verb,
+				Group:       integreatlyv1alpha1.GroupVersion.Group,
+				Resource:    "rhmis",
+				Subresource: Subresource,
+				Name:        installation.Name,
+			},
+		},
+	}
+	if err := h.client.Create(req.Context(), access); err != nil {
+		log.Error("failed to review admin api access", err)
+		return http.StatusInternalServerError, errors.New("failed to authorize")
+	}
+	if !access.Status.Allowed {
+		return http.StatusForbidden, fmt.Errorf("user %s can't %s rhmis/%s", user.Username, verb, Subresource)
+	}
+	return http.StatusOK, nil
+}
+
+func (h *handler) getInstallation(_ context.Context, installation *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	result := Installation{
+		Name:       installation.Name,
+		Type:       installation.Spec.Type,
+		Stage:      string(installation.Status.Stage),
+		Version:    installation.Status.Version,
+		ToVersion:  installation.Status.ToVersion,
+		LastError:  installation.Status.LastError,
+		Products:   []Product{},
+		Conditions: installation.Status.Conditions,
+	}
+	for _, stage := range installation.Status.Stages {
+		for _, product := range stage.Products {
+			result.Products = append(result.Products, Product{
+				Name:    string(product.Name),
+				Version: string(product.Version),
+				Phase:   string(product.Phase),
+				Host:    product.Host,
+			})
+		}
+	}
+	sort.Slice(result.Products, func(i, j int) bool {
+		return result.Products[i].Name < result.Products[j].Name
+	})
+	return http.StatusOK, result, nil
+}
+
+func (h *handler) getTenants(ctx context.Context, _ *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	tenantList := &integreatlyv1alpha1.APIManagementTenantList{}
+	if err := h.client.List(ctx, tenantList); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	tenants := []Tenant{}
+	for _, tenant := range tenantList.Items {
+		tenants = append(tenants, Tenant{
+			Name:               tenant.Name,
+			Namespace:          tenant.Namespace,
+			ProvisioningStatus: string(tenant.Status.ProvisioningStatus),
+			TenantURL:          tenant.Status.TenantUrl,
+			LastError:          tenant.Status.LastError,
+		})
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		if tenants[i].Namespace != tenants[j].Namespace {
+			return tenants[i].Namespace < tenants[j].Namespace
+		}
+		return tenants[i].Name < tenants[j].Name
+	})
+	return http.StatusOK, tenants, nil
+}
+
+func (h *handler) getQuota(_ context.Context, installation *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	return http.StatusOK, Quota{Quota: installation.Status.Quota, ToQuota: installation.Status.ToQuota}, nil
+}
+
+func (h *handler) getVersions(ctx context.Context, installation *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	info, err := h.buildInfo.Get(ctx, installation)
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to get versions: %w", err)
+	}
+	return http.StatusOK, info, nil
+}
+
+// getAlerts returns the alert history of the installation. The optional since
+// query parameter, in RFC 3339 format, limits it to later transitions
+func (h *handler) getAlerts(ctx context.Context, installation *integreatlyv1alpha1.RHMI, query url.Values) (int, interface{}, error) {
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return http.StatusBadRequest, nil, errors.New("since must be an RFC 3339 time")
+		}
+		since = parsed
+	}
+
+	entries, err := alerthistory.Get(ctx, h.client, installation.Namespace, since)
+	if err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to get alert history: %w", err)
+	}
+	return http.StatusOK, entries, nil
+}
+
+// triggerBackup creates a snapshot of every Postgres and Redis of the
+// installation. The snapshots complete asynchronously, their status is on the
+// snapshot CRs
+func (h *handler) triggerBackup(ctx context.Context, installation *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	suffix := h.now().UTC().Format("2006-01-02-150405")
+	backup := Backup{Snapshots: []string{}}
+
+	postgresList := &crov1alpha1.PostgresList{}
+	if err := h.client.List(ctx, postgresList, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to list postgres instances: %w", err)
+	}
+	for _, postgres := range postgresList.Items {
+		snapshot := &crov1alpha1.PostgresSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-adminapi-snapshot-%s", postgres.Name, suffix), Namespace: installation.Namespace},
+			Spec:       crov1alpha1.PostgresSnapshotSpec{ResourceName: postgres.Name},
+		}
+		if err := h.client.Create(ctx, snapshot); err != nil {
+			return http.StatusInternalServerError, nil, fmt.Errorf("failed to create snapshot of postgres %s: %w", postgres.Name, err)
+		}
+		backup.Snapshots = append(backup.Snapshots, snapshot.Name)
+	}
+
+	redisList := &crov1alpha1.RedisList{}
+	if err := h.client.List(ctx, redisList, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to list redis instances: %w", err)
+	}
+	for _, redis := range redisList.Items {
+		snapshot := &crov1alpha1.RedisSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-adminapi-snapshot-%s", redis.Name, suffix), Namespace: installation.Namespace},
+			Spec:       crov1alpha1.RedisSnapshotSpec{ResourceName: redis.Name},
+		}
+		if err := h.client.Create(ctx, snapshot); err != nil {
+			return http.StatusInternalServerError, nil, fmt.Errorf("failed to create snapshot of redis %s: %w", redis.Name, err)
+		}
+		backup.Snapshots = append(backup.Snapshots, snapshot.Name)
+	}
+
+	log.Infof("Triggered backup through the admin api", l.Fields{"snapshots": backup.Snapshots})
+	return http.StatusAccepted, backup, nil
+}
+
+// approveUpgrade approves the pending install plan of the operator
+// subscription, the same way service affecting upgrades are approved by hand
+func (h *handler) approveUpgrade(ctx context.Context, installation *integreatlyv1alpha1.RHMI, _ url.Values) (int, interface{}, error) {
+	subscriptions := &operatorsv1alpha1.SubscriptionList{}
+	if err := h.client.List(ctx, subscriptions, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return http.StatusInternalServerError, nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions.Items {
+		if subscription.Status.CurrentCSV == subscription.Status.InstalledCSV || subscription.Status.InstallPlanRef == nil {
+			continue
+		}
+		installPlan := &operatorsv1alpha1.InstallPlan{}
+		if err := h.client.Get(ctx, k8sclient.ObjectKey{Name: subscription.Status.InstallPlanRef.Name, Namespace: subscription.Status.InstallPlanRef.Namespace}, installPlan); err != nil {
+			return http.StatusInternalServerError, nil, fmt.Errorf("failed to get install plan: %w", err)
+		}
+		if installPlan.Spec.Approved || len(installPlan.Spec.ClusterServiceVersionNames) == 0 {
+			continue
+		}
+
+		installPlan.Spec.Approved = true
+		if err := h.client.Update(ctx, installPlan); err != nil {
+			return http.StatusInternalServerError, nil, fmt.Errorf("failed to approve install plan: %w", err)
+		}
+		h.recorder.Eventf(installPlan, "Normal", integreatlyv1alpha1.EventUpgradeApproved,
+			"Approving %s install plan through the admin api: %s", installPlan.Name, installPlan.Spec.ClusterServiceVersionNames[0])
+		return http.StatusOK, UpgradeApproval{InstallPlan: installPlan.Name, CSV: installPlan.Spec.ClusterServiceVersionNames[0]}, nil
+	}
+
+	return http.StatusConflict, nil, errors.New("no upgrade waiting for approval")
+}
@@ -0,0 +1,302 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/alerthistory"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/buildinfo"
+	"github.com/integr8ly/integreatly-operator/utils"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reviewClient answers the token and access reviews, tokens are the user
+// names and the users are allowed the verbs in allowed
+type reviewClient struct {
+	k8sclient.Client
+	allowed map[string][]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj k8sclient.Object, opts ...k8sclient.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		_, ok := c.allowed[review.Spec.Token]
+		review.Status.Authenticated = ok
+		review.Status.User.Username = review.Spec.Token
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attributes := review.Spec.ResourceAttributes
+		if attributes.Resource != "rhmis" || attributes.Subresource != Subresource {
+			return nil
+		}
+		for _, verb := range c.allowed[review.Spec.User] {
+			review.Status.Allowed = review.Status.Allowed || verb == attributes.Verb
+		}
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestHandler(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+		Spec:       integreatlyv1alpha1.RHMISpec{Type: string(integreatlyv1alpha1.InstallationTypeManagedApi)},
+		Status: integreatlyv1alpha1.RHMIStatus{
+			Stage:   integreatlyv1alpha1.CompleteStage,
+			Version: "1.30.0",
+			Quota:   "1 Million",
+			ToQuota: "5 Million",
+			Stages: map[integreatlyv1alpha1.StageName]integreatlyv1alpha1.RHMIStageStatus{
+				integreatlyv1alpha1.InstallStage: {
+					Name: integreatlyv1alpha1.InstallStage,
+					Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+						integreatlyv1alpha1.ProductRHSSOUser: {Name: integreatlyv1alpha1.ProductRHSSOUser, Version: "7.6", Phase: integreatlyv1alpha1.PhaseCompleted},
+						integreatlyv1alpha1.Product3Scale:    {Name: integreatlyv1alpha1.Product3Scale, Version: "2.13", Phase: integreatlyv1alpha1.PhaseCompleted},
+					},
+				},
+			},
+		},
+	}
+	objects := func() []runtime.Object {
+		return []runtime.Object{
+			installation.DeepCopy(),
+			&integreatlyv1alpha1.APIManagementTenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "bob-dev"},
+				Status:     integreatlyv1alpha1.APIManagementTenantStatus{ProvisioningStatus: "3scale account ready", TenantUrl: "https://bob.example.com"},
+			},
+			&integreatlyv1alpha1.APIManagementTenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "alice-dev"},
+			},
+			&crov1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "threescale-postgres-rhoam", Namespace: installation.Namespace}},
+			&crov1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: "threescale-backend-redis-rhoam", Namespace: installation.Namespace}},
+			&operatorsv1alpha1.Subscription{
+				ObjectMeta: metav1.ObjectMeta{Name: "addon-managed-api-service", Namespace: installation.Namespace},
+				Status: operatorsv1alpha1.SubscriptionStatus{
+					CurrentCSV:     "managed-api-service.v1.31.0",
+					InstalledCSV:   "managed-api-service.v1.30.0",
+					InstallPlanRef: &corev1.ObjectReference{Name: "install-abcde", Namespace: installation.Namespace},
+				},
+			},
+			&operatorsv1alpha1.InstallPlan{
+				ObjectMeta: metav1.ObjectMeta{Name: "install-abcde", Namespace: installation.Namespace},
+				Spec:       operatorsv1alpha1.InstallPlanSpec{ClusterServiceVersionNames: []string{"managed-api-service.v1.31.0"}},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: alerthistory.ConfigMapName, Namespace: installation.Namespace},
+				Data: map[string]string{"history": `[
+					{"alert": "ThreeScaleApicastDown", "state": "firing", "time": "2023-01-01T00:00:00Z", "labels": {}},
+					{"alert": "ThreeScaleApicastDown", "state": "resolved", "time": "2023-01-02T00:00:00Z", "labels": {}}
+				]`},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		want       interface{}
+		verify     func(t *testing.T, client k8sclient.Client)
+	}{
+		{
+			name:       "missing token",
+			method:     http.MethodGet,
+			path:       "installation",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown token",
+			method:     http.MethodGet,
+			path:       "installation",
+			token:      "mallory",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "reader can't run actions",
+			method:     http.MethodPost,
+			path:       "actions/backup",
+			token:      "reader",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "secrets",
+			token:      "reader",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodPost,
+			path:       "installation",
+			token:      "admin",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "installation",
+			method:     http.MethodGet,
+			path:       "installation",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			want: &Installation{
+				Name:    "rhoam",
+				Type:    string(integreatlyv1alpha1.InstallationTypeManagedApi),
+				Stage:   string(integreatlyv1alpha1.CompleteStage),
+				Version: "1.30.0",
+				Products: []Product{
+					{Name: string(integreatlyv1alpha1.Product3Scale), Version: "2.13", Phase: string(integreatlyv1alpha1.PhaseCompleted)},
+					{Name: string(integreatlyv1alpha1.ProductRHSSOUser), Version: "7.6", Phase: string(integreatlyv1alpha1.PhaseCompleted)},
+				},
+			},
+		},
+		{
+			name:       "tenants",
+			method:     http.MethodGet,
+			path:       "tenants",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			want: &[]Tenant{
+				{Name: "tenant", Namespace: "alice-dev"},
+				{Name: "tenant", Namespace: "bob-dev", ProvisioningStatus: "3scale account ready", TenantURL: "https://bob.example.com"},
+			},
+		},
+		{
+			name:       "quota",
+			method:     http.MethodGet,
+			path:       "quota",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			want:       &Quota{Quota: "1 Million", ToQuota: "5 Million"},
+		},
+		{
+			name:       "alerts",
+			method:     http.MethodGet,
+			path:       "alerts",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			want: &[]alerthistory.Entry{
+				{Alert: "ThreeScaleApicastDown", State: alerthistory.StateFiring, Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Labels: map[string]string{}},
+				{Alert: "ThreeScaleApicastDown", State: alerthistory.StateResolved, Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Labels: map[string]string{}},
+			},
+		},
+		{
+			name:       "alerts since",
+			method:     http.MethodGet,
+			path:       "alerts?since=2023-01-01T12:00:00Z",
+			token:      "reader",
+			wantStatus: http.StatusOK,
+			want: &[]alerthistory.Entry{
+				{Alert: "ThreeScaleApicastDown", State: alerthistory.StateResolved, Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Labels: map[string]string{}},
+			},
+		},
+		{
+			name:       "alerts invalid since",
+			method:     http.MethodGet,
+			path:       "alerts?since=yesterday",
+			token:      "reader",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "alerts require a token",
+			method:     http.MethodGet,
+			path:       "alerts",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "backup",
+			method:     http.MethodPost,
+			path:       "actions/backup",
+			token:      "admin",
+			wantStatus: http.StatusAccepted,
+			want: &Backup{Snapshots: []string{
+				"threescale-postgres-rhoam-adminapi-snapshot-2023-01-02-030405",
+				"threescale-backend-redis-rhoam-adminapi-snapshot-2023-01-02-030405",
+			}},
+			verify: func(t *testing.T, client k8sclient.Client) {
+				snapshot := &crov1alpha1.PostgresSnapshot{}
+				if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: "threescale-postgres-rhoam-adminapi-snapshot-2023-01-02-030405", Namespace: installation.Namespace}, snapshot); err != nil {
+					t.Fatal(err)
+				}
+				if snapshot.Spec.ResourceName != "threescale-postgres-rhoam" {
+					t.Errorf("unexpected snapshot resource %s", snapshot.Spec.ResourceName)
+				}
+			},
+		},
+		{
+			name:       "approve upgrade",
+			method:     http.MethodPost,
+			path:       "actions/approve-upgrade",
+			token:      "admin",
+			wantStatus: http.StatusOK,
+			want:       &UpgradeApproval{InstallPlan: "install-abcde", CSV: "managed-api-service.v1.31.0"},
+			verify: func(t *testing.T, client k8sclient.Client) {
+				installPlan := &operatorsv1alpha1.InstallPlan{}
+				if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: "install-abcde", Namespace: installation.Namespace}, installPlan); err != nil {
+					t.Fatal(err)
+				}
+				if !installPlan.Spec.Approved {
+					t.Error("expected the install plan to be approved")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &reviewClient{
+				Client:  utils.NewTestClient(scheme, objects()...),
+				allowed: map[string][]string{"reader": {"get"}, "admin": {"get", "create"}},
+			}
+			h := &handler{
+				client:    client,
+				namespace: installation.Namespace,
+				recorder:  record.NewFakeRecorder(10),
+				now:       func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) },
+				buildInfo: buildinfo.NewCache(client, time.Minute),
+			}
+
+			req := httptest.NewRequest(tt.method, Path+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.want != nil {
+				got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface()
+				if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected %+v, got %+v", tt.want, got)
+				}
+			}
+			if tt.verify != nil {
+				tt.verify(t, client)
+			}
+		})
+	}
+}
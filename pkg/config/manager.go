@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
@@ -84,6 +85,9 @@ type Manager struct {
 	cfgmap       *corev1.ConfigMap
 	context      context.Context
 	installation *integreatlyv1alpha1.RHMI
+	// mu guards the config map, the products of a stage are reconciled
+	// concurrently
+	mu sync.RWMutex
 }
 
 func (m *Manager) ReadProduct(product integreatlyv1alpha1.ProductName) (ConfigReadable, error) {
@@ -195,6 +199,8 @@ func (m *Manager) WriteConfig(config ConfigReadable) error {
 	if err != nil {
 		return err
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	err = m.Client.Get(m.context, k8sclient.ObjectKey{Name: m.cfgmap.Name, Namespace: m.Namespace}, m.cfgmap)
 	if errors.IsNotFound(err) {
 		m.cfgmap.Data = map[string]string{string(config.GetProductName()): string(stringConfig)}
@@ -208,7 +214,9 @@ func (m *Manager) WriteConfig(config ConfigReadable) error {
 }
 
 func (m *Manager) readConfigForProduct(product integreatlyv1alpha1.ProductName) (ProductConfig, error) {
+	m.mu.RLock()
 	config := m.cfgmap.Data[string(product)]
+	m.mu.RUnlock()
 	decoder := yaml.NewDecoder(strings.NewReader(config))
 	retConfig := ProductConfig{}
 	if config == "" {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/buildinfo"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/version"
@@ -14,6 +15,7 @@ import (
 	prometheusConfig "github.com/prometheus/common/config"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
+	"time"
 )
 
 // Custom metrics
@@ -47,6 +49,49 @@ var (
 		},
 	)
 
+	// ComponentInfo has a series for the operator build and for every operand
+	// image running in the installation namespaces
+	ComponentInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rhoam_component_info",
+			Help: "RHOAM operator build and running operand images",
+		},
+		[]string{
+			"component",
+			"version",
+			"git_sha",
+			"build_date",
+			"namespace",
+			"container",
+			"image",
+			"image_digest",
+		},
+	)
+
+	// CredentialLeakBlocked counts the writes of credentials into resources
+	// that aren't Secrets blocked by the operator client
+	CredentialLeakBlocked = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rhoam_credential_leak_blocked_total",
+			Help: "Writes of credentials into resources that aren't Secrets blocked by the operator",
+		},
+		[]string{
+			"kind",
+			"pattern",
+		},
+	)
+
+	// CertificateExpiry is the expiry of the customer provided certificates
+	CertificateExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rhoam_certificate_expiry_timestamp_seconds",
+			Help: "Expiry of the customer provided certificates of the custom domain routes, as a Unix timestamp",
+		},
+		[]string{
+			"secret",
+		},
+	)
+
 	RHOAMVersion = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "rhoam_version",
@@ -169,6 +214,65 @@ var (
 			Help: "Measures if the last reconcile of the installation controller is delayed",
 		},
 	)
+
+	ProductReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "rhoam_product_reconcile_duration_seconds",
+			Help:    "Duration of the reconciles of the RHOAM products",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+		},
+		[]string{
+			"product",
+			"stage",
+		},
+	)
+
+	ProductReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rhoam_product_reconcile_errors_total",
+			Help: "Number of the failed reconciles of the RHOAM products",
+		},
+		[]string{
+			"product",
+			"stage",
+		},
+	)
+
+	// LDAPFederationUp is whether the LDAP server federated with the realm of
+	// a product accepts its bind credentials
+	LDAPFederationUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rhoam_ldap_federation_up",
+			Help: "Whether the LDAP server federated with the realm of the product accepts its bind credentials",
+		},
+		[]string{
+			"product",
+		},
+	)
+
+	// LDAPFederationLastSync is the last synchronization of the users of the
+	// LDAP server federated with the realm of a product
+	LDAPFederationLastSync = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rhoam_ldap_federation_last_sync_timestamp_seconds",
+			Help: "Last synchronization of the users of the LDAP server federated with the realm of the product, as a Unix timestamp",
+		},
+		[]string{
+			"product",
+		},
+	)
+
+	ProductPhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "rhoam_product_phase",
+			Help: "Phase of the RHOAM products, 1 for the current phase of the product",
+		},
+		[]string{
+			"product",
+			"stage",
+			"phase",
+		},
+	)
 )
 
 const (
@@ -206,6 +310,37 @@ func SetInfo(installation *integreatlyv1alpha1.RHMI) {
 	)
 }
 
+// SetComponentInfo exposes the rhoam_component_info metric for the operator
+// build and each operand image
+func SetComponentInfo(info *buildinfo.BuildInfo) {
+	ComponentInfo.Reset()
+	if info == nil {
+		return
+	}
+	ComponentInfo.With(prometheus.Labels{
+		"component":    "rhoam-operator",
+		"version":      info.Version,
+		"git_sha":      info.GitSHA,
+		"build_date":   info.BuildDate,
+		"namespace":    "",
+		"container":    "",
+		"image":        "",
+		"image_digest": "",
+	}).Set(1)
+	for _, image := range info.Components {
+		ComponentInfo.With(prometheus.Labels{
+			"component":    image.Component,
+			"version":      "",
+			"git_sha":      "",
+			"build_date":   "",
+			"namespace":    image.Namespace,
+			"container":    image.Container,
+			"image":        image.Image,
+			"image_digest": image.Digest,
+		}).Set(1)
+	}
+}
+
 // SetStatus exposes RHOAM_status metric for each stage
 func SetStatus(installation *integreatlyv1alpha1.RHMI) {
 	RHOAMStatus.Reset()
@@ -257,6 +392,57 @@ func SetNoActivated3ScaleTenantAccount(username string) {
 	NoActivated3ScaleTenantAccount.WithLabelValues(username).Set(float64(1))
 }
 
+// ObserveProductReconcile reports the duration, the error and the phase of a
+// reconcile of a product
+func ObserveProductReconcile(product integreatlyv1alpha1.ProductName, stage integreatlyv1alpha1.StageName, phase integreatlyv1alpha1.StatusPhase, duration time.Duration, err error) {
+	ProductReconcileDuration.WithLabelValues(string(product), string(stage)).Observe(duration.Seconds())
+	if err != nil {
+		ProductReconcileErrors.WithLabelValues(string(product), string(stage)).Inc()
+	}
+	SetProductPhase(product, stage, phase)
+}
+
+// SetProductPhase reports the current phase of a product
+func SetProductPhase(product integreatlyv1alpha1.ProductName, stage integreatlyv1alpha1.StageName, phase integreatlyv1alpha1.StatusPhase) {
+	ProductPhase.DeletePartialMatch(prometheus.Labels{"product": string(product), "stage": string(stage)})
+	ProductPhase.WithLabelValues(string(product), string(stage), string(phase)).Set(1)
+}
+
+// SetLDAPFederation reports the health of the LDAP federation of a product,
+// the last sync is omitted until the users are first synchronized
+func SetLDAPFederation(product integreatlyv1alpha1.ProductName, up bool, lastSync *time.Time) {
+	value := float64(0)
+	if up {
+		value = 1
+	}
+	LDAPFederationUp.WithLabelValues(string(product)).Set(value)
+	if lastSync == nil {
+		LDAPFederationLastSync.DeleteLabelValues(string(product))
+		return
+	}
+	LDAPFederationLastSync.WithLabelValues(string(product)).Set(float64(lastSync.Unix()))
+}
+
+// DeleteLDAPFederation removes the metrics of the LDAP federation of a
+// product
+func DeleteLDAPFederation(product integreatlyv1alpha1.ProductName) {
+	LDAPFederationUp.DeleteLabelValues(string(product))
+	LDAPFederationLastSync.DeleteLabelValues(string(product))
+}
+
+func IncCredentialLeakBlocked(kind, pattern string) {
+	CredentialLeakBlocked.WithLabelValues(kind, pattern).Inc()
+}
+
+// SetCertificateExpiry reports the expiry of the given certificates, keyed by
+// the name of their secret
+func SetCertificateExpiry(expiry map[string]time.Time) {
+	CertificateExpiry.Reset()
+	for secret, notAfter := range expiry {
+		CertificateExpiry.WithLabelValues(secret).Set(float64(notAfter.Unix()))
+	}
+}
+
 func SetQuota(quota string, toQuota string) {
 	Quota.Reset()
 	Quota.WithLabelValues(quota, toQuota).Set(float64(1))
@@ -2,14 +2,18 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 
 	"github.com/integr8ly/integreatly-operator/utils"
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -225,3 +229,84 @@ func Test_GetStats(t *testing.T) {
 		})
 	}
 }
+
+func TestObserveProductReconcile(t *testing.T) {
+	ProductReconcileDuration.Reset()
+	ProductReconcileErrors.Reset()
+	ProductPhase.Reset()
+
+	ObserveProductReconcile(v1alpha1.Product3Scale, v1alpha1.InstallStage, v1alpha1.PhaseInProgress, 2*time.Second, errors.New("error"))
+	ObserveProductReconcile(v1alpha1.Product3Scale, v1alpha1.InstallStage, v1alpha1.PhaseCompleted, time.Second, nil)
+
+	metric := &dto.Metric{}
+	if err := ProductReconcileDuration.WithLabelValues(string(v1alpha1.Product3Scale), string(v1alpha1.InstallStage)).(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if got := metric.GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("reconcile duration sample count = %v, want 2", got)
+	}
+	if got := metric.GetHistogram().GetSampleSum(); got != 3 {
+		t.Errorf("reconcile duration sample sum = %v, want 3", got)
+	}
+
+	metric = &dto.Metric{}
+	if err := ProductReconcileErrors.WithLabelValues(string(v1alpha1.Product3Scale), string(v1alpha1.InstallStage)).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if got := metric.GetCounter().GetValue(); got != 1 {
+		t.Errorf("reconcile errors = %v, want 1", got)
+	}
+
+	metrics := make(chan prometheus.Metric, 10)
+	ProductPhase.Collect(metrics)
+	close(metrics)
+	var phases []string
+	for m := range metrics {
+		metric := &dto.Metric{}
+		if err := m.Write(metric); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "phase" {
+				phases = append(phases, label.GetValue())
+			}
+		}
+	}
+	if want := []string{string(v1alpha1.PhaseCompleted)}; !reflect.DeepEqual(phases, want) {
+		t.Errorf("product phases = %v, want %v", phases, want)
+	}
+}
+
+func TestSetLDAPFederation(t *testing.T) {
+	LDAPFederationUp.Reset()
+	LDAPFederationLastSync.Reset()
+
+	lastSync := time.Unix(1700000000, 0)
+	SetLDAPFederation(v1alpha1.ProductRHSSOUser, true, &lastSync)
+	SetLDAPFederation(v1alpha1.ProductRHSSOUser, false, nil)
+
+	metric := &dto.Metric{}
+	if err := LDAPFederationUp.WithLabelValues(string(v1alpha1.ProductRHSSOUser)).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if got := metric.GetGauge().GetValue(); got != 0 {
+		t.Errorf("LDAP federation up = %v, want 0", got)
+	}
+	if deleted := LDAPFederationLastSync.DeleteLabelValues(string(v1alpha1.ProductRHSSOUser)); deleted {
+		t.Error("expected the last sync to be removed without sync")
+	}
+
+	SetLDAPFederation(v1alpha1.ProductRHSSOUser, true, &lastSync)
+	metric = &dto.Metric{}
+	if err := LDAPFederationLastSync.WithLabelValues(string(v1alpha1.ProductRHSSOUser)).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	if got := metric.GetGauge().GetValue(); got != 1700000000 {
+		t.Errorf("LDAP federation last sync = %v, want 1700000000", got)
+	}
+
+	DeleteLDAPFederation(v1alpha1.ProductRHSSOUser)
+	if deleted := LDAPFederationUp.DeleteLabelValues(string(v1alpha1.ProductRHSSOUser)); deleted {
+		t.Error("expected the LDAP federation metrics to be removed")
+	}
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FeatureUsage is only set when the installation opts in to telemetry, it
+// is reported to Red Hat with the other rhoam_ metrics and must only carry
+// the fields of the TelemetryContract
+var FeatureUsage = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "rhoam_feature_usage",
+		Help: "Anonymised RHOAM feature usage, 1 when the feature is enabled",
+	},
+	[]string{
+		"install_type",
+		"quota",
+		"tenants",
+		"feature",
+	},
+)
+
+// TelemetryField documents a label of the rhoam_feature_usage metric
+type TelemetryField struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TelemetryContract is the complete list of the data reported when telemetry
+// is enabled, it's published to the admins with the report
+var TelemetryContract = []TelemetryField{
+	{Name: "install_type", Description: "Installation type, such as managed-api or multitenant-managed-api"},
+	{Name: "quota", Description: "Name of the active quota tier"},
+	{Name: "tenants", Description: "Range of the number of API management tenants: 0, 1-10, 11-50, 51-100 or 100+"},
+	{Name: "feature", Description: "Name of an optional feature, the value is 1 when it's enabled and 0 otherwise"},
+}
+
+// TelemetryReport is the anonymised feature usage of an installation
+type TelemetryReport struct {
+	InstallType string          `json:"installType"`
+	Quota       string          `json:"quota"`
+	Tenants     string          `json:"tenants"`
+	Features    map[string]bool `json:"features"`
+}
+
+// GetTelemetryReport summarises the features used by installation without any
+// names, hosts or addresses
+func GetTelemetryReport(installation *integreatlyv1alpha1.RHMI, tenantCount int) *TelemetryReport {
+	spec, status := installation.Spec, installation.Status
+	return &TelemetryReport{
+		InstallType: spec.Type,
+		Quota:       status.Quota,
+		Tenants:     TenantCountRange(tenantCount),
+		Features: map[string]bool{
+			"custom_domain":              status.CustomDomain != nil && status.CustomDomain.Enabled,
+			"custom_domain_dns":          spec.CustomDomainDNS != nil,
+			"custom_domain_routes":       spec.CustomDomain != nil,
+			"custom_smtp":                status.CustomSmtp != nil && status.CustomSmtp.Enabled,
+			"smtp_provider":              spec.SMTPProvider != nil,
+			"smtp_relay":                 spec.SMTPRelay != nil,
+			"maintenance_mode":           spec.MaintenanceMode != nil && spec.MaintenanceMode.Enabled,
+			"s3_compatible_storage":      spec.ThreeScaleFileStorage != nil,
+			"user_sso_password_policy":   spec.UserSSOPasswordPolicy != nil,
+			"cloud_resources_kms_key":    spec.CloudResourcesKMSKeyARN != "",
+			"gateway_cors":               len(spec.GatewayCORSPolicies) > 0,
+			"gateway_jwt":                len(spec.GatewayJWTProviders) > 0,
+			"gateway_traffic_split":      len(spec.GatewayTrafficSplits) > 0,
+			"maintenance_window":         spec.Maintenance != nil,
+			"sso_realm_export":           spec.RealmExport != nil,
+			"application_plan_templates": len(spec.ApplicationPlanTemplates) > 0,
+			"product_overrides":          len(spec.Products) > 0,
+			"reconcile_tuning":           spec.Reconcile != nil,
+			"resource_overrides":         len(spec.ResourceOverrides) > 0,
+			"placement":                  spec.Placement != nil,
+			"quota_transition":           spec.QuotaTransition != nil,
+			"alert_receivers":            spec.Alerting != nil && len(spec.Alerting.Receivers) > 0,
+			"remote_write":               spec.Observability != nil && len(spec.Observability.RemoteWrite) > 0,
+			"log_forwarding":             spec.LogForwarding != nil,
+			"tracing":                    spec.Tracing != nil,
+			"slos":                       len(spec.SLOs) > 0,
+			"dashboard_imports":          spec.Dashboards != nil,
+			"grafana_data_sources":       len(spec.DataSources) > 0,
+			"usage_report_export":        spec.Metering != nil && spec.Metering.Export != nil,
+		},
+	}
+}
+
+// TenantCountRange buckets the number of tenants so the exact count isn't
+// reported
+func TenantCountRange(count int) string {
+	switch {
+	case count <= 0:
+		return "0"
+	case count <= 10:
+		return "1-10"
+	case count <= 50:
+		return "11-50"
+	case count <= 100:
+		return "51-100"
+	default:
+		return "100+"
+	}
+}
+
+// SetFeatureUsage exposes the rhoam_feature_usage metric for report, the
+// metric is removed when report is nil
+func SetFeatureUsage(report *TelemetryReport) {
+	FeatureUsage.Reset()
+	if report == nil {
+		return
+	}
+	for feature, enabled := range report.Features {
+		value := 0.0
+		if enabled {
+			value = 1
+		}
+		FeatureUsage.With(prometheus.Labels{
+			"install_type": report.InstallType,
+			"quota":        report.Quota,
+			"tenants":      report.Tenants,
+			"feature":      feature,
+		}).Set(value)
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTenantCountRange(t *testing.T) {
+	tests := map[int]string{0: "0", 1: "1-10", 10: "1-10", 11: "11-50", 50: "11-50", 100: "51-100", 101: "100+"}
+	for count, want := range tests {
+		if got := TenantCountRange(count); got != want {
+			t.Errorf("TenantCountRange(%d) = %s, want %s", count, got, want)
+		}
+	}
+}
+
+func TestSetFeatureUsage(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(FeatureUsage)
+
+	installation := &v1alpha1.RHMI{
+		Spec: v1alpha1.RHMISpec{
+			Type:                string(v1alpha1.InstallationTypeManagedApi),
+			RoutingSubdomain:    "apps.example.com",
+			GatewayJWTProviders: []v1alpha1.JWTProviderSpec{{Name: "orders"}},
+		},
+		Status: v1alpha1.RHMIStatus{
+			Quota:        "200",
+			CustomDomain: &v1alpha1.CustomDomainStatus{Enabled: true},
+		},
+	}
+	report := GetTelemetryReport(installation, 12)
+	if report.InstallType != string(v1alpha1.InstallationTypeManagedApi) || report.Quota != "200" || report.Tenants != "11-50" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	SetFeatureUsage(report)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 1 || len(families[0].GetMetric()) != len(report.Features) {
+		t.Fatalf("expected a series per feature, got %v", families)
+	}
+
+	var contract []string
+	for _, field := range TelemetryContract {
+		contract = append(contract, field.Name)
+	}
+	sort.Strings(contract)
+	var enabled []string
+	for _, metric := range families[0].GetMetric() {
+		// the contract shown to the admins has to list every reported label
+		var labels []string
+		feature := ""
+		for _, label := range metric.GetLabel() {
+			labels = append(labels, label.GetName())
+			if label.GetName() == "feature" {
+				feature = label.GetValue()
+			}
+		}
+		sort.Strings(labels)
+		if len(labels) != len(contract) {
+			t.Fatalf("expected the labels %v of the contract, got %v", contract, labels)
+		}
+		for i := range labels {
+			if labels[i] != contract[i] {
+				t.Fatalf("expected the labels %v of the contract, got %v", contract, labels)
+			}
+		}
+		if metric.GetGauge().GetValue() == 1 {
+			enabled = append(enabled, feature)
+		}
+	}
+	sort.Strings(enabled)
+	if len(enabled) != 2 || enabled[0] != "custom_domain" || enabled[1] != "gateway_jwt" {
+		t.Fatalf("expected only custom_domain and gateway_jwt to be enabled, got %v", enabled)
+	}
+
+	SetFeatureUsage(nil)
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(families) != 0 {
+		t.Fatalf("expected no series when telemetry is disabled, got %v", families)
+	}
+}
@@ -0,0 +1,291 @@
+package cloudresources
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	croResources "github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	configv1 "github.com/openshift/api/config/v1"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	cloudWatchExporterName  = "cloudwatch-exporter"
+	cloudWatchExporterImage = "quay.io/prometheus/cloudwatch-exporter:v0.15.5"
+	cloudWatchExporterPort  = 9106
+
+	cloudWatchExporterConfigKey = "config.yml"
+	// croCredentialsSecret holds the credentials CRO minted for itself,
+	// they're allowed to read the CloudWatch metrics
+	croCredentialsSecret = "cloud-resources-aws-credentials"
+)
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// cloudWatchMetric is a CloudWatch metric of the RDS or ElastiCache instances
+// of the installation the exporter exposes to Prometheus
+type cloudWatchMetric struct {
+	namespace  string
+	name       string
+	statistic  string
+	dimensions []string
+}
+
+var cloudWatchMetrics = []cloudWatchMetric{
+	{namespace: "AWS/RDS", name: "FreeStorageSpace", statistic: "Minimum", dimensions: []string{"DBInstanceIdentifier"}},
+	{namespace: "AWS/RDS", name: "CPUUtilization", statistic: "Average", dimensions: []string{"DBInstanceIdentifier"}},
+	{namespace: "AWS/RDS", name: "DatabaseConnections", statistic: "Maximum", dimensions: []string{"DBInstanceIdentifier"}},
+	{namespace: "AWS/RDS", name: "DiskQueueDepth", statistic: "Average", dimensions: []string{"DBInstanceIdentifier"}},
+	{namespace: "AWS/RDS", name: "ReplicaLag", statistic: "Maximum", dimensions: []string{"DBInstanceIdentifier"}},
+	{namespace: "AWS/ElastiCache", name: "DatabaseMemoryUsagePercentage", statistic: "Maximum", dimensions: []string{"CacheClusterId"}},
+	{namespace: "AWS/ElastiCache", name: "MemoryFragmentationRatio", statistic: "Average", dimensions: []string{"CacheClusterId"}},
+	{namespace: "AWS/ElastiCache", name: "CurrConnections", statistic: "Maximum", dimensions: []string{"CacheClusterId"}},
+	{namespace: "AWS/ElastiCache", name: "ReplicationLag", statistic: "Maximum", dimensions: []string{"CacheClusterId"}},
+}
+
+// reconcileCloudWatchExporter deploys the CloudWatch exporter exposing the
+// metrics of the RDS and ElastiCache instances CRO provisioned on AWS. It's
+// removed when the installation uses the cluster storage, and on STS
+// clusters as CRO has no credentials the exporter can use
+func (r *Reconciler) reconcileCloudWatchExporter(ctx context.Context, serverClient k8sclient.Client, isSTS bool) (integreatlyv1alpha1.StatusPhase, error) {
+	namespace := r.Config.GetOperatorNamespace()
+	objectMeta := metav1.ObjectMeta{Name: cloudWatchExporterName, Namespace: namespace}
+
+	platformType, err := cluster.GetPlatformType(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get the platform type: %w", err)
+	}
+	if platformType != configv1.AWSPlatformType || isSTS || strings.ToLower(r.installation.Spec.UseClusterStorage) != "false" {
+		for _, obj := range []k8sclient.Object{
+			&prometheus.ServiceMonitor{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&appsv1.Deployment{ObjectMeta: objectMeta},
+			&corev1.ConfigMap{ObjectMeta: objectMeta},
+		} {
+			if err := serverClient.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete cloudwatch exporter %T: %w", obj, err)
+			}
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	region, err := croResources.GetAWSRegion(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	clusterID, err := croResources.GetClusterID(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	exporterConfig, err := cloudWatchExporterConfig(region, clusterID)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to build cloudwatch exporter config: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, configMap, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(configMap, r.installation)
+		configMap.Data = map[string]string{cloudWatchExporterConfigKey: string(exporterConfig)}
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile cloudwatch exporter config map: %w", err)
+	}
+
+	labels := map[string]string{"app": cloudWatchExporterName}
+	credentialEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: croCredentialsSecret},
+			Key:                  key,
+		}}}
+	}
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta}
+	opRes, err := controllerutil.CreateOrUpdate(ctx, serverClient, deployment, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(deployment, r.installation)
+		deployment.Labels = labels
+		deployment.Spec.Replicas = &[]int32{1}[0]
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template.Labels = labels
+		// roll the exporter out again when its configuration changes
+		deployment.Spec.Template.Annotations = map[string]string{"integreatly.org/config-hash": fmt.Sprintf("%x", sha256.Sum256(exporterConfig))}
+		deployment.Spec.Template.Spec.PriorityClassName = r.installation.Spec.PriorityClassName
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:  cloudWatchExporterName,
+			Image: fips.Image(r.installation, cloudWatchExporterImage),
+			Env: []corev1.EnvVar{
+				credentialEnv("AWS_ACCESS_KEY_ID", "aws_access_key_id"),
+				credentialEnv("AWS_SECRET_ACCESS_KEY", "aws_secret_access_key"),
+			},
+			Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: cloudWatchExporterPort, Protocol: corev1.ProtocolTCP}},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/-/ready", Port: intstr.FromInt(cloudWatchExporterPort)}},
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/config"}},
+		}}
+		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: cloudWatchExporterName}},
+			},
+		}}
+		return podsecurity.Mutate(r.installation)(deployment, &deployment.Spec.Template)
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile cloudwatch exporter deployment: %w", err)
+	}
+	if opRes != controllerutil.OperationResultNone {
+		r.log.Infof("Operation result cloudwatch exporter", l.Fields{"result": opRes})
+	}
+
+	service := &corev1.Service{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, r.installation)
+		service.Labels = labels
+		service.Spec.Selector = labels
+		service.Spec.Ports = []corev1.ServicePort{
+			{Name: "http", Port: cloudWatchExporterPort, TargetPort: intstr.FromInt(cloudWatchExporterPort), Protocol: corev1.ProtocolTCP},
+		}
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile cloudwatch exporter service: %w", err)
+	}
+
+	serviceMonitor := &prometheus.ServiceMonitor{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, serviceMonitor, func() error {
+		serviceMonitor.Labels = map[string]string{
+			"monitoring-key": "middleware",
+		}
+		serviceMonitor.Spec = prometheus.ServiceMonitorSpec{
+			// the exporter queries CloudWatch on each scrape, its metrics
+			// are aggregated by the minute
+			Endpoints: []prometheus.Endpoint{{Port: "http", Interval: "60s", ScrapeTimeout: "50s"}},
+			Selector:  metav1.LabelSelector{MatchLabels: labels},
+		}
+		resources.SetMetricRelabelConfigs(serviceMonitor.Spec.Endpoints, r.Config.GetProductName(), r.installation.Spec.Type)
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile cloudwatch exporter service monitor: %w", err)
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// cloudWatchExporterConfig returns the configuration of the exporter. CRO
+// names the instances after the cluster ID without its non alphanumeric
+// characters, so only the instances of the cluster are selected
+func cloudWatchExporterConfig(region, clusterID string) ([]byte, error) {
+	instancesRegex := fmt.Sprintf("^%s.*", nonAlphanumeric.ReplaceAllString(clusterID, ""))
+
+	var metrics []map[string]interface{}
+	for _, metric := range cloudWatchMetrics {
+		selection := map[string][]string{}
+		for _, dimension := range metric.dimensions {
+			selection[dimension] = []string{instancesRegex}
+		}
+		metrics = append(metrics, map[string]interface{}{
+			"aws_namespace":              metric.namespace,
+			"aws_metric_name":            metric.name,
+			"aws_dimensions":             metric.dimensions,
+			"aws_dimension_select_regex": selection,
+			"aws_statistics":             []string{metric.statistic},
+		})
+	}
+	return yaml.Marshal(map[string]interface{}{
+		"region":              region,
+		"use_get_metric_data": true,
+		"metrics":             metrics,
+	})
+}
+
+// cloudWatchExporterAlerts returns the alerts on the metrics of the exporter.
+// They don't fire where the exporter isn't deployed
+func cloudWatchExporterAlerts(namespace, installationName string) resources.AlertConfiguration {
+	labels := map[string]string{"severity": "warning", "product": installationName}
+	return resources.AlertConfiguration{
+		AlertName: "cro-cloudwatch-alerts",
+		Namespace: namespace,
+		GroupName: "cloud-resource-operator-cloudwatch.rules",
+		Rules: []monv1.Rule{
+			{
+				Alert: "RHOAMPostgresDiskPressure",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The disk queue depth of the postgres instance {{ $labels.dbinstance_identifier }} has been above 20 for 15 minutes.",
+				},
+				Expr:   intstr.FromString("aws_rds_disk_queue_depth_average > 20"),
+				For:    "15m",
+				Labels: labels,
+			},
+			{
+				Alert: "RHOAMPostgresConnectionsSaturated",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The postgres instance {{ $labels.instanceID }} is using {{ $value | humanizePercentage }} of its max connections.",
+				},
+				// RDS sets max_connections to LEAST(DBInstanceClassMemory/9531392, 5000)
+				Expr: intstr.FromString(`label_replace(aws_rds_database_connections_maximum, "instanceID", "$1", "dbinstance_identifier", "(.*)")
+/ on(instanceID) group_left() clamp_max(max by (instanceID) (cro_postgres_max_memory) / 9531392, 5000) > 0.9`),
+				For:    "15m",
+				Labels: labels,
+			},
+			{
+				Alert: "RHOAMPostgresReplicaLagHigh",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The replica of the postgres instance {{ $labels.dbinstance_identifier }} has been more than 5 minutes behind for 15 minutes.",
+				},
+				Expr:   intstr.FromString("aws_rds_replica_lag_maximum > 300"),
+				For:    "15m",
+				Labels: labels,
+			},
+			{
+				Alert: "RHOAMRedisMemoryFragmentationHigh",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The memory fragmentation ratio of the redis node {{ $labels.cache_cluster_id }} has been above 1.5 for 30 minutes.",
+				},
+				Expr:   intstr.FromString("aws_elasticache_memory_fragmentation_ratio_average > 1.5"),
+				For:    "30m",
+				Labels: labels,
+			},
+			{
+				Alert: "RHOAMRedisConnectionsSaturated",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The redis node {{ $labels.cache_cluster_id }} is using more than 90% of its 65000 max clients.",
+				},
+				Expr:   intstr.FromString("aws_elasticache_curr_connections_maximum > 0.9 * 65000"),
+				For:    "15m",
+				Labels: labels,
+			},
+			{
+				Alert: "RHOAMRedisReplicationLagHigh",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The replica redis node {{ $labels.cache_cluster_id }} has been more than 30 seconds behind for 15 minutes.",
+				},
+				Expr:   intstr.FromString("aws_elasticache_replication_lag_maximum > 30"),
+				For:    "15m",
+				Labels: labels,
+			},
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package cloudresources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	configv1 "github.com/openshift/api/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileCloudWatchExporter(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const operatorNamespace = "cro-operator-test"
+	infrastructure := func(platformType configv1.PlatformType) *configv1.Infrastructure {
+		infra := &configv1.Infrastructure{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status: configv1.InfrastructureStatus{
+				InfrastructureName: "rhoam-cluster-x1y2z",
+				PlatformStatus:     &configv1.PlatformStatus{Type: platformType},
+			},
+		}
+		if platformType == configv1.AWSPlatformType {
+			infra.Status.PlatformStatus.AWS = &configv1.AWSPlatformStatus{Region: "eu-west-1"}
+		}
+		return infra
+	}
+	existingDeployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: cloudWatchExporterName, Namespace: operatorNamespace}}
+
+	tests := []struct {
+		name              string
+		client            client.Client
+		useClusterStorage string
+		isSTS             bool
+		wantDeployed      bool
+	}{
+		{
+			name:              "deploys the exporter on AWS with the cloud storage",
+			client:            utils.NewTestClient(scheme, infrastructure(configv1.AWSPlatformType)),
+			useClusterStorage: "false",
+			wantDeployed:      true,
+		},
+		{
+			name:              "removes the exporter with the cluster storage",
+			client:            utils.NewTestClient(scheme, infrastructure(configv1.AWSPlatformType), existingDeployment.DeepCopy()),
+			useClusterStorage: "true",
+		},
+		{
+			name:              "removes the exporter on STS clusters",
+			client:            utils.NewTestClient(scheme, infrastructure(configv1.AWSPlatformType), existingDeployment.DeepCopy()),
+			useClusterStorage: "false",
+			isSTS:             true,
+		},
+		{
+			name:              "doesn't deploy the exporter on GCP",
+			client:            utils.NewTestClient(scheme, infrastructure(configv1.GCPPlatformType)),
+			useClusterStorage: "false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{
+				Config: config.NewCloudResources(config.ProductConfig{"OPERATOR_NAMESPACE": operatorNamespace}),
+				installation: &integreatlyv1alpha1.RHMI{
+					ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+					Spec:       integreatlyv1alpha1.RHMISpec{Type: string(integreatlyv1alpha1.InstallationTypeManagedApi), UseClusterStorage: tt.useClusterStorage},
+				},
+				log: logger.NewLogger(),
+			}
+			phase, err := r.reconcileCloudWatchExporter(context.TODO(), tt.client, tt.isSTS)
+			if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+				t.Fatalf("reconcileCloudWatchExporter() returned %v, %v", phase, err)
+			}
+
+			deployment := &appsv1.Deployment{}
+			err = tt.client.Get(context.TODO(), client.ObjectKeyFromObject(existingDeployment), deployment)
+			if !tt.wantDeployed {
+				if !k8serr.IsNotFound(err) {
+					t.Errorf("expected no exporter deployment, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the exporter deployment, got %v", err)
+			}
+			if env := deployment.Spec.Template.Spec.Containers[0].Env; env[0].ValueFrom.SecretKeyRef.Name != croCredentialsSecret {
+				t.Errorf("expected the exporter to use the credentials of CRO, got %v", env)
+			}
+			configMap := &corev1.ConfigMap{}
+			if err := tt.client.Get(context.TODO(), client.ObjectKeyFromObject(existingDeployment), configMap); err != nil {
+				t.Fatalf("expected the exporter config map, got %v", err)
+			}
+			exporterConfig := configMap.Data[cloudWatchExporterConfigKey]
+			for _, want := range []string{"region: eu-west-1", "- ^rhoamclusterx1y2z.*", "aws_metric_name: MemoryFragmentationRatio"} {
+				if !strings.Contains(exporterConfig, want) {
+					t.Errorf("expected the exporter config to contain %q, got %s", want, exporterConfig)
+				}
+			}
+		})
+	}
+}
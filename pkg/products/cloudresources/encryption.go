@@ -0,0 +1,123 @@
+package cloudresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croUtil "github.com/integr8ly/cloud-resource-operator/pkg/client"
+	croProviders "github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// reconcileEncryptionKey sets the customer managed KMS key of the installation
+// in the RDS and ElastiCache create strategies, or removes it so the AWS
+// managed keys are used again. CRO only uses the create strategy when
+// provisioning, existing instances keep the key they were encrypted with
+func (r *Reconciler) reconcileEncryptionKey(ctx context.Context, client k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	if r.Config.GetStrategiesConfigMapName() != croAWS.DefaultConfigMapName {
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	keyARN := r.installation.Spec.CloudResourcesKMSKeyARN
+	if keyARN != "" {
+		if err := validateKMSKeyARN(keyARN); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+	}
+
+	cfgMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.Config.GetStrategiesConfigMapName(),
+			Namespace: r.installation.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, client, cfgMap, func() error {
+		if err := updateCreateStrategy(cfgMap, croProviders.PostgresResourceType, &rds.CreateDBInstanceInput{}, func(input interface{}) {
+			rdsInput := input.(*rds.CreateDBInstanceInput)
+			rdsInput.KmsKeyId = nil
+			if keyARN != "" {
+				rdsInput.KmsKeyId = aws.String(keyARN)
+				rdsInput.StorageEncrypted = aws.Bool(true)
+			}
+		}); err != nil {
+			return err
+		}
+		return updateCreateStrategy(cfgMap, croProviders.RedisResourceType, &elasticache.CreateReplicationGroupInput{}, func(input interface{}) {
+			elasticacheInput := input.(*elasticache.CreateReplicationGroupInput)
+			elasticacheInput.KmsKeyId = nil
+			if keyARN != "" {
+				elasticacheInput.KmsKeyId = aws.String(keyARN)
+				elasticacheInput.AtRestEncryptionEnabled = aws.Bool(true)
+			}
+		})
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile kms key in cloud resources strategies: %w", err)
+	}
+	if op == controllerutil.OperationResultUpdated {
+		r.log.Infof("Updated cloud resources encryption key", l.Fields{"kmsKeyARN": keyARN})
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// validateKMSKeyARN checks the ARN refers to a KMS key or alias, RDS and
+// ElastiCache also accept bare key IDs but those are ambiguous across accounts
+func validateKMSKeyARN(keyARN string) error {
+	parsed, err := arn.Parse(keyARN)
+	if err != nil {
+		return fmt.Errorf("invalid kms key arn %q: %w", keyARN, err)
+	}
+	if parsed.Service != "kms" || parsed.Region == "" || parsed.AccountID == "" {
+		return fmt.Errorf("invalid kms key arn %q: expected arn:<partition>:kms:<region>:<account>:key/<id>", keyARN)
+	}
+	if !strings.HasPrefix(parsed.Resource, "key/") && !strings.HasPrefix(parsed.Resource, "alias/") {
+		return fmt.Errorf("invalid kms key arn %q: resource must be a key or an alias", keyARN)
+	}
+	return nil
+}
+
+// updateCreateStrategy decodes the production create strategy of the resource
+// type into input, applies update to it and encodes it back
+func updateCreateStrategy(cfgMap *corev1.ConfigMap, resourceType croProviders.ResourceType, input interface{}, update func(input interface{})) error {
+	var rawStrategy map[string]*croAWS.StrategyConfig
+	if err := json.Unmarshal([]byte(cfgMap.Data[string(resourceType)]), &rawStrategy); err != nil {
+		return fmt.Errorf("failed to unmarshal strategy mapping for resource type %s: %w", resourceType, err)
+	}
+	strategy, ok := rawStrategy[croUtil.TierProduction]
+	if !ok || strategy == nil {
+		return fmt.Errorf("no %s strategy for resource type %s", croUtil.TierProduction, resourceType)
+	}
+
+	if len(strategy.CreateStrategy) > 0 {
+		if err := json.Unmarshal(strategy.CreateStrategy, input); err != nil {
+			return fmt.Errorf("failed to unmarshal %s create strategy: %w", resourceType, err)
+		}
+	}
+	update(input)
+
+	createStrategy, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	strategy.CreateStrategy = createStrategy
+
+	marshalledStrategy, err := json.Marshal(rawStrategy)
+	if err != nil {
+		return err
+	}
+	cfgMap.Data[string(resourceType)] = string(marshalledStrategy)
+	return nil
+}
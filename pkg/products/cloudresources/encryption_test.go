@@ -0,0 +1,156 @@
+package cloudresources
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileEncryptionKey(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		testNamespace = "test-namespace"
+		keyARN        = "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	)
+
+	strategies := func(postgresCreate, redisCreate string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: croAWS.DefaultConfigMapName, Namespace: testNamespace},
+			Data: map[string]string{
+				"postgres": `{"production": {"region": "", "createStrategy": ` + postgresCreate + `, "deleteStrategy": {}}}`,
+				"redis":    `{"production": {"region": "", "createStrategy": ` + redisCreate + `, "deleteStrategy": {}}}`,
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		keyARN         string
+		strategyName   string
+		cfgMap         *corev1.ConfigMap
+		want           integreatlyv1alpha1.StatusPhase
+		wantErr        bool
+		wantKey        string
+		wantBackupTime string
+	}{
+		{
+			name:         "test nothing is done outside of aws",
+			keyARN:       "invalid",
+			strategyName: "cloud-resources-gcp-strategies",
+			want:         integreatlyv1alpha1.PhaseCompleted,
+		},
+		{
+			name:         "test invalid key arn fails",
+			keyARN:       "arn:aws:s3:::bucket",
+			strategyName: croAWS.DefaultConfigMapName,
+			cfgMap:       strategies(`{}`, `{}`),
+			want:         integreatlyv1alpha1.PhaseFailed,
+			wantErr:      true,
+		},
+		{
+			name:           "test key is set in the create strategies",
+			keyARN:         keyARN,
+			strategyName:   croAWS.DefaultConfigMapName,
+			cfgMap:         strategies(`{"PreferredBackupWindow": "03:01-04:01"}`, `{}`),
+			want:           integreatlyv1alpha1.PhaseCompleted,
+			wantKey:        keyARN,
+			wantBackupTime: "03:01-04:01",
+		},
+		{
+			name:         "test key is removed from the create strategies",
+			strategyName: croAWS.DefaultConfigMapName,
+			cfgMap:       strategies(`{"KmsKeyId": "`+keyARN+`"}`, `{"KmsKeyId": "`+keyARN+`"}`),
+			want:         integreatlyv1alpha1.PhaseCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var serverClient client.Client
+			if tt.cfgMap != nil {
+				serverClient = utils.NewTestClient(scheme, tt.cfgMap)
+			} else {
+				serverClient = utils.NewTestClient(scheme)
+			}
+			r := &Reconciler{
+				Config: config.NewCloudResources(config.ProductConfig{
+					"NAMESPACE": testNamespace,
+				}),
+				installation: &integreatlyv1alpha1.RHMI{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace},
+					Spec:       integreatlyv1alpha1.RHMISpec{CloudResourcesKMSKeyARN: tt.keyARN},
+				},
+				log: getLogger(),
+			}
+			r.Config.SetStrategiesConfigMapName(tt.strategyName)
+
+			got, err := r.reconcileEncryptionKey(context.TODO(), serverClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileEncryptionKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("reconcileEncryptionKey() got = %v, want %v", got, tt.want)
+			}
+			if tt.cfgMap == nil || tt.wantErr {
+				return
+			}
+
+			cfgMap := &corev1.ConfigMap{}
+			if err := serverClient.Get(context.TODO(), client.ObjectKeyFromObject(tt.cfgMap), cfgMap); err != nil {
+				t.Fatal(err)
+			}
+
+			rdsInput := &rds.CreateDBInstanceInput{}
+			createStrategy(t, cfgMap, "postgres", rdsInput)
+			if got := stringValue(rdsInput.KmsKeyId); got != tt.wantKey {
+				t.Errorf("expected postgres kms key %q, got %q", tt.wantKey, got)
+			}
+			if got := stringValue(rdsInput.PreferredBackupWindow); got != tt.wantBackupTime {
+				t.Errorf("expected postgres backup window %q to be kept, got %q", tt.wantBackupTime, got)
+			}
+			if tt.wantKey != "" && (rdsInput.StorageEncrypted == nil || !*rdsInput.StorageEncrypted) {
+				t.Error("expected postgres storage to be encrypted")
+			}
+
+			elasticacheInput := &elasticache.CreateReplicationGroupInput{}
+			createStrategy(t, cfgMap, "redis", elasticacheInput)
+			if got := stringValue(elasticacheInput.KmsKeyId); got != tt.wantKey {
+				t.Errorf("expected redis kms key %q, got %q", tt.wantKey, got)
+			}
+			if tt.wantKey != "" && (elasticacheInput.AtRestEncryptionEnabled == nil || !*elasticacheInput.AtRestEncryptionEnabled) {
+				t.Error("expected redis at rest encryption to be enabled")
+			}
+		})
+	}
+}
+
+func createStrategy(t *testing.T, cfgMap *corev1.ConfigMap, resourceType string, input interface{}) {
+	var rawStrategy map[string]*croAWS.StrategyConfig
+	if err := json.Unmarshal([]byte(cfgMap.Data[resourceType]), &rawStrategy); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(rawStrategy["production"].CreateStrategy, input); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
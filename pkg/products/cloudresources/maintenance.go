@@ -0,0 +1,136 @@
+package cloudresources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croProviders "github.com/integr8ly/cloud-resource-operator/pkg/providers"
+	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// backupHour and backupMinute start the daily backup window, they're the
+	// values passed to CRO by reconcileCloudResourceStrategies
+	backupHour   = 3
+	backupMinute = 1
+
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseMaintenanceApplyFrom parses a "<ddd> hh:mm" start time
+func parseMaintenanceApplyFrom(applyFrom string) (time.Weekday, int, int, error) {
+	dayString, timeString, found := strings.Cut(strings.TrimSpace(applyFrom), " ")
+	day, ok := weekdays[strings.ToLower(dayString)]
+	if !found || !ok {
+		return 0, 0, 0, fmt.Errorf("invalid maintenance start %q, expected <ddd> hh:mm", applyFrom)
+	}
+	parsed, err := time.Parse("15:04", timeString)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid maintenance start %q, expected <ddd> hh:mm: %w", applyFrom, err)
+	}
+	return day, parsed.Hour(), parsed.Minute(), nil
+}
+
+// getMaintenanceDuration returns the duration of the window, which has to be
+// accepted by both RDS and ElastiCache
+func getMaintenanceDuration(maintenance *integreatlyv1alpha1.MaintenanceWindowSpec) (time.Duration, error) {
+	if maintenance.Duration == nil {
+		return time.Hour, nil
+	}
+	duration := maintenance.Duration.Duration
+	if duration < time.Hour || duration > 24*time.Hour || duration%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid maintenance duration %s, expected whole minutes between 1h and 24h", duration)
+	}
+	return duration, nil
+}
+
+// buildAWSMaintenanceWindow returns the ddd:hh:mm-ddd:hh:mm window, failing
+// when it overlaps the daily backup window as AWS rejects it
+func buildAWSMaintenanceWindow(day time.Weekday, hour, minute int, duration time.Duration) (string, error) {
+	start := int(day)*minutesPerDay + hour*60 + minute
+	end := start + int(duration/time.Minute)
+
+	// the window can run into the following week, so the backups of the first
+	// day of the next week are checked too
+	for backupDay := 0; backupDay <= 7; backupDay++ {
+		backupStart := backupDay*minutesPerDay + backupHour*60 + backupMinute
+		backupEnd := backupStart + 60
+		if start <= backupEnd && end >= backupStart {
+			return "", fmt.Errorf("maintenance window overlaps the daily backup window %02d:%02d-%02d:%02d UTC", backupHour, backupMinute, backupHour+1, backupMinute)
+		}
+	}
+
+	format := func(minutes int) string {
+		minutes %= minutesPerWeek
+		weekday := time.Weekday(minutes / minutesPerDay)
+		return fmt.Sprintf("%s:%02d:%02d", strings.ToLower(weekday.String()[:3]), minutes%minutesPerDay/60, minutes%60)
+	}
+	return fmt.Sprintf("%s-%s", format(start), format(end)), nil
+}
+
+// reconcileAWSMaintenanceWindow sets the maintenance window of the RHMI spec
+// in the RDS and ElastiCache create strategies, CRO applies changes to the
+// window to the existing instances
+func (r *Reconciler) reconcileAWSMaintenanceWindow(ctx context.Context, client k8sclient.Client, maintenanceWindow string) error {
+	backupWindow := fmt.Sprintf("%02d:%02d-%02d:%02d", backupHour, backupMinute, backupHour+1, backupMinute)
+
+	cfgMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      croAWS.DefaultConfigMapName,
+			Namespace: r.installation.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, client, cfgMap, func() error {
+		defaults := croAWS.BuildDefaultConfigMap(cfgMap.Name, cfgMap.Namespace).Data
+		if cfgMap.Data == nil {
+			cfgMap.Data = defaults
+		}
+		for _, resourceType := range []croProviders.ResourceType{croProviders.PostgresResourceType, croProviders.RedisResourceType} {
+			if _, ok := cfgMap.Data[string(resourceType)]; !ok {
+				cfgMap.Data[string(resourceType)] = defaults[string(resourceType)]
+			}
+		}
+
+		if err := updateCreateStrategy(cfgMap, croProviders.PostgresResourceType, &rds.CreateDBInstanceInput{}, func(input interface{}) {
+			rdsInput := input.(*rds.CreateDBInstanceInput)
+			rdsInput.PreferredBackupWindow = aws.String(backupWindow)
+			rdsInput.PreferredMaintenanceWindow = aws.String(maintenanceWindow)
+		}); err != nil {
+			return err
+		}
+		return updateCreateStrategy(cfgMap, croProviders.RedisResourceType, &elasticache.CreateReplicationGroupInput{}, func(input interface{}) {
+			elasticacheInput := input.(*elasticache.CreateReplicationGroupInput)
+			elasticacheInput.SnapshotWindow = aws.String(backupWindow)
+			elasticacheInput.PreferredMaintenanceWindow = aws.String(maintenanceWindow)
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile maintenance window in cloud resources strategies: %w", err)
+	}
+	if op == controllerutil.OperationResultUpdated {
+		r.log.Infof("Updated cloud resources maintenance window", l.Fields{"maintenanceWindow": maintenanceWindow})
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package cloudresources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/rds"
+	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBuildAWSMaintenanceWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		applyFrom string
+		duration  *metav1.Duration
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "test default duration is 1h",
+			applyFrom: "thu 02:00",
+			want:      "thu:02:00-thu:03:00",
+		},
+		{
+			name:      "test day is case insensitive",
+			applyFrom: "Mon 10:30",
+			duration:  &metav1.Duration{Duration: 3 * time.Hour},
+			want:      "mon:10:30-mon:13:30",
+		},
+		{
+			name:      "test window wraps into the following week",
+			applyFrom: "sat 23:30",
+			duration:  &metav1.Duration{Duration: 2 * time.Hour},
+			want:      "sat:23:30-sun:01:30",
+		},
+		{
+			name:      "test window overlapping the backup window fails",
+			applyFrom: "wed 02:30",
+			duration:  &metav1.Duration{Duration: time.Hour},
+			wantErr:   true,
+		},
+		{
+			name:      "test window overlapping the backup window of the next day fails",
+			applyFrom: "sun 04:30",
+			duration:  &metav1.Duration{Duration: 24 * time.Hour},
+			wantErr:   true,
+		},
+		{
+			name:      "test duration under 1h fails",
+			applyFrom: "sun 12:00",
+			duration:  &metav1.Duration{Duration: 30 * time.Minute},
+			wantErr:   true,
+		},
+		{
+			name:      "test duration over 24h fails",
+			applyFrom: "sun 12:00",
+			duration:  &metav1.Duration{Duration: 25 * time.Hour},
+			wantErr:   true,
+		},
+		{
+			name:      "test invalid day fails",
+			applyFrom: "someday 12:00",
+			wantErr:   true,
+		},
+		{
+			name:      "test invalid time fails",
+			applyFrom: "sun 24:00",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maintenance := &integreatlyv1alpha1.MaintenanceWindowSpec{ApplyFrom: tt.applyFrom, Duration: tt.duration}
+
+			day, hour, minute, err := parseMaintenanceApplyFrom(maintenance.ApplyFrom)
+			var duration time.Duration
+			if err == nil {
+				duration, err = getMaintenanceDuration(maintenance)
+			}
+			var got string
+			if err == nil {
+				got, err = buildAWSMaintenanceWindow(day, hour, minute, duration)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildAWSMaintenanceWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildAWSMaintenanceWindow() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconciler_reconcileAWSMaintenanceWindow(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		testNamespace     = "test-namespace"
+		maintenanceWindow = "sat:23:30-sun:01:30"
+	)
+
+	tests := []struct {
+		name    string
+		cfgMap  *corev1.ConfigMap
+		wantKey string
+	}{
+		{
+			name: "test default strategies are created with the window",
+		},
+		{
+			name: "test window is set in the existing strategies",
+			cfgMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: croAWS.DefaultConfigMapName, Namespace: testNamespace},
+				Data: map[string]string{
+					"postgres": `{"production": {"region": "", "createStrategy": {"KmsKeyId": "key", "PreferredMaintenanceWindow": "thu:02:00-thu:03:00"}, "deleteStrategy": {}}}`,
+					"redis":    `{"production": {"region": "", "createStrategy": {"KmsKeyId": "key"}, "deleteStrategy": {}}}`,
+				},
+			},
+			wantKey: "key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var serverClient client.Client
+			if tt.cfgMap != nil {
+				serverClient = utils.NewTestClient(scheme, tt.cfgMap)
+			} else {
+				serverClient = utils.NewTestClient(scheme)
+			}
+			r := &Reconciler{
+				installation: &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace}},
+				log:          getLogger(),
+			}
+
+			if err := r.reconcileAWSMaintenanceWindow(context.TODO(), serverClient, maintenanceWindow); err != nil {
+				t.Fatal(err)
+			}
+
+			cfgMap := &corev1.ConfigMap{}
+			if err := serverClient.Get(context.TODO(), client.ObjectKey{Name: croAWS.DefaultConfigMapName, Namespace: testNamespace}, cfgMap); err != nil {
+				t.Fatal(err)
+			}
+
+			rdsInput := &rds.CreateDBInstanceInput{}
+			createStrategy(t, cfgMap, "postgres", rdsInput)
+			if got := stringValue(rdsInput.PreferredMaintenanceWindow); got != maintenanceWindow {
+				t.Errorf("expected postgres maintenance window %q, got %q", maintenanceWindow, got)
+			}
+			if got := stringValue(rdsInput.PreferredBackupWindow); got != "03:01-04:01" {
+				t.Errorf("expected postgres backup window 03:01-04:01, got %q", got)
+			}
+			if got := stringValue(rdsInput.KmsKeyId); got != tt.wantKey {
+				t.Errorf("expected postgres kms key %q to be kept, got %q", tt.wantKey, got)
+			}
+
+			elasticacheInput := &elasticache.CreateReplicationGroupInput{}
+			createStrategy(t, cfgMap, "redis", elasticacheInput)
+			if got := stringValue(elasticacheInput.PreferredMaintenanceWindow); got != maintenanceWindow {
+				t.Errorf("expected redis maintenance window %q, got %q", maintenanceWindow, got)
+			}
+			if got := stringValue(elasticacheInput.SnapshotWindow); got != "03:01-04:01" {
+				t.Errorf("expected redis snapshot window 03:01-04:01, got %q", got)
+			}
+		})
+	}
+}
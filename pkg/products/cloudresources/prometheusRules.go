@@ -85,6 +85,7 @@ func (r *Reconciler) newAlertsReconciler(ctx context.Context, client k8sclient.C
 					},
 				},
 			},
+			cloudWatchExporterAlerts(namespace, installationName),
 		},
 	}
 
@@ -0,0 +1,109 @@
+package cloudresources
+
+import (
+	"context"
+	"fmt"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croTypes "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// provisioningMilestoneAnnotation records the last provisioning milestone
+	// an event was emitted for on the CRO resources, so each milestone is
+	// only reported once
+	provisioningMilestoneAnnotation = "integreatly.org/provisioning-milestone"
+
+	milestoneCreated = "created"
+	milestoneReady   = "ready"
+	milestoneFailed  = "failed"
+)
+
+// provisionedResource is a CRO resource of the installation and its status
+type provisionedResource struct {
+	kind   string
+	object k8sclient.Object
+	status croTypes.ResourceTypeStatus
+}
+
+// reconcileProvisioningEvents emits an event on the installation when a CRO
+// resource of the installation is created, becomes ready or fails to
+// provision
+func (r *Reconciler) reconcileProvisioningEvents(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI) error {
+	var provisioned []provisionedResource
+
+	postgresInstances := &crov1alpha1.PostgresList{}
+	if err := client.List(ctx, postgresInstances, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return fmt.Errorf("failed to list postgres instances: %w", err)
+	}
+	for i := range postgresInstances.Items {
+		postgres := &postgresInstances.Items[i]
+		provisioned = append(provisioned, provisionedResource{kind: "Postgres", object: postgres, status: postgres.Status})
+	}
+
+	redisInstances := &crov1alpha1.RedisList{}
+	if err := client.List(ctx, redisInstances, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return fmt.Errorf("failed to list redis instances: %w", err)
+	}
+	for i := range redisInstances.Items {
+		redis := &redisInstances.Items[i]
+		provisioned = append(provisioned, provisionedResource{kind: "Redis", object: redis, status: redis.Status})
+	}
+
+	blobStorages := &crov1alpha1.BlobStorageList{}
+	if err := client.List(ctx, blobStorages, k8sclient.InNamespace(installation.Namespace)); err != nil {
+		return fmt.Errorf("failed to list blobStorage instances: %w", err)
+	}
+	for i := range blobStorages.Items {
+		blobStorage := &blobStorages.Items[i]
+		provisioned = append(provisioned, provisionedResource{kind: "BlobStorage", object: blobStorage, status: blobStorage.Status})
+	}
+
+	for _, resource := range provisioned {
+		if err := r.emitProvisioningEvent(ctx, client, installation, resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) emitProvisioningEvent(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI, resource provisionedResource) error {
+	milestone := milestoneCreated
+	switch resource.status.Phase {
+	case croTypes.PhaseComplete:
+		milestone = milestoneReady
+	case croTypes.PhaseFailed:
+		milestone = milestoneFailed
+	case croTypes.PhaseDeleteInProgress:
+		return nil
+	}
+
+	object := resource.object
+	if object.GetAnnotations()[provisioningMilestoneAnnotation] == milestone {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s %s/%s", resource.kind, object.GetNamespace(), object.GetName())
+	switch milestone {
+	case milestoneCreated:
+		r.recorder.Event(installation, "Normal", integreatlyv1alpha1.EventCloudResourceCreated, fmt.Sprintf("%s was created and is being provisioned", name))
+	case milestoneReady:
+		r.recorder.Event(installation, "Normal", integreatlyv1alpha1.EventCloudResourceReady, fmt.Sprintf("%s was provisioned by the %s %s strategy", name, resource.status.Provider, resource.status.Strategy))
+	case milestoneFailed:
+		r.recorder.Event(installation, "Warning", integreatlyv1alpha1.EventCloudResourceFailed, fmt.Sprintf("%s failed to provision: %s", name, resource.status.Message))
+	}
+
+	patch := k8sclient.MergeFrom(object.DeepCopyObject().(k8sclient.Object))
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[provisioningMilestoneAnnotation] = milestone
+	object.SetAnnotations(annotations)
+	if err := client.Patch(ctx, object, patch); err != nil {
+		return fmt.Errorf("failed to record the provisioning milestone of %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cloudresources
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croTypes "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileProvisioningEvents(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const testNamespace = "redhat-rhoam-operator"
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: testNamespace},
+	}
+	postgres := func(phase croTypes.StatusPhase, milestone string) *crov1alpha1.Postgres {
+		p := &crov1alpha1.Postgres{
+			ObjectMeta: metav1.ObjectMeta{Name: "threescale-postgres", Namespace: testNamespace},
+			Status: croTypes.ResourceTypeStatus{
+				Phase:    phase,
+				Provider: "aws",
+				Strategy: "aws-rds",
+				Message:  "failed to create the rds instance",
+			},
+		}
+		if milestone != "" {
+			p.Annotations = map[string]string{provisioningMilestoneAnnotation: milestone}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name          string
+		objects       []runtime.Object
+		wantEvent     string
+		wantMilestone string
+	}{
+		{
+			name:          "test created event for a resource being provisioned",
+			objects:       []runtime.Object{postgres(croTypes.PhaseInProgress, "")},
+			wantEvent:     integreatlyv1alpha1.EventCloudResourceCreated,
+			wantMilestone: milestoneCreated,
+		},
+		{
+			name:          "test ready event for a provisioned resource",
+			objects:       []runtime.Object{postgres(croTypes.PhaseComplete, milestoneCreated)},
+			wantEvent:     integreatlyv1alpha1.EventCloudResourceReady,
+			wantMilestone: milestoneReady,
+		},
+		{
+			name:          "test failed event for a resource that failed to provision",
+			objects:       []runtime.Object{postgres(croTypes.PhaseFailed, milestoneCreated)},
+			wantEvent:     integreatlyv1alpha1.EventCloudResourceFailed,
+			wantMilestone: milestoneFailed,
+		},
+		{
+			name:          "test no event for a milestone already reported",
+			objects:       []runtime.Object{postgres(croTypes.PhaseComplete, milestoneReady)},
+			wantMilestone: milestoneReady,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			r := &Reconciler{recorder: recorder}
+
+			if err := r.reconcileProvisioningEvents(context.TODO(), serverClient, installation); err != nil {
+				t.Fatalf("reconcileProvisioningEvents() error = %v", err)
+			}
+
+			if tt.wantEvent == "" && len(recorder.Events) != 0 {
+				t.Fatalf("expected no event, got %s", <-recorder.Events)
+			}
+			if tt.wantEvent != "" {
+				if len(recorder.Events) != 1 {
+					t.Fatalf("expected a %s event, got none", tt.wantEvent)
+				}
+				if event := <-recorder.Events; !strings.Contains(event, tt.wantEvent) {
+					t.Fatalf("expected a %s event, got %s", tt.wantEvent, event)
+				}
+			}
+
+			updated := &crov1alpha1.Postgres{}
+			if err := serverClient.Get(context.TODO(), client.ObjectKey{Name: "threescale-postgres", Namespace: testNamespace}, updated); err != nil {
+				t.Fatal(err)
+			}
+			if milestone := updated.Annotations[provisioningMilestoneAnnotation]; milestone != tt.wantMilestone {
+				t.Errorf("milestone = %s, want %s", milestone, tt.wantMilestone)
+			}
+		})
+	}
+}
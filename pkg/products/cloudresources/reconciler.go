@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/sts"
@@ -84,13 +85,9 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		return nil, fmt.Errorf("could not read cloud resources config: %w", err)
 	}
 
-	productConfig.SetNamespace(installation.Spec.NamespacePrefix + defaultInstallationNamespace)
+	productConfig.SetNamespace(installation.ProductNamespace(defaultInstallationNamespace))
 
-	if installation.Spec.OperatorsInProductNamespace {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace())
-	} else {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace() + "-operator")
-	}
+	productConfig.SetOperatorNamespace(installation.OperatorNamespace(productConfig.GetNamespace()))
 
 	if err := configManager.WriteConfig(productConfig); err != nil {
 		return nil, fmt.Errorf("error writing cloudresources config : %w", err)
@@ -225,6 +222,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.reconcileEncryptionKey(ctx, client)
+	if err != nil {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile Cloud Resource encryption key", err)
+		return phase, err
+	}
+
 	if err := r.reconcileCIDRValue(ctx, client); err != nil {
 		phase := integreatlyv1alpha1.PhaseFailed
 		events.HandleError(r.recorder, installation, phase, "Failed to reconcile CIDR value", err)
@@ -273,6 +276,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, nil
 	}
 
+	phase, err = r.reconcileCloudWatchExporter(ctx, client, isSTS)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile cloudwatch exporter", err)
+		return phase, err
+	}
+
 	alertsReconciler, err := r.newAlertsReconciler(ctx, client, r.log, r.installation.Spec.Type, config.GetOboNamespace(r.installation.Namespace))
 	if err != nil {
 		events.HandleError(r.recorder, installation, phase, "Failed to get new alerts reconciler", err)
@@ -285,16 +294,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		events.HandleError(r.recorder, installation, phase, "Failed to reconcile operator endpoint available alerts", err)
 		return phase, err
 	}
+	if err := r.reconcileProvisioningEvents(ctx, client, installation); err != nil {
+		r.log.Error("Failed to emit the cloud resource provisioning events", err)
+	}
+
 	productStatus.Host = r.Config.GetHost()
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
+	productStatus.FIPS = fips.ProductStatus(installation, cloudWatchExporterImage)
 
 	err = r.ConfigManager.WriteConfig(r.Config)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("could not write cloud resources config: %w", err)
 	}
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Infof("Reconcile successful", l.Fields{"productStatus": r.Config.GetProductName()})
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
@@ -716,7 +730,33 @@ func (r *Reconciler) reconcileCloudResourceStrategies(ctx context.Context, clien
 		hour = DefaultMaintenanceHour
 	}
 
-	timeConfig := croStrat.NewStrategyTimeConfig(3, 01, day, hour, 00)
+	// the maintenance window of the RHMI spec overrides the addon parameters
+	minute := 0
+	if maintenance := r.installation.Spec.Maintenance; maintenance != nil {
+		day, hour, minute, err = parseMaintenanceApplyFrom(maintenance.ApplyFrom)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		duration, err := getMaintenanceDuration(maintenance)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+
+		// CRO always builds a 1h window on AWS, so the strategies are updated
+		// directly to honour the duration
+		if r.Config.GetStrategiesConfigMapName() == croAWS.DefaultConfigMapName {
+			maintenanceWindow, err := buildAWSMaintenanceWindow(day, hour, minute, duration)
+			if err != nil {
+				return integreatlyv1alpha1.PhaseFailed, err
+			}
+			if err := r.reconcileAWSMaintenanceWindow(ctx, client, maintenanceWindow); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, err
+			}
+			return integreatlyv1alpha1.PhaseCompleted, nil
+		}
+	}
+
+	timeConfig := croStrat.NewStrategyTimeConfig(backupHour, backupMinute, day, hour, minute)
 
 	err = croUtil.ReconcileStrategyMaps(ctx, client, timeConfig, croUtil.TierProduction, r.ConfigManager.GetOperatorNamespace())
 	if err != nil {
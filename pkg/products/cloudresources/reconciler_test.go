@@ -3,6 +3,7 @@ package cloudresources
 import (
 	"context"
 	"testing"
+	"time"
 
 	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
 	croGCP "github.com/integr8ly/cloud-resource-operator/pkg/providers/gcp"
@@ -720,7 +721,8 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 						return testNamespace
 					},
 				},
-				log: getLogger(),
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 				recorder: nil,
@@ -748,7 +750,8 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 						return testNamespace
 					},
 				},
-				log: getLogger(),
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 			},
@@ -775,7 +778,8 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 						return testNamespace
 					},
 				},
-				log: getLogger(),
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 			},
@@ -799,7 +803,8 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 						return testNamespace
 					},
 				},
-				log: getLogger(),
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 			},
@@ -826,7 +831,8 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 						return testNamespace
 					},
 				},
-				log: getLogger(),
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 			},
@@ -846,14 +852,79 @@ func TestReconciler_reconcileCloudResourceStrategies(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "failure reconciling strategy map",
+			name: "success when the maintenance window is set in the RHMI spec",
 			fields: fields{
+				Config: config.NewCloudResources(config.ProductConfig{
+					"STRATEGIES_CONFIG_MAP_NAME": croAWS.DefaultConfigMapName,
+				}),
 				ConfigManager: &config.ConfigReadWriterMock{
 					GetOperatorNamespaceFunc: func() string {
 						return testNamespace
 					},
 				},
 				log: getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{
+					ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace},
+					Spec: integreatlyv1alpha1.RHMISpec{
+						Maintenance: &integreatlyv1alpha1.MaintenanceWindowSpec{
+							ApplyFrom: "sun 23:00",
+							Duration:  &metav1.Duration{Duration: 4 * time.Hour},
+						},
+					},
+				},
+				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
+					WithProductDeclaration(marketplace.ProductDeclaration{}),
+			},
+			args: args{
+				client: moqclient.NewSigsClientMoqWithScheme(scheme,
+					clusterInfrastructure(configv1.AWSPlatformType),
+					addonParamsSecret(testNamespace, map[string][]byte{}),
+				),
+				ctx: context.TODO(),
+			},
+			want:    integreatlyv1alpha1.PhaseCompleted,
+			wantErr: false,
+		},
+		{
+			name: "error when the maintenance window of the RHMI spec overlaps the backup window",
+			fields: fields{
+				Config: config.NewCloudResources(config.ProductConfig{
+					"STRATEGIES_CONFIG_MAP_NAME": croAWS.DefaultConfigMapName,
+				}),
+				ConfigManager: &config.ConfigReadWriterMock{
+					GetOperatorNamespaceFunc: func() string {
+						return testNamespace
+					},
+				},
+				log: getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{
+					Spec: integreatlyv1alpha1.RHMISpec{
+						Maintenance: &integreatlyv1alpha1.MaintenanceWindowSpec{ApplyFrom: "mon 03:00"},
+					},
+				},
+				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
+					WithProductDeclaration(marketplace.ProductDeclaration{}),
+			},
+			args: args{
+				client: moqclient.NewSigsClientMoqWithScheme(scheme,
+					clusterInfrastructure(configv1.AWSPlatformType),
+					addonParamsSecret(testNamespace, map[string][]byte{}),
+				),
+				ctx: context.TODO(),
+			},
+			want:    integreatlyv1alpha1.PhaseFailed,
+			wantErr: true,
+		},
+		{
+			name: "failure reconciling strategy map",
+			fields: fields{
+				ConfigManager: &config.ConfigReadWriterMock{
+					GetOperatorNamespaceFunc: func() string {
+						return testNamespace
+					},
+				},
+				log:          getLogger(),
+				installation: &integreatlyv1alpha1.RHMI{},
 				Reconciler: resources.NewReconciler(&marketplace.MarketplaceInterfaceMock{}).
 					WithProductDeclaration(marketplace.ProductDeclaration{}),
 				recorder: nil,
@@ -0,0 +1,183 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	customerDataSourcesName            = "customer-extra-datasources"
+	customerDataSourcesCredentialsName = "customer-extra-datasources-credentials"
+)
+
+// reconcileExtraDataSources adds the data sources of the spec to the managed
+// Grafana, or removes them when there are none anymore. The Grafana operator
+// doesn't read the credentials from secrets, so they're copied into a secret
+// in the Grafana namespace, exposed to Grafana as environment variables and
+// referenced from the data sources
+func (r *Reconciler) reconcileExtraDataSources(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	dataSourceCR := &grafanav1alpha1.GrafanaDataSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      customerDataSourcesName,
+			Namespace: r.Config.GetOperatorNamespace(),
+		},
+	}
+	credentialsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      customerDataSourcesCredentialsName,
+			Namespace: r.Config.GetOperatorNamespace(),
+		},
+	}
+	if len(r.installation.Spec.DataSources) == 0 {
+		for _, object := range []k8sclient.Object{dataSourceCR, credentialsSecret} {
+			if err := serverClient.Delete(ctx, object); err != nil && !k8serr.IsNotFound(err) {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete extra grafana data sources: %w", err)
+			}
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	var dataSources []grafanav1alpha1.GrafanaDataSourceFields
+	credentialsData := map[string][]byte{}
+	for i, spec := range r.installation.Spec.DataSources {
+		credentials := map[string][]byte{}
+		if spec.CredentialsSecret != "" {
+			secret := &corev1.Secret{}
+			if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: spec.CredentialsSecret, Namespace: r.installation.Namespace}, secret); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get the credentials of data source %s: %w", spec.Name, err)
+			}
+			credentials = secret.Data
+		}
+		for _, key := range dataSourceCredentialKeys(spec.Type) {
+			if len(credentials[key]) > 0 {
+				credentialsData[dataSourceCredentialSecretKey(i, key)] = credentials[key]
+			}
+		}
+		dataSources = append(dataSources, extraDataSource(i, spec, credentials))
+	}
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, credentialsSecret, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(credentialsSecret, r.installation)
+		credentialsSecret.Data = credentialsData
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile extra grafana data sources credentials: %w", err)
+	}
+
+	status, err := controllerutil.CreateOrUpdate(ctx, serverClient, dataSourceCR, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(dataSourceCR, r.installation)
+		dataSourceCR.Spec = grafanav1alpha1.GrafanaDataSourceSpec{
+			Name:        "customer-extra.yaml",
+			Datasources: dataSources,
+		}
+		return nil
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile extra grafana data sources: %w", err)
+	}
+	if status != controllerutil.OperationResultNone {
+		r.log.Infof("Grafana extra datasources: ", l.Fields{"status": status})
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// extraDataSourcesEnv returns the environment variables of the Grafana
+// deployment holding the credentials of the extra data sources. They're
+// optional, so the credentials the secret of a data source doesn't have are
+// left empty
+func extraDataSourcesEnv(dataSources []integreatlyv1alpha1.DataSourceSpec) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for i, spec := range dataSources {
+		if spec.CredentialsSecret == "" {
+			continue
+		}
+		for _, key := range dataSourceCredentialKeys(spec.Type) {
+			env = append(env, corev1.EnvVar{
+				Name: dataSourceCredentialEnv(i, key),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: customerDataSourcesCredentialsName},
+						Key:                  dataSourceCredentialSecretKey(i, key),
+						Optional:             boolPtr(true),
+					},
+				},
+			})
+		}
+	}
+	return env
+}
+
+// dataSourceCredentialKeys returns the keys read from the credentials secret
+// of a data source of the type
+func dataSourceCredentialKeys(dataSourceType integreatlyv1alpha1.DataSourceType) []string {
+	switch dataSourceType {
+	case integreatlyv1alpha1.DataSourceCloudWatch:
+		return []string{"aws_access_key_id", "aws_secret_access_key"}
+	case integreatlyv1alpha1.DataSourcePrometheus:
+		return []string{"password", "token"}
+	}
+	return nil
+}
+
+func dataSourceCredentialSecretKey(index int, key string) string {
+	return fmt.Sprintf("datasource-%d-%s", index, key)
+}
+
+func dataSourceCredentialEnv(index int, key string) string {
+	return fmt.Sprintf("GF_EXTRA_DATASOURCE_%d_%s", index, strings.ToUpper(key))
+}
+
+// dataSourceCredentialRef is expanded by Grafana to the value of the
+// environment variable when it provisions the data source
+func dataSourceCredentialRef(index int, key string) string {
+	return fmt.Sprintf("$__env{%s}", dataSourceCredentialEnv(index, key))
+}
+
+// extraDataSource returns the Grafana data source of the spec at the index,
+// authenticated with references to the credentials of its secret
+func extraDataSource(index int, spec integreatlyv1alpha1.DataSourceSpec, credentials map[string][]byte) grafanav1alpha1.GrafanaDataSourceFields {
+	dataSource := grafanav1alpha1.GrafanaDataSourceFields{
+		Name:     spec.Name,
+		Access:   "proxy",
+		Editable: false,
+		Version:  1,
+	}
+
+	switch spec.Type {
+	case integreatlyv1alpha1.DataSourceCloudWatch:
+		dataSource.Type = "cloudwatch"
+		dataSource.JsonData.DefaultRegion = spec.Region
+		dataSource.JsonData.AssumeRoleArn = spec.AssumeRoleARN
+		// without credentials the data source uses the default chain of
+		// the AWS SDK, such as the role of the node
+		dataSource.JsonData.AuthType = "default"
+		if len(credentials["aws_access_key_id"]) > 0 {
+			dataSource.JsonData.AuthType = "keys"
+			dataSource.SecureJsonData.AccessKey = dataSourceCredentialRef(index, "aws_access_key_id")
+			dataSource.SecureJsonData.SecretKey = dataSourceCredentialRef(index, "aws_secret_access_key")
+		}
+	case integreatlyv1alpha1.DataSourcePrometheus:
+		dataSource.Type = "prometheus"
+		dataSource.Url = spec.URL
+		if len(credentials["username"]) > 0 {
+			dataSource.BasicAuth = true
+			dataSource.BasicAuthUser = string(credentials["username"])
+			dataSource.SecureJsonData.BasicAuthPassword = dataSourceCredentialRef(index, "password")
+		} else if len(credentials["token"]) > 0 {
+			dataSource.JsonData.HTTPHeaderName1 = "Authorization"
+			dataSource.SecureJsonData.HTTPHeaderValue1 = "Bearer " + dataSourceCredentialRef(index, "token")
+		}
+	}
+	return dataSource
+}
@@ -0,0 +1,188 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DashboardImportLabel marks the GrafanaDashboards and the ConfigMaps
+	// of the customer imported into the managed Grafana
+	DashboardImportLabel = "integreatly.org/grafana-dashboard"
+
+	importedDashboardLabel  = "integreatly.org/imported-dashboard"
+	importedDashboardPrefix = "imported-"
+
+	dashboardKindGrafanaDashboard = "GrafanaDashboard"
+	dashboardKindConfigMap        = "ConfigMap"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// importedDashboard is a customer dashboard to import, or the reason it
+// can't be
+type importedDashboard struct {
+	status integreatlyv1alpha1.ImportedDashboardStatus
+	spec   grafanav1alpha1.GrafanaDashboardSpec
+}
+
+// reconcileImportedDashboards copies the labeled GrafanaDashboards and
+// ConfigMaps of the namespaces of the dashboards spec into the namespace of
+// the managed Grafana. The imported dashboards that aren't labeled anymore
+// are removed, and the result of each import is reported in the status
+func (r *Reconciler) reconcileImportedDashboards(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	var dashboards []importedDashboard
+	if r.installation.Spec.Dashboards != nil {
+		for _, namespace := range r.installation.Spec.Dashboards.Namespaces {
+			namespaceDashboards, err := listCustomerDashboards(ctx, serverClient, namespace)
+			if err != nil {
+				return integreatlyv1alpha1.PhaseFailed, err
+			}
+			dashboards = append(dashboards, namespaceDashboards...)
+		}
+	}
+
+	imported := map[string]bool{}
+	var statuses []integreatlyv1alpha1.ImportedDashboardStatus
+	for _, dashboard := range dashboards {
+		if dashboard.status.Imported {
+			name := importedDashboardName(dashboard.status)
+			imported[name] = true
+
+			grafanaDB := &grafanav1alpha1.GrafanaDashboard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: r.Config.GetOperatorNamespace(),
+				},
+			}
+			opRes, err := controllerutil.CreateOrUpdate(ctx, serverClient, grafanaDB, func() error {
+				grafanaDB.Labels = map[string]string{
+					"monitoring-key":       "customer",
+					importedDashboardLabel: "true",
+				}
+				grafanaDB.Spec = dashboard.spec
+				return nil
+			})
+			if err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to import dashboard %s/%s: %w", dashboard.status.Namespace, dashboard.status.Name, err)
+			}
+			if opRes != controllerutil.OperationResultNone {
+				r.log.Infof("Operation result grafana dashboard", l.Fields{"grafanaDashboard": grafanaDB.Name, "result": opRes})
+			}
+		}
+		statuses = append(statuses, dashboard.status)
+	}
+	r.installation.Status.ImportedDashboards = statuses
+
+	existing := &grafanav1alpha1.GrafanaDashboardList{}
+	if err := serverClient.List(ctx, existing, k8sclient.InNamespace(r.Config.GetOperatorNamespace()), k8sclient.HasLabels{importedDashboardLabel}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list imported dashboards: %w", err)
+	}
+	for i := range existing.Items {
+		if imported[existing.Items[i].Name] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, &existing.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete imported dashboard %s: %w", existing.Items[i].Name, err)
+		}
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// listCustomerDashboards returns the labeled dashboards of the namespace.
+// The dashboards are put in a folder named after their namespace unless
+// they set their own
+func listCustomerDashboards(ctx context.Context, serverClient k8sclient.Client, namespace string) ([]importedDashboard, error) {
+	selector := k8sclient.MatchingLabels{DashboardImportLabel: "true"}
+	var dashboards []importedDashboard
+
+	grafanaDashboards := &grafanav1alpha1.GrafanaDashboardList{}
+	if err := serverClient.List(ctx, grafanaDashboards, k8sclient.InNamespace(namespace), selector); err != nil {
+		return nil, fmt.Errorf("failed to list the dashboards of namespace %s: %w", namespace, err)
+	}
+	for _, grafanaDashboard := range grafanaDashboards.Items {
+		dashboard := importedDashboard{
+			status: integreatlyv1alpha1.ImportedDashboardStatus{Namespace: namespace, Name: grafanaDashboard.Name, Kind: dashboardKindGrafanaDashboard},
+			spec: grafanav1alpha1.GrafanaDashboardSpec{
+				Json:             grafanaDashboard.Spec.Json,
+				Plugins:          grafanaDashboard.Spec.Plugins,
+				Datasources:      grafanaDashboard.Spec.Datasources,
+				CustomFolderName: grafanaDashboard.Spec.CustomFolderName,
+			},
+		}
+		// the other sources are resolved by the Grafana operator from the
+		// namespace of the dashboard, or fetched from outside the cluster
+		if grafanaDashboard.Spec.Url != "" || grafanaDashboard.Spec.ConfigMapRef != nil || grafanaDashboard.Spec.GrafanaCom != nil || grafanaDashboard.Spec.Jsonnet != "" {
+			dashboard.status.Message = "only the json dashboards can be imported"
+		} else {
+			dashboard.status.Message = validateDashboardJSON(grafanaDashboard.Spec.Json)
+		}
+		dashboards = append(dashboards, dashboard)
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := serverClient.List(ctx, configMaps, k8sclient.InNamespace(namespace), selector); err != nil {
+		return nil, fmt.Errorf("failed to list the dashboard config maps of namespace %s: %w", namespace, err)
+	}
+	for _, configMap := range configMaps.Items {
+		keys := make([]string, 0, len(configMap.Data))
+		for key := range configMap.Data {
+			if strings.HasSuffix(key, ".json") {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			dashboards = append(dashboards, importedDashboard{
+				status: integreatlyv1alpha1.ImportedDashboardStatus{
+					Namespace: namespace,
+					Name:      configMap.Name + "/" + key,
+					Kind:      dashboardKindConfigMap,
+					Message:   validateDashboardJSON(configMap.Data[key]),
+				},
+				spec: grafanav1alpha1.GrafanaDashboardSpec{Json: configMap.Data[key]},
+			})
+		}
+	}
+
+	for i := range dashboards {
+		dashboards[i].status.Imported = dashboards[i].status.Message == ""
+		if dashboards[i].spec.CustomFolderName == "" {
+			dashboards[i].spec.CustomFolderName = namespace
+		}
+	}
+	return dashboards, nil
+}
+
+// validateDashboardJSON returns why the json isn't a Grafana dashboard, or
+// an empty string when it is
+func validateDashboardJSON(dashboardJSON string) string {
+	dashboard := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(dashboardJSON), &dashboard); err != nil {
+		return fmt.Sprintf("invalid dashboard json: %v", err)
+	}
+	if title, ok := dashboard["title"].(string); !ok || title == "" {
+		return "the dashboard has no title"
+	}
+	return ""
+}
+
+// importedDashboardName is the name of the GrafanaDashboard importing the
+// customer dashboard into the namespace of the managed Grafana
+func importedDashboardName(status integreatlyv1alpha1.ImportedDashboardStatus) string {
+	name := strings.TrimSuffix(strings.ToLower(status.Namespace+"-"+status.Name), ".json")
+	return importedDashboardPrefix + strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+}
@@ -0,0 +1,108 @@
+package grafana
+
+import (
+	"context"
+	"testing"
+
+	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileImportedDashboards(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const operatorNamespace = "customer-monitoring-operator"
+	labels := map[string]string{DashboardImportLabel: "true"}
+	staleDashboard := &grafanav1alpha1.GrafanaDashboard{ObjectMeta: metav1.ObjectMeta{
+		Name: "imported-team-a-removed", Namespace: operatorNamespace, Labels: map[string]string{importedDashboardLabel: "true"},
+	}}
+
+	tests := []struct {
+		name         string
+		dashboards   *integreatlyv1alpha1.DashboardsSpec
+		objects      []runtime.Object
+		wantImported []string
+		wantStatuses []integreatlyv1alpha1.ImportedDashboardStatus
+	}{
+		{
+			name:       "imports the labeled dashboards of the namespaces",
+			dashboards: &integreatlyv1alpha1.DashboardsSpec{Namespaces: []string{"team-a"}},
+			objects: []runtime.Object{
+				&grafanav1alpha1.GrafanaDashboard{
+					ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team-a", Labels: labels},
+					Spec:       grafanav1alpha1.GrafanaDashboardSpec{Json: `{"title": "Orders"}`},
+				},
+				&grafanav1alpha1.GrafanaDashboard{
+					ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "team-a", Labels: labels},
+					Spec:       grafanav1alpha1.GrafanaDashboardSpec{Url: "https://example.com/dashboard.json"},
+				},
+				&grafanav1alpha1.GrafanaDashboard{
+					ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "team-a"},
+					Spec:       grafanav1alpha1.GrafanaDashboardSpec{Json: `{"title": "Unlabeled"}`},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "payments", Namespace: "team-a", Labels: labels},
+					Data:       map[string]string{"Latency.json": `{"title": "Latency"}`, "broken.json": `{"title":`, "README.md": "docs"},
+				},
+				staleDashboard,
+			},
+			wantImported: []string{"imported-team-a-orders", "imported-team-a-payments-latency"},
+			wantStatuses: []integreatlyv1alpha1.ImportedDashboardStatus{
+				{Namespace: "team-a", Name: "orders", Kind: "GrafanaDashboard", Imported: true},
+				{Namespace: "team-a", Name: "remote", Kind: "GrafanaDashboard", Message: "only the json dashboards can be imported"},
+				{Namespace: "team-a", Name: "payments/Latency.json", Kind: "ConfigMap", Imported: true},
+				{Namespace: "team-a", Name: "payments/broken.json", Kind: "ConfigMap", Message: "invalid dashboard json: unexpected end of JSON input"},
+			},
+		},
+		{
+			name:    "removes the imported dashboards without dashboards spec",
+			objects: []runtime.Object{staleDashboard},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			reconciler := getBasicReconciler()
+			reconciler.Config = config.NewGrafana(config.ProductConfig{"OPERATOR_NAMESPACE": operatorNamespace})
+			reconciler.installation.Spec.Dashboards = tt.dashboards
+
+			phase, err := reconciler.reconcileImportedDashboards(context.TODO(), serverClient)
+			if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+				t.Fatalf("reconcileImportedDashboards() returned %v, %v", phase, err)
+			}
+
+			for _, name := range tt.wantImported {
+				dashboard := &grafanav1alpha1.GrafanaDashboard{}
+				if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: operatorNamespace}, dashboard); err != nil {
+					t.Fatalf("expected dashboard %s to be imported, got %v", name, err)
+				}
+				if dashboard.Labels["monitoring-key"] != "customer" || dashboard.Spec.CustomFolderName != "team-a" {
+					t.Errorf("expected dashboard %s to be selected by the managed Grafana in the folder of its namespace, got %v", name, dashboard)
+				}
+			}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(staleDashboard), &grafanav1alpha1.GrafanaDashboard{}); !k8serr.IsNotFound(err) {
+				t.Errorf("expected the dashboards not labeled anymore to be removed, got %v", err)
+			}
+
+			statuses := reconciler.installation.Status.ImportedDashboards
+			if len(statuses) != len(tt.wantStatuses) {
+				t.Fatalf("expected statuses %v, got %v", tt.wantStatuses, statuses)
+			}
+			for i := range statuses {
+				if statuses[i] != tt.wantStatuses[i] {
+					t.Errorf("expected status %v, got %v", tt.wantStatuses[i], statuses[i])
+				}
+			}
+		})
+	}
+}
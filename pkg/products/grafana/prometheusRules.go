@@ -8,16 +8,21 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-func (r *Reconciler) newAlertReconciler(logger l.Logger, installType string, namespace string) resources.AlertReconciler {
+func (r *Reconciler) newAlertReconciler(logger l.Logger, installType string, namespace string) (resources.AlertReconciler, error) {
 	installationName := resources.InstallationNames[installType]
 
 	alertNamePrefix := "customer-monitoring-"
 
+	sloAlerts, err := r.sloAlerts(namespace, installationName)
+	if err != nil {
+		return nil, err
+	}
+
 	return &resources.AlertReconcilerImpl{
 		Installation: r.installation,
 		Log:          logger,
 		ProductName:  "Grafana",
-		Alerts: []resources.AlertConfiguration{
+		Alerts: append([]resources.AlertConfiguration{
 			{
 				AlertName: alertNamePrefix + "ksm-endpoint-alerts",
 				GroupName: "grafana-operator-endpoint.rules",
@@ -72,6 +77,6 @@ func (r *Reconciler) newAlertReconciler(logger l.Logger, installType string, nam
 					},
 				},
 			},
-		},
-	}
+		}, sloAlerts...),
+	}, nil
 }
@@ -0,0 +1,104 @@
+package grafana
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestReconciler_reconcileHost(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		installation *integreatlyv1alpha1.RHMI
+		initObjs     []runtime.Object
+		writeErr     error
+		want         integreatlyv1alpha1.StatusPhase
+		wantErr      bool
+		wantHost     string
+	}{
+		{
+			name:         "test host is set from the grafana route",
+			installation: utils.NewTestManagedApiInstallation(),
+			initObjs:     []runtime.Object{grafanaRoute("grafana.apps.example.com")},
+			want:         integreatlyv1alpha1.PhaseCompleted,
+			wantHost:     "https://grafana.apps.example.com",
+		},
+		{
+			name:         "test host is set from the grafana route for multitenant installations",
+			installation: utils.NewTestMultitenantInstallation(),
+			initObjs:     []runtime.Object{grafanaRoute("grafana.apps.example.com")},
+			want:         integreatlyv1alpha1.PhaseCompleted,
+			wantHost:     "https://grafana.apps.example.com",
+		},
+		{
+			name:         "test failed when the grafana route does not exist",
+			installation: utils.NewTestManagedApiInstallation(),
+			want:         integreatlyv1alpha1.PhaseFailed,
+			wantErr:      true,
+		},
+		{
+			name:         "test failed when the host cannot be written to the config",
+			installation: utils.NewTestManagedApiInstallation(),
+			initObjs:     []runtime.Object{grafanaRoute("grafana.apps.example.com")},
+			writeErr:     errors.New("config map conflict"),
+			want:         integreatlyv1alpha1.PhaseFailed,
+			wantErr:      true,
+			wantHost:     "https://grafana.apps.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, append(tt.initObjs, tt.installation)...)
+			r := &Reconciler{
+				ConfigManager: &config.ConfigReadWriterMock{
+					WriteConfigFunc: func(config.ConfigReadable) error {
+						return tt.writeErr
+					},
+				},
+				Config: config.NewGrafana(config.ProductConfig{
+					"NAMESPACE":          utils.TestNamespacePrefix + "grafana",
+					"OPERATOR_NAMESPACE": utils.TestNamespacePrefix + "grafana-operator",
+				}),
+				installation: tt.installation,
+				log:          l.NewLoggerWithContext(l.Fields{l.ProductLogContext: "grafana"}),
+				recorder:     record.NewFakeRecorder(50),
+			}
+
+			got, err := r.reconcileHost(context.TODO(), client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("reconcileHost() got = %v, want %v", got, tt.want)
+			}
+			if host := r.Config.GetHost(); host != tt.wantHost {
+				t.Fatalf("expected host %q, got %q", tt.wantHost, host)
+			}
+		})
+	}
+}
+
+func grafanaRoute(host string) *routev1.Route {
+	return &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultRoutename,
+			Namespace: utils.TestNamespacePrefix + "grafana-operator",
+		},
+		Spec: routev1.RouteSpec{Host: host},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 	consolev1 "github.com/openshift/api/console/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -72,7 +73,7 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		return nil, fmt.Errorf("no product declaration found for grafana")
 	}
 
-	ns := installation.Spec.NamespacePrefix + defaultInstallationNamespace + "-operator"
+	ns := installation.ProductNamespace(defaultInstallationNamespace + "-operator")
 	productConfig, err := configManager.ReadGrafana()
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve grafana config: %w", err)
@@ -190,7 +191,29 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		}
 	}
 
-	alertsReconciler := r.newAlertReconciler(r.log, r.installation.Spec.Type, config.GetOboNamespace(r.installation.Namespace))
+	phase, err = r.reconcileSLOs(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile SLOs", err)
+		return phase, err
+	}
+
+	phase, err = r.reconcileImportedDashboards(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to import customer dashboards", err)
+		return phase, err
+	}
+
+	phase, err = r.reconcileExtraDataSources(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile extra grafana data sources", err)
+		return phase, err
+	}
+
+	alertsReconciler, err := r.newAlertReconciler(r.log, r.installation.Spec.Type, config.GetOboNamespace(r.installation.Namespace))
+	if err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Failed to build grafana alerts", err)
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
 	if phase, err := alertsReconciler.ReconcileAlerts(ctx, client); err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.recorder, installation, phase, "Failed to reconcile grafana alerts", err)
 		return phase, err
@@ -204,7 +227,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Info("Reconciled successfully")
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
@@ -359,7 +382,12 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, client k8sclient.C
 				},
 			},
 			Deployment: &grafanav1alpha1.GrafanaDeployment{
-				PriorityClassName: r.installation.Spec.PriorityClassName,
+				PriorityClassName:        r.installation.Spec.PriorityClassName,
+				NodeSelector:             resources.PlacementNodeSelector(r.installation.Spec.Placement),
+				Tolerations:              resources.PlacementTolerations(r.installation.Spec.Placement),
+				SecurityContext:          podsecurity.PodSecurityContext(r.installation),
+				ContainerSecurityContext: podsecurity.SecurityContext(r.installation),
+				Env:                      extraDataSourcesEnv(r.installation.Spec.DataSources),
 			},
 			Secrets: []string{"grafana-k8s-tls", "grafana-k8s-proxy"},
 			Service: &grafanav1alpha1.GrafanaService{
@@ -577,7 +605,7 @@ func GetGrafanaConsoleURL(ctx context.Context, serverClient k8sclient.Client, in
 		return grafanaConsoleURL, nil
 	}
 
-	ns := installation.Spec.NamespacePrefix + defaultInstallationNamespace
+	ns := installation.ProductNamespace(defaultInstallationNamespace)
 	grafanaRoute := &routev1.Route{}
 
 	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: defaultRoutename, Namespace: ns}, grafanaRoute)
@@ -600,6 +628,7 @@ func (r *Reconciler) reconcileConsoleLink(ctx context.Context, serverClient k8sc
 		}
 
 		_, err := controllerutil.CreateOrUpdate(ctx, serverClient, cl, func() error {
+			resources.PrepareObjectLabels(cl, r.installation, false, false, false)
 			cl.Spec = consolev1.ConsoleLinkSpec{
 				ApplicationMenu: &consolev1.ApplicationMenuSpec{
 					ImageURL: grafanaIcon,
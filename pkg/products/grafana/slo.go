@@ -0,0 +1,287 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	sloAlertNamePrefix = "customer-monitoring-slo-"
+	sloDashboardPrefix = "slo-"
+	sloLabel           = "integreatly.org/slo"
+	defaultSLOWindow   = "28d"
+
+	sloErrorRatioRecord = "slo:sli_error:ratio_rate"
+)
+
+// sloRateWindows are the windows the error ratio of the SLOs is recorded
+// over, the ones of the burn rate alerts
+var sloRateWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// sloBurnRateAlert is a multi-window burn rate alert, firing when the error
+// budget is consumed BurnRate times faster than allowed over both windows
+// https://sre.google/workbook/alerting-on-slos/
+type sloBurnRateAlert struct {
+	Name        string
+	LongWindow  string
+	ShortWindow string
+	BurnRate    float64
+	For         monv1.Duration
+	Severity    string
+}
+
+var sloBurnRateAlerts = []sloBurnRateAlert{
+	{Name: "SLOErrorBudgetBurn1hto5m", LongWindow: "1h", ShortWindow: "5m", BurnRate: 14.4, For: "2m", Severity: "critical"},
+	{Name: "SLOErrorBudgetBurn6hto30m", LongWindow: "6h", ShortWindow: "30m", BurnRate: 6, For: "15m", Severity: "critical"},
+	{Name: "SLOErrorBudgetBurn1dto2h", LongWindow: "1d", ShortWindow: "2h", BurnRate: 3, For: "1h", Severity: "warning"},
+	{Name: "SLOErrorBudgetBurn3dto6h", LongWindow: "3d", ShortWindow: "6h", BurnRate: 1, For: "3h", Severity: "warning"},
+}
+
+// sloWindow returns the window of the SLO, defaulted when the CR was created
+// without the CRD defaults
+func sloWindow(slo integreatlyv1alpha1.SLOSpec) string {
+	if slo.Window == "" {
+		return defaultSLOWindow
+	}
+	return slo.Window
+}
+
+// sloErrorBudget returns the ratio of requests allowed to fail by the
+// objective of the SLO
+func sloErrorBudget(slo integreatlyv1alpha1.SLOSpec) (float64, error) {
+	objective, err := strconv.ParseFloat(slo.Objective, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid objective %q of slo %s: %w", slo.Objective, slo.Name, err)
+	}
+	// round away the floating point error of the subtraction
+	return math.Round((1-objective/100)*1e9) / 1e9, nil
+}
+
+// sloErrorRatioExpr returns the ratio of the requests of the SLO that failed
+// its indicator over the window
+func sloErrorRatioExpr(installation *integreatlyv1alpha1.RHMI, slo integreatlyv1alpha1.SLOSpec, window string) (string, error) {
+	var namespace, route string
+	switch slo.Product {
+	case integreatlyv1alpha1.Product3Scale:
+		namespace, route = installation.ProductNamespace("3scale"), "^zync-3scale-api-.*"
+	case integreatlyv1alpha1.ProductRHSSO:
+		namespace, route = installation.ProductNamespace("rhsso"), "^keycloak.*"
+	case integreatlyv1alpha1.ProductRHSSOUser:
+		namespace, route = installation.ProductNamespace("user-sso"), "^keycloak.*"
+	default:
+		return "", fmt.Errorf("unsupported product %s of slo %s", slo.Product, slo.Name)
+	}
+	if slo.Route != "" {
+		route = slo.Route
+	}
+
+	switch slo.Indicator {
+	case integreatlyv1alpha1.SLIAvailability:
+		return fmt.Sprintf(`sum(rate(haproxy_backend_http_responses_total{route=~%[1]q, exported_namespace=%[2]q, code="5xx"}[%[3]s]))
+/ sum(rate(haproxy_backend_http_responses_total{route=~%[1]q, exported_namespace=%[2]q}[%[3]s]))`, route, namespace, window), nil
+	case integreatlyv1alpha1.SLILatency:
+		if slo.LatencyThreshold == nil {
+			return "", fmt.Errorf("slo %s requires a latency threshold", slo.Name)
+		}
+		// APIcast observes the request durations in seconds, the SSO
+		// instances in milliseconds. The bucket boundaries are matched
+		// with and without decimals as they're formatted like floats
+		metric, threshold := "total_response_time_seconds", slo.LatencyThreshold.Seconds()
+		if slo.Product != integreatlyv1alpha1.Product3Scale {
+			metric, threshold = "keycloak_request_duration", float64(slo.LatencyThreshold.Milliseconds())
+		}
+		le := strings.ReplaceAll(strconv.FormatFloat(threshold, 'f', -1, 64), ".", `\\.`)
+		return fmt.Sprintf(`1 - (
+  sum(rate(%[1]s_bucket{namespace=%[2]q, le=~"%[3]s(\\.0)?"}[%[4]s]))
+  / sum(rate(%[1]s_count{namespace=%[2]q}[%[4]s]))
+)`, metric, namespace, le, window), nil
+	default:
+		return "", fmt.Errorf("unsupported indicator %s of slo %s", slo.Indicator, slo.Name)
+	}
+}
+
+// sloAlerts returns the recording rules and the burn rate alerts of the SLOs
+// of the installation, a PrometheusRule per SLO
+func (r *Reconciler) sloAlerts(namespace, installationName string) ([]resources.AlertConfiguration, error) {
+	var alerts []resources.AlertConfiguration
+	for _, slo := range r.installation.Spec.SLOs {
+		budget, err := sloErrorBudget(slo)
+		if err != nil {
+			return nil, err
+		}
+		labels := map[string]string{"slo": slo.Name}
+		selector := fmt.Sprintf("{slo=%q}", slo.Name)
+
+		var rules []monv1.Rule
+		windows := append(append([]string{}, sloRateWindows...), sloWindow(slo))
+		for _, window := range windows {
+			expr, err := sloErrorRatioExpr(r.installation, slo, window)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, monv1.Rule{Record: sloErrorRatioRecord + window, Expr: intstr.FromString(expr), Labels: labels})
+		}
+		rules = append(rules, monv1.Rule{
+			Record: "slo:error_budget:ratio",
+			Expr:   intstr.FromString(fmt.Sprintf("vector(%g)", budget)),
+			Labels: labels,
+		})
+
+		for _, alert := range sloBurnRateAlerts {
+			threshold := fmt.Sprintf("(%g * %g)", alert.BurnRate, budget)
+			rules = append(rules, monv1.Rule{
+				Alert: alert.Name,
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": fmt.Sprintf("The %s SLO is burning its %s error budget %gx faster than allowed over the last %s and %s.", slo.Name, sloWindow(slo), alert.BurnRate, alert.LongWindow, alert.ShortWindow),
+				},
+				Expr: intstr.FromString(fmt.Sprintf("%[1]s%[2]s%[3]s > %[5]s\nand\n%[1]s%[4]s%[3]s > %[5]s",
+					sloErrorRatioRecord, alert.LongWindow, selector, alert.ShortWindow, threshold)),
+				For:    alert.For,
+				Labels: map[string]string{"severity": alert.Severity, "slo": slo.Name, "product": installationName},
+			})
+		}
+
+		alerts = append(alerts, resources.AlertConfiguration{
+			AlertName: sloAlertNamePrefix + slo.Name,
+			GroupName: fmt.Sprintf("slo-%s.rules", slo.Name),
+			Namespace: namespace,
+			Rules:     rules,
+		})
+	}
+	return alerts, nil
+}
+
+// reconcileSLOs creates a Grafana dashboard per SLO of the installation, and
+// removes the dashboards and the rules of the SLOs removed from the spec
+func (r *Reconciler) reconcileSLOs(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	slos := map[string]bool{}
+	for _, slo := range r.installation.Spec.SLOs {
+		slos[slo.Name] = true
+
+		dashboardJSON, err := getSLODashboardJSON(slo)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		grafanaDB := &grafanav1alpha1.GrafanaDashboard{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sloDashboardPrefix + slo.Name,
+				Namespace: r.Config.GetOperatorNamespace(),
+			},
+		}
+		opRes, err := controllerutil.CreateOrUpdate(ctx, serverClient, grafanaDB, func() error {
+			grafanaDB.Labels = map[string]string{
+				"monitoring-key": "customer",
+				sloLabel:         slo.Name,
+			}
+			grafanaDB.Spec = grafanav1alpha1.GrafanaDashboardSpec{Json: dashboardJSON}
+			return nil
+		})
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile slo %s dashboard: %w", slo.Name, err)
+		}
+		if opRes != controllerutil.OperationResultNone {
+			r.log.Infof("Operation result grafana dashboard", l.Fields{"grafanaDashboard": grafanaDB.Name, "result": opRes})
+		}
+	}
+
+	dashboards := &grafanav1alpha1.GrafanaDashboardList{}
+	if err := serverClient.List(ctx, dashboards, k8sclient.InNamespace(r.Config.GetOperatorNamespace()), k8sclient.HasLabels{sloLabel}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list slo dashboards: %w", err)
+	}
+	for i := range dashboards.Items {
+		if slos[dashboards.Items[i].Labels[sloLabel]] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, &dashboards.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete slo dashboard %s: %w", dashboards.Items[i].Name, err)
+		}
+	}
+
+	rules := &monv1.PrometheusRuleList{}
+	if err := serverClient.List(ctx, rules, k8sclient.InNamespace(config.GetOboNamespace(r.installation.Namespace))); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list slo rules: %w", err)
+	}
+	for _, rule := range rules.Items {
+		if !strings.HasPrefix(rule.Name, sloAlertNamePrefix) || slos[strings.TrimPrefix(rule.Name, sloAlertNamePrefix)] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, rule); err != nil && !k8serr.IsNotFound(err) {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete slo rule %s: %w", rule.Name, err)
+		}
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// getSLODashboardJSON returns the dashboard of the SLO, showing its error
+// budget, its error ratio and how fast its error budget is burnt
+func getSLODashboardJSON(slo integreatlyv1alpha1.SLOSpec) (string, error) {
+	selector := fmt.Sprintf("{slo=%q}", slo.Name)
+	window := sloWindow(slo)
+	budget := "slo:error_budget:ratio" + selector
+
+	target := func(expr, legend string) map[string]interface{} {
+		return map[string]interface{}{"expr": expr, "legendFormat": legend}
+	}
+	panel := func(id int, title, panelType, unit string, x, y, w int, targets ...map[string]interface{}) map[string]interface{} {
+		for i := range targets {
+			targets[i]["refId"] = string(rune('A' + i))
+		}
+		return map[string]interface{}{
+			"id":          id,
+			"title":       title,
+			"type":        panelType,
+			"datasource":  "Prometheus",
+			"gridPos":     map[string]int{"h": 8, "w": w, "x": x, "y": y},
+			"fieldConfig": map[string]interface{}{"defaults": map[string]interface{}{"unit": unit}, "overrides": []interface{}{}},
+			"targets":     targets,
+		}
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         fmt.Sprintf("SLO / %s", slo.Name),
+		"uid":           sloDashboardPrefix + slo.Name,
+		"tags":          []string{"slo", string(slo.Product)},
+		"editable":      true,
+		"schemaVersion": 27,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-" + window, "to": "now"},
+		"panels": []interface{}{
+			panel(1, "Objective", "stat", "percentunit", 0, 0, 6,
+				target("1 - "+budget, "objective")),
+			panel(2, fmt.Sprintf("SLI over %s", window), "stat", "percentunit", 6, 0, 6,
+				target(fmt.Sprintf("1 - %s%s%s", sloErrorRatioRecord, window, selector), "sli")),
+			panel(3, "Error budget remaining", "stat", "percentunit", 12, 0, 12,
+				target(fmt.Sprintf("1 - %s%s%s / on(slo) %s", sloErrorRatioRecord, window, selector, budget), "remaining")),
+			panel(4, "Error ratio", "timeseries", "percentunit", 0, 8, 12,
+				target(sloErrorRatioRecord+"5m"+selector, "5m"),
+				target(sloErrorRatioRecord+"1h"+selector, "1h"),
+				target(budget, "error budget")),
+			panel(5, "Burn rate", "timeseries", "none", 12, 8, 12,
+				target(fmt.Sprintf("%s1h%s / on(slo) %s", sloErrorRatioRecord, selector, budget), "1h"),
+				target(fmt.Sprintf("%s6h%s / on(slo) %s", sloErrorRatioRecord, selector, budget), "6h"),
+				target(fmt.Sprintf("%s3d%s / on(slo) %s", sloErrorRatioRecord, selector, budget), "3d")),
+		},
+	}
+	dashboardJSON, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal slo %s dashboard: %w", slo.Name, err)
+	}
+	return string(dashboardJSON), nil
+}
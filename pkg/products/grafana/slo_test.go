@@ -0,0 +1,141 @@
+package grafana
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSLOErrorRatioExpr(t *testing.T) {
+	installation := basicInstallation()
+	installation.Spec.NamespacePrefix = "redhat-rhoam-"
+
+	tests := []struct {
+		name     string
+		slo      integreatlyv1alpha1.SLOSpec
+		wantPart string
+		wantErr  bool
+	}{
+		{
+			name:     "availability of the gateway routes of a tenant",
+			slo:      integreatlyv1alpha1.SLOSpec{Name: "tenant-a", Product: integreatlyv1alpha1.Product3Scale, Indicator: integreatlyv1alpha1.SLIAvailability, Route: "^zync-3scale-api-tenant-a.*"},
+			wantPart: `route=~"^zync-3scale-api-tenant-a.*", exported_namespace="redhat-rhoam-3scale", code="5xx"}[1h]`,
+		},
+		{
+			name:     "latency of the gateway in seconds",
+			slo:      integreatlyv1alpha1.SLOSpec{Name: "gateway-latency", Product: integreatlyv1alpha1.Product3Scale, Indicator: integreatlyv1alpha1.SLILatency, LatencyThreshold: &metav1.Duration{Duration: 250 * time.Millisecond}},
+			wantPart: `total_response_time_seconds_bucket{namespace="redhat-rhoam-3scale", le=~"0\\.25(\\.0)?"}[1h]`,
+		},
+		{
+			name:     "latency of the SSO instance in milliseconds",
+			slo:      integreatlyv1alpha1.SLOSpec{Name: "sso-latency", Product: integreatlyv1alpha1.ProductRHSSOUser, Indicator: integreatlyv1alpha1.SLILatency, LatencyThreshold: &metav1.Duration{Duration: time.Second}},
+			wantPart: `keycloak_request_duration_bucket{namespace="redhat-rhoam-user-sso", le=~"1000(\\.0)?"}[1h]`,
+		},
+		{
+			name:    "unsupported product",
+			slo:     integreatlyv1alpha1.SLOSpec{Name: "grafana", Product: integreatlyv1alpha1.ProductGrafana, Indicator: integreatlyv1alpha1.SLIAvailability},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := sloErrorRatioExpr(installation, tt.slo, "1h")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sloErrorRatioExpr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !strings.Contains(expr, tt.wantPart) {
+				t.Errorf("expected the expression to contain %s, got %s", tt.wantPart, expr)
+			}
+		})
+	}
+}
+
+func TestReconciler_sloAlerts(t *testing.T) {
+	reconciler := getBasicReconciler()
+	reconciler.installation.Spec.SLOs = []integreatlyv1alpha1.SLOSpec{
+		{Name: "gateway-availability", Product: integreatlyv1alpha1.Product3Scale, Indicator: integreatlyv1alpha1.SLIAvailability, Objective: "99.9", Window: "30d"},
+	}
+
+	alerts, err := reconciler.sloAlerts("redhat-rhoam-customer-monitoring", "rhoam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].AlertName != "customer-monitoring-slo-gateway-availability" {
+		t.Fatalf("expected a rule of the SLO, got %v", alerts)
+	}
+
+	records, fastBurn := map[string]bool{}, ""
+	for _, rule := range alerts[0].Rules.([]monv1.Rule) {
+		records[rule.Record] = rule.Record != ""
+		if rule.Alert == "SLOErrorBudgetBurn1hto5m" {
+			fastBurn = rule.Expr.String()
+		}
+	}
+	for _, record := range []string{"slo:sli_error:ratio_rate5m", "slo:sli_error:ratio_rate3d", "slo:sli_error:ratio_rate30d", "slo:error_budget:ratio"} {
+		if !records[record] {
+			t.Errorf("expected the %s recording rule, got %v", record, records)
+		}
+	}
+	if !strings.Contains(fastBurn, `slo:sli_error:ratio_rate1h{slo="gateway-availability"} > (14.4 * 0.001)`) {
+		t.Errorf("expected the fast burn alert to compare the 1h error ratio to 14.4 times the error budget, got %s", fastBurn)
+	}
+
+	reconciler.installation.Spec.SLOs[0].Objective = "high"
+	if _, err := reconciler.sloAlerts("redhat-rhoam-customer-monitoring", "rhoam"); err == nil {
+		t.Error("expected an error for an invalid objective")
+	}
+}
+
+func TestReconciler_reconcileSLOs(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const operatorNamespace = "customer-monitoring-operator"
+	oboNamespace := config.GetOboNamespace(defaultInstallationNamespace)
+	staleDashboard := &grafanav1alpha1.GrafanaDashboard{ObjectMeta: metav1.ObjectMeta{
+		Name: "slo-removed", Namespace: operatorNamespace, Labels: map[string]string{sloLabel: "removed"},
+	}}
+	staleRule := &monv1.PrometheusRule{ObjectMeta: metav1.ObjectMeta{Name: "customer-monitoring-slo-removed", Namespace: oboNamespace}}
+	otherRule := &monv1.PrometheusRule{ObjectMeta: metav1.ObjectMeta{Name: "customer-monitoring-ksm-grafana-alerts", Namespace: oboNamespace}}
+	serverClient := utils.NewTestClient(scheme, staleDashboard, staleRule, otherRule)
+
+	reconciler := getBasicReconciler()
+	reconciler.Config = config.NewGrafana(config.ProductConfig{"OPERATOR_NAMESPACE": operatorNamespace})
+	reconciler.installation.Spec.SLOs = []integreatlyv1alpha1.SLOSpec{
+		{Name: "sso-availability", Product: integreatlyv1alpha1.ProductRHSSO, Indicator: integreatlyv1alpha1.SLIAvailability, Objective: "99"},
+	}
+
+	phase, err := reconciler.reconcileSLOs(context.TODO(), serverClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		t.Fatalf("reconcileSLOs() returned %v, %v", phase, err)
+	}
+
+	dashboard := &grafanav1alpha1.GrafanaDashboard{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: "slo-sso-availability", Namespace: operatorNamespace}, dashboard); err != nil {
+		t.Fatalf("expected the dashboard of the SLO, got %v", err)
+	}
+	if dashboard.Labels["monitoring-key"] != "customer" || !strings.Contains(dashboard.Spec.Json, `slo:error_budget:ratio{slo=\"sso-availability\"}`) {
+		t.Errorf("expected a customer dashboard of the SLO, got %v", dashboard)
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(staleDashboard), &grafanav1alpha1.GrafanaDashboard{}); !k8serr.IsNotFound(err) {
+		t.Errorf("expected the dashboard of the removed SLO to be deleted, got %v", err)
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(staleRule), &monv1.PrometheusRule{}); !k8serr.IsNotFound(err) {
+		t.Errorf("expected the rule of the removed SLO to be deleted, got %v", err)
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(otherRule), &monv1.PrometheusRule{}); err != nil {
+		t.Errorf("expected the other rules to be kept, got %v", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/config"
 	marin3rconfig "github.com/integr8ly/integreatly-operator/pkg/products/marin3r/config"
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
 	"gopkg.in/yaml.v2"
@@ -22,6 +24,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -224,7 +227,7 @@ func (r *RateLimitServiceReconciler) reconcileDeployment(ctx context.Context, cl
 			deployment.Spec.Template.Spec.Containers = []corev1.Container{{}}
 		}
 		deployment.Spec.Template.Spec.Containers[0].Name = quota.RateLimitName
-		deployment.Spec.Template.Spec.Containers[0].Image = rateLimitImage
+		deployment.Spec.Template.Spec.Containers[0].Image = fips.Image(r.Installation, rateLimitImage)
 		deployment.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
 			{
 				MountPath: "/srv/runtime_data/current/config",
@@ -283,6 +286,10 @@ func (r *RateLimitServiceReconciler) reconcileDeployment(ctx context.Context, cl
 			resources.AllMutationsOf(
 				resources.MutateZoneTopologySpreadConstraints("app"),
 				resources.MutateMultiAZAntiAffinity(ctx, client, "app"),
+				resources.MutateNodeArchitectureAffinity(ctx, client, integreatlyv1alpha1.ProductMarin3r),
+				resources.MutatePlacement(r.Installation.Spec.Placement),
+				podsecurity.Mutate(r.Installation),
+				fips.Mutate(r.Installation),
 			),
 			deployment,
 		); err != nil {
@@ -498,7 +505,12 @@ func (r *RateLimitServiceReconciler) getMultitenantRHOAMLimitadorSetting(ctx con
 		return nil, err
 	}
 
-	return []limitadorLimit{
+	tenantRateLimits, err := ratelimit.GetTenantRateLimits(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := []limitadorLimit{
 		{
 			Namespace: ratelimit.RateLimitDomain,
 			MaxValue:  r.RateLimitConfig.RequestsPerUnit,
@@ -521,7 +533,50 @@ func (r *RateLimitServiceReconciler) getMultitenantRHOAMLimitadorSetting(ctx con
 				headerKey,
 			},
 		},
-	}, nil
+	}
+	return append(limits, getTenantLimitadorLimits(tenantRateLimits, limitPerTenant, unitInSeconds)...), nil
+}
+
+// getTenantLimitadorLimits returns the limits of the tenants overriding the
+// limit per tenant. Their requests are matched by a distinct descriptor, so
+// the limit per tenant applies to them when they don't override it
+func getTenantLimitadorLimits(tenantRateLimits []ratelimit.TenantRateLimit, limitPerTenant uint32, unitInSeconds uint64) []limitadorLimit {
+	var limits []limitadorLimit
+	for _, tenantRateLimit := range tenantRateLimits {
+		conditions := []string{
+			fmt.Sprintf("%s == %s", headerMatch, ratelimit.TenantDescriptorValue),
+			fmt.Sprintf("%s == %s", headerKey, tenantRateLimit.Tenant),
+		}
+
+		if tenantRateLimit.RequestsPerSecond > 0 {
+			limits = append(limits, limitadorLimit{
+				Namespace:  ratelimit.RateLimitDomain,
+				MaxValue:   tenantRateLimit.RequestsPerSecond,
+				Seconds:    1,
+				Conditions: conditions,
+				Variables:  []string{headerKey},
+			})
+		} else {
+			limits = append(limits, limitadorLimit{
+				Namespace:  ratelimit.RateLimitDomain,
+				MaxValue:   limitPerTenant,
+				Seconds:    unitInSeconds,
+				Conditions: conditions,
+				Variables:  []string{headerKey},
+			})
+		}
+
+		if tenantRateLimit.DailyQuota > 0 {
+			limits = append(limits, limitadorLimit{
+				Namespace:  ratelimit.RateLimitDomain,
+				MaxValue:   tenantRateLimit.DailyQuota,
+				Seconds:    60 * 60 * 24,
+				Conditions: conditions,
+				Variables:  []string{headerKey},
+			})
+		}
+	}
+	return limits
 }
 
 func (r *RateLimitServiceReconciler) ensureLimits(ctx context.Context, client k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
@@ -623,11 +678,23 @@ func (r *RateLimitServiceReconciler) differentLimitSettings(redisLimits []limita
 	return !reflect.DeepEqual(redisLimits, currentLimits)
 }
 
+// sortByNamespaceAndMaxValue sorts the limits, the limits of the tenants with
+// the same max value are sorted by their period and conditions. The
+// conditions of a limit are sorted too as limitador doesn't keep their order
 func sortByNamespaceAndMaxValue(elems []limitadorLimit) {
+	for i := range elems {
+		sort.Strings(elems[i].Conditions)
+	}
 	sort.Slice(elems, func(i, j int) bool {
 		if elems[i].Namespace != elems[j].Namespace {
 			return elems[i].Namespace < elems[j].Namespace
 		}
-		return elems[i].MaxValue < elems[j].MaxValue
+		if elems[i].MaxValue != elems[j].MaxValue {
+			return elems[i].MaxValue < elems[j].MaxValue
+		}
+		if elems[i].Seconds != elems[j].Seconds {
+			return elems[i].Seconds < elems[j].Seconds
+		}
+		return strings.Join(elems[i].Conditions, ",") < strings.Join(elems[j].Conditions, ",")
 	})
 }
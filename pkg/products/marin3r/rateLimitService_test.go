@@ -441,6 +441,36 @@ func TestRateLimitServiceReconciler_differentLimitSettings(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "test slices are sorted by Conditions if matching MaxValue",
+			args: args{
+				redisLimits: []limitadorLimit{
+					{
+						Namespace:  "test",
+						MaxValue:   1,
+						Conditions: []string{"tenant == b", "header_match == per-tenant-limit"},
+					},
+					{
+						Namespace:  "test",
+						MaxValue:   1,
+						Conditions: []string{"header_match == per-tenant-limit", "tenant == a"},
+					},
+				},
+				currentLimits: []limitadorLimit{
+					{
+						Namespace:  "test",
+						MaxValue:   1,
+						Conditions: []string{"header_match == per-tenant-limit", "tenant == a"},
+					},
+					{
+						Namespace:  "test",
+						MaxValue:   1,
+						Conditions: []string{"header_match == per-tenant-limit", "tenant == b"},
+					},
+				},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -566,6 +596,105 @@ func TestRateLimitServiceReconciler_getLimitadorSetting(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test get rhoam multitenant limitator config with tenant overrides",
+			args: args{
+				ctx: context.TODO(),
+				client: utils.NewTestClient(scheme,
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      multitenantLimitConfigMap,
+							Namespace: "test",
+						},
+						Data: map[string]string{
+							multitenantRateLimit: "10",
+						},
+					},
+					&integreatlyv1alpha1.APIManagementTenant{
+						ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "tenant-a-dev"},
+						Spec: integreatlyv1alpha1.APIManagementTenantSpec{
+							RateLimit: &integreatlyv1alpha1.TenantRateLimitSpec{RequestsPerSecond: 5, DailyQuota: 1000},
+						},
+						Status: integreatlyv1alpha1.APIManagementTenantStatus{Phase: integreatlyv1alpha1.TenantPhaseReady},
+					},
+					&integreatlyv1alpha1.APIManagementTenant{
+						ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "tenant-b-stage"},
+						Spec: integreatlyv1alpha1.APIManagementTenantSpec{
+							RateLimit: &integreatlyv1alpha1.TenantRateLimitSpec{DailyQuota: 2000},
+						},
+						Status: integreatlyv1alpha1.APIManagementTenantStatus{Phase: integreatlyv1alpha1.TenantPhaseProvisioning},
+					},
+					&integreatlyv1alpha1.APIManagementTenant{
+						ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "tenant-c-dev"},
+						Spec: integreatlyv1alpha1.APIManagementTenantSpec{
+							RateLimit: &integreatlyv1alpha1.TenantRateLimitSpec{RequestsPerSecond: 5},
+						},
+						Status: integreatlyv1alpha1.APIManagementTenantStatus{ProvisioningStatus: integreatlyv1alpha1.WontProvisionTenant},
+					},
+				),
+			},
+			fields: fields{
+				Namespace: "test",
+				Installation: &integreatlyv1alpha1.RHMI{
+					Spec: integreatlyv1alpha1.RHMISpec{
+						Type: string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+					},
+				},
+				RateLimitConfig: marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 1},
+			},
+			want: []limitadorLimit{
+				{
+					Namespace: ratelimit.RateLimitDomain,
+					MaxValue:  1,
+					Seconds:   60,
+					Conditions: []string{
+						fmt.Sprintf("%s == %s", genericKey, ratelimit.RateLimitDescriptorValue),
+					},
+					Variables: []string{
+						genericKey,
+					},
+				},
+				{
+					Namespace: ratelimit.RateLimitDomain,
+					MaxValue:  10,
+					Seconds:   60,
+					Conditions: []string{
+						fmt.Sprintf("%s == %s", headerMatch, multitenantDescriptorValue),
+					},
+					Variables: []string{
+						headerKey,
+					},
+				},
+				{
+					Namespace:  ratelimit.RateLimitDomain,
+					MaxValue:   5,
+					Seconds:    1,
+					Conditions: []string{"header_match == per-tenant-limit", "tenant == tenant-a"},
+					Variables:  []string{headerKey},
+				},
+				{
+					Namespace:  ratelimit.RateLimitDomain,
+					MaxValue:   1000,
+					Seconds:    60 * 60 * 24,
+					Conditions: []string{"header_match == per-tenant-limit", "tenant == tenant-a"},
+					Variables:  []string{headerKey},
+				},
+				{
+					Namespace:  ratelimit.RateLimitDomain,
+					MaxValue:   10,
+					Seconds:    60,
+					Conditions: []string{"header_match == per-tenant-limit", "tenant == tenant-b"},
+					Variables:  []string{headerKey},
+				},
+				{
+					Namespace:  ratelimit.RateLimitDomain,
+					MaxValue:   2000,
+					Seconds:    60 * 60 * 24,
+					Conditions: []string{"header_match == per-tenant-limit", "tenant == tenant-b"},
+					Variables:  []string{headerKey},
+				},
+			},
+		},
 		{
 			name: "test error get rhoam multitenant limitator config",
 			args: args{
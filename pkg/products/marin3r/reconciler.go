@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/integr8ly/integreatly-operator/pkg/products/grafana"
 
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
@@ -70,18 +71,14 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		return nil, fmt.Errorf("no product declaration found for marin3r")
 	}
 
-	ns := installation.Spec.NamespacePrefix + defaultInstallationNamespace
+	ns := installation.ProductNamespace(defaultInstallationNamespace)
 	productConfig, err := configManager.ReadMarin3r()
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve threescale config: %w", err)
 	}
 
 	productConfig.SetNamespace(ns)
-	if installation.Spec.OperatorsInProductNamespace {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace())
-	} else {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace() + "-operator")
-	}
+	productConfig.SetOperatorNamespace(installation.OperatorNamespace(productConfig.GetNamespace()))
 
 	if err := configManager.WriteConfig(productConfig); err != nil {
 		return nil, fmt.Errorf("error writing marin3r config : %w", err)
@@ -174,7 +171,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
-	phase, err = r.reconcileSubscription(ctx, client, operatorNamespace, operatorNamespace)
+	phase, err = r.reconcileSubscription(ctx, client, productStatus, operatorNamespace, operatorNamespace)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.recorder, installation, phase, fmt.Sprintf("Failed to reconcile %s subscription", constants.CloudResourceSubscriptionName), err)
 		return phase, err
@@ -226,7 +223,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, nil
 	}
 
-	phase, err = NewRateLimitServiceReconciler(r.RateLimitConfig, installation, productNamespace, externalRedisSecretName, resources.NewPodExecutor(r.log), r.ConfigManager).
+	phase, err = NewRateLimitServiceReconciler(r.RateLimitConfig, installation, productNamespace, externalRedisSecretName, resources.NewInstallationPodExecutor(installation, r.log), r.ConfigManager).
 		ReconcileRateLimitService(ctx, client, productConfig)
 	if err != nil {
 		events.HandleError(r.recorder, installation, phase, "Failed to reconcile rate limit service", err)
@@ -268,13 +265,19 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 	productStatus.Host = r.Config.GetHost()
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
+	productStatus.FIPS = fips.ProductStatus(installation, rateLimitImage, discoveryServiceImage())
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Info("Installation successful")
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
 func (r *Reconciler) reconcileAlerts(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI, namespace string) (integreatlyv1alpha1.StatusPhase, error) {
+	// The alerts link to the rate limiting dashboard, there's none to link to
+	// when Grafana is disabled
+	if installation.IsProductDisabled(integreatlyv1alpha1.ProductGrafana) {
+		return r.reconcileAlertsWithDashboard(ctx, client, installation, namespace, "")
+	}
 
 	grafanaConsoleURL, err := grafana.GetGrafanaConsoleURL(ctx, client, installation)
 	if err != nil {
@@ -296,6 +299,10 @@ func (r *Reconciler) reconcileAlerts(ctx context.Context, client k8sclient.Clien
 	}
 
 	grafanaDashboardURL := fmt.Sprintf("%s/d/66ab72e0d012aacf34f907be9d81cd9e/rate-limiting", grafanaConsoleURL)
+	return r.reconcileAlertsWithDashboard(ctx, client, installation, namespace, grafanaDashboardURL)
+}
+
+func (r *Reconciler) reconcileAlertsWithDashboard(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI, namespace, grafanaDashboardURL string) (integreatlyv1alpha1.StatusPhase, error) {
 	alertReconciler, err := r.newAlertsReconciler(grafanaDashboardURL, namespace)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, err
@@ -388,7 +395,7 @@ func (r *Reconciler) reconcileDiscoveryService(ctx context.Context, client k8scl
 	}
 
 	_, err = controllerutil.CreateOrUpdate(ctx, client, discoveryService, func() error {
-		image := fmt.Sprintf("quay.io/3scale/marin3r:v%s", integreatlyv1alpha1.VersionMarin3r)
+		image := fips.Image(r.installation, discoveryServiceImage())
 		discoveryService.Spec.Image = &image
 		return nil
 	})
@@ -401,6 +408,10 @@ func (r *Reconciler) reconcileDiscoveryService(ctx context.Context, client k8scl
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
+func discoveryServiceImage() string {
+	return fmt.Sprintf("quay.io/3scale/marin3r:v%s", integreatlyv1alpha1.VersionMarin3r)
+}
+
 func (r *Reconciler) deleteDiscoveryService(ctx context.Context, client k8sclient.Client) error {
 	threescaleConfig, err := r.ConfigManager.ReadThreeScale()
 	if err != nil {
@@ -422,7 +433,7 @@ func (r *Reconciler) deleteDiscoveryService(ctx context.Context, client k8sclien
 	return client.Delete(ctx, discoveryService)
 }
 
-func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8sclient.Client, productNamespace string, operatorNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
+func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8sclient.Client, productStatus *integreatlyv1alpha1.RHMIProductStatus, productNamespace string, operatorNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
 	target := marketplace.Target{
 		SubscriptionName: constants.Marin3rSubscriptionName,
 		Namespace:        operatorNamespace,
@@ -442,22 +453,26 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8s
 		ctx,
 		target,
 		[]string{},
-		r.preUpgradeBackupExecutor(),
+		r.preUpgradeBackupExecutor(productStatus),
 		serverClient,
 		catalogSourceReconciler,
 		r.log,
 	)
 }
 
-func (r *Reconciler) preUpgradeBackupExecutor() backup.BackupExecutor {
+func (r *Reconciler) preUpgradeBackupExecutor(productStatus *integreatlyv1alpha1.RHMIProductStatus) backup.BackupExecutor {
 	if r.installation.Spec.UseClusterStorage != "false" {
 		return backup.NewNoopBackupExecutor()
 	}
 
-	return backup.NewAWSBackupExecutor(
-		r.installation.Namespace,
-		fmt.Sprintf("%s%s", constants.RateLimitRedisPrefix, r.installation.Name),
-		backup.RedisSnapshotType,
+	return backup.NewPreUpgradeHook(
+		backup.NewAWSBackupExecutor(
+			r.installation.Namespace,
+			fmt.Sprintf("%s%s", constants.RateLimitRedisPrefix, r.installation.Name),
+			backup.RedisSnapshotType,
+		),
+		r.installation,
+		productStatus,
 	)
 }
 
@@ -490,6 +505,7 @@ func (r *Reconciler) reconcileServiceMonitor(ctx context.Context, client k8sclie
 				},
 			},
 		}
+		resources.SetMetricRelabelConfigs(serviceMonitor.Spec.Endpoints, r.Config.GetProductName(), r.installation.Spec.Type)
 		return nil
 	})
 
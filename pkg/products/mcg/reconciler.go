@@ -63,17 +63,13 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		return nil, fmt.Errorf("could not retrieve mcg config: %w", err)
 	}
 	if mcgConfig.GetNamespace() == "" {
-		mcgConfig.SetNamespace(installation.Spec.NamespacePrefix + DefaultInstallationNamespace)
+		mcgConfig.SetNamespace(installation.ProductNamespace(DefaultInstallationNamespace))
 		if err := configManager.WriteConfig(mcgConfig); err != nil {
 			return nil, fmt.Errorf("error writing mcg config : %w", err)
 		}
 	}
 	if mcgConfig.GetOperatorNamespace() == "" {
-		if installation.Spec.OperatorsInProductNamespace {
-			mcgConfig.SetOperatorNamespace(mcgConfig.GetNamespace())
-		} else {
-			mcgConfig.SetOperatorNamespace(mcgConfig.GetNamespace() + "-operator")
-		}
+		mcgConfig.SetOperatorNamespace(installation.OperatorNamespace(mcgConfig.GetNamespace()))
 	}
 
 	return &Reconciler{
@@ -169,7 +165,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Infof("Installation reconciled successfully", l.Fields{"productStatus": r.Config.GetProductName()})
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
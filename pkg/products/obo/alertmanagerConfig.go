@@ -189,6 +189,10 @@ func ReconcileAlertManagerSecrets(ctx context.Context, serverClient k8sclient.Cl
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("could not parse alert manager configuration template: %w", err)
 	}
+	configSecretData, err = applyCustomerAlerting(ctx, serverClient, installation, configSecretData, `{{template "email.integreatly.subject" . }}`, `{{ template "email.integreatly.html" . }}`)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("could not apply the alerting spec to the alert manager configuration: %w", err)
+	}
 	configSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      config.AlertManagerConfigSecretName,
@@ -0,0 +1,167 @@
+package obo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// customerReceiverPrefix keeps the receivers of the alerting spec apart
+	// from the Red Hat SRE ones
+	customerReceiverPrefix = "customer-"
+
+	heartbeatReceiver        = customerReceiverPrefix + "heartbeat"
+	defaultHeartbeatInterval = "5m"
+)
+
+// applyCustomerAlerting adds the receivers, routes and heartbeat of the
+// alerting spec of the installation to the Alertmanager configuration. The
+// customer routes are matched first and continue, so the alerts keep reaching
+// the SRE receivers
+func applyCustomerAlerting(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, configData []byte, subject, html string) ([]byte, error) {
+	alerting := installation.Spec.Alerting
+	if alerting == nil || (len(alerting.Receivers) == 0 && alerting.Heartbeat == nil) {
+		return configData, nil
+	}
+
+	alertmanagerConfig := map[string]interface{}{}
+	if err := yaml.Unmarshal(configData, &alertmanagerConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse alertmanager configuration: %w", err)
+	}
+
+	receivers, _ := alertmanagerConfig["receivers"].([]interface{})
+	for _, spec := range alerting.Receivers {
+		receiver, err := customerReceiver(ctx, serverClient, installation.Namespace, spec, subject, html)
+		if err != nil {
+			return nil, err
+		}
+		receivers = append(receivers, receiver)
+	}
+
+	var heartbeatRoute map[string]interface{}
+	if alerting.Heartbeat != nil {
+		receiver, err := customerReceiver(ctx, serverClient, installation.Namespace, integreatlyv1alpha1.AlertReceiverSpec{
+			Name:    strings.TrimPrefix(heartbeatReceiver, customerReceiverPrefix),
+			Webhook: &integreatlyv1alpha1.WebhookReceiverSpec{URLSecret: alerting.Heartbeat.URLSecret},
+		}, subject, html)
+		if err != nil {
+			return nil, err
+		}
+		receivers = append(receivers, receiver)
+
+		interval := alerting.Heartbeat.Interval
+		if interval == "" {
+			interval = defaultHeartbeatInterval
+		}
+		heartbeatRoute = map[string]interface{}{
+			"match":           map[string]interface{}{"alertname": "DeadMansSwitch"},
+			"receiver":        heartbeatReceiver,
+			"repeat_interval": interval,
+			"continue":        true,
+		}
+	}
+	alertmanagerConfig["receivers"] = receivers
+
+	route, _ := alertmanagerConfig["route"].(map[string]interface{})
+	if route == nil {
+		route = map[string]interface{}{}
+	}
+	existingRoutes, _ := route["routes"].([]interface{})
+	routes := make([]interface{}, 0, len(alerting.Routes)+len(existingRoutes)+1)
+	if heartbeatRoute != nil {
+		routes = append(routes, heartbeatRoute)
+	}
+	for _, spec := range alerting.Routes {
+		routes = append(routes, map[string]interface{}{
+			"match":    map[string]interface{}{"severity": spec.Severity},
+			"receiver": customerReceiverPrefix + spec.Receiver,
+			"continue": true,
+		})
+	}
+	route["routes"] = append(routes, existingRoutes...)
+	alertmanagerConfig["route"] = route
+
+	return yaml.Marshal(alertmanagerConfig)
+}
+
+func customerReceiver(ctx context.Context, serverClient k8sclient.Client, namespace string, spec integreatlyv1alpha1.AlertReceiverSpec, subject, html string) (map[string]interface{}, error) {
+	receiver := map[string]interface{}{"name": customerReceiverPrefix + spec.Name}
+	secretValue := func(selector corev1.SecretKeySelector) (string, error) {
+		secret := &corev1.Secret{}
+		if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+			return "", fmt.Errorf("failed to get secret %s of alert receiver %s: %w", selector.Name, spec.Name, err)
+		}
+		value := string(secret.Data[selector.Key])
+		if value == "" {
+			return "", fmt.Errorf("secret %s of alert receiver %s has no %s key", selector.Name, spec.Name, selector.Key)
+		}
+		return value, nil
+	}
+
+	if spec.PagerDuty != nil {
+		routingKey, err := secretValue(spec.PagerDuty.RoutingKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		receiver["pagerduty_configs"] = []interface{}{map[string]interface{}{
+			"routing_key":   routingKey,
+			"description":   subject,
+			"send_resolved": true,
+		}}
+	}
+	if spec.Slack != nil {
+		webhookURL, err := secretValue(spec.Slack.WebhookURLSecret)
+		if err != nil {
+			return nil, err
+		}
+		slackConfig := map[string]interface{}{
+			"api_url":       webhookURL,
+			"title":         subject,
+			"send_resolved": true,
+		}
+		if spec.Slack.Channel != "" {
+			slackConfig["channel"] = spec.Slack.Channel
+		}
+		receiver["slack_configs"] = []interface{}{slackConfig}
+	}
+	if spec.OpsGenie != nil {
+		apiKey, err := secretValue(spec.OpsGenie.APIKeySecret)
+		if err != nil {
+			return nil, err
+		}
+		opsGenieConfig := map[string]interface{}{
+			"api_key":       apiKey,
+			"message":       subject,
+			"send_resolved": true,
+		}
+		if spec.OpsGenie.APIURL != "" {
+			opsGenieConfig["api_url"] = spec.OpsGenie.APIURL
+		}
+		receiver["opsgenie_configs"] = []interface{}{opsGenieConfig}
+	}
+	if spec.Webhook != nil {
+		url, err := secretValue(spec.Webhook.URLSecret)
+		if err != nil {
+			return nil, err
+		}
+		receiver["webhook_configs"] = []interface{}{map[string]interface{}{
+			"url":           url,
+			"send_resolved": true,
+		}}
+	}
+	if spec.Email != nil {
+		receiver["email_configs"] = []interface{}{map[string]interface{}{
+			"to":            strings.Join(spec.Email.To, ", "),
+			"headers":       map[string]interface{}{"Subject": subject},
+			"html":          html,
+			"send_resolved": true,
+		}}
+	}
+	return receiver, nil
+}
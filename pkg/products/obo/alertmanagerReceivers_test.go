@@ -0,0 +1,137 @@
+package obo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyCustomerAlerting(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	baseConfig := []byte(`
+route:
+  receiver: default
+  routes:
+    - match:
+        severity: critical
+      receiver: critical
+receivers:
+  - name: default
+  - name: critical
+`)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "alerting", Namespace: namespace},
+		Data: map[string][]byte{
+			"pagerduty": []byte("routing-key"),
+			"slack":     []byte("https://hooks.slack.com/services/T0/B0/X"),
+		},
+	}
+	selector := func(key string) corev1.SecretKeySelector {
+		return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "alerting"}, Key: key}
+	}
+
+	tests := []struct {
+		name          string
+		alerting      *integreatlyv1alpha1.AlertingSpec
+		wantErr       string
+		wantReceivers []string
+		wantRoutes    []string
+	}{
+		{
+			name:          "keeps the configuration without alerting spec",
+			wantReceivers: []string{"default", "critical"},
+			wantRoutes:    []string{"critical"},
+		},
+		{
+			name: "routes the alerts to the customer receivers before the SRE ones",
+			alerting: &integreatlyv1alpha1.AlertingSpec{
+				Receivers: []integreatlyv1alpha1.AlertReceiverSpec{
+					{Name: "oncall", PagerDuty: &integreatlyv1alpha1.PagerDutyReceiverSpec{RoutingKeySecret: selector("pagerduty")}},
+					{Name: "team", Slack: &integreatlyv1alpha1.SlackReceiverSpec{WebhookURLSecret: selector("slack"), Channel: "#alerts"}, Email: &integreatlyv1alpha1.EmailReceiverSpec{To: []string{"team@example.com"}}},
+				},
+				Routes: []integreatlyv1alpha1.AlertRouteSpec{
+					{Severity: "critical", Receiver: "oncall"},
+					{Severity: "warning", Receiver: "team"},
+				},
+			},
+			wantReceivers: []string{"default", "critical", "customer-oncall", "customer-team"},
+			wantRoutes:    []string{"customer-oncall", "customer-team", "critical"},
+		},
+		{
+			name: "pings the heartbeat with the DeadMansSwitch alert",
+			alerting: &integreatlyv1alpha1.AlertingSpec{
+				Heartbeat: &integreatlyv1alpha1.HeartbeatSpec{URLSecret: selector("slack")},
+			},
+			wantReceivers: []string{"default", "critical", "customer-heartbeat"},
+			wantRoutes:    []string{"customer-heartbeat", "critical"},
+		},
+		{
+			name: "fails when a receiver secret key is missing",
+			alerting: &integreatlyv1alpha1.AlertingSpec{
+				Receivers: []integreatlyv1alpha1.AlertReceiverSpec{
+					{Name: "hook", Webhook: &integreatlyv1alpha1.WebhookReceiverSpec{URLSecret: selector("webhook")}},
+				},
+			},
+			wantErr: "has no webhook key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       integreatlyv1alpha1.RHMISpec{Alerting: tt.alerting},
+			}
+
+			configData, err := applyCustomerAlerting(context.TODO(), utils.NewTestClient(scheme, secret), installation, baseConfig, "subject", "html")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parsed := struct {
+				Route struct {
+					Routes []struct {
+						Receiver string `json:"receiver"`
+						Continue bool   `json:"continue"`
+					} `json:"routes"`
+				} `json:"route"`
+				Receivers []map[string]interface{} `json:"receivers"`
+			}{}
+			if err := yaml.Unmarshal(configData, &parsed); err != nil {
+				t.Fatal(err)
+			}
+			var receivers, routes []string
+			for _, receiver := range parsed.Receivers {
+				receivers = append(receivers, receiver["name"].(string))
+			}
+			for _, route := range parsed.Route.Routes {
+				routes = append(routes, route.Receiver)
+				if strings.HasPrefix(route.Receiver, customerReceiverPrefix) && !route.Continue {
+					t.Errorf("expected the customer route to %s to continue", route.Receiver)
+				}
+			}
+			if strings.Join(receivers, ",") != strings.Join(tt.wantReceivers, ",") {
+				t.Errorf("expected receivers %v, got %v", tt.wantReceivers, receivers)
+			}
+			if strings.Join(routes, ",") != strings.Join(tt.wantRoutes, ",") {
+				t.Errorf("expected routes %v, got %v", tt.wantRoutes, routes)
+			}
+		})
+	}
+}
@@ -50,6 +50,57 @@ func OboAlertsReconciler(logger l.Logger, installation *integreatlyv1alpha1.RHMI
 				},
 			},
 		},
+		{
+			AlertName: "deadmansswitch-alerts",
+			GroupName: "general.rules",
+			Namespace: namespace,
+			Rules: []monv1.Rule{
+				{
+					Alert: "DeadMansSwitch",
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "Always firing alert, its heartbeat stops reaching the Dead Man's Switch receivers when the monitoring stack is down",
+					},
+					Expr:   intstr.FromString("vector(1)"),
+					Labels: map[string]string{"severity": "none", "product": installationName},
+				},
+			},
+		},
+		{
+			AlertName: "remote-write-alerts",
+			GroupName: "remote-write.rules",
+			Namespace: namespace,
+			Rules: []monv1.Rule{
+				{
+					Record: "rhoam:remote_write_lag_seconds",
+					Expr:   intstr.FromString(fmt.Sprintf("max by(remote_name, url) (prometheus_remote_storage_highest_timestamp_in_seconds - ignoring(remote_name, url) group_right() prometheus_remote_storage_queue_highest_sent_timestamp_seconds{remote_name=~'%s.*'})", remoteWritePrefix)),
+				},
+				{
+					Record: "rhoam:remote_write_failed_samples:ratio_rate5m",
+					Expr:   intstr.FromString(fmt.Sprintf("sum by(remote_name, url) (rate(prometheus_remote_storage_samples_failed_total{remote_name=~'%[1]s.*'}[5m])) / (sum by(remote_name, url) (rate(prometheus_remote_storage_samples_failed_total{remote_name=~'%[1]s.*'}[5m])) + sum by(remote_name, url) (rate(prometheus_remote_storage_samples_total{remote_name=~'%[1]s.*'}[5m])))", remoteWritePrefix)),
+				},
+				{
+					Alert: "RHOAMRemoteWriteBehind",
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "The remote write endpoint {{ $labels.remote_name }} is {{ $value }} seconds behind for longer than 15 minutes",
+					},
+					Expr:   intstr.FromString("rhoam:remote_write_lag_seconds > 600"),
+					For:    "15m",
+					Labels: map[string]string{"severity": "warning", "product": installationName},
+				},
+				{
+					Alert: "RHOAMRemoteWriteFailing",
+					Annotations: map[string]string{
+						"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+						"message": "The remote write endpoint {{ $labels.remote_name }} has been rejecting more than 10 percent of the samples for longer than 15 minutes",
+					},
+					Expr:   intstr.FromString("rhoam:remote_write_failed_samples:ratio_rate5m > 0.1"),
+					For:    "15m",
+					Labels: map[string]string{"severity": "warning", "product": installationName},
+				},
+			},
+		},
 		{
 			AlertName: "ksm-alerts",
 			Namespace: namespace,
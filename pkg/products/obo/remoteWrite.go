@@ -0,0 +1,187 @@
+package obo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// remoteWritePrefix keeps the remote write endpoints of the observability
+	// spec apart from the ones set by the monitoring stack
+	remoteWritePrefix = "customer-"
+	// remoteWriteSecretLabel marks the credentials copied to the monitoring
+	// namespace, so the ones of removed endpoints are deleted
+	remoteWriteSecretLabel = "integreatly.org/remote-write"
+
+	remoteWriteUsernameKey = "username"
+	remoteWritePasswordKey = "password"
+	remoteWriteTokenKey    = "token"
+)
+
+// ReconcileRemoteWrite renders the remote write endpoints of the observability
+// spec of the installation into the Prometheus instances of the monitoring
+// stack. Prometheus can only read the credentials from its own namespace, so
+// they're copied from the installation namespace
+func ReconcileRemoteWrite(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI) error {
+	namespace := config.GetOboNamespace(installation.Namespace)
+
+	var specs []integreatlyv1alpha1.RemoteWriteSpec
+	if installation.Spec.Observability != nil {
+		specs = installation.Spec.Observability.RemoteWrite
+	}
+
+	remoteWrites := make([]monv1.RemoteWriteSpec, 0, len(specs))
+	secrets := map[string]bool{}
+	for _, spec := range specs {
+		remoteWrite, secretName, err := reconcileRemoteWriteEndpoint(ctx, serverClient, installation, namespace, spec)
+		if err != nil {
+			return err
+		}
+		if secretName != "" {
+			secrets[secretName] = true
+		}
+		remoteWrites = append(remoteWrites, remoteWrite)
+	}
+
+	prometheuses := &monv1.PrometheusList{}
+	if err := serverClient.List(ctx, prometheuses, k8sclient.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list prometheus instances in %s: %w", namespace, err)
+	}
+	for _, prometheus := range prometheuses.Items {
+		desired := make([]monv1.RemoteWriteSpec, 0, len(prometheus.Spec.RemoteWrite)+len(remoteWrites))
+		for _, remoteWrite := range prometheus.Spec.RemoteWrite {
+			if !strings.HasPrefix(remoteWrite.Name, remoteWritePrefix) {
+				desired = append(desired, remoteWrite)
+			}
+		}
+		desired = append(desired, remoteWrites...)
+		if len(desired) == len(prometheus.Spec.RemoteWrite) && (len(desired) == 0 || equality.Semantic.DeepEqual(desired, prometheus.Spec.RemoteWrite)) {
+			continue
+		}
+
+		patch := k8sclient.MergeFrom(prometheus.DeepCopy())
+		prometheus.Spec.RemoteWrite = desired
+		if err := serverClient.Patch(ctx, prometheus, patch); err != nil {
+			return fmt.Errorf("failed to update remote write of prometheus %s: %w", prometheus.Name, err)
+		}
+	}
+
+	existing := &corev1.SecretList{}
+	if err := serverClient.List(ctx, existing, k8sclient.InNamespace(namespace), k8sclient.HasLabels{remoteWriteSecretLabel}); err != nil {
+		return fmt.Errorf("failed to list remote write secrets: %w", err)
+	}
+	for i := range existing.Items {
+		if secrets[existing.Items[i].Name] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, &existing.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete remote write secret %s: %w", existing.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcileRemoteWriteEndpoint copies the credentials of the endpoint to the
+// monitoring namespace and returns its Prometheus configuration
+func reconcileRemoteWriteEndpoint(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, namespace string, spec integreatlyv1alpha1.RemoteWriteSpec) (monv1.RemoteWriteSpec, string, error) {
+	remoteWrite := monv1.RemoteWriteSpec{
+		Name: remoteWritePrefix + spec.Name,
+		URL:  spec.URL,
+	}
+	if len(spec.MetricAllowlist) > 0 {
+		remoteWrite.WriteRelabelConfigs = append(remoteWrite.WriteRelabelConfigs, monv1.RelabelConfig{
+			SourceLabels: []monv1.LabelName{"__name__"},
+			Regex:        "(" + strings.Join(spec.MetricAllowlist, ")|(") + ")",
+			Action:       "keep",
+		})
+	}
+	for _, relabel := range spec.WriteRelabelConfigs {
+		sourceLabels := make([]monv1.LabelName, 0, len(relabel.SourceLabels))
+		for _, label := range relabel.SourceLabels {
+			sourceLabels = append(sourceLabels, monv1.LabelName(label))
+		}
+		remoteWrite.WriteRelabelConfigs = append(remoteWrite.WriteRelabelConfigs, monv1.RelabelConfig{
+			SourceLabels: sourceLabels,
+			Separator:    relabel.Separator,
+			TargetLabel:  relabel.TargetLabel,
+			Regex:        relabel.Regex,
+			Replacement:  relabel.Replacement,
+			Action:       relabel.Action,
+		})
+	}
+
+	data := map[string][]byte{}
+	if spec.BasicAuth != nil {
+		username, err := remoteWriteSecretValue(ctx, serverClient, installation.Namespace, spec.Name, spec.BasicAuth.UsernameSecret)
+		if err != nil {
+			return remoteWrite, "", err
+		}
+		password, err := remoteWriteSecretValue(ctx, serverClient, installation.Namespace, spec.Name, spec.BasicAuth.PasswordSecret)
+		if err != nil {
+			return remoteWrite, "", err
+		}
+		data[remoteWriteUsernameKey] = username
+		data[remoteWritePasswordKey] = password
+	}
+	if spec.BearerTokenSecret != nil {
+		token, err := remoteWriteSecretValue(ctx, serverClient, installation.Namespace, spec.Name, *spec.BearerTokenSecret)
+		if err != nil {
+			return remoteWrite, "", err
+		}
+		data[remoteWriteTokenKey] = token
+	}
+	if len(data) == 0 {
+		return remoteWrite, "", nil
+	}
+
+	secretName := "rhoam-remote-write-" + spec.Name
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[remoteWriteSecretLabel] = spec.Name
+		secret.Data = data
+		return nil
+	}); err != nil {
+		return remoteWrite, "", fmt.Errorf("failed to reconcile remote write secret %s: %w", secretName, err)
+	}
+
+	selector := func(key string) corev1.SecretKeySelector {
+		return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secretName}, Key: key}
+	}
+	if spec.BasicAuth != nil {
+		remoteWrite.BasicAuth = &monv1.BasicAuth{
+			Username: selector(remoteWriteUsernameKey),
+			Password: selector(remoteWritePasswordKey),
+		}
+	}
+	if spec.BearerTokenSecret != nil {
+		credentials := selector(remoteWriteTokenKey)
+		remoteWrite.Authorization = &monv1.Authorization{SafeAuthorization: monv1.SafeAuthorization{Credentials: &credentials}}
+	}
+	return remoteWrite, secretName, nil
+}
+
+func remoteWriteSecretValue(ctx context.Context, serverClient k8sclient.Client, namespace, endpoint string, selector corev1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s of remote write endpoint %s: %w", selector.Name, endpoint, err)
+	}
+	value := secret.Data[selector.Key]
+	if len(value) == 0 {
+		return nil, fmt.Errorf("secret %s of remote write endpoint %s has no %s key", selector.Name, endpoint, selector.Key)
+	}
+	return value, nil
+}
@@ -0,0 +1,136 @@
+package obo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileRemoteWrite(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	const oboNamespace = namespace + "-observability"
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "grafana-cloud", Namespace: namespace},
+		Data: map[string][]byte{
+			"username": []byte("123456"),
+			"password": []byte("glc_token"),
+		},
+	}
+	staleSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam-remote-write-thanos", Namespace: oboNamespace, Labels: map[string]string{remoteWriteSecretLabel: "thanos"}},
+	}
+	prometheus := &monv1.Prometheus{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: oboNamespace},
+		Spec: monv1.PrometheusSpec{
+			CommonPrometheusFields: monv1.CommonPrometheusFields{
+				RemoteWrite: []monv1.RemoteWriteSpec{
+					{Name: "observatorium", URL: "https://observatorium.example.com"},
+					{Name: "customer-thanos", URL: "https://thanos.example.com"},
+				},
+			},
+		},
+	}
+	selector := func(key string) corev1.SecretKeySelector {
+		return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "grafana-cloud"}, Key: key}
+	}
+
+	tests := []struct {
+		name             string
+		observability    *integreatlyv1alpha1.ObservabilitySpec
+		wantErr          string
+		wantRemoteWrites []string
+		wantSecret       bool
+	}{
+		{
+			name:             "removes the customer endpoints without observability spec",
+			wantRemoteWrites: []string{"observatorium"},
+		},
+		{
+			name: "adds the customer endpoints after the monitoring stack ones",
+			observability: &integreatlyv1alpha1.ObservabilitySpec{
+				RemoteWrite: []integreatlyv1alpha1.RemoteWriteSpec{
+					{
+						Name:            "grafana",
+						URL:             "https://prometheus.grafana.net/api/prom/push",
+						BasicAuth:       &integreatlyv1alpha1.RemoteWriteBasicAuth{UsernameSecret: selector("username"), PasswordSecret: selector("password")},
+						MetricAllowlist: []string{"threescale_.*", "keycloak_.*"},
+					},
+				},
+			},
+			wantRemoteWrites: []string{"observatorium", "customer-grafana"},
+			wantSecret:       true,
+		},
+		{
+			name: "fails when a credentials secret key is missing",
+			observability: &integreatlyv1alpha1.ObservabilitySpec{
+				RemoteWrite: []integreatlyv1alpha1.RemoteWriteSpec{
+					{Name: "datadog", URL: "https://api.datadoghq.com/api/v1/series", BearerTokenSecret: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "grafana-cloud"}, Key: "token"}},
+				},
+			},
+			wantErr: "has no token key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, credentials.DeepCopy(), staleSecret.DeepCopy(), prometheus.DeepCopy())
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       integreatlyv1alpha1.RHMISpec{Observability: tt.observability},
+			}
+
+			err := ReconcileRemoteWrite(context.TODO(), serverClient, installation)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updated := &monv1.Prometheus{}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(prometheus), updated); err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, remoteWrite := range updated.Spec.RemoteWrite {
+				names = append(names, remoteWrite.Name)
+				if remoteWrite.Name != "customer-grafana" {
+					continue
+				}
+				if remoteWrite.BasicAuth == nil || remoteWrite.BasicAuth.Password.Name != "rhoam-remote-write-grafana" {
+					t.Errorf("expected the credentials to be read from the copied secret, got %v", remoteWrite.BasicAuth)
+				}
+				if len(remoteWrite.WriteRelabelConfigs) != 1 || remoteWrite.WriteRelabelConfigs[0].Regex != "(threescale_.*)|(keycloak_.*)" {
+					t.Errorf("expected the allowlist to be kept, got %v", remoteWrite.WriteRelabelConfigs)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantRemoteWrites, ",") {
+				t.Errorf("expected remote writes %v, got %v", tt.wantRemoteWrites, names)
+			}
+
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(staleSecret), &corev1.Secret{}); !k8serr.IsNotFound(err) {
+				t.Errorf("expected the secret of the removed endpoint to be deleted, got %v", err)
+			}
+			copied := &corev1.Secret{}
+			err = serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: "rhoam-remote-write-grafana", Namespace: oboNamespace}, copied)
+			if tt.wantSecret && string(copied.Data[remoteWritePasswordKey]) != "glc_token" {
+				t.Errorf("expected the credentials to be copied, got %v", err)
+			}
+		})
+	}
+}
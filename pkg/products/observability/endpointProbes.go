@@ -0,0 +1,316 @@
+package observability
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	prometheusApi "github.com/prometheus/client_golang/api"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	endpointProberName  = "endpoint-prober"
+	endpointProberImage = "quay.io/prometheus/blackbox-exporter:v0.24.0"
+	endpointProberPort  = 9115
+
+	// EndpointProbeJob is the job of the metrics of the endpoint probes,
+	// probe_success and probe_duration_seconds
+	EndpointProbeJob = "rhoam-endpoint-probe"
+
+	endpointProbeLabel    = "integreatly.org/endpoint-probe"
+	endpointProbePrefix   = "endpoint-"
+	endpointProbeModule   = "http_2xx"
+	endpointProbeInterval = "30s"
+
+	prometheusServiceName = "rhoam-prometheus"
+)
+
+// endpointProberConfig only accepts a 2xx response over a verified TLS
+// connection, the customers reach the endpoints the same way
+const endpointProberConfig = `modules:
+  http_2xx:
+    prober: http
+    timeout: 10s
+    http:
+      preferred_ip_protocol: ip4
+      follow_redirects: true
+`
+
+// probedEndpoint is a customer facing endpoint of the installation
+type probedEndpoint struct {
+	name string
+	url  string
+}
+
+// reconcileEndpointProbes deploys a blackbox exporter in the monitoring
+// namespace and a Probe for each customer facing endpoint, so the RHOAM
+// Prometheus probes them from inside the cluster. The health of the
+// endpoints is then summarized in the status of the installation
+func (r *Reconciler) reconcileEndpointProbes(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	namespace := config.GetOboNamespace(r.installation.Namespace)
+
+	endpoints, err := r.probedEndpoints()
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	if err := r.reconcileEndpointProber(ctx, serverClient, namespace); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	probed := map[string]bool{}
+	for _, endpoint := range endpoints {
+		probe := &monv1.Probe{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      endpointProbePrefix + endpoint.name,
+				Namespace: namespace,
+			},
+		}
+		probed[probe.Name] = true
+		opRes, err := controllerutil.CreateOrUpdate(ctx, serverClient, probe, func() error {
+			probe.Labels = map[string]string{
+				config.GetOboLabelSelectorKey(): config.GetOboLabelSelector(),
+				endpointProbeLabel:              endpoint.name,
+			}
+			probe.Spec = monv1.ProbeSpec{
+				JobName:  EndpointProbeJob,
+				Module:   endpointProbeModule,
+				Interval: endpointProbeInterval,
+				ProberSpec: monv1.ProberSpec{
+					URL: fmt.Sprintf("%s.%s.svc:%d", endpointProberName, namespace, endpointProberPort),
+				},
+				Targets: monv1.ProbeTargets{
+					StaticConfig: &monv1.ProbeTargetStaticConfig{
+						Targets: []string{endpoint.url},
+						Labels:  map[string]string{"endpoint": endpoint.name},
+					},
+				},
+			}
+			return nil
+		})
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile probe of endpoint %s: %w", endpoint.name, err)
+		}
+		if opRes != controllerutil.OperationResultNone {
+			r.log.Infof("Operation result endpoint probe", l.Fields{"probe": probe.Name, "result": opRes})
+		}
+	}
+
+	// the endpoints of the products that were removed aren't probed anymore
+	probes := &monv1.ProbeList{}
+	if err := serverClient.List(ctx, probes, k8sclient.InNamespace(namespace), k8sclient.HasLabels{endpointProbeLabel}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list endpoint probes: %w", err)
+	}
+	for _, probe := range probes.Items {
+		if probed[probe.Name] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, probe); err != nil && !k8serr.IsNotFound(err) {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete endpoint probe %s: %w", probe.Name, err)
+		}
+	}
+
+	// the probes are scraped by Prometheus, not being able to query them
+	// shouldn't block the installation
+	health, err := queryEndpointHealth(ctx, serverClient, namespace, endpoints)
+	if err != nil {
+		r.log.Warning("Failed to query the health of the endpoints: " + err.Error())
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	r.installation.Status.EndpointHealth = health
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// probedEndpoints returns the customer facing endpoints of the products
+// installed so far. The apicast gateways are probed on their readiness
+// endpoint as their routes are only created for the customer products
+func (r *Reconciler) probedEndpoints() ([]probedEndpoint, error) {
+	var endpoints []probedEndpoint
+
+	threescaleConfig, err := r.ConfigManager.ReadThreeScale()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve threescale config: %w", err)
+	}
+	if threescaleConfig.GetHost() != "" {
+		endpoints = append(endpoints,
+			probedEndpoint{name: "3scale-admin", url: threescaleConfig.GetHost() + threescaleConfig.GetBlackboxTargetPathForAdminUI()},
+			probedEndpoint{name: "apicast-staging", url: fmt.Sprintf("http://apicast-staging.%s.svc:8090/status/ready", threescaleConfig.GetNamespace())},
+			probedEndpoint{name: "apicast-production", url: fmt.Sprintf("http://apicast-production.%s.svc:8090/status/ready", threescaleConfig.GetNamespace())},
+		)
+	}
+
+	rhssoConfig, err := r.ConfigManager.ReadRHSSO()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve rhsso config: %w", err)
+	}
+	if rhssoConfig.GetHost() != "" {
+		endpoints = append(endpoints, probedEndpoint{name: "rhsso", url: fmt.Sprintf("%s/auth/realms/%s", rhssoConfig.GetHost(), rhssoConfig.GetRealm())})
+	}
+
+	rhssoUserConfig, err := r.ConfigManager.ReadRHSSOUser()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve rhsso user config: %w", err)
+	}
+	if rhssoUserConfig.GetHost() != "" {
+		endpoints = append(endpoints, probedEndpoint{name: "user-sso", url: fmt.Sprintf("%s/auth/realms/%s", rhssoUserConfig.GetHost(), rhssoUserConfig.GetRealm())})
+	}
+
+	return endpoints, nil
+}
+
+// reconcileEndpointProber deploys the blackbox exporter the probes go
+// through
+func (r *Reconciler) reconcileEndpointProber(ctx context.Context, serverClient k8sclient.Client, namespace string) error {
+	objectMeta := metav1.ObjectMeta{Name: endpointProberName, Namespace: namespace}
+	labels := map[string]string{"app": endpointProberName}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, configMap, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(configMap, r.installation)
+		configMap.Data = map[string]string{"config.yml": endpointProberConfig}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile endpoint prober config map: %w", err)
+	}
+
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta}
+	opRes, err := controllerutil.CreateOrUpdate(ctx, serverClient, deployment, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(deployment, r.installation)
+		deployment.Labels = labels
+		deployment.Spec.Replicas = &[]int32{1}[0]
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template.Labels = labels
+		deployment.Spec.Template.Annotations = map[string]string{"integreatly.org/config-hash": fmt.Sprintf("%x", sha256.Sum256([]byte(endpointProberConfig)))}
+		deployment.Spec.Template.Spec.PriorityClassName = r.installation.Spec.PriorityClassName
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+			Name:  endpointProberName,
+			Image: endpointProberImage,
+			Args:  []string{"--config.file=/config/config.yml"},
+			Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: endpointProberPort, Protocol: corev1.ProtocolTCP}},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/-/healthy", Port: intstr.FromInt(endpointProberPort)}},
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/config"}},
+		}}
+		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: endpointProberName}},
+			},
+		}}
+		return podsecurity.Mutate(r.installation)(deployment, &deployment.Spec.Template)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reconcile endpoint prober deployment: %w", err)
+	}
+	if opRes != controllerutil.OperationResultNone {
+		r.log.Infof("Operation result endpoint prober", l.Fields{"result": opRes})
+	}
+
+	service := &corev1.Service{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, r.installation)
+		service.Labels = labels
+		service.Spec.Selector = labels
+		service.Spec.Ports = []corev1.ServicePort{
+			{Name: "http", Port: endpointProberPort, TargetPort: intstr.FromInt(endpointProberPort), Protocol: corev1.ProtocolTCP},
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile endpoint prober service: %w", err)
+	}
+	return nil
+}
+
+// queryEndpointHealth queries the RHOAM Prometheus for the results of the
+// probes of the endpoints
+func queryEndpointHealth(ctx context.Context, serverClient k8sclient.Client, namespace string, endpoints []probedEndpoint) ([]integreatlyv1alpha1.EndpointHealthStatus, error) {
+	prometheusService := &corev1.Service{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: prometheusServiceName, Namespace: namespace}, prometheusService); err != nil {
+		return nil, fmt.Errorf("failed to get prometheus service: %w", err)
+	}
+	var port int32
+	for _, servicePort := range prometheusService.Spec.Ports {
+		if servicePort.Name == "web" {
+			port = servicePort.Port
+		}
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("failed to find web port of prometheus service")
+	}
+
+	apiClient, err := prometheusApi.NewClient(prometheusApi.Config{
+		Address: fmt.Sprintf("http://%s.%s.svc:%d", prometheusService.Name, prometheusService.Namespace, port),
+	})
+	if err != nil {
+		return nil, err
+	}
+	api := prometheusv1.NewAPI(apiClient)
+
+	var results []model.Vector
+	for _, query := range []string{
+		fmt.Sprintf(`probe_success{job="%s"}`, EndpointProbeJob),
+		fmt.Sprintf(`probe_duration_seconds{job="%s"}`, EndpointProbeJob),
+		fmt.Sprintf(`avg_over_time(probe_success{job="%s"}[1h])`, EndpointProbeJob),
+	} {
+		value, _, err := api.Query(ctx, query, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", query, err)
+		}
+		vector, ok := value.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %s of %s", value.Type(), query)
+		}
+		results = append(results, vector)
+	}
+	return endpointHealth(endpoints, results[0], results[1], results[2]), nil
+}
+
+// endpointHealth summarizes the probe results of the endpoints. The
+// endpoints that weren't probed yet are reported down
+func endpointHealth(endpoints []probedEndpoint, success, duration, availability model.Vector) []integreatlyv1alpha1.EndpointHealthStatus {
+	byEndpoint := func(vector model.Vector) map[string]float64 {
+		values := map[string]float64{}
+		for _, sample := range vector {
+			values[string(sample.Metric["endpoint"])] = float64(sample.Value)
+		}
+		return values
+	}
+	successes, durations, availabilities := byEndpoint(success), byEndpoint(duration), byEndpoint(availability)
+
+	var statuses []integreatlyv1alpha1.EndpointHealthStatus
+	for _, endpoint := range endpoints {
+		status := integreatlyv1alpha1.EndpointHealthStatus{
+			Name: endpoint.name,
+			URL:  endpoint.url,
+			Up:   successes[endpoint.name] == 1,
+		}
+		if seconds, ok := durations[endpoint.name]; ok {
+			status.Latency = &metav1.Duration{Duration: time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)}
+		}
+		if ratio, ok := availabilities[endpoint.name]; ok {
+			status.Availability = strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", ratio*100), "0"), ".") + "%"
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
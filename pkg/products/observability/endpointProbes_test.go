@@ -0,0 +1,115 @@
+package observability
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	"github.com/prometheus/common/model"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileEndpointProbes(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := basicInstallation()
+	namespace := config.GetOboNamespace(installation.Namespace)
+	staleProbe := &monv1.Probe{ObjectMeta: metav1.ObjectMeta{
+		Name: "endpoint-user-sso", Namespace: namespace, Labels: map[string]string{endpointProbeLabel: "user-sso"},
+	}}
+	serverClient := utils.NewTestClient(scheme, staleProbe)
+
+	r := &Reconciler{
+		ConfigManager: &config.ConfigReadWriterMock{
+			ReadThreeScaleFunc: func() (*config.ThreeScale, error) {
+				return config.NewThreeScale(config.ProductConfig{
+					"HOST":                          "https://3scale-admin.apps.example.com",
+					"BLACKBOX_TARGET_PATH_ADMIN_UI": "/p/login/",
+					"NAMESPACE":                     "redhat-rhoam-3scale",
+				}), nil
+			},
+			ReadRHSSOFunc: func() (*config.RHSSO, error) {
+				return config.NewRHSSO(config.ProductConfig{"HOST": "https://keycloak.apps.example.com", "REALM": "openshift"}), nil
+			},
+			ReadRHSSOUserFunc: func() (*config.RHSSOUser, error) {
+				return config.NewRHSSOUser(config.ProductConfig{}), nil
+			},
+		},
+		installation: installation,
+		log:          getLogger(),
+	}
+
+	phase, err := r.reconcileEndpointProbes(context.TODO(), serverClient)
+	if err != nil || phase != v1alpha1.PhaseCompleted {
+		t.Fatalf("reconcileEndpointProbes() returned %v, %v", phase, err)
+	}
+
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: endpointProberName, Namespace: namespace}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("expected the endpoint prober deployment, got %v", err)
+	}
+	wantTargets := map[string]string{
+		"endpoint-3scale-admin":       "https://3scale-admin.apps.example.com/p/login/",
+		"endpoint-apicast-staging":    "http://apicast-staging.redhat-rhoam-3scale.svc:8090/status/ready",
+		"endpoint-apicast-production": "http://apicast-production.redhat-rhoam-3scale.svc:8090/status/ready",
+		"endpoint-rhsso":              "https://keycloak.apps.example.com/auth/realms/openshift",
+	}
+	for name, target := range wantTargets {
+		probe := &monv1.Probe{}
+		if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: namespace}, probe); err != nil {
+			t.Fatalf("expected the probe %s, got %v", name, err)
+		}
+		if got := probe.Spec.Targets.StaticConfig.Targets; len(got) != 1 || got[0] != target {
+			t.Errorf("expected the probe %s to target %s, got %v", name, target, got)
+		}
+		if probe.Labels[config.GetOboLabelSelectorKey()] != config.GetOboLabelSelector() || probe.Spec.JobName != EndpointProbeJob {
+			t.Errorf("expected the probe %s to be scraped by the RHOAM prometheus, got %v", name, probe)
+		}
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(staleProbe), &monv1.Probe{}); !k8serr.IsNotFound(err) {
+		t.Errorf("expected the probe of the endpoint that isn't installed to be deleted, got %v", err)
+	}
+}
+
+func TestEndpointHealth(t *testing.T) {
+	endpoints := []probedEndpoint{
+		{name: "rhsso", url: "https://keycloak.apps.example.com/auth/realms/openshift"},
+		{name: "3scale-admin", url: "https://3scale-admin.apps.example.com/p/login/"},
+		{name: "apicast-production", url: "http://apicast-production.redhat-rhoam-3scale.svc:8090/status/ready"},
+	}
+	sample := func(endpoint string, value float64) *model.Sample {
+		return &model.Sample{Metric: model.Metric{"endpoint": model.LabelValue(endpoint)}, Value: model.SampleValue(value)}
+	}
+
+	got := endpointHealth(endpoints,
+		model.Vector{sample("rhsso", 1), sample("3scale-admin", 0)},
+		model.Vector{sample("rhsso", 0.1234), sample("3scale-admin", 10)},
+		model.Vector{sample("rhsso", 1), sample("3scale-admin", 0.9875)},
+	)
+
+	want := []v1alpha1.EndpointHealthStatus{
+		{Name: "3scale-admin", URL: endpoints[1].url, Up: false, Latency: &metav1.Duration{Duration: 10 * time.Second}, Availability: "98.75%"},
+		{Name: "apicast-production", URL: endpoints[2].url, Up: false},
+		{Name: "rhsso", URL: endpoints[0].url, Up: true, Latency: &metav1.Duration{Duration: 123 * time.Millisecond}, Availability: "100%"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d endpoints, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].URL != want[i].URL || got[i].Up != want[i].Up || got[i].Availability != want[i].Availability {
+			t.Errorf("expected %v, got %v", want[i], got[i])
+		}
+		if (got[i].Latency == nil) != (want[i].Latency == nil) || (got[i].Latency != nil && got[i].Latency.Duration != want[i].Latency.Duration) {
+			t.Errorf("expected the latency of %s to be %v, got %v", want[i].Name, want[i].Latency, got[i].Latency)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ExtraMonitorLabel opts a ServiceMonitor or PodMonitor in a RHOAM
+	// namespace in to being scraped by the RHOAM monitoring stack
+	ExtraMonitorLabel = "integreatly.org/scrape"
+	// ExtraMonitorRejectedAnnotation is set on opted in monitors that aren't
+	// scraped, with the guardrail they break
+	ExtraMonitorRejectedAnnotation = "integreatly.org/scrape-rejected"
+	// extraMonitorManagedAnnotation marks monitors the monitoring label was
+	// added to, so it can be removed again when they opt out
+	extraMonitorManagedAnnotation = "integreatly.org/scrape-managed"
+
+	maxExtraMonitorsPerNamespace   = 5
+	maxExtraMonitorEndpoints       = 3
+	defaultExtraMonitorSampleLimit = uint64(1000)
+	maxExtraMonitorSampleLimit     = uint64(5000)
+)
+
+// extraMonitor is the part of a ServiceMonitor or PodMonitor checked by the
+// guardrails
+type extraMonitor struct {
+	object            k8sclient.Object
+	endpoints         int
+	namespaceSelector prometheus.NamespaceSelector
+	sampleLimit       *uint64
+}
+
+// reconcileExtraMonitors adds the RHOAM monitoring label to ServiceMonitors
+// and PodMonitors labeled with ExtraMonitorLabel in RHOAM namespaces. To keep
+// the cardinality of the RHOAM Prometheus in check each namespace can have up
+// to maxExtraMonitorsPerNamespace monitors with up to maxExtraMonitorEndpoints
+// endpoints, only selecting targets in their own namespace, and their sample
+// limit is capped at maxExtraMonitorSampleLimit
+func (r *Reconciler) reconcileExtraMonitors(ctx context.Context, client k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := client.List(ctx, namespaces, k8sclient.MatchingLabels{config.GetOboLabelSelectorKey(): config.GetOboLabelSelector()}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list monitored namespaces: %w", err)
+	}
+
+	for _, ns := range namespaces.Items {
+		monitors, err := listExtraMonitors(ctx, client, ns.Name)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+
+		accepted := 0
+		for _, monitor := range monitors {
+			original := monitor.object.DeepCopyObject()
+			labels := monitor.object.GetLabels()
+			annotations := monitor.object.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+
+			if labels[ExtraMonitorLabel] != "true" {
+				if annotations[extraMonitorManagedAnnotation] != "true" {
+					continue
+				}
+				delete(labels, config.GetOboLabelSelectorKey())
+				delete(annotations, extraMonitorManagedAnnotation)
+				delete(annotations, ExtraMonitorRejectedAnnotation)
+			} else if reason := monitor.rejectReason(accepted); reason != "" {
+				r.log.Warningf("Extra monitor rejected", l.Fields{"namespace": ns.Name, "name": monitor.object.GetName(), "reason": reason})
+				delete(labels, config.GetOboLabelSelectorKey())
+				annotations[extraMonitorManagedAnnotation] = "true"
+				annotations[ExtraMonitorRejectedAnnotation] = reason
+			} else {
+				accepted++
+				labels[config.GetOboLabelSelectorKey()] = config.GetOboLabelSelector()
+				annotations[extraMonitorManagedAnnotation] = "true"
+				delete(annotations, ExtraMonitorRejectedAnnotation)
+				if *monitor.sampleLimit == 0 {
+					*monitor.sampleLimit = defaultExtraMonitorSampleLimit
+				} else if *monitor.sampleLimit > maxExtraMonitorSampleLimit {
+					*monitor.sampleLimit = maxExtraMonitorSampleLimit
+				}
+			}
+
+			monitor.object.SetLabels(labels)
+			monitor.object.SetAnnotations(annotations)
+			if equality.Semantic.DeepEqual(original, monitor.object) {
+				continue
+			}
+			if err := client.Update(ctx, monitor.object); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update monitor %s/%s: %w", ns.Name, monitor.object.GetName(), err)
+			}
+		}
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+func (m extraMonitor) rejectReason(accepted int) string {
+	ns := m.object.GetNamespace()
+	if m.namespaceSelector.Any || len(m.namespaceSelector.MatchNames) > 1 ||
+		(len(m.namespaceSelector.MatchNames) == 1 && m.namespaceSelector.MatchNames[0] != ns) {
+		return fmt.Sprintf("namespaceSelector must only select namespace %s", ns)
+	}
+	if m.endpoints > maxExtraMonitorEndpoints {
+		return fmt.Sprintf("more than %d endpoints", maxExtraMonitorEndpoints)
+	}
+	if accepted >= maxExtraMonitorsPerNamespace {
+		return fmt.Sprintf("more than %d monitors in namespace %s", maxExtraMonitorsPerNamespace, ns)
+	}
+	return ""
+}
+
+// listExtraMonitors returns the ServiceMonitors and PodMonitors in namespace
+// that are, or were, opted in. They're sorted by name so the same monitors are
+// accepted when a namespace has more than maxExtraMonitorsPerNamespace
+func listExtraMonitors(ctx context.Context, client k8sclient.Client, namespace string) ([]extraMonitor, error) {
+	var monitors []extraMonitor
+
+	serviceMonitors := &prometheus.ServiceMonitorList{}
+	if err := client.List(ctx, serviceMonitors, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list service monitors in namespace %s: %w", namespace, err)
+	}
+	for _, serviceMonitor := range serviceMonitors.Items {
+		monitors = append(monitors, extraMonitor{
+			object:            serviceMonitor,
+			endpoints:         len(serviceMonitor.Spec.Endpoints),
+			namespaceSelector: serviceMonitor.Spec.NamespaceSelector,
+			sampleLimit:       &serviceMonitor.Spec.SampleLimit,
+		})
+	}
+
+	podMonitors := &prometheus.PodMonitorList{}
+	if err := client.List(ctx, podMonitors, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pod monitors in namespace %s: %w", namespace, err)
+	}
+	for _, podMonitor := range podMonitors.Items {
+		monitors = append(monitors, extraMonitor{
+			object:            podMonitor,
+			endpoints:         len(podMonitor.Spec.PodMetricsEndpoints),
+			namespaceSelector: podMonitor.Spec.NamespaceSelector,
+			sampleLimit:       &podMonitor.Spec.SampleLimit,
+		})
+	}
+
+	sort.SliceStable(monitors, func(i, j int) bool {
+		return monitors[i].object.GetName() < monitors[j].object.GetName()
+	})
+	return monitors, nil
+}
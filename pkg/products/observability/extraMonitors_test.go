@@ -0,0 +1,186 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const extraMonitorNamespace = "redhat-rhoam-3scale"
+
+func extraServiceMonitor(name string, labels, annotations map[string]string, endpoints int, sampleLimit uint64) *prometheus.ServiceMonitor {
+	return &prometheus.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   extraMonitorNamespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: prometheus.ServiceMonitorSpec{
+			Endpoints:   make([]prometheus.Endpoint, endpoints),
+			SampleLimit: sampleLimit,
+		},
+	}
+}
+
+func TestReconciler_reconcileExtraMonitors(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	monitoredNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   extraMonitorNamespace,
+			Labels: map[string]string{config.GetOboLabelSelectorKey(): config.GetOboLabelSelector()},
+		},
+	}
+	optIn := map[string]string{ExtraMonitorLabel: "true"}
+
+	tooMany := []runtime.Object{monitoredNamespace}
+	for i := 0; i <= maxExtraMonitorsPerNamespace; i++ {
+		tooMany = append(tooMany, extraServiceMonitor(fmt.Sprintf("exporter-%d", i), optIn, nil, 1, 0))
+	}
+
+	tests := []struct {
+		name     string
+		initObjs []runtime.Object
+		assert   func(k8sclient.Client) error
+	}{
+		{
+			name: "test opted in monitor is labeled for scraping with a default sample limit",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				extraServiceMonitor("exporter", optIn, nil, 1, 0),
+			},
+			assert: func(c k8sclient.Client) error {
+				return assertExtraMonitor(c, "exporter", true, defaultExtraMonitorSampleLimit)
+			},
+		},
+		{
+			name: "test sample limit is capped",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				extraServiceMonitor("exporter", optIn, nil, 1, 100000),
+			},
+			assert: func(c k8sclient.Client) error {
+				return assertExtraMonitor(c, "exporter", true, maxExtraMonitorSampleLimit)
+			},
+		},
+		{
+			name: "test monitor with too many endpoints is rejected",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				extraServiceMonitor("exporter", optIn, nil, maxExtraMonitorEndpoints+1, 0),
+			},
+			assert: func(c k8sclient.Client) error {
+				return assertExtraMonitor(c, "exporter", false, 0)
+			},
+		},
+		{
+			name: "test monitor selecting other namespaces is rejected",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				&prometheus.PodMonitor{
+					ObjectMeta: metav1.ObjectMeta{Name: "exporter", Namespace: extraMonitorNamespace, Labels: optIn},
+					Spec: prometheus.PodMonitorSpec{
+						NamespaceSelector: prometheus.NamespaceSelector{Any: true},
+					},
+				},
+			},
+			assert: func(c k8sclient.Client) error {
+				monitor := &prometheus.PodMonitor{}
+				if err := c.Get(context.TODO(), k8sclient.ObjectKey{Name: "exporter", Namespace: extraMonitorNamespace}, monitor); err != nil {
+					return err
+				}
+				if _, ok := monitor.Labels[config.GetOboLabelSelectorKey()]; ok || monitor.Annotations[ExtraMonitorRejectedAnnotation] == "" {
+					return fmt.Errorf("expected pod monitor to be rejected, got labels %v annotations %v", monitor.Labels, monitor.Annotations)
+				}
+				return nil
+			},
+		},
+		{
+			name:     "test monitors over the namespace limit are rejected",
+			initObjs: tooMany,
+			assert: func(c k8sclient.Client) error {
+				if err := assertExtraMonitor(c, "exporter-0", true, defaultExtraMonitorSampleLimit); err != nil {
+					return err
+				}
+				return assertExtraMonitor(c, fmt.Sprintf("exporter-%d", maxExtraMonitorsPerNamespace), false, 0)
+			},
+		},
+		{
+			name: "test monitoring label is removed from opted out monitor",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				extraServiceMonitor("exporter",
+					map[string]string{config.GetOboLabelSelectorKey(): config.GetOboLabelSelector()},
+					map[string]string{extraMonitorManagedAnnotation: "true"}, 1, 1000),
+			},
+			assert: func(c k8sclient.Client) error {
+				monitor := &prometheus.ServiceMonitor{}
+				if err := c.Get(context.TODO(), k8sclient.ObjectKey{Name: "exporter", Namespace: extraMonitorNamespace}, monitor); err != nil {
+					return err
+				}
+				if _, ok := monitor.Labels[config.GetOboLabelSelectorKey()]; ok {
+					return fmt.Errorf("expected monitoring label to be removed, got %v", monitor.Labels)
+				}
+				return nil
+			},
+		},
+		{
+			name: "test product monitors that aren't opted in are left alone",
+			initObjs: []runtime.Object{
+				monitoredNamespace,
+				extraServiceMonitor("exporter", map[string]string{config.GetOboLabelSelectorKey(): config.GetOboLabelSelector()}, nil, 5, 0),
+			},
+			assert: func(c k8sclient.Client) error {
+				return assertExtraMonitor(c, "exporter", true, 0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			r := &Reconciler{log: getLogger()}
+
+			phase, err := r.reconcileExtraMonitors(context.TODO(), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if phase != v1alpha1.PhaseCompleted {
+				t.Fatalf("expected phase %s, got %s", v1alpha1.PhaseCompleted, phase)
+			}
+			if err := tt.assert(client); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func assertExtraMonitor(c k8sclient.Client, name string, scraped bool, sampleLimit uint64) error {
+	monitor := &prometheus.ServiceMonitor{}
+	if err := c.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: extraMonitorNamespace}, monitor); err != nil {
+		return err
+	}
+	if got := monitor.Labels[config.GetOboLabelSelectorKey()] == config.GetOboLabelSelector(); got != scraped {
+		return fmt.Errorf("expected %s scraped to be %t, got labels %v", name, scraped, monitor.Labels)
+	}
+	if !scraped && monitor.Annotations[ExtraMonitorRejectedAnnotation] == "" {
+		return fmt.Errorf("expected %s to have a rejected annotation, got %v", name, monitor.Annotations)
+	}
+	if scraped && monitor.Spec.SampleLimit != sampleLimit {
+		return fmt.Errorf("expected %s sample limit %d, got %d", name, sampleLimit, monitor.Spec.SampleLimit)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// relabeledProducts are the products whose namespaces hold monitors, most of
+// them created by the product operators, that get the product and tenant
+// relabeling
+var relabeledProducts = []integreatlyv1alpha1.ProductName{
+	integreatlyv1alpha1.Product3Scale,
+	integreatlyv1alpha1.ProductRHSSO,
+	integreatlyv1alpha1.ProductRHSSOUser,
+	integreatlyv1alpha1.ProductCloudResources,
+	integreatlyv1alpha1.ProductGrafana,
+	integreatlyv1alpha1.ProductMarin3r,
+	integreatlyv1alpha1.ProductMCG,
+	integreatlyv1alpha1.ProductObservability,
+}
+
+// reconcileMonitorRelabeling sets the product and tenant relabeling on every
+// ServiceMonitor and PodMonitor in the product and operator namespaces, and
+// on the monitors of the RHOAM operator in the installation namespace. The
+// monitors owned by other operators can be reset by them, so the relabeling
+// is set again on every reconcile
+func (r *Reconciler) reconcileMonitorRelabeling(ctx context.Context, client k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	namespaces, err := r.monitorRelabelNamespaces()
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	for namespace, productName := range namespaces {
+		serviceMonitors := &prometheus.ServiceMonitorList{}
+		if err := client.List(ctx, serviceMonitors, k8sclient.InNamespace(namespace)); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list service monitors in namespace %s: %w", namespace, err)
+		}
+		for _, serviceMonitor := range serviceMonitors.Items {
+			original := serviceMonitor.DeepCopy()
+			resources.SetMetricRelabelConfigs(serviceMonitor.Spec.Endpoints, productName, r.installation.Spec.Type)
+			if equality.Semantic.DeepEqual(original, serviceMonitor) {
+				continue
+			}
+			if err := client.Update(ctx, serviceMonitor); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update service monitor %s/%s: %w", namespace, serviceMonitor.Name, err)
+			}
+		}
+
+		podMonitors := &prometheus.PodMonitorList{}
+		if err := client.List(ctx, podMonitors, k8sclient.InNamespace(namespace)); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list pod monitors in namespace %s: %w", namespace, err)
+		}
+		for _, podMonitor := range podMonitors.Items {
+			original := podMonitor.DeepCopy()
+			resources.SetPodMetricRelabelConfigs(podMonitor.Spec.PodMetricsEndpoints, productName, r.installation.Spec.Type)
+			if equality.Semantic.DeepEqual(original, podMonitor) {
+				continue
+			}
+			if err := client.Update(ctx, podMonitor); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update pod monitor %s/%s: %w", namespace, podMonitor.Name, err)
+			}
+		}
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// monitorRelabelNamespaces maps the namespaces whose monitors are relabeled
+// to the product they're relabeled with
+func (r *Reconciler) monitorRelabelNamespaces() (map[string]integreatlyv1alpha1.ProductName, error) {
+	namespaces := map[string]integreatlyv1alpha1.ProductName{
+		r.installation.Namespace: resources.OperatorMetricProduct,
+	}
+
+	for _, productName := range relabeledProducts {
+		productConfig, err := r.ConfigManager.ReadProduct(productName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s config: %w", productName, err)
+		}
+		if namespace := productConfig.GetNamespace(); namespace != "" {
+			namespaces[namespace] = productName
+		}
+		if withOperator, ok := productConfig.(interface{ GetOperatorNamespace() string }); ok {
+			if namespace := withOperator.GetOperatorNamespace(); namespace != "" {
+				namespaces[namespace] = productName
+			}
+		}
+	}
+
+	return namespaces, nil
+}
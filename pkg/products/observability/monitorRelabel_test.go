@@ -0,0 +1,144 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileMonitorRelabeling(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configManager := &config.ConfigReadWriterMock{
+		ReadProductFunc: func(product v1alpha1.ProductName) (config.ConfigReadable, error) {
+			switch product {
+			case v1alpha1.ProductRHSSO:
+				rhsso := config.NewRHSSO(config.ProductConfig{})
+				rhsso.SetNamespace("redhat-rhoam-rhsso")
+				rhsso.SetOperatorNamespace("redhat-rhoam-rhsso-operator")
+				return rhsso, nil
+			case v1alpha1.ProductCloudResources:
+				cro := config.NewCloudResources(config.ProductConfig{})
+				cro.SetNamespace("redhat-rhoam-cloud-resources")
+				return cro, nil
+			}
+			return config.NewObservability(config.ProductConfig{}), nil
+		},
+	}
+
+	tests := []struct {
+		name            string
+		initObjs        []runtime.Object
+		monitor         k8sclient.ObjectKey
+		expectedProduct v1alpha1.ProductName
+	}{
+		{
+			name: "test product monitor is relabeled",
+			initObjs: []runtime.Object{
+				&prometheus.ServiceMonitor{
+					ObjectMeta: metav1.ObjectMeta{Name: "keycloak", Namespace: "redhat-rhoam-rhsso"},
+					Spec: prometheus.ServiceMonitorSpec{
+						Endpoints: []prometheus.Endpoint{{Port: "metrics"}},
+					},
+				},
+			},
+			monitor:         k8sclient.ObjectKey{Name: "keycloak", Namespace: "redhat-rhoam-rhsso"},
+			expectedProduct: v1alpha1.ProductRHSSO,
+		},
+		{
+			name: "test product operator monitor is relabeled",
+			initObjs: []runtime.Object{
+				&prometheus.ServiceMonitor{
+					ObjectMeta: metav1.ObjectMeta{Name: "keycloak-operator", Namespace: "redhat-rhoam-rhsso-operator"},
+					Spec: prometheus.ServiceMonitorSpec{
+						Endpoints: []prometheus.Endpoint{{Port: "metrics"}},
+					},
+				},
+			},
+			monitor:         k8sclient.ObjectKey{Name: "keycloak-operator", Namespace: "redhat-rhoam-rhsso-operator"},
+			expectedProduct: v1alpha1.ProductRHSSO,
+		},
+		{
+			name: "test RHOAM operator monitor is relabeled",
+			initObjs: []runtime.Object{
+				&prometheus.ServiceMonitor{
+					ObjectMeta: metav1.ObjectMeta{Name: "rhmi-operator-metrics", Namespace: defaultInstallationNamespace},
+					Spec: prometheus.ServiceMonitorSpec{
+						Endpoints: []prometheus.Endpoint{{Port: "https"}},
+					},
+				},
+			},
+			monitor:         k8sclient.ObjectKey{Name: "rhmi-operator-metrics", Namespace: defaultInstallationNamespace},
+			expectedProduct: resources.OperatorMetricProduct,
+		},
+		{
+			name: "test pod monitor is relabeled",
+			initObjs: []runtime.Object{
+				&prometheus.PodMonitor{
+					ObjectMeta: metav1.ObjectMeta{Name: "cloud-resource-operator", Namespace: "redhat-rhoam-cloud-resources"},
+					Spec: prometheus.PodMonitorSpec{
+						PodMetricsEndpoints: []prometheus.PodMetricsEndpoint{{Port: "metrics"}},
+					},
+				},
+			},
+			monitor:         k8sclient.ObjectKey{Name: "cloud-resource-operator", Namespace: "redhat-rhoam-cloud-resources"},
+			expectedProduct: v1alpha1.ProductCloudResources,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			r := &Reconciler{ConfigManager: configManager, installation: basicInstallation(), log: getLogger()}
+
+			phase, err := r.reconcileMonitorRelabeling(context.TODO(), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if phase != v1alpha1.PhaseCompleted {
+				t.Fatalf("expected phase %s, got %s", v1alpha1.PhaseCompleted, phase)
+			}
+			if err := assertMonitorProduct(client, tt.monitor, tt.expectedProduct); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func assertMonitorProduct(c k8sclient.Client, key k8sclient.ObjectKey, product v1alpha1.ProductName) error {
+	var relabelings [][]*prometheus.RelabelConfig
+
+	serviceMonitor := &prometheus.ServiceMonitor{}
+	if err := c.Get(context.TODO(), key, serviceMonitor); err == nil {
+		for _, endpoint := range serviceMonitor.Spec.Endpoints {
+			relabelings = append(relabelings, endpoint.RelabelConfigs)
+		}
+	} else {
+		podMonitor := &prometheus.PodMonitor{}
+		if err := c.Get(context.TODO(), key, podMonitor); err != nil {
+			return err
+		}
+		for _, endpoint := range podMonitor.Spec.PodMetricsEndpoints {
+			relabelings = append(relabelings, endpoint.RelabelConfigs)
+		}
+	}
+
+	for _, configs := range relabelings {
+		if len(configs) != 1 || configs[0].TargetLabel != resources.ProductMetricLabel || configs[0].Replacement != string(product) {
+			return fmt.Errorf("expected %s to be relabeled with product %s, got %+v", key, product, configs)
+		}
+	}
+	return nil
+}
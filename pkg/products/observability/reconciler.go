@@ -59,11 +59,7 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 	productConfig.SetNamespacePrefix(installation.Spec.NamespacePrefix)
 	productConfig.SetNamespace(ns)
 
-	if installation.Spec.OperatorsInProductNamespace {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace())
-	} else {
-		productConfig.SetOperatorNamespace(productConfig.GetNamespace() + "-operator")
-	}
+	productConfig.SetOperatorNamespace(installation.OperatorNamespace(productConfig.GetNamespace()))
 
 	if err := configManager.WriteConfig(productConfig); err != nil {
 		return nil, err
@@ -129,7 +125,25 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	phase, err = r.reconcileExtraMonitors(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile extra monitors", err)
+		return phase, err
+	}
+
+	phase, err = r.reconcileMonitorRelabeling(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile monitor relabeling", err)
+		return phase, err
+	}
+
+	phase, err = r.reconcileEndpointProbes(ctx, client)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile endpoint probes", err)
+		return phase, err
+	}
+
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Info("Reconciled successfully")
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
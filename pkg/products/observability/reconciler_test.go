@@ -259,6 +259,18 @@ func TestReconciler_fullReconcile(t *testing.T) {
 				ReadObservabilityFunc: func() (ready *config.Observability, e error) {
 					return config.NewObservability(config.ProductConfig{}), nil
 				},
+				ReadThreeScaleFunc: func() (*config.ThreeScale, error) {
+					return config.NewThreeScale(config.ProductConfig{}), nil
+				},
+				ReadRHSSOFunc: func() (*config.RHSSO, error) {
+					return config.NewRHSSO(config.ProductConfig{}), nil
+				},
+				ReadRHSSOUserFunc: func() (*config.RHSSOUser, error) {
+					return config.NewRHSSOUser(config.ProductConfig{}), nil
+				},
+				ReadProductFunc: func(product v1alpha1.ProductName) (config.ConfigReadable, error) {
+					return config.NewObservability(config.ProductConfig{}), nil
+				},
 			},
 			FakeMPM: &marketplace.MarketplaceInterfaceMock{
 				InstallOperatorFunc: func(ctx context.Context, serverClient k8sclient.Client, t marketplace.Target, operatorGroupNamespaces []string, approvalStrategy operatorsv1alpha1.Approval, catalogSourceReconciler marketplace.CatalogSourceReconciler) error {
@@ -6,11 +6,13 @@ import (
 	"errors"
 	"time"
 
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 
 	"github.com/integr8ly/integreatly-operator/pkg/products/marin3r"
 	"github.com/integr8ly/integreatly-operator/pkg/products/mcg"
 
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
 	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
 
 	"net/http"
@@ -112,7 +114,7 @@ func NewReconciler(product integreatlyv1alpha1.ProductName, rc *rest.Config, con
 		if err != nil {
 			return nil, err
 		}
-		reconciler, err = rhsso.NewReconciler(configManager, installation, oauthv1Client, mpm, recorder, rc.Host, &keycloakCommon.LocalConfigKeycloakFactory{}, log, productDeclaration)
+		reconciler, err = rhsso.NewReconciler(configManager, installation, oauthv1Client, mpm, recorder, rc.Host, keycloakFactory(installation), log, productDeclaration)
 		if err != nil {
 			return nil, err
 		}
@@ -122,7 +124,7 @@ func NewReconciler(product integreatlyv1alpha1.ProductName, rc *rest.Config, con
 		if err != nil {
 			return nil, err
 		}
-		reconciler, err = rhssouser.NewReconciler(configManager, installation, oauthv1Client, mpm, recorder, rc.Host, &keycloakCommon.LocalConfigKeycloakFactory{}, log, productDeclaration)
+		reconciler, err = rhssouser.NewReconciler(configManager, installation, oauthv1Client, mpm, recorder, rc.Host, keycloakFactory(installation), log, productDeclaration)
 		if err != nil {
 			return nil, err
 		}
@@ -142,11 +144,11 @@ func NewReconciler(product integreatlyv1alpha1.ProductName, rc *rest.Config, con
 		/* #nosec */
 		httpc := &http.Client{
 			Timeout: time.Second * 10,
-			Transport: &http.Transport{
+			Transport: dryrun.Transport(installation, &http.Transport{
 				DisableKeepAlives: true,
 				IdleConnTimeout:   time.Second * 10,
 				TLSClientConfig:   &tls.Config{InsecureSkipVerify: installation.Spec.SelfSignedCerts}, // gosec G402, value is read from CR config
-			},
+			}),
 		}
 
 		if installation.Spec.SelfSignedCerts {
@@ -176,6 +178,22 @@ func NewReconciler(product integreatlyv1alpha1.ProductName, rc *rest.Config, con
 	return reconciler, err
 }
 
+// keycloakFactory returns the factory of the Keycloak admin API clients of
+// the SSO reconcilers. The admin API has no dry run, so no client is built
+// during a dry run
+func keycloakFactory(installation *integreatlyv1alpha1.RHMI) keycloakCommon.KeycloakClientFactory {
+	if installation.IsDryRun() {
+		return &dryRunKeycloakFactory{}
+	}
+	return &keycloakCommon.LocalConfigKeycloakFactory{}
+}
+
+type dryRunKeycloakFactory struct{}
+
+func (f *dryRunKeycloakFactory) AuthenticatedClient(_ keycloak.Keycloak) (keycloakCommon.KeycloakInterface, error) {
+	return nil, &dryrun.SkippedError{Operation: "Keycloak admin API requests"}
+}
+
 type NoOp struct {
 }
 
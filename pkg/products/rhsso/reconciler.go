@@ -16,6 +16,7 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/products/rhssocommon"
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/events"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
@@ -30,6 +31,7 @@ import (
 	oauthClient "github.com/openshift/client-go/oauth/clientset/versioned/typed/oauth/v1"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
+	customDomain "github.com/integr8ly/integreatly-operator/pkg/resources/custom-domain"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	k8sresource "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -47,7 +49,6 @@ var (
 	authFlowAlias             = "authdelay"
 	adminCredentialSecretName = "credential-" + keycloakName
 	ssoType                   = "rhsso"
-	postgresResourceName      = "rhsso-postgres-rhmi"
 	routeName                 = "keycloak-edge"
 	lastPodRestart            = time.Now()
 )
@@ -174,7 +175,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
-	phase, err = r.ReconcileSubscription(ctx, serverClient, installation, productNamespace, operatorNamespace, postgresResourceName)
+	phase, err = r.ReconcileSubscription(ctx, serverClient, installation, productNamespace, operatorNamespace,
+		r.PreUpgradeBackupsExecutor(productStatus, constants.RHSSOPostgresPrefix+installation.Name, keycloakName, productNamespace))
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.Recorder, installation, phase, fmt.Sprintf("Failed to reconcile %s subscription", constants.RHSSOSubscriptionName), err)
 		return phase, err
@@ -198,6 +200,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.ReconcileCustomKeycloakRoute(ctx, serverClient, r.Config.RHSSOCommon, integreatlyv1alpha1.CustomRouteRHSSO, routeName, customDomain.DefaultHostResolver)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile keycloak custom route", err)
+		return phase, err
+	}
+
 	phase, err = r.ReconcileCloudResources(constants.RHSSOPostgresPrefix, defaultOperandNamespace, ssoType, r.Config.RHSSOCommon, ctx, installation, serverClient)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile cloud resources", err)
@@ -232,6 +240,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.ReconcileSecretRotation(ctx, serverClient, productStatus, keycloakName, adminCredentialSecretName, productNamespace)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile admin credential rotation", err)
+		return phase, err
+	}
+
 	phase, err = resources.ReconcileSecretToRHMIOperatorNamespace(ctx, serverClient, r.ConfigManager, adminCredentialSecretName, productNamespace)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile admin credential secret to RHMI operator namespace", err)
@@ -250,9 +264,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.ReconcileRealmExport(ctx, serverClient, keycloakName, r.Config.GetNamespace())
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile realm export", err)
+		return phase, err
+	}
+
 	productStatus.Host = r.Config.GetHost()
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
+	productStatus.FIPS = fips.ProductStatus(installation)
 
 	events.HandleProductComplete(r.Recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	return integreatlyv1alpha1.PhaseCompleted, nil
@@ -278,6 +299,12 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 			},
 		},
 	}
+
+	themeConfigMap, err := r.ReconcileThemeConfigMap(ctx, serverClient, r.Config.GetNamespace())
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
 	or, err := controllerutil.CreateOrUpdate(ctx, serverClient, kc, func() error {
 		kc.Spec.Extensions = []string{
 			rhssocommon.KeycloakMetricsExtension,
@@ -323,6 +350,8 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 		if experimentalSpec != nil {
 			kc.Spec.KeycloakDeploymentSpec.Experimental = *experimentalSpec
 		}
+		rhssocommon.SetFIPSEnv(kc, installation)
+		rhssocommon.SetTheme(kc, installation, themeConfigMap)
 
 		return nil
 	})
@@ -415,6 +444,18 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to sync openshift idp client secret: %w", err)
 	}
 
+	if err := r.ReconcileRelaySMTP(ctx, serverClient, kc, authenticated, keycloakRealmName); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to configure smtp relay: %w", err)
+	}
+
+	if err := r.ReconcileRealmTheme(ctx, serverClient, kc, authenticated, keycloakRealmName); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile the realm theme: %w", err)
+	}
+
+	if err := r.ReconcileRealmCustomizations(ctx, serverClient, kc, authenticated, integreatlyv1alpha1.ProductRHSSO, keycloakRealmName, false); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile the realm customizations: %w", err)
+	}
+
 	// Get all currently existing keycloak users
 	keycloakUsers, err := GetKeycloakUsers(ctx, serverClient, r.Config.GetNamespace())
 	if err != nil {
@@ -940,6 +940,15 @@ func getMoqKeycloakClientFactory() keycloakCommon.KeycloakClientFactory {
 				FindAuthenticationExecutionForFlowFunc:   keycloakInterfaceMock.FindAuthenticationExecutionForFlow,
 				ListAuthenticationExecutionsForFlowFunc:  keycloakInterfaceMock.ListAuthenticationExecutionsForFlow,
 				UpdateAuthenticationExecutionForFlowFunc: keycloakInterfaceMock.UpdateAuthenticationExecutionForFlow,
+				ListIdentityProvidersFunc: func(realmName string) ([]*keycloak.KeycloakIdentityProvider, error) {
+					return []*keycloak.KeycloakIdentityProvider{}, nil
+				},
+				ListClientsFunc: func(realmName string) ([]*keycloak.KeycloakAPIClient, error) {
+					return []*keycloak.KeycloakAPIClient{}, nil
+				},
+				GetRealmFunc: func(realmName string) (*keycloak.KeycloakRealm, error) {
+					return &keycloak.KeycloakRealm{Spec: keycloak.KeycloakRealmSpec{Realm: &keycloak.KeycloakAPIRealm{Realm: realmName}}}, nil
+				},
 			}, nil
 		}}
 }
@@ -0,0 +1,351 @@
+package rhssocommon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RealmCustomizationMarker is set in the config of the identity providers and the attributes of the clients added
+// by the realm customizations, to the name of the customization. The marked ones no customization defines anymore
+// are removed from the realm, the unmarked ones are left to the operator and the realm admins
+const RealmCustomizationMarker = "integreatly.org/realm-customization"
+
+// ReconcileRealmCustomizations merges the RealmCustomization CRs of the product, in the namespace of the
+// installation, into its realm and reports on each of them whether it's applied. A customization failing to apply
+// is reported on its CR without failing the product. managedPasswordPolicy rejects the password policies of the
+// customizations when the operator sets the password policy of the realm
+func (r *Reconciler) ReconcileRealmCustomizations(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, kcClient keycloakCommon.KeycloakInterface, product integreatlyv1alpha1.ProductName, realmName string, managedPasswordPolicy bool) error {
+	customizationList := &integreatlyv1alpha1.RealmCustomizationList{}
+	if err := serverClient.List(ctx, customizationList, k8sclient.InNamespace(r.Installation.Namespace)); err != nil {
+		return fmt.Errorf("failed to list the realm customizations: %w", err)
+	}
+	customizations := []integreatlyv1alpha1.RealmCustomization{}
+	for _, customization := range customizationList.Items {
+		if customization.Spec.Product == product {
+			customizations = append(customizations, customization)
+		}
+	}
+
+	identityProviders, err := kcClient.ListIdentityProviders(realmName)
+	if err != nil {
+		return fmt.Errorf("failed to list the identity providers of realm %s: %w", realmName, err)
+	}
+	clients, err := kcClient.ListClients(realmName)
+	if err != nil {
+		return fmt.Errorf("failed to list the clients of realm %s: %w", realmName, err)
+	}
+	realm := newRealmEntities(identityProviders, clients)
+
+	valid, rejected := validRealmCustomizations(customizations, realm, managedPasswordPolicy)
+	failed := map[string]string{}
+	for i := range valid {
+		if err := r.applyRealmCustomization(ctx, serverClient, kc, kcClient, realmName, &valid[i], realm); err != nil {
+			r.Log.Error(fmt.Sprintf("Failed to apply realm customization %s", valid[i].Name), err)
+			failed[valid[i].Name] = err.Error()
+		}
+	}
+
+	if err := removeRealmCustomizations(kcClient, realmName, customizations, realm); err != nil {
+		return err
+	}
+	return updateRealmCustomizationStatuses(ctx, serverClient, realmName, customizations, rejected, failed)
+}
+
+// realmEntities are the identity providers and clients of a realm, by alias and client ID
+type realmEntities struct {
+	identityProviders map[string]*keycloak.KeycloakIdentityProvider
+	clients           map[string]*keycloak.KeycloakAPIClient
+}
+
+func newRealmEntities(identityProviders []*keycloak.KeycloakIdentityProvider, clients []*keycloak.KeycloakAPIClient) realmEntities {
+	realm := realmEntities{
+		identityProviders: map[string]*keycloak.KeycloakIdentityProvider{},
+		clients:           map[string]*keycloak.KeycloakAPIClient{},
+	}
+	for _, idp := range identityProviders {
+		realm.identityProviders[idp.Alias] = idp
+	}
+	for _, client := range clients {
+		realm.clients[client.ClientID] = client
+	}
+	return realm
+}
+
+// validRealmCustomizations returns the customizations that can be merged into the realm, sorted by name, and the
+// reason each of the others is rejected for. A customization is rejected when it's invalid, when it redefines an
+// identity provider or client of the realm no customization added, or when a previous customization by name
+// already defines one of its identity providers, clients, required actions or password policy
+func validRealmCustomizations(customizations []integreatlyv1alpha1.RealmCustomization, realm realmEntities, managedPasswordPolicy bool) ([]integreatlyv1alpha1.RealmCustomization, map[string]string) {
+	sorted := make([]integreatlyv1alpha1.RealmCustomization, len(customizations))
+	copy(sorted, customizations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	valid := []integreatlyv1alpha1.RealmCustomization{}
+	rejected := map[string]string{}
+	identityProviders := map[string]string{}
+	clients := map[string]string{}
+	requiredActions := map[string]string{}
+	passwordPolicy := ""
+	for _, customization := range sorted {
+		if reason := realmCustomizationConflict(&customization, realm, managedPasswordPolicy, identityProviders, clients, requiredActions, passwordPolicy); reason != "" {
+			rejected[customization.Name] = reason
+			continue
+		}
+		for _, idp := range customization.Spec.IdentityProviders {
+			identityProviders[idp.Alias] = customization.Name
+		}
+		for _, client := range customization.Spec.Clients {
+			clients[client.ClientID] = customization.Name
+		}
+		for _, action := range customization.Spec.RequiredActions {
+			requiredActions[action.Alias] = customization.Name
+		}
+		if customization.Spec.PasswordPolicy != "" {
+			passwordPolicy = customization.Name
+		}
+		valid = append(valid, customization)
+	}
+	return valid, rejected
+}
+
+func realmCustomizationConflict(customization *integreatlyv1alpha1.RealmCustomization, realm realmEntities, managedPasswordPolicy bool, identityProviders, clients, requiredActions map[string]string, passwordPolicy string) string {
+	if err := customization.ValidateCreate(); err != nil {
+		return err.Error()
+	}
+	for _, idp := range customization.Spec.IdentityProviders {
+		if existing, ok := realm.identityProviders[idp.Alias]; ok && existing.Config[RealmCustomizationMarker] == "" {
+			return fmt.Sprintf("the %s identity provider isn't managed by the realm customizations", idp.Alias)
+		}
+		if name, ok := identityProviders[idp.Alias]; ok {
+			return fmt.Sprintf("the %s customization already defines the %s identity provider", name, idp.Alias)
+		}
+	}
+	for _, client := range customization.Spec.Clients {
+		if existing, ok := realm.clients[client.ClientID]; ok && existing.Attributes[RealmCustomizationMarker] == "" {
+			return fmt.Sprintf("the %s client isn't managed by the realm customizations", client.ClientID)
+		}
+		if name, ok := clients[client.ClientID]; ok {
+			return fmt.Sprintf("the %s customization already defines the %s client", name, client.ClientID)
+		}
+	}
+	for _, action := range customization.Spec.RequiredActions {
+		if name, ok := requiredActions[action.Alias]; ok {
+			return fmt.Sprintf("the %s customization already defines the %s required action", name, action.Alias)
+		}
+	}
+	if customization.Spec.PasswordPolicy != "" {
+		if managedPasswordPolicy {
+			return "the password policy of the realm is set by the installation"
+		}
+		if passwordPolicy != "" {
+			return fmt.Sprintf("the %s customization already sets the password policy", passwordPolicy)
+		}
+	}
+	return ""
+}
+
+// applyRealmCustomization creates or updates the identity providers and clients of the customization, overwriting
+// the changes made to them in the console, and sets its required actions and password policy
+func (r *Reconciler) applyRealmCustomization(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, kcClient keycloakCommon.KeycloakInterface, realmName string, customization *integreatlyv1alpha1.RealmCustomization, realm realmEntities) error {
+	for _, idpSpec := range customization.Spec.IdentityProviders {
+		idp, err := r.realmIdentityProvider(ctx, serverClient, customization.Name, idpSpec)
+		if err != nil {
+			return err
+		}
+		if existing, ok := realm.identityProviders[idp.Alias]; ok {
+			idp.InternalID = existing.InternalID
+			if err := kcClient.UpdateIdentityProvider(idp, realmName); err != nil {
+				return fmt.Errorf("failed to update the %s identity provider: %w", idp.Alias, err)
+			}
+			continue
+		}
+		if _, err := kcClient.CreateIdentityProvider(idp, realmName); err != nil {
+			return fmt.Errorf("failed to create the %s identity provider: %w", idp.Alias, err)
+		}
+		r.Log.Infof("Created identity provider", l.Fields{"realm": realmName, "alias": idp.Alias, "customization": customization.Name})
+	}
+
+	for _, clientSpec := range customization.Spec.Clients {
+		client := realmClient(customization.Name, clientSpec)
+		if existing, ok := realm.clients[client.ClientID]; ok {
+			client.ID = existing.ID
+			if err := kcClient.UpdateClient(client, realmName); err != nil {
+				return fmt.Errorf("failed to update the %s client: %w", client.ClientID, err)
+			}
+			continue
+		}
+		if _, err := kcClient.CreateClient(client, realmName); err != nil {
+			return fmt.Errorf("failed to create the %s client: %w", client.ClientID, err)
+		}
+		r.Log.Infof("Created client", l.Fields{"realm": realmName, "clientId": client.ClientID, "customization": customization.Name})
+	}
+
+	for _, action := range customization.Spec.RequiredActions {
+		if err := r.enableRequiredAction(ctx, serverClient, kc, realmName, action); err != nil {
+			return err
+		}
+	}
+
+	if customization.Spec.PasswordPolicy == "" {
+		return nil
+	}
+	kcRealm, err := kcClient.GetRealm(realmName)
+	if err != nil {
+		return fmt.Errorf("failed to get realm %s: %w", realmName, err)
+	}
+	if kcRealm == nil || kcRealm.Spec.Realm == nil {
+		return fmt.Errorf("realm %s not found", realmName)
+	}
+	if kcRealm.Spec.Realm.PasswordPolicy == customization.Spec.PasswordPolicy {
+		return nil
+	}
+	return r.UpdateRealmSettings(ctx, serverClient, kc, realmName, map[string]interface{}{"passwordPolicy": customization.Spec.PasswordPolicy})
+}
+
+func (r *Reconciler) realmIdentityProvider(ctx context.Context, serverClient k8sclient.Client, customizationName string, spec integreatlyv1alpha1.RealmIdentityProviderSpec) (*keycloak.KeycloakIdentityProvider, error) {
+	config := map[string]string{}
+	for key, value := range spec.Config {
+		config[key] = value
+	}
+	config[RealmCustomizationMarker] = customizationName
+
+	if spec.ClientSecretRef != nil {
+		secret := &corev1.Secret{}
+		if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: spec.ClientSecretRef.Name, Namespace: r.Installation.Namespace}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the client secret of the %s identity provider: %w", spec.Alias, err)
+		}
+		clientSecret, ok := secret.Data[spec.ClientSecretRef.Key]
+		if !ok {
+			return nil, fmt.Errorf("the %s secret has no %s key for the %s identity provider", spec.ClientSecretRef.Name, spec.ClientSecretRef.Key, spec.Alias)
+		}
+		config["clientSecret"] = string(clientSecret)
+	}
+
+	return &keycloak.KeycloakIdentityProvider{
+		Alias:                     spec.Alias,
+		DisplayName:               spec.DisplayName,
+		ProviderID:                spec.ProviderID,
+		Enabled:                   spec.Enabled == nil || *spec.Enabled,
+		TrustEmail:                spec.TrustEmail,
+		FirstBrokerLoginFlowAlias: spec.FirstBrokerLoginFlowAlias,
+		Config:                    config,
+	}, nil
+}
+
+func realmClient(customizationName string, spec integreatlyv1alpha1.RealmClientSpec) *keycloak.KeycloakAPIClient {
+	return &keycloak.KeycloakAPIClient{
+		ClientID:                  spec.ClientID,
+		Name:                      spec.Name,
+		Enabled:                   spec.Enabled == nil || *spec.Enabled,
+		Protocol:                  "openid-connect",
+		PublicClient:              spec.PublicClient,
+		RedirectUris:              spec.RedirectURIs,
+		WebOrigins:                spec.WebOrigins,
+		StandardFlowEnabled:       spec.StandardFlowEnabled == nil || *spec.StandardFlowEnabled,
+		DirectAccessGrantsEnabled: spec.DirectAccessGrantsEnabled,
+		ServiceAccountsEnabled:    spec.ServiceAccountsEnabled,
+		Attributes:                map[string]string{RealmCustomizationMarker: customizationName},
+	}
+}
+
+// enableRequiredAction enables a required action registered in the realm. The keycloak client doesn't cover the
+// required actions so they're updated with the admin API
+func (r *Reconciler) enableRequiredAction(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, realmName string, spec integreatlyv1alpha1.RealmRequiredActionSpec) error {
+	path := fmt.Sprintf("realms/%s/authentication/required-actions/%s", realmName, spec.Alias)
+	action := map[string]interface{}{}
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodGet, path, nil, &action); err != nil {
+		return fmt.Errorf("failed to get the %s required action: %w", spec.Alias, err)
+	}
+	if action["enabled"] == true && action["defaultAction"] == spec.DefaultAction {
+		return nil
+	}
+	action["enabled"] = true
+	action["defaultAction"] = spec.DefaultAction
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPut, path, action, nil); err != nil {
+		return fmt.Errorf("failed to enable the %s required action: %w", spec.Alias, err)
+	}
+	r.Log.Infof("Enabled required action", l.Fields{"realm": realmName, "alias": spec.Alias})
+	return nil
+}
+
+// removeRealmCustomizations removes the identity providers and clients added by the customizations that none of
+// the customizations of the product defines anymore. The ones of the rejected customizations are kept
+func removeRealmCustomizations(kcClient keycloakCommon.KeycloakInterface, realmName string, customizations []integreatlyv1alpha1.RealmCustomization, realm realmEntities) error {
+	identityProviders := map[string]bool{}
+	clients := map[string]bool{}
+	for _, customization := range customizations {
+		for _, idp := range customization.Spec.IdentityProviders {
+			identityProviders[idp.Alias] = true
+		}
+		for _, client := range customization.Spec.Clients {
+			clients[client.ClientID] = true
+		}
+	}
+
+	for alias, idp := range realm.identityProviders {
+		if identityProviders[alias] || idp.Config[RealmCustomizationMarker] == "" {
+			continue
+		}
+		if err := kcClient.DeleteIdentityProvider(alias, realmName); err != nil {
+			return fmt.Errorf("failed to remove the %s identity provider of realm %s: %w", alias, realmName, err)
+		}
+	}
+	for clientID, client := range realm.clients {
+		if clients[clientID] || client.Attributes[RealmCustomizationMarker] == "" {
+			continue
+		}
+		if err := kcClient.DeleteClient(client.ID, realmName); err != nil {
+			return fmt.Errorf("failed to remove the %s client of realm %s: %w", clientID, realmName, err)
+		}
+	}
+	return nil
+}
+
+// updateRealmCustomizationStatuses reports on the RealmCustomization CRs whether they're merged into the realm
+func updateRealmCustomizationStatuses(ctx context.Context, serverClient k8sclient.Client, realmName string, customizations []integreatlyv1alpha1.RealmCustomization, rejected, failed map[string]string) error {
+	for i := range customizations {
+		customization := &customizations[i]
+		status := customization.Status.DeepCopy()
+
+		condition := metav1.Condition{
+			Type:               integreatlyv1alpha1.RealmCustomizationAppliedConditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Applied",
+			Message:            fmt.Sprintf("The customization is merged into the %s realm", realmName),
+			ObservedGeneration: customization.Generation,
+		}
+		if reason, ok := rejected[customization.Name]; ok {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "Rejected"
+			condition.Message = reason
+		} else if reason, ok := failed[customization.Name]; ok {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "Failed"
+			condition.Message = reason
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+
+		if reflect.DeepEqual(status, &customization.Status) {
+			continue
+		}
+		customization.Status = *status
+		if err := serverClient.Status().Update(ctx, customization); err != nil {
+			return fmt.Errorf("error updating the status of the %s realm customization %w", customization.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package rhssocommon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const customizationNamespace = "redhat-rhoam-operator"
+
+func realmCustomization(name string, spec integreatlyv1alpha1.RealmCustomizationSpec) *integreatlyv1alpha1.RealmCustomization {
+	if spec.Product == "" {
+		spec.Product = integreatlyv1alpha1.ProductRHSSO
+	}
+	return &integreatlyv1alpha1.RealmCustomization{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: customizationNamespace},
+		Spec:       spec,
+	}
+}
+
+func TestValidRealmCustomizations(t *testing.T) {
+	realm := newRealmEntities(
+		[]*keycloak.KeycloakIdentityProvider{
+			{Alias: "openshift-v4", Config: map[string]string{}},
+			{Alias: "azure", Config: map[string]string{RealmCustomizationMarker: "corporate"}},
+		},
+		[]*keycloak.KeycloakAPIClient{{ClientID: "3scale"}},
+	)
+	azure := integreatlyv1alpha1.RealmIdentityProviderSpec{Alias: "azure", ProviderID: "oidc"}
+
+	tests := []struct {
+		name                  string
+		customizations        []integreatlyv1alpha1.RealmCustomization
+		managedPasswordPolicy bool
+		wantValid             []string
+		wantRejected          []string
+	}{
+		{
+			name: "identity providers added by the customizations can be redefined",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("renamed", integreatlyv1alpha1.RealmCustomizationSpec{IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{azure}}),
+			},
+			wantValid: []string{"renamed"},
+		},
+		{
+			name: "identity providers of the operator rejected",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("openshift", integreatlyv1alpha1.RealmCustomizationSpec{IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{{Alias: "openshift-v4", ProviderID: "openshift-v4"}}}),
+			},
+			wantRejected: []string{"openshift"},
+		},
+		{
+			name: "clients of the operator rejected",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("clients", integreatlyv1alpha1.RealmCustomizationSpec{Clients: []integreatlyv1alpha1.RealmClientSpec{{ClientID: "3scale"}}}),
+			},
+			wantRejected: []string{"clients"},
+		},
+		{
+			name: "first customization by name wins a conflict",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("b-corporate", integreatlyv1alpha1.RealmCustomizationSpec{IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{azure}}),
+				*realmCustomization("a-corporate", integreatlyv1alpha1.RealmCustomizationSpec{IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{azure}}),
+				*realmCustomization("c-actions", integreatlyv1alpha1.RealmCustomizationSpec{RequiredActions: []integreatlyv1alpha1.RealmRequiredActionSpec{{Alias: "CONFIGURE_TOTP"}}}),
+				*realmCustomization("d-actions", integreatlyv1alpha1.RealmCustomizationSpec{RequiredActions: []integreatlyv1alpha1.RealmRequiredActionSpec{{Alias: "CONFIGURE_TOTP"}}}),
+			},
+			wantValid:    []string{"a-corporate", "c-actions"},
+			wantRejected: []string{"b-corporate", "d-actions"},
+		},
+		{
+			name: "single password policy per realm",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("policy", integreatlyv1alpha1.RealmCustomizationSpec{PasswordPolicy: "length(12)"}),
+				*realmCustomization("stricter-policy", integreatlyv1alpha1.RealmCustomizationSpec{PasswordPolicy: "length(16)"}),
+			},
+			wantValid:    []string{"policy"},
+			wantRejected: []string{"stricter-policy"},
+		},
+		{
+			name: "password policy rejected when set by the installation",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("policy", integreatlyv1alpha1.RealmCustomizationSpec{PasswordPolicy: "length(12)"}),
+			},
+			managedPasswordPolicy: true,
+			wantRejected:          []string{"policy"},
+		},
+		{
+			name: "invalid customization rejected",
+			customizations: []integreatlyv1alpha1.RealmCustomization{
+				*realmCustomization("twice", integreatlyv1alpha1.RealmCustomizationSpec{IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{azure, azure}}),
+				*realmCustomization("no-client-id", integreatlyv1alpha1.RealmCustomizationSpec{Clients: []integreatlyv1alpha1.RealmClientSpec{{Name: "portal"}}}),
+				*realmCustomization("threescale", integreatlyv1alpha1.RealmCustomizationSpec{Product: integreatlyv1alpha1.Product3Scale}),
+			},
+			wantRejected: []string{"no-client-id", "threescale", "twice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, rejected := validRealmCustomizations(tt.customizations, realm, tt.managedPasswordPolicy)
+
+			validNames := []string{}
+			for _, customization := range valid {
+				validNames = append(validNames, customization.Name)
+			}
+			if len(tt.wantValid) == 0 {
+				tt.wantValid = []string{}
+			}
+			if !reflect.DeepEqual(validNames, tt.wantValid) {
+				t.Errorf("valid = %v, want %v", validNames, tt.wantValid)
+			}
+			if len(rejected) != len(tt.wantRejected) {
+				t.Errorf("rejected = %v, want %v", rejected, tt.wantRejected)
+			}
+			for _, name := range tt.wantRejected {
+				if _, ok := rejected[name]; !ok {
+					t.Errorf("expected %s to be rejected, got %v", name, rejected)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciler_ReconcileRealmCustomizations(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corporate := realmCustomization("corporate", integreatlyv1alpha1.RealmCustomizationSpec{
+		IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{{
+			Alias:           "azure",
+			ProviderID:      "oidc",
+			Config:          map[string]string{"clientId": "rhoam"},
+			ClientSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "azure-idp"}, Key: "secret"},
+		}},
+		Clients: []integreatlyv1alpha1.RealmClientSpec{{ClientID: "portal", RedirectURIs: []string{"https://portal.example.com/*"}}},
+	})
+	conflicting := realmCustomization("openshift", integreatlyv1alpha1.RealmCustomizationSpec{
+		IdentityProviders: []integreatlyv1alpha1.RealmIdentityProviderSpec{{Alias: "openshift-v4", ProviderID: "openshift-v4"}},
+	})
+	userSSO := realmCustomization("user-sso", integreatlyv1alpha1.RealmCustomizationSpec{
+		Product: integreatlyv1alpha1.ProductRHSSOUser,
+		Clients: []integreatlyv1alpha1.RealmClientSpec{{ClientID: "user-portal"}},
+	})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "azure-idp", Namespace: customizationNamespace},
+		Data:       map[string][]byte{"secret": []byte("client-secret")},
+	}
+
+	identityProviders := []*keycloak.KeycloakIdentityProvider{
+		{Alias: "openshift-v4", Config: map[string]string{}},
+		{Alias: "google", Config: map[string]string{RealmCustomizationMarker: "removed"}},
+	}
+	clients := []*keycloak.KeycloakAPIClient{
+		{ID: "1", ClientID: "3scale"},
+		{ID: "2", ClientID: "portal", Attributes: map[string]string{RealmCustomizationMarker: "corporate"}},
+		{ID: "3", ClientID: "legacy-portal", Attributes: map[string]string{RealmCustomizationMarker: "removed"}},
+	}
+
+	var createdIdPs, deletedIdPs, deletedClients []string
+	var updatedClients []*keycloak.KeycloakAPIClient
+	kcClient := &keycloakCommon.KeycloakInterfaceMock{
+		ListIdentityProvidersFunc: func(realmName string) ([]*keycloak.KeycloakIdentityProvider, error) {
+			return identityProviders, nil
+		},
+		ListClientsFunc: func(realmName string) ([]*keycloak.KeycloakAPIClient, error) {
+			return clients, nil
+		},
+		CreateIdentityProviderFunc: func(idp *keycloak.KeycloakIdentityProvider, realmName string) (string, error) {
+			if idp.Config["clientSecret"] != "client-secret" || idp.Config[RealmCustomizationMarker] != "corporate" {
+				t.Errorf("unexpected config of the %s identity provider: %v", idp.Alias, idp.Config)
+			}
+			createdIdPs = append(createdIdPs, idp.Alias)
+			return idp.Alias, nil
+		},
+		UpdateClientFunc: func(client *keycloak.KeycloakAPIClient, realmName string) error {
+			updatedClients = append(updatedClients, client)
+			return nil
+		},
+		DeleteIdentityProviderFunc: func(alias string, realmName string) error {
+			deletedIdPs = append(deletedIdPs, alias)
+			return nil
+		},
+		DeleteClientFunc: func(clientID string, realmName string) error {
+			deletedClients = append(deletedClients, clientID)
+			return nil
+		},
+	}
+
+	serverClient := utils.NewTestClient(scheme, corporate, conflicting, userSSO, secret)
+	r := &Reconciler{
+		Installation: &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: customizationNamespace}},
+		Log:          l.NewLogger(),
+	}
+
+	if err := r.ReconcileRealmCustomizations(context.TODO(), serverClient, &keycloak.Keycloak{}, kcClient, integreatlyv1alpha1.ProductRHSSO, "openshift", false); err != nil {
+		t.Fatalf("ReconcileRealmCustomizations() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(createdIdPs, []string{"azure"}) {
+		t.Errorf("created identity providers = %v, want [azure]", createdIdPs)
+	}
+	if len(updatedClients) != 1 || updatedClients[0].ID != "2" || !updatedClients[0].StandardFlowEnabled || !updatedClients[0].Enabled {
+		t.Errorf("updated clients = %v, want the enabled portal client", updatedClients)
+	}
+	if !reflect.DeepEqual(deletedIdPs, []string{"google"}) {
+		t.Errorf("deleted identity providers = %v, want [google]", deletedIdPs)
+	}
+	if !reflect.DeepEqual(deletedClients, []string{"3"}) {
+		t.Errorf("deleted clients = %v, want [3]", deletedClients)
+	}
+
+	wantApplied := map[string]metav1.ConditionStatus{"corporate": metav1.ConditionTrue, "openshift": metav1.ConditionFalse}
+	for name, want := range wantApplied {
+		customization := &integreatlyv1alpha1.RealmCustomization{}
+		if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: customizationNamespace}, customization); err != nil {
+			t.Fatal(err)
+		}
+		condition := meta.FindStatusCondition(customization.Status.Conditions, integreatlyv1alpha1.RealmCustomizationAppliedConditionType)
+		if condition == nil || condition.Status != want {
+			t.Errorf("%s applied condition = %v, want %s", name, condition, want)
+		}
+	}
+
+	customization := &integreatlyv1alpha1.RealmCustomization{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(userSSO), customization); err != nil {
+		t.Fatal(err)
+	}
+	if len(customization.Status.Conditions) != 0 {
+		t.Errorf("the customization of another product was reported: %v", customization.Status.Conditions)
+	}
+}
@@ -0,0 +1,139 @@
+package rhssocommon
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	cs "github.com/integr8ly/integreatly-operator/pkg/resources/custom-smtp"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakModel "github.com/integr8ly/keycloak-client/pkg"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReconcileRelaySMTP sends the emails of the realm, such as password resets
+// and invites, through the SMTP relay of the installation once it's ready
+func (r *Reconciler) ReconcileRelaySMTP(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, kcClient keycloakCommon.KeycloakInterface, realmName string) error {
+	if !cs.RelayEnabled(r.Installation) {
+		return nil
+	}
+
+	realm, err := kcClient.GetRealm(realmName)
+	if err != nil {
+		return fmt.Errorf("failed to get realm %s: %w", realmName, err)
+	}
+	if realm == nil || realm.Spec.Realm == nil {
+		return fmt.Errorf("realm %s not found", realmName)
+	}
+
+	smtpServer := RelaySMTPServer(r.Installation)
+	if reflect.DeepEqual(realm.Spec.Realm.SMTPServer, smtpServer) {
+		return nil
+	}
+	r.Log.Infof("Configuring SMTP relay", l.Fields{"realm": realmName})
+	return r.UpdateRealmSettings(ctx, serverClient, kc, realmName, map[string]interface{}{"smtpServer": smtpServer})
+}
+
+// RelaySMTPServer returns the SMTP server settings of a realm sending its
+// emails through the SMTP relay
+func RelaySMTPServer(installation *integreatlyv1alpha1.RHMI) map[string]string {
+	return map[string]string{
+		"host": installation.Status.SMTPRelay.Host,
+		"port": fmt.Sprint(cs.RelayPort),
+		"from": installation.Spec.SMTPRelay.FromAddress,
+		"auth": "false",
+		"ssl":  "false",
+	}
+}
+
+// UpdateRealmSettings updates top level settings of a realm with the Keycloak admin API. UpdateRealm of the
+// keycloak client sends the whole custom resource rather than the realm representation so can't be used
+func (r *Reconciler) UpdateRealmSettings(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, realmName string, settings map[string]interface{}) error {
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPut, "realms/"+realmName, settings, nil); err != nil {
+		return fmt.Errorf("failed to update realm %s: %w", realmName, err)
+	}
+	return nil
+}
+
+// AdminRequest sends a request to the Keycloak admin API, for the endpoints the keycloak client doesn't cover,
+// logged in with the admin credentials of the instance. The body and the response are JSON, the response is
+// decoded into result unless it's nil
+func (r *Reconciler) AdminRequest(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, method, path string, body, result interface{}) error {
+	adminCreds := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: kc.Status.CredentialSecret, Namespace: kc.Namespace}, adminCreds); err != nil {
+		return fmt.Errorf("failed to get the admin credentials: %w", err)
+	}
+
+	/* #nosec */
+	httpc := &http.Client{
+		Timeout: time.Second * 10,
+		Transport: dryrun.Transport(r.Installation, &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: r.Installation.Spec.SelfSignedCerts}, // gosec G402, value is read from CR config
+		}),
+	}
+
+	form := url.Values{}
+	form.Add("username", string(adminCreds.Data[keycloakModel.AdminUsernameProperty]))
+	form.Add("password", string(adminCreds.Data[keycloakModel.AdminPasswordProperty]))
+	form.Add("client_id", "admin-cli")
+	form.Add("grant_type", "password")
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/auth/realms/master/protocol/openid-connect/token", kc.Status.ExternalURL), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	tokenReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenRes, err := httpc.Do(tokenReq)
+	if err != nil {
+		return fmt.Errorf("error performing token request: %w", err)
+	}
+	defer tokenRes.Body.Close()
+	token := &keycloak.TokenResponse{}
+	if err := json.NewDecoder(tokenRes.Body).Decode(token); err != nil {
+		return fmt.Errorf("error parsing token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("failed to log in to keycloak: %s %s", tokenRes.Status, token.ErrorDescription)
+	}
+
+	var reqBody []byte
+	if body != nil {
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/auth/admin/%s", kc.Status.ExternalURL, path), bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	res, err := httpc.Do(req)
+	if err != nil {
+		return fmt.Errorf("error performing %s %s request: %w", method, path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", method, path, res.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("error parsing %s %s response: %w", method, path, err)
+	}
+	return nil
+}
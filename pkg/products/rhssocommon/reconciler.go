@@ -3,6 +3,7 @@ package rhssocommon
 import (
 	"context"
 	"fmt"
+	"time"
 
 	grafanav1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
 	croType "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
@@ -12,11 +13,19 @@ import (
 	"github.com/integr8ly/integreatly-operator/pkg/resources/backup"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/cluster"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
+	customDomain "github.com/integr8ly/integreatly-operator/pkg/resources/custom-domain"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/marketplace"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/realmexport"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/rotation"
 	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
 	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakModel "github.com/integr8ly/keycloak-client/pkg"
 	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
 	appsv1 "github.com/openshift/api/apps/v1"
 	configv1 "github.com/openshift/api/config/v1"
@@ -33,6 +42,7 @@ import (
 	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/tools/record"
@@ -48,6 +58,9 @@ var (
 
 const (
 	KeycloakMetricsExtension = "https://github.com/integr8ly/keycloak-metrics-spi/releases/download/2.5.3/keycloak-metrics-spi.jar"
+
+	// adminRealmName is the realm of the admin of the keycloak instances
+	adminRealmName = "master"
 )
 
 type Reconciler struct {
@@ -78,15 +91,11 @@ func NewReconciler(configManager config.ConfigReadWriter, mpm marketplace.Market
 
 func SetNameSpaces(installation *integreatlyv1alpha1.RHMI, config *config.RHSSOCommon, namespace string) {
 	if config.GetNamespace() == "" {
-		config.SetNamespace(installation.Spec.NamespacePrefix + namespace)
+		config.SetNamespace(installation.ProductNamespace(namespace))
 	}
 
 	if config.GetOperatorNamespace() == "" {
-		if installation.Spec.OperatorsInProductNamespace {
-			config.SetOperatorNamespace(config.GetNamespace())
-		} else {
-			config.SetOperatorNamespace(config.GetNamespace() + "-operator")
-		}
+		config.SetOperatorNamespace(installation.OperatorNamespace(config.GetNamespace()))
 	}
 }
 
@@ -288,6 +297,19 @@ func (r *Reconciler) CreateKeycloakRoute(ctx context.Context, serverClient k8scl
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
+// ReconcileCustomKeycloakRoute serves the keycloak route on the hostname of
+// the custom domain spec
+func (r *Reconciler) ReconcileCustomKeycloakRoute(ctx context.Context, serverClient k8sclient.Client, ssoCommon *config.RHSSOCommon, name integreatlyv1alpha1.CustomRouteName, routeName string, resolve customDomain.HostResolver) (integreatlyv1alpha1.StatusPhase, error) {
+	keycloakRoute := &routev1.Route{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: routeName, Namespace: ssoCommon.GetNamespace()}, keycloakRoute); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("error getting keycloak route %s: %w", routeName, err)
+	}
+	if err := customDomain.ReconcileCustomRoute(ctx, serverClient, r.Installation, name, keycloakRoute, resolve); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
 func (r *Reconciler) SetupOpenshiftIDP(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, sso config.RHSSOInterface, kcr *keycloak.KeycloakRealm, redirectUris []string, tenant string) error {
 	var (
 		clientSecret string
@@ -424,7 +446,7 @@ func (r *Reconciler) getClientSecret(ctx context.Context, serverClient k8sclient
 }
 
 func (r *Reconciler) GetOAuthClientName(sso config.RHSSOInterface) string {
-	return r.Installation.Spec.NamespacePrefix + string(sso.GetProductName())
+	return r.Installation.ProductNamespace(string(sso.GetProductName()))
 }
 
 func ContainsIdentityProvider(providers []*keycloak.KeycloakIdentityProvider, alias string) bool {
@@ -473,19 +495,28 @@ func (r *Reconciler) ReconcileCloudResources(dbPRefix string, defaultNamespace s
 	return integreatlyv1alpha1.PhaseAwaitingCloudResources, nil
 }
 
-func (r *Reconciler) PreUpgradeBackupsExecutor(resourceName string) backup.BackupExecutor {
-	if r.Installation.Spec.UseClusterStorage != "false" {
-		return backup.NewNoopBackupExecutor()
+// PreUpgradeBackupsExecutor backs up the Postgres instance of keycloak before
+// its operator is upgraded, with a snapshot on AWS storage or a dump on
+// cluster storage, and its realms when realm exports are configured
+func (r *Reconciler) PreUpgradeBackupsExecutor(productStatus *integreatlyv1alpha1.RHMIProductStatus, resourceName string, keycloakName string, productNamespace string) backup.BackupExecutor {
+	executors := []backup.BackupExecutor{
+		backup.NewPostgresDumpBackupExecutor(r.Installation.Namespace, resourceName),
+	}
+	if r.Installation.Spec.UseClusterStorage == "false" {
+		executors[0] = backup.NewAWSBackupExecutor(
+			r.Installation.Namespace,
+			resourceName,
+			backup.PostgresSnapshotType,
+		)
+	}
+	if spec := r.Installation.Spec.RealmExport; spec != nil {
+		executors = append(executors, realmexport.NewBackupExecutor(spec, keycloakName, productNamespace, r.Installation.Namespace))
 	}
 
-	return backup.NewAWSBackupExecutor(
-		r.Installation.Namespace,
-		resourceName,
-		backup.PostgresSnapshotType,
-	)
+	return backup.NewPreUpgradeHook(backup.NewConcurrentBackupExecutor(executors...), r.Installation, productStatus)
 }
 
-func (r *Reconciler) ReconcileSubscription(ctx context.Context, serverClient k8sclient.Client, inst *integreatlyv1alpha1.RHMI, productNamespace string, operatorNamespace string, resourceName string) (integreatlyv1alpha1.StatusPhase, error) {
+func (r *Reconciler) ReconcileSubscription(ctx context.Context, serverClient k8sclient.Client, inst *integreatlyv1alpha1.RHMI, productNamespace string, operatorNamespace string, preUpgradeBackupExecutor backup.BackupExecutor) (integreatlyv1alpha1.StatusPhase, error) {
 	target := marketplace.Target{
 		SubscriptionName: constants.RHSSOSubscriptionName,
 		Namespace:        operatorNamespace,
@@ -505,7 +536,7 @@ func (r *Reconciler) ReconcileSubscription(ctx context.Context, serverClient k8s
 		ctx,
 		target,
 		[]string{productNamespace},
-		r.PreUpgradeBackupsExecutor(resourceName),
+		preUpgradeBackupExecutor,
 		serverClient,
 		catalogSourceReconciler,
 		r.Log,
@@ -530,6 +561,9 @@ func (r *Reconciler) ReconcileStatefulSet(ctx context.Context, serverClient k8sc
 		resources.AllMutationsOf(
 			resources.MutateMultiAZAntiAffinity(ctx, serverClient, "app"),
 			resources.MutateZoneTopologySpreadConstraints("app"),
+			resources.MutateNodeArchitectureAffinity(ctx, serverClient, integreatlyv1alpha1.ProductRHSSO),
+			resources.MutatePlacement(r.Installation.Spec.Placement),
+			podsecurity.Mutate(r.Installation),
 			mutatePodPriority,
 		),
 		statefulSet,
@@ -728,6 +762,78 @@ func (r *Reconciler) ExportAlerts(ctx context.Context, apiClient k8sclient.Clien
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
+// ReconcileRealmExport exports the realms of the keycloak instance to the
+// bucket of the installation spec, and imports the selected export into it
+func (r *Reconciler) ReconcileRealmExport(ctx context.Context, serverClient k8sclient.Client, keycloakName string, productNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
+	spec := r.Installation.Spec.RealmExport
+	if spec == nil {
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	// the exports and imports go through the admin API and the bucket, which
+	// have no dry run
+	if r.Installation.IsDryRun() {
+		return integreatlyv1alpha1.PhaseFailed, &dryrun.SkippedError{Operation: "realm export"}
+	}
+
+	kc := &keycloak.Keycloak{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: keycloakName, Namespace: productNamespace}, kc); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get keycloak %s: %w", keycloakName, err)
+	}
+	admin, err := realmexport.NewKeycloakAdmin(ctx, serverClient, kc)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	store, err := objectstore.NewS3ObjectStore(ctx, serverClient, r.Installation.Namespace, &spec.Storage)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	if err := realmexport.Reconcile(ctx, serverClient, spec, kc, admin, store, time.Now(), r.Log); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// ReconcileSecretRotation rotates the password of the admin of the keycloak
+// instance. It's changed through the admin API, authenticated with the current
+// password, before the credential secret is updated. The keycloak statefulset
+// reading the secret is rolled out once it's rotated
+func (r *Reconciler) ReconcileSecretRotation(ctx context.Context, serverClient k8sclient.Client, productStatus *integreatlyv1alpha1.RHMIProductStatus, keycloakName string, credentialSecretName string, productNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
+	kc := &keycloak.Keycloak{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: keycloakName, Namespace: productNamespace}, kc); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get keycloak %s: %w", keycloakName, err)
+	}
+
+	admin := rotation.Credential{
+		Name:   "keycloak-admin",
+		Secret: types.NamespacedName{Name: credentialSecretName, Namespace: productNamespace},
+		Keys:   []string{keycloakModel.AdminPasswordProperty},
+		Apply: func(ctx context.Context, rotated *corev1.Secret) error {
+			authenticated, err := r.KeycloakClientFactory.AuthenticatedClient(*kc)
+			if err != nil {
+				return fmt.Errorf("failed to authenticate client in keycloak api %w", err)
+			}
+			username := string(rotated.Data[keycloakModel.AdminUsernameProperty])
+			user, err := authenticated.FindUserByUsername(username, adminRealmName)
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return fmt.Errorf("admin user %s not found in the %s realm", username, adminRealmName)
+			}
+			return authenticated.UpdatePassword(user, adminRealmName, string(rotated.Data[keycloakModel.AdminPasswordProperty]))
+		},
+		Dependents: []rotation.Dependent{{
+			Object:   &k8sappsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "keycloak", Namespace: productNamespace}},
+			Template: resources.SelectFromStatefulSet,
+		}},
+	}
+	if err := rotation.Reconcile(ctx, serverClient, r.Installation, productStatus, time.Now(), r.Log, admin); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
 // ReconcileCSVEnvVars will take a keycloak-operator CSV and a map of env vars to update or create
 func (r *Reconciler) ReconcileCSVEnvVars(csv *operatorsv1alpha1.ClusterServiceVersion, envVars map[string]string) (*operatorsv1alpha1.ClusterServiceVersion, bool, error) {
 	updated := false
@@ -829,3 +935,16 @@ func (r *Reconciler) ConfigureExperimentalSpec(ctx context.Context, serverClient
 	}
 	return nil, nil
 }
+
+// SetFIPSEnv replaces the environment variables of the experimental spec of
+// the keycloak switching it to the FIPS crypto provider, or removes them when
+// the installation doesn't run in FIPS mode
+func SetFIPSEnv(kc *keycloak.Keycloak, installation *integreatlyv1alpha1.RHMI) {
+	env := make([]corev1.EnvVar, 0, len(kc.Spec.KeycloakDeploymentSpec.Experimental.Env))
+	for _, envVar := range kc.Spec.KeycloakDeploymentSpec.Experimental.Env {
+		if envVar.Name != fips.KeycloakJavaOptsEnvName {
+			env = append(env, envVar)
+		}
+	}
+	kc.Spec.KeycloakDeploymentSpec.Experimental.Env = append(env, fips.KeycloakEnv(installation)...)
+}
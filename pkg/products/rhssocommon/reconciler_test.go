@@ -23,11 +23,13 @@ import (
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/rotation"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
 	controllerruntime "sigs.k8s.io/controller-runtime"
 
+	keycloakModel "github.com/integr8ly/keycloak-client/pkg"
 	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
 
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
@@ -1746,3 +1748,90 @@ func retrievePdb(client k8sclient.Client) (*policyv1.PodDisruptionBudget, error)
 
 	return pdb, nil
 }
+
+func TestReconciler_ReconcileSecretRotation(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const credentialSecretName = "credential-" + keycloakName
+	kc := &keycloak.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: keycloakName, Namespace: defaultNamespace}}
+	adminSecret := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: credentialSecretName, Namespace: defaultNamespace, Annotations: annotations},
+			Data: map[string][]byte{
+				keycloakModel.AdminUsernameProperty: []byte("admin"),
+				keycloakModel.AdminPasswordProperty: []byte("admin-password"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		secret      *corev1.Secret
+		updateErr   error
+		want        integreatlyv1alpha1.StatusPhase
+		wantErr     bool
+		wantRotated bool
+	}{
+		{
+			name:   "test keycloak isn't rolled out without a rotation",
+			secret: adminSecret(nil),
+			want:   integreatlyv1alpha1.PhaseCompleted,
+		},
+		{
+			name:        "test admin rotation rolls out keycloak",
+			secret:      adminSecret(map[string]string{rotation.RotateAnnotation: "true"}),
+			want:        integreatlyv1alpha1.PhaseCompleted,
+			wantRotated: true,
+		},
+		{
+			name:      "test keycloak isn't rolled out when the password update fails",
+			secret:    adminSecret(map[string]string{rotation.RotateAnnotation: "true"}),
+			updateErr: errors.New("unauthorized"),
+			want:      integreatlyv1alpha1.PhaseFailed,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "keycloak", Namespace: defaultNamespace}}
+			serverClient := utils.NewTestClient(scheme, kc, tt.secret, statefulSet)
+			kcClient := &keycloakCommon.KeycloakInterfaceMock{
+				FindUserByUsernameFunc: func(name string, realm string) (*keycloak.KeycloakAPIUser, error) {
+					return &keycloak.KeycloakAPIUser{UserName: name}, nil
+				},
+				UpdatePasswordFunc: func(user *keycloak.KeycloakAPIUser, realmName string, newPass string) error {
+					return tt.updateErr
+				},
+			}
+			r := &Reconciler{
+				Installation: utils.NewTestManagedApiInstallation(),
+				Log:          getLogger(),
+				KeycloakClientFactory: &keycloakCommon.KeycloakClientFactoryMock{
+					AuthenticatedClientFunc: func(kc keycloak.Keycloak) (keycloakCommon.KeycloakInterface, error) {
+						return kcClient, nil
+					},
+				},
+			}
+
+			got, err := r.ReconcileSecretRotation(context.TODO(), serverClient, &integreatlyv1alpha1.RHMIProductStatus{}, keycloakName, credentialSecretName, defaultNamespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileSecretRotation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ReconcileSecretRotation() got = %v, want %v", got, tt.want)
+			}
+
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(statefulSet), statefulSet); err != nil {
+				t.Fatal(err)
+			}
+			_, rotated := statefulSet.Spec.Template.Annotations[rotation.RotatedAtAnnotation+"-keycloak-admin"]
+			if rotated != tt.wantRotated {
+				t.Errorf("expected keycloak rolled out to be %v, got %v", tt.wantRotated, rotated)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package rhssocommon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ThemeConfigMapName is the copy of the ConfigMap of the theme of the installation in the namespace of a keycloak
+	ThemeConfigMapName = "rhoam-keycloak-theme"
+	// ThemeHashAnnotation is set on the keycloak pods with the hash of the files of the theme, Keycloak caches the
+	// themes so the pods are rolled out with their changes
+	ThemeHashAnnotation = "integreatly.org/theme-hash"
+
+	themeVolumeName = "rhoam-keycloak-theme"
+	themesDir       = "/opt/eap/themes"
+	// themePathSeparator stands for the / of the paths of the files of the theme in the keys of its ConfigMap
+	themePathSeparator = "__"
+)
+
+// ReconcileThemeConfigMap copies the ConfigMap holding the files of the theme of the installation into the namespace
+// of a keycloak, or removes the copy once the theme isn't deployed from a ConfigMap. It returns the copy, nil when
+// there's none
+func (r *Reconciler) ReconcileThemeConfigMap(ctx context.Context, serverClient k8sclient.Client, namespace string) (*corev1.ConfigMap, error) {
+	theme := r.Installation.Spec.KeycloakTheme
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ThemeConfigMapName,
+			Namespace: namespace,
+		},
+	}
+	if theme == nil || theme.ConfigMap == "" {
+		if err := serverClient.Delete(ctx, configMap); err != nil && !k8serr.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to remove the theme config map from %s: %w", namespace, err)
+		}
+		return nil, nil
+	}
+
+	source := &corev1.ConfigMap{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: theme.ConfigMap, Namespace: r.Installation.Namespace}, source); err != nil {
+		return nil, fmt.Errorf("failed to get the %s theme config map: %w", theme.ConfigMap, err)
+	}
+	or, err := controllerutil.CreateOrUpdate(ctx, serverClient, configMap, func() error {
+		configMap.Data = source.Data
+		configMap.BinaryData = source.BinaryData
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy the %s theme config map to %s: %w", theme.ConfigMap, namespace, err)
+	}
+	if or != controllerutil.OperationResultNone {
+		r.Log.Infof("Operation result", l.Fields{"configMap": ThemeConfigMapName, "namespace": namespace, "result": or})
+	}
+	return configMap, nil
+}
+
+// SetTheme mounts the files of the theme of the installation into the themes directory of the keycloak, or deploys
+// its archive as an extension, and removes them once the theme isn't set. The extensions of the keycloak are
+// expected to be reset before, as the archive is appended to them
+func SetTheme(kc *keycloak.Keycloak, installation *integreatlyv1alpha1.RHMI, configMap *corev1.ConfigMap) {
+	volumes := make([]keycloak.VolumeSpec, 0, len(kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items))
+	for _, volume := range kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items {
+		if volume.Name != themeVolumeName {
+			volumes = append(volumes, volume)
+		}
+	}
+	delete(kc.Spec.KeycloakDeploymentSpec.PodAnnotations, ThemeHashAnnotation)
+
+	theme := installation.Spec.KeycloakTheme
+	if theme != nil && theme.ArchiveURL != "" && !resources.Contains(kc.Spec.Extensions, theme.ArchiveURL) {
+		kc.Spec.Extensions = append(kc.Spec.Extensions, theme.ArchiveURL)
+	}
+	if theme != nil && configMap != nil {
+		keys := themeFiles(configMap)
+		items := make([]corev1.KeyToPath, 0, len(keys))
+		hash := sha256.New()
+		for _, key := range keys {
+			items = append(items, corev1.KeyToPath{Key: key, Path: strings.ReplaceAll(key, themePathSeparator, "/")})
+			hash.Write([]byte(key))
+			hash.Write([]byte(configMap.Data[key]))
+			hash.Write(configMap.BinaryData[key])
+		}
+		volumes = append(volumes, keycloak.VolumeSpec{
+			Name:       themeVolumeName,
+			MountPath:  fmt.Sprintf("%s/%s", themesDir, theme.Name),
+			ConfigMaps: []string{configMap.Name},
+			Items:      items,
+		})
+		if kc.Spec.KeycloakDeploymentSpec.PodAnnotations == nil {
+			kc.Spec.KeycloakDeploymentSpec.PodAnnotations = map[string]string{}
+		}
+		kc.Spec.KeycloakDeploymentSpec.PodAnnotations[ThemeHashAnnotation] = hex.EncodeToString(hash.Sum(nil))
+	}
+	kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items = volumes
+}
+
+// themeFiles returns the keys of the files of the theme in its ConfigMap, sorted
+func themeFiles(configMap *corev1.ConfigMap) []string {
+	keys := make([]string, 0, len(configMap.Data)+len(configMap.BinaryData))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	for key := range configMap.BinaryData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReconcileRealmTheme sets the theme of the installation as the login and email theme of the realm. The realm is
+// left as it is without it, so the themes set on it by other means are kept
+func (r *Reconciler) ReconcileRealmTheme(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, kcClient keycloakCommon.KeycloakInterface, realmName string) error {
+	theme := r.Installation.Spec.KeycloakTheme
+	if theme == nil {
+		return nil
+	}
+
+	realm, err := kcClient.GetRealm(realmName)
+	if err != nil {
+		return fmt.Errorf("failed to get realm %s: %w", realmName, err)
+	}
+	if realm == nil || realm.Spec.Realm == nil {
+		return fmt.Errorf("realm %s not found", realmName)
+	}
+
+	settings := map[string]interface{}{}
+	if loginTheme := realmTheme(theme, integreatlyv1alpha1.KeycloakThemeLogin, realm.Spec.Realm.LoginTheme); loginTheme != realm.Spec.Realm.LoginTheme {
+		settings["loginTheme"] = loginTheme
+	}
+	if emailTheme := realmTheme(theme, integreatlyv1alpha1.KeycloakThemeEmail, realm.Spec.Realm.EmailTheme); emailTheme != realm.Spec.Realm.EmailTheme {
+		settings["emailTheme"] = emailTheme
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	r.Log.Infof("Configuring realm theme", l.Fields{"realm": realmName, "settings": settings})
+	return r.UpdateRealmSettings(ctx, serverClient, kc, realmName, settings)
+}
+
+// realmTheme returns the theme of the given type of a realm currently using the given one. The theme of the
+// installation is removed from the types it no longer applies to, the other themes are left as they are
+func realmTheme(theme *integreatlyv1alpha1.KeycloakThemeSpec, themeType integreatlyv1alpha1.KeycloakThemeType, current string) string {
+	if theme == nil {
+		return current
+	}
+	if len(theme.Types) == 0 || containsThemeType(theme.Types, themeType) {
+		return theme.Name
+	}
+	if current == theme.Name {
+		return ""
+	}
+	return current
+}
+
+func containsThemeType(types []integreatlyv1alpha1.KeycloakThemeType, themeType integreatlyv1alpha1.KeycloakThemeType) bool {
+	for _, t := range types {
+		if t == themeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package rhssocommon
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSetTheme(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ThemeConfigMapName},
+		Data: map[string]string{
+			"login__theme.properties":                 "parent=rh-sso",
+			"email__messages__messages_en.properties": "emailVerificationSubject=Verify your ACME account",
+		},
+		BinaryData: map[string][]byte{"login__resources__img__logo.png": []byte("png")},
+	}
+	otherVolume := keycloak.VolumeSpec{Name: "other", MountPath: "/opt/other"}
+
+	tests := []struct {
+		name           string
+		theme          *integreatlyv1alpha1.KeycloakThemeSpec
+		configMap      *corev1.ConfigMap
+		wantExtensions []string
+		wantVolumes    []keycloak.VolumeSpec
+		wantHash       bool
+	}{
+		{
+			name:      "theme mounted from its ConfigMap",
+			theme:     &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", ConfigMap: "acme-theme"},
+			configMap: configMap,
+			wantVolumes: []keycloak.VolumeSpec{otherVolume, {
+				Name:       themeVolumeName,
+				MountPath:  "/opt/eap/themes/acme",
+				ConfigMaps: []string{ThemeConfigMapName},
+				Items: []corev1.KeyToPath{
+					{Key: "email__messages__messages_en.properties", Path: "email/messages/messages_en.properties"},
+					{Key: "login__resources__img__logo.png", Path: "login/resources/img/logo.png"},
+					{Key: "login__theme.properties", Path: "login/theme.properties"},
+				},
+			}},
+			wantHash: true,
+		},
+		{
+			name:           "theme deployed from its archive",
+			theme:          &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", ArchiveURL: "https://example.com/acme.jar"},
+			wantExtensions: []string{KeycloakMetricsExtension, "https://example.com/acme.jar"},
+			wantVolumes:    []keycloak.VolumeSpec{otherVolume},
+		},
+		{
+			name:        "theme removed",
+			wantVolumes: []keycloak.VolumeSpec{otherVolume},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := &keycloak.Keycloak{}
+			kc.Spec.Extensions = []string{KeycloakMetricsExtension}
+			kc.Spec.KeycloakDeploymentSpec.PodAnnotations = map[string]string{ThemeHashAnnotation: "previous"}
+			kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items = []keycloak.VolumeSpec{otherVolume, {Name: themeVolumeName, MountPath: "/opt/eap/themes/previous"}}
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{KeycloakTheme: tt.theme}}
+
+			SetTheme(kc, installation, tt.configMap)
+			SetTheme(kc, installation, tt.configMap)
+
+			wantExtensions := tt.wantExtensions
+			if wantExtensions == nil {
+				wantExtensions = []string{KeycloakMetricsExtension}
+			}
+			if !reflect.DeepEqual(kc.Spec.Extensions, wantExtensions) {
+				t.Errorf("extensions = %v, want %v", kc.Spec.Extensions, wantExtensions)
+			}
+			if !reflect.DeepEqual(kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items, tt.wantVolumes) {
+				t.Errorf("volumes = %+v, want %+v", kc.Spec.KeycloakDeploymentSpec.Experimental.Volumes.Items, tt.wantVolumes)
+			}
+			hash, ok := kc.Spec.KeycloakDeploymentSpec.PodAnnotations[ThemeHashAnnotation]
+			if ok != tt.wantHash || hash == "previous" {
+				t.Errorf("theme hash annotation = %q, want it set %v", hash, tt.wantHash)
+			}
+		})
+	}
+}
+
+func TestReconciler_ReconcileThemeConfigMap(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme-theme", Namespace: customizationNamespace},
+		Data:       map[string]string{"login__theme.properties": "parent=rh-sso"},
+	}
+	serverClient := utils.NewTestClient(scheme, source)
+	installation := &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: customizationNamespace}}
+	installation.Spec.KeycloakTheme = &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", ConfigMap: "acme-theme"}
+	r := &Reconciler{Installation: installation, Log: l.NewLogger()}
+
+	configMap, err := r.ReconcileThemeConfigMap(context.TODO(), serverClient, "redhat-rhoam-rhsso")
+	if err != nil {
+		t.Fatalf("ReconcileThemeConfigMap() error = %v", err)
+	}
+	copied := &corev1.ConfigMap{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: ThemeConfigMapName, Namespace: "redhat-rhoam-rhsso"}, copied); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(copied.Data, source.Data) || configMap.Name != ThemeConfigMapName {
+		t.Errorf("theme config map = %v, want a copy of %v", copied.Data, source.Data)
+	}
+
+	installation.Spec.KeycloakTheme = &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", ArchiveURL: "https://example.com/acme.jar"}
+	if configMap, err = r.ReconcileThemeConfigMap(context.TODO(), serverClient, "redhat-rhoam-rhsso"); err != nil || configMap != nil {
+		t.Fatalf("ReconcileThemeConfigMap() = %v, %v, want no config map", configMap, err)
+	}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: ThemeConfigMapName, Namespace: "redhat-rhoam-rhsso"}, copied); !k8serr.IsNotFound(err) {
+		t.Errorf("expected the theme config map to be removed, got %v", err)
+	}
+}
+
+func TestRealmTheme(t *testing.T) {
+	tests := []struct {
+		name      string
+		theme     *integreatlyv1alpha1.KeycloakThemeSpec
+		themeType integreatlyv1alpha1.KeycloakThemeType
+		current   string
+		want      string
+	}{
+		{
+			name:      "theme of every type by default",
+			theme:     &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme"},
+			themeType: integreatlyv1alpha1.KeycloakThemeEmail,
+			current:   "rh-sso",
+			want:      "acme",
+		},
+		{
+			name:      "theme of another type",
+			theme:     &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", Types: []integreatlyv1alpha1.KeycloakThemeType{integreatlyv1alpha1.KeycloakThemeLogin}},
+			themeType: integreatlyv1alpha1.KeycloakThemeEmail,
+			current:   "acme",
+			want:      "",
+		},
+		{
+			name:      "other theme of another type left as it is",
+			theme:     &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", Types: []integreatlyv1alpha1.KeycloakThemeType{integreatlyv1alpha1.KeycloakThemeLogin}},
+			themeType: integreatlyv1alpha1.KeycloakThemeEmail,
+			current:   "customer",
+			want:      "customer",
+		},
+		{
+			name:      "theme of Keycloak left as it is without the theme of the installation",
+			themeType: integreatlyv1alpha1.KeycloakThemeLogin,
+			current:   "keycloak",
+			want:      "keycloak",
+		},
+		{
+			name:      "other theme left as it is without the theme of the installation",
+			themeType: integreatlyv1alpha1.KeycloakThemeLogin,
+			current:   "customer",
+			want:      "customer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := realmTheme(tt.theme, tt.themeType, tt.current); got != tt.want {
+				t.Errorf("realmTheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconciler_ReconcileRealmTheme(t *testing.T) {
+	kcClient := &keycloakCommon.KeycloakInterfaceMock{
+		GetRealmFunc: func(realmName string) (*keycloak.KeycloakRealm, error) {
+			return &keycloak.KeycloakRealm{Spec: keycloak.KeycloakRealmSpec{Realm: &keycloak.KeycloakAPIRealm{Realm: realmName, LoginTheme: "acme", EmailTheme: "acme"}}}, nil
+		},
+	}
+	installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{KeycloakTheme: &integreatlyv1alpha1.KeycloakThemeSpec{Name: "acme", ConfigMap: "acme-theme"}}}
+	r := &Reconciler{Installation: installation, Log: l.NewLogger()}
+
+	// the realm already uses the theme, the admin API isn't called
+	if err := r.ReconcileRealmTheme(context.TODO(), nil, &keycloak.Keycloak{}, kcClient, "openshift"); err != nil {
+		t.Fatalf("ReconcileRealmTheme() error = %v", err)
+	}
+
+	// without the theme of the installation the realm is left alone
+	installation.Spec.KeycloakTheme = nil
+	if err := r.ReconcileRealmTheme(context.TODO(), nil, &keycloak.Keycloak{}, kcClient, "openshift"); err != nil {
+		t.Fatalf("ReconcileRealmTheme() error = %v", err)
+	}
+	if calls := len(kcClient.GetRealmCalls()); calls != 1 {
+		t.Errorf("expected the realm to be read once, with the theme of the installation, got %d reads", calls)
+	}
+}
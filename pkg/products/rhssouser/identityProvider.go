@@ -0,0 +1,379 @@
+package rhssouser
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultIdentityProviderAlias = "corporate"
+	// identityProviderMarker is set in the config of the identity provider federated from the installation spec, the
+	// marked identity providers with another alias are removed from the realm
+	identityProviderMarker = "integreatly.org/user-sso-identity-provider"
+
+	samlPostBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	// maxIdentityProviderDocumentSize limits the discovery documents and metadata read from the identity providers
+	maxIdentityProviderDocumentSize = 1 << 20
+)
+
+var identityProviderHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// reconcileIdentityProvider federates the master realm with the corporate identity provider of the installation. Its
+// configuration is read from its OpenID Connect discovery document or SAML metadata on every reconcile, which
+// validates it's reachable. An identity provider that can't be read is reported in the product status without
+// failing the product, the realm keeps its last configuration
+func (r *Reconciler) reconcileIdentityProvider(ctx context.Context, serverClient k8sclient.Client, productStatus *integreatlyv1alpha1.RHMIProductStatus) (integreatlyv1alpha1.StatusPhase, error) {
+	spec := r.Installation.Spec.UserSSOIdentityProvider
+	if spec == nil && productStatus.IdentityProvider == nil {
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	kc := &keycloak.Keycloak{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: keycloakName, Namespace: r.Config.GetNamespace()}, kc); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get keycloak %s: %w", keycloakName, err)
+	}
+	kcClient, err := r.KeycloakClientFactory.AuthenticatedClient(*kc)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to authenticate client in keycloak api %w", err)
+	}
+	identityProviders, err := kcClient.ListIdentityProviders(masterRealmName)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to list the identity providers of the user SSO realm: %w", err)
+	}
+
+	alias := identityProviderAlias(spec)
+	var existing *keycloak.KeycloakIdentityProvider
+	for _, idp := range identityProviders {
+		if idp.Alias == alias {
+			existing = idp
+			continue
+		}
+		if idp.Config[identityProviderMarker] == "" {
+			continue
+		}
+		if err := kcClient.DeleteIdentityProvider(idp.Alias, masterRealmName); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to remove the %s identity provider of the user SSO realm: %w", idp.Alias, err)
+		}
+		r.Log.Infof("Removed identity provider", l.Fields{"alias": idp.Alias})
+	}
+	if spec == nil {
+		productStatus.IdentityProvider = nil
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	if existing != nil && existing.Config[identityProviderMarker] == "" {
+		setIdentityProviderStatus(productStatus, alias, false, fmt.Sprintf("the %s identity provider of the realm isn't managed by the installation", alias), time.Now())
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	config, err := r.identityProviderConfig(ctx, serverClient, spec)
+	if err != nil {
+		r.Log.Error(fmt.Sprintf("Failed to read the configuration of the %s identity provider", alias), err)
+		setIdentityProviderStatus(productStatus, alias, false, err.Error(), time.Now())
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	idp := &keycloak.KeycloakIdentityProvider{
+		Alias:                     alias,
+		DisplayName:               spec.DisplayName,
+		ProviderID:                string(spec.Type),
+		Enabled:                   true,
+		TrustEmail:                spec.TrustEmail,
+		FirstBrokerLoginFlowAlias: firstBrokerLoginFlowAlias,
+		Config:                    config,
+	}
+	if existing == nil {
+		if _, err := kcClient.CreateIdentityProvider(idp, masterRealmName); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to create the %s identity provider: %w", alias, err)
+		}
+		r.Log.Infof("Created identity provider", l.Fields{"alias": alias, "type": spec.Type})
+	} else {
+		idp.InternalID = existing.InternalID
+		if err := kcClient.UpdateIdentityProvider(idp, masterRealmName); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update the %s identity provider: %w", alias, err)
+		}
+	}
+
+	if err := r.reconcileIdentityProviderMappers(ctx, serverClient, kc, alias, spec.Mappers); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	setIdentityProviderStatus(productStatus, alias, true, "", time.Now())
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+func identityProviderAlias(spec *integreatlyv1alpha1.UserSSOIdentityProviderSpec) string {
+	if spec == nil {
+		return ""
+	}
+	if spec.Alias == "" {
+		return defaultIdentityProviderAlias
+	}
+	return spec.Alias
+}
+
+// identityProviderConfig returns the Keycloak config of the identity provider, read from the identity provider and
+// completed with the entries of its config secret
+func (r *Reconciler) identityProviderConfig(ctx context.Context, serverClient k8sclient.Client, spec *integreatlyv1alpha1.UserSSOIdentityProviderSpec) (map[string]string, error) {
+	var config map[string]string
+	var err error
+	switch spec.Type {
+	case integreatlyv1alpha1.UserSSOIdentityProviderOIDC:
+		config, err = discoverOIDCProvider(ctx, spec.IssuerURL)
+		if spec.ClientID != "" && err == nil {
+			config["clientId"] = spec.ClientID
+		}
+	case integreatlyv1alpha1.UserSSOIdentityProviderSAML:
+		config, err = readSAMLMetadata(ctx, spec.MetadataURL)
+	default:
+		err = fmt.Errorf("unsupported identity provider type %s", spec.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.ConfigSecret != "" {
+		secret := &corev1.Secret{}
+		if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: spec.ConfigSecret, Namespace: r.Installation.Namespace}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the %s config secret: %w", spec.ConfigSecret, err)
+		}
+		for key, value := range secret.Data {
+			config[key] = string(value)
+		}
+	}
+	if spec.Type == integreatlyv1alpha1.UserSSOIdentityProviderOIDC && config["clientId"] == "" {
+		return nil, fmt.Errorf("the clientId of the OpenID Connect provider isn't set by clientID or the config secret")
+	}
+
+	if config["syncMode"] == "" {
+		config["syncMode"] = "IMPORT"
+	}
+	config[identityProviderMarker] = "true"
+	return config, nil
+}
+
+type oidcDiscoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// discoverOIDCProvider returns the config of an OpenID Connect provider from its discovery document
+func discoverOIDCProvider(ctx context.Context, issuerURL string) (map[string]string, error) {
+	body, err := getIdentityProviderDocument(ctx, strings.TrimSuffix(issuerURL, "/")+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	discovery := &oidcDiscoveryDocument{}
+	if err := json.Unmarshal(body, discovery); err != nil {
+		return nil, fmt.Errorf("failed to parse the discovery document of %s: %w", issuerURL, err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return nil, fmt.Errorf("the discovery document of %s is issued by %s", issuerURL, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" {
+		return nil, fmt.Errorf("the discovery document of %s has no authorization or token endpoint", issuerURL)
+	}
+
+	config := map[string]string{
+		"issuer":            discovery.Issuer,
+		"authorizationUrl":  discovery.AuthorizationEndpoint,
+		"tokenUrl":          discovery.TokenEndpoint,
+		"clientAuthMethod":  "client_secret_post",
+		"defaultScope":      "openid profile email",
+		"validateSignature": "false",
+	}
+	if discovery.UserinfoEndpoint != "" {
+		config["userInfoUrl"] = discovery.UserinfoEndpoint
+	}
+	if discovery.EndSessionEndpoint != "" {
+		config["logoutUrl"] = discovery.EndSessionEndpoint
+	}
+	if discovery.JwksURI != "" {
+		config["jwksUrl"] = discovery.JwksURI
+		config["useJwksUrl"] = "true"
+		config["validateSignature"] = "true"
+	}
+	return config, nil
+}
+
+type samlEntityDescriptor struct {
+	EntityID         string `xml:"entityID,attr"`
+	IDPSSODescriptor *struct {
+		KeyDescriptors []struct {
+			Use         string `xml:"use,attr"`
+			Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+		} `xml:"KeyDescriptor"`
+		SingleSignOnServices []samlEndpoint `xml:"SingleSignOnService"`
+		SingleLogoutServices []samlEndpoint `xml:"SingleLogoutService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+type samlEndpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+// readSAMLMetadata returns the config of a SAML provider from its metadata, preferring its HTTP-POST endpoints
+func readSAMLMetadata(ctx context.Context, metadataURL string) (map[string]string, error) {
+	body, err := getIdentityProviderDocument(ctx, metadataURL)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &samlEntityDescriptor{}
+	if err := xml.Unmarshal(body, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse the SAML metadata of %s: %w", metadataURL, err)
+	}
+	if metadata.IDPSSODescriptor == nil {
+		return nil, fmt.Errorf("the SAML metadata of %s doesn't describe an identity provider", metadataURL)
+	}
+	signOn := samlEndpointFor(metadata.IDPSSODescriptor.SingleSignOnServices)
+	if signOn == nil {
+		return nil, fmt.Errorf("the SAML metadata of %s has no single sign-on service", metadataURL)
+	}
+
+	postBinding := fmt.Sprint(signOn.Binding == samlPostBinding)
+	config := map[string]string{
+		"idpEntityId":             metadata.EntityID,
+		"singleSignOnServiceUrl":  signOn.Location,
+		"postBindingAuthnRequest": postBinding,
+		"postBindingResponse":     "true",
+		"principalType":           "SUBJECT",
+		"nameIDPolicyFormat":      "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified",
+		"validateSignature":       "false",
+	}
+	if logout := samlEndpointFor(metadata.IDPSSODescriptor.SingleLogoutServices); logout != nil {
+		config["singleLogoutServiceUrl"] = logout.Location
+		config["postBindingLogout"] = fmt.Sprint(logout.Binding == samlPostBinding)
+	}
+	var certificates []string
+	for _, key := range metadata.IDPSSODescriptor.KeyDescriptors {
+		if key.Use != "encryption" && key.Certificate != "" {
+			certificates = append(certificates, strings.Join(strings.Fields(key.Certificate), ""))
+		}
+	}
+	if len(certificates) > 0 {
+		config["signingCertificate"] = strings.Join(certificates, ",")
+		config["validateSignature"] = "true"
+	}
+	return config, nil
+}
+
+func samlEndpointFor(endpoints []samlEndpoint) *samlEndpoint {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	for i := range endpoints {
+		if endpoints[i].Binding == samlPostBinding {
+			return &endpoints[i]
+		}
+	}
+	return &endpoints[0]
+}
+
+func getIdentityProviderDocument(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := identityProviderHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the identity provider: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", url, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxIdentityProviderDocumentSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+	return body, nil
+}
+
+type identityProviderMapper struct {
+	ID                     string            `json:"id,omitempty"`
+	Name                   string            `json:"name"`
+	IdentityProviderAlias  string            `json:"identityProviderAlias"`
+	IdentityProviderMapper string            `json:"identityProviderMapper"`
+	Config                 map[string]string `json:"config"`
+}
+
+// reconcileIdentityProviderMappers makes the mappers of the identity provider the ones of the spec. The keycloak
+// client doesn't cover the mappers so they're managed with the admin API
+func (r *Reconciler) reconcileIdentityProviderMappers(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, alias string, specs []integreatlyv1alpha1.IdentityProviderMapperSpec) error {
+	path := fmt.Sprintf("realms/%s/identity-provider/instances/%s/mappers", masterRealmName, alias)
+	existing := []identityProviderMapper{}
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodGet, path, nil, &existing); err != nil {
+		return fmt.Errorf("failed to list the mappers of the %s identity provider: %w", alias, err)
+	}
+	byName := map[string]identityProviderMapper{}
+	for _, mapper := range existing {
+		byName[mapper.Name] = mapper
+	}
+
+	for _, spec := range specs {
+		mapper := identityProviderMapper{
+			Name:                   spec.Name,
+			IdentityProviderAlias:  alias,
+			IdentityProviderMapper: spec.Type,
+			Config:                 map[string]string{"syncMode": "INHERIT"},
+		}
+		for key, value := range spec.Config {
+			mapper.Config[key] = value
+		}
+
+		current, ok := byName[spec.Name]
+		if !ok {
+			if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPost, path, mapper, nil); err != nil {
+				return fmt.Errorf("failed to create the %s mapper of the %s identity provider: %w", spec.Name, alias, err)
+			}
+			continue
+		}
+		delete(byName, spec.Name)
+		if current.IdentityProviderMapper == mapper.IdentityProviderMapper && reflect.DeepEqual(current.Config, mapper.Config) {
+			continue
+		}
+		mapper.ID = current.ID
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPut, path+"/"+current.ID, mapper, nil); err != nil {
+			return fmt.Errorf("failed to update the %s mapper of the %s identity provider: %w", spec.Name, alias, err)
+		}
+	}
+
+	for name, mapper := range byName {
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodDelete, path+"/"+mapper.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to remove the %s mapper of the %s identity provider: %w", name, alias, err)
+		}
+	}
+	return nil
+}
+
+// setIdentityProviderStatus reports the connectivity of the identity provider, its transition time only changes
+// with the connectivity
+func setIdentityProviderStatus(productStatus *integreatlyv1alpha1.RHMIProductStatus, alias string, connected bool, message string, now time.Time) {
+	if status := productStatus.IdentityProvider; status != nil && status.Alias == alias && status.Connected == connected {
+		status.Message = message
+		return
+	}
+	productStatus.IdentityProvider = &integreatlyv1alpha1.IdentityProviderStatus{
+		Alias:              alias,
+		Connected:          connected,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(now),
+	}
+}
@@ -0,0 +1,295 @@
+package rhssouser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/products/rhssocommon"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const samlMetadata = `<?xml version="1.0"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" entityID="http://www.okta.com/exk1">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo><ds:X509Data><ds:X509Certificate>
+        MIIDpDCCAoygAwIBAgIGAX
+        signing
+      </ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://okta.example.com/sso/redirect"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://okta.example.com/sso/post"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+
+// newIdentityProviderServer serves the discovery document of an OpenID Connect provider, the metadata of a SAML
+// provider and the mappers endpoints of the Keycloak admin API, recording the mappers created
+func newIdentityProviderServer(t *testing.T, createdMappers *[]identityProviderMapper) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/oidc/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(oidcDiscoveryDocument{
+				Issuer:                server.URL + "/oidc",
+				AuthorizationEndpoint: server.URL + "/oidc/authorize",
+				TokenEndpoint:         server.URL + "/oidc/token",
+				JwksURI:               server.URL + "/oidc/keys",
+			})
+		case "/other/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(oidcDiscoveryDocument{Issuer: "https://login.example.com", AuthorizationEndpoint: "a", TokenEndpoint: "t"})
+		case "/saml/metadata":
+			_, _ = w.Write([]byte(samlMetadata))
+		case "/auth/realms/master/protocol/openid-connect/token":
+			_ = json.NewEncoder(w).Encode(keycloak.TokenResponse{AccessToken: "token"})
+		case "/auth/admin/realms/master/identity-provider/instances/corporate/mappers":
+			if req.Method == http.MethodPost {
+				mapper := identityProviderMapper{}
+				_ = json.NewDecoder(req.Body).Decode(&mapper)
+				*createdMappers = append(*createdMappers, mapper)
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]identityProviderMapper{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDiscoverOIDCProvider(t *testing.T) {
+	server := newIdentityProviderServer(t, nil)
+
+	tests := []struct {
+		name      string
+		issuerURL string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "endpoints of the discovery document",
+			issuerURL: server.URL + "/oidc/",
+			want: map[string]string{
+				"authorizationUrl":  server.URL + "/oidc/authorize",
+				"tokenUrl":          server.URL + "/oidc/token",
+				"jwksUrl":           server.URL + "/oidc/keys",
+				"validateSignature": "true",
+			},
+		},
+		{
+			name:      "document issued for another issuer",
+			issuerURL: server.URL + "/other",
+			wantErr:   true,
+		},
+		{
+			name:      "unreachable issuer",
+			issuerURL: server.URL + "/missing",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := discoverOIDCProvider(context.TODO(), tt.issuerURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("discoverOIDCProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("config %s = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestReadSAMLMetadata(t *testing.T) {
+	server := newIdentityProviderServer(t, nil)
+
+	got, err := readSAMLMetadata(context.TODO(), server.URL+"/saml/metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"idpEntityId":             "http://www.okta.com/exk1",
+		"singleSignOnServiceUrl":  "https://okta.example.com/sso/post",
+		"postBindingAuthnRequest": "true",
+		"signingCertificate":      "MIIDpDCCAoygAwIBAgIGAXsigning",
+		"validateSignature":       "true",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("config %s = %q, want %q", key, got[key], value)
+		}
+	}
+
+	if _, err := readSAMLMetadata(context.TODO(), server.URL+"/oidc/.well-known/openid-configuration"); err == nil {
+		t.Error("expected an error reading a document that isn't SAML metadata")
+	}
+}
+
+func TestReconciler_reconcileIdentityProvider(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		idp               func(serverURL string) *integreatlyv1alpha1.UserSSOIdentityProviderSpec
+		status            *integreatlyv1alpha1.IdentityProviderStatus
+		identityProviders []*keycloak.KeycloakIdentityProvider
+		wantCreated       bool
+		wantDeleted       []string
+		wantMappers       int
+		wantStatus        *integreatlyv1alpha1.IdentityProviderStatus
+	}{
+		{
+			name: "nothing to do without identity provider",
+		},
+		{
+			name: "OpenID Connect provider created with its mappers",
+			idp: func(serverURL string) *integreatlyv1alpha1.UserSSOIdentityProviderSpec {
+				return &integreatlyv1alpha1.UserSSOIdentityProviderSpec{
+					Type:         integreatlyv1alpha1.UserSSOIdentityProviderOIDC,
+					IssuerURL:    serverURL + "/oidc",
+					ClientID:     "rhoam",
+					ConfigSecret: "corporate-idp",
+					Mappers:      []integreatlyv1alpha1.IdentityProviderMapperSpec{{Name: "email", Type: "oidc-user-attribute-idp-mapper", Config: map[string]string{"claim": "email"}}},
+				}
+			},
+			identityProviders: []*keycloak.KeycloakIdentityProvider{
+				{Alias: idpAlias, Config: map[string]string{}},
+				{Alias: "okta", Config: map[string]string{identityProviderMarker: "true"}},
+			},
+			wantCreated: true,
+			wantDeleted: []string{"okta"},
+			wantMappers: 1,
+			wantStatus:  &integreatlyv1alpha1.IdentityProviderStatus{Alias: "corporate", Connected: true},
+		},
+		{
+			name: "unreachable identity provider reported in the status",
+			idp: func(serverURL string) *integreatlyv1alpha1.UserSSOIdentityProviderSpec {
+				return &integreatlyv1alpha1.UserSSOIdentityProviderSpec{Type: integreatlyv1alpha1.UserSSOIdentityProviderSAML, MetadataURL: serverURL + "/missing"}
+			},
+			wantStatus: &integreatlyv1alpha1.IdentityProviderStatus{Alias: "corporate", Message: "failed to get"},
+		},
+		{
+			name: "identity provider created in the console left as it is",
+			idp: func(serverURL string) *integreatlyv1alpha1.UserSSOIdentityProviderSpec {
+				return &integreatlyv1alpha1.UserSSOIdentityProviderSpec{Type: integreatlyv1alpha1.UserSSOIdentityProviderSAML, MetadataURL: serverURL + "/saml/metadata"}
+			},
+			identityProviders: []*keycloak.KeycloakIdentityProvider{{Alias: "corporate", Config: map[string]string{}}},
+			wantStatus:        &integreatlyv1alpha1.IdentityProviderStatus{Alias: "corporate", Message: "isn't managed by the installation"},
+		},
+		{
+			name:              "identity provider removed from the spec",
+			status:            &integreatlyv1alpha1.IdentityProviderStatus{Alias: "corporate", Connected: true},
+			identityProviders: []*keycloak.KeycloakIdentityProvider{{Alias: "corporate", Config: map[string]string{identityProviderMarker: "true"}}},
+			wantDeleted:       []string{"corporate"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdMappers []identityProviderMapper
+			server := newIdentityProviderServer(t, &createdMappers)
+
+			kc := &keycloak.Keycloak{
+				ObjectMeta: metav1.ObjectMeta{Name: keycloakName, Namespace: "user-sso"},
+				Status:     keycloak.KeycloakStatus{CredentialSecret: adminCredentialSecretName, ExternalURL: server.URL},
+			}
+			serverClient := utils.NewTestClient(scheme, kc, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: adminCredentialSecretName, Namespace: "user-sso"},
+				Data:       map[string][]byte{"ADMIN_USERNAME": []byte("admin"), "ADMIN_PASSWORD": []byte("password")},
+			}, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "corporate-idp", Namespace: "rhoam-operator"},
+				Data:       map[string][]byte{"clientSecret": []byte("client-secret")},
+			})
+
+			var created *keycloak.KeycloakIdentityProvider
+			var deleted []string
+			kcClient := &keycloakCommon.KeycloakInterfaceMock{
+				ListIdentityProvidersFunc: func(realmName string) ([]*keycloak.KeycloakIdentityProvider, error) {
+					return tt.identityProviders, nil
+				},
+				CreateIdentityProviderFunc: func(idp *keycloak.KeycloakIdentityProvider, realmName string) (string, error) {
+					created = idp
+					return idp.Alias, nil
+				},
+				DeleteIdentityProviderFunc: func(alias string, realmName string) error {
+					deleted = append(deleted, alias)
+					return nil
+				},
+			}
+
+			installation := &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "rhoam-operator"}}
+			if tt.idp != nil {
+				installation.Spec.UserSSOIdentityProvider = tt.idp(server.URL)
+			}
+			r := &Reconciler{
+				Config: config.NewRHSSOUser(config.ProductConfig{"NAMESPACE": "user-sso"}),
+				Log:    getLogger(),
+				Reconciler: &rhssocommon.Reconciler{
+					Installation: installation,
+					KeycloakClientFactory: &keycloakCommon.KeycloakClientFactoryMock{
+						AuthenticatedClientFunc: func(kc keycloak.Keycloak) (keycloakCommon.KeycloakInterface, error) {
+							return kcClient, nil
+						},
+					},
+				},
+			}
+			productStatus := &integreatlyv1alpha1.RHMIProductStatus{IdentityProvider: tt.status}
+
+			phase, err := r.reconcileIdentityProvider(context.TODO(), serverClient, productStatus)
+			if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+				t.Fatalf("reconcileIdentityProvider() = %s, %v", phase, err)
+			}
+
+			if (created != nil) != tt.wantCreated {
+				t.Fatalf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if created != nil {
+				if created.Config["clientId"] != "rhoam" || created.Config["clientSecret"] != "client-secret" || created.Config["tokenUrl"] != server.URL+"/oidc/token" {
+					t.Errorf("unexpected config of the identity provider: %v", created.Config)
+				}
+			}
+			if fmt.Sprint(deleted) != fmt.Sprint(tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if len(createdMappers) != tt.wantMappers {
+				t.Errorf("created mappers = %v, want %d", createdMappers, tt.wantMappers)
+			}
+			for _, mapper := range createdMappers {
+				if mapper.IdentityProviderAlias != "corporate" || mapper.Config["syncMode"] != "INHERIT" {
+					t.Errorf("unexpected mapper %+v", mapper)
+				}
+			}
+
+			got := productStatus.IdentityProvider
+			if (got == nil) != (tt.wantStatus == nil) {
+				t.Fatalf("status = %+v, want %+v", got, tt.wantStatus)
+			}
+			if got == nil {
+				return
+			}
+			if got.Alias != tt.wantStatus.Alias || got.Connected != tt.wantStatus.Connected || (got.Message == "") != (tt.wantStatus.Message == "") || !strings.Contains(got.Message, tt.wantStatus.Message) {
+				t.Errorf("status = %+v, want %+v", got, tt.wantStatus)
+			}
+			if got.LastTransitionTime.IsZero() || time.Since(got.LastTransitionTime.Time) > time.Minute {
+				t.Errorf("unexpected transition time %s", got.LastTransitionTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package rhssouser
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/metrics"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ldapFederationName is the name of the user storage provider federating the LDAP server of the installation, the
+	// other providers of the realm are left as they are
+	ldapFederationName        = "rhoam-ldap"
+	ldapProviderType          = "org.keycloak.storage.UserStorageProvider"
+	ldapMapperProviderType    = "org.keycloak.storage.ldap.mappers.LDAPStorageMapper"
+	ldapBindCredentialHashKey = "integreatly.org/bind-credential-hash"
+	// ldapMapperMarker is set in the config of the mappers of the spec, the marked mappers that aren't in the spec
+	// anymore are removed. The default mappers of the provider are left as they are
+	ldapMapperMarker = "integreatly.org/user-sso-ldap-federation"
+
+	defaultLDAPFullSyncPeriod         = 24 * time.Hour
+	defaultLDAPChangedUsersSyncPeriod = time.Hour
+)
+
+// keycloakComponent is a component of the Keycloak admin API, the keycloak client doesn't cover them
+type keycloakComponent struct {
+	ID           string              `json:"id,omitempty"`
+	Name         string              `json:"name"`
+	ProviderID   string              `json:"providerId"`
+	ProviderType string              `json:"providerType"`
+	ParentID     string              `json:"parentId"`
+	Config       map[string][]string `json:"config"`
+}
+
+// reconcileLDAPFederation federates the master realm with the LDAP server of the installation. Keycloak synchronizes
+// the users on the schedule of the spec, the health of the federation is checked with a bind to the LDAP server on
+// every reconcile and reported in the product status and the rhoam_ldap_federation metrics, which are alerted on
+func (r *Reconciler) reconcileLDAPFederation(ctx context.Context, serverClient k8sclient.Client, productStatus *integreatlyv1alpha1.RHMIProductStatus) (integreatlyv1alpha1.StatusPhase, error) {
+	spec := r.Installation.Spec.UserSSOLDAPFederation
+	if spec == nil && productStatus.LDAPFederation == nil {
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	kc := &keycloak.Keycloak{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: keycloakName, Namespace: r.Config.GetNamespace()}, kc); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get keycloak %s: %w", keycloakName, err)
+	}
+	existing, err := r.getLDAPComponent(ctx, serverClient, kc)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	if spec == nil {
+		if existing != nil {
+			if err := r.AdminRequest(ctx, serverClient, kc, http.MethodDelete, fmt.Sprintf("realms/%s/components/%s", masterRealmName, existing.ID), nil, nil); err != nil {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to remove the LDAP federation of the user SSO realm: %w", err)
+			}
+			r.Log.Info("Removed LDAP federation")
+		}
+		productStatus.LDAPFederation = nil
+		metrics.DeleteLDAPFederation(integreatlyv1alpha1.ProductRHSSOUser)
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	bindSecret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: spec.BindCredentialsSecret, Namespace: r.Installation.Namespace}, bindSecret); err != nil {
+		r.Log.Error("Failed to get the LDAP bind credentials", err)
+		r.setLDAPFederationHealth(productStatus, false, fmt.Sprintf("failed to get the %s bind credentials secret: %v", spec.BindCredentialsSecret, err), existing)
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	bindDN, bindCredential := string(bindSecret.Data["bindDn"]), string(bindSecret.Data["bindCredential"])
+	if bindDN == "" || bindCredential == "" {
+		r.setLDAPFederationHealth(productStatus, false, fmt.Sprintf("the %s bind credentials secret is missing the bindDn or bindCredential", spec.BindCredentialsSecret), existing)
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	config := ldapComponentConfig(spec, bindDN, bindCredential)
+	if existing == nil {
+		component := keycloakComponent{
+			Name:         ldapFederationName,
+			ProviderID:   "ldap",
+			ProviderType: ldapProviderType,
+			ParentID:     masterRealmName,
+			Config:       config,
+		}
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPost, fmt.Sprintf("realms/%s/components", masterRealmName), component, nil); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to create the LDAP federation of the user SSO realm: %w", err)
+		}
+		r.Log.Infof("Created LDAP federation", l.Fields{"connectionURL": spec.ConnectionURL})
+		if existing, err = r.getLDAPComponent(ctx, serverClient, kc); err != nil || existing == nil {
+			return integreatlyv1alpha1.PhaseInProgress, err
+		}
+	} else if !ldapComponentConfigured(existing, config) {
+		if existing.Config == nil {
+			existing.Config = map[string][]string{}
+		}
+		for key, value := range config {
+			existing.Config[key] = value
+		}
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPut, fmt.Sprintf("realms/%s/components/%s", masterRealmName, existing.ID), existing, nil); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update the LDAP federation of the user SSO realm: %w", err)
+		}
+		r.Log.Infof("Updated LDAP federation", l.Fields{"connectionURL": spec.ConnectionURL})
+	}
+
+	if err := r.reconcileLDAPMappers(ctx, serverClient, kc, existing.ID, spec.Mappers); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	connection := map[string]string{
+		"action":           "testAuthentication",
+		"connectionUrl":    spec.ConnectionURL,
+		"bindDn":           bindDN,
+		"bindCredential":   bindCredential,
+		"useTruststoreSpi": "ldapsOnly",
+		"componentId":      existing.ID,
+	}
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPost, fmt.Sprintf("realms/%s/testLDAPConnection", masterRealmName), connection, nil); err != nil {
+		r.Log.Error("Failed to bind to the LDAP server", err)
+		r.setLDAPFederationHealth(productStatus, false, fmt.Sprintf("failed to bind to the LDAP server: %v", err), existing)
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	r.setLDAPFederationHealth(productStatus, true, "", existing)
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+func (r *Reconciler) getLDAPComponent(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak) (*keycloakComponent, error) {
+	components := []*keycloakComponent{}
+	path := fmt.Sprintf("realms/%s/components?type=%s&name=%s", masterRealmName, url.QueryEscape(ldapProviderType), url.QueryEscape(ldapFederationName))
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodGet, path, nil, &components); err != nil {
+		return nil, fmt.Errorf("failed to list the user storage providers of the user SSO realm: %w", err)
+	}
+	for _, component := range components {
+		if component.Name == ldapFederationName {
+			return component, nil
+		}
+	}
+	return nil, nil
+}
+
+// ldapComponentConfig returns the config of the LDAP user storage provider. The users are imported read only, the
+// sync periods are in seconds
+func ldapComponentConfig(spec *integreatlyv1alpha1.UserSSOLDAPFederationSpec, bindDN, bindCredential string) map[string][]string {
+	vendor := spec.Vendor
+	if vendor == "" {
+		vendor = integreatlyv1alpha1.LDAPVendorOther
+	}
+	usernameAttribute, rdnAttribute, uuidAttribute, objectClasses := "uid", "uid", "entryUUID", []string{"inetOrgPerson", "organizationalPerson"}
+	switch vendor {
+	case integreatlyv1alpha1.LDAPVendorActiveDirectory:
+		usernameAttribute, rdnAttribute, uuidAttribute, objectClasses = "sAMAccountName", "cn", "objectGUID", []string{"person", "organizationalPerson", "user"}
+	case integreatlyv1alpha1.LDAPVendorRHDS:
+		uuidAttribute = "nsuniqueid"
+	}
+	if spec.UsernameAttribute != "" {
+		usernameAttribute = spec.UsernameAttribute
+	}
+	if spec.UUIDAttribute != "" {
+		uuidAttribute = spec.UUIDAttribute
+	}
+	if len(spec.UserObjectClasses) > 0 {
+		objectClasses = spec.UserObjectClasses
+	}
+
+	fullSyncPeriod, changedUsersSyncPeriod := ldapSyncPeriods(spec)
+	credentialHash := sha256.Sum256([]byte(bindCredential))
+	return map[string][]string{
+		"enabled":                 {"true"},
+		"priority":                {"0"},
+		"vendor":                  {string(vendor)},
+		"connectionUrl":           {spec.ConnectionURL},
+		"useTruststoreSpi":        {"ldapsOnly"},
+		"authType":                {"simple"},
+		"bindDn":                  {bindDN},
+		"bindCredential":          {bindCredential},
+		ldapBindCredentialHashKey: {hex.EncodeToString(credentialHash[:])},
+		"usersDn":                 {spec.UsersDN},
+		"usernameLDAPAttribute":   {usernameAttribute},
+		"rdnLDAPAttribute":        {rdnAttribute},
+		"uuidLDAPAttribute":       {uuidAttribute},
+		"userObjectClasses":       {strings.Join(objectClasses, ", ")},
+		"customUserSearchFilter":  {spec.UserFilter},
+		"searchScope":             {"2"},
+		"pagination":              {"true"},
+		"editMode":                {"READ_ONLY"},
+		"importEnabled":           {"true"},
+		"syncRegistrations":       {"false"},
+		"batchSizeForSync":        {"1000"},
+		"fullSyncPeriod":          {strconv.Itoa(int(fullSyncPeriod.Seconds()))},
+		"changedSyncPeriod":       {strconv.Itoa(int(changedUsersSyncPeriod.Seconds()))},
+	}
+}
+
+func ldapSyncPeriods(spec *integreatlyv1alpha1.UserSSOLDAPFederationSpec) (time.Duration, time.Duration) {
+	fullSyncPeriod, changedUsersSyncPeriod := defaultLDAPFullSyncPeriod, defaultLDAPChangedUsersSyncPeriod
+	if schedule := spec.SyncSchedule; schedule != nil {
+		if schedule.FullSyncPeriod != nil {
+			fullSyncPeriod = schedule.FullSyncPeriod.Duration
+		}
+		if schedule.ChangedUsersSyncPeriod != nil {
+			changedUsersSyncPeriod = schedule.ChangedUsersSyncPeriod.Duration
+		}
+	}
+	return fullSyncPeriod, changedUsersSyncPeriod
+}
+
+// ldapComponentConfigured checks the provider has the given config. Keycloak masks the bind credential, its changes
+// are detected with its hash
+func ldapComponentConfigured(component *keycloakComponent, config map[string][]string) bool {
+	for key, value := range config {
+		if key == "bindCredential" {
+			continue
+		}
+		current := component.Config[key]
+		if len(current) == 0 && len(value) == 1 && value[0] == "" {
+			continue
+		}
+		if !reflect.DeepEqual(current, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileLDAPMappers makes the mappers of the spec the ones of the provider. A mapper of the spec replaces the
+// default mapper of the same name, the mappers removed from the spec are removed from the provider
+func (r *Reconciler) reconcileLDAPMappers(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, parentID string, specs []integreatlyv1alpha1.LDAPMapperSpec) error {
+	path := fmt.Sprintf("realms/%s/components", masterRealmName)
+	existing := []*keycloakComponent{}
+	if err := r.AdminRequest(ctx, serverClient, kc, http.MethodGet, fmt.Sprintf("%s?parent=%s&type=%s", path, url.QueryEscape(parentID), url.QueryEscape(ldapMapperProviderType)), nil, &existing); err != nil {
+		return fmt.Errorf("failed to list the LDAP mappers: %w", err)
+	}
+	byName := map[string]*keycloakComponent{}
+	for _, mapper := range existing {
+		byName[mapper.Name] = mapper
+	}
+
+	for _, spec := range specs {
+		mapper := keycloakComponent{
+			Name:         spec.Name,
+			ProviderID:   spec.Type,
+			ProviderType: ldapMapperProviderType,
+			ParentID:     parentID,
+			Config:       map[string][]string{ldapMapperMarker: {"true"}},
+		}
+		for key, value := range spec.Config {
+			mapper.Config[key] = []string{value}
+		}
+
+		current, ok := byName[spec.Name]
+		delete(byName, spec.Name)
+		if ok && current.ProviderID != spec.Type {
+			// the type of a mapper can't be updated
+			if err := r.AdminRequest(ctx, serverClient, kc, http.MethodDelete, path+"/"+current.ID, nil, nil); err != nil {
+				return fmt.Errorf("failed to remove the %s LDAP mapper: %w", spec.Name, err)
+			}
+			ok = false
+		}
+		if !ok {
+			if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPost, path, mapper, nil); err != nil {
+				return fmt.Errorf("failed to create the %s LDAP mapper: %w", spec.Name, err)
+			}
+			continue
+		}
+		if ldapComponentConfigured(current, mapper.Config) {
+			continue
+		}
+		if current.Config == nil {
+			current.Config = map[string][]string{}
+		}
+		for key, value := range mapper.Config {
+			current.Config[key] = value
+		}
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodPut, path+"/"+current.ID, current, nil); err != nil {
+			return fmt.Errorf("failed to update the %s LDAP mapper: %w", spec.Name, err)
+		}
+	}
+
+	for name, mapper := range byName {
+		if len(mapper.Config[ldapMapperMarker]) == 0 {
+			continue
+		}
+		if err := r.AdminRequest(ctx, serverClient, kc, http.MethodDelete, path+"/"+mapper.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to remove the %s LDAP mapper: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setLDAPFederationHealth reports the health of the LDAP federation in the product status and the metrics. The last
+// sync is read from the provider, Keycloak only records the successful syncs
+func (r *Reconciler) setLDAPFederationHealth(productStatus *integreatlyv1alpha1.RHMIProductStatus, connected bool, message string, component *keycloakComponent) {
+	var lastSync *time.Time
+	if component != nil && len(component.Config["lastSync"]) > 0 {
+		if seconds, err := strconv.ParseInt(component.Config["lastSync"][0], 10, 64); err == nil && seconds > 0 {
+			t := time.Unix(seconds, 0)
+			lastSync = &t
+		}
+	}
+	setLDAPFederationStatus(productStatus, connected, message, lastSync, time.Now())
+	metrics.SetLDAPFederation(integreatlyv1alpha1.ProductRHSSOUser, connected, lastSync)
+}
+
+// setLDAPFederationStatus reports the health of the LDAP federation, its transition time only changes with the
+// connectivity
+func setLDAPFederationStatus(productStatus *integreatlyv1alpha1.RHMIProductStatus, connected bool, message string, lastSync *time.Time, now time.Time) {
+	status := productStatus.LDAPFederation
+	if status == nil || status.Connected != connected {
+		previous := status
+		status = &integreatlyv1alpha1.LDAPFederationStatus{Connected: connected, LastTransitionTime: metav1.NewTime(now)}
+		if previous != nil {
+			status.LastSync = previous.LastSync
+		}
+		productStatus.LDAPFederation = status
+	}
+	status.Message = message
+	if lastSync != nil {
+		t := metav1.NewTime(*lastSync)
+		status.LastSync = &t
+	}
+}
@@ -0,0 +1,260 @@
+package rhssouser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/products/rhssocommon"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	monv1 "github.com/rhobs/obo-prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeComponentsServer serves the components and LDAP connection test endpoints of the Keycloak admin API
+type fakeComponentsServer struct {
+	components []*keycloakComponent
+	bindFails  bool
+	requests   []string
+}
+
+func (f *fakeComponentsServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, "/auth/admin/realms/master/")
+	if req.URL.Path == "/auth/realms/master/protocol/openid-connect/token" {
+		_ = json.NewEncoder(w).Encode(keycloak.TokenResponse{AccessToken: "token"})
+		return
+	}
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", req.Method, path))
+
+	switch {
+	case path == "testLDAPConnection":
+		if f.bindFails {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	case path == "components" && req.Method == http.MethodGet:
+		components := []*keycloakComponent{}
+		for _, component := range f.components {
+			query := req.URL.Query()
+			if component.ProviderType == query.Get("type") && (query.Get("name") == "" || component.Name == query.Get("name")) && (query.Get("parent") == "" || component.ParentID == query.Get("parent")) {
+				components = append(components, component)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(components)
+	case path == "components" && req.Method == http.MethodPost:
+		component := &keycloakComponent{}
+		_ = json.NewDecoder(req.Body).Decode(component)
+		component.ID = fmt.Sprintf("%s-id", component.Name)
+		f.components = append(f.components, component)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "components/"):
+		id := strings.TrimPrefix(path, "components/")
+		for i, component := range f.components {
+			if component.ID != id {
+				continue
+			}
+			if req.Method == http.MethodDelete {
+				f.components = append(f.components[:i], f.components[i+1:]...)
+			} else {
+				_ = json.NewDecoder(req.Body).Decode(component)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeComponentsServer) component(name string) *keycloakComponent {
+	for _, component := range f.components {
+		if component.Name == name {
+			return component
+		}
+	}
+	return nil
+}
+
+func TestReconciler_reconcileLDAPFederation(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastSync := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	ldapSpec := &integreatlyv1alpha1.UserSSOLDAPFederationSpec{
+		ConnectionURL:         "ldaps://ldap.example.com",
+		BindCredentialsSecret: "ldap-bind",
+		UsersDN:               "ou=users,dc=example,dc=com",
+		Vendor:                integreatlyv1alpha1.LDAPVendorActiveDirectory,
+		Mappers:               []integreatlyv1alpha1.LDAPMapperSpec{{Name: "groups", Type: "group-ldap-mapper", Config: map[string]string{"groups.dn": "ou=groups,dc=example,dc=com"}}},
+		SyncSchedule:          &integreatlyv1alpha1.LDAPSyncScheduleSpec{ChangedUsersSyncPeriod: &metav1.Duration{Duration: 15 * time.Minute}},
+	}
+	configured := ldapComponentConfig(ldapSpec, "cn=rhoam", "password")
+	configured["lastSync"] = []string{fmt.Sprint(lastSync.Unix())}
+
+	tests := []struct {
+		name          string
+		spec          *integreatlyv1alpha1.UserSSOLDAPFederationSpec
+		noBindSecret  bool
+		status        *integreatlyv1alpha1.LDAPFederationStatus
+		components    []*keycloakComponent
+		bindFails     bool
+		wantRequests  []string
+		wantComponent bool
+		wantStatus    *integreatlyv1alpha1.LDAPFederationStatus
+	}{
+		{
+			name: "nothing to do without LDAP federation",
+		},
+		{
+			name: "LDAP federation created with its mappers",
+			spec: ldapSpec,
+			wantRequests: []string{
+				"GET components", "POST components", "GET components", "GET components", "POST components", "POST testLDAPConnection",
+			},
+			wantComponent: true,
+			wantStatus:    &integreatlyv1alpha1.LDAPFederationStatus{Connected: true},
+		},
+		{
+			name: "LDAP federation up to date with its bind rejected",
+			spec: ldapSpec,
+			components: []*keycloakComponent{
+				{ID: "ldap-id", Name: ldapFederationName, ProviderID: "ldap", ProviderType: ldapProviderType, ParentID: "master", Config: configured},
+				{ID: "groups-id", Name: "groups", ProviderID: "group-ldap-mapper", ProviderType: ldapMapperProviderType, ParentID: "ldap-id", Config: map[string][]string{ldapMapperMarker: {"true"}, "groups.dn": {"ou=groups,dc=example,dc=com"}}},
+				{ID: "roles-id", Name: "roles", ProviderID: "role-ldap-mapper", ProviderType: ldapMapperProviderType, ParentID: "ldap-id", Config: map[string][]string{ldapMapperMarker: {"true"}}},
+				{ID: "email-id", Name: "email", ProviderID: "user-attribute-ldap-mapper", ProviderType: ldapMapperProviderType, ParentID: "ldap-id", Config: map[string][]string{}},
+			},
+			bindFails:     true,
+			wantRequests:  []string{"GET components", "GET components", "DELETE components/roles-id", "POST testLDAPConnection"},
+			wantComponent: true,
+			wantStatus:    &integreatlyv1alpha1.LDAPFederationStatus{Message: "failed to bind", LastSync: &metav1.Time{Time: lastSync}},
+		},
+		{
+			name:          "bind credentials secret missing",
+			spec:          ldapSpec,
+			noBindSecret:  true,
+			wantRequests:  []string{"GET components"},
+			wantStatus:    &integreatlyv1alpha1.LDAPFederationStatus{Message: "failed to get the ldap-bind bind credentials secret"},
+			wantComponent: false,
+		},
+		{
+			name:   "LDAP federation removed from the spec",
+			status: &integreatlyv1alpha1.LDAPFederationStatus{Connected: true},
+			components: []*keycloakComponent{
+				{ID: "ldap-id", Name: ldapFederationName, ProviderID: "ldap", ProviderType: ldapProviderType, ParentID: "master", Config: configured},
+			},
+			wantRequests: []string{"GET components", "DELETE components/ldap-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeComponentsServer{components: tt.components, bindFails: tt.bindFails}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			objs := []runtime.Object{
+				&keycloak.Keycloak{
+					ObjectMeta: metav1.ObjectMeta{Name: keycloakName, Namespace: "user-sso"},
+					Status:     keycloak.KeycloakStatus{CredentialSecret: adminCredentialSecretName, ExternalURL: server.URL},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: adminCredentialSecretName, Namespace: "user-sso"},
+					Data:       map[string][]byte{"ADMIN_USERNAME": []byte("admin"), "ADMIN_PASSWORD": []byte("password")},
+				},
+			}
+			if !tt.noBindSecret {
+				objs = append(objs, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "rhoam-operator"},
+					Data:       map[string][]byte{"bindDn": []byte("cn=rhoam"), "bindCredential": []byte("password")},
+				})
+			}
+			serverClient := utils.NewTestClient(scheme, objs...)
+
+			installation := &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "rhoam-operator"}}
+			installation.Spec.UserSSOLDAPFederation = tt.spec
+			r := &Reconciler{
+				Config:     config.NewRHSSOUser(config.ProductConfig{"NAMESPACE": "user-sso"}),
+				Log:        getLogger(),
+				Reconciler: &rhssocommon.Reconciler{Installation: installation},
+			}
+			productStatus := &integreatlyv1alpha1.RHMIProductStatus{LDAPFederation: tt.status}
+
+			phase, err := r.reconcileLDAPFederation(context.TODO(), serverClient, productStatus)
+			if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+				t.Fatalf("reconcileLDAPFederation() = %s, %v", phase, err)
+			}
+
+			if fmt.Sprint(fake.requests) != fmt.Sprint(tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", fake.requests, tt.wantRequests)
+			}
+			component := fake.component(ldapFederationName)
+			if (component != nil) != tt.wantComponent {
+				t.Fatalf("component = %+v, want %v", component, tt.wantComponent)
+			}
+			if component != nil {
+				if component.Config["uuidLDAPAttribute"][0] != "objectGUID" || component.Config["changedSyncPeriod"][0] != "900" || component.Config["fullSyncPeriod"][0] != "86400" {
+					t.Errorf("unexpected config of the LDAP federation: %v", component.Config)
+				}
+				if mapper := fake.component("groups"); mapper == nil || mapper.ParentID != component.ID || mapper.Config[ldapMapperMarker] == nil {
+					t.Errorf("unexpected groups mapper %+v", mapper)
+				}
+				if fake.component("roles") != nil {
+					t.Error("expected the mapper removed from the spec to be removed")
+				}
+			}
+
+			got := productStatus.LDAPFederation
+			if (got == nil) != (tt.wantStatus == nil) {
+				t.Fatalf("status = %+v, want %+v", got, tt.wantStatus)
+			}
+			if got == nil {
+				return
+			}
+			if got.Connected != tt.wantStatus.Connected || (got.Message == "") != (tt.wantStatus.Message == "") || !strings.Contains(got.Message, tt.wantStatus.Message) {
+				t.Errorf("status = %+v, want %+v", got, tt.wantStatus)
+			}
+			if (got.LastSync == nil) != (tt.wantStatus.LastSync == nil) || got.LastSync != nil && !got.LastSync.Equal(tt.wantStatus.LastSync) {
+				t.Errorf("last sync = %v, want %v", got.LastSync, tt.wantStatus.LastSync)
+			}
+		})
+	}
+}
+
+func TestReconciler_newLDAPFederationAlerts(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *integreatlyv1alpha1.LDAPSyncScheduleSpec
+		want     string
+	}{
+		{
+			name: "default sync schedule",
+			want: "> 10800",
+		},
+		{
+			name:     "full sync more frequent than the changed users sync",
+			schedule: &integreatlyv1alpha1.LDAPSyncScheduleSpec{FullSyncPeriod: &metav1.Duration{Duration: 30 * time.Minute}},
+			want:     "> 5400",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{Reconciler: &rhssocommon.Reconciler{Installation: &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{
+				UserSSOLDAPFederation: &integreatlyv1alpha1.UserSSOLDAPFederationSpec{SyncSchedule: tt.schedule},
+			}}}}
+			alerts := r.newLDAPFederationAlerts("rhoam", "observability")
+			expr := alerts.Rules.([]monv1.Rule)[1].Expr.String()
+			if !strings.HasSuffix(expr, tt.want) {
+				t.Errorf("sync alert expr = %s, want suffix %s", expr, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package rhssouser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultBruteForceWaitIncrementSeconds = int32(60)
+	// conditionalOTPFlowName is the browser flow step asking users that
+	// configured a one time password for it
+	conditionalOTPFlowName = "Browser - Conditional OTP"
+)
+
+// reconcilePasswordPolicy enforces the user SSO password policy of the installation on the master realm. The
+// keycloak operator doesn't update existing realms so the settings are compared and updated with the admin API
+func (r *Reconciler) reconcilePasswordPolicy(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak, kcClient keycloakCommon.KeycloakInterface) (integreatlyv1alpha1.StatusPhase, error) {
+	policy := r.Installation.Spec.UserSSOPasswordPolicy
+	if policy == nil {
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	if err := validatePasswordPolicy(policy); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("invalid user SSO password policy: %w", err)
+	}
+
+	realm, err := kcClient.GetRealm(masterRealmName)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get user SSO master realm: %w", err)
+	}
+	if realm == nil || realm.Spec.Realm == nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("user SSO master realm not found")
+	}
+
+	settings := passwordPolicySettings(policy, realm.Spec.Realm)
+	if len(settings) > 0 {
+		changed := make([]string, 0, len(settings))
+		for key := range settings {
+			changed = append(changed, key)
+		}
+		sort.Strings(changed)
+		r.Log.Infof("Updating user SSO password policy", l.Fields{"settings": changed})
+
+		if err := r.UpdateRealmSettings(ctx, serverClient, kc, masterRealmName, settings); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update user SSO password policy: %w", err)
+		}
+	}
+
+	return r.reconcileOTPRequirement(kcClient, policy.RequireOTP)
+}
+
+func validatePasswordPolicy(policy *integreatlyv1alpha1.PasswordPolicySpec) error {
+	if policy.MinLength != 0 && (policy.MinLength < 8 || policy.MinLength > 128) {
+		return fmt.Errorf("minLength must be between 8 and 128, got %d", policy.MinLength)
+	}
+	if policy.History < 0 || policy.History > 24 {
+		return fmt.Errorf("history must be between 0 and 24, got %d", policy.History)
+	}
+	if bf := policy.BruteForceDetection; bf != nil {
+		if bf.MaxLoginFailures < 1 {
+			return fmt.Errorf("bruteForceDetection.maxLoginFailures must be at least 1, got %d", bf.MaxLoginFailures)
+		}
+		if bf.WaitIncrementSeconds < 0 {
+			return fmt.Errorf("bruteForceDetection.waitIncrementSeconds can't be negative, got %d", bf.WaitIncrementSeconds)
+		}
+	}
+	return nil
+}
+
+// passwordPolicySettings returns the realm settings that differ from the policy
+func passwordPolicySettings(policy *integreatlyv1alpha1.PasswordPolicySpec, realm *keycloak.KeycloakAPIRealm) map[string]interface{} {
+	settings := map[string]interface{}{}
+
+	if passwordPolicy := keycloakPasswordPolicy(policy); realm.PasswordPolicy != passwordPolicy {
+		settings["passwordPolicy"] = passwordPolicy
+	}
+
+	bruteForce := policy.BruteForceDetection
+	if realm.BruteForceProtected == nil || *realm.BruteForceProtected != (bruteForce != nil) {
+		settings["bruteForceProtected"] = bruteForce != nil
+	}
+	if bruteForce == nil {
+		return settings
+	}
+
+	waitIncrement := bruteForce.WaitIncrementSeconds
+	if waitIncrement == 0 {
+		waitIncrement = defaultBruteForceWaitIncrementSeconds
+	}
+	if realm.FailureFactor == nil || *realm.FailureFactor != bruteForce.MaxLoginFailures {
+		settings["failureFactor"] = bruteForce.MaxLoginFailures
+	}
+	if realm.WaitIncrementSeconds == nil || *realm.WaitIncrementSeconds != waitIncrement {
+		settings["waitIncrementSeconds"] = waitIncrement
+	}
+	if realm.PermanentLockout == nil || *realm.PermanentLockout != bruteForce.PermanentLockout {
+		settings["permanentLockout"] = bruteForce.PermanentLockout
+	}
+	return settings
+}
+
+// keycloakPasswordPolicy formats the policy as a Keycloak password policy, e.g. "length(12) and passwordHistory(5)"
+func keycloakPasswordPolicy(policy *integreatlyv1alpha1.PasswordPolicySpec) string {
+	var rules []string
+	if policy.MinLength > 0 {
+		rules = append(rules, fmt.Sprintf("length(%d)", policy.MinLength))
+	}
+	if policy.History > 0 {
+		rules = append(rules, fmt.Sprintf("passwordHistory(%d)", policy.History))
+	}
+	return strings.Join(rules, " and ")
+}
+
+// reconcileOTPRequirement makes the one time password step of the browser flow required for every user, or
+// only for users that configured one time passwords
+func (r *Reconciler) reconcileOTPRequirement(kcClient keycloakCommon.KeycloakInterface, requireOTP bool) (integreatlyv1alpha1.StatusPhase, error) {
+	requirement := "CONDITIONAL"
+	if requireOTP {
+		requirement = "REQUIRED"
+	}
+
+	executions, err := kcClient.ListAuthenticationExecutionsForFlow("browser", masterRealmName)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to retrieve execution flows on master realm: %w", err)
+	}
+	for _, execution := range executions {
+		if execution.DisplayName != conditionalOTPFlowName {
+			continue
+		}
+		if execution.Requirement == requirement {
+			return integreatlyv1alpha1.PhaseCompleted, nil
+		}
+		execution.Requirement = requirement
+		if err := kcClient.UpdateAuthenticationExecutionForFlow("browser", masterRealmName, execution); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update one time password requirement: %w", err)
+		}
+		r.Log.Infof("Updated user SSO one time password requirement", l.Fields{"requirement": requirement})
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	if requireOTP {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to find %q in the master realm browser flow", conditionalOTPFlowName)
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
@@ -0,0 +1,168 @@
+package rhssouser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/products/rhssocommon"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	keycloakCommon "github.com/integr8ly/keycloak-client/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconciler_reconcilePasswordPolicy(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabled := true
+	failureFactor := int32(5)
+	waitIncrement := int32(60)
+	permanentLockout := false
+
+	tests := []struct {
+		name            string
+		policy          *integreatlyv1alpha1.PasswordPolicySpec
+		realm           *keycloak.KeycloakAPIRealm
+		otpRequirement  string
+		wantPhase       integreatlyv1alpha1.StatusPhase
+		wantErr         bool
+		wantSettings    map[string]interface{}
+		wantRequirement string
+	}{
+		{
+			name:      "test nothing is changed without a policy",
+			realm:     &keycloak.KeycloakAPIRealm{PasswordPolicy: "length(4)"},
+			wantPhase: integreatlyv1alpha1.PhaseCompleted,
+		},
+		{
+			name:      "test invalid policy fails",
+			policy:    &integreatlyv1alpha1.PasswordPolicySpec{MinLength: 4},
+			realm:     &keycloak.KeycloakAPIRealm{},
+			wantPhase: integreatlyv1alpha1.PhaseFailed,
+			wantErr:   true,
+		},
+		{
+			name: "test drifted realm settings are updated",
+			policy: &integreatlyv1alpha1.PasswordPolicySpec{
+				MinLength:           12,
+				History:             5,
+				BruteForceDetection: &integreatlyv1alpha1.BruteForceDetectionSpec{MaxLoginFailures: 5},
+			},
+			realm:          &keycloak.KeycloakAPIRealm{PasswordPolicy: "length(8)"},
+			otpRequirement: "CONDITIONAL",
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantSettings: map[string]interface{}{
+				"passwordPolicy":       "length(12) and passwordHistory(5)",
+				"bruteForceProtected":  true,
+				"failureFactor":        float64(5),
+				"waitIncrementSeconds": float64(60),
+				"permanentLockout":     false,
+			},
+			wantRequirement: "CONDITIONAL",
+		},
+		{
+			name: "test realm matching the policy isn't updated and otp is required",
+			policy: &integreatlyv1alpha1.PasswordPolicySpec{
+				MinLength:           12,
+				BruteForceDetection: &integreatlyv1alpha1.BruteForceDetectionSpec{MaxLoginFailures: 5},
+				RequireOTP:          true,
+			},
+			realm: &keycloak.KeycloakAPIRealm{
+				PasswordPolicy:       "length(12)",
+				BruteForceProtected:  &enabled,
+				FailureFactor:        &failureFactor,
+				WaitIncrementSeconds: &waitIncrement,
+				PermanentLockout:     &permanentLockout,
+			},
+			otpRequirement:  "CONDITIONAL",
+			wantPhase:       integreatlyv1alpha1.PhaseCompleted,
+			wantRequirement: "REQUIRED",
+		},
+		{
+			name:      "test failure when otp is required without an otp step in the browser flow",
+			policy:    &integreatlyv1alpha1.PasswordPolicySpec{RequireOTP: true},
+			realm:     &keycloak.KeycloakAPIRealm{BruteForceProtected: new(bool)},
+			wantPhase: integreatlyv1alpha1.PhaseFailed,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSettings map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/auth/realms/master/protocol/openid-connect/token":
+					_ = json.NewEncoder(w).Encode(keycloak.TokenResponse{AccessToken: "token"})
+				case "/auth/admin/realms/" + masterRealmName:
+					if req.Header.Get("Authorization") != "Bearer token" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					_ = json.NewDecoder(req.Body).Decode(&gotSettings)
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			kc := &keycloak.Keycloak{
+				ObjectMeta: metav1.ObjectMeta{Name: keycloakName, Namespace: "user-sso"},
+				Status:     keycloak.KeycloakStatus{CredentialSecret: adminCredentialSecretName, ExternalURL: server.URL},
+			}
+			serverClient := utils.NewTestClient(scheme, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: adminCredentialSecretName, Namespace: "user-sso"},
+				Data:       map[string][]byte{"ADMIN_USERNAME": []byte("admin"), "ADMIN_PASSWORD": []byte("password")},
+			})
+
+			var executions []*keycloak.AuthenticationExecutionInfo
+			if tt.otpRequirement != "" {
+				executions = append(executions, &keycloak.AuthenticationExecutionInfo{DisplayName: conditionalOTPFlowName, Requirement: tt.otpRequirement})
+			}
+			gotRequirement := tt.otpRequirement
+			kcClient := &keycloakCommon.KeycloakInterfaceMock{
+				GetRealmFunc: func(realmName string) (*keycloak.KeycloakRealm, error) {
+					return &keycloak.KeycloakRealm{Spec: keycloak.KeycloakRealmSpec{Realm: tt.realm}}, nil
+				},
+				ListAuthenticationExecutionsForFlowFunc: func(flowAlias, realmName string) ([]*keycloak.AuthenticationExecutionInfo, error) {
+					return executions, nil
+				},
+				UpdateAuthenticationExecutionForFlowFunc: func(flowAlias, realmName string, execution *keycloak.AuthenticationExecutionInfo) error {
+					gotRequirement = execution.Requirement
+					return nil
+				},
+			}
+
+			r := &Reconciler{
+				Log: getLogger(),
+				Reconciler: &rhssocommon.Reconciler{
+					Installation: &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{UserSSOPasswordPolicy: tt.policy}},
+				},
+			}
+
+			phase, err := r.reconcilePasswordPolicy(context.TODO(), serverClient, kc, kcClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcilePasswordPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if phase != tt.wantPhase {
+				t.Fatalf("expected phase %s, got %s", tt.wantPhase, phase)
+			}
+			if !reflect.DeepEqual(gotSettings, tt.wantSettings) {
+				t.Fatalf("expected realm settings %v, got %v", tt.wantSettings, gotSettings)
+			}
+			if gotRequirement != tt.wantRequirement {
+				t.Fatalf("expected otp requirement %s, got %s", tt.wantRequirement, gotRequirement)
+			}
+		})
+	}
+}
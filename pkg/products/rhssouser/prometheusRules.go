@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources"
@@ -18,7 +19,7 @@ func (r *Reconciler) newAlertsReconciler(logger l.Logger, installType string, na
 	operatorAlertName := "user-sso-operator-ksm-endpoint-alerts"
 	userSsoAlerts := "rhssouser-general"
 
-	return &resources.AlertReconcilerImpl{
+	alertReconciler := &resources.AlertReconcilerImpl{
 		ProductName:  "RHSSO User",
 		Installation: r.Installation,
 		Log:          logger,
@@ -168,4 +169,53 @@ func (r *Reconciler) newAlertsReconciler(logger l.Logger, installType string, na
 			},
 		},
 	}
+
+	ldapFederationAlerts := r.newLDAPFederationAlerts(installationName, namespace)
+	if r.Installation.Spec.UserSSOLDAPFederation != nil {
+		alertReconciler.Alerts = append(alertReconciler.Alerts, ldapFederationAlerts)
+	} else {
+		alertReconciler.RemovedAlerts = append(alertReconciler.RemovedAlerts, ldapFederationAlerts)
+	}
+	return alertReconciler
+}
+
+// newLDAPFederationAlerts alerts on the LDAP federation failing to bind, and on the users not synchronized for three
+// times the shortest sync period of the spec
+func (r *Reconciler) newLDAPFederationAlerts(installationName string, namespace string) resources.AlertConfiguration {
+	syncPeriod := defaultLDAPChangedUsersSyncPeriod
+	if spec := r.Installation.Spec.UserSSOLDAPFederation; spec != nil {
+		fullSyncPeriod, changedUsersSyncPeriod := ldapSyncPeriods(spec)
+		syncPeriod = changedUsersSyncPeriod
+		if fullSyncPeriod < syncPeriod {
+			syncPeriod = fullSyncPeriod
+		}
+	}
+
+	return resources.AlertConfiguration{
+		AlertName: "user-sso-ldap-federation-alerts",
+		GroupName: "user-sso-ldap-federation.rules",
+		Namespace: namespace,
+		Rules: []monv1.Rule{
+			{
+				Alert: "RHOAMUserSsoLDAPFederationDown",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": "The LDAP server federated with the user SSO realm has rejected the bind credentials or been unreachable for the last 15 minutes. Check the ldapFederation status of the rhssouser product.",
+				},
+				Expr:   intstr.FromString(fmt.Sprintf(`rhoam_ldap_federation_up{product="%s"} == 0`, integreatlyv1alpha1.ProductRHSSOUser)),
+				For:    "15m",
+				Labels: map[string]string{"severity": "warning", "product": installationName},
+			},
+			{
+				Alert: "RHOAMUserSsoLDAPSyncStale",
+				Annotations: map[string]string{
+					"sop_url": resources.SopUrlAlertsAndTroubleshooting,
+					"message": fmt.Sprintf("The users of the LDAP server federated with the user SSO realm haven't been synchronized for more than %s.", 3*syncPeriod),
+				},
+				Expr:   intstr.FromString(fmt.Sprintf(`time() - rhoam_ldap_federation_last_sync_timestamp_seconds{product="%s"} > %d`, integreatlyv1alpha1.ProductRHSSOUser, int64((3 * syncPeriod).Seconds()))),
+				For:    "5m",
+				Labels: map[string]string{"severity": "warning", "product": installationName},
+			},
+		},
+	}
 }
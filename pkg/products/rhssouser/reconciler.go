@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/integr8ly/integreatly-operator/pkg/products/rhssocommon"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
 
@@ -33,6 +34,7 @@ import (
 	oauthClient "github.com/openshift/client-go/oauth/clientset/versioned/typed/oauth/v1"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
+	customDomain "github.com/integr8ly/integreatly-operator/pkg/resources/custom-domain"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -47,7 +49,6 @@ var (
 	masterRealmName           = "master"
 	adminCredentialSecretName = "credential-" + keycloakName
 	ssoType                   = "user sso"
-	postgresResourceName      = "rhssouser-postgres-rhmi"
 	routeName                 = "keycloak"
 )
 
@@ -205,7 +206,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
-	phase, err = r.ReconcileSubscription(ctx, serverClient, installation, productNamespace, operatorNamespace, postgresResourceName)
+	phase, err = r.ReconcileSubscription(ctx, serverClient, installation, productNamespace, operatorNamespace,
+		r.PreUpgradeBackupsExecutor(productStatus, constants.RHSSOUserProstgresPrefix+installation.Name, keycloakName, productNamespace))
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.Recorder, installation, phase, fmt.Sprintf("Failed to reconcile %s subscription", constants.RHSSOSubscriptionName), err)
 		return phase, err
@@ -229,6 +231,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.ReconcileCustomKeycloakRoute(ctx, serverClient, r.Config.RHSSOCommon, integreatlyv1alpha1.CustomRouteRHSSOUser, routeName, customDomain.DefaultHostResolver)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile keycloak custom route", err)
+		return phase, err
+	}
+
 	// Wait for RHSSO postgres to be completed
 	phase, err = resources.WaitForRHSSOPostgresToBeComplete(serverClient, installation.Name, r.ConfigManager.GetOperatorNamespace())
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
@@ -265,6 +273,24 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("error writing to config in rhssouser reconciler: %w", err)
 	}
 
+	phase, err = r.reconcileIdentityProvider(ctx, serverClient, productStatus)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile the corporate identity provider", err)
+		return phase, err
+	}
+
+	phase, err = r.reconcileLDAPFederation(ctx, serverClient, productStatus)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile the LDAP federation", err)
+		return phase, err
+	}
+
+	phase, err = r.ReconcileSecretRotation(ctx, serverClient, productStatus, keycloakName, adminCredentialSecretName, productNamespace)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile admin credential rotation", err)
+		return phase, err
+	}
+
 	phase, err = resources.ReconcileSecretToRHMIOperatorNamespace(ctx, serverClient, r.ConfigManager, adminCredentialSecretName, productNamespace)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile admin credential secret to RHMI operator namespace", err)
@@ -293,9 +319,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.ReconcileRealmExport(ctx, serverClient, keycloakName, r.Config.GetNamespace())
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile realm export", err)
+		return phase, err
+	}
+
 	productStatus.Host = r.Config.GetHost()
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
+	productStatus.FIPS = fips.ProductStatus(installation)
 
 	events.HandleProductComplete(r.Recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.Log.Infof("Reconcile successful", l.Fields{"productStatus": r.Config.GetProductName()})
@@ -331,6 +364,11 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 		}
 	}
 
+	themeConfigMap, err := r.ReconcileThemeConfigMap(ctx, serverClient, r.Config.GetNamespace())
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
 	or, err := controllerutil.CreateOrUpdate(ctx, serverClient, kc, func() error {
 		owner.AddIntegreatlyOwnerAnnotations(kc, installation)
 		kc.Spec.Extensions = []string{
@@ -362,6 +400,8 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 		if experimentalSpec != nil {
 			kc.Spec.KeycloakDeploymentSpec.Experimental = *experimentalSpec
 		}
+		rhssocommon.SetFIPSEnv(kc, installation)
+		rhssocommon.SetTheme(kc, installation, themeConfigMap)
 
 		return nil
 	})
@@ -418,6 +458,24 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, installation *inte
 		return phase, err
 	}
 
+	phase, err = r.reconcilePasswordPolicy(ctx, serverClient, kc, kcClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.Recorder, installation, phase, "Failed to reconcile user SSO password policy", err)
+		return phase, err
+	}
+
+	if err := r.ReconcileRelaySMTP(ctx, serverClient, kc, kcClient, masterRealmName); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to configure smtp relay on user SSO: %w", err)
+	}
+
+	if err := r.ReconcileRealmTheme(ctx, serverClient, kc, kcClient, masterRealmName); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile the realm theme of user SSO: %w", err)
+	}
+
+	if err := r.ReconcileRealmCustomizations(ctx, serverClient, kc, kcClient, integreatlyv1alpha1.ProductRHSSOUser, masterRealmName, installation.Spec.UserSSOPasswordPolicy != nil); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile the realm customizations of user SSO: %w", err)
+	}
+
 	_, err = r.reconcileFirstLoginAuthFlow(kc)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("Failed to reconcile first broker login authentication flow: %w", err)
@@ -1352,6 +1410,7 @@ func (r *Reconciler) reconcileConsoleLink(ctx context.Context, serverClient k8sc
 	}
 
 	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, cl, func() error {
+		resources.PrepareObjectLabels(cl, r.Installation, false, false, false)
 		cl.Spec = consolev1.ConsoleLinkSpec{
 			ApplicationMenu: &consolev1.ApplicationMenuSpec{
 				ImageURL: userSSOIcon,
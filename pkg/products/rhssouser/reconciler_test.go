@@ -1186,6 +1186,8 @@ func getMoqKeycloakClientFactory() keycloakCommon.KeycloakClientFactory {
 			FindAuthenticationExecutionForFlowFunc:   keycloakInterfaceMock.FindAuthenticationExecutionForFlow,
 			UpdateAuthenticationExecutionForFlowFunc: keycloakInterfaceMock.UpdateAuthenticationExecutionForFlow,
 			ListClientsFunc:                          keycloakInterfaceMock.ListClients,
+			ListIdentityProvidersFunc:                keycloakInterfaceMock.ListIdentityProviders,
+			GetRealmFunc:                             keycloakInterfaceMock.GetRealm,
 			ListOfActivesUsersPerRealmFunc:           keycloakInterfaceMock.ListOfActivesUsersPerRealm,
 		}, nil
 	}}
@@ -1495,6 +1497,14 @@ func createKeycloakInterfaceMock() (keycloakCommon.KeycloakInterface, *mockClien
 		return "dummy-group-realm-role-id", nil
 	}
 
+	listIdentityProvidersFunc := func(realmName string) ([]*keycloak.KeycloakIdentityProvider, error) {
+		return []*keycloak.KeycloakIdentityProvider{}, nil
+	}
+
+	getRealmFunc := func(realmName string) (*keycloak.KeycloakRealm, error) {
+		return &keycloak.KeycloakRealm{Spec: keycloak.KeycloakRealmSpec{Realm: &keycloak.KeycloakAPIRealm{Realm: realmName}}}, nil
+	}
+
 	listClientsFunc := func(realmName string) ([]*keycloak.KeycloakAPIClient, error) {
 		return []*keycloak.KeycloakAPIClient{
 			&keycloak.KeycloakAPIClient{
@@ -1591,6 +1601,8 @@ func createKeycloakInterfaceMock() (keycloakCommon.KeycloakInterface, *mockClien
 		FindAuthenticationExecutionForFlowFunc:   findAuthenticationExecutionForFlowFunc,
 		UpdateAuthenticationExecutionForFlowFunc: updateAuthenticationExecutionForFlowFunc,
 		ListClientsFunc:                          listClientsFunc,
+		ListIdentityProvidersFunc:                listIdentityProvidersFunc,
+		GetRealmFunc:                             getRealmFunc,
 		ListOfActivesUsersPerRealmFunc:           listOfActivesUsersPerRealmFunc,
 	}, &context
 }
@@ -0,0 +1,453 @@
+package threescale
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	portaClient "github.com/3scale/3scale-porta-go-client/client"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applicationPlanClient is the part of the 3scale account management API of a
+// tenant used to sync the application plan templates
+type applicationPlanClient interface {
+	ListProducts() (*portaClient.ProductList, error)
+	ListProductMetrics(productID int64) (*portaClient.MetricJSONList, error)
+	ListApplicationPlansByProduct(productID int64) (*portaClient.ApplicationPlanJSONList, error)
+	CreateApplicationPlan(productID int64, params portaClient.Params) (*portaClient.ApplicationPlan, error)
+	UpdateApplicationPlan(productID, id int64, params portaClient.Params) (*portaClient.ApplicationPlan, error)
+	ListApplicationPlansLimits(planID int64) (*portaClient.ApplicationPlanLimitList, error)
+	CreateApplicationPlanLimit(planID, metricID int64, params portaClient.Params) (*portaClient.ApplicationPlanLimit, error)
+	UpdateApplicationPlanLimit(planID, metricID, limitID int64, params portaClient.Params) (*portaClient.ApplicationPlanLimit, error)
+	DeleteApplicationPlanLimit(planID, metricID, limitID int64) error
+	// ListProductFeatures and ListApplicationPlanFeatures return the IDs of
+	// the features by system name
+	ListProductFeatures(productID int64) (map[string]int64, error)
+	ListApplicationPlanFeatures(planID int64) (map[string]int64, error)
+	EnableApplicationPlanFeature(planID, featureID int64) error
+	DisableApplicationPlanFeature(planID, featureID int64) error
+}
+
+// tenantPlanClient adds the features API, which the porta client doesn't
+// support, to the porta client of a tenant
+type tenantPlanClient struct {
+	*portaClient.ThreeScaleClient
+	httpc       *http.Client
+	adminURL    string
+	accessToken string
+}
+
+type featureList struct {
+	Features []struct {
+		Feature struct {
+			ID         int64  `json:"id"`
+			SystemName string `json:"system_name"`
+		} `json:"feature"`
+	} `json:"features"`
+}
+
+func newTenantPlanClient(account SignUpAccount, installation *integreatlyv1alpha1.RHMI) (*tenantPlanClient, error) {
+	adminURL, err := url.Parse(account.AccountDetail.AdminBaseURL)
+	if err != nil || adminURL.Host == "" {
+		return nil, fmt.Errorf("invalid admin url %q of tenant %s", account.AccountDetail.AdminBaseURL, account.AccountDetail.OrgName)
+	}
+	adminPortal, err := portaClient.NewAdminPortal("https", adminURL.Hostname(), 443)
+	if err != nil {
+		return nil, fmt.Errorf("could not create admin portal of tenant %s: %w", account.AccountDetail.OrgName, err)
+	}
+
+	httpc := &http.Client{
+		Timeout: time.Second * 10,
+		Transport: dryrun.Transport(installation, &http.Transport{
+			DisableKeepAlives: true,
+			IdleConnTimeout:   time.Second * 10,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: installation.Spec.SelfSignedCerts}, //#nosec G402 -- value is read from CR config
+		}),
+	}
+
+	return &tenantPlanClient{
+		ThreeScaleClient: portaClient.NewThreeScale(adminPortal, account.AccountAccessToken.Value, httpc),
+		httpc:            httpc,
+		adminURL:         "https://" + adminURL.Hostname(),
+		accessToken:      account.AccountAccessToken.Value,
+	}, nil
+}
+
+func (c *tenantPlanClient) ListProductFeatures(productID int64) (map[string]int64, error) {
+	return c.listFeatures(fmt.Sprintf("/admin/api/services/%d/features.json", productID))
+}
+
+func (c *tenantPlanClient) ListApplicationPlanFeatures(planID int64) (map[string]int64, error) {
+	return c.listFeatures(fmt.Sprintf("/admin/api/application_plans/%d/features.json", planID))
+}
+
+func (c *tenantPlanClient) EnableApplicationPlanFeature(planID, featureID int64) error {
+	_, err := c.do(http.MethodPost, fmt.Sprintf("/admin/api/application_plans/%d/features.json", planID), url.Values{"feature_id": {strconv.FormatInt(featureID, 10)}}, http.StatusCreated)
+	return err
+}
+
+func (c *tenantPlanClient) DisableApplicationPlanFeature(planID, featureID int64) error {
+	_, err := c.do(http.MethodDelete, fmt.Sprintf("/admin/api/application_plans/%d/features/%d.json", planID, featureID), nil, http.StatusOK)
+	return err
+}
+
+func (c *tenantPlanClient) listFeatures(path string) (map[string]int64, error) {
+	res, err := c.do(http.MethodGet, path, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	list := &featureList{}
+	if err := json.NewDecoder(res.Body).Decode(list); err != nil {
+		return nil, fmt.Errorf("failed to decode features: %w", err)
+	}
+	features := map[string]int64{}
+	for _, feature := range list.Features {
+		features[feature.Feature.SystemName] = feature.Feature.ID
+	}
+	return features, nil
+}
+
+func (c *tenantPlanClient) do(method, path string, values url.Values, expectedStatus int) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.adminURL+path, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth("", c.accessToken)
+	req.Header.Set("Accept", "application/json")
+	if values != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	res, err := c.httpc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := assertStatusCode(expectedStatus, res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if method != http.MethodGet {
+		res.Body.Close()
+	}
+	return res, nil
+}
+
+// validateApplicationPlanTemplates checks that no two templates of a quota
+// manage the same plan
+func validateApplicationPlanTemplates(templates []integreatlyv1alpha1.ApplicationPlanTemplateSpec) error {
+	// the templates managing each plan by quota, "" for all quotas
+	type planKey struct{ product, systemName string }
+	plans := map[planKey]map[string]string{}
+
+	for _, template := range templates {
+		if template.Product == "" || template.SystemName == "" || template.PlanName == "" {
+			return fmt.Errorf("application plan template %q must set the product, system name and plan name", template.Name)
+		}
+		key := planKey{template.Product, template.SystemName}
+		if plans[key] == nil {
+			plans[key] = map[string]string{}
+		}
+		quotas := template.Quotas
+		if len(quotas) == 0 {
+			quotas = []string{""}
+		}
+		for _, quota := range quotas {
+			for otherQuota, other := range plans[key] {
+				if quota == "" || otherQuota == "" || quota == otherQuota {
+					return fmt.Errorf("application plan templates %q and %q both manage plan %s of product %s", other, template.Name, template.SystemName, template.Product)
+				}
+			}
+		}
+		for _, quota := range quotas {
+			plans[key][quota] = template.Name
+		}
+
+		limits := map[string]bool{}
+		for _, limit := range template.Limits {
+			key := limit.Metric + "/" + limit.Period
+			if limits[key] {
+				return fmt.Errorf("application plan template %q has more than one %s limit for %s", template.Name, limit.Period, limit.Metric)
+			}
+			limits[key] = true
+		}
+	}
+
+	return nil
+}
+
+// getQuotaApplicationPlanTemplates returns the templates of the quota
+func getQuotaApplicationPlanTemplates(templates []integreatlyv1alpha1.ApplicationPlanTemplateSpec, quota string) []integreatlyv1alpha1.ApplicationPlanTemplateSpec {
+	var quotaTemplates []integreatlyv1alpha1.ApplicationPlanTemplateSpec
+	for _, template := range templates {
+		if len(template.Quotas) == 0 {
+			quotaTemplates = append(quotaTemplates, template)
+			continue
+		}
+		for _, templateQuota := range template.Quotas {
+			if templateQuota == quota {
+				quotaTemplates = append(quotaTemplates, template)
+				break
+			}
+		}
+	}
+	return quotaTemplates
+}
+
+// reconcileTenantsApplicationPlans applies the application plan templates to
+// the created tenant accounts. Failures are logged per account so one broken
+// tenant doesn't block the others
+func (r *Reconciler) reconcileTenantsApplicationPlans(ctx context.Context, serverClient k8sclient.Client, accounts []AccountDetail, tenantsCreated *corev1.ConfigMap, signUpAccountsSecret *corev1.Secret) {
+	for _, account := range accounts {
+		if account.State != "approved" || tenantsCreated.Data[account.OrgName] != "true" {
+			continue
+		}
+		token, ok := signUpAccountsSecret.Data[account.OrgName]
+		if !ok || len(token) == 0 {
+			continue
+		}
+
+		signUpAccount := SignUpAccount{
+			AccountDetail:      account,
+			AccountAccessToken: AccountAccessToken{Value: string(token)},
+		}
+		if err := r.reconcileTenantApplicationPlans(ctx, serverClient, signUpAccount); err != nil {
+			r.log.Errorf("Error reconciling application plans for the tenant account",
+				l.Fields{
+					"tenantAccountId":   account.Id,
+					"tenantAccountName": account.OrgName,
+				},
+				err,
+			)
+		}
+	}
+}
+
+// reconcileTenantApplicationPlans applies the application plan templates of
+// the installed quota to the tenant account. The templates are applied again
+// whenever they or the quota change, as long as a product is missing in the
+// tenant they're retried
+func (r *Reconciler) reconcileTenantApplicationPlans(ctx context.Context, serverClient k8sclient.Client, account SignUpAccount) error {
+	tenant, err := getAPIManagementTenantForAccount(ctx, serverClient, account.AccountDetail)
+	if err != nil {
+		return err
+	}
+	if tenant == nil {
+		return nil
+	}
+
+	templates := getQuotaApplicationPlanTemplates(r.installation.Spec.ApplicationPlanTemplates, r.installation.Status.Quota)
+	configHash := ""
+	if len(templates) > 0 {
+		if err := validateApplicationPlanTemplates(r.installation.Spec.ApplicationPlanTemplates); err != nil {
+			return err
+		}
+		templatesJSON, err := json.Marshal(templates)
+		if err != nil {
+			return fmt.Errorf("failed to marshal application plan templates: %w", err)
+		}
+		hash := sha256.Sum256(templatesJSON)
+		configHash = hex.EncodeToString(hash[:])
+	}
+	if tenant.Status.ApplicationPlansHash == configHash {
+		return nil
+	}
+
+	if len(templates) > 0 {
+		planClient, err := newTenantPlanClient(account, r.installation)
+		if err != nil {
+			return err
+		}
+		synced, err := syncApplicationPlans(planClient, templates)
+		if err != nil {
+			return fmt.Errorf("failed to sync application plans of tenant %s: %w", account.AccountDetail.OrgName, err)
+		}
+		if !synced {
+			r.log.Infof("Waiting on the tenant products of the application plan templates", l.Fields{"tenantAccountName": account.AccountDetail.OrgName})
+			return nil
+		}
+		r.log.Infof("Synced application plans", l.Fields{"tenantAccountName": account.AccountDetail.OrgName, "templates": len(templates)})
+	}
+
+	tenant.Status.ApplicationPlansHash = configHash
+	if err := serverClient.Status().Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to update application plans hash of tenant %s: %w", tenant.Name, err)
+	}
+	return nil
+}
+
+// syncApplicationPlans creates or updates the plans of the templates in the
+// tenant's products. It returns false if a product doesn't exist yet
+func syncApplicationPlans(planClient applicationPlanClient, templates []integreatlyv1alpha1.ApplicationPlanTemplateSpec) (bool, error) {
+	products, err := planClient.ListProducts()
+	if err != nil {
+		return false, fmt.Errorf("failed to list products: %w", err)
+	}
+	productIDs := map[string]int64{}
+	for _, product := range products.Products {
+		productIDs[product.Element.SystemName] = product.Element.ID
+	}
+
+	synced := true
+	for _, template := range templates {
+		productID, ok := productIDs[template.Product]
+		if !ok {
+			synced = false
+			continue
+		}
+		if err := syncApplicationPlan(planClient, productID, template); err != nil {
+			return false, fmt.Errorf("application plan template %s: %w", template.Name, err)
+		}
+	}
+	return synced, nil
+}
+
+func syncApplicationPlan(planClient applicationPlanClient, productID int64, template integreatlyv1alpha1.ApplicationPlanTemplateSpec) error {
+	plans, err := planClient.ListApplicationPlansByProduct(productID)
+	if err != nil {
+		return fmt.Errorf("failed to list application plans: %w", err)
+	}
+	params := portaClient.Params{
+		"name":              template.PlanName,
+		"approval_required": strconv.FormatBool(template.ApprovalRequired),
+		"trial_period_days": strconv.Itoa(int(template.TrialPeriodDays)),
+	}
+
+	var planID int64
+	for _, plan := range plans.Plans {
+		if plan.Element.SystemName != template.SystemName {
+			continue
+		}
+		planID = plan.Element.ID
+		if plan.Element.Name != template.PlanName || plan.Element.ApprovalRequired != template.ApprovalRequired || plan.Element.TrialPeriodDays != int(template.TrialPeriodDays) {
+			if _, err := planClient.UpdateApplicationPlan(productID, planID, params); err != nil {
+				return fmt.Errorf("failed to update application plan %s: %w", template.SystemName, err)
+			}
+		}
+		break
+	}
+	if planID == 0 {
+		params["system_name"] = template.SystemName
+		params["state_event"] = "publish"
+		plan, err := planClient.CreateApplicationPlan(productID, params)
+		if err != nil {
+			return fmt.Errorf("failed to create application plan %s: %w", template.SystemName, err)
+		}
+		planID = plan.Element.ID
+	}
+
+	if err := syncApplicationPlanLimits(planClient, productID, planID, template.Limits); err != nil {
+		return err
+	}
+	return syncApplicationPlanFeatures(planClient, productID, planID, template.Features)
+}
+
+func syncApplicationPlanLimits(planClient applicationPlanClient, productID, planID int64, limits []integreatlyv1alpha1.PlanLimitSpec) error {
+	metrics, err := planClient.ListProductMetrics(productID)
+	if err != nil {
+		return fmt.Errorf("failed to list metrics: %w", err)
+	}
+	metricIDs := map[string]int64{}
+	for _, metric := range metrics.Metrics {
+		metricIDs[metric.Element.SystemName] = metric.Element.ID
+	}
+
+	type limitKey struct {
+		metricID int64
+		period   string
+	}
+	desired := map[limitKey]int{}
+	for _, limit := range limits {
+		metricID, ok := metricIDs[limit.Metric]
+		if !ok {
+			return fmt.Errorf("metric %s not found", limit.Metric)
+		}
+		desired[limitKey{metricID, limit.Period}] = int(limit.Value)
+	}
+
+	existing, err := planClient.ListApplicationPlansLimits(planID)
+	if err != nil {
+		return fmt.Errorf("failed to list limits: %w", err)
+	}
+	for _, limit := range existing.Limits {
+		key := limitKey{limit.Element.MetricID, limit.Element.Period}
+		value, ok := desired[key]
+		if !ok {
+			if err := planClient.DeleteApplicationPlanLimit(planID, key.metricID, limit.Element.ID); err != nil {
+				return fmt.Errorf("failed to delete limit: %w", err)
+			}
+			continue
+		}
+		delete(desired, key)
+		if limit.Element.Value != value {
+			if _, err := planClient.UpdateApplicationPlanLimit(planID, key.metricID, limit.Element.ID, portaClient.Params{"value": strconv.Itoa(value)}); err != nil {
+				return fmt.Errorf("failed to update limit: %w", err)
+			}
+		}
+	}
+
+	// create the missing limits in a stable order
+	keys := make([]limitKey, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metricID != keys[j].metricID {
+			return keys[i].metricID < keys[j].metricID
+		}
+		return keys[i].period < keys[j].period
+	})
+	for _, key := range keys {
+		if _, err := planClient.CreateApplicationPlanLimit(planID, key.metricID, portaClient.Params{"period": key.period, "value": strconv.Itoa(desired[key])}); err != nil {
+			return fmt.Errorf("failed to create limit: %w", err)
+		}
+	}
+	return nil
+}
+
+func syncApplicationPlanFeatures(planClient applicationPlanClient, productID, planID int64, features []string) error {
+	productFeatures, err := planClient.ListProductFeatures(productID)
+	if err != nil {
+		return fmt.Errorf("failed to list features: %w", err)
+	}
+	planFeatures, err := planClient.ListApplicationPlanFeatures(planID)
+	if err != nil {
+		return fmt.Errorf("failed to list plan features: %w", err)
+	}
+
+	enabled := map[string]bool{}
+	for _, feature := range features {
+		featureID, ok := productFeatures[feature]
+		if !ok {
+			return fmt.Errorf("feature %s not found", feature)
+		}
+		enabled[feature] = true
+		if _, ok := planFeatures[feature]; !ok {
+			if err := planClient.EnableApplicationPlanFeature(planID, featureID); err != nil {
+				return fmt.Errorf("failed to enable feature %s: %w", feature, err)
+			}
+		}
+	}
+	for feature, featureID := range planFeatures {
+		if !enabled[feature] {
+			if err := planClient.DisableApplicationPlanFeature(planID, featureID); err != nil {
+				return fmt.Errorf("failed to disable feature %s: %w", feature, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package threescale
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	portaClient "github.com/3scale/3scale-porta-go-client/client"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+)
+
+// fakeApplicationPlanClient keeps the plans, limits and features of a single
+// tenant in memory
+type fakeApplicationPlanClient struct {
+	products     map[string]int64
+	metrics      map[int64]map[string]int64
+	features     map[int64]map[string]int64
+	plans        map[int64][]portaClient.ApplicationPlanItem
+	limits       map[int64][]portaClient.ApplicationPlanLimitItem
+	planFeatures map[int64]map[string]int64
+	nextID       int64
+}
+
+func (c *fakeApplicationPlanClient) id() int64 {
+	c.nextID++
+	return c.nextID
+}
+
+func (c *fakeApplicationPlanClient) ListProducts() (*portaClient.ProductList, error) {
+	list := &portaClient.ProductList{}
+	for systemName, id := range c.products {
+		list.Products = append(list.Products, portaClient.Product{Element: portaClient.ProductItem{ID: id, SystemName: systemName}})
+	}
+	return list, nil
+}
+
+func (c *fakeApplicationPlanClient) ListProductMetrics(productID int64) (*portaClient.MetricJSONList, error) {
+	list := &portaClient.MetricJSONList{}
+	for systemName, id := range c.metrics[productID] {
+		list.Metrics = append(list.Metrics, portaClient.MetricJSON{Element: portaClient.MetricItem{ID: id, SystemName: systemName}})
+	}
+	return list, nil
+}
+
+func (c *fakeApplicationPlanClient) ListApplicationPlansByProduct(productID int64) (*portaClient.ApplicationPlanJSONList, error) {
+	list := &portaClient.ApplicationPlanJSONList{}
+	for _, plan := range c.plans[productID] {
+		list.Plans = append(list.Plans, portaClient.ApplicationPlan{Element: plan})
+	}
+	return list, nil
+}
+
+func (c *fakeApplicationPlanClient) CreateApplicationPlan(productID int64, params portaClient.Params) (*portaClient.ApplicationPlan, error) {
+	plan := portaClient.ApplicationPlanItem{ID: c.id(), SystemName: params["system_name"], State: "published"}
+	applyPlanParams(&plan, params)
+	c.plans[productID] = append(c.plans[productID], plan)
+	return &portaClient.ApplicationPlan{Element: plan}, nil
+}
+
+func (c *fakeApplicationPlanClient) UpdateApplicationPlan(productID, id int64, params portaClient.Params) (*portaClient.ApplicationPlan, error) {
+	for i := range c.plans[productID] {
+		if c.plans[productID][i].ID == id {
+			applyPlanParams(&c.plans[productID][i], params)
+			return &portaClient.ApplicationPlan{Element: c.plans[productID][i]}, nil
+		}
+	}
+	return nil, portaClient.ApiErr{}
+}
+
+func applyPlanParams(plan *portaClient.ApplicationPlanItem, params portaClient.Params) {
+	plan.Name = params["name"]
+	plan.ApprovalRequired, _ = strconv.ParseBool(params["approval_required"])
+	plan.TrialPeriodDays, _ = strconv.Atoi(params["trial_period_days"])
+}
+
+func (c *fakeApplicationPlanClient) ListApplicationPlansLimits(planID int64) (*portaClient.ApplicationPlanLimitList, error) {
+	list := &portaClient.ApplicationPlanLimitList{}
+	for _, limit := range c.limits[planID] {
+		list.Limits = append(list.Limits, portaClient.ApplicationPlanLimit{Element: limit})
+	}
+	return list, nil
+}
+
+func (c *fakeApplicationPlanClient) CreateApplicationPlanLimit(planID, metricID int64, params portaClient.Params) (*portaClient.ApplicationPlanLimit, error) {
+	value, _ := strconv.Atoi(params["value"])
+	limit := portaClient.ApplicationPlanLimitItem{ID: c.id(), PlanID: planID, MetricID: metricID, Period: params["period"], Value: value}
+	c.limits[planID] = append(c.limits[planID], limit)
+	return &portaClient.ApplicationPlanLimit{Element: limit}, nil
+}
+
+func (c *fakeApplicationPlanClient) UpdateApplicationPlanLimit(planID, metricID, limitID int64, params portaClient.Params) (*portaClient.ApplicationPlanLimit, error) {
+	for i := range c.limits[planID] {
+		if c.limits[planID][i].ID == limitID {
+			c.limits[planID][i].Value, _ = strconv.Atoi(params["value"])
+			return &portaClient.ApplicationPlanLimit{Element: c.limits[planID][i]}, nil
+		}
+	}
+	return nil, portaClient.ApiErr{}
+}
+
+func (c *fakeApplicationPlanClient) DeleteApplicationPlanLimit(planID, metricID, limitID int64) error {
+	for i := range c.limits[planID] {
+		if c.limits[planID][i].ID == limitID {
+			c.limits[planID] = append(c.limits[planID][:i], c.limits[planID][i+1:]...)
+			return nil
+		}
+	}
+	return portaClient.ApiErr{}
+}
+
+func (c *fakeApplicationPlanClient) ListProductFeatures(productID int64) (map[string]int64, error) {
+	return c.features[productID], nil
+}
+
+func (c *fakeApplicationPlanClient) ListApplicationPlanFeatures(planID int64) (map[string]int64, error) {
+	features := map[string]int64{}
+	for name, id := range c.planFeatures[planID] {
+		features[name] = id
+	}
+	return features, nil
+}
+
+func (c *fakeApplicationPlanClient) EnableApplicationPlanFeature(planID, featureID int64) error {
+	for _, features := range c.features {
+		for name, id := range features {
+			if id == featureID {
+				if c.planFeatures[planID] == nil {
+					c.planFeatures[planID] = map[string]int64{}
+				}
+				c.planFeatures[planID][name] = id
+			}
+		}
+	}
+	return nil
+}
+
+func (c *fakeApplicationPlanClient) DisableApplicationPlanFeature(planID, featureID int64) error {
+	for name, id := range c.planFeatures[planID] {
+		if id == featureID {
+			delete(c.planFeatures[planID], name)
+		}
+	}
+	return nil
+}
+
+func newFakeApplicationPlanClient() *fakeApplicationPlanClient {
+	return &fakeApplicationPlanClient{
+		products: map[string]int64{"api": 1},
+		metrics:  map[int64]map[string]int64{1: {"hits": 10, "search": 11}},
+		features: map[int64]map[string]int64{1: {"support": 20, "analytics": 21}},
+		plans: map[int64][]portaClient.ApplicationPlanItem{1: {
+			{ID: 30, Name: "Old basic", SystemName: "basic", TrialPeriodDays: 0},
+			{ID: 31, Name: "Unmanaged", SystemName: "unmanaged"},
+		}},
+		limits: map[int64][]portaClient.ApplicationPlanLimitItem{
+			30: {
+				{ID: 40, PlanID: 30, MetricID: 10, Period: "day", Value: 10},
+				{ID: 41, PlanID: 30, MetricID: 11, Period: "day", Value: 5},
+			},
+			31: {{ID: 42, PlanID: 31, MetricID: 10, Period: "day", Value: 1}},
+		},
+		planFeatures: map[int64]map[string]int64{30: {"analytics": 21}},
+		nextID:       100,
+	}
+}
+
+func TestValidateApplicationPlanTemplates(t *testing.T) {
+	template := func(name, product, systemName string, quotas ...string) integreatlyv1alpha1.ApplicationPlanTemplateSpec {
+		return integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+			Name:       name,
+			Quotas:     quotas,
+			Product:    product,
+			SystemName: systemName,
+			PlanName:   name,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		templates []integreatlyv1alpha1.ApplicationPlanTemplateSpec
+		wantErr   bool
+	}{
+		{
+			name: "templates of different quotas can manage the same plan",
+			templates: []integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+				template("small", "api", "basic", "1 Million"),
+				template("large", "api", "basic", "5 Million"),
+				template("other", "api", "premium"),
+			},
+		},
+		{
+			name: "templates of the same quota can't manage the same plan",
+			templates: []integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+				template("small", "api", "basic", "1 Million"),
+				template("other", "api", "basic", "5 Million", "1 Million"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "a template of all quotas conflicts with a template of a quota",
+			templates: []integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+				template("small", "api", "basic", "1 Million"),
+				template("all", "api", "basic"),
+			},
+			wantErr: true,
+		},
+		{
+			name:      "template without a product",
+			templates: []integreatlyv1alpha1.ApplicationPlanTemplateSpec{template("small", "", "basic")},
+			wantErr:   true,
+		},
+		{
+			name: "duplicated limit",
+			templates: []integreatlyv1alpha1.ApplicationPlanTemplateSpec{{
+				Name:       "small",
+				Product:    "api",
+				SystemName: "basic",
+				PlanName:   "Basic",
+				Limits: []integreatlyv1alpha1.PlanLimitSpec{
+					{Metric: "hits", Period: "day", Value: 1},
+					{Metric: "hits", Period: "day", Value: 2},
+				},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateApplicationPlanTemplates(tt.templates); (err != nil) != tt.wantErr {
+				t.Errorf("validateApplicationPlanTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetQuotaApplicationPlanTemplates(t *testing.T) {
+	templates := []integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+		{Name: "small", Quotas: []string{"1 Million"}},
+		{Name: "large", Quotas: []string{"5 Million", "10 Million"}},
+		{Name: "all"},
+	}
+
+	var names []string
+	for _, template := range getQuotaApplicationPlanTemplates(templates, "10 Million") {
+		names = append(names, template.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"large", "all"}) {
+		t.Fatalf("expected the templates of the quota and of all quotas, got %v", names)
+	}
+}
+
+func TestSyncApplicationPlans(t *testing.T) {
+	templates := []integreatlyv1alpha1.ApplicationPlanTemplateSpec{
+		{
+			Name:             "basic",
+			Product:          "api",
+			SystemName:       "basic",
+			PlanName:         "Basic",
+			TrialPeriodDays:  14,
+			ApprovalRequired: true,
+			Features:         []string{"support"},
+			Limits: []integreatlyv1alpha1.PlanLimitSpec{
+				{Metric: "hits", Period: "day", Value: 1000},
+				{Metric: "hits", Period: "minute", Value: 10},
+			},
+		},
+		{
+			Name:       "premium",
+			Product:    "api",
+			SystemName: "premium",
+			PlanName:   "Premium",
+			Features:   []string{"support", "analytics"},
+		},
+	}
+
+	t.Run("plans are created and updated to match the templates", func(t *testing.T) {
+		client := newFakeApplicationPlanClient()
+
+		synced, err := syncApplicationPlans(client, templates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !synced {
+			t.Fatal("expected the plans to be synced")
+		}
+
+		basic := client.plans[1][0]
+		if basic.Name != "Basic" || basic.TrialPeriodDays != 14 || !basic.ApprovalRequired {
+			t.Errorf("expected the basic plan to be updated, got %+v", basic)
+		}
+		var limits []string
+		for _, limit := range client.limits[30] {
+			limits = append(limits, strconv.FormatInt(limit.MetricID, 10)+"/"+limit.Period+"/"+strconv.Itoa(limit.Value))
+		}
+		sort.Strings(limits)
+		if !reflect.DeepEqual(limits, []string{"10/day/1000", "10/minute/10"}) {
+			t.Errorf("expected the limits of the basic plan to match the template, got %v", limits)
+		}
+		if !reflect.DeepEqual(client.planFeatures[30], map[string]int64{"support": 20}) {
+			t.Errorf("expected only the support feature on the basic plan, got %v", client.planFeatures[30])
+		}
+
+		if len(client.plans[1]) != 3 {
+			t.Fatalf("expected the premium plan to be created, got %+v", client.plans[1])
+		}
+		premium := client.plans[1][2]
+		if premium.SystemName != "premium" || premium.Name != "Premium" {
+			t.Errorf("unexpected premium plan %+v", premium)
+		}
+		if len(client.planFeatures[premium.ID]) != 2 {
+			t.Errorf("expected both features on the premium plan, got %v", client.planFeatures[premium.ID])
+		}
+
+		if len(client.limits[31]) != 1 {
+			t.Errorf("expected the limits of the unmanaged plan to be kept, got %v", client.limits[31])
+		}
+	})
+
+	t.Run("missing products are reported as not synced", func(t *testing.T) {
+		client := newFakeApplicationPlanClient()
+		delete(client.products, "api")
+
+		synced, err := syncApplicationPlans(client, templates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if synced {
+			t.Fatal("expected the plans not to be synced without the product")
+		}
+	})
+
+	t.Run("unknown metric", func(t *testing.T) {
+		client := newFakeApplicationPlanClient()
+		_, err := syncApplicationPlans(client, []integreatlyv1alpha1.ApplicationPlanTemplateSpec{{
+			Name:       "basic",
+			Product:    "api",
+			SystemName: "basic",
+			PlanName:   "Basic",
+			Limits:     []integreatlyv1alpha1.PlanLimitSpec{{Metric: "missing", Period: "day", Value: 1}},
+		}})
+		if err == nil {
+			t.Fatal("expected an error for the unknown metric")
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package threescale
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/user"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const paymentGatewayTypeKey = "type"
+
+// tenantBillingConfig is the billing configuration applied to a tenant
+// account through the rails runner of the system-master container
+type tenantBillingConfig struct {
+	AccountID             int               `json:"account_id"`
+	InvoicingEnabled      bool              `json:"invoicing_enabled"`
+	ChargingEnabled       bool              `json:"charging_enabled"`
+	Currency              string            `json:"currency,omitempty"`
+	PaymentGatewayType    string            `json:"payment_gateway_type,omitempty"`
+	PaymentGatewayOptions map[string]string `json:"payment_gateway_options,omitempty"`
+}
+
+// billingRailsScript applies the tenantBillingConfig written to its standard
+// input. The config is passed through stdin so the payment gateway secrets
+// don't show up in the command line of the process, and can't break out of
+// the shell and ruby strings
+const billingRailsScript = `bundle exec rails runner "` +
+	`c = JSON.parse(STDIN.read); ` +
+	`a = Account.find(c['account_id']); ` +
+	`if c['invoicing_enabled'] then ` +
+	`a.settings.allow_finance! if a.settings.finance_switch == 'denied'; ` +
+	`a.settings.show_finance! if a.settings.finance_switch == 'hidden'; ` +
+	`elsif a.settings.finance_switch != 'denied' then ` +
+	`a.settings.deny_finance!; ` +
+	`end; ` +
+	`bs = a.billing_strategy || Finance::PostpaidBillingStrategy.create!(account: a); ` +
+	`bs.charging_enabled = c['charging_enabled']; ` +
+	`bs.currency = c['currency'] if c['currency']; ` +
+	`bs.save!; ` +
+	`if c['payment_gateway_type'] then ` +
+	`a.payment_gateway_type = c['payment_gateway_type'].to_sym; ` +
+	`a.payment_gateway_options = c['payment_gateway_options'] || {}; ` +
+	`a.save!; ` +
+	`end"`
+
+// reconcileTenantsBilling applies the billing configuration to every approved
+// tenant account on each pass, so changes to the billing spec reach accounts
+// that were created before the change. Accounts whose configuration hasn't
+// changed are skipped by reconcileTenantBilling
+func (r *Reconciler) reconcileTenantsBilling(ctx context.Context, serverClient k8sclient.Client, accounts []AccountDetail) {
+	for _, account := range accounts {
+		if account.State != "approved" {
+			continue
+		}
+		if err := r.reconcileTenantBilling(ctx, serverClient, account); err != nil {
+			r.log.Errorf("Error reconciling billing configuration for the tenant account",
+				l.Fields{
+					"tenantAccountId":   account.Id,
+					"tenantAccountName": account.OrgName,
+				},
+				err,
+			)
+		}
+	}
+}
+
+// reconcileTenantBilling applies the billing configuration of the
+// APIManagementTenant that owns the account. The configuration is applied
+// again whenever it changes or the account is recreated
+func (r *Reconciler) reconcileTenantBilling(ctx context.Context, serverClient k8sclient.Client, account AccountDetail) error {
+	tenant, err := getAPIManagementTenantForAccount(ctx, serverClient, account)
+	if err != nil {
+		return err
+	}
+	if tenant == nil || tenant.Spec.Billing == nil {
+		return nil
+	}
+
+	config, err := getTenantBillingConfig(ctx, serverClient, tenant, account)
+	if err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal billing config for tenant %s: %w", account.OrgName, err)
+	}
+	hash := sha256.Sum256(configJSON)
+	configHash := hex.EncodeToString(hash[:])
+	if tenant.Status.BillingConfigHash == configHash {
+		return nil
+	}
+
+	podName, err := r.getRunningSystemAppPodName(ctx, serverClient)
+	if err != nil {
+		return err
+	}
+	if podName == "" {
+		r.log.Info("Waiting on system-app pod to start to configure tenant billing")
+		return nil
+	}
+
+	_, stderr, err := r.podExecutor.ExecuteRemoteContainerCommandWithStdin(r.Config.GetNamespace(), podName, "system-master",
+		[]string{"/bin/bash", "-c", billingRailsScript}, string(configJSON))
+	if err != nil {
+		return fmt.Errorf("failed to configure billing for tenant %s: %w", account.OrgName, err)
+	}
+	if stderr != "" {
+		return fmt.Errorf("failed to configure billing for tenant %s: %w", account.OrgName, errors.New(stderr))
+	}
+	r.log.Infof("Configured tenant billing", l.Fields{"tenantAccountName": account.OrgName, "invoicingEnabled": config.InvoicingEnabled, "chargingEnabled": config.ChargingEnabled})
+
+	tenant.Status.BillingConfigHash = configHash
+	if err := serverClient.Status().Update(ctx, tenant); err != nil {
+		return fmt.Errorf("failed to update billing config hash of tenant %s: %w", tenant.Name, err)
+	}
+
+	return nil
+}
+
+// getAPIManagementTenantForAccount returns the APIManagementTenant of the user
+// that the account was created for, or nil if there is none
+func getAPIManagementTenantForAccount(ctx context.Context, serverClient k8sclient.Client, account AccountDetail) (*integreatlyv1alpha1.APIManagementTenant, error) {
+	tenants := &integreatlyv1alpha1.APIManagementTenantList{}
+	if err := serverClient.List(ctx, tenants); err != nil {
+		return nil, fmt.Errorf("failed to list APIManagementTenants: %w", err)
+	}
+
+	for i := range tenants.Items {
+		tenantName, err := user.SanitiseTenantUserName(tenants.Items[i].GetUsername())
+		if err != nil {
+			return nil, err
+		}
+		if tenantName == account.OrgName {
+			return &tenants.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func getTenantBillingConfig(ctx context.Context, serverClient k8sclient.Client, tenant *integreatlyv1alpha1.APIManagementTenant, account AccountDetail) (*tenantBillingConfig, error) {
+	billing := tenant.Spec.Billing
+	config := &tenantBillingConfig{
+		AccountID:        account.Id,
+		InvoicingEnabled: billing.InvoicingEnabled,
+		ChargingEnabled:  billing.ChargingEnabled,
+		Currency:         billing.Currency,
+	}
+
+	if billing.PaymentGatewaySecretRef == nil {
+		return config, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: billing.PaymentGatewaySecretRef.Name, Namespace: tenant.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get payment gateway secret of tenant %s: %w", tenant.Name, err)
+	}
+
+	gatewayType, ok := secret.Data[paymentGatewayTypeKey]
+	if !ok || len(gatewayType) == 0 {
+		return nil, fmt.Errorf("payment gateway secret %s is missing the %s key", secret.Name, paymentGatewayTypeKey)
+	}
+	config.PaymentGatewayType = string(gatewayType)
+	config.PaymentGatewayOptions = map[string]string{}
+	for key, value := range secret.Data {
+		if key != paymentGatewayTypeKey {
+			config.PaymentGatewayOptions[key] = string(value)
+		}
+	}
+
+	return config, nil
+}
+
+func (r *Reconciler) getRunningSystemAppPodName(ctx context.Context, serverClient k8sclient.Client) (string, error) {
+	pods := &corev1.PodList{}
+	if err := serverClient.List(ctx, pods, k8sclient.InNamespace(r.Config.GetNamespace()), k8sclient.MatchingLabels{"deploymentConfig": systemAppDCName}); err != nil {
+		return "", fmt.Errorf("failed to list system-app pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			return pod.Name, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,263 @@
+package threescale
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	usersv1 "github.com/openshift/api/user/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileTenantBilling(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := AccountDetail{
+		Id:      5,
+		OrgName: "alice",
+	}
+	systemAppPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "system-app-1-abcde",
+			Namespace: defaultInstallationNamespace,
+			Labels:    map[string]string{"deploymentConfig": systemAppDCName},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	tenant := func(billing *integreatlyv1alpha1.TenantBillingSpec, hash string) *integreatlyv1alpha1.APIManagementTenant {
+		return &integreatlyv1alpha1.APIManagementTenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "tenant",
+				Namespace: "alice-dev",
+			},
+			Spec: integreatlyv1alpha1.APIManagementTenantSpec{
+				Billing: billing,
+			},
+			Status: integreatlyv1alpha1.APIManagementTenantStatus{
+				BillingConfigHash: hash,
+			},
+		}
+	}
+	gatewaySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "payment-gateway",
+			Namespace: "alice-dev",
+		},
+		Data: map[string][]byte{
+			"type":  []byte("stripe"),
+			"login": []byte("sk_test_'\"; exit 1"),
+		},
+	}
+	billing := &integreatlyv1alpha1.TenantBillingSpec{
+		InvoicingEnabled:        true,
+		ChargingEnabled:         true,
+		Currency:                "EUR",
+		PaymentGatewaySecretRef: &corev1.LocalObjectReference{Name: gatewaySecret.Name},
+	}
+
+	tests := []struct {
+		name         string
+		initObjs     []runtime.Object
+		wantErr      bool
+		wantExecuted bool
+		assert       func(*testing.T, k8sclient.Client, []string, string)
+	}{
+		{
+			name:     "test nothing applied when account has no tenant",
+			initObjs: []runtime.Object{systemAppPod},
+		},
+		{
+			name:     "test nothing applied when tenant has no billing configuration",
+			initObjs: []runtime.Object{systemAppPod, tenant(nil, "")},
+		},
+		{
+			name:         "test billing configuration is applied and recorded",
+			initObjs:     []runtime.Object{systemAppPod, gatewaySecret, tenant(billing, "")},
+			wantExecuted: true,
+			assert: func(t *testing.T, c k8sclient.Client, command []string, stdin string) {
+				if strings.Contains(strings.Join(command, " "), "sk_test") {
+					t.Fatalf("expected payment gateway secret not to be passed in the command line, got %v", command)
+				}
+				applied := &tenantBillingConfig{}
+				if err := json.Unmarshal([]byte(stdin), applied); err != nil {
+					t.Fatalf("failed to unmarshal billing config: %v", err)
+				}
+				if applied.AccountID != account.Id || applied.Currency != "EUR" || applied.PaymentGatewayType != "stripe" || applied.PaymentGatewayOptions["login"] != "sk_test_'\"; exit 1" {
+					t.Fatalf("unexpected billing config applied: %+v", applied)
+				}
+
+				updated := &integreatlyv1alpha1.APIManagementTenant{}
+				if err := c.Get(context.TODO(), k8sclient.ObjectKey{Name: "tenant", Namespace: "alice-dev"}, updated); err != nil {
+					t.Fatal(err)
+				}
+				if updated.Status.BillingConfigHash == "" {
+					t.Fatal("expected billing config hash to be recorded")
+				}
+			},
+		},
+		{
+			name:     "test error when payment gateway secret has no type",
+			initObjs: []runtime.Object{systemAppPod, tenant(billing, ""), &corev1.Secret{ObjectMeta: gatewaySecret.ObjectMeta}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			var command []string
+			var stdin string
+			r := &Reconciler{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				log: getLogger(),
+				podExecutor: &resources.PodExecutorInterfaceMock{
+					ExecuteRemoteContainerCommandWithStdinFunc: func(ns string, podName string, container string, cmd []string, in string) (string, string, error) {
+						command = cmd
+						stdin = in
+						return "", "", nil
+					},
+				},
+			}
+
+			err := r.reconcileTenantBilling(context.TODO(), client, account)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileTenantBilling() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (command != nil) != tt.wantExecuted {
+				t.Fatalf("expected billing config executed to be %t", tt.wantExecuted)
+			}
+			if tt.assert != nil {
+				tt.assert(t, client, command, stdin)
+			}
+
+			// Applying the same configuration again is a no-op
+			if tt.wantExecuted {
+				command = nil
+				if err := r.reconcileTenantBilling(context.TODO(), client, account); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if command != nil {
+					t.Fatal("expected unchanged billing configuration not to be applied again")
+				}
+			}
+		})
+	}
+}
+
+func TestReconciler_reconcile3scaleMultiTenancyBilling(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := AccountDetail{
+		Id:      5,
+		Name:    "alice",
+		OrgName: "alice",
+		State:   "approved",
+	}
+	// the billing configuration that was applied when the account was created
+	appliedConfig, err := json.Marshal(&tenantBillingConfig{AccountID: account.Id, InvoicingEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appliedHash := sha256.Sum256(appliedConfig)
+
+	initObjs := []runtime.Object{
+		&usersv1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice", Annotations: map[string]string{"tenant": "yes"}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "system-seed", Namespace: defaultInstallationNamespace},
+			Data:       map[string][]byte{"MASTER_ACCESS_TOKEN": []byte("token")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenants-created", Namespace: defaultInstallationNamespace},
+			Data:       map[string]string{account.OrgName: "true"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "system-app-1-abcde",
+				Namespace: defaultInstallationNamespace,
+				Labels:    map[string]string{"deploymentConfig": systemAppDCName},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&integreatlyv1alpha1.APIManagementTenant{
+			ObjectMeta: metav1.ObjectMeta{Name: "tenant", Namespace: "alice-dev"},
+			Spec: integreatlyv1alpha1.APIManagementTenantSpec{
+				// charging was enabled after the account was created
+				Billing: &integreatlyv1alpha1.TenantBillingSpec{InvoicingEnabled: true, ChargingEnabled: true},
+			},
+			Status: integreatlyv1alpha1.APIManagementTenantStatus{
+				BillingConfigHash: hex.EncodeToString(appliedHash[:]),
+			},
+		},
+	}
+	client := utils.NewTestClient(scheme, initObjs...)
+
+	var command []string
+	r := &Reconciler{
+		Config: config.NewThreeScale(config.ProductConfig{
+			"NAMESPACE": defaultInstallationNamespace,
+		}),
+		installation: &integreatlyv1alpha1.RHMI{},
+		log:          getLogger(),
+		tsClient: &ThreeScaleInterfaceMock{
+			ListTenantAccountsFunc: func(accessToken string, page int, filterFn func(ac AccountDetail) bool) ([]AccountDetail, error) {
+				if page > 1 {
+					return nil, nil
+				}
+				return []AccountDetail{account}, nil
+			},
+			DeleteTenantsFunc: func(accessToken string, accounts []AccountDetail) error {
+				return nil
+			},
+		},
+		podExecutor: &resources.PodExecutorInterfaceMock{
+			ExecuteRemoteContainerCommandWithStdinFunc: func(ns string, podName string, container string, cmd []string, stdin string) (string, string, error) {
+				command = cmd
+				return "", "", nil
+			},
+		},
+	}
+
+	phase, err := r.reconcile3scaleMultiTenancy(context.TODO(), client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != integreatlyv1alpha1.PhaseCompleted {
+		t.Fatalf("expected phase %s, got %s", integreatlyv1alpha1.PhaseCompleted, phase)
+	}
+	if command == nil {
+		t.Fatal("expected the changed billing configuration to be applied to the created tenant account")
+	}
+	if !strings.Contains(command[len(command)-1], "Account.find") {
+		t.Fatalf("expected the billing rails script to be executed, got %v", command)
+	}
+
+	updated := &integreatlyv1alpha1.APIManagementTenant{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: "tenant", Namespace: "alice-dev"}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status.BillingConfigHash == hex.EncodeToString(appliedHash[:]) {
+		t.Fatal("expected the billing config hash to be updated")
+	}
+}
@@ -90,6 +90,23 @@ func getAppsV1Client(appsv1PreReqs map[string]*appsv1.DeploymentConfig) appsv1Cl
 	return fakeAppsv1
 }
 
+// getRolloutAppsV1Client returns a client recording the deployment configs
+// instantiated, failing every instantiation with err when it is set
+func getRolloutAppsV1Client(err error) (appsv1Client.AppsV1Interface, *[]string) {
+	rolledOut := &[]string{}
+	fakeAppsv1 := fakeappsv1Client.NewSimpleClientset()
+	fakeAppsv1.PrependReactor("create", "deploymentconfigs", func(action testing.Action) (handled bool, ret runtime.Object, reactErr error) {
+		if action, ok := action.(testing.CreateActionImpl); ok && action.Subresource == "instantiate" {
+			if err != nil {
+				return true, nil, err
+			}
+			*rolledOut = append(*rolledOut, action.Name)
+		}
+		return true, nil, nil
+	})
+	return fakeAppsv1.AppsV1(), rolledOut
+}
+
 func getThreeScaleClient() *ThreeScaleInterfaceMock {
 	testUsers := &Users{
 		Users: []*User{},
@@ -0,0 +1,212 @@
+package threescale
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	cors "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"golang.org/x/net/http/httpguts"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const corsFilterName = "envoy.filters.http.cors"
+
+var corsMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// validateCORSPolicies checks the gateway CORS policies can be rendered, and
+// that no host is claimed by more than one policy as envoy rejects route
+// configurations with duplicated domains
+func validateCORSPolicies(policies []integreatlyv1alpha1.CORSPolicySpec) error {
+	names := map[string]bool{}
+	hosts := map[string]string{}
+
+	for _, policy := range policies {
+		if names[policy.Name] {
+			return fmt.Errorf("duplicated cors policy %q", policy.Name)
+		}
+		names[policy.Name] = true
+
+		if len(policy.Hosts) == 0 {
+			return fmt.Errorf("cors policy %q has no hosts", policy.Name)
+		}
+		for _, host := range policy.Hosts {
+			host = strings.ToLower(host)
+			if err := validateCORSHost(host); err != nil {
+				return fmt.Errorf("cors policy %q: %w", policy.Name, err)
+			}
+			if other, ok := hosts[host]; ok {
+				return fmt.Errorf("cors policies %q and %q conflict on host %q", other, policy.Name, host)
+			}
+			hosts[host] = policy.Name
+		}
+
+		if len(policy.AllowOrigins) == 0 {
+			return fmt.Errorf("cors policy %q has no allowed origins", policy.Name)
+		}
+		for _, origin := range policy.AllowOrigins {
+			if origin == "*" && policy.AllowCredentials {
+				return fmt.Errorf("cors policy %q can't allow credentials for any origin", policy.Name)
+			}
+			if err := validateCORSOrigin(origin); err != nil {
+				return fmt.Errorf("cors policy %q: %w", policy.Name, err)
+			}
+		}
+
+		for _, method := range policy.AllowMethods {
+			if !corsMethods[method] {
+				return fmt.Errorf("cors policy %q: invalid method %q", policy.Name, method)
+			}
+		}
+		for _, header := range append(append([]string{}, policy.AllowHeaders...), policy.ExposeHeaders...) {
+			if !httpguts.ValidHeaderFieldName(header) {
+				return fmt.Errorf("cors policy %q: invalid header %q", policy.Name, header)
+			}
+		}
+		if policy.MaxAgeSeconds < 0 {
+			return fmt.Errorf("cors policy %q: max age can't be negative", policy.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateCORSHost accepts the domains envoy matches virtual hosts on, except
+// * which is the default virtual host of the gateway
+func validateCORSHost(host string) error {
+	if host == "*" {
+		return fmt.Errorf("host * conflicts with the default gateway host")
+	}
+	return validateGatewayHost(host)
+}
+
+// validateGatewayHost accepts a host with an optional leading or trailing *
+func validateGatewayHost(host string) error {
+	if strings.Count(host, "*") > 1 || (strings.Contains(host, "*") && !strings.HasPrefix(host, "*") && !strings.HasSuffix(host, "*")) {
+		return fmt.Errorf("invalid host %q, only a leading or trailing * is allowed", host)
+	}
+	if strings.ContainsAny(host, "/ ") {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	return nil
+}
+
+// validateCORSOrigin accepts *, or a scheme and host with an optional port
+// where the first label of the host can be * to match any subdomain
+func validateCORSOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	parsed, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+		return fmt.Errorf("invalid origin %q, expected <scheme>://<host>[:<port>]", origin)
+	}
+	if strings.Contains(parsed.Host, "*") {
+		return fmt.Errorf("invalid origin %q, only the first label of the host can be *", origin)
+	}
+	return nil
+}
+
+// getCORSHTTPFilter returns the filter answering preflight requests and adding
+// the CORS headers of the virtual host policies. It has to run before the rate
+// limit filter so preflight requests don't count against the limits
+func getCORSHTTPFilter() (*hcm.HttpFilter, error) {
+	corsSerial, err := anypb.New(&cors.Cors{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert cors filter for Apicast envoy configuration: %v", err)
+	}
+
+	return &hcm.HttpFilter{
+		Name:       corsFilterName,
+		ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: corsSerial},
+	}, nil
+}
+
+// getCORSVirtualHosts returns a virtual host for each CORS policy, routing its
+// hosts to apicast like the default virtual host
+func getCORSVirtualHosts(installation *integreatlyv1alpha1.RHMI, clusterName string) ([]*envoyroutev3.VirtualHost, error) {
+	virtualHosts := make([]*envoyroutev3.VirtualHost, 0, len(installation.Spec.GatewayCORSPolicies))
+
+	for _, policy := range installation.Spec.GatewayCORSPolicies {
+		corsSerial, err := anypb.New(getCORSPolicy(policy))
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert cors policy %s: %v", policy.Name, err)
+		}
+
+		domains := make([]string, 0, len(policy.Hosts))
+		for _, host := range policy.Hosts {
+			domains = append(domains, strings.ToLower(host))
+		}
+
+		virtualHosts = append(virtualHosts, &envoyroutev3.VirtualHost{
+			Name:                 fmt.Sprintf("%s-cors-%s", clusterName, policy.Name),
+			Domains:              domains,
+			Routes:               getAPICastRoutes(installation, clusterName),
+			TypedPerFilterConfig: map[string]*anypb.Any{corsFilterName: corsSerial},
+		})
+	}
+
+	return virtualHosts, nil
+}
+
+func getCORSPolicy(policy integreatlyv1alpha1.CORSPolicySpec) *cors.CorsPolicy {
+	corsPolicy := &cors.CorsPolicy{
+		AllowMethods:     strings.Join(policy.AllowMethods, ","),
+		AllowHeaders:     strings.Join(policy.AllowHeaders, ","),
+		ExposeHeaders:    strings.Join(policy.ExposeHeaders, ","),
+		AllowCredentials: &wrappers.BoolValue{Value: policy.AllowCredentials},
+	}
+	if policy.MaxAgeSeconds > 0 {
+		corsPolicy.MaxAge = strconv.Itoa(int(policy.MaxAgeSeconds))
+	}
+
+	for _, origin := range policy.AllowOrigins {
+		switch {
+		case origin == "*":
+			corsPolicy.AllowOriginStringMatch = append(corsPolicy.AllowOriginStringMatch, &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_SafeRegex{
+					SafeRegex: &matcher.RegexMatcher{
+						EngineType: &matcher.RegexMatcher_GoogleRe2{},
+						Regex:      ".*",
+					},
+				},
+			})
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*.")
+			corsPolicy.AllowOriginStringMatch = append(corsPolicy.AllowOriginStringMatch, &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_SafeRegex{
+					SafeRegex: &matcher.RegexMatcher{
+						EngineType: &matcher.RegexMatcher_GoogleRe2{},
+						Regex:      fmt.Sprintf("^%s://[a-zA-Z0-9.-]+\\.%s$", regexp.QuoteMeta(scheme), regexp.QuoteMeta(host)),
+					},
+				},
+			})
+		default:
+			corsPolicy.AllowOriginStringMatch = append(corsPolicy.AllowOriginStringMatch, &matcher.StringMatcher{
+				MatchPattern: &matcher.StringMatcher_Exact{Exact: origin},
+				IgnoreCase:   true,
+			})
+		}
+	}
+
+	return corsPolicy
+}
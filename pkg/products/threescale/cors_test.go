@@ -0,0 +1,165 @@
+package threescale
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cors "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCORSPolicies(t *testing.T) {
+	policy := func(name string, hosts ...string) integreatlyv1alpha1.CORSPolicySpec {
+		return integreatlyv1alpha1.CORSPolicySpec{
+			Name:         name,
+			Hosts:        hosts,
+			AllowOrigins: []string{"https://app.example.com", "https://*.example.com:8443"},
+			AllowMethods: []string{"GET", "POST"},
+			AllowHeaders: []string{"Authorization", "Content-Type"},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		policies []integreatlyv1alpha1.CORSPolicySpec
+		update   func(policies []integreatlyv1alpha1.CORSPolicySpec)
+		wantErr  string
+	}{
+		{
+			name:     "test valid policies",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders-apicast.apps.example.com"), policy("shop", "*.shop.example.com")},
+		},
+		{
+			name:     "test hosts claimed by two policies conflict",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "api.example.com"), policy("shop", "API.example.com")},
+			wantErr:  `cors policies "orders" and "shop" conflict on host "api.example.com"`,
+		},
+		{
+			name:     "test duplicated names",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders.example.com"), policy("orders", "shop.example.com")},
+			wantErr:  `duplicated cors policy "orders"`,
+		},
+		{
+			name:     "test default host can't be claimed",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "*")},
+			wantErr:  "conflicts with the default gateway host",
+		},
+		{
+			name:     "test wildcard in the middle of a host",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "api.*.example.com")},
+			wantErr:  "only a leading or trailing * is allowed",
+		},
+		{
+			name:     "test origin with a path",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders.example.com")},
+			update: func(policies []integreatlyv1alpha1.CORSPolicySpec) {
+				policies[0].AllowOrigins = []string{"https://app.example.com/index.html"}
+			},
+			wantErr: "expected <scheme>://<host>[:<port>]",
+		},
+		{
+			name:     "test credentials with any origin",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders.example.com")},
+			update: func(policies []integreatlyv1alpha1.CORSPolicySpec) {
+				policies[0].AllowOrigins = []string{"*"}
+				policies[0].AllowCredentials = true
+			},
+			wantErr: "can't allow credentials for any origin",
+		},
+		{
+			name:     "test unknown method",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders.example.com")},
+			update: func(policies []integreatlyv1alpha1.CORSPolicySpec) {
+				policies[0].AllowMethods = []string{"get"}
+			},
+			wantErr: `invalid method "get"`,
+		},
+		{
+			name:     "test invalid header",
+			policies: []integreatlyv1alpha1.CORSPolicySpec{policy("orders", "orders.example.com")},
+			update: func(policies []integreatlyv1alpha1.CORSPolicySpec) {
+				policies[0].ExposeHeaders = []string{"X Total"}
+			},
+			wantErr: `invalid header "X Total"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.update != nil {
+				tt.update(tt.policies)
+			}
+			err := validateCORSPolicies(tt.policies)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetAPICastVirtualHostsCORS(t *testing.T) {
+	installation := &integreatlyv1alpha1.RHMI{
+		Spec: integreatlyv1alpha1.RHMISpec{
+			Type: string(integreatlyv1alpha1.InstallationTypeManagedApi),
+			GatewayCORSPolicies: []integreatlyv1alpha1.CORSPolicySpec{{
+				Name:             "orders",
+				Hosts:            []string{"Orders-apicast.apps.example.com"},
+				AllowOrigins:     []string{"https://app.example.com", "https://*.example.com"},
+				AllowMethods:     []string{"GET", "POST"},
+				AllowHeaders:     []string{"Authorization"},
+				MaxAgeSeconds:    600,
+				AllowCredentials: true,
+			}},
+		},
+	}
+
+	virtualHosts, err := getAPICastVirtualHosts(installation, ApicastClusterName, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(virtualHosts) != 2 {
+		t.Fatalf("expected a cors and a default virtual host, got %d", len(virtualHosts))
+	}
+
+	corsHost, defaultHost := virtualHosts[0], virtualHosts[1]
+	if corsHost.Name != ApicastClusterName+"-cors-orders" || !reflect.DeepEqual(corsHost.Domains, []string{"orders-apicast.apps.example.com"}) {
+		t.Fatalf("unexpected cors virtual host %s %v", corsHost.Name, corsHost.Domains)
+	}
+	if !reflect.DeepEqual(defaultHost.Domains, []string{"*"}) || defaultHost.TypedPerFilterConfig != nil {
+		t.Fatalf("expected the default virtual host without cors policy, got %v", defaultHost)
+	}
+	if corsHost.Routes[0].GetRoute().GetCluster() != ApicastClusterName {
+		t.Fatalf("expected cors virtual host to route to apicast, got %v", corsHost.Routes[0])
+	}
+
+	policy := &cors.CorsPolicy{}
+	if err := corsHost.TypedPerFilterConfig[corsFilterName].UnmarshalTo(policy); err != nil {
+		t.Fatal(err)
+	}
+	if policy.AllowMethods != "GET,POST" || policy.AllowHeaders != "Authorization" || policy.MaxAge != "600" || !policy.AllowCredentials.GetValue() {
+		t.Fatalf("unexpected cors policy %v", policy)
+	}
+	if len(policy.AllowOriginStringMatch) != 2 ||
+		policy.AllowOriginStringMatch[0].GetExact() != "https://app.example.com" ||
+		policy.AllowOriginStringMatch[1].GetSafeRegex().GetRegex() != `^https://[a-zA-Z0-9.-]+\.example\.com$` {
+		t.Fatalf("unexpected allowed origins %v", policy.AllowOriginStringMatch)
+	}
+
+	installation.Spec.MaintenanceMode = &integreatlyv1alpha1.MaintenanceModeSpec{Enabled: true, Until: metav1.NewTime(time.Now().Add(time.Hour))}
+	virtualHosts, err = getAPICastVirtualHosts(installation, ApicastClusterName, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(virtualHosts) != 1 {
+		t.Fatalf("expected only the maintenance mode virtual host, got %d", len(virtualHosts))
+	}
+}
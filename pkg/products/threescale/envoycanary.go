@@ -0,0 +1,376 @@
+package threescale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoylistenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_runtime "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/addon"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	appsv1 "github.com/openshift/api/apps/v1"
+	k8sappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// EnvoyCanaryRolloutParam is the addon parameter that enables validating
+	// apicast envoy configuration changes on a canary gateway before they're
+	// rolled out to every apicast production replica
+	EnvoyCanaryRolloutParam = "envoy-canary-rollout"
+
+	apicastCanaryName   = "apicast-canary"
+	apicastCanaryNodeID = "apicast-ratelimit-canary"
+	apicastCanaryLabel  = "integreatly.org/envoy-canary"
+	// envoyCanaryRejectedAnnotation holds the hash of the envoy resources
+	// rejected by the canary, so the same configuration isn't retried
+	envoyCanaryRejectedAnnotation = "integreatly.org/envoy-canary-rejected"
+
+	// envoyCanaryProbeSecretName is the secret in the 3scale namespace with
+	// the synthetic request sent to the canary gateway
+	envoyCanaryProbeSecretName = "apicast-canary-probe"
+	// envoyCanaryProbeHostKey is the host of a product mapped in APIcast
+	envoyCanaryProbeHostKey = "host"
+	// envoyCanaryProbePathKey is the path requested, / by default
+	envoyCanaryProbePathKey = "path"
+	// envoyCanaryProbeUserKeyKey is the user key of a test application of
+	// the product, sent in the user_key query parameter
+	envoyCanaryProbeUserKeyKey = "user_key"
+
+	envoyCanaryTimeout       = 10 * time.Minute
+	envoyCanaryProbeRequests = 10
+	envoyCanaryProbeTimeout  = 5 * time.Second
+)
+
+// isEnvoyCanaryRolloutEnabled returns whether apicast envoy configuration
+// changes are validated on a canary gateway first. It's disabled when the
+// addon parameters secret doesn't exist
+func (r *Reconciler) isEnvoyCanaryRolloutEnabled(ctx context.Context, serverClient k8sclient.Client) (bool, error) {
+	enabled, ok, err := addon.GetBoolParameter(ctx, serverClient, r.installation.Namespace, EnvoyCanaryRolloutParam)
+	if k8serr.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve %s addon parameter: %w", EnvoyCanaryRolloutParam, err)
+	}
+
+	return ok && enabled, nil
+}
+
+// rolloutAPICastEnvoyConfig applies a change to the apicast envoy
+// configuration to a single canary apicast replica, with its own envoy node
+// ID, and only updates the configuration of the production replicas once
+// marin3r has published it to the canary and the canary answers the synthetic
+// requests of the envoyCanaryProbeSecretName secret with a 2xx or a 429. The
+// synthetic requests are skipped when the secret doesn't exist, as APIcast
+// only serves the hosts of its products to clients with valid credentials.
+// When the canary fails the production
+// replicas keep the last configuration that passed, and the rejected
+// configuration isn't retried until it changes
+func (r *Reconciler) rolloutAPICastEnvoyConfig(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, clusters []*envoyclusterv3.Cluster, listeners []*envoylistenerv3.Listener, runtimes *envoy_runtime.Runtime) (integreatlyv1alpha1.StatusPhase, error) {
+	envoyResources, err := ratelimit.NewEnvoyResources(clusters, listeners, runtimes)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	desiredHash, err := ratelimit.EnvoyResourcesHash(envoyResources)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	productionProxyConfig := ratelimit.NewEnvoyConfig(ApicastClusterName, r.Config.GetNamespace(), ApicastNodeID)
+	production := &marin3rv1alpha1.EnvoyConfig{}
+	err = serverClient.Get(ctx, k8sclient.ObjectKey{Name: ApicastClusterName, Namespace: r.Config.GetNamespace()}, production)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get apicast envoyconfig: %w", err)
+	}
+	// there's no traffic to protect before the first configuration is applied
+	if k8serr.IsNotFound(err) {
+		if err := productionProxyConfig.CreateEnvoyConfig(ctx, serverClient, clusters, listeners, runtimes, installation); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	if production.Annotations[ratelimit.EnvoyResourcesHashAnnotation] == desiredHash {
+		return r.deleteAPICastCanary(ctx, serverClient, false)
+	}
+
+	canary := &marin3rv1alpha1.EnvoyConfig{}
+	err = serverClient.Get(ctx, k8sclient.ObjectKey{Name: apicastCanaryName, Namespace: r.Config.GetNamespace()}, canary)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get canary envoyconfig: %w", err)
+	}
+	if err == nil && canary.Annotations[envoyCanaryRejectedAnnotation] == desiredHash {
+		r.log.Infof("Envoy configuration was rejected by the canary, keeping the current configuration", l.Fields{"hash": desiredHash})
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+	// the status of a canary holding another configuration is stale, start
+	// the validation with a new canary
+	if err == nil && canary.Annotations[ratelimit.EnvoyResourcesHashAnnotation] != desiredHash {
+		if _, err := r.deleteAPICastCanary(ctx, serverClient, false); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		return integreatlyv1alpha1.PhaseInProgress, nil
+	}
+
+	r.log.Infof("Validating envoy configuration on the canary gateway", l.Fields{"hash": desiredHash})
+	canaryProxyConfig := ratelimit.NewEnvoyConfig(apicastCanaryName, r.Config.GetNamespace(), apicastCanaryNodeID)
+	if err := canaryProxyConfig.CreateEnvoyConfig(ctx, serverClient, clusters, listeners, runtimes, installation); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	deployment, err := r.reconcileAPICastCanary(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: apicastCanaryName, Namespace: r.Config.GetNamespace()}, canary); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get canary envoyconfig: %w", err)
+	}
+	if canary.Status.CacheState != nil && *canary.Status.CacheState == marin3rv1alpha1.RollbackState ||
+		meta.IsStatusConditionTrue(canary.Status.Conditions, marin3rv1alpha1.RollbackFailedCondition) {
+		return r.rejectAPICastEnvoyConfig(ctx, serverClient, installation, canary, desiredHash, "envoy rejected the configuration")
+	}
+
+	published := canary.Status.CacheState != nil && *canary.Status.CacheState == marin3rv1alpha1.InSyncState &&
+		canary.Status.PublishedVersion != nil && canary.Status.DesiredVersion != nil &&
+		*canary.Status.PublishedVersion == *canary.Status.DesiredVersion
+	if !published || deployment.Status.AvailableReplicas < 1 {
+		if !deployment.CreationTimestamp.IsZero() && time.Since(deployment.CreationTimestamp.Time) > envoyCanaryTimeout {
+			return r.rejectAPICastEnvoyConfig(ctx, serverClient, installation, canary, desiredHash, "canary gateway didn't become ready")
+		}
+		r.log.Info("Waiting for the canary gateway to be ready")
+		return integreatlyv1alpha1.PhaseInProgress, nil
+	}
+
+	probe, err := r.getEnvoyCanaryProbe(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	if probe == nil {
+		r.log.Infof("No synthetic request configured for the canary gateway, skipping it", l.Fields{"secret": envoyCanaryProbeSecretName})
+	} else if r.probeEnvoyCanary != nil {
+		for i := 0; i < envoyCanaryProbeRequests; i++ {
+			status, err := r.probeEnvoyCanary(ctx, *probe)
+			if err != nil {
+				return r.rejectAPICastEnvoyConfig(ctx, serverClient, installation, canary, desiredHash, fmt.Sprintf("canary request failed: %v", err))
+			}
+			if !isEnvoyCanaryStatusHealthy(status) {
+				return r.rejectAPICastEnvoyConfig(ctx, serverClient, installation, canary, desiredHash, fmt.Sprintf("canary responded with status %d", status))
+			}
+		}
+	}
+
+	r.log.Infof("Envoy configuration validated on the canary gateway, rolling out", l.Fields{"hash": desiredHash})
+	if err := productionProxyConfig.CreateEnvoyConfig(ctx, serverClient, clusters, listeners, runtimes, installation); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	meta.SetStatusCondition(&installation.Status.Conditions, installation.EnvoyConfigRolledOutCondition())
+	r.recorder.Event(installation, "Normal", integreatlyv1alpha1.EventEnvoyConfigRolledOut, fmt.Sprintf("Envoy configuration %s validated on the canary gateway and rolled out", desiredHash))
+
+	return r.deleteAPICastCanary(ctx, serverClient, false)
+}
+
+// rejectAPICastEnvoyConfig records that the canary rejected the envoy
+// configuration and removes the canary gateway. The canary envoyconfig is
+// kept to remember the rejected configuration
+func (r *Reconciler) rejectAPICastEnvoyConfig(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, canary *marin3rv1alpha1.EnvoyConfig, hash, reason string) (integreatlyv1alpha1.StatusPhase, error) {
+	r.log.Warningf("Envoy configuration rejected by the canary gateway", l.Fields{"hash": hash, "reason": reason})
+
+	if canary.Annotations == nil {
+		canary.Annotations = map[string]string{}
+	}
+	canary.Annotations[envoyCanaryRejectedAnnotation] = hash
+	if err := serverClient.Update(ctx, canary); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to mark canary envoyconfig as rejected: %w", err)
+	}
+
+	msg := fmt.Sprintf("Envoy configuration %s not rolled out: %s", hash, reason)
+	meta.SetStatusCondition(&installation.Status.Conditions, installation.EnvoyConfigRejectedCondition(msg))
+	r.recorder.Event(installation, "Warning", integreatlyv1alpha1.EventEnvoyConfigRejected, msg)
+
+	return r.deleteAPICastCanary(ctx, serverClient, true)
+}
+
+// reconcileAPICastCanary creates a single replica of the apicast production
+// pods, with the canary envoy node ID, and a service to reach it. The labels
+// selecting the production pods are removed so the canary doesn't receive
+// production traffic
+func (r *Reconciler) reconcileAPICastCanary(ctx context.Context, serverClient k8sclient.Client) (*k8sappsv1.Deployment, error) {
+	production := &appsv1.DeploymentConfig{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: apicastProductionDCName, Namespace: r.Config.GetNamespace()}, production); err != nil {
+		return nil, fmt.Errorf("failed to get %s deploymentconfig: %w", apicastProductionDCName, err)
+	}
+	productionService := &corev1.Service{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: apicastProductionDCName, Namespace: r.Config.GetNamespace()}, productionService); err != nil {
+		return nil, fmt.Errorf("failed to get %s service: %w", apicastProductionDCName, err)
+	}
+	if production.Spec.Template == nil {
+		return nil, fmt.Errorf("%s deploymentconfig has no pod template", apicastProductionDCName)
+	}
+
+	labels := map[string]string{}
+	for key, value := range production.Spec.Template.Labels {
+		labels[key] = value
+	}
+	for key := range production.Spec.Selector {
+		delete(labels, key)
+	}
+	for key := range productionService.Spec.Selector {
+		delete(labels, key)
+	}
+	labels[apicastCanaryLabel] = "true"
+
+	annotations := map[string]string{}
+	for key, value := range production.Spec.Template.Annotations {
+		annotations[key] = value
+	}
+	annotations["marin3r.3scale.net/node-id"] = apicastCanaryNodeID
+
+	deployment := &k8sappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      apicastCanaryName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, deployment, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(deployment, r.installation)
+		deployment.Spec.Replicas = pointer.Int32(1)
+		deployment.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{apicastCanaryLabel: "true"},
+		}
+		deployment.Spec.Template.Labels = labels
+		deployment.Spec.Template.Annotations = annotations
+		deployment.Spec.Template.Spec = *production.Spec.Template.Spec.DeepCopy()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile canary deployment: %w", err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      apicastCanaryName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, r.installation)
+		service.Spec.Selector = map[string]string{apicastCanaryLabel: "true"}
+		service.Spec.Ports = []corev1.ServicePort{
+			{
+				Name:       "gateway",
+				Port:       ApicastEnvoyProxyPort,
+				TargetPort: intstr.FromInt(ApicastEnvoyProxyPort),
+				Protocol:   corev1.ProtocolTCP,
+			},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile canary service: %w", err)
+	}
+
+	return deployment, nil
+}
+
+// deleteAPICastCanary removes the canary gateway and, unless keepEnvoyConfig
+// is set, the canary envoyconfig
+func (r *Reconciler) deleteAPICastCanary(ctx context.Context, serverClient k8sclient.Client, keepEnvoyConfig bool) (integreatlyv1alpha1.StatusPhase, error) {
+	objects := []k8sclient.Object{
+		&k8sappsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: apicastCanaryName, Namespace: r.Config.GetNamespace()}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: apicastCanaryName, Namespace: r.Config.GetNamespace()}},
+	}
+	if !keepEnvoyConfig {
+		objects = append(objects, &marin3rv1alpha1.EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Name: apicastCanaryName, Namespace: r.Config.GetNamespace()}})
+	}
+
+	for _, object := range objects {
+		if err := k8sclient.IgnoreNotFound(serverClient.Delete(ctx, object)); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete canary %T: %w", object, err)
+		}
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// envoyCanaryProbe is a synthetic request to the canary gateway
+type envoyCanaryProbe struct {
+	// URL of the canary service, with the path and credentials
+	URL string
+	// Host is the product host the request is sent for
+	Host string
+}
+
+// getEnvoyCanaryProbe returns the synthetic request of the
+// envoyCanaryProbeSecretName secret, or nil if the secret doesn't exist
+func (r *Reconciler) getEnvoyCanaryProbe(ctx context.Context, serverClient k8sclient.Client) (*envoyCanaryProbe, error) {
+	secret := &corev1.Secret{}
+	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: envoyCanaryProbeSecretName, Namespace: r.Config.GetNamespace()}, secret)
+	if k8serr.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s secret: %w", envoyCanaryProbeSecretName, err)
+	}
+
+	host := string(secret.Data[envoyCanaryProbeHostKey])
+	if host == "" {
+		return nil, fmt.Errorf("%s secret is missing the %s key", envoyCanaryProbeSecretName, envoyCanaryProbeHostKey)
+	}
+	path := string(secret.Data[envoyCanaryProbePathKey])
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%s secret has an invalid %s %q, it must start with /", envoyCanaryProbeSecretName, envoyCanaryProbePathKey, path)
+	}
+
+	probeURL := fmt.Sprintf("http://%s.%s.svc:%d%s", apicastCanaryName, r.Config.GetNamespace(), ApicastEnvoyProxyPort, path)
+	if userKey := string(secret.Data[envoyCanaryProbeUserKeyKey]); userKey != "" {
+		probeURL += "?" + url.Values{"user_key": {userKey}}.Encode()
+	}
+
+	return &envoyCanaryProbe{URL: probeURL, Host: host}, nil
+}
+
+// isEnvoyCanaryStatusHealthy returns whether a canary response status shows
+// a working filter chain: the request was either served or rate limited. Any
+// other status, including a 401, 403 or 404 from a broken filter chain, is an
+// anomaly
+func isEnvoyCanaryStatusHealthy(status int) bool {
+	return (status >= http.StatusOK && status < http.StatusMultipleChoices) || status == http.StatusTooManyRequests
+}
+
+// probeEnvoyCanary sends a synthetic request to the canary gateway and
+// returns the response status
+func probeEnvoyCanary(ctx context.Context, probe envoyCanaryProbe) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, envoyCanaryProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Host = probe.Host
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,427 @@
+package threescale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoylistenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	"github.com/integr8ly/integreatly-operator/utils"
+	appsv1 "github.com/openshift/api/apps/v1"
+	k8sappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_rolloutAPICastEnvoyConfig(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters := []*envoyclusterv3.Cluster{ratelimit.CreateClusterResource(ApicastContainerAddress, ApicastClusterName, ApicastContainerPort)}
+	listeners := []*envoylistenerv3.Listener{ratelimit.CreateListenerResource(ApicastListenerName, ApicastEnvoyProxyAddress, ApicastEnvoyProxyPort, nil)}
+	runtimes := ratelimit.CreateRuntimesResource()
+	envoyResources, err := ratelimit.NewEnvoyResources(clusters, listeners, runtimes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desiredHash, err := ratelimit.EnvoyResourcesHash(envoyResources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envoyConfig := func(name, hash string, annotations map[string]string, cacheState string) *marin3rv1alpha1.EnvoyConfig {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ratelimit.EnvoyResourcesHashAnnotation] = hash
+		version := "v1"
+		return &marin3rv1alpha1.EnvoyConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultInstallationNamespace, Annotations: annotations},
+			Status: marin3rv1alpha1.EnvoyConfigStatus{
+				CacheState:       &cacheState,
+				PublishedVersion: &version,
+				DesiredVersion:   &version,
+			},
+		}
+	}
+	productionDC := &appsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: apicastProductionDCName, Namespace: defaultInstallationNamespace},
+		Spec: appsv1.DeploymentConfigSpec{
+			Selector: map[string]string{"deploymentconfig": apicastProductionDCName},
+			Template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"deploymentconfig":             apicastProductionDCName,
+						"threescale_component_element": "production",
+						"marin3r.3scale.net/status":    "enabled",
+					},
+					Annotations: map[string]string{"marin3r.3scale.net/node-id": ApicastNodeID},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "apicast"}}},
+			},
+		},
+	}
+	productionService := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: apicastProductionDCName, Namespace: defaultInstallationNamespace},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"threescale_component_element": "production"},
+		},
+	}
+	readyCanary := &k8sappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: apicastCanaryName, Namespace: defaultInstallationNamespace},
+		Status:     k8sappsv1.DeploymentStatus{AvailableReplicas: 1},
+	}
+	probeSecret := func(host, userKey string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: envoyCanaryProbeSecretName, Namespace: defaultInstallationNamespace},
+			Data: map[string][]byte{
+				envoyCanaryProbeHostKey:    []byte(host),
+				envoyCanaryProbePathKey:    []byte("/echo"),
+				envoyCanaryProbeUserKeyKey: []byte(userKey),
+			},
+		}
+	}
+	// apicast answers like APIcast: not found for hosts that aren't mapped
+	// to a product, forbidden without a valid user key
+	apicast := func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Host != "api.example.com":
+			w.WriteHeader(http.StatusNotFound)
+		case req.URL.Query().Get("user_key") == "":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Authentication parameters missing")
+		case req.URL.Query().Get("user_key") != "test-key" || req.URL.Path != "/echo":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Authentication failed")
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	status := func(status int) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(status)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		initObjs         []runtime.Object
+		apicast          http.HandlerFunc
+		wantProbed       bool
+		wantPhase        integreatlyv1alpha1.StatusPhase
+		wantProduction   string
+		wantCanary       bool
+		wantRejected     bool
+		wantCondition    metav1.ConditionStatus
+		wantCanaryLabels map[string]string
+	}{
+		{
+			name:           "test first configuration is applied without a canary",
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: desiredHash,
+		},
+		{
+			name: "test canary is removed when production is up to date",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				readyCanary,
+			},
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: desiredHash,
+		},
+		{
+			name: "test canary without production selector labels is created for a changed configuration",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+			},
+			wantPhase:      integreatlyv1alpha1.PhaseInProgress,
+			wantProduction: "previous",
+			wantCanary:     true,
+			wantCanaryLabels: map[string]string{
+				"marin3r.3scale.net/status": "enabled",
+				apicastCanaryLabel:          "true",
+			},
+		},
+		{
+			name: "test configuration is rolled out once validated on the canary",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("api.example.com", "test-key"),
+			},
+			apicast:        status(http.StatusTooManyRequests),
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: desiredHash,
+			wantCondition:  metav1.ConditionTrue,
+		},
+		{
+			name: "test configuration is rolled out when the canary serves requests",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("api.example.com", "test-key"),
+			},
+			apicast:        apicast,
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: desiredHash,
+			wantCondition:  metav1.ConditionTrue,
+		},
+		{
+			name: "test configuration is rejected when the canary returns server errors",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("api.example.com", "test-key"),
+			},
+			apicast:        status(http.StatusServiceUnavailable),
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+			wantCondition:  metav1.ConditionFalse,
+		},
+		{
+			name: "test configuration is rejected when the canary rejects the test key",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("api.example.com", "revoked-key"),
+			},
+			apicast:        apicast,
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+			wantCondition:  metav1.ConditionFalse,
+		},
+		{
+			name: "test configuration is rejected when the canary doesn't route the mapped host",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("unmapped.example.com", "test-key"),
+			},
+			apicast:        apicast,
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+			wantCondition:  metav1.ConditionFalse,
+		},
+		{
+			name: "test configuration is rejected when the canary can't be reached",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+				probeSecret("api.example.com", "test-key"),
+			},
+			wantProbed:     true,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+			wantCondition:  metav1.ConditionFalse,
+		},
+		{
+			name: "test configuration is rolled out without synthetic requests when none is configured",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.InSyncState),
+				productionDC,
+				productionService,
+				readyCanary,
+			},
+			apicast:        apicast,
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: desiredHash,
+			wantCondition:  metav1.ConditionTrue,
+		},
+		{
+			name: "test configuration is rejected when envoy rolled back the canary",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, nil, marin3rv1alpha1.RollbackState),
+				productionDC,
+				productionService,
+				readyCanary,
+			},
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+			wantCondition:  metav1.ConditionFalse,
+		},
+		{
+			name: "test canary holding another configuration is replaced",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, "rejected", map[string]string{envoyCanaryRejectedAnnotation: "rejected"}, marin3rv1alpha1.InSyncState),
+				readyCanary,
+			},
+			wantPhase:      integreatlyv1alpha1.PhaseInProgress,
+			wantProduction: "previous",
+		},
+		{
+			name: "test rejected configuration is not retried",
+			initObjs: []runtime.Object{
+				envoyConfig(ApicastClusterName, "previous", nil, marin3rv1alpha1.InSyncState),
+				envoyConfig(apicastCanaryName, desiredHash, map[string]string{envoyCanaryRejectedAnnotation: desiredHash}, marin3rv1alpha1.InSyncState),
+			},
+			wantPhase:      integreatlyv1alpha1.PhaseCompleted,
+			wantProduction: "previous",
+			wantRejected:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+				Spec:       integreatlyv1alpha1.RHMISpec{Type: string(integreatlyv1alpha1.InstallationTypeManagedApi)},
+			}
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			// the canary is unreachable without a handler
+			server := httptest.NewServer(tt.apicast)
+			if tt.apicast == nil {
+				server.Close()
+			}
+			defer server.Close()
+			probed := false
+			r := &Reconciler{
+				Config:       config.NewThreeScale(config.ProductConfig{"NAMESPACE": defaultInstallationNamespace}),
+				installation: installation,
+				log:          getLogger(),
+				recorder:     record.NewFakeRecorder(10),
+				probeEnvoyCanary: func(ctx context.Context, probe envoyCanaryProbe) (int, error) {
+					probed = true
+					canaryURL := fmt.Sprintf("http://%s.%s.svc:%d", apicastCanaryName, defaultInstallationNamespace, ApicastEnvoyProxyPort)
+					if !strings.HasPrefix(probe.URL, canaryURL) {
+						return 0, fmt.Errorf("expected the canary service to be probed, got %s", probe.URL)
+					}
+					probe.URL = server.URL + strings.TrimPrefix(probe.URL, canaryURL)
+					return probeEnvoyCanary(ctx, probe)
+				},
+			}
+
+			phase, err := r.rolloutAPICastEnvoyConfig(context.TODO(), client, installation, clusters, listeners, runtimes)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if phase != tt.wantPhase {
+				t.Fatalf("expected phase %s, got %s", tt.wantPhase, phase)
+			}
+			if probed != tt.wantProbed {
+				t.Fatalf("expected canary probed to be %t, got %t", tt.wantProbed, probed)
+			}
+
+			if err := assertEnvoyCanaryRollout(client, tt.wantProduction, tt.wantCanary, tt.wantRejected, tt.wantCanaryLabels); err != nil {
+				t.Fatal(err)
+			}
+
+			condition := meta.FindStatusCondition(installation.Status.Conditions, integreatlyv1alpha1.EnvoyConfigRolloutConditionType.String())
+			if tt.wantCondition == "" {
+				if condition != nil {
+					t.Fatalf("expected no rollout condition, got %v", condition)
+				}
+			} else if condition == nil || condition.Status != tt.wantCondition {
+				t.Fatalf("expected rollout condition %s, got %v", tt.wantCondition, condition)
+			}
+		})
+	}
+}
+
+func assertEnvoyCanaryRollout(client k8sclient.Client, wantProduction string, wantCanary, wantRejected bool, wantCanaryLabels map[string]string) error {
+	production := &marin3rv1alpha1.EnvoyConfig{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: ApicastClusterName, Namespace: defaultInstallationNamespace}, production); err != nil {
+		return err
+	}
+	if got := production.Annotations[ratelimit.EnvoyResourcesHashAnnotation]; got != wantProduction {
+		return fmt.Errorf("expected production envoy resources %s, got %s", wantProduction, got)
+	}
+
+	deployment := &k8sappsv1.Deployment{}
+	err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: apicastCanaryName, Namespace: defaultInstallationNamespace}, deployment)
+	if wantCanary != (err == nil) {
+		return fmt.Errorf("expected canary deployment to exist to be %t, got error %v", wantCanary, err)
+	}
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if wantCanary {
+		if deployment.Spec.Template.Annotations["marin3r.3scale.net/node-id"] != apicastCanaryNodeID {
+			return fmt.Errorf("expected canary envoy node id %s, got %v", apicastCanaryNodeID, deployment.Spec.Template.Annotations)
+		}
+		if fmt.Sprint(deployment.Spec.Template.Labels) != fmt.Sprint(wantCanaryLabels) {
+			return fmt.Errorf("expected canary labels %v, got %v", wantCanaryLabels, deployment.Spec.Template.Labels)
+		}
+	}
+
+	canary := &marin3rv1alpha1.EnvoyConfig{}
+	err = client.Get(context.TODO(), k8sclient.ObjectKey{Name: apicastCanaryName, Namespace: defaultInstallationNamespace}, canary)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if rejected := err == nil && canary.Annotations[envoyCanaryRejectedAnnotation] != ""; rejected != wantRejected {
+		return fmt.Errorf("expected canary envoy config rejected to be %t, got %v", wantRejected, canary.Annotations)
+	}
+	return nil
+}
+
+func TestIsEnvoyCanaryStatusHealthy(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{status: http.StatusOK, want: true},
+		{status: http.StatusNoContent, want: true},
+		{status: http.StatusTooManyRequests, want: true},
+		{status: http.StatusMovedPermanently, want: false},
+		{status: http.StatusUnauthorized, want: false},
+		{status: http.StatusForbidden, want: false},
+		{status: http.StatusNotFound, want: false},
+		{status: http.StatusServiceUnavailable, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if got := isEnvoyCanaryStatusHealthy(tt.status); got != tt.want {
+				t.Fatalf("expected status %d healthy to be %t, got %t", tt.status, tt.want, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,12 @@ package threescale
 
 import (
 	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
 	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoylistenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoyratelimitconfigv3 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
@@ -38,6 +44,8 @@ const (
 	tenantHeaderName         = "tenant"
 	safeRegex                = ".*apicast.*"
 	multitenantDescriptorKey = "per-mt-limit"
+
+	defaultMaintenanceModeMessage = "The service is temporarily unavailable due to scheduled maintenance"
 )
 
 /*
@@ -304,7 +312,82 @@ virtualHosts:
     descriptorValue: slowpath
     stage: 0
 */
-func getAPICastVirtualHosts(installation *integreatlyv1alpha1.RHMI, clusterName string) []*envoyroutev3.VirtualHost {
+func getAPICastVirtualHosts(installation *integreatlyv1alpha1.RHMI, clusterName string, now time.Time) ([]*envoyroutev3.VirtualHost, error) {
+	if installation.IsMaintenanceModeActive(now) {
+		return getMaintenanceModeVirtualHosts(installation, clusterName), nil
+	}
+
+	// the hosts of the CORS policies are matched before the default host
+	virtualHosts, err := getCORSVirtualHosts(installation, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	virtualHost := envoyroutev3.VirtualHost{
+		Name:    clusterName,
+		Domains: []string{"*"},
+		Routes:  getAPICastRoutes(installation, clusterName),
+	}
+	return append(virtualHosts, &virtualHost), nil
+}
+
+func getAPICastRoutes(installation *integreatlyv1alpha1.RHMI, clusterName string) []*envoyroutev3.Route {
+	return append(getTrafficSplitRoutes(installation, clusterName), &envoyroutev3.Route{
+		Match: &envoyroutev3.RouteMatch{
+			PathSpecifier: &envoyroutev3.RouteMatch_Prefix{
+				Prefix: "/",
+			},
+		},
+		Action: &envoyroutev3.Route_Route{
+			Route: getAPICastRouteAction(installation, clusterName),
+		},
+	})
+}
+
+func getAPICastRouteAction(installation *integreatlyv1alpha1.RHMI, clusterName string) *envoyroutev3.RouteAction {
+	return &envoyroutev3.RouteAction{
+		ClusterSpecifier: &envoyroutev3.RouteAction_Cluster{
+			Cluster: clusterName,
+		},
+		Timeout: &duration.Duration{
+			Seconds: 75,
+		},
+		RateLimits: getRateLimitsPerInstallType(installation),
+	}
+}
+
+/*
+*
+virtualHosts:
+  - domains:
+  - '*'
+    name: apicast-ratelimit
+    routes:
+  - match:
+    prefix: /
+    directResponse:
+    status: 503
+    body:
+    inlineString: <message>
+    responseHeadersToAdd:
+  - header:
+    key: Retry-After
+    value: <retry after>
+*/
+func getMaintenanceModeVirtualHosts(installation *integreatlyv1alpha1.RHMI, clusterName string) []*envoyroutev3.VirtualHost {
+	maintenanceMode := installation.Spec.MaintenanceMode
+
+	message := maintenanceMode.Message
+	if message == "" {
+		message = defaultMaintenanceModeMessage
+	}
+	// The Retry-After date is used by default so the envoy config doesn't
+	// change on every reconcile while maintenance mode is active
+	retryAfter := maintenanceMode.Until.UTC().Format(http.TimeFormat)
+	if maintenanceMode.RetryAfterSeconds > 0 {
+		retryAfter = strconv.FormatInt(maintenanceMode.RetryAfterSeconds, 10)
+	}
+
 	virtualHost := envoyroutev3.VirtualHost{
 		Name:    clusterName,
 		Domains: []string{"*"},
@@ -316,15 +399,22 @@ func getAPICastVirtualHosts(installation *integreatlyv1alpha1.RHMI, clusterName
 						Prefix: "/",
 					},
 				},
-				Action: &envoyroutev3.Route_Route{
-					Route: &envoyroutev3.RouteAction{
-						ClusterSpecifier: &envoyroutev3.RouteAction_Cluster{
-							Cluster: clusterName,
+				Action: &envoyroutev3.Route_DirectResponse{
+					DirectResponse: &envoyroutev3.DirectResponseAction{
+						Status: http.StatusServiceUnavailable,
+						Body: &envoycorev3.DataSource{
+							Specifier: &envoycorev3.DataSource_InlineString{
+								InlineString: message,
+							},
 						},
-						Timeout: &duration.Duration{
-							Seconds: 75,
+					},
+				},
+				ResponseHeadersToAdd: []*envoycorev3.HeaderValueOption{
+					{
+						Header: &envoycorev3.HeaderValue{
+							Key:   "Retry-After",
+							Value: retryAfter,
 						},
-						RateLimits: getRateLimitsPerInstallType(installation),
 					},
 				},
 			},
@@ -345,6 +435,108 @@ func getRateLimitsPerInstallType(installation *integreatlyv1alpha1.RHMI) []*envo
 	return routes
 }
 
+// setTenantRateLimits replaces the multitenant descriptor of the routes to
+// apicast with distinct descriptors for the tenants overriding the rate limit
+// per tenant. Their requests are excluded from the multitenant descriptor, so
+// only the limits of the tenant apply to them
+func setTenantRateLimits(virtualHosts []*envoyroutev3.VirtualHost, tenantRateLimits []ratelimit.TenantRateLimit) {
+	if len(tenantRateLimits) == 0 {
+		return
+	}
+
+	tenants := make([]string, 0, len(tenantRateLimits))
+	for _, tenantRateLimit := range tenantRateLimits {
+		tenants = append(tenants, regexp.QuoteMeta(tenantRateLimit.Tenant))
+	}
+	tenantsRegex := fmt.Sprintf("^(%s)$", strings.Join(tenants, "|"))
+	descriptors := []*envoyroutev3.RateLimit{
+		getTenantRatelimitDescriptor(multitenantDescriptorKey, tenantsRegex, true),
+		getTenantRatelimitDescriptor(ratelimit.TenantDescriptorValue, tenantsRegex, false),
+	}
+
+	for _, virtualHost := range virtualHosts {
+		for _, route := range virtualHost.Routes {
+			routeAction, ok := route.Action.(*envoyroutev3.Route_Route)
+			if !ok {
+				continue
+			}
+			var rateLimits []*envoyroutev3.RateLimit
+			for _, rateLimit := range routeAction.Route.RateLimits {
+				// the routes share the descriptor of getRateLimitsPerInstallType
+				if rateLimit == &multiTenantRatelimitDescriptor {
+					rateLimits = append(rateLimits, descriptors...)
+					continue
+				}
+				rateLimits = append(rateLimits, rateLimit)
+			}
+			routeAction.Route.RateLimits = rateLimits
+		}
+	}
+}
+
+/*
+Defines the actions for the requests of the tenants matching, or with
+invertMatch not matching, the tenants regex
+
+  - actions:
+  - header_value_match:
+    descriptor_value: <descriptor value>
+    headers:
+  - name: host
+    safe_regex_match:
+    regex: ".*apicast.*"
+  - name: tenant
+    safe_regex_match:
+    regex: <tenants regex>
+    invert_match: <invert match>
+  - request_headers:
+    header_name: tenant
+    descriptor_key: tenant
+*/
+func getTenantRatelimitDescriptor(descriptorValue, tenantsRegex string, invertMatch bool) *envoyroutev3.RateLimit {
+	return &envoyroutev3.RateLimit{
+		Stage: &wrappers.UInt32Value{Value: 0},
+		Actions: []*envoyroutev3.RateLimit_Action{
+			{
+				ActionSpecifier: &envoyroutev3.RateLimit_Action_HeaderValueMatch_{
+					HeaderValueMatch: &envoyroutev3.RateLimit_Action_HeaderValueMatch{
+						DescriptorValue: descriptorValue,
+						Headers: []*envoyroutev3.HeaderMatcher{
+							{
+								Name: headerName,
+								HeaderMatchSpecifier: &envoyroutev3.HeaderMatcher_SafeRegexMatch{
+									SafeRegexMatch: &matcher.RegexMatcher{
+										EngineType: &matcher.RegexMatcher_GoogleRe2{},
+										Regex:      safeRegex,
+									},
+								},
+							},
+							{
+								Name: tenantHeaderName,
+								HeaderMatchSpecifier: &envoyroutev3.HeaderMatcher_SafeRegexMatch{
+									SafeRegexMatch: &matcher.RegexMatcher{
+										EngineType: &matcher.RegexMatcher_GoogleRe2{},
+										Regex:      tenantsRegex,
+									},
+								},
+								InvertMatch: invertMatch,
+							},
+						},
+					},
+				},
+			},
+			{
+				ActionSpecifier: &envoyroutev3.RateLimit_Action_RequestHeaders_{
+					RequestHeaders: &envoyroutev3.RateLimit_Action_RequestHeaders{
+						HeaderName:    tenantHeaderName,
+						DescriptorKey: tenantHeaderName,
+					},
+				},
+			},
+		},
+	}
+}
+
 /*
 *
 virtual_hosts:
@@ -405,10 +597,15 @@ func getBackendListenerVitualHosts(clusterName string) []*envoyroutev3.VirtualHo
     statPrefix: ingress_http
     httpProtocolOptions:
     enableTrailers: true
+    stripAnyHostPort: true
+    stripTrailingHostDot: true
 
 *
 */
-func getListenerResourceFilters(virtualHosts []*envoyroutev3.VirtualHost, httpFilters []*hcm.HttpFilter) ([]*envoylistenerv3.Filter, error) {
+// getListenerResourceFilters normalises the host of the requests before the
+// filters and the routing, so a port or a trailing dot in the host can't skip
+// the rules matching the :authority, such as the JWT requirements
+func getListenerResourceFilters(virtualHosts []*envoyroutev3.VirtualHost, httpFilters []*hcm.HttpFilter, tracing *hcm.HttpConnectionManager_Tracing) ([]*envoylistenerv3.Filter, error) {
 	manager := &hcm.HttpConnectionManager{
 		CodecType:  hcm.HttpConnectionManager_AUTO,
 		StatPrefix: "ingress_http",
@@ -422,6 +619,11 @@ func getListenerResourceFilters(virtualHosts []*envoyroutev3.VirtualHost, httpFi
 			EnableTrailers: true,
 		},
 		HttpFilters: httpFilters,
+		Tracing:     tracing,
+		StripPortMode: &hcm.HttpConnectionManager_StripAnyHostPort{
+			StripAnyHostPort: true,
+		},
+		StripTrailingHostDot: true,
 	}
 
 	pbst, err := anypb.New(manager)
@@ -0,0 +1,219 @@
+package threescale
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetAPICastVirtualHosts(t *testing.T) {
+	now := time.Now()
+	until := metav1.NewTime(now.Add(time.Hour))
+
+	tests := []struct {
+		name             string
+		maintenanceMode  *integreatlyv1alpha1.MaintenanceModeSpec
+		wantMaintenance  bool
+		wantRetryAfter   string
+		wantResponseBody string
+	}{
+		{
+			name: "test requests are routed to apicast without maintenance mode",
+		},
+		{
+			name:            "test requests are routed to apicast once maintenance mode expired",
+			maintenanceMode: &integreatlyv1alpha1.MaintenanceModeSpec{Enabled: true, Until: metav1.NewTime(now.Add(-time.Minute))},
+		},
+		{
+			name:             "test 503 with retry after date during maintenance mode",
+			maintenanceMode:  &integreatlyv1alpha1.MaintenanceModeSpec{Enabled: true, Until: until},
+			wantMaintenance:  true,
+			wantRetryAfter:   until.UTC().Format(http.TimeFormat),
+			wantResponseBody: defaultMaintenanceModeMessage,
+		},
+		{
+			name:             "test 503 with configured retry after and message during maintenance mode",
+			maintenanceMode:  &integreatlyv1alpha1.MaintenanceModeSpec{Enabled: true, Until: until, RetryAfterSeconds: 120, Message: "Back soon"},
+			wantMaintenance:  true,
+			wantRetryAfter:   "120",
+			wantResponseBody: "Back soon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				Spec: integreatlyv1alpha1.RHMISpec{
+					Type:            string(integreatlyv1alpha1.InstallationTypeManagedApi),
+					MaintenanceMode: tt.maintenanceMode,
+				},
+			}
+
+			virtualHosts, err := getAPICastVirtualHosts(installation, ApicastClusterName, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			route := virtualHosts[0].Routes[0]
+			directResponse, isDirectResponse := route.Action.(*envoyroutev3.Route_DirectResponse)
+			if isDirectResponse != tt.wantMaintenance {
+				t.Fatalf("expected direct response to be %t, got route action %T", tt.wantMaintenance, route.Action)
+			}
+			if !tt.wantMaintenance {
+				return
+			}
+
+			if directResponse.DirectResponse.Status != http.StatusServiceUnavailable {
+				t.Fatalf("expected status 503, got %d", directResponse.DirectResponse.Status)
+			}
+			if body := directResponse.DirectResponse.Body.GetInlineString(); body != tt.wantResponseBody {
+				t.Fatalf("expected body %q, got %q", tt.wantResponseBody, body)
+			}
+			if len(route.ResponseHeadersToAdd) != 1 || route.ResponseHeadersToAdd[0].Header.Key != "Retry-After" || route.ResponseHeadersToAdd[0].Header.Value != tt.wantRetryAfter {
+				t.Fatalf("expected Retry-After %s, got %v", tt.wantRetryAfter, route.ResponseHeadersToAdd)
+			}
+		})
+	}
+}
+
+func TestReconciler_recordMaintenanceMode(t *testing.T) {
+	now := time.Now()
+	active := &integreatlyv1alpha1.MaintenanceModeSpec{Enabled: true, Until: metav1.NewTime(now.Add(time.Hour))}
+
+	tests := []struct {
+		name            string
+		maintenanceMode *integreatlyv1alpha1.MaintenanceModeSpec
+		conditions      []metav1.Condition
+		wantCondition   metav1.ConditionStatus
+		wantEvent       string
+	}{
+		{
+			name: "test nothing recorded without maintenance mode",
+		},
+		{
+			name:            "test maintenance mode start is recorded",
+			maintenanceMode: active,
+			wantCondition:   metav1.ConditionTrue,
+			wantEvent:       integreatlyv1alpha1.EventMaintenanceModeOn,
+		},
+		{
+			name:            "test ongoing maintenance mode is recorded once",
+			maintenanceMode: active,
+			conditions:      []metav1.Condition{(&integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{MaintenanceMode: active}}).MaintenanceModeActiveCondition()},
+			wantCondition:   metav1.ConditionTrue,
+		},
+		{
+			name:          "test maintenance mode end is recorded",
+			conditions:    []metav1.Condition{(&integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{MaintenanceMode: active}}).MaintenanceModeActiveCondition()},
+			wantCondition: metav1.ConditionFalse,
+			wantEvent:     integreatlyv1alpha1.EventMaintenanceModeOff,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{
+				log:      getLogger(),
+				recorder: recorder,
+			}
+			installation := &integreatlyv1alpha1.RHMI{
+				Spec:   integreatlyv1alpha1.RHMISpec{MaintenanceMode: tt.maintenanceMode},
+				Status: integreatlyv1alpha1.RHMIStatus{Conditions: tt.conditions},
+			}
+
+			r.recordMaintenanceMode(installation, now)
+
+			condition := meta.FindStatusCondition(installation.Status.Conditions, integreatlyv1alpha1.MaintenanceModeConditionType.String())
+			if tt.wantCondition == "" {
+				if condition != nil {
+					t.Fatalf("expected no maintenance mode condition, got %v", condition)
+				}
+			} else if condition == nil || condition.Status != tt.wantCondition {
+				t.Fatalf("expected maintenance mode condition %s, got %v", tt.wantCondition, condition)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if tt.wantEvent == "" || !strings.Contains(event, tt.wantEvent) {
+					t.Fatalf("unexpected event %s", event)
+				}
+			default:
+				if tt.wantEvent != "" {
+					t.Fatalf("expected %s event", tt.wantEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestSetTenantRateLimits(t *testing.T) {
+	tests := []struct {
+		name             string
+		tenantRateLimits []ratelimit.TenantRateLimit
+		wantDescriptors  []string
+		wantTenantsRegex string
+	}{
+		{
+			name:            "test multitenant descriptor is kept without tenant overrides",
+			wantDescriptors: []string{"", multitenantDescriptorKey},
+		},
+		{
+			name: "test tenants overriding the limit per tenant get a distinct descriptor",
+			tenantRateLimits: []ratelimit.TenantRateLimit{
+				{Tenant: "tenant-a", TenantRateLimitSpec: integreatlyv1alpha1.TenantRateLimitSpec{RequestsPerSecond: 5}},
+				{Tenant: "tenant.b", TenantRateLimitSpec: integreatlyv1alpha1.TenantRateLimitSpec{DailyQuota: 1000}},
+			},
+			wantDescriptors:  []string{"", multitenantDescriptorKey, ratelimit.TenantDescriptorValue},
+			wantTenantsRegex: `^(tenant-a|tenant\.b)$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{
+				Spec: integreatlyv1alpha1.RHMISpec{
+					Type: string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+				},
+			}
+			virtualHosts, err := getAPICastVirtualHosts(installation, ApicastClusterName, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			setTenantRateLimits(virtualHosts, tt.tenantRateLimits)
+
+			rateLimits := virtualHosts[0].Routes[0].GetRoute().RateLimits
+			var descriptors []string
+			for _, rateLimit := range rateLimits {
+				descriptors = append(descriptors, rateLimit.Actions[0].GetHeaderValueMatch().GetDescriptorValue())
+			}
+			if !reflect.DeepEqual(descriptors, tt.wantDescriptors) {
+				t.Fatalf("expected descriptors %v, got %v", tt.wantDescriptors, descriptors)
+			}
+			if tt.wantTenantsRegex == "" {
+				return
+			}
+
+			for _, rateLimit := range rateLimits[1:] {
+				headers := rateLimit.Actions[0].GetHeaderValueMatch().Headers
+				tenantHeader := headers[len(headers)-1]
+				if tenantHeader.Name != tenantHeaderName || tenantHeader.GetSafeRegexMatch().Regex != tt.wantTenantsRegex {
+					t.Fatalf("expected tenant header matching %s, got %v", tt.wantTenantsRegex, tenantHeader)
+				}
+				wantInvertMatch := rateLimit.Actions[0].GetHeaderValueMatch().DescriptorValue == multitenantDescriptorKey
+				if tenantHeader.InvertMatch != wantInvertMatch {
+					t.Fatalf("expected invert match %t for descriptor %s", wantInvertMatch, rateLimit.Actions[0].GetHeaderValueMatch().DescriptorValue)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package threescale
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	threescaleAmp "github.com/3scale/3scale-operator/pkg/3scale/amp/component"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	appsv1 "github.com/openshift/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// keys of the S3 compatible storage credentials secret
+	s3CompatibleAccessKeyID     = "accessKeyID"
+	s3CompatibleSecretAccessKey = "secretAccessKey"
+	s3CompatibleBucketName      = "bucketName"
+	s3CompatibleBucketRegion    = "bucketRegion"
+	s3CompatibleCABundle        = "ca.crt"
+
+	s3CABundleSecretName = "s3-ca-bundle"
+	s3CABundleVolumeName = "s3-ca-bundle"
+	s3CABundleMountPath  = "/etc/pki/s3-ca"
+	// awsCABundleEnvVar is read by the aws sdk used by system to verify the
+	// S3 endpoint's certificate
+	awsCABundleEnvVar = "AWS_CA_BUNDLE"
+)
+
+// createS3CompatibleSecret maps the credentials of the S3 compatible storage in
+// the installation spec to the 3scale s3 credentials secret, and copies the
+// optional CA bundle to the 3scale namespace
+func (r *Reconciler) createS3CompatibleSecret(ctx context.Context, serverClient k8sclient.Client, credSec *corev1.Secret, storage *integreatlyv1alpha1.S3CompatibleStorageSpec) error {
+	storageSec := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: storage.CredentialsSecret, Namespace: r.installation.Namespace}, storageSec); err != nil {
+		return fmt.Errorf("failed to get s3 compatible storage secret %s: %w", storage.CredentialsSecret, err)
+	}
+	for _, key := range []string{s3CompatibleAccessKeyID, s3CompatibleSecretAccessKey, s3CompatibleBucketName} {
+		if len(storageSec.Data[key]) == 0 {
+			return fmt.Errorf("s3 compatible storage secret %s is missing %s", storage.CredentialsSecret, key)
+		}
+	}
+
+	endpoint, err := url.Parse(storage.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return fmt.Errorf("invalid s3 compatible storage endpoint %q", storage.Endpoint)
+	}
+
+	region := []byte(s3BucketRegion)
+	if len(storageSec.Data[s3CompatibleBucketRegion]) > 0 {
+		region = storageSec.Data[s3CompatibleBucketRegion]
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, serverClient, credSec, func() error {
+		if credSec.Data == nil {
+			credSec.Data = map[string][]byte{}
+		}
+		credSec.Data[threescaleAmp.AwsAccessKeyID] = storageSec.Data[s3CompatibleAccessKeyID]
+		credSec.Data[threescaleAmp.AwsSecretAccessKey] = storageSec.Data[s3CompatibleSecretAccessKey]
+		credSec.Data[threescaleAmp.AwsBucket] = storageSec.Data[s3CompatibleBucketName]
+		credSec.Data[threescaleAmp.AwsRegion] = region
+		credSec.Data[threescaleAmp.AwsProtocol] = []byte(endpoint.Scheme)
+		credSec.Data[threescaleAmp.AwsHostname] = []byte(endpoint.Host)
+		credSec.Data[threescaleAmp.AwsPathStyle] = []byte(strconv.FormatBool(storage.PathStyle))
+
+		// the storage may have been moved off AWS S3 with STS
+		delete(credSec.Data, threescaleAmp.AwsRoleArn)
+		delete(credSec.Data, threescaleAmp.AwsWebIdentityTokenFile)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	caSec := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s3CABundleSecretName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+	if len(storageSec.Data[s3CompatibleCABundle]) == 0 {
+		if err := serverClient.Delete(ctx, caSec); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete s3 ca bundle secret: %w", err)
+		}
+		return nil
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, serverClient, caSec, func() error {
+		caSec.Data = map[string][]byte{s3CompatibleCABundle: storageSec.Data[s3CompatibleCABundle]}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update s3 ca bundle secret: %w", err)
+	}
+	return nil
+}
+
+// reconcileS3CABundle mounts the CA bundle of the S3 compatible storage in the
+// system deployment configs, the APIManager has no option for it
+func (r *Reconciler) reconcileS3CABundle(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	caSec := &corev1.Secret{}
+	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: s3CABundleSecretName, Namespace: r.Config.GetNamespace()}, caSec)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get s3 ca bundle secret: %w", err)
+	}
+	enabled := err == nil
+
+	for _, dcName := range []string{systemAppDCName, "system-sidekiq"} {
+		dc := &appsv1.DeploymentConfig{}
+		if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: dcName, Namespace: r.Config.GetNamespace()}, dc); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to get %s deployment config: %w", dcName, err)
+		}
+		if !updateS3CABundle(dc, enabled) {
+			continue
+		}
+		r.log.Infof("Updating s3 ca bundle", l.Fields{"deploymentConfig": dcName, "enabled": enabled})
+		if err := serverClient.Update(ctx, dc); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update %s deployment config: %w", dcName, err)
+		}
+		if err := r.RolloutDeployment(ctx, dcName); err != nil {
+			r.log.Error(fmt.Sprintf("Rollout %v deployment", dcName), err)
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// updateS3CABundle adds, or removes, the s3 ca bundle volume to the pod template
+// and the mount and AWS_CA_BUNDLE env var to its containers. Returns true if
+// the deployment config was changed
+func updateS3CABundle(dc *appsv1.DeploymentConfig, enabled bool) bool {
+	if dc.Spec.Template == nil {
+		return false
+	}
+	podSpec := &dc.Spec.Template.Spec
+	updated := false
+
+	volumes := []corev1.Volume{}
+	for _, volume := range podSpec.Volumes {
+		if volume.Name != s3CABundleVolumeName {
+			volumes = append(volumes, volume)
+		}
+	}
+	if enabled {
+		volumes = append(volumes, corev1.Volume{
+			Name: s3CABundleVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: s3CABundleSecretName},
+			},
+		})
+	}
+	if len(volumes) != len(podSpec.Volumes) {
+		podSpec.Volumes = volumes
+		updated = true
+	}
+
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+
+		mounts := []corev1.VolumeMount{}
+		for _, mount := range container.VolumeMounts {
+			if mount.Name != s3CABundleVolumeName {
+				mounts = append(mounts, mount)
+			}
+		}
+		if enabled {
+			mounts = append(mounts, corev1.VolumeMount{Name: s3CABundleVolumeName, MountPath: s3CABundleMountPath, ReadOnly: true})
+		}
+		if len(mounts) != len(container.VolumeMounts) {
+			container.VolumeMounts = mounts
+			updated = true
+		}
+
+		env := []corev1.EnvVar{}
+		for _, envVar := range container.Env {
+			if envVar.Name != awsCABundleEnvVar {
+				env = append(env, envVar)
+			}
+		}
+		if enabled {
+			env = append(env, corev1.EnvVar{Name: awsCABundleEnvVar, Value: s3CABundleMountPath + "/" + s3CompatibleCABundle})
+		}
+		if len(env) != len(container.Env) {
+			container.Env = env
+			updated = true
+		}
+	}
+
+	return updated
+}
@@ -0,0 +1,185 @@
+package threescale
+
+import (
+	"context"
+	"testing"
+
+	threescaleAmp "github.com/3scale/3scale-operator/pkg/3scale/amp/component"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	appsv1 "github.com/openshift/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_createS3CompatibleSecret(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storageSecret := func(data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "minio-credentials", Namespace: utils.TestNamespacePrefix + "operator"},
+			Data:       map[string][]byte{},
+		}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+	credentials := map[string]string{
+		s3CompatibleAccessKeyID:     "access",
+		s3CompatibleSecretAccessKey: "secret",
+		s3CompatibleBucketName:      "assets",
+	}
+	withCA := map[string]string{s3CompatibleCABundle: "ca"}
+	for key, value := range credentials {
+		withCA[key] = value
+	}
+
+	tests := []struct {
+		name     string
+		storage  *integreatlyv1alpha1.S3CompatibleStorageSpec
+		initObjs []runtime.Object
+		wantErr  bool
+		wantData map[string]string
+		wantCA   bool
+	}{
+		{
+			name:     "test error when the credentials secret is missing fields",
+			storage:  &integreatlyv1alpha1.S3CompatibleStorageSpec{CredentialsSecret: "minio-credentials", Endpoint: "https://minio.example.com"},
+			initObjs: []runtime.Object{storageSecret(map[string]string{s3CompatibleAccessKeyID: "access"})},
+			wantErr:  true,
+		},
+		{
+			name:     "test error when the endpoint has no host",
+			storage:  &integreatlyv1alpha1.S3CompatibleStorageSpec{CredentialsSecret: "minio-credentials", Endpoint: "minio"},
+			initObjs: []runtime.Object{storageSecret(credentials)},
+			wantErr:  true,
+		},
+		{
+			name:     "test endpoint and path style are set in the 3scale s3 secret",
+			storage:  &integreatlyv1alpha1.S3CompatibleStorageSpec{CredentialsSecret: "minio-credentials", Endpoint: "http://minio.example.com:9000", PathStyle: true},
+			initObjs: []runtime.Object{storageSecret(credentials)},
+			wantData: map[string]string{
+				threescaleAmp.AwsAccessKeyID:     "access",
+				threescaleAmp.AwsSecretAccessKey: "secret",
+				threescaleAmp.AwsBucket:          "assets",
+				threescaleAmp.AwsRegion:          s3BucketRegion,
+				threescaleAmp.AwsProtocol:        "http",
+				threescaleAmp.AwsHostname:        "minio.example.com:9000",
+				threescaleAmp.AwsPathStyle:       "true",
+			},
+		},
+		{
+			name:     "test ca bundle is copied to the 3scale namespace",
+			storage:  &integreatlyv1alpha1.S3CompatibleStorageSpec{CredentialsSecret: "minio-credentials", Endpoint: "https://s3.openshift-storage.svc"},
+			initObjs: []runtime.Object{storageSecret(withCA)},
+			wantData: map[string]string{
+				threescaleAmp.AwsProtocol:  "https",
+				threescaleAmp.AwsHostname:  "s3.openshift-storage.svc",
+				threescaleAmp.AwsPathStyle: "false",
+			},
+			wantCA: true,
+		},
+		{
+			name:    "test sts keys and stale ca bundle are removed",
+			storage: &integreatlyv1alpha1.S3CompatibleStorageSpec{CredentialsSecret: "minio-credentials", Endpoint: "https://minio.example.com"},
+			initObjs: []runtime.Object{
+				storageSecret(credentials),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: s3CredentialsSecretName, Namespace: defaultInstallationNamespace},
+					Data:       map[string][]byte{threescaleAmp.AwsRoleArn: []byte("arn"), threescaleAmp.AwsWebIdentityTokenFile: []byte("token")},
+				},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: s3CABundleSecretName, Namespace: defaultInstallationNamespace}},
+			},
+			wantData: map[string]string{threescaleAmp.AwsHostname: "minio.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			r := &Reconciler{
+				Config:       config.NewThreeScale(config.ProductConfig{"NAMESPACE": defaultInstallationNamespace}),
+				installation: &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Namespace: utils.TestNamespacePrefix + "operator"}},
+				log:          getLogger(),
+			}
+			credSec := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: s3CredentialsSecretName, Namespace: defaultInstallationNamespace}}
+
+			err := r.createS3CompatibleSecret(context.TODO(), client, credSec, tt.storage)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createS3CompatibleSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := &corev1.Secret{}
+			if err := client.Get(context.TODO(), k8sclient.ObjectKeyFromObject(credSec), got); err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range tt.wantData {
+				if string(got.Data[key]) != value {
+					t.Errorf("expected %s to be %q, got %q", key, value, got.Data[key])
+				}
+			}
+			for _, key := range []string{threescaleAmp.AwsRoleArn, threescaleAmp.AwsWebIdentityTokenFile} {
+				if _, ok := got.Data[key]; ok {
+					t.Errorf("expected %s to be removed", key)
+				}
+			}
+
+			err = client.Get(context.TODO(), k8sclient.ObjectKey{Name: s3CABundleSecretName, Namespace: defaultInstallationNamespace}, &corev1.Secret{})
+			if tt.wantCA && err != nil {
+				t.Errorf("expected ca bundle secret, got %v", err)
+			}
+			if !tt.wantCA && !k8serr.IsNotFound(err) {
+				t.Errorf("expected no ca bundle secret, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateS3CABundle(t *testing.T) {
+	dc := func(env []corev1.EnvVar) *appsv1.DeploymentConfig {
+		return &appsv1.DeploymentConfig{
+			Spec: appsv1.DeploymentConfigSpec{
+				Template: &corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "system-master", Env: env}},
+					},
+				},
+			},
+		}
+	}
+
+	enabled := dc(nil)
+	if !updateS3CABundle(enabled, true) {
+		t.Fatal("expected deployment config to be updated when enabling the ca bundle")
+	}
+	container := enabled.Spec.Template.Spec.Containers[0]
+	if len(enabled.Spec.Template.Spec.Volumes) != 1 || len(container.VolumeMounts) != 1 || len(container.Env) != 1 || container.Env[0].Name != awsCABundleEnvVar {
+		t.Fatalf("expected ca bundle volume, mount and env var, got %+v", enabled.Spec.Template.Spec)
+	}
+	if updateS3CABundle(enabled, true) {
+		t.Fatal("expected no update when the ca bundle is already mounted")
+	}
+
+	if !updateS3CABundle(enabled, false) {
+		t.Fatal("expected deployment config to be updated when disabling the ca bundle")
+	}
+	container = enabled.Spec.Template.Spec.Containers[0]
+	if len(enabled.Spec.Template.Spec.Volumes) != 0 || len(container.VolumeMounts) != 0 || len(container.Env) != 0 {
+		t.Fatalf("expected ca bundle to be removed, got %+v", enabled.Spec.Template.Spec)
+	}
+
+	if updateS3CABundle(dc([]corev1.EnvVar{{Name: "SUPPORT_EMAIL"}}), false) {
+		t.Fatal("expected no update without a ca bundle")
+	}
+}
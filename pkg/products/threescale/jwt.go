@@ -0,0 +1,452 @@
+package threescale
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy/container/defaults"
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoylistenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	jwtauthn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
+	router "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	jwtFilterName = "envoy.filters.http.jwt_authn"
+	// rhssoJWKSPath is appended to the issuer of the providers without a JWKS URI
+	rhssoJWKSPath = "/protocol/openid-connect/certs"
+	// jwksTrustedCAFile is the system CA bundle of the envoy sidecar image
+	jwksTrustedCAFile = "/etc/pki/tls/certs/ca-bundle.crt"
+
+	defaultJWTClockSkewSeconds      = 60
+	defaultJWKSCacheDurationSeconds = 600
+
+	envoyStatsName     = "apicast-envoy-stats"
+	envoyStatsPortName = "envoy-stats"
+	// envoyStatsPort is the listener serving only the prometheus stats of
+	// the envoy admin API, so the rest of the admin API isn't exposed
+	envoyStatsPort             = 9902
+	envoyStatsPath             = "/stats/prometheus"
+	envoyAdminClusterName      = "envoy-admin"
+	envoyJWTServiceMonitorName = "3scale-envoy-jwt-service-monitor"
+	envoyJWTMetricsRegex       = "envoy_http_jwt_authn_.*"
+)
+
+// validateJWTProviders checks the gateway JWT providers can be rendered, and
+// that no host is claimed by more than one provider as only the first
+// matching requirement rule is applied
+func validateJWTProviders(providers []integreatlyv1alpha1.JWTProviderSpec) error {
+	names := map[string]bool{}
+	hosts := map[string]string{}
+
+	for _, provider := range providers {
+		if names[provider.Name] {
+			return fmt.Errorf("duplicated jwt provider %q", provider.Name)
+		}
+		names[provider.Name] = true
+
+		if len(provider.Hosts) == 0 {
+			return fmt.Errorf("jwt provider %q has no hosts", provider.Name)
+		}
+		for _, host := range provider.Hosts {
+			host = strings.ToLower(host)
+			if host != "*" {
+				if err := validateGatewayHost(host); err != nil {
+					return fmt.Errorf("jwt provider %q: %w", provider.Name, err)
+				}
+			}
+			if other, ok := hosts[host]; ok {
+				return fmt.Errorf("jwt providers %q and %q conflict on host %q", other, provider.Name, host)
+			}
+			hosts[host] = provider.Name
+		}
+
+		if _, err := parseHTTPSURL(provider.Issuer); err != nil {
+			return fmt.Errorf("jwt provider %q: invalid issuer: %w", provider.Name, err)
+		}
+		if _, err := parseHTTPSURL(getJWKSURI(provider)); err != nil {
+			return fmt.Errorf("jwt provider %q: invalid jwks uri: %w", provider.Name, err)
+		}
+		if provider.ClockSkewSeconds != nil && *provider.ClockSkewSeconds < 0 {
+			return fmt.Errorf("jwt provider %q: clock skew can't be negative", provider.Name)
+		}
+		if provider.JWKSCacheDurationSeconds < 0 || provider.TokenCacheSize < 0 {
+			return fmt.Errorf("jwt provider %q: cache settings can't be negative", provider.Name)
+		}
+	}
+
+	return nil
+}
+
+func parseHTTPSURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%q must be an https url", rawURL)
+	}
+	return parsed, nil
+}
+
+func getJWKSURI(provider integreatlyv1alpha1.JWTProviderSpec) string {
+	if provider.JWKSURI != "" {
+		return provider.JWKSURI
+	}
+	return strings.TrimSuffix(provider.Issuer, "/") + rhssoJWKSPath
+}
+
+func getJWKSClusterName(provider integreatlyv1alpha1.JWTProviderSpec) string {
+	return "jwks-" + provider.Name
+}
+
+/*
+*
+
+	httpFilters:
+	- name: envoy.filters.http.jwt_authn
+	  typedConfig:
+	    providers:
+	      <name>:
+	        issuer: <issuer>
+	        audiences: <audiences>
+	        forward: true
+	        remoteJwks:
+	          httpUri:
+	            uri: <jwks uri>
+	            cluster: jwks-<name>
+	            timeout: 5s
+	          cacheDuration: 600s
+	    rules:
+	    - match:
+	        prefix: /
+	        headers:
+	        - name: :authority
+	          stringMatch: <host>
+	      requires:
+	        providerName: <name>
+
+*
+*/
+func getJWTHTTPFilter(providers []integreatlyv1alpha1.JWTProviderSpec) (*hcm.HttpFilter, error) {
+	jwtAuthentication := &jwtauthn.JwtAuthentication{
+		Providers: map[string]*jwtauthn.JwtProvider{},
+	}
+	// the first matching rule applies, so exact hosts are matched before the
+	// wildcards and * last
+	var exactRules, wildcardRules, defaultRules []*jwtauthn.RequirementRule
+
+	for _, provider := range providers {
+		clockSkew := uint32(defaultJWTClockSkewSeconds)
+		if provider.ClockSkewSeconds != nil {
+			clockSkew = uint32(*provider.ClockSkewSeconds)
+		}
+		cacheDuration := int64(defaultJWKSCacheDurationSeconds)
+		if provider.JWKSCacheDurationSeconds > 0 {
+			cacheDuration = int64(provider.JWKSCacheDurationSeconds)
+		}
+
+		jwtProvider := &jwtauthn.JwtProvider{
+			Issuer:    provider.Issuer,
+			Audiences: provider.Audiences,
+			// APIcast still gets the token to apply its own policies
+			Forward:          true,
+			ClockSkewSeconds: clockSkew,
+			JwksSourceSpecifier: &jwtauthn.JwtProvider_RemoteJwks{
+				RemoteJwks: &jwtauthn.RemoteJwks{
+					HttpUri: &envoycorev3.HttpUri{
+						Uri:              getJWKSURI(provider),
+						HttpUpstreamType: &envoycorev3.HttpUri_Cluster{Cluster: getJWKSClusterName(provider)},
+						Timeout:          durationpb.New(5 * time.Second),
+					},
+					CacheDuration: durationpb.New(time.Duration(cacheDuration) * time.Second),
+					// keys are fetched when the config is loaded instead of
+					// on the first request
+					AsyncFetch: &jwtauthn.JwksAsyncFetch{},
+				},
+			},
+		}
+		if provider.TokenCacheSize > 0 {
+			jwtProvider.JwtCacheConfig = &jwtauthn.JwtCacheConfig{JwtCacheSize: uint32(provider.TokenCacheSize)}
+		}
+		jwtAuthentication.Providers[provider.Name] = jwtProvider
+
+		for _, host := range provider.Hosts {
+			host = strings.ToLower(host)
+			rule := &jwtauthn.RequirementRule{
+				Match: getHostRouteMatch(host),
+				RequirementType: &jwtauthn.RequirementRule_Requires{
+					Requires: &jwtauthn.JwtRequirement{
+						RequiresType: &jwtauthn.JwtRequirement_ProviderName{ProviderName: provider.Name},
+					},
+				},
+			}
+			switch {
+			case host == "*":
+				defaultRules = append(defaultRules, rule)
+			case strings.Contains(host, "*"):
+				wildcardRules = append(wildcardRules, rule)
+			default:
+				exactRules = append(exactRules, rule)
+			}
+		}
+	}
+	jwtAuthentication.Rules = append(append(exactRules, wildcardRules...), defaultRules...)
+
+	jwtSerial, err := anypb.New(jwtAuthentication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert jwt filter for Apicast envoy configuration: %v", err)
+	}
+
+	return &hcm.HttpFilter{
+		Name:       jwtFilterName,
+		ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: jwtSerial},
+	}, nil
+}
+
+// getHostRouteMatch matches every path of the host, a leading or trailing *
+// matches the host suffix or prefix like the virtual host domains. The
+// listener strips the port and the trailing dot of the :authority before the
+// match, see getListenerResourceFilters
+func getHostRouteMatch(host string) *envoyroutev3.RouteMatch {
+	routeMatch := &envoyroutev3.RouteMatch{
+		PathSpecifier: &envoyroutev3.RouteMatch_Prefix{Prefix: "/"},
+	}
+	if host == "*" {
+		return routeMatch
+	}
+
+	stringMatcher := &matcher.StringMatcher{IgnoreCase: true}
+	switch {
+	case strings.HasPrefix(host, "*"):
+		stringMatcher.MatchPattern = &matcher.StringMatcher_Suffix{Suffix: strings.TrimPrefix(host, "*")}
+	case strings.HasSuffix(host, "*"):
+		stringMatcher.MatchPattern = &matcher.StringMatcher_Prefix{Prefix: strings.TrimSuffix(host, "*")}
+	default:
+		stringMatcher.MatchPattern = &matcher.StringMatcher_Exact{Exact: host}
+	}
+	routeMatch.Headers = []*envoyroutev3.HeaderMatcher{{
+		Name:                 ":authority",
+		HeaderMatchSpecifier: &envoyroutev3.HeaderMatcher_StringMatch{StringMatch: stringMatcher},
+	}}
+	return routeMatch
+}
+
+// getJWKSClusters returns a cluster for each provider to fetch its signing
+// keys from, verified with the system CA bundle and negotiated with the TLS
+// parameters
+func getJWKSClusters(providers []integreatlyv1alpha1.JWTProviderSpec, tlsParams *tlsv3.TlsParameters) ([]*envoyclusterv3.Cluster, error) {
+	clusters := make([]*envoyclusterv3.Cluster, 0, len(providers))
+
+	for _, provider := range providers {
+		jwksURI, err := parseHTTPSURL(getJWKSURI(provider))
+		if err != nil {
+			return nil, err
+		}
+		port := 443
+		if jwksURI.Port() != "" {
+			if port, err = strconv.Atoi(jwksURI.Port()); err != nil {
+				return nil, fmt.Errorf("invalid jwks uri port %q: %w", jwksURI.Port(), err)
+			}
+		}
+
+		tlsSerial, err := anypb.New(&tlsv3.UpstreamTlsContext{
+			Sni: jwksURI.Hostname(),
+			CommonTlsContext: &tlsv3.CommonTlsContext{
+				TlsParams: tlsParams,
+				ValidationContextType: &tlsv3.CommonTlsContext_ValidationContext{
+					ValidationContext: &tlsv3.CertificateValidationContext{
+						TrustedCa: &envoycorev3.DataSource{
+							Specifier: &envoycorev3.DataSource_Filename{Filename: jwksTrustedCAFile},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert jwks tls context for provider %s: %v", provider.Name, err)
+		}
+
+		cluster := ratelimit.CreateClusterResource(jwksURI.Hostname(), getJWKSClusterName(provider), port)
+		cluster.TransportSocket = &envoycorev3.TransportSocket{
+			Name:       ratelimit.TransportSocketName,
+			ConfigType: &envoycorev3.TransportSocket_TypedConfig{TypedConfig: tlsSerial},
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+/*
+*
+
+	clusters:
+	- name: envoy-admin
+	  loadAssignment:
+	    endpoints:
+	    - lbEndpoints:
+	      - endpoint:
+	          address:
+	            socketAddress:
+	              address: 127.0.0.1
+	              portValue: 9901
+	listeners:
+	- name: envoy-stats
+	  address:
+	    socketAddress:
+	      address: 0.0.0.0
+	      portValue: 9902
+	  filterChains:
+	  - filters:
+	    - name: envoy.filters.network.http_connection_manager
+	      typedConfig:
+	        routeConfig:
+	          virtualHosts:
+	          - domains:
+	            - '*'
+	            routes:
+	            - match:
+	                path: /stats/prometheus
+	                headers:
+	                - name: :method
+	                  stringMatch:
+	                    exact: GET
+	              route:
+	                cluster: envoy-admin
+
+*
+*/
+// getEnvoyStatsResources returns a listener proxying only GET requests of the
+// prometheus stats to the envoy admin API of the sidecar, every other admin
+// path gets a 404
+func getEnvoyStatsResources() (*envoyclusterv3.Cluster, *envoylistenerv3.Listener, error) {
+	routerSerial, err := anypb.New(&router.Router{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert router filter for the envoy stats listener: %v", err)
+	}
+
+	virtualHosts := []*envoyroutev3.VirtualHost{{
+		Name:    envoyStatsName,
+		Domains: []string{"*"},
+		Routes: []*envoyroutev3.Route{{
+			Match: &envoyroutev3.RouteMatch{
+				PathSpecifier: &envoyroutev3.RouteMatch_Path{Path: envoyStatsPath},
+				Headers: []*envoyroutev3.HeaderMatcher{{
+					Name: ":method",
+					HeaderMatchSpecifier: &envoyroutev3.HeaderMatcher_StringMatch{
+						StringMatch: &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: "GET"}},
+					},
+				}},
+			},
+			Action: &envoyroutev3.Route_Route{
+				Route: &envoyroutev3.RouteAction{
+					ClusterSpecifier: &envoyroutev3.RouteAction_Cluster{Cluster: envoyAdminClusterName},
+				},
+			},
+		}},
+	}}
+	filters, err := getListenerResourceFilters(virtualHosts, []*hcm.HttpFilter{{
+		Name:       "envoy.filters.http.router",
+		ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: routerSerial},
+	}}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster := ratelimit.CreateClusterResource("127.0.0.1", envoyAdminClusterName, int(defaults.EnvoyAdminPort))
+	listener := ratelimit.CreateListenerResource(envoyStatsName, "0.0.0.0", envoyStatsPort, filters)
+	return cluster, listener, nil
+}
+
+// reconcileJWTValidationMetrics scrapes the jwt_authn stats of the production
+// gateway sidecars from the stats listener while JWT providers are set, so
+// the allowed and denied requests and the failed JWKS fetches are monitored
+func (r *Reconciler) reconcileJWTValidationMetrics(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      envoyStatsName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+	serviceMonitor := &prometheus.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      envoyJWTServiceMonitorName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+
+	if len(r.installation.Spec.GatewayJWTProviders) == 0 {
+		for _, obj := range []k8sclient.Object{serviceMonitor, service} {
+			if err := serverClient.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+				return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to delete %s: %w", obj.GetName(), err)
+			}
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, r.installation)
+		service.Labels = map[string]string{"app": envoyStatsName}
+		service.Spec.Selector = map[string]string{"deploymentconfig": apicastProductionDCName}
+		service.Spec.Ports = []corev1.ServicePort{{
+			Name:       envoyStatsPortName,
+			Port:       envoyStatsPort,
+			TargetPort: intstr.FromInt(envoyStatsPort),
+			Protocol:   corev1.ProtocolTCP,
+		}}
+		return nil
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile envoy stats service: %w", err)
+	}
+
+	_, err = controllerutil.CreateOrUpdate(ctx, serverClient, serviceMonitor, func() error {
+		serviceMonitor.Labels = map[string]string{
+			"monitoring-key": "middleware",
+		}
+		serviceMonitor.Spec = prometheus.ServiceMonitorSpec{
+			Endpoints: []prometheus.Endpoint{{
+				Port: envoyStatsPortName,
+				Path: envoyStatsPath,
+				MetricRelabelConfigs: []*prometheus.RelabelConfig{{
+					Action:       "keep",
+					SourceLabels: []prometheus.LabelName{"__name__"},
+					Regex:        envoyJWTMetricsRegex,
+				}},
+			}},
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": envoyStatsName},
+			},
+		}
+		resources.SetMetricRelabelConfigs(serviceMonitor.Spec.Endpoints, r.Config.GetProductName(), r.installation.Spec.Type)
+		return nil
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile envoy jwt service monitor: %w", err)
+	}
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
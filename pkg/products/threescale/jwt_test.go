@@ -0,0 +1,357 @@
+package threescale
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	jwtauthn "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestValidateJWTProviders(t *testing.T) {
+	provider := func(name string, hosts ...string) integreatlyv1alpha1.JWTProviderSpec {
+		return integreatlyv1alpha1.JWTProviderSpec{
+			Name:   name,
+			Hosts:  hosts,
+			Issuer: "https://keycloak.example.com/auth/realms/" + name,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		providers []integreatlyv1alpha1.JWTProviderSpec
+		update    func(providers []integreatlyv1alpha1.JWTProviderSpec)
+		wantErr   string
+	}{
+		{
+			name:      "test valid providers",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "orders.example.com"), provider("shop", "*.shop.example.com", "*")},
+		},
+		{
+			name:      "test hosts claimed by two providers conflict",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "api.example.com"), provider("shop", "API.example.com")},
+			wantErr:   `jwt providers "orders" and "shop" conflict on host "api.example.com"`,
+		},
+		{
+			name:      "test duplicated names",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "orders.example.com"), provider("orders", "shop.example.com")},
+			wantErr:   `duplicated jwt provider "orders"`,
+		},
+		{
+			name:      "test issuer without https",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "orders.example.com")},
+			update: func(providers []integreatlyv1alpha1.JWTProviderSpec) {
+				providers[0].Issuer = "http://keycloak.example.com/auth/realms/orders"
+			},
+			wantErr: "invalid issuer",
+		},
+		{
+			name:      "test jwks uri without https",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "orders.example.com")},
+			update: func(providers []integreatlyv1alpha1.JWTProviderSpec) {
+				providers[0].JWKSURI = "keycloak.example.com/certs"
+			},
+			wantErr: "invalid jwks uri",
+		},
+		{
+			name:      "test negative clock skew",
+			providers: []integreatlyv1alpha1.JWTProviderSpec{provider("orders", "orders.example.com")},
+			update: func(providers []integreatlyv1alpha1.JWTProviderSpec) {
+				skew := int32(-1)
+				providers[0].ClockSkewSeconds = &skew
+			},
+			wantErr: "clock skew can't be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.update != nil {
+				tt.update(tt.providers)
+			}
+			err := validateJWTProviders(tt.providers)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetJWTHTTPFilter(t *testing.T) {
+	skew := int32(0)
+	providers := []integreatlyv1alpha1.JWTProviderSpec{
+		{
+			Name:   "default",
+			Hosts:  []string{"*"},
+			Issuer: "https://keycloak.example.com/auth/realms/default/",
+		},
+		{
+			Name:                     "orders",
+			Hosts:                    []string{"*.orders.example.com", "Orders.example.com"},
+			Issuer:                   "https://sso.example.com/realms/orders",
+			JWKSURI:                  "https://sso.example.com:8443/keys",
+			Audiences:                []string{"orders-api"},
+			ClockSkewSeconds:         &skew,
+			JWKSCacheDurationSeconds: 60,
+			TokenCacheSize:           100,
+		},
+	}
+
+	filter, err := getJWTHTTPFilter(providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtAuthentication := &jwtauthn.JwtAuthentication{}
+	if err := filter.GetTypedConfig().UnmarshalTo(jwtAuthentication); err != nil {
+		t.Fatal(err)
+	}
+
+	defaultProvider := jwtAuthentication.Providers["default"]
+	if defaultProvider.GetRemoteJwks().GetHttpUri().GetUri() != "https://keycloak.example.com/auth/realms/default/protocol/openid-connect/certs" {
+		t.Fatalf("expected the rhsso jwks uri of the issuer, got %s", defaultProvider.GetRemoteJwks().GetHttpUri().GetUri())
+	}
+	if defaultProvider.ClockSkewSeconds != defaultJWTClockSkewSeconds || defaultProvider.GetRemoteJwks().GetCacheDuration().GetSeconds() != defaultJWKSCacheDurationSeconds || defaultProvider.JwtCacheConfig != nil {
+		t.Fatalf("expected the default clock skew and cache, got %v", defaultProvider)
+	}
+
+	ordersProvider := jwtAuthentication.Providers["orders"]
+	if ordersProvider.GetRemoteJwks().GetHttpUri().GetCluster() != "jwks-orders" || ordersProvider.Audiences[0] != "orders-api" || !ordersProvider.Forward {
+		t.Fatalf("unexpected orders provider %v", ordersProvider)
+	}
+	if ordersProvider.ClockSkewSeconds != 0 || ordersProvider.GetRemoteJwks().GetCacheDuration().GetSeconds() != 60 || ordersProvider.GetJwtCacheConfig().GetJwtCacheSize() != 100 {
+		t.Fatalf("expected the clock skew and cache of the orders provider, got %v", ordersProvider)
+	}
+
+	if len(jwtAuthentication.Rules) != 3 {
+		t.Fatalf("expected a rule per host, got %d", len(jwtAuthentication.Rules))
+	}
+	exact, wildcard, catchAll := jwtAuthentication.Rules[0], jwtAuthentication.Rules[1], jwtAuthentication.Rules[2]
+	if exact.Match.Headers[0].GetStringMatch().GetExact() != "orders.example.com" || exact.GetRequires().GetProviderName() != "orders" {
+		t.Fatalf("expected the exact host rule first, got %v", exact)
+	}
+	if wildcard.Match.Headers[0].GetStringMatch().GetSuffix() != ".orders.example.com" {
+		t.Fatalf("expected the wildcard host rule second, got %v", wildcard)
+	}
+	if len(catchAll.Match.Headers) != 0 || catchAll.GetRequires().GetProviderName() != "default" {
+		t.Fatalf("expected the * rule last, got %v", catchAll)
+	}
+
+	clusters, err := getJWKSClusters(providers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordersCluster := clusters[1]
+	endpoint := ordersCluster.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress()
+	if ordersCluster.Name != "jwks-orders" || endpoint.Address != "sso.example.com" || endpoint.GetPortValue() != 8443 {
+		t.Fatalf("unexpected jwks cluster %v", ordersCluster)
+	}
+	tlsContext := &tlsv3.UpstreamTlsContext{}
+	if err := ordersCluster.TransportSocket.GetTypedConfig().UnmarshalTo(tlsContext); err != nil {
+		t.Fatal(err)
+	}
+	if tlsContext.Sni != "sso.example.com" {
+		t.Fatalf("expected the jwks host as sni, got %s", tlsContext.Sni)
+	}
+	if clusters[0].LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().GetPortValue() != 443 {
+		t.Fatalf("expected the default https port, got %v", clusters[0])
+	}
+}
+
+// TestGetJWTHTTPFilterAuthorityBypass checks that a port or a trailing dot in
+// the authority doesn't skip the rule of a host, the listener strips both
+// before the jwt_authn filter matches the rules
+func TestGetJWTHTTPFilterAuthorityBypass(t *testing.T) {
+	providers := []integreatlyv1alpha1.JWTProviderSpec{
+		{
+			Name:   "orders",
+			Hosts:  []string{"orders.example.com"},
+			Issuer: "https://keycloak.example.com/auth/realms/orders",
+		},
+		{
+			Name:   "shop",
+			Hosts:  []string{"*.shop.example.com"},
+			Issuer: "https://keycloak.example.com/auth/realms/shop",
+		},
+	}
+	filter, err := getJWTHTTPFilter(providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtAuthentication := &jwtauthn.JwtAuthentication{}
+	if err := filter.GetTypedConfig().UnmarshalTo(jwtAuthentication); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := getListenerResourceFilters([]*envoyroutev3.VirtualHost{{Domains: []string{"*"}}}, []*hcm.HttpFilter{filter}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := &hcm.HttpConnectionManager{}
+	if err := filters[0].GetTypedConfig().UnmarshalTo(manager); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		authority string
+		want      string
+	}{
+		{
+			name:      "test exact host",
+			authority: "orders.example.com",
+			want:      "orders",
+		},
+		{
+			name:      "test exact host with a port",
+			authority: "orders.example.com:443",
+			want:      "orders",
+		},
+		{
+			name:      "test exact host with a trailing dot",
+			authority: "Orders.example.com.",
+			want:      "orders",
+		},
+		{
+			name:      "test wildcard host with a port and a trailing dot",
+			authority: "api.shop.example.com.:8443",
+			want:      "shop",
+		},
+		{
+			name:      "test unrelated host requires no provider",
+			authority: "other.example.com:443",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authority := normalizeAuthority(manager, tt.authority)
+			if got := matchJWTRequirement(jwtAuthentication.Rules, authority); got != tt.want {
+				t.Fatalf("expected provider %q for %s, got %q", tt.want, tt.authority, got)
+			}
+		})
+	}
+}
+
+// normalizeAuthority strips the authority the way the connection manager does
+// before the http filters run
+func normalizeAuthority(manager *hcm.HttpConnectionManager, authority string) string {
+	if manager.GetStripAnyHostPort() {
+		if i := strings.LastIndex(authority, ":"); i != -1 {
+			authority = authority[:i]
+		}
+	}
+	if manager.StripTrailingHostDot {
+		authority = strings.TrimSuffix(authority, ".")
+	}
+	return authority
+}
+
+// matchJWTRequirement returns the provider required by the first rule matching
+// the authority
+func matchJWTRequirement(rules []*jwtauthn.RequirementRule, authority string) string {
+	for _, rule := range rules {
+		matched := true
+		for _, header := range rule.Match.Headers {
+			stringMatch := header.GetStringMatch()
+			value := strings.ToLower(authority)
+			switch {
+			case stringMatch.GetExact() != "":
+				matched = matched && value == strings.ToLower(stringMatch.GetExact())
+			case stringMatch.GetSuffix() != "":
+				matched = matched && strings.HasSuffix(value, strings.ToLower(stringMatch.GetSuffix()))
+			case stringMatch.GetPrefix() != "":
+				matched = matched && strings.HasPrefix(value, strings.ToLower(stringMatch.GetPrefix()))
+			}
+		}
+		if matched {
+			return rule.GetRequires().GetProviderName()
+		}
+	}
+	return ""
+}
+
+func TestGetEnvoyStatsResources(t *testing.T) {
+	cluster, listener, err := getEnvoyStatsResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Name != envoyAdminClusterName || cluster.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().Address != "127.0.0.1" {
+		t.Fatalf("expected the admin api on localhost, got %v", cluster)
+	}
+	if listener.Address.GetSocketAddress().GetPortValue() != envoyStatsPort {
+		t.Fatalf("expected the stats port, got %v", listener.Address)
+	}
+	manager := &hcm.HttpConnectionManager{}
+	if err := listener.FilterChains[0].Filters[0].GetTypedConfig().UnmarshalTo(manager); err != nil {
+		t.Fatal(err)
+	}
+	routes := manager.GetRouteConfig().VirtualHosts[0].Routes
+	if len(routes) != 1 || routes[0].Match.GetPath() != envoyStatsPath || routes[0].GetRoute().GetCluster() != envoyAdminClusterName {
+		t.Fatalf("expected only the prometheus stats to be routed to the admin api, got %v", routes)
+	}
+}
+
+func TestReconcileJWTValidationMetrics(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		Spec: integreatlyv1alpha1.RHMISpec{
+			GatewayJWTProviders: []integreatlyv1alpha1.JWTProviderSpec{{
+				Name:   "orders",
+				Hosts:  []string{"*"},
+				Issuer: "https://keycloak.example.com/auth/realms/orders",
+			}},
+		},
+	}
+	client := utils.NewTestClient(scheme)
+	r := &Reconciler{
+		Config:       config.NewThreeScale(config.ProductConfig{"NAMESPACE": defaultInstallationNamespace}),
+		installation: installation,
+		log:          getLogger(),
+	}
+
+	if _, err := r.reconcileJWTValidationMetrics(context.TODO(), client); err != nil {
+		t.Fatal(err)
+	}
+	service := &corev1.Service{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: envoyStatsName, Namespace: defaultInstallationNamespace}, service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Selector["deploymentconfig"] != apicastProductionDCName || service.Spec.Ports[0].Port != envoyStatsPort {
+		t.Fatalf("unexpected envoy stats service %v", service.Spec)
+	}
+	serviceMonitor := &prometheus.ServiceMonitor{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: envoyJWTServiceMonitorName, Namespace: defaultInstallationNamespace}, serviceMonitor); err != nil {
+		t.Fatal(err)
+	}
+	if serviceMonitor.Spec.Endpoints[0].MetricRelabelConfigs[0].Regex != envoyJWTMetricsRegex {
+		t.Fatalf("expected only the jwt_authn metrics to be kept, got %v", serviceMonitor.Spec.Endpoints[0].MetricRelabelConfigs)
+	}
+
+	installation.Spec.GatewayJWTProviders = nil
+	if _, err := r.reconcileJWTValidationMetrics(context.TODO(), client); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: envoyStatsName, Namespace: defaultInstallationNamespace}, service); !k8serr.IsNotFound(err) {
+		t.Fatalf("expected envoy stats service to be deleted, got %v", err)
+	}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: envoyJWTServiceMonitorName, Namespace: defaultInstallationNamespace}, serviceMonitor); !k8serr.IsNotFound(err) {
+		t.Fatalf("expected service monitor to be deleted, got %v", err)
+	}
+}
@@ -13,7 +13,10 @@ import (
 
 	portaClient "github.com/3scale/3scale-porta-go-client/client"
 	"github.com/integr8ly/integreatly-operator/pkg/addon"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/fips"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/rotation"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 
@@ -40,6 +43,7 @@ import (
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/events"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/routeverification"
 
 	"github.com/integr8ly/integreatly-operator/pkg/resources/backup"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
@@ -72,6 +76,7 @@ import (
 	oauthClient "github.com/openshift/client-go/oauth/clientset/versioned/typed/oauth/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
@@ -97,6 +102,7 @@ const (
 	backendListenerDCName          = "backend-listener"
 	systemSeedSecretName           = "system-seed"
 	systemMasterApiCastSecretName  = "system-master-apicast"
+	systemSMTPSecretName           = "system-smtp"
 	systemAppDCName                = "system-app"
 	multitenantID                  = "rhoam-mt"
 	registrySecretName             = "threescale-registry-auth"
@@ -111,6 +117,10 @@ const (
 	stsS3CredentialsSecretName  = "sts-s3-credentials"                              // #nosec G101 -- This is a false positive
 	stsWebIdentityTokenFilePath = "/var/run/secrets/openshift/serviceaccount/token" // #nosec G101 -- This is a false positive
 	stsTokenAudience            = "openshift"
+	// s3CredentialsRolloutAnnotation is set on the s3 credentials secret when
+	// the static access keys are removed, until the system deployments caching
+	// them have been rolled out
+	s3CredentialsRolloutAnnotation = "integreatly.org/s3-credentials-rollout"
 )
 
 var (
@@ -135,18 +145,14 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		return nil, fmt.Errorf("no product declaration found for 3scale")
 	}
 
-	ns := installation.Spec.NamespacePrefix + defaultInstallationNamespace
+	ns := installation.ProductNamespace(defaultInstallationNamespace)
 	threescaleConfig, err := configManager.ReadThreeScale()
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve threescale config: %w", err)
 	}
 
 	threescaleConfig.SetNamespace(ns)
-	if installation.Spec.OperatorsInProductNamespace {
-		threescaleConfig.SetOperatorNamespace(threescaleConfig.GetNamespace())
-	} else {
-		threescaleConfig.SetOperatorNamespace(threescaleConfig.GetNamespace() + "-operator")
-	}
+	threescaleConfig.SetOperatorNamespace(installation.OperatorNamespace(threescaleConfig.GetNamespace()))
 	threescaleConfig.SetBlackboxTargetPathForAdminUI("/p/login/")
 
 	if err := configManager.WriteConfig(threescaleConfig); err != nil {
@@ -164,7 +170,11 @@ func NewReconciler(configManager config.ConfigReadWriter, installation *integrea
 		Reconciler:    resources.NewReconciler(mpm).WithProductDeclaration(*productDeclaration),
 		recorder:      recorder,
 		log:           logger,
-		podExecutor:   resources.NewPodExecutor(logger),
+		podExecutor:   resources.NewInstallationPodExecutor(installation, logger),
+
+		newRouteVerifier:   routeverification.NewVerifier,
+		routeVerifications: routeverification.DefaultScheduler,
+		probeEnvoyCanary:   probeEnvoyCanary,
 	}, nil
 }
 
@@ -181,6 +191,14 @@ type Reconciler struct {
 	recorder    record.EventRecorder
 	log         l.Logger
 	podExecutor resources.PodExecutorInterface
+	// newRouteVerifier creates the verifier used to check the DNS records and
+	// certificates of the 3scale routes. Verification is skipped when nil
+	newRouteVerifier func(externalResolver string) *routeverification.Verifier
+	// routeVerifications runs the route verifications in the background
+	routeVerifications *routeverification.Scheduler
+	// probeEnvoyCanary sends a synthetic request to the canary gateway and
+	// returns the response status. The canary isn't probed when nil
+	probeEnvoyCanary func(ctx context.Context, probe envoyCanaryProbe) (int, error)
 }
 
 func (r *Reconciler) GetPreflightObject(ns string) k8sclient.Object {
@@ -293,6 +311,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 			return phase, err
 		}
 		customDomain.UpdateErrorAndCustomDomainMetric(r.installation, customDomainActive, err)
+
+		// Record failures are reported in the custom domain status, the DNS
+		// changes propagate asynchronously so they don't block the install
+		if err := customDomain.ReconcileDNS(ctx, serverClient, r.installation, customDomain.NewDNSProvider); err != nil {
+			r.log.Error("Failed to reconcile custom domain DNS records", err)
+		}
 	}
 
 	phase, err = r.ReconcileNamespace(ctx, operatorNamespace, installation, serverClient, r.log)
@@ -331,7 +355,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return integreatlyv1alpha1.PhaseFailed, err
 	}
 
-	phase, err = r.reconcileSubscription(ctx, serverClient, installation, productNamespace, operatorNamespace)
+	phase, err = r.reconcileSubscription(ctx, serverClient, installation, productStatus, productNamespace, operatorNamespace)
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 		events.HandleError(r.recorder, installation, phase, fmt.Sprintf("Failed to reconcile %s subscription", constants.ThreeScaleSubscriptionName), err)
 		return phase, err
@@ -374,7 +398,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 			events.HandleError(r.recorder, installation, phase, "Failed to reconcile external data sources", err)
 			return phase, err
 		}
-		if platformType != configv1.GCPPlatformType {
+		if platformType != configv1.GCPPlatformType && installation.Spec.ThreeScaleFileStorage == nil {
 			phase, err = r.reconcileBlobStorage(ctx, serverClient)
 			if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
 				events.HandleError(r.recorder, installation, phase, "Failed to reconcile blob storage", err)
@@ -398,12 +422,27 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	if err := r.verifyRoutes(ctx, serverClient); err != nil {
+		r.log.Error("failed to verify 3scale routes", err)
+	}
+
+	if err := r.reconcileCustomRoutes(ctx, serverClient, customDomain.DefaultHostResolver); err != nil {
+		events.HandleError(r.recorder, installation, integreatlyv1alpha1.PhaseFailed, "Failed to reconcile 3scale custom routes", err)
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
 	if integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(installation.Spec.Type)) {
 		phase, err = r.reconcile3scaleMultiTenancy(ctx, serverClient)
 		if err != nil {
 			r.log.Error("reconcile3scaleMultiTenancy", err)
 			return phase, err
 		}
+
+		phase, err = r.reconcileTenantGatewaySNI(ctx, serverClient)
+		if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+			events.HandleError(r.recorder, installation, phase, "Failed to reconcile tenant gateway SNI route", err)
+			return phase, err
+		}
 	}
 
 	r.log.Info("Successfully deployed")
@@ -436,6 +475,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.reconcileS3CABundle(ctx, serverClient)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile s3 ca bundle", err)
+		return phase, err
+	}
+
 	phase, err = r.reconcileRHSSOIntegration(ctx, serverClient)
 	r.log.Infof("reconcileRHSSOIntegration", l.Fields{"phase": phase})
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
@@ -485,6 +530,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 		return phase, err
 	}
 
+	phase, err = r.reconcileSecretRotation(ctx, serverClient, productStatus)
+	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+		events.HandleError(r.recorder, installation, phase, "Failed to reconcile admin credential rotation", err)
+		return phase, err
+	}
+
 	phase, err = r.backupSystemSecrets(ctx, serverClient, installation)
 	r.log.Infof("backupSystemSecrets", l.Fields{"phase": phase})
 	if err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
@@ -561,8 +612,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, installation *integreatlyv1a
 	productStatus.Host = r.Config.GetHost()
 	productStatus.Version = r.Config.GetProductVersion()
 	productStatus.OperatorVersion = r.Config.GetOperatorVersion()
+	productStatus.FIPS = fips.ProductStatus(installation)
 
-	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.ProductsStage, r.Config.GetProductName())
+	events.HandleProductComplete(r.recorder, installation, integreatlyv1alpha1.InstallStage, r.Config.GetProductName())
 	r.log.Infof("Installation reconciled successfully", l.Fields{"productStatus": r.Config.GetProductName()})
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
@@ -582,6 +634,59 @@ func (r *Reconciler) restoreSystemSecrets(ctx context.Context, serverClient k8sc
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
+// reconcileSecretRotation rotates the password of the admin of the 3scale
+// tenant and refreshes the copy of the smtp credentials. The admin password is
+// changed through the account management API before the seed secret is
+// updated. The system deployments reading both secrets are rolled out after
+// either is rotated
+func (r *Reconciler) reconcileSecretRotation(ctx context.Context, serverClient k8sclient.Client, productStatus *integreatlyv1alpha1.RHMIProductStatus) (integreatlyv1alpha1.StatusPhase, error) {
+	ns := r.Config.GetNamespace()
+	systemDependents := []rotation.Dependent{}
+	for _, name := range []string{systemAppDCName, "system-sidekiq"} {
+		systemDependents = append(systemDependents, rotation.Dependent{
+			Object:   &appsv1.DeploymentConfig{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}},
+			Template: resources.SelectFromDeploymentConfig,
+		})
+	}
+
+	admin := rotation.Credential{
+		Name:   "3scale-admin",
+		Secret: k8sTypes.NamespacedName{Name: systemSeedSecretName, Namespace: ns},
+		Keys:   []string{"ADMIN_PASSWORD"},
+		Apply: func(ctx context.Context, rotated *corev1.Secret) error {
+			accessToken := string(rotated.Data["ADMIN_ACCESS_TOKEN"])
+			user, err := r.tsClient.GetUser(string(rotated.Data["ADMIN_USER"]), accessToken)
+			if err != nil {
+				return err
+			}
+			return r.tsClient.UpdateUserPassword(accessToken, user.UserDetails.Id, string(rotated.Data["ADMIN_PASSWORD"]))
+		},
+		Dependents: systemDependents,
+	}
+	// The smtp credentials aren't generated by the operator, rotating their
+	// copy picks up the current credentials of the installation
+	smtp := rotation.Credential{
+		Name:   "3scale-smtp",
+		Secret: k8sTypes.NamespacedName{Name: systemSMTPSecretName, Namespace: ns},
+		Apply: func(ctx context.Context, rotated *corev1.Secret) error {
+			if integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(r.installation.Spec.Type)) {
+				return nil
+			}
+			credSec := &corev1.Secret{}
+			if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: r.smtpCredentialsSecretName(), Namespace: r.installation.Namespace}, credSec); err != nil {
+				return fmt.Errorf("failed to get smtp credentials secret: %w", err)
+			}
+			setSMTPConfig(credSec, rotated)
+			return nil
+		},
+		Dependents: systemDependents,
+	}
+	if err := rotation.Reconcile(ctx, serverClient, r.installation, productStatus, time.Now(), r.log, admin, smtp); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
 // Copies the seed and master api cast secrets for later restoration
 func (r *Reconciler) backupSystemSecrets(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI) (integreatlyv1alpha1.StatusPhase, error) {
 	for _, secretName := range []string{systemSeedSecretName, systemMasterApiCastSecretName} {
@@ -617,21 +722,14 @@ func (r *Reconciler) reconcileSMTPCredentials(ctx context.Context, serverClient
 
 	// get the secret containing smtp credentials
 	credSec := &corev1.Secret{}
-	secretName := r.installation.Spec.SMTPSecret
-
-	if r.installation.Status.CustomSmtp != nil && r.installation.Status.CustomSmtp.Enabled {
-		r.log.Info("configuring user smtp for 3scale notifications")
-		secretName = cs.CustomSecret
-	}
-
-	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: secretName, Namespace: r.installation.Namespace}, credSec)
+	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: r.smtpCredentialsSecretName(), Namespace: r.installation.Namespace}, credSec)
 	if err != nil {
 		r.log.Warningf("could not obtain smtp credentials secret", l.Fields{"error": err})
 	}
 
 	smtpConfigSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "system-smtp",
+			Name:      systemSMTPSecretName,
 			Namespace: r.Config.GetNamespace(),
 		},
 		Data: map[string][]byte{},
@@ -644,41 +742,9 @@ func (r *Reconciler) reconcileSMTPCredentials(ctx context.Context, serverClient
 			smtpConfigSecret.Data = map[string][]byte{}
 		}
 
-		smtpUpdated := false
-
 		// There is an issue with setting smtp values and creating Tenants. CreateTenant fails when SMTP values are set.
 		if !integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(r.installation.Spec.Type)) {
-
-			if string(credSec.Data["host"]) != string(smtpConfigSecret.Data["address"]) {
-				smtpConfigSecret.Data["address"] = credSec.Data["host"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["authentication"]) != string(smtpConfigSecret.Data["authentication"]) {
-				smtpConfigSecret.Data["authentication"] = credSec.Data["authentication"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["domain"]) != string(smtpConfigSecret.Data["domain"]) {
-				smtpConfigSecret.Data["domain"] = credSec.Data["domain"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["openssl.verify.mode"]) != string(smtpConfigSecret.Data["openssl.verify.mode"]) {
-				smtpConfigSecret.Data["openssl.verify.mode"] = credSec.Data["openssl.verify.mode"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["password"]) != string(smtpConfigSecret.Data["password"]) {
-				smtpConfigSecret.Data["password"] = credSec.Data["password"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["port"]) != string(smtpConfigSecret.Data["port"]) {
-				smtpConfigSecret.Data["port"] = credSec.Data["port"]
-				smtpUpdated = true
-			}
-			if string(credSec.Data["username"]) != string(smtpConfigSecret.Data["username"]) {
-				smtpConfigSecret.Data["username"] = credSec.Data["username"]
-				smtpUpdated = true
-			}
-
-			if smtpUpdated {
+			if setSMTPConfig(credSec, smtpConfigSecret) {
 				err = r.RolloutDeployment(ctx, "system-app")
 				if err != nil {
 					r.log.Error("Rollout system-app deployment", err)
@@ -699,6 +765,40 @@ func (r *Reconciler) reconcileSMTPCredentials(ctx context.Context, serverClient
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
+// smtpCredentialsSecretName returns the secret in the installation namespace
+// the 3scale smtp settings are copied from
+func (r *Reconciler) smtpCredentialsSecretName() string {
+	if cs.RelayEnabled(r.installation) {
+		r.log.Info("configuring smtp relay for 3scale notifications")
+		return cs.RelaySecret
+	} else if r.installation.Status.CustomSmtp != nil && r.installation.Status.CustomSmtp.Enabled {
+		r.log.Info("configuring user smtp for 3scale notifications")
+		return cs.CustomSecret
+	}
+	return r.installation.Spec.SMTPSecret
+}
+
+// setSMTPConfig copies the smtp credentials to the 3scale smtp secret, it
+// returns whether any of them changed
+func setSMTPConfig(credSec *corev1.Secret, smtpConfigSecret *corev1.Secret) bool {
+	smtpUpdated := false
+	for credKey, configKey := range map[string]string{
+		"host":                "address",
+		"authentication":      "authentication",
+		"domain":              "domain",
+		"openssl.verify.mode": "openssl.verify.mode",
+		"password":            "password",
+		"port":                "port",
+		"username":            "username",
+	} {
+		if string(credSec.Data[credKey]) != string(smtpConfigSecret.Data[configKey]) {
+			smtpConfigSecret.Data[configKey] = credSec.Data[credKey]
+			smtpUpdated = true
+		}
+	}
+	return smtpUpdated
+}
+
 func (r *Reconciler) reconcileComponents(ctx context.Context, serverClient k8sclient.Client, productConfig quota.ProductConfig, platformType configv1.PlatformType) (integreatlyv1alpha1.StatusPhase, error) {
 	fss, err := r.getBlobStorageFileStorageSpec(ctx, serverClient, platformType)
 	if err != nil {
@@ -724,6 +824,13 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, serverClient k8scl
 		antiAffinityRequired = false
 	}
 
+	nodeAffinity, err := resources.GetProductNodeArchitectureAffinity(ctx, serverClient, integreatlyv1alpha1.Product3Scale)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	// The APIManager can only select nodes through the affinity
+	nodeAffinity = resources.PlacementNodeAffinity(nodeAffinity, r.installation.Spec.Placement)
+
 	ExternalComponentsTrue := true
 	resourceRequirements := true
 	replicas := r.Config.GetReplicasConfig(r.installation)
@@ -735,6 +842,22 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, serverClient k8scl
 	zyncQueReplicas := replicas["zyncQue"]
 	apicastport := apicastHTTPsPort
 
+	tenantGatewaySNI, err := r.isTenantGatewaySNIEnabled(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	// The certificate must exist before APIcast production references it
+	if tenantGatewaySNI {
+		if _, err := r.reconcileTenantGatewayCertificate(ctx, serverClient); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+	}
+
+	// The tracing configuration must exist before APIcast references it
+	if err := r.reconcileAPIcastTracingSecret(ctx, serverClient); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
 	status, err := controllerutil.CreateOrUpdate(ctx, serverClient, apim, func() error {
 		// Check nested "optional" fields
 		*apim = prepareNestedOptionalFields(*apim)
@@ -757,6 +880,15 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, serverClient k8scl
 		apim.Spec.Apicast.StagingSpec.HTTPSPort = &apicastport
 		apim.Spec.Apicast.ProductionSpec.HTTPSPort = &apicastport
 
+		// Tenant gateways served through the SNI route use the operator managed certificate
+		if tenantGatewaySNI {
+			apim.Spec.Apicast.ProductionSpec.HTTPSCertificateSecretRef = &corev1.LocalObjectReference{Name: tenantGatewayTLSSecretName}
+		} else if apim.Spec.Apicast.ProductionSpec.HTTPSCertificateSecretRef != nil && apim.Spec.Apicast.ProductionSpec.HTTPSCertificateSecretRef.Name == tenantGatewayTLSSecretName {
+			apim.Spec.Apicast.ProductionSpec.HTTPSCertificateSecretRef = nil
+		}
+
+		setAPIcastOpenTracing(apim, r.installation)
+
 		// Set priority class names
 		apim.Spec.System.AppSpec.PriorityClassName = &r.installation.Spec.PriorityClassName
 		apim.Spec.System.SidekiqSpec.PriorityClassName = &r.installation.Spec.PriorityClassName
@@ -835,6 +967,32 @@ func (r *Reconciler) reconcileComponents(ctx context.Context, serverClient k8scl
 			"threescale_component_element": "zync-que",
 		})
 
+		// Keep the components on nodes with an architecture the 3scale images
+		// are available for, and on the nodes selected by the placement
+		for _, affinity := range []*corev1.Affinity{
+			apim.Spec.System.AppSpec.Affinity,
+			apim.Spec.System.SidekiqSpec.Affinity,
+			apim.Spec.Apicast.ProductionSpec.Affinity,
+			apim.Spec.Apicast.StagingSpec.Affinity,
+			apim.Spec.Backend.ListenerSpec.Affinity,
+			apim.Spec.Backend.WorkerSpec.Affinity,
+			apim.Spec.Backend.CronSpec.Affinity,
+			apim.Spec.Zync.AppSpec.Affinity,
+			apim.Spec.Zync.QueSpec.Affinity,
+		} {
+			affinity.NodeAffinity = nodeAffinity
+		}
+		tolerations := resources.PlacementTolerations(r.installation.Spec.Placement)
+		apim.Spec.System.AppSpec.Tolerations = tolerations
+		apim.Spec.System.SidekiqSpec.Tolerations = tolerations
+		apim.Spec.Apicast.ProductionSpec.Tolerations = tolerations
+		apim.Spec.Apicast.StagingSpec.Tolerations = tolerations
+		apim.Spec.Backend.ListenerSpec.Tolerations = tolerations
+		apim.Spec.Backend.WorkerSpec.Tolerations = tolerations
+		apim.Spec.Backend.CronSpec.Tolerations = tolerations
+		apim.Spec.Zync.AppSpec.Tolerations = tolerations
+		apim.Spec.Zync.QueSpec.Tolerations = tolerations
+
 		err = productConfig.Configure(apim)
 
 		if err != nil {
@@ -1023,8 +1181,10 @@ func (r *Reconciler) getBlobStorageFileStorageSpec(ctx context.Context, serverCl
 
 	var err error
 	var isSTS bool
-	switch platformType {
-	case configv1.AWSPlatformType:
+	switch {
+	case r.installation.Spec.ThreeScaleFileStorage != nil:
+		err = r.createS3CompatibleSecret(ctx, serverClient, credSec, r.installation.Spec.ThreeScaleFileStorage)
+	case platformType == configv1.AWSPlatformType:
 		blobStorage := &crov1.BlobStorage{}
 		// get blob storage cr
 		err = serverClient.Get(ctx, k8sclient.ObjectKey{Name: fmt.Sprintf("%s%s", constants.ThreeScaleBlobStoragePrefix, r.installation.Name), Namespace: r.installation.Namespace}, blobStorage)
@@ -1064,7 +1224,7 @@ func (r *Reconciler) getBlobStorageFileStorageSpec(ctx context.Context, serverCl
 				return nil
 			})
 		}
-	case configv1.GCPPlatformType:
+	case platformType == configv1.GCPPlatformType:
 		err = r.createMCGS3Secret(ctx, serverClient, credSec)
 	default:
 		err = fmt.Errorf("unsupported cluster type: %s", platformType)
@@ -1100,6 +1260,9 @@ func (r *Reconciler) createStsS3Secret(ctx context.Context, serverClient k8sclie
 	}
 
 	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, credSec, func() error {
+		if credSec.Data == nil {
+			credSec.Data = map[string][]byte{}
+		}
 		for key := range blobStorageSec.Data {
 			switch key {
 			case "bucketName":
@@ -1112,14 +1275,48 @@ func (r *Reconciler) createStsS3Secret(ctx context.Context, serverClient k8sclie
 		credSec.Data[threescaleAmp.AwsRoleArn] = stsSecret.Data["role_arn"]
 		credSec.Data[threescaleAmp.AwsWebIdentityTokenFile] = []byte(stsWebIdentityTokenFilePath)
 
+		// Installs created before STS was available use static access keys,
+		// remove them so 3scale only uses the role based credentials. The
+		// rollout is recorded in the same update so it's retried if it fails
+		for _, key := range []string{threescaleAmp.AwsAccessKeyID, threescaleAmp.AwsSecretAccessKey} {
+			if _, ok := credSec.Data[key]; ok {
+				delete(credSec.Data, key)
+				if credSec.Annotations == nil {
+					credSec.Annotations = map[string]string{}
+				}
+				credSec.Annotations[s3CredentialsRolloutAnnotation] = "true"
+			}
+		}
+
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	if _, ok := credSec.Annotations[s3CredentialsRolloutAnnotation]; !ok {
+		return nil
+	}
+
+	// The static keys are cached by the running pods, roll them out to pick up
+	// the role based credentials
+	for _, name := range []string{"system-app", "system-sidekiq"} {
+		if err := r.RolloutDeployment(ctx, name); err != nil {
+			return fmt.Errorf("failed to rollout %s deployment for s3 credentials change: %w", name, err)
+		}
+	}
+
+	delete(credSec.Annotations, s3CredentialsRolloutAnnotation)
+	if err := serverClient.Update(ctx, credSec); err != nil {
+		return fmt.Errorf("failed to clear s3 credentials rollout annotation: %w", err)
+	}
+	r.log.Info("Rotated 3scale s3 credentials from static access keys to role based credentials")
+
+	return nil
 }
 
 func (r *Reconciler) createMCGS3Secret(ctx context.Context, serverClient k8sclient.Client, credSec *corev1.Secret) error {
-	mcgNamespace := r.installation.Spec.NamespacePrefix + mcg.DefaultInstallationNamespace + "-operator"
+	mcgNamespace := r.installation.OperatorNamespace(r.installation.ProductNamespace(mcg.DefaultInstallationNamespace))
 	// Retrieve object bucket claim
 	objbc := &noobaav1.ObjectBucketClaim{}
 	err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: mcg.ThreescaleBucketClaim, Namespace: mcgNamespace}, objbc)
@@ -1465,7 +1662,7 @@ func (r *Reconciler) reconcileRHSSOIntegration(ctx context.Context, serverClient
 }
 
 func (r *Reconciler) getOAuthClientName() string {
-	return r.installation.Spec.NamespacePrefix + string(r.Config.GetProductName())
+	return r.installation.ProductNamespace(string(r.Config.GetProductName()))
 }
 
 func (r *Reconciler) reconcileOpenshiftUsers(ctx context.Context, _ *integreatlyv1alpha1.RHMI, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
@@ -1985,6 +2182,11 @@ func (r *Reconciler) reconcile3scaleMultiTenancy(ctx context.Context, serverClie
 		}
 	}
 
+	// Roll out the application plan templates to the created accounts, the
+	// accounts are skipped by the loop above once created
+	r.reconcileTenantsApplicationPlans(ctx, serverClient, allAccounts, tenantsCreated, signUpAccountsSecret)
+	r.reconcileTenantsBilling(ctx, serverClient, allAccounts)
+
 	if len(accountsToBeCreated) > 0 {
 		r.log.Infof("Returning in progress as there were accounts created and users need to be activated",
 			l.Fields{"totalAccountsCreated": len(accountsToBeCreated)},
@@ -2129,6 +2331,7 @@ func (r *Reconciler) reconcileDashboardLink(ctx context.Context, serverClient k8
 
 	tenantNamespaces := []string{fmt.Sprintf("%s-stage", username), fmt.Sprintf("%s-dev", username)}
 	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, cl, func() error {
+		resources.PrepareObjectLabels(cl, r.installation, false, false, false)
 		cl.Spec = consolev1.ConsoleLinkSpec{
 			Location: consolev1.NamespaceDashboard,
 			Link: consolev1.Link{
@@ -2284,27 +2487,39 @@ func getMTAccountsToBeDeleted(usersIdentity []userHelper.MultiTenantUser, accoun
 	return accountsToBeDeleted
 }
 
-func (r *Reconciler) preUpgradeBackupExecutor() backup.BackupExecutor {
+// preUpgradeBackupExecutor backs up the system database and the Redis
+// instances of 3scale before its operator is upgraded. On cluster storage the
+// Redis instances have no snapshots and only the system database is dumped
+func (r *Reconciler) preUpgradeBackupExecutor(productStatus *integreatlyv1alpha1.RHMIProductStatus) backup.BackupExecutor {
+	postgresName := fmt.Sprintf("%s%s", constants.ThreeScalePostgresPrefix, r.installation.Name)
 	if r.installation.Spec.UseClusterStorage != "false" {
-		return backup.NewNoopBackupExecutor()
+		return backup.NewPreUpgradeHook(
+			backup.NewPostgresDumpBackupExecutor(r.installation.Namespace, postgresName),
+			r.installation,
+			productStatus,
+		)
 	}
 
-	return backup.NewConcurrentBackupExecutor(
-		backup.NewAWSBackupExecutor(
-			r.installation.Namespace,
-			"threescale-postgres-rhmi",
-			backup.PostgresSnapshotType,
-		),
-		backup.NewAWSBackupExecutor(
-			r.installation.Namespace,
-			"threescale-backend-redis-rhmi",
-			backup.RedisSnapshotType,
-		),
-		backup.NewAWSBackupExecutor(
-			r.installation.Namespace,
-			"threescale-redis-rhmi",
-			backup.RedisSnapshotType,
+	return backup.NewPreUpgradeHook(
+		backup.NewConcurrentBackupExecutor(
+			backup.NewAWSBackupExecutor(
+				r.installation.Namespace,
+				postgresName,
+				backup.PostgresSnapshotType,
+			),
+			backup.NewAWSBackupExecutor(
+				r.installation.Namespace,
+				fmt.Sprintf("%s%s", constants.ThreeScaleBackendRedisPrefix, r.installation.Name),
+				backup.RedisSnapshotType,
+			),
+			backup.NewAWSBackupExecutor(
+				r.installation.Namespace,
+				fmt.Sprintf("%s%s", constants.ThreeScaleSystemRedisPrefix, r.installation.Name),
+				backup.RedisSnapshotType,
+			),
 		),
+		r.installation,
+		productStatus,
 	)
 }
 
@@ -2805,7 +3020,7 @@ func (r *Reconciler) reconcileRouteEditRole(ctx context.Context, client k8sclien
 	return integreatlyv1alpha1.PhaseCompleted, nil
 }
 
-func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8sclient.Client, rhmi *integreatlyv1alpha1.RHMI, productNamespace string, operatorNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
+func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8sclient.Client, rhmi *integreatlyv1alpha1.RHMI, productStatus *integreatlyv1alpha1.RHMIProductStatus, productNamespace string, operatorNamespace string) (integreatlyv1alpha1.StatusPhase, error) {
 	target := marketplace.Target{
 		SubscriptionName: constants.ThreeScaleSubscriptionName,
 		Namespace:        operatorNamespace,
@@ -2825,7 +3040,7 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8s
 			ctx,
 			target,
 			[]string{productNamespace},
-			r.preUpgradeBackupExecutor(),
+			r.preUpgradeBackupExecutor(productStatus),
 			serverClient,
 			catalogSourceReconciler,
 			r.log,
@@ -2835,7 +3050,7 @@ func (r *Reconciler) reconcileSubscription(ctx context.Context, serverClient k8s
 		ctx,
 		target,
 		[]string{},
-		r.preUpgradeBackupExecutor(),
+		r.preUpgradeBackupExecutor(productStatus),
 		serverClient,
 		catalogSourceReconciler,
 		r.log,
@@ -2850,6 +3065,7 @@ func (r *Reconciler) reconcileConsoleLink(ctx context.Context, serverClient k8sc
 	}
 
 	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, cl, func() error {
+		resources.PrepareObjectLabels(cl, r.installation, false, false, false)
 		cl.Spec = consolev1.ConsoleLinkSpec{
 			ApplicationMenu: &consolev1.ApplicationMenuSpec{
 				ImageURL: threeScaleIcon,
@@ -2900,6 +3116,9 @@ func (r *Reconciler) reconcileDeploymentConfigs(ctx context.Context, serverClien
 			resources.SelectFromDeploymentConfig,
 			resources.AllMutationsOf(
 				resources.MutateZoneTopologySpreadConstraints("app"),
+				resources.MutatePlacement(r.installation.Spec.Placement),
+				podsecurity.Mutate(r.installation),
+				fips.Mutate(r.installation),
 			),
 			deploymentConfig,
 		)
@@ -3036,7 +3255,7 @@ func (r *Reconciler) reconcileRatelimitingTo3scaleComponents(ctx context.Context
 	}
 
 	// transport socket configuration
-	apicastTLSContext, err := ratelimit.CreateApicastTransportSocketConfig()
+	apicastTLSContext, err := ratelimit.CreateApicastTransportSocketConfig(fips.TLSParameters(r.installation))
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, err
 	}
@@ -3064,10 +3283,79 @@ func (r *Reconciler) reconcileRatelimitingTo3scaleComponents(ctx context.Context
 		}
 	}
 
+	now := time.Now()
+
+	// gateway JWT providers, the validation is skipped in maintenance mode as
+	// every request is answered with a 503
+	var jwksClusters []*envoyclusterv3.Cluster
+	var statsListeners []*envoylistenerv3.Listener
+	if len(installation.Spec.GatewayJWTProviders) > 0 && !installation.IsMaintenanceModeActive(now) {
+		if err := validateJWTProviders(installation.Spec.GatewayJWTProviders); err != nil {
+			r.log.Error("Invalid gateway JWT providers", err)
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		jwtFilter, err := getJWTHTTPFilter(installation.Spec.GatewayJWTProviders)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		apicastHTTPFilters = append([]*hcm.HttpFilter{jwtFilter}, apicastHTTPFilters...)
+		jwksClusters, err = getJWKSClusters(installation.Spec.GatewayJWTProviders, fips.TLSParameters(installation))
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		// the jwt_authn metrics are scraped from a stats only listener
+		statsCluster, statsListener, err := getEnvoyStatsResources()
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		jwksClusters = append(jwksClusters, statsCluster)
+		statsListeners = append(statsListeners, statsListener)
+	}
+
+	// gateway CORS policies, the previous envoy config is kept while they're
+	// invalid. The CORS filter runs first so preflight requests aren't
+	// rejected for missing a JWT
+	if len(installation.Spec.GatewayCORSPolicies) > 0 {
+		if err := validateCORSPolicies(installation.Spec.GatewayCORSPolicies); err != nil {
+			r.log.Error("Invalid gateway CORS policies", err)
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		corsFilter, err := getCORSHTTPFilter()
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		apicastHTTPFilters = append([]*hcm.HttpFilter{corsFilter}, apicastHTTPFilters...)
+	}
+
+	if err := validateTrafficSplits(installation.Spec.GatewayTrafficSplits); err != nil {
+		r.log.Error("Invalid gateway traffic splits", err)
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
 	// apicast listener
+	apiCastVirtualHosts, err := getAPICastVirtualHosts(installation, ApicastClusterName, now)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	if integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(installation.Spec.Type)) {
+		tenantRateLimits, err := ratelimit.GetTenantRateLimits(ctx, serverClient)
+		if err != nil {
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		setTenantRateLimits(apiCastVirtualHosts, tenantRateLimits)
+	}
+	apiCastTracing, err := getEnvoyTracing(installation, ApicastClusterName)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	tracingClusters, err := getTracingClusters(installation)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
 	apiCastFilters, err := getListenerResourceFilters(
-		getAPICastVirtualHosts(installation, ApicastClusterName),
+		apiCastVirtualHosts,
 		apicastHTTPFilters,
+		apiCastTracing,
 	)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, err
@@ -3084,12 +3372,38 @@ func (r *Reconciler) reconcileRatelimitingTo3scaleComponents(ctx context.Context
 	apiCastRuntimes := ratelimit.CreateRuntimesResource()
 
 	// create envoy config for apicast
-	apiCastProxyConfig := ratelimit.NewEnvoyConfig(ApicastClusterName, r.Config.GetNamespace(), ApicastNodeID)
-	err = apiCastProxyConfig.CreateEnvoyConfig(ctx, serverClient, []*envoyclusterv3.Cluster{apiCastClusterResource, ratelimitClusterResource}, []*envoylistenerv3.Listener{apiCastListenerResource}, apiCastRuntimes, installation)
+	canaryRollout, err := r.isEnvoyCanaryRolloutEnabled(ctx, serverClient)
 	if err != nil {
-		r.log.Errorf("Failed to create envoyconfig for apicast", l.Fields{"APICast": ApicastClusterName}, err)
 		return integreatlyv1alpha1.PhaseFailed, err
 	}
+	apiCastClusters := append([]*envoyclusterv3.Cluster{apiCastClusterResource, ratelimitClusterResource}, jwksClusters...)
+	apiCastClusters = append(apiCastClusters, tracingClusters...)
+	apiCastListeners := append([]*envoylistenerv3.Listener{apiCastListenerResource}, statsListeners...)
+	apiCastPhase := integreatlyv1alpha1.PhaseCompleted
+	// maintenance mode answers every request with a 503, so it can't be
+	// validated on the canary and is rolled out directly
+	if canaryRollout && !installation.IsMaintenanceModeActive(now) {
+		apiCastPhase, err = r.rolloutAPICastEnvoyConfig(ctx, serverClient, installation, apiCastClusters, apiCastListeners, apiCastRuntimes)
+		if err != nil {
+			r.log.Errorf("Failed to roll out envoyconfig for apicast", l.Fields{"APICast": ApicastClusterName}, err)
+			return apiCastPhase, err
+		}
+	} else {
+		apiCastProxyConfig := ratelimit.NewEnvoyConfig(ApicastClusterName, r.Config.GetNamespace(), ApicastNodeID)
+		err = apiCastProxyConfig.CreateEnvoyConfig(ctx, serverClient, apiCastClusters, apiCastListeners, apiCastRuntimes, installation)
+		if err != nil {
+			r.log.Errorf("Failed to create envoyconfig for apicast", l.Fields{"APICast": ApicastClusterName}, err)
+			return integreatlyv1alpha1.PhaseFailed, err
+		}
+		if phase, err := r.deleteAPICastCanary(ctx, serverClient, false); err != nil {
+			return phase, err
+		}
+	}
+	r.recordMaintenanceMode(installation, now)
+	if phase, err := r.reconcileJWTValidationMetrics(ctx, serverClient); err != nil {
+		r.log.Error("Failed to reconcile gateway JWT validation metrics", err)
+		return phase, err
+	}
 
 	// backend-listener cluster
 	backendClusterResource := ratelimit.CreateClusterResource(
@@ -3103,9 +3417,14 @@ func (r *Reconciler) reconcileRatelimitingTo3scaleComponents(ctx context.Context
 		return integreatlyv1alpha1.PhaseFailed, err
 	}
 	// backend listener listener
+	backendTracing, err := getEnvoyTracing(installation, BackendClusterName)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
 	backendFilters, err := getListenerResourceFilters(
 		getBackendListenerVitualHosts(BackendClusterName),
 		backendHTTPFilters,
+		backendTracing,
 	)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, err
@@ -3122,13 +3441,37 @@ func (r *Reconciler) reconcileRatelimitingTo3scaleComponents(ctx context.Context
 
 	// create envoy config for backend listener
 	backendProxyConfig := ratelimit.NewEnvoyConfig(BackendClusterName, r.Config.GetNamespace(), BackendNodeID)
-	err = backendProxyConfig.CreateEnvoyConfig(ctx, serverClient, []*envoyclusterv3.Cluster{backendClusterResource, ratelimitClusterResource}, []*envoylistenerv3.Listener{backendListenerResource}, backendRuntimes, installation)
+	backendClusters := append([]*envoyclusterv3.Cluster{backendClusterResource, ratelimitClusterResource}, tracingClusters...)
+	err = backendProxyConfig.CreateEnvoyConfig(ctx, serverClient, backendClusters, []*envoylistenerv3.Listener{backendListenerResource}, backendRuntimes, installation)
 	if err != nil {
 		r.log.Errorf("Failed to create envoyconfig for backend-listener", l.Fields{"BackendListener": BackendClusterName}, err)
 		return integreatlyv1alpha1.PhaseFailed, err
 	}
 
-	return integreatlyv1alpha1.PhaseCompleted, nil
+	return apiCastPhase, nil
+}
+
+// recordMaintenanceMode keeps an audit record of the gateways entering and
+// leaving maintenance mode in the RHMI conditions and events
+func (r *Reconciler) recordMaintenanceMode(installation *integreatlyv1alpha1.RHMI, now time.Time) {
+	wasActive := meta.IsStatusConditionTrue(installation.Status.Conditions, integreatlyv1alpha1.MaintenanceModeConditionType.String())
+	active := installation.IsMaintenanceModeActive(now)
+
+	if active {
+		condition := installation.MaintenanceModeActiveCondition()
+		meta.SetStatusCondition(&installation.Status.Conditions, condition)
+		if !wasActive {
+			r.log.Infof("Gateways entered maintenance mode", l.Fields{"until": installation.Spec.MaintenanceMode.Until})
+			r.recorder.Event(installation, "Normal", integreatlyv1alpha1.EventMaintenanceModeOn, condition.Message)
+		}
+		return
+	}
+
+	if wasActive {
+		r.log.Info("Gateways left maintenance mode")
+		meta.SetStatusCondition(&installation.Status.Conditions, installation.MaintenanceModeInactiveCondition())
+		r.recorder.Event(installation, "Normal", integreatlyv1alpha1.EventMaintenanceModeOff, "Customer APIs available")
+	}
 }
 
 func (r *Reconciler) getRateLimitServiceCR(ctx context.Context, serverClient k8sclient.Client) (*corev1.Service, error) {
@@ -3432,7 +3775,7 @@ func checkRedirects(host string, path string, res *http.Response, statusCode int
 
 func (r *Reconciler) getKeycloakUserFromAccount(client k8sclient.Client, accountName string) (*keycloak.KeycloakUser, error) {
 	kcUserList := &keycloak.KeycloakUserList{}
-	if err := client.List(context.TODO(), kcUserList, k8sclient.InNamespace(fmt.Sprintf("%srhsso", r.installation.Spec.NamespacePrefix))); err != nil {
+	if err := client.List(context.TODO(), kcUserList, k8sclient.InNamespace(r.installation.ProductNamespace(string(integreatlyv1alpha1.ProductRHSSO)))); err != nil {
 		return nil, fmt.Errorf("failed to get list of KeycloakUsers, err: %v", err)
 	}
 	for _, kcUser := range kcUserList.Items {
@@ -3447,7 +3790,7 @@ func (r *Reconciler) getKeycloakUserFromAccount(client k8sclient.Client, account
 
 func (r *Reconciler) getKeycloakClientFromAccount(client k8sclient.Client, accountName string) (*keycloak.KeycloakClient, error) {
 	kcClientList := &keycloak.KeycloakClientList{}
-	if err := client.List(context.TODO(), kcClientList, k8sclient.InNamespace(fmt.Sprintf("%srhsso", r.installation.Spec.NamespacePrefix))); err != nil {
+	if err := client.List(context.TODO(), kcClientList, k8sclient.InNamespace(r.installation.ProductNamespace(string(integreatlyv1alpha1.ProductRHSSO)))); err != nil {
 		return nil, fmt.Errorf("failed to get list of KeycloakClients, err: %v", err)
 	}
 	for _, kcClient := range kcClientList.Items {
@@ -3487,6 +3830,7 @@ func (r *Reconciler) reconcileServiceMonitor(ctx context.Context, client k8sclie
 				},
 			},
 		}
+		resources.SetMetricRelabelConfigs(serviceMonitor.Spec.Endpoints, r.Config.GetProductName(), r.installation.Spec.Type)
 		return nil
 	})
 
@@ -3702,3 +4046,83 @@ func (r *Reconciler) reconcileTenantOutgoingEmailAddress(ctx context.Context, se
 	}
 	return nil
 }
+
+// verifyRoutes resolves the hosts of the 3scale routes, including the tenant
+// routes, and checks the certificates they serve. Problems are reported in the
+// RoutesVerified condition instead of failing the reconcile, as they're usually
+// caused by DNS propagation outside of the operator's control
+// reconcileCustomRoutes serves the master, admin and developer portals of the
+// default tenant on the hostnames of the custom domain spec
+func (r *Reconciler) reconcileCustomRoutes(ctx context.Context, serverClient k8sclient.Client, resolve customDomain.HostResolver) error {
+	portals := []struct {
+		name   integreatlyv1alpha1.CustomRouteName
+		label  string
+		filter func(r routev1.Route) bool
+	}{
+		{integreatlyv1alpha1.CustomRouteThreeScaleMaster, labelRouteToSystemMaster, nil},
+		{integreatlyv1alpha1.CustomRouteThreeScaleAdmin, labelRouteToSystemProvider, func(r routev1.Route) bool {
+			return strings.HasPrefix(r.Spec.Host, "3scale-admin.")
+		}},
+		{integreatlyv1alpha1.CustomRouteThreeScaleDeveloper, labelRouteToSystemDeveloper, func(r routev1.Route) bool {
+			return strings.HasPrefix(r.Spec.Host, "3scale.")
+		}},
+	}
+
+	for _, portal := range portals {
+		route, err := r.getThreescaleRoute(ctx, serverClient, portal.label, portal.filter)
+		if err != nil {
+			return err
+		}
+		// the custom route is created once zync creates the portal route
+		if route == nil {
+			continue
+		}
+		if err := customDomain.ReconcileCustomRoute(ctx, serverClient, r.installation, portal.name, route, resolve); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyRoutes resolves the hosts of the 3scale routes, including the tenant
+// routes, and checks the certificates they serve. Problems are reported in the
+// RoutesVerified condition instead of failing the reconcile, as they're usually
+// caused by DNS propagation outside of the operator's control. The routes are
+// verified in the background at most once per interval, the last result is
+// kept in the status
+func (r *Reconciler) verifyRoutes(ctx context.Context, serverClient k8sclient.Client) error {
+	if r.newRouteVerifier == nil || r.routeVerifications == nil {
+		return nil
+	}
+
+	externalResolver, _, err := addon.GetStringParameter(ctx, serverClient, r.installation.Namespace, routeverification.ExternalResolverParam)
+	if err != nil && !k8serr.IsNotFound(err) {
+		return fmt.Errorf("failed to retrieve %s addon parameter: %w", routeverification.ExternalResolverParam, err)
+	}
+
+	routes := &routev1.RouteList{}
+	if err := serverClient.List(ctx, routes, k8sclient.InNamespace(r.Config.GetNamespace())); err != nil {
+		return fmt.Errorf("failed to list 3scale routes: %w", err)
+	}
+
+	verifier := r.newRouteVerifier(externalResolver)
+	result, ok := r.routeVerifications.Verify(fmt.Sprintf("%s/%s", r.Config.GetNamespace(), r.Config.GetProductName()), func(ctx context.Context) []string {
+		return verifier.VerifyRoutes(ctx, routes.Items)
+	})
+	if !ok {
+		return nil
+	}
+
+	r.installation.Status.RouteVerification = &integreatlyv1alpha1.RouteVerificationStatus{
+		LastVerified: metav1.NewTime(result.Time),
+		Problems:     result.Problems,
+	}
+	if len(result.Problems) > 0 {
+		r.log.Warningf("3scale routes failed verification", l.Fields{"problems": result.Problems})
+		meta.SetStatusCondition(&r.installation.Status.Conditions, r.installation.RoutesUnverifiedCondition(strings.Join(result.Problems, "; ")))
+		return nil
+	}
+
+	meta.SetStatusCondition(&r.installation.Status.Conditions, r.installation.RoutesVerifiedCondition())
+	return nil
+}
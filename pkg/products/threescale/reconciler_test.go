@@ -31,6 +31,7 @@ import (
 	moqclient "github.com/integr8ly/integreatly-operator/pkg/client"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/rotation"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 
 	threescalev1 "github.com/3scale/3scale-operator/apis/apps/v1alpha1"
@@ -3682,10 +3683,14 @@ func TestReconciler_createStsS3Secret(t *testing.T) {
 		blobStorageSec *corev1.Secret
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name            string
+		fields          fields
+		args            args
+		rolloutErr      error
+		wantErr         bool
+		wantKeysRemoved bool
+		wantRollout     bool
+		wantRolloutMark bool
 	}{
 		{
 			name: "test unable to get secret",
@@ -3733,16 +3738,144 @@ func TestReconciler_createStsS3Secret(t *testing.T) {
 				}),
 			},
 		},
+		{
+			name: "test static access keys are removed from existing s3 secret",
+			args: args{
+				ctx: context.TODO(),
+				serverClient: utils.NewTestClient(scheme, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      stsS3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+					Data: map[string][]byte{
+						"role_arn": []byte("roleArn"),
+					},
+				}, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      s3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+					Data: map[string][]byte{
+						threescaleAmp.AwsAccessKeyID:     []byte("keyID"),
+						threescaleAmp.AwsSecretAccessKey: []byte("secretKey"),
+					},
+				}),
+				credSec: &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      s3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+				},
+				blobStorageSec: &corev1.Secret{
+					Data: map[string][]byte{
+						"bucketName":   []byte("bucket"),
+						"bucketRegion": []byte("region"),
+					},
+				},
+			},
+			fields: fields{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				log: getLogger(),
+			},
+			wantKeysRemoved: true,
+			wantRollout:     true,
+		},
+		{
+			name: "test rollout failure is returned and retried after static access keys are removed",
+			args: args{
+				ctx: context.TODO(),
+				serverClient: utils.NewTestClient(scheme, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      stsS3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+					Data: map[string][]byte{
+						"role_arn": []byte("roleArn"),
+					},
+				}, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      s3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+					Data: map[string][]byte{
+						threescaleAmp.AwsAccessKeyID:     []byte("keyID"),
+						threescaleAmp.AwsSecretAccessKey: []byte("secretKey"),
+					},
+				}),
+				credSec: &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      s3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+				},
+				blobStorageSec: &corev1.Secret{},
+			},
+			fields: fields{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				log: getLogger(),
+			},
+			rolloutErr:      fmt.Errorf("deploymentconfig instantiate failed"),
+			wantErr:         true,
+			wantKeysRemoved: true,
+			wantRolloutMark: true,
+		},
+		{
+			name: "test pending rollout is retried once static access keys are already removed",
+			args: args{
+				ctx: context.TODO(),
+				serverClient: utils.NewTestClient(scheme, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      stsS3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+					Data: map[string][]byte{
+						"role_arn": []byte("roleArn"),
+					},
+				}, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        s3CredentialsSecretName,
+						Namespace:   defaultInstallationNamespace,
+						Annotations: map[string]string{s3CredentialsRolloutAnnotation: "true"},
+					},
+					Data: map[string][]byte{
+						threescaleAmp.AwsRoleArn: []byte("roleArn"),
+					},
+				}),
+				credSec: &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      s3CredentialsSecretName,
+						Namespace: defaultInstallationNamespace,
+					},
+				},
+				blobStorageSec: &corev1.Secret{},
+			},
+			fields: fields{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				log: getLogger(),
+			},
+			wantKeysRemoved: true,
+			wantRollout:     true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			appsv1Client, rolledOut := getRolloutAppsV1Client(tt.rolloutErr)
+			if tt.fields.appsv1Client != nil {
+				appsv1Client = tt.fields.appsv1Client
+			}
 			r := &Reconciler{
 				ConfigManager: tt.fields.ConfigManager,
 				Config:        tt.fields.Config,
 				mpm:           tt.fields.mpm,
 				installation:  tt.fields.installation,
 				tsClient:      tt.fields.tsClient,
-				appsv1Client:  tt.fields.appsv1Client,
+				appsv1Client:  appsv1Client,
 				oauthv1Client: tt.fields.oauthv1Client,
 				Reconciler:    tt.fields.Reconciler,
 				extraParams:   tt.fields.extraParams,
@@ -3752,6 +3885,30 @@ func TestReconciler_createStsS3Secret(t *testing.T) {
 			if err := r.createStsS3Secret(tt.args.ctx, tt.args.serverClient, tt.args.credSec, tt.args.blobStorageSec); (err != nil) != tt.wantErr {
 				t.Errorf("createStsS3Secret() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.wantKeysRemoved {
+				credSec := &corev1.Secret{}
+				if err := tt.args.serverClient.Get(tt.args.ctx, k8sclient.ObjectKey{Name: s3CredentialsSecretName, Namespace: defaultInstallationNamespace}, credSec); err != nil {
+					t.Fatalf("failed to get s3 credentials secret: %v", err)
+				}
+				for _, key := range []string{threescaleAmp.AwsAccessKeyID, threescaleAmp.AwsSecretAccessKey} {
+					if _, ok := credSec.Data[key]; ok {
+						t.Errorf("expected %s to be removed from s3 credentials secret", key)
+					}
+				}
+				if string(credSec.Data[threescaleAmp.AwsRoleArn]) != "roleArn" {
+					t.Errorf("expected role arn to be set, got %s", credSec.Data[threescaleAmp.AwsRoleArn])
+				}
+				if _, ok := credSec.Annotations[s3CredentialsRolloutAnnotation]; ok != tt.wantRolloutMark {
+					t.Errorf("expected rollout annotation set to be %v, got %v", tt.wantRolloutMark, ok)
+				}
+			}
+			wantRolledOut := []string{}
+			if tt.wantRollout {
+				wantRolledOut = []string{"system-app", "system-sidekiq"}
+			}
+			if !reflect.DeepEqual(*rolledOut, wantRolledOut) {
+				t.Errorf("expected deployments %v to be rolled out, got %v", wantRolledOut, *rolledOut)
+			}
 		})
 	}
 }
@@ -4636,8 +4793,9 @@ func TestReconciler_reconcileDashboardLink(t *testing.T) {
 		{
 			name: "Success reconciling console link",
 			fields: fields{
-				Config: config.NewThreeScale(config.ProductConfig{}),
-				log:    getLogger(),
+				Config:       config.NewThreeScale(config.ProductConfig{}),
+				installation: &integreatlyv1alpha1.RHMI{},
+				log:          getLogger(),
 			},
 			args: args{
 				ctx:          context.TODO(),
@@ -4650,8 +4808,9 @@ func TestReconciler_reconcileDashboardLink(t *testing.T) {
 		{
 			name: "Failure reconciling console link",
 			fields: fields{
-				Config: config.NewThreeScale(config.ProductConfig{}),
-				log:    getLogger(),
+				Config:       config.NewThreeScale(config.ProductConfig{}),
+				installation: &integreatlyv1alpha1.RHMI{},
+				log:          getLogger(),
 			},
 			args: args{
 				ctx: context.TODO(),
@@ -4799,3 +4958,132 @@ func TestIsQuotaChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciler_reconcileSecretRotation(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := utils.NewTestManagedApiInstallation()
+	installation.Spec.SMTPSecret = utils.TestNamespacePrefix + "smtp"
+
+	seed := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: systemSeedSecretName, Namespace: defaultInstallationNamespace, Annotations: annotations},
+			Data: map[string][]byte{
+				"ADMIN_USER":         []byte("admin"),
+				"ADMIN_PASSWORD":     []byte("admin-password"),
+				"ADMIN_ACCESS_TOKEN": []byte("token"),
+			},
+		}
+	}
+	systemSMTP := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: systemSMTPSecretName, Namespace: defaultInstallationNamespace, Annotations: annotations},
+			Data:       map[string][]byte{"address": []byte("smtp.example.com"), "password": []byte("old-password")},
+		}
+	}
+	smtpSource := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: installation.Spec.SMTPSecret, Namespace: installation.Namespace},
+		Data:       map[string][]byte{"host": []byte("smtp.example.com"), "password": []byte("new-password")},
+	}
+	rotate := map[string]string{rotation.RotateAnnotation: "true"}
+
+	tests := []struct {
+		name                string
+		initObjs            []runtime.Object
+		want                integreatlyv1alpha1.StatusPhase
+		wantErr             bool
+		wantRotated         []string
+		wantPasswordUpdates int
+		wantSMTPPassword    string
+	}{
+		{
+			name:                "test admin rotation rolls out the system deployments",
+			initObjs:            []runtime.Object{seed(rotate), systemSMTP(nil), smtpSource},
+			want:                integreatlyv1alpha1.PhaseCompleted,
+			wantRotated:         []string{"3scale-admin"},
+			wantPasswordUpdates: 1,
+			wantSMTPPassword:    "old-password",
+		},
+		{
+			name:             "test smtp copy is refreshed and rolls out the system deployments",
+			initObjs:         []runtime.Object{seed(nil), systemSMTP(rotate), smtpSource},
+			want:             integreatlyv1alpha1.PhaseCompleted,
+			wantRotated:      []string{"3scale-smtp"},
+			wantSMTPPassword: "new-password",
+		},
+		{
+			name:             "test smtp copy is kept when the smtp credentials are missing",
+			initObjs:         []runtime.Object{seed(nil), systemSMTP(rotate)},
+			want:             integreatlyv1alpha1.PhaseFailed,
+			wantErr:          true,
+			wantSMTPPassword: "old-password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependents := []runtime.Object{}
+			for _, name := range []string{systemAppDCName, "system-sidekiq"} {
+				dependents = append(dependents, &openshiftappsv1.DeploymentConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultInstallationNamespace},
+					Spec:       openshiftappsv1.DeploymentConfigSpec{Template: &corev1.PodTemplateSpec{}},
+				})
+			}
+			serverClient := utils.NewTestClient(scheme, append(tt.initObjs, dependents...)...)
+			tsClient := &ThreeScaleInterfaceMock{
+				GetUserFunc: func(username string, accessToken string) (*User, error) {
+					return &User{UserDetails: UserDetails{Id: 1, Username: username}}, nil
+				},
+				UpdateUserPasswordFunc: func(accessToken string, userID int, password string) error {
+					return nil
+				},
+			}
+			r := &Reconciler{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				installation: installation,
+				tsClient:     tsClient,
+				log:          getLogger(),
+			}
+
+			got, err := r.reconcileSecretRotation(context.TODO(), serverClient, &integreatlyv1alpha1.RHMIProductStatus{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileSecretRotation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("reconcileSecretRotation() got = %v, want %v", got, tt.want)
+			}
+
+			smtp := &corev1.Secret{}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: systemSMTPSecretName, Namespace: defaultInstallationNamespace}, smtp); err != nil {
+				t.Fatal(err)
+			}
+			if string(smtp.Data["password"]) != tt.wantSMTPPassword {
+				t.Errorf("expected smtp password %s, got %s", tt.wantSMTPPassword, smtp.Data["password"])
+			}
+
+			for _, name := range []string{systemAppDCName, "system-sidekiq"} {
+				dc := &openshiftappsv1.DeploymentConfig{}
+				if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: defaultInstallationNamespace}, dc); err != nil {
+					t.Fatal(err)
+				}
+				var rotated []string
+				for _, credential := range []string{"3scale-admin", "3scale-smtp"} {
+					if _, ok := dc.Spec.Template.Annotations[rotation.RotatedAtAnnotation+"-"+credential]; ok {
+						rotated = append(rotated, credential)
+					}
+				}
+				if !reflect.DeepEqual(rotated, tt.wantRotated) {
+					t.Errorf("expected %s to be rolled out for %v, got %v", name, tt.wantRotated, rotated)
+				}
+			}
+			if calls := len(tsClient.UpdateUserPasswordCalls()); calls != tt.wantPasswordUpdates {
+				t.Errorf("expected %d admin password updates in 3scale, got %d", tt.wantPasswordUpdates, calls)
+			}
+		})
+	}
+}
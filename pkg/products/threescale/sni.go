@@ -0,0 +1,387 @@
+package threescale
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/addon"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// TenantGatewaySNIParam is the addon parameter that enables serving the
+	// tenant gateways through a single SNI passthrough route
+	TenantGatewaySNIParam = "tenant-gateway-sni"
+
+	tenantGatewaySNIRouteName  = "tenant-gateway-sni"
+	tenantGatewayTLSSecretName = "tenant-gateway-sni-tls"
+	tenantGatewayCASecretName  = "tenant-gateway-sni-ca"
+	tenantGatewayCACertKey     = "ca.crt"
+	tenantGatewayCAKeyKey      = "ca.key"
+	// tenantGatewayPreviousCAKey keeps the rotated CA in the bundle until it
+	// expires, so the certificates it signed are trusted until they're renewed
+	tenantGatewayPreviousCAKey   = "previous-ca.crt"
+	tenantGatewayCABundleName    = "tenant-gateway-sni-ca-bundle"
+	tenantGatewayCABundleKey     = "ca-bundle.crt"
+	tenantGatewaySubdomainPrefix = "gateway"
+
+	tenantGatewayCAValidity   = 2 * 365 * 24 * time.Hour
+	tenantGatewayCARenewal    = 90 * 24 * time.Hour
+	tenantGatewayCertValidity = 365 * 24 * time.Hour
+	tenantGatewayCertRenewal  = 30 * 24 * time.Hour
+)
+
+// isTenantGatewaySNIEnabled returns whether the tenant gateways are served
+// through the shared SNI route. It's disabled when the addon parameters secret
+// doesn't exist
+func (r *Reconciler) isTenantGatewaySNIEnabled(ctx context.Context, serverClient k8sclient.Client) (bool, error) {
+	if !integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(r.installation.Spec.Type)) {
+		return false, nil
+	}
+
+	enabled, ok, err := addon.GetBoolParameter(ctx, serverClient, r.installation.Namespace, TenantGatewaySNIParam)
+	if k8serr.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve %s addon parameter: %w", TenantGatewaySNIParam, err)
+	}
+
+	return ok && enabled, nil
+}
+
+// tenantGatewaySNIDomain is the domain under which every tenant gateway is
+// reachable when SNI routing is enabled, e.g. <tenant>.gateway.apps.example.com
+func (r *Reconciler) tenantGatewaySNIDomain() string {
+	return fmt.Sprintf("%s.%s", tenantGatewaySubdomainPrefix, r.installation.Spec.RoutingSubdomain)
+}
+
+// reconcileTenantGatewaySNI serves the tenant gateways of a multitenant
+// installation through a single wildcard passthrough route to APIcast
+// production, instead of a route per tenant. APIcast terminates TLS using a
+// certificate signed by an operator managed CA, which is published with the
+// CA it rotated in the tenantGatewayCABundleName ConfigMap so clients can
+// trust it. Wildcard routes
+// must be allowed by the ingress controller for the route to be admitted
+func (r *Reconciler) reconcileTenantGatewaySNI(ctx context.Context, serverClient k8sclient.Client) (integreatlyv1alpha1.StatusPhase, error) {
+	ns := r.Config.GetNamespace()
+
+	enabled, err := r.isTenantGatewaySNIEnabled(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+	if !enabled {
+		for _, obj := range []k8sclient.Object{
+			&routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: tenantGatewaySNIRouteName, Namespace: ns}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: tenantGatewayCABundleName, Namespace: ns}},
+		} {
+			if phase, err := k8s.EnsureObjectDeleted(ctx, serverClient, obj); err != nil || phase != integreatlyv1alpha1.PhaseCompleted {
+				return phase, err
+			}
+		}
+		return integreatlyv1alpha1.PhaseCompleted, nil
+	}
+
+	caBundlePEM, err := r.reconcileTenantGatewayCertificate(ctx, serverClient)
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, err
+	}
+
+	caBundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantGatewayCABundleName,
+			Namespace: ns,
+		},
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, caBundle, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(caBundle, r.installation)
+		caBundle.Data = map[string]string{tenantGatewayCABundleKey: string(caBundlePEM)}
+		return nil
+	}); err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile tenant gateway CA bundle: %w", err)
+	}
+
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantGatewaySNIRouteName,
+			Namespace: ns,
+		},
+	}
+	or, err := controllerutil.CreateOrUpdate(ctx, serverClient, route, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(route, r.installation)
+		route.Spec = routev1.RouteSpec{
+			Host: fmt.Sprintf("wildcard.%s", r.tenantGatewaySNIDomain()),
+			To: routev1.RouteTargetReference{
+				Kind: "Service",
+				Name: apicastProductionDCName,
+			},
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromString("httpsproxy"),
+			},
+			TLS: &routev1.TLSConfig{
+				Termination: routev1.TLSTerminationPassthrough,
+			},
+			WildcardPolicy: routev1.WildcardPolicySubdomain,
+		}
+		return nil
+	})
+	if err != nil {
+		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to reconcile tenant gateway SNI route: %w", err)
+	}
+	r.log.Infof("Operation Result creating route", l.Fields{"route": route.Name, "result": or})
+
+	return integreatlyv1alpha1.PhaseCompleted, nil
+}
+
+// tenantGatewayCA is the operator managed CA signing the tenant gateway
+// certificate
+type tenantGatewayCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+}
+
+// reconcileTenantGatewayCA ensures the tenantGatewayCASecretName secret holds
+// a CA valid beyond the renewal period and returns it with the PEM encoded
+// bundle of the trusted CAs. The CA is rotated on its own schedule, the
+// previous CA stays in the bundle until it expires
+func (r *Reconciler) reconcileTenantGatewayCA(ctx context.Context, serverClient k8sclient.Client, now time.Time) (*tenantGatewayCA, []byte, error) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantGatewayCASecretName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+
+	var ca *tenantGatewayCA
+	_, err := controllerutil.CreateOrUpdate(ctx, serverClient, secret, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(secret, r.installation)
+		secret.Type = corev1.SecretTypeOpaque
+
+		current, err := parseTenantGatewayCA(secret.Data)
+		if err == nil && now.Add(tenantGatewayCARenewal).Before(current.cert.NotAfter) {
+			ca = current
+			if !isCertificatePEMValid(secret.Data[tenantGatewayPreviousCAKey], now) {
+				delete(secret.Data, tenantGatewayPreviousCAKey)
+			}
+			return nil
+		}
+
+		r.log.Info("Issuing tenant gateway CA")
+		certPEM, keyPEM, err := issueTenantGatewayCA(now)
+		if err != nil {
+			return err
+		}
+		data := map[string][]byte{
+			tenantGatewayCACertKey: certPEM,
+			tenantGatewayCAKeyKey:  keyPEM,
+		}
+		if current != nil && now.Before(current.cert.NotAfter) {
+			data[tenantGatewayPreviousCAKey] = current.certPEM
+		}
+		secret.Data = data
+		ca, err = parseTenantGatewayCA(data)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reconcile tenant gateway CA: %w", err)
+	}
+
+	bundle := append(append([]byte{}, secret.Data[tenantGatewayCACertKey]...), secret.Data[tenantGatewayPreviousCAKey]...)
+	return ca, bundle, nil
+}
+
+// reconcileTenantGatewayCertificate ensures the tenantGatewayTLSSecretName
+// secret holds a valid wildcard certificate for the tenant gateway domain and
+// returns the PEM encoded bundle of the trusted CAs. Only the certificate is
+// reissued, signed by the current CA, when it's close to expiry, the domain
+// changes or none of the trusted CAs signed it
+func (r *Reconciler) reconcileTenantGatewayCertificate(ctx context.Context, serverClient k8sclient.Client) ([]byte, error) {
+	now := time.Now()
+	ca, bundle, err := r.reconcileTenantGatewayCA(ctx, serverClient, now)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := r.tenantGatewaySNIDomain()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantGatewayTLSSecretName,
+			Namespace: r.Config.GetNamespace(),
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, serverClient, secret, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(secret, r.installation)
+		secret.Type = corev1.SecretTypeTLS
+
+		if isTenantGatewayCertificateValid(secret.Data, domain, bundle, now) {
+			return nil
+		}
+
+		r.log.Infof("Issuing tenant gateway certificate", l.Fields{"domain": domain})
+		certPEM, keyPEM, err := issueTenantGatewayCertificate(domain, ca, now)
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			corev1.TLSCertKey:       append(certPEM, ca.certPEM...),
+			corev1.TLSPrivateKeyKey: keyPEM,
+			tenantGatewayCACertKey:  ca.certPEM,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile tenant gateway certificate: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// isTenantGatewayCertificateValid returns whether the certificate of data is
+// issued for domain by one of the CAs of bundle and isn't close to expiry
+func isTenantGatewayCertificateValid(data map[string][]byte, domain string, bundle []byte, now time.Time) bool {
+	if len(data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false
+	}
+	block, _ := pem.Decode(data[corev1.TLSCertKey])
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return false
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		DNSName:     fmt.Sprintf("tenant.%s", domain),
+		Roots:       roots,
+		CurrentTime: now,
+	}); err != nil {
+		return false
+	}
+
+	return now.Add(tenantGatewayCertRenewal).Before(cert.NotAfter)
+}
+
+// isCertificatePEMValid returns whether the PEM encoded certificate hasn't
+// expired yet
+func isCertificatePEMValid(certPEM []byte, now time.Time) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return now.Before(cert.NotAfter)
+}
+
+// parseTenantGatewayCA returns the CA stored in the data of the CA secret
+func parseTenantGatewayCA(data map[string][]byte) (*tenantGatewayCA, error) {
+	certBlock, _ := pem.Decode(data[tenantGatewayCACertKey])
+	keyBlock, _ := pem.Decode(data[tenantGatewayCAKeyKey])
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("tenant gateway CA not found")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tenant gateway CA: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tenant gateway CA key: %w", err)
+	}
+	return &tenantGatewayCA{cert: cert, key: key, certPEM: data[tenantGatewayCACertKey]}, nil
+}
+
+// issueTenantGatewayCA creates a new CA for the tenant gateway certificates.
+// It returns the PEM encoded CA certificate and its key
+func issueTenantGatewayCA(now time.Time) ([]byte, []byte, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate tenant gateway CA key: %w", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: "tenant-gateway-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(tenantGatewayCAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create tenant gateway CA: %w", err)
+	}
+
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal tenant gateway CA key: %w", err)
+	}
+
+	return encodePEM("CERTIFICATE", caDER), encodePEM("EC PRIVATE KEY", caKeyDER), nil
+}
+
+// issueTenantGatewayCertificate uses ca to sign a wildcard certificate for
+// domain, expiring no later than the CA. It returns the PEM encoded
+// certificate and its key
+func issueTenantGatewayCertificate(domain string, ca *tenantGatewayCA, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate tenant gateway key: %w", err)
+	}
+	notAfter := now.Add(tenantGatewayCertValidity)
+	if ca.cert.NotAfter.Before(notAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano() + 1),
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("*.%s", domain)},
+		DNSNames:     []string{fmt.Sprintf("*.%s", domain)},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create tenant gateway certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal tenant gateway key: %w", err)
+	}
+
+	return encodePEM("CERTIFICATE", certDER), encodePEM("EC PRIVATE KEY", keyDER), nil
+}
+
+func encodePEM(blockType string, der []byte) []byte {
+	buf := &bytes.Buffer{}
+	_ = pem.Encode(buf, &pem.Block{Type: blockType, Bytes: der})
+	return buf.Bytes()
+}
@@ -0,0 +1,281 @@
+package threescale
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/utils"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconciler_reconcileTenantGatewaySNI(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rhoam",
+			Namespace: "redhat-rhoam-operator",
+		},
+		Spec: integreatlyv1alpha1.RHMISpec{
+			Type:             string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+			RoutingSubdomain: "apps.example.com",
+		},
+	}
+	parameters := func(enabled string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "addon-managed-api-service-parameters",
+				Namespace: installation.Namespace,
+			},
+			Data: map[string][]byte{
+				TenantGatewaySNIParam: []byte(enabled),
+			},
+		}
+	}
+	existingRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantGatewaySNIRouteName,
+			Namespace: defaultInstallationNamespace,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		initObjs  []runtime.Object
+		wantRoute bool
+		wantPhase integreatlyv1alpha1.StatusPhase
+	}{
+		{
+			name:      "test SNI route is not created without addon parameters",
+			initObjs:  []runtime.Object{},
+			wantPhase: integreatlyv1alpha1.PhaseCompleted,
+		},
+		{
+			name:      "test SNI route is removed when disabled",
+			initObjs:  []runtime.Object{parameters("false"), existingRoute},
+			wantPhase: integreatlyv1alpha1.PhaseInProgress,
+		},
+		{
+			name:      "test SNI route and certificate bundle are created when enabled",
+			initObjs:  []runtime.Object{parameters("true")},
+			wantRoute: true,
+			wantPhase: integreatlyv1alpha1.PhaseCompleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			r := &Reconciler{
+				Config: config.NewThreeScale(config.ProductConfig{
+					"NAMESPACE": defaultInstallationNamespace,
+				}),
+				installation: installation,
+				log:          getLogger(),
+			}
+
+			phase, err := r.reconcileTenantGatewaySNI(context.TODO(), client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if phase != tt.wantPhase {
+				t.Fatalf("expected phase %s, got %s", tt.wantPhase, phase)
+			}
+
+			route := &routev1.Route{}
+			err = client.Get(context.TODO(), k8sclient.ObjectKey{Name: tenantGatewaySNIRouteName, Namespace: defaultInstallationNamespace}, route)
+			if !tt.wantRoute {
+				if !k8serr.IsNotFound(err) {
+					t.Fatalf("expected SNI route not to exist, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get SNI route: %v", err)
+			}
+			if route.Spec.Host != "wildcard.gateway.apps.example.com" || route.Spec.WildcardPolicy != routev1.WildcardPolicySubdomain || route.Spec.TLS.Termination != routev1.TLSTerminationPassthrough {
+				t.Fatalf("unexpected SNI route spec: %+v", route.Spec)
+			}
+
+			secret := &corev1.Secret{}
+			if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: tenantGatewayTLSSecretName, Namespace: defaultInstallationNamespace}, secret); err != nil {
+				t.Fatalf("failed to get tenant gateway certificate: %v", err)
+			}
+
+			caBundle := &corev1.ConfigMap{}
+			if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: tenantGatewayCABundleName, Namespace: defaultInstallationNamespace}, caBundle); err != nil {
+				t.Fatalf("failed to get tenant gateway CA bundle: %v", err)
+			}
+			if !isTenantGatewayCertificateValid(secret.Data, r.tenantGatewaySNIDomain(), []byte(caBundle.Data[tenantGatewayCABundleKey]), time.Now()) {
+				t.Fatal("expected a valid tenant gateway certificate")
+			}
+			if caBundle.Data[tenantGatewayCABundleKey] != string(secret.Data["ca.crt"]) {
+				t.Fatal("expected CA bundle to contain the certificate CA")
+			}
+
+			// The certificate is kept while it's valid
+			if _, err := r.reconcileTenantGatewaySNI(context.TODO(), client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated := &corev1.Secret{}
+			if err := client.Get(context.TODO(), k8sclient.ObjectKeyFromObject(secret), updated); err != nil {
+				t.Fatal(err)
+			}
+			if string(updated.Data[corev1.TLSCertKey]) != string(secret.Data[corev1.TLSCertKey]) {
+				t.Fatal("expected tenant gateway certificate not to be reissued")
+			}
+
+			// Only the certificate is reissued when the domain changes, the CA
+			// is kept
+			r.installation = installation.DeepCopy()
+			r.installation.Spec.RoutingSubdomain = "apps.other.com"
+			if _, err := r.reconcileTenantGatewaySNI(context.TODO(), client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := client.Get(context.TODO(), k8sclient.ObjectKeyFromObject(secret), updated); err != nil {
+				t.Fatal(err)
+			}
+			if string(updated.Data[corev1.TLSCertKey]) == string(secret.Data[corev1.TLSCertKey]) || string(updated.Data[tenantGatewayCACertKey]) != string(secret.Data[tenantGatewayCACertKey]) {
+				t.Fatal("expected tenant gateway certificate to be signed again by the same CA")
+			}
+		})
+	}
+}
+
+func TestReconciler_reconcileTenantGatewayCA(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := utils.NewTestClient(scheme)
+	r := &Reconciler{
+		Config: config.NewThreeScale(config.ProductConfig{
+			"NAMESPACE": defaultInstallationNamespace,
+		}),
+		installation: &integreatlyv1alpha1.RHMI{},
+		log:          getLogger(),
+	}
+
+	now := time.Now()
+	ca, bundle, err := r.reconcileTenantGatewayCA(context.TODO(), client, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bundle) != string(ca.certPEM) {
+		t.Fatal("expected only the CA in the bundle")
+	}
+	certPEM, keyPEM, err := issueTenantGatewayCertificate("gateway.apps.example.com", ca, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: keyPEM}
+
+	// The CA is kept until it's close to expiry
+	kept, _, err := r.reconcileTenantGatewayCA(context.TODO(), client, now.Add(tenantGatewayCAValidity-2*tenantGatewayCARenewal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(kept.certPEM) != string(ca.certPEM) {
+		t.Fatal("expected the tenant gateway CA not to be rotated")
+	}
+
+	// The rotated CA stays in the bundle, so the certificate it signed is
+	// trusted until it's renewed
+	rotatedAt := now.Add(tenantGatewayCAValidity - tenantGatewayCARenewal/2)
+	rotated, bundle, err := r.reconcileTenantGatewayCA(context.TODO(), client, rotatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rotated.certPEM) == string(ca.certPEM) || string(bundle) != string(rotated.certPEM)+string(ca.certPEM) {
+		t.Fatal("expected the tenant gateway CA to be rotated with the previous CA in the bundle")
+	}
+	if !isTenantGatewayCertificateValid(data, "gateway.apps.example.com", bundle, now) {
+		t.Fatal("expected the certificate signed by the previous CA to be trusted")
+	}
+
+	// The previous CA is dropped from the bundle once it expired
+	_, bundle, err = r.reconcileTenantGatewayCA(context.TODO(), client, ca.cert.NotAfter.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bundle) != string(rotated.certPEM) {
+		t.Fatal("expected the expired CA to be removed from the bundle")
+	}
+}
+
+func TestIsTenantGatewayCertificateValid(t *testing.T) {
+	now := time.Now()
+	caPEM, caKeyPEM, err := issueTenantGatewayCA(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := parseTenantGatewayCA(map[string][]byte{tenantGatewayCACertKey: caPEM, tenantGatewayCAKeyKey: caKeyPEM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCAPEM, _, err := issueTenantGatewayCA(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, keyPEM, err := issueTenantGatewayCertificate("gateway.apps.example.com", ca, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       certPEM,
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	tests := []struct {
+		name   string
+		domain string
+		bundle []byte
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "test valid certificate",
+			domain: "gateway.apps.example.com",
+			bundle: caPEM,
+			now:    now,
+			want:   true,
+		},
+		{
+			name:   "test certificate for a different domain",
+			domain: "gateway.apps.other.com",
+			bundle: caPEM,
+			now:    now,
+		},
+		{
+			name:   "test certificate close to expiry",
+			domain: "gateway.apps.example.com",
+			bundle: caPEM,
+			now:    now.Add(tenantGatewayCertValidity - tenantGatewayCertRenewal/2),
+		},
+		{
+			name:   "test certificate signed by an untrusted CA",
+			domain: "gateway.apps.example.com",
+			bundle: otherCAPEM,
+			now:    now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTenantGatewayCertificateValid(data, tt.domain, tt.bundle, tt.now); got != tt.want {
+				t.Fatalf("isTenantGatewayCertificateValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ type ThreeScaleInterface interface {
 	SetUserAsMember(userID int, accessToken string) (*http.Response, error)
 	SetFromEmailAddress(emailAddress string, accessToken string) (*http.Response, error)
 	UpdateUser(userID int, username string, email string, accessToken string) (*http.Response, error)
+	UpdateUserPassword(accessToken string, userID int, password string) error
 	UpdateTenant(id int64, params client.Params, portaClient *client.ThreeScaleClient) error
 
 	CreateAccount(accessToken, orgName, username string) (string, error)
@@ -321,6 +322,22 @@ func (tsc *threeScaleClient) UpdateUser(userID int, username string, email strin
 	return res, err
 }
 
+func (tsc *threeScaleClient) UpdateUserPassword(accessToken string, userID int, password string) error {
+	res, err := tsc.makeRequest(
+		"PUT",
+		fmt.Sprintf("users/%d.json", userID),
+		withAccessToken(accessToken, map[string]interface{}{
+			"password":              password,
+			"password_confirmation": password,
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	return assertStatusCode(http.StatusOK, res)
+}
+
 func (tsc *threeScaleClient) CreateAccount(accessToken, orgName, username string) (string, error) {
 	data := map[string]interface{}{
 		"org_name": orgName,
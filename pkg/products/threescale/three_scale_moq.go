@@ -121,6 +121,9 @@ var _ ThreeScaleInterface = &ThreeScaleInterfaceMock{}
 //			UpdateUserFunc: func(userID int, username string, email string, accessToken string) (*http.Response, error) {
 //				panic("mock out the UpdateUser method")
 //			},
+//			UpdateUserPasswordFunc: func(accessToken string, userID int, password string) error {
+//				panic("mock out the UpdateUserPassword method")
+//			},
 //		}
 //
 //		// use mockedThreeScaleInterface in code that requires ThreeScaleInterface
@@ -230,6 +233,9 @@ type ThreeScaleInterfaceMock struct {
 	// UpdateUserFunc mocks the UpdateUser method.
 	UpdateUserFunc func(userID int, username string, email string, accessToken string) (*http.Response, error)
 
+	// UpdateUserPasswordFunc mocks the UpdateUserPassword method.
+	UpdateUserPasswordFunc func(accessToken string, userID int, password string) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// ActivateUser holds details about calls to the ActivateUser method.
@@ -520,6 +526,15 @@ type ThreeScaleInterfaceMock struct {
 			// AccessToken is the accessToken argument value.
 			AccessToken string
 		}
+		// UpdateUserPassword holds details about calls to the UpdateUserPassword method.
+		UpdateUserPassword []struct {
+			// AccessToken is the accessToken argument value.
+			AccessToken string
+			// UserID is the userID argument value.
+			UserID int
+			// Password is the password argument value.
+			Password string
+		}
 	}
 	lockActivateUser                    sync.RWMutex
 	lockAddAuthProviderToAccount        sync.RWMutex
@@ -555,6 +570,7 @@ type ThreeScaleInterfaceMock struct {
 	lockSetUserAsMember                 sync.RWMutex
 	lockUpdateTenant                    sync.RWMutex
 	lockUpdateUser                      sync.RWMutex
+	lockUpdateUserPassword              sync.RWMutex
 }
 
 // ActivateUser calls ActivateUserFunc.
@@ -1880,3 +1896,43 @@ func (mock *ThreeScaleInterfaceMock) UpdateUserCalls() []struct {
 	mock.lockUpdateUser.RUnlock()
 	return calls
 }
+
+// UpdateUserPassword calls UpdateUserPasswordFunc.
+func (mock *ThreeScaleInterfaceMock) UpdateUserPassword(accessToken string, userID int, password string) error {
+	if mock.UpdateUserPasswordFunc == nil {
+		panic("ThreeScaleInterfaceMock.UpdateUserPasswordFunc: method is nil but ThreeScaleInterface.UpdateUserPassword was just called")
+	}
+	callInfo := struct {
+		AccessToken string
+		UserID      int
+		Password    string
+	}{
+		AccessToken: accessToken,
+		UserID:      userID,
+		Password:    password,
+	}
+	mock.lockUpdateUserPassword.Lock()
+	mock.calls.UpdateUserPassword = append(mock.calls.UpdateUserPassword, callInfo)
+	mock.lockUpdateUserPassword.Unlock()
+	return mock.UpdateUserPasswordFunc(accessToken, userID, password)
+}
+
+// UpdateUserPasswordCalls gets all the calls that were made to UpdateUserPassword.
+// Check the length with:
+//
+//	len(mockedThreeScaleInterface.UpdateUserPasswordCalls())
+func (mock *ThreeScaleInterfaceMock) UpdateUserPasswordCalls() []struct {
+	AccessToken string
+	UserID      int
+	Password    string
+} {
+	var calls []struct {
+		AccessToken string
+		UserID      int
+		Password    string
+	}
+	mock.lockUpdateUserPassword.RLock()
+	calls = mock.calls.UpdateUserPassword
+	mock.lockUpdateUserPassword.RUnlock()
+	return calls
+}
@@ -0,0 +1,130 @@
+package threescale
+
+import (
+	"context"
+	"fmt"
+
+	threescalev1 "github.com/3scale/3scale-operator/apis/apps/v1alpha1"
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tracev3 "github.com/envoyproxy/go-control-plane/envoy/config/trace/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/ratelimit"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/tracing"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// apicastTracingSecretName holds the OpenTracing configuration of
+	// APIcast when tracing is enabled
+	apicastTracingSecretName = "rhoam-apicast-tracing"
+	apicastTracingLibrary    = "jaeger"
+
+	TracingClusterName = "opentelemetry-collector"
+)
+
+// reconcileAPIcastTracingSecret writes the OpenTracing configuration of
+// APIcast, or removes it when tracing isn't enabled
+func (r *Reconciler) reconcileAPIcastTracingSecret(ctx context.Context, serverClient k8sclient.Client) error {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: apicastTracingSecretName, Namespace: r.Config.GetNamespace()}}
+	if !tracing.Enabled(r.installation) {
+		if err := serverClient.Delete(ctx, secret); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete apicast tracing secret: %w", err)
+		}
+		return nil
+	}
+
+	config, err := tracing.APIcastConfig(r.installation, "apicast")
+	if err != nil {
+		return fmt.Errorf("failed to build apicast tracing configuration: %w", err)
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, secret, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(secret, r.installation)
+		secret.Data = map[string][]byte{tracing.APIcastConfigKey: config}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile apicast tracing secret: %w", err)
+	}
+	return nil
+}
+
+// setAPIcastOpenTracing enables the OpenTracing module of both APIcast
+// environments when tracing is enabled. Tracing configurations not set by
+// RHOAM are left untouched
+func setAPIcastOpenTracing(apim *threescalev1.APIManager, installation *integreatlyv1alpha1.RHMI) {
+	var openTracing *threescalev1.APIcastOpenTracingSpec
+	if tracing.Enabled(installation) {
+		enabled := true
+		library := apicastTracingLibrary
+		openTracing = &threescalev1.APIcastOpenTracingSpec{
+			Enabled:                &enabled,
+			TracingLibrary:         &library,
+			TracingConfigSecretRef: &corev1.LocalObjectReference{Name: apicastTracingSecretName},
+		}
+	}
+
+	managed := func(current *threescalev1.APIcastOpenTracingSpec) bool {
+		return current == nil || (current.TracingConfigSecretRef != nil && current.TracingConfigSecretRef.Name == apicastTracingSecretName)
+	}
+	if managed(apim.Spec.Apicast.ProductionSpec.OpenTracing) {
+		apim.Spec.Apicast.ProductionSpec.OpenTracing = openTracing
+	}
+	if managed(apim.Spec.Apicast.StagingSpec.OpenTracing) {
+		apim.Spec.Apicast.StagingSpec.OpenTracing = openTracing.DeepCopy()
+	}
+}
+
+// getEnvoyTracing returns the tracing configuration of the connection
+// manager of the envoy proxies, sending the spans to the collector, or nil
+// when tracing isn't enabled
+func getEnvoyTracing(installation *integreatlyv1alpha1.RHMI, serviceName string) (*hcm.HttpConnectionManager_Tracing, error) {
+	if !tracing.Enabled(installation) {
+		return nil, nil
+	}
+
+	config, err := anypb.New(&tracev3.OpenTelemetryConfig{
+		GrpcService: &envoycorev3.GrpcService{
+			TargetSpecifier: &envoycorev3.GrpcService_EnvoyGrpc_{
+				EnvoyGrpc: &envoycorev3.GrpcService_EnvoyGrpc{ClusterName: TracingClusterName},
+			},
+		},
+		ServiceName: serviceName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the opentelemetry tracer config: %w", err)
+	}
+
+	return &hcm.HttpConnectionManager_Tracing{
+		RandomSampling: &typev3.Percent{Value: float64(tracing.SamplingPercentage(installation))},
+		Provider: &tracev3.Tracing_Http{
+			Name:       "envoy.tracers.opentelemetry",
+			ConfigType: &tracev3.Tracing_Http_TypedConfig{TypedConfig: config},
+		},
+	}, nil
+}
+
+// getTracingClusters returns the cluster of the collector the envoy proxies
+// send their spans to when tracing is enabled
+func getTracingClusters(installation *integreatlyv1alpha1.RHMI) ([]*envoyclusterv3.Cluster, error) {
+	if !tracing.Enabled(installation) {
+		return nil, nil
+	}
+
+	cluster := ratelimit.CreateClusterResource(tracing.CollectorHost(installation), TracingClusterName, tracing.OTLPPort)
+	extensionProtocol, err := ratelimit.CreateTypedExtensionProtocol()
+	if err != nil {
+		return nil, err
+	}
+	cluster.TypedExtensionProtocolOptions = map[string]*anypb.Any{
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": extensionProtocol,
+	}
+	return []*envoyclusterv3.Cluster{cluster}, nil
+}
@@ -0,0 +1,97 @@
+package threescale
+
+import (
+	"testing"
+
+	threescalev1 "github.com/3scale/3scale-operator/apis/apps/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetAPIcastOpenTracing(t *testing.T) {
+	customerTracing := &threescalev1.APIcastOpenTracingSpec{TracingConfigSecretRef: &corev1.LocalObjectReference{Name: "customer-jaeger"}}
+	rhoamTracing := &threescalev1.APIcastOpenTracingSpec{TracingConfigSecretRef: &corev1.LocalObjectReference{Name: apicastTracingSecretName}}
+
+	tests := []struct {
+		name           string
+		tracing        *integreatlyv1alpha1.TracingSpec
+		production     *threescalev1.APIcastOpenTracingSpec
+		wantProduction string
+		wantStaging    string
+	}{
+		{
+			name:           "enables the OpenTracing module of both environments",
+			tracing:        &integreatlyv1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317"},
+			wantProduction: apicastTracingSecretName,
+			wantStaging:    apicastTracingSecretName,
+		},
+		{
+			name:           "keeps the OpenTracing configurations not set by RHOAM",
+			tracing:        &integreatlyv1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317"},
+			production:     customerTracing,
+			wantProduction: "customer-jaeger",
+			wantStaging:    apicastTracingSecretName,
+		},
+		{
+			name:       "disables the OpenTracing module set by RHOAM without tracing spec",
+			production: rhoamTracing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apim := &threescalev1.APIManager{}
+			apim.Spec.Apicast = &threescalev1.ApicastSpec{
+				ProductionSpec: &threescalev1.ApicastProductionSpec{OpenTracing: tt.production.DeepCopy()},
+				StagingSpec:    &threescalev1.ApicastStagingSpec{},
+			}
+			installation := &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+				Spec:       integreatlyv1alpha1.RHMISpec{Tracing: tt.tracing},
+			}
+
+			setAPIcastOpenTracing(apim, installation)
+
+			secretName := func(openTracing *threescalev1.APIcastOpenTracingSpec) string {
+				if openTracing == nil || openTracing.TracingConfigSecretRef == nil {
+					return ""
+				}
+				return openTracing.TracingConfigSecretRef.Name
+			}
+			if got := secretName(apim.Spec.Apicast.ProductionSpec.OpenTracing); got != tt.wantProduction {
+				t.Errorf("expected the production tracing config %q, got %q", tt.wantProduction, got)
+			}
+			if got := secretName(apim.Spec.Apicast.StagingSpec.OpenTracing); got != tt.wantStaging {
+				t.Errorf("expected the staging tracing config %q, got %q", tt.wantStaging, got)
+			}
+		})
+	}
+}
+
+func TestGetEnvoyTracing(t *testing.T) {
+	installation := &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Namespace: "redhat-rhoam-operator"}}
+	tracing, err := getEnvoyTracing(installation, ApicastClusterName)
+	if err != nil || tracing != nil {
+		t.Fatalf("expected no tracing without tracing spec, got %v, %v", tracing, err)
+	}
+	clusters, err := getTracingClusters(installation)
+	if err != nil || len(clusters) != 0 {
+		t.Fatalf("expected no tracing cluster without tracing spec, got %v, %v", clusters, err)
+	}
+
+	installation.Spec.Tracing = &integreatlyv1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317"}
+	tracing, err = getEnvoyTracing(installation, ApicastClusterName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracing.GetRandomSampling().GetValue() != 10 {
+		t.Errorf("expected the default sampling percentage, got %v", tracing.GetRandomSampling())
+	}
+	clusters, err = getTracingClusters(installation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != TracingClusterName {
+		t.Errorf("expected the collector cluster, got %v", clusters)
+	}
+}
@@ -0,0 +1,167 @@
+package threescale
+
+import (
+	"fmt"
+	"strings"
+
+	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	envoytypev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	defaultTrafficSplitVersionHeader = "X-RHOAM-Backend-Version"
+	defaultTrafficSplitStableVersion = "stable"
+	defaultTrafficSplitCanaryVersion = "canary"
+	// trafficSplitRuntimeKeyPrefix lets the canary weight of a split be
+	// overridden in the envoy runtime without changing the RHMI CR
+	trafficSplitRuntimeKeyPrefix = "routing.traffic_split."
+)
+
+// validateTrafficSplits checks the gateway traffic splits can be rendered, and
+// that no host is claimed by more than one split as only the first matching
+// route is applied
+func validateTrafficSplits(splits []integreatlyv1alpha1.TrafficSplitSpec) error {
+	names := map[string]bool{}
+	hosts := map[string]string{}
+
+	for _, split := range splits {
+		if names[split.Name] {
+			return fmt.Errorf("duplicated traffic split %q", split.Name)
+		}
+		names[split.Name] = true
+
+		if len(split.Hosts) == 0 {
+			return fmt.Errorf("traffic split %q has no hosts", split.Name)
+		}
+		for _, host := range split.Hosts {
+			host = strings.ToLower(host)
+			if host != "*" {
+				if err := validateGatewayHost(host); err != nil {
+					return fmt.Errorf("traffic split %q: %w", split.Name, err)
+				}
+			}
+			if other, ok := hosts[host]; ok {
+				return fmt.Errorf("traffic splits %q and %q conflict on host %q", other, split.Name, host)
+			}
+			hosts[host] = split.Name
+		}
+
+		if !httpguts.ValidHeaderFieldName(getTrafficSplitVersionHeader(split)) {
+			return fmt.Errorf("traffic split %q: invalid version header %q", split.Name, split.VersionHeader)
+		}
+		stable, canary := getTrafficSplitVersions(split)
+		for _, version := range []string{stable, canary} {
+			if !httpguts.ValidHeaderFieldValue(version) || strings.TrimSpace(version) != version {
+				return fmt.Errorf("traffic split %q: invalid version %q", split.Name, version)
+			}
+		}
+		if stable == canary {
+			return fmt.Errorf("traffic split %q: the stable and canary versions are both %q", split.Name, stable)
+		}
+		if split.CanaryWeight < 0 || split.CanaryWeight > 100 {
+			return fmt.Errorf("traffic split %q: canary weight must be between 0 and 100", split.Name)
+		}
+		if match := split.CanaryMatch; match != nil {
+			if !httpguts.ValidHeaderFieldName(match.Header) || !httpguts.ValidHeaderFieldValue(match.Value) {
+				return fmt.Errorf("traffic split %q: invalid canary match %s: %s", split.Name, match.Header, match.Value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func getTrafficSplitVersionHeader(split integreatlyv1alpha1.TrafficSplitSpec) string {
+	if split.VersionHeader != "" {
+		return split.VersionHeader
+	}
+	return defaultTrafficSplitVersionHeader
+}
+
+func getTrafficSplitVersions(split integreatlyv1alpha1.TrafficSplitSpec) (string, string) {
+	stable, canary := split.StableVersion, split.CanaryVersion
+	if stable == "" {
+		stable = defaultTrafficSplitStableVersion
+	}
+	if canary == "" {
+		canary = defaultTrafficSplitCanaryVersion
+	}
+	return stable, canary
+}
+
+// getTrafficSplitRoutes returns the routes tagging the requests to the hosts
+// of the traffic splits with the selected version. They're matched before the
+// default apicast route, exact hosts first and * last
+func getTrafficSplitRoutes(installation *integreatlyv1alpha1.RHMI, clusterName string) []*envoyroutev3.Route {
+	var exactRoutes, wildcardRoutes, defaultRoutes []*envoyroutev3.Route
+
+	for _, split := range installation.Spec.GatewayTrafficSplits {
+		versionHeader := getTrafficSplitVersionHeader(split)
+		stable, canary := getTrafficSplitVersions(split)
+
+		for _, host := range split.Hosts {
+			host = strings.ToLower(host)
+			var routes []*envoyroutev3.Route
+
+			if match := split.CanaryMatch; match != nil {
+				routeMatch := getHostRouteMatch(host)
+				routeMatch.Headers = append(routeMatch.Headers, &envoyroutev3.HeaderMatcher{
+					Name: match.Header,
+					HeaderMatchSpecifier: &envoyroutev3.HeaderMatcher_StringMatch{
+						StringMatch: &matcher.StringMatcher{
+							MatchPattern: &matcher.StringMatcher_Exact{Exact: match.Value},
+						},
+					},
+				})
+				routes = append(routes, getTrafficSplitRoute(installation, clusterName, routeMatch, versionHeader, canary))
+			}
+			if split.CanaryWeight > 0 {
+				routeMatch := getHostRouteMatch(host)
+				routeMatch.RuntimeFraction = &envoycorev3.RuntimeFractionalPercent{
+					DefaultValue: &envoytypev3.FractionalPercent{
+						Numerator:   uint32(split.CanaryWeight),
+						Denominator: envoytypev3.FractionalPercent_HUNDRED,
+					},
+					RuntimeKey: trafficSplitRuntimeKeyPrefix + split.Name,
+				}
+				routes = append(routes, getTrafficSplitRoute(installation, clusterName, routeMatch, versionHeader, canary))
+			}
+			routes = append(routes, getTrafficSplitRoute(installation, clusterName, getHostRouteMatch(host), versionHeader, stable))
+
+			switch {
+			case host == "*":
+				defaultRoutes = append(defaultRoutes, routes...)
+			case strings.Contains(host, "*"):
+				wildcardRoutes = append(wildcardRoutes, routes...)
+			default:
+				exactRoutes = append(exactRoutes, routes...)
+			}
+		}
+	}
+
+	return append(append(exactRoutes, wildcardRoutes...), defaultRoutes...)
+}
+
+// getTrafficSplitRoute routes to apicast like the default route, so the rate
+// limits are applied whatever the version
+func getTrafficSplitRoute(installation *integreatlyv1alpha1.RHMI, clusterName string, routeMatch *envoyroutev3.RouteMatch, versionHeader, version string) *envoyroutev3.Route {
+	return &envoyroutev3.Route{
+		Match: routeMatch,
+		Action: &envoyroutev3.Route_Route{
+			Route: getAPICastRouteAction(installation, clusterName),
+		},
+		RequestHeadersToAdd: []*envoycorev3.HeaderValueOption{{
+			Header: &envoycorev3.HeaderValue{
+				Key:   versionHeader,
+				Value: version,
+			},
+			// the version sent by the client is replaced so it can only opt
+			// in through the canary match
+			AppendAction: envoycorev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+		}},
+	}
+}
@@ -0,0 +1,145 @@
+package threescale
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	envoycorev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+)
+
+func TestValidateTrafficSplits(t *testing.T) {
+	split := func(name string, hosts ...string) integreatlyv1alpha1.TrafficSplitSpec {
+		return integreatlyv1alpha1.TrafficSplitSpec{
+			Name:         name,
+			Hosts:        hosts,
+			CanaryWeight: 10,
+			CanaryMatch:  &integreatlyv1alpha1.TrafficSplitMatch{Header: "X-Canary", Value: "true"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		splits  []integreatlyv1alpha1.TrafficSplitSpec
+		update  func(splits []integreatlyv1alpha1.TrafficSplitSpec)
+		wantErr string
+	}{
+		{
+			name:   "test valid splits",
+			splits: []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "orders.example.com"), split("shop", "*.shop.example.com", "*")},
+		},
+		{
+			name:    "test hosts claimed by two splits conflict",
+			splits:  []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "api.example.com"), split("shop", "API.example.com")},
+			wantErr: `traffic splits "orders" and "shop" conflict on host "api.example.com"`,
+		},
+		{
+			name:    "test duplicated names",
+			splits:  []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "orders.example.com"), split("orders", "shop.example.com")},
+			wantErr: `duplicated traffic split "orders"`,
+		},
+		{
+			name:   "test same stable and canary versions",
+			splits: []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "orders.example.com")},
+			update: func(splits []integreatlyv1alpha1.TrafficSplitSpec) {
+				splits[0].StableVersion = "canary"
+			},
+			wantErr: `the stable and canary versions are both "canary"`,
+		},
+		{
+			name:   "test weight over 100",
+			splits: []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "orders.example.com")},
+			update: func(splits []integreatlyv1alpha1.TrafficSplitSpec) {
+				splits[0].CanaryWeight = 101
+			},
+			wantErr: "canary weight must be between 0 and 100",
+		},
+		{
+			name:   "test invalid canary match header",
+			splits: []integreatlyv1alpha1.TrafficSplitSpec{split("orders", "orders.example.com")},
+			update: func(splits []integreatlyv1alpha1.TrafficSplitSpec) {
+				splits[0].CanaryMatch.Header = "X Canary"
+			},
+			wantErr: "invalid canary match",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.update != nil {
+				tt.update(tt.splits)
+			}
+			err := validateTrafficSplits(tt.splits)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGetAPICastVirtualHostsTrafficSplits(t *testing.T) {
+	installation := &integreatlyv1alpha1.RHMI{
+		Spec: integreatlyv1alpha1.RHMISpec{
+			Type: string(integreatlyv1alpha1.InstallationTypeManagedApi),
+			GatewayTrafficSplits: []integreatlyv1alpha1.TrafficSplitSpec{
+				{
+					Name:          "all",
+					Hosts:         []string{"*"},
+					StableVersion: "v1",
+					CanaryVersion: "v2",
+				},
+				{
+					Name:         "orders",
+					Hosts:        []string{"Orders.example.com"},
+					CanaryWeight: 25,
+					CanaryMatch:  &integreatlyv1alpha1.TrafficSplitMatch{Header: "X-Canary", Value: "true"},
+				},
+			},
+		},
+	}
+
+	virtualHosts, err := getAPICastVirtualHosts(installation, ApicastClusterName, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := virtualHosts[0].Routes
+	if len(routes) != 5 {
+		t.Fatalf("expected 3 orders routes, the * split route and the default route, got %d", len(routes))
+	}
+
+	versionOf := func(i int) string {
+		header := routes[i].RequestHeadersToAdd[0]
+		if header.AppendAction != envoycorev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD {
+			t.Fatalf("expected the version header of route %d to be overwritten", i)
+		}
+		return header.Header.Key + "=" + header.Header.Value
+	}
+
+	if routes[0].Match.Headers[0].GetStringMatch().GetExact() != "orders.example.com" || routes[0].Match.Headers[1].Name != "X-Canary" || versionOf(0) != "X-RHOAM-Backend-Version=canary" {
+		t.Fatalf("expected the canary match route first, got %v", routes[0])
+	}
+	if routes[1].Match.RuntimeFraction.GetDefaultValue().GetNumerator() != 25 || routes[1].Match.RuntimeFraction.RuntimeKey != "routing.traffic_split.orders" || versionOf(1) != "X-RHOAM-Backend-Version=canary" {
+		t.Fatalf("expected the weighted canary route second, got %v", routes[1])
+	}
+	if routes[2].Match.RuntimeFraction != nil || versionOf(2) != "X-RHOAM-Backend-Version=stable" {
+		t.Fatalf("expected the stable route third, got %v", routes[2])
+	}
+	if len(routes[3].Match.Headers) != 0 || versionOf(3) != "X-RHOAM-Backend-Version=v1" {
+		t.Fatalf("expected the * split after the host splits, got %v", routes[3])
+	}
+	if routes[4].RequestHeadersToAdd != nil {
+		t.Fatalf("expected the default route last, got %v", routes[4])
+	}
+	for i, route := range routes {
+		if route.GetRoute().GetCluster() != ApicastClusterName || len(route.GetRoute().RateLimits) == 0 {
+			t.Fatalf("expected route %d to be rate limited and sent to apicast, got %v", i, route.GetRoute())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AdoptOrphanedResources re-binds the resources left behind by a deleted
+// installation to inst, so a recreated RHMI CR picks up the existing products
+// instead of colliding with them. Namespaces and OAuthClients are adopted
+// when they are labelled with the UID of an installation that no longer
+// exists, namespaces only when they have the namespace prefix of inst. The
+// owner annotations of the cloud resources in the installation namespace are
+// updated so they map back to inst. It returns the adopted namespaces
+func AdoptOrphanedResources(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI, log l.Logger) ([]string, error) {
+	installations := &integreatlyv1alpha1.RHMIList{}
+	if err := client.List(ctx, installations); err != nil {
+		return nil, fmt.Errorf("failed to list installations: %w", err)
+	}
+	liveUIDs := map[string]bool{}
+	liveNames := map[string]bool{}
+	for _, installation := range installations.Items {
+		liveUIDs[string(installation.GetUID())] = true
+		if installation.Namespace == inst.Namespace {
+			liveNames[installation.Name] = true
+		}
+	}
+	liveUIDs[string(inst.GetUID())] = true
+	liveNames[inst.Name] = true
+
+	orphaned, err := orphanedSelector(liveUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	nsList := &corev1.NamespaceList{}
+	if err := client.List(ctx, nsList, k8sclient.MatchingLabelsSelector{Selector: orphaned}); err != nil {
+		return nil, fmt.Errorf("failed to list orphaned namespaces: %w", err)
+	}
+	var adopted []string
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+		if inst.Spec.NamespacePrefix == "" || !strings.HasPrefix(ns.Name, inst.Spec.NamespacePrefix) {
+			continue
+		}
+		if err := adopt(ctx, client, ns, inst); err != nil {
+			return adopted, fmt.Errorf("failed to adopt namespace %s: %w", ns.Name, err)
+		}
+		log.Infof("Adopted namespace of a deleted installation", l.Fields{"ns": ns.Name})
+		adopted = append(adopted, ns.Name)
+	}
+
+	oauthClients := &oauthv1.OAuthClientList{}
+	if err := client.List(ctx, oauthClients, k8sclient.MatchingLabelsSelector{Selector: orphaned}); err != nil {
+		return adopted, fmt.Errorf("failed to list orphaned oauth clients: %w", err)
+	}
+	for i := range oauthClients.Items {
+		oauthClient := &oauthClients.Items[i]
+		if err := adopt(ctx, client, oauthClient, inst); err != nil {
+			return adopted, fmt.Errorf("failed to adopt oauth client %s: %w", oauthClient.Name, err)
+		}
+		log.Infof("Adopted oauth client of a deleted installation", l.Fields{"oauthClient": oauthClient.Name})
+	}
+
+	if err := adoptCloudResources(ctx, client, inst, liveNames, log); err != nil {
+		return adopted, err
+	}
+
+	return adopted, nil
+}
+
+// orphanedSelector matches the resources labelled with the UID of an
+// installation that isn't one of liveUIDs
+func orphanedSelector(liveUIDs map[string]bool) (labels.Selector, error) {
+	uids := make([]string, 0, len(liveUIDs))
+	for uid := range liveUIDs {
+		uids = append(uids, uid)
+	}
+	exists, err := labels.NewRequirement(OwnerLabelKey, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	notLive, err := labels.NewRequirement(OwnerLabelKey, selection.NotIn, uids)
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*exists, *notLive), nil
+}
+
+func adopt(ctx context.Context, client k8sclient.Client, obj k8sclient.Object, inst *integreatlyv1alpha1.RHMI) error {
+	objLabels := obj.GetLabels()
+	objLabels[OwnerLabelKey] = string(inst.GetUID())
+	obj.SetLabels(objLabels)
+	return client.Update(ctx, obj)
+}
+
+// adoptCloudResources updates the owner annotations of the cloud resources of
+// a deleted installation in the same namespace, the resources are found by
+// name so the existing cloud instances and their connection secrets are reused
+func adoptCloudResources(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI, liveNames map[string]bool, log l.Logger) error {
+	lists := []k8sclient.ObjectList{
+		&crov1alpha1.PostgresList{},
+		&crov1alpha1.RedisList{},
+		&crov1alpha1.BlobStorageList{},
+	}
+	for _, list := range lists {
+		if err := client.List(ctx, list, k8sclient.InNamespace(inst.Namespace)); err != nil {
+			return fmt.Errorf("failed to list cloud resources: %w", err)
+		}
+		var objects []k8sclient.Object
+		switch typed := list.(type) {
+		case *crov1alpha1.PostgresList:
+			for i := range typed.Items {
+				objects = append(objects, &typed.Items[i])
+			}
+		case *crov1alpha1.RedisList:
+			for i := range typed.Items {
+				objects = append(objects, &typed.Items[i])
+			}
+		case *crov1alpha1.BlobStorageList:
+			for i := range typed.Items {
+				objects = append(objects, &typed.Items[i])
+			}
+		}
+
+		for _, obj := range objects {
+			annotations := obj.GetAnnotations()
+			ownerName, ok := annotations[owner.IntegreatlyOwnerName]
+			if !ok || liveNames[ownerName] || annotations[owner.IntegreatlyOwnerNamespace] != inst.Namespace {
+				continue
+			}
+			owner.AddIntegreatlyOwnerAnnotations(obj, inst)
+			if err := client.Update(ctx, obj); err != nil {
+				return fmt.Errorf("failed to adopt cloud resource %s: %w", obj.GetName(), err)
+			}
+			log.Infof("Adopted cloud resource of a deleted installation", l.Fields{"name": obj.GetName(), "previousOwner": ownerName})
+		}
+	}
+	return nil
+}
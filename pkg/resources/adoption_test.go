@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/utils"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAdoptOrphanedResources(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator", UID: "new-uid"},
+		Spec:       integreatlyv1alpha1.RHMISpec{NamespacePrefix: "redhat-rhoam-"},
+	}
+	otherInstallation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other-operator", UID: "other-uid"},
+	}
+	namespace := func(name, uid string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{OwnerLabelKey: uid}}}
+	}
+	postgres := func(name, ownerName string) *crov1alpha1.Postgres {
+		return &crov1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: installation.Namespace,
+			Annotations: map[string]string{
+				owner.IntegreatlyOwnerName:      ownerName,
+				owner.IntegreatlyOwnerNamespace: installation.Namespace,
+			},
+		}}
+	}
+
+	serverClient := utils.NewTestClient(scheme,
+		installation,
+		otherInstallation,
+		namespace("redhat-rhoam-3scale", "old-uid"),
+		namespace("redhat-rhoam-rhsso", "new-uid"),
+		namespace("other-3scale", "other-uid"),
+		namespace("unrelated", "old-uid"),
+		&oauthv1.OAuthClient{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-3scale", Labels: map[string]string{OwnerLabelKey: "old-uid"}}},
+		postgres("threescale-postgres-old", "rhoam-old"),
+		postgres("threescale-postgres-rhoam", "rhoam"),
+	)
+
+	adopted, err := AdoptOrphanedResources(context.TODO(), serverClient, installation, l.NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(adopted, []string{"redhat-rhoam-3scale"}) {
+		t.Fatalf("expected only the orphaned namespace with the installation prefix to be adopted, got %v", adopted)
+	}
+
+	expectedOwners := map[string]string{
+		"redhat-rhoam-3scale": "new-uid",
+		"redhat-rhoam-rhsso":  "new-uid",
+		"other-3scale":        "other-uid",
+		"unrelated":           "old-uid",
+	}
+	for name, uid := range expectedOwners {
+		ns := &corev1.Namespace{}
+		if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: name}, ns); err != nil {
+			t.Fatal(err)
+		}
+		if ns.Labels[OwnerLabelKey] != uid {
+			t.Errorf("expected namespace %s to be owned by %s, got %s", name, uid, ns.Labels[OwnerLabelKey])
+		}
+	}
+
+	oauthClient := &oauthv1.OAuthClient{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: "redhat-rhoam-3scale"}, oauthClient); err != nil {
+		t.Fatal(err)
+	}
+	if !IsOwnedBy(oauthClient, installation) {
+		t.Errorf("expected oauth client to be adopted, got labels %v", oauthClient.Labels)
+	}
+
+	adoptedPostgres := &crov1alpha1.Postgres{}
+	if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: "threescale-postgres-old", Namespace: installation.Namespace}, adoptedPostgres); err != nil {
+		t.Fatal(err)
+	}
+	if adoptedPostgres.Annotations[owner.IntegreatlyOwnerName] != installation.Name {
+		t.Errorf("expected postgres to be adopted, got annotations %v", adoptedPostgres.Annotations)
+	}
+}
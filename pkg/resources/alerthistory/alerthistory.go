@@ -0,0 +1,237 @@
+package alerthistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	prometheusApi "github.com/prometheus/client_golang/api"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ConfigMapName is the config map in the operator namespace the alert
+	// history is kept in, so it's also collected by a namespace must-gather
+	ConfigMapName = "rhoam-alert-history"
+
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+
+	// MaxEntries keeps the config map well below the object size limit, the
+	// oldest transitions are dropped first
+	MaxEntries = 1000
+
+	prometheusServiceName = "rhoam-prometheus"
+
+	historyKey = "history"
+	firingKey  = "firing"
+)
+
+var log = l.NewLoggerWithContext(l.Fields{l.ComponentLogContext: "alerthistory"})
+
+// Entry is an alert starting or stopping to fire. Time is when the
+// transition was observed, ActiveAt when Prometheus started evaluating the
+// alert as active
+type Entry struct {
+	Alert    string            `json:"alert"`
+	Severity string            `json:"severity,omitempty"`
+	State    string            `json:"state"`
+	Time     time.Time         `json:"time"`
+	ActiveAt *time.Time        `json:"activeAt,omitempty"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// Reconcile records the transitions of the alerts currently reported by the
+// RHOAM Prometheus in the alert history of the installation namespace
+func Reconcile(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI) error {
+	prometheusService := &corev1.Service{}
+	err := client.Get(ctx, k8sclient.ObjectKey{Name: prometheusServiceName, Namespace: config.GetOboNamespace(installation.Namespace)}, prometheusService)
+	if err != nil {
+		return fmt.Errorf("failed to get prometheus service: %w", err)
+	}
+
+	var port int32
+	for _, servicePort := range prometheusService.Spec.Ports {
+		if servicePort.Name == "web" {
+			port = servicePort.Port
+		}
+	}
+	if port == 0 {
+		return fmt.Errorf("failed to find web port of prometheus service")
+	}
+
+	apiClient, err := prometheusApi.NewClient(prometheusApi.Config{
+		Address: fmt.Sprintf("http://%s.%s.svc:%d", prometheusService.Name, prometheusService.Namespace, port),
+	})
+	if err != nil {
+		return err
+	}
+	alerts, err := prometheusv1.NewAPI(apiClient).Alerts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get prometheus alerts: %w", err)
+	}
+
+	return Record(ctx, client, installation.Namespace, alerts.Alerts, time.Now())
+}
+
+// Record appends a transition to the alert history for every alert that
+// started firing, or stopped firing, since the last time it was called
+func Record(ctx context.Context, client k8sclient.Client, namespace string, alerts []prometheusv1.Alert, now time.Time) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, client, cm, func() error {
+		history, firing, err := decode(cm)
+		if err != nil {
+			// a corrupted history shouldn't block recording new transitions
+			log.Error("Resetting unreadable alert history", err)
+			history, firing = []Entry{}, map[string]Entry{}
+		}
+
+		current := map[string]Entry{}
+		for _, alert := range alerts {
+			if alert.State != prometheusv1.AlertStateFiring {
+				continue
+			}
+			activeAt := alert.ActiveAt.UTC()
+			entry := Entry{
+				Alert:    string(alert.Labels[model.AlertNameLabel]),
+				Severity: string(alert.Labels["severity"]),
+				State:    StateFiring,
+				Time:     now.UTC(),
+				ActiveAt: &activeAt,
+				Labels:   labels(alert.Labels),
+			}
+			key := alert.Labels.Fingerprint().String()
+			current[key] = entry
+			if _, ok := firing[key]; !ok {
+				history = append(history, entry)
+			}
+		}
+
+		var resolved []string
+		for key := range firing {
+			if _, ok := current[key]; !ok {
+				resolved = append(resolved, key)
+			}
+		}
+		sort.Strings(resolved)
+		for _, key := range resolved {
+			entry := firing[key]
+			entry.State = StateResolved
+			entry.Time = now.UTC()
+			entry.ActiveAt = nil
+			history = append(history, entry)
+		}
+
+		if len(history) > MaxEntries {
+			history = history[len(history)-MaxEntries:]
+		}
+		return encode(cm, history, current)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record alert history: %w", err)
+	}
+	return nil
+}
+
+// Get returns the recorded transitions after since, oldest first
+func Get(ctx context.Context, client k8sclient.Client, namespace string, since time.Time) ([]Entry, error) {
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: ConfigMapName, Namespace: namespace}, cm); err != nil {
+		if k8serr.IsNotFound(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to get alert history: %w", err)
+	}
+	history, _, err := decode(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, entry := range history {
+		if entry.Time.After(since) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Firing returns the alerts firing when the history was last recorded,
+// sorted by name
+func Firing(ctx context.Context, client k8sclient.Client, namespace string) ([]Entry, error) {
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: ConfigMapName, Namespace: namespace}, cm); err != nil {
+		if k8serr.IsNotFound(err) {
+			return []Entry{}, nil
+		}
+		return nil, fmt.Errorf("failed to get alert history: %w", err)
+	}
+	_, firing, err := decode(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(firing))
+	for _, entry := range firing {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Alert < entries[j].Alert })
+	return entries, nil
+}
+
+func labels(labelSet model.LabelSet) map[string]string {
+	result := make(map[string]string, len(labelSet))
+	for name, value := range labelSet {
+		result[string(name)] = string(value)
+	}
+	return result
+}
+
+func decode(cm *corev1.ConfigMap) ([]Entry, map[string]Entry, error) {
+	history := []Entry{}
+	firing := map[string]Entry{}
+	if value, ok := cm.Data[historyKey]; ok {
+		if err := json.Unmarshal([]byte(value), &history); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode alert history: %w", err)
+		}
+	}
+	if value, ok := cm.Data[firingKey]; ok {
+		if err := json.Unmarshal([]byte(value), &firing); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode firing alerts: %w", err)
+		}
+	}
+	return history, firing, nil
+}
+
+func encode(cm *corev1.ConfigMap, history []Entry, firing map[string]Entry) error {
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	firingJSON, err := json.Marshal(firing)
+	if err != nil {
+		return err
+	}
+	cm.Data = map[string]string{
+		historyKey: string(historyJSON),
+		firingKey:  string(firingJSON),
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package alerthistory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/utils"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+const testNamespace = utils.TestNamespacePrefix + "operator"
+
+func alert(name string, state prometheusv1.AlertState) prometheusv1.Alert {
+	return prometheusv1.Alert{
+		Labels: model.LabelSet{
+			model.AlertNameLabel: model.LabelValue(name),
+			"severity":           "critical",
+		},
+		State:    state,
+		ActiveAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRecord(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2023, 1, 1, 0, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		rounds [][]prometheusv1.Alert
+		want   []string
+	}{
+		{
+			name:   "test pending alerts aren't recorded",
+			rounds: [][]prometheusv1.Alert{{alert("ThreeScaleApicastDown", prometheusv1.AlertStatePending)}},
+			want:   []string{},
+		},
+		{
+			name: "test alert firing across rounds is recorded once",
+			rounds: [][]prometheusv1.Alert{
+				{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring)},
+				{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring)},
+			},
+			want: []string{"ThreeScaleApicastDown/firing"},
+		},
+		{
+			name: "test alerts that stop firing are resolved",
+			rounds: [][]prometheusv1.Alert{
+				{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring), alert("RHSSODown", prometheusv1.AlertStateFiring)},
+				{alert("RHSSODown", prometheusv1.AlertStateFiring)},
+				{},
+				{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring)},
+			},
+			want: []string{
+				"ThreeScaleApicastDown/firing",
+				"RHSSODown/firing",
+				"ThreeScaleApicastDown/resolved",
+				"RHSSODown/resolved",
+				"ThreeScaleApicastDown/firing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme)
+			for i, alerts := range tt.rounds {
+				if err := Record(context.TODO(), client, testNamespace, alerts, start.Add(time.Duration(i)*time.Minute)); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+
+			entries, err := Get(context.TODO(), client, testNamespace, time.Time{})
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("expected %d entries, got %v", len(tt.want), entries)
+			}
+			for i, entry := range entries {
+				if got := entry.Alert + "/" + entry.State; got != tt.want[i] {
+					t.Errorf("expected entry %d to be %s, got %s", i, tt.want[i], got)
+				}
+				if entry.Severity != "critical" {
+					t.Errorf("expected severity critical, got %s", entry.Severity)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordCapsHistory(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := utils.NewTestClient(scheme)
+	now := time.Now()
+
+	for i := 0; i < MaxEntries/2+1; i++ {
+		if err := Record(context.TODO(), client, testNamespace, []prometheusv1.Alert{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring)}, now); err != nil {
+			t.Fatal(err)
+		}
+		if err := Record(context.TODO(), client, testNamespace, nil, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := Get(context.TODO(), client, testNamespace, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != MaxEntries {
+		t.Fatalf("expected history to be capped at %d entries, got %d", MaxEntries, len(entries))
+	}
+}
+
+func TestFiring(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := utils.NewTestClient(scheme)
+
+	firing, err := Firing(context.TODO(), client, testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(firing) != 0 {
+		t.Fatalf("expected no firing alerts without a history, got %v", firing)
+	}
+
+	rounds := [][]prometheusv1.Alert{
+		{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring), alert("RHSSODown", prometheusv1.AlertStateFiring)},
+		{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring), alert("RHSSODown", prometheusv1.AlertStateFiring), alert("MarinDown", prometheusv1.AlertStatePending)},
+		{alert("ThreeScaleApicastDown", prometheusv1.AlertStateFiring), alert("RHSSOUserDown", prometheusv1.AlertStateFiring)},
+	}
+	for _, alerts := range rounds {
+		if err := Record(context.TODO(), client, testNamespace, alerts, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	firing, err = Firing(context.TODO(), client, testNamespace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range firing {
+		got = append(got, entry.Alert)
+	}
+	want := []string{"RHSSOUserDown", "ThreeScaleApicastDown"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected firing alerts %v, got %v", want, got)
+	}
+}
@@ -44,7 +44,7 @@ const (
 
 // PerformBackup creates a snapshot CR and waits until the status of the CR
 // is `complete`
-func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) error {
+func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
 	log.Infof("Performing backup on AWS", l.Fields{"snapshotType": e.SnapshotType, "resourceName": e.ResourceName})
 
 	snapshotName := fmt.Sprintf("%s-preupgrade-snapshot-%s", e.ResourceName, time.Now().Format("2006-01-02-150405"))
@@ -72,14 +72,14 @@ func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.
 			},
 		}
 	default:
-		return fmt.Errorf("Unsupported value for AWSShapshotType. Expected %s or %s, got %s",
+		return nil, fmt.Errorf("Unsupported value for AWSShapshotType. Expected %s or %s, got %s",
 			PostgresSnapshotType, RedisSnapshotType, e.SnapshotType)
 	}
 
 	// Create the CR
 	err := client.Create(context.TODO(), snapshotCR.(k8sclient.Object))
 	if err != nil {
-		return fmt.Errorf("Error creating %s for backup of resource %s: %v",
+		return nil, fmt.Errorf("Error creating %s for backup of resource %s: %v",
 			e.SnapshotType, e.ResourceName, err)
 	}
 
@@ -97,7 +97,7 @@ func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.
 	for {
 		// If it times out, return an error
 		if time.Now().After(started.Add(timeout)) {
-			return fmt.Errorf("Snapshot of %s %s timed out", e.ResourceName, e.SnapshotType)
+			return nil, fmt.Errorf("Snapshot of %s %s timed out", e.ResourceName, e.SnapshotType)
 		}
 
 		// Get the CR
@@ -106,7 +106,7 @@ func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.
 			Namespace: e.SnapshotNamespace,
 		}, queryCR.(k8sclient.Object))
 		if err != nil {
-			return fmt.Errorf("Error occurred querying snapshot for backup %s", e.ResourceName)
+			return nil, fmt.Errorf("Error occurred querying snapshot for backup %s", e.ResourceName)
 		}
 
 		// Get the phase
@@ -125,14 +125,21 @@ func (e *AWSBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.
 
 		// If the snapshot failed, return an error with the message
 		if phase == crotypes.PhaseFailed {
-			return fmt.Errorf("Snapshot failed: %s", message)
+			return nil, fmt.Errorf("Snapshot failed: %s", message)
 		}
 
 		// If it's complete, break the loop
 		if phase == crotypes.PhaseComplete {
 			break
 		}
+
+		time.Sleep(pollInterval)
 	}
 
-	return nil
+	return []Artifact{{
+		Kind:        string(e.SnapshotType),
+		Namespace:   e.SnapshotNamespace,
+		Name:        snapshotName,
+		CompletedAt: time.Now(),
+	}}, nil
 }
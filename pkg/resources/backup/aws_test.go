@@ -59,10 +59,13 @@ func TestAWSSnapshotPostgres(t *testing.T) {
 		}
 	}()
 
-	err = executor.PerformBackup(client, time.Second*10)
+	artifacts, err := executor.PerformBackup(client, time.Second*10)
 	if err != nil {
 		t.Errorf("Unexpected error performing postgres backup: %v", err)
 	}
+	if len(artifacts) != 1 || !strings.HasPrefix(artifacts[0].Name, fmt.Sprintf("%s-preupgrade-snapshot", resourceName)) {
+		t.Errorf("Expected the snapshot to be returned as artifact, got %v", artifacts)
+	}
 }
 
 // TestAWSSnapshotRedis tests that the AWSBackupExecutor succesfully creates
@@ -110,10 +113,13 @@ func TestAWSSnapshotRedis(t *testing.T) {
 		}
 	}()
 
-	err = executor.PerformBackup(client, time.Second*10)
+	artifacts, err := executor.PerformBackup(client, time.Second*10)
 	if err != nil {
 		t.Errorf("Unexpected error performing postgres backup: %v", err)
 	}
+	if len(artifacts) != 1 || !strings.HasPrefix(artifacts[0].Name, fmt.Sprintf("%s-preupgrade-snapshot", resourceName)) {
+		t.Errorf("Expected the snapshot to be returned as artifact, got %v", artifacts)
+	}
 }
 
 // TestAWSSnapshotPostgres_FailedJob tests that the AWSBackupExecutor returns
@@ -163,7 +169,7 @@ func TestAWSSnapshotPostgres_FailedJob(t *testing.T) {
 		}
 	}()
 
-	err = executor.PerformBackup(client, time.Second*10)
+	_, err = executor.PerformBackup(client, time.Second*10)
 	if err == nil {
 		t.Fatal("Expected error when performing fail backup")
 		return
@@ -222,7 +228,7 @@ func TestAWSSnapshotRedis_FailedJob(t *testing.T) {
 		}
 	}()
 
-	err = executor.PerformBackup(client, time.Second*10)
+	_, err = executor.PerformBackup(client, time.Second*10)
 	if err == nil {
 		t.Fatal("Expected error when performing fail backup")
 		return
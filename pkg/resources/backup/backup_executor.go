@@ -4,15 +4,27 @@ import (
 	"fmt"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"golang.org/x/sync/errgroup"
+	"sync"
 	"time"
 
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// pollInterval between the checks of the completion of a backup
+var pollInterval = 5 * time.Second
+
+// Artifact is a backup taken by a BackupExecutor
+type Artifact struct {
+	Kind        string
+	Namespace   string
+	Name        string
+	CompletedAt time.Time
+}
+
 // BackupExecutor knows how to perform backups and wait for their successful
-// completion
+// completion, returning the artifacts they took
 type BackupExecutor interface {
-	PerformBackup(client k8sclient.Client, timeout time.Duration) error
+	PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error)
 }
 
 // NoopBackupExecutor does nothing. For components that do not require backups
@@ -23,9 +35,9 @@ func NewNoopBackupExecutor() BackupExecutor {
 }
 
 // PerformBackup simply returns a `nil` error
-func (e *NoopBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) error {
+func (e *NoopBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
 	log.Info("No backup to perform")
-	return nil
+	return nil, nil
 }
 
 // ConcurrentBackupExecutor performs backups by delegating the operation into
@@ -41,10 +53,12 @@ func NewConcurrentBackupExecutor(executors ...BackupExecutor) BackupExecutor {
 	}
 }
 
-func (e *ConcurrentBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) error {
+func (e *ConcurrentBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
 	log.Infof("Concurrently performing backups", l.Fields{"backups": len(e.Executors)})
 
 	var g errgroup.Group
+	var mu sync.Mutex
+	var artifacts []Artifact
 
 	for _, backup := range e.Executors {
 		// We need to re-assign the BackupExecutor instance in the scope of the
@@ -52,13 +66,17 @@ func (e *ConcurrentBackupExecutor) PerformBackup(client k8sclient.Client, timeou
 		// the value pointed by the `backup` variable will have changed
 		each := backup
 		g.Go(func() error {
-			return each.PerformBackup(client, timeout)
+			taken, err := each.PerformBackup(client, timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			artifacts = append(artifacts, taken...)
+			return err
 		})
 	}
 
 	if err := g.Wait(); err != nil {
-		return fmt.Errorf("Error occurred when performing concurrent backups: %v", err)
+		return artifacts, fmt.Errorf("Error occurred when performing concurrent backups: %v", err)
 	}
 
-	return nil
+	return artifacts, nil
 }
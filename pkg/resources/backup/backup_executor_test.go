@@ -10,6 +10,11 @@ import (
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+func init() {
+	// the fake client completes the backups right away
+	pollInterval = 10 * time.Millisecond
+}
+
 func TestConcurrentBackup(t *testing.T) {
 	scheme := runtime.NewScheme()
 	client := utils.NewTestClient(scheme)
@@ -27,12 +32,15 @@ func TestConcurrentBackup(t *testing.T) {
 	)
 
 	timeStarted := time.Now()
-	err := executor.PerformBackup(client, time.Second*3)
+	artifacts, err := executor.PerformBackup(client, time.Second*3)
 	timeFinished := time.Now()
 
 	if err != nil {
 		t.Errorf("Unexpected error performing concurrent backups: %v", err)
 	}
+	if len(artifacts) != 7 {
+		t.Errorf("Expected the artifacts of the 7 backups, got %d", len(artifacts))
+	}
 
 	elapsed := timeFinished.Sub(timeStarted)
 	// Add 2 seconds threshold (more than enough) for context switching. If it
@@ -46,10 +54,10 @@ type mockBackupExecutor struct {
 	SleepTime time.Duration
 }
 
-func (e mockBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) error {
+func (e mockBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
 	if e.SleepTime > timeout {
-		return fmt.Errorf("SleepTime %v for mock is greater than given timeout %v", e.SleepTime, timeout)
+		return nil, fmt.Errorf("SleepTime %v for mock is greater than given timeout %v", e.SleepTime, timeout)
 	}
 	time.Sleep(e.SleepTime)
-	return nil
+	return []Artifact{{Kind: "Mock", Name: "mock", CompletedAt: time.Now()}}, nil
 }
@@ -29,7 +29,7 @@ func NewCronJobBackupExecutor(cronJobName, namespace, jobGenerateName string) Ba
 	}
 }
 
-func (e *CronJobBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) error {
+func (e *CronJobBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
 	log.Infof("Performing backup by creating Job", l.Fields{"cronJob": e.CronJobName, "ns": e.Namespace})
 
 	// Generate the job name
@@ -42,7 +42,7 @@ func (e *CronJobBackupExecutor) PerformBackup(client k8sclient.Client, timeout t
 		Namespace: e.Namespace,
 	}, cronJob)
 	if err != nil {
-		return fmt.Errorf("Error obtaining CronJob %s in namespace %s: %v", e.CronJobName, e.Namespace, err)
+		return nil, fmt.Errorf("Error obtaining CronJob %s in namespace %s: %v", e.CronJobName, e.Namespace, err)
 	}
 
 	// Create the Job based on the CronJob spec
@@ -55,21 +55,33 @@ func (e *CronJobBackupExecutor) PerformBackup(client k8sclient.Client, timeout t
 		Spec: jobTemplate.Spec,
 	}
 	if err := client.Create(context.TODO(), job); err != nil {
-		return fmt.Errorf("Error creating Job from CronJob %s in namespace %s: %v",
+		return nil, fmt.Errorf("Error creating Job from CronJob %s in namespace %s: %v",
 			e.CronJobName, e.Namespace, err)
 	}
 
-	// Query the newly created job until either it finishes, or it times out
+	if err := waitForJob(client, e.Namespace, jobName, timeout); err != nil {
+		return nil, err
+	}
+	return []Artifact{{
+		Kind:        "Job",
+		Namespace:   e.Namespace,
+		Name:        jobName,
+		CompletedAt: time.Now(),
+	}}, nil
+}
+
+// waitForJob queries the Job until either it finishes, or it times out
+func waitForJob(client k8sclient.Client, namespace, jobName string, timeout time.Duration) error {
 	timeStarted := time.Now()
 	for {
 		if time.Now().After(timeStarted.Add(timeout)) {
-			return fmt.Errorf("Timed out when waiting for Job %s to finish", "")
+			return fmt.Errorf("Timed out when waiting for Job %s to finish", jobName)
 		}
 
 		queryJob := &batchv1.Job{}
-		err = client.Get(context.TODO(), types.NamespacedName{Name: jobName, Namespace: e.Namespace}, queryJob)
+		err := client.Get(context.TODO(), types.NamespacedName{Name: jobName, Namespace: namespace}, queryJob)
 		if err != nil {
-			return fmt.Errorf("Error querying newly created Job %s in namespace %s: %v", "", e.Namespace, err)
+			return fmt.Errorf("Error querying newly created Job %s in namespace %s: %v", jobName, namespace, err)
 		}
 
 		// If the completion time field is set, the job finished succesfully
@@ -81,6 +93,8 @@ func (e *CronJobBackupExecutor) PerformBackup(client k8sclient.Client, timeout t
 		if err := getJobError(queryJob); err != nil {
 			return fmt.Errorf("Error performing backup job: %w", err)
 		}
+
+		time.Sleep(pollInterval)
 	}
 }
 
@@ -79,10 +79,13 @@ func TestCronJob(t *testing.T) {
 	}()
 
 	// Call `PerformBackup` and assert that no error is returned
-	err := executor.PerformBackup(client, time.Second*10)
+	artifacts, err := executor.PerformBackup(client, time.Second*10)
 	if err != nil {
 		t.Errorf("Unexpected error running backup from CronJob: %v", err)
 	}
+	if len(artifacts) != 1 || !strings.HasPrefix(artifacts[0].Name, generateJobName) {
+		t.Errorf("Expected the Job to be returned as artifact, got %v", artifacts)
+	}
 }
 
 func TestCronJob_NoCronJob(t *testing.T) {
@@ -95,7 +98,7 @@ func TestCronJob_NoCronJob(t *testing.T) {
 	client := createMockClientForCronJob(t)
 	executor := NewCronJobBackupExecutor(cronJobName, namespace, generateJobName)
 
-	err := executor.PerformBackup(client, time.Second*1)
+	_, err := executor.PerformBackup(client, time.Second*1)
 	if err == nil {
 		t.Errorf("Expected backup to fail as no CronJob is found")
 	}
@@ -171,7 +174,7 @@ func TestCronJob_FailedJob(t *testing.T) {
 	}()
 
 	// Call `PerformBackup` and assert that no error is returned
-	err := executor.PerformBackup(client, time.Second*10)
+	_, err := executor.PerformBackup(client, time.Second*10)
 	if err == nil {
 		t.Error("Expected backup to fail as Job failed")
 	}
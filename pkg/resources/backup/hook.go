@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PreUpgradeHook performs the backups of a product before its operator is
+// upgraded, within the pre-upgrade backup timeout of the installation, and
+// records their artifacts in the status of the product
+type PreUpgradeHook struct {
+	Executor      BackupExecutor
+	Installation  *integreatlyv1alpha1.RHMI
+	ProductStatus *integreatlyv1alpha1.RHMIProductStatus
+}
+
+func NewPreUpgradeHook(executor BackupExecutor, installation *integreatlyv1alpha1.RHMI, productStatus *integreatlyv1alpha1.RHMIProductStatus) BackupExecutor {
+	return &PreUpgradeHook{
+		Executor:      executor,
+		Installation:  installation,
+		ProductStatus: productStatus,
+	}
+}
+
+// PerformBackup performs the backups of the executor. The timeout of the
+// installation spec replaces the default timeout of the caller. The backups
+// are skipped in dry run, as they can't be previewed
+func (h *PreUpgradeHook) PerformBackup(client k8sclient.Client, _ time.Duration) ([]Artifact, error) {
+	if h.Installation.IsDryRun() {
+		return nil, &dryrun.SkippedError{Operation: "pre-upgrade backup"}
+	}
+
+	status := &integreatlyv1alpha1.PreUpgradeBackupStatus{StartedAt: metav1.Now()}
+	artifacts, err := h.Executor.PerformBackup(client, h.Installation.PreUpgradeBackupTimeout())
+	for _, artifact := range artifacts {
+		status.Artifacts = append(status.Artifacts, integreatlyv1alpha1.BackupArtifact{
+			Kind:        artifact.Kind,
+			Namespace:   artifact.Namespace,
+			Name:        artifact.Name,
+			CompletedAt: metav1.NewTime(artifact.CompletedAt),
+		})
+	}
+	completedAt := metav1.Now()
+	status.CompletedAt = &completedAt
+	status.Phase = integreatlyv1alpha1.BackupCompleted
+	if err != nil {
+		status.Phase = integreatlyv1alpha1.BackupFailed
+		status.Message = err.Error()
+	}
+	h.ProductStatus.PreUpgradeBackup = status
+	return artifacts, err
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	"github.com/integr8ly/integreatly-operator/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPreUpgradeHook(t *testing.T) {
+	tests := []struct {
+		name          string
+		installation  *integreatlyv1alpha1.RHMI
+		executor      BackupExecutor
+		wantErr       bool
+		wantSkipped   bool
+		wantPhase     integreatlyv1alpha1.BackupPhase
+		wantArtifacts int
+	}{
+		{
+			name:          "test completed backups are recorded with their artifacts",
+			installation:  &integreatlyv1alpha1.RHMI{},
+			executor:      NewConcurrentBackupExecutor(mockBackupExecutor{}, mockBackupExecutor{}),
+			wantPhase:     integreatlyv1alpha1.BackupCompleted,
+			wantArtifacts: 2,
+		},
+		{
+			name: "test backups use the timeout of the installation",
+			installation: &integreatlyv1alpha1.RHMI{
+				Spec: integreatlyv1alpha1.RHMISpec{
+					PreUpgradeBackup: &integreatlyv1alpha1.PreUpgradeBackupSpec{Timeout: &metav1.Duration{Duration: time.Millisecond}},
+				},
+			},
+			executor:  mockBackupExecutor{SleepTime: time.Second},
+			wantErr:   true,
+			wantPhase: integreatlyv1alpha1.BackupFailed,
+		},
+		{
+			name: "test backups are skipped in dry run",
+			installation: &integreatlyv1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{integreatlyv1alpha1.DryRunAnnotation: "true"}},
+			},
+			executor:    mockBackupExecutor{},
+			wantErr:     true,
+			wantSkipped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productStatus := &integreatlyv1alpha1.RHMIProductStatus{}
+			hook := NewPreUpgradeHook(tt.executor, tt.installation, productStatus)
+
+			// the timeout of the caller is ignored
+			artifacts, err := hook.PerformBackup(utils.NewTestClient(runtime.NewScheme()), time.Hour)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PerformBackup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			skipped := &dryrun.SkippedError{}
+			if errors.As(err, &skipped) != tt.wantSkipped {
+				t.Errorf("expected skipped %v, got error %v", tt.wantSkipped, err)
+			}
+			if len(artifacts) != tt.wantArtifacts {
+				t.Errorf("expected %d artifacts, got %d", tt.wantArtifacts, len(artifacts))
+			}
+
+			status := productStatus.PreUpgradeBackup
+			if tt.wantSkipped {
+				if status != nil {
+					t.Errorf("expected no backup status in dry run, got %+v", status)
+				}
+				return
+			}
+			if status == nil {
+				t.Fatal("expected the backup status to be recorded")
+			}
+			if status.Phase != tt.wantPhase {
+				t.Errorf("expected phase %s, got %s", tt.wantPhase, status.Phase)
+			}
+			if len(status.Artifacts) != tt.wantArtifacts {
+				t.Errorf("expected %d artifacts in the status, got %d", tt.wantArtifacts, len(status.Artifacts))
+			}
+			if status.CompletedAt == nil || status.CompletedAt.Before(&status.StartedAt) {
+				t.Errorf("unexpected backup times %v - %v", status.StartedAt, status.CompletedAt)
+			}
+			if tt.wantErr && status.Message == "" {
+				t.Error("expected the failure to be recorded in the message")
+			}
+		})
+	}
+}
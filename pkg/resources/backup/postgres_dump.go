@@ -0,0 +1,153 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PostgresDumpArtifactKind is the kind of the artifacts of the
+	// PostgresDumpBackupExecutor
+	PostgresDumpArtifactKind = "PostgresDump"
+
+	postgresDumpImage      = "registry.redhat.io/rhel8/postgresql-13"
+	postgresDumpVolumeSize = "5Gi"
+	postgresDumpMountPath  = "/backups"
+)
+
+// PostgresDumpBackupExecutor performs backups of the Postgres instances of the
+// cluster storage, which have no snapshots, by dumping their database to a
+// volume with a Job
+type PostgresDumpBackupExecutor struct {
+	Namespace    string // Namespace of the Postgres CR, where the Job and the volume are created
+	ResourceName string // Name of the Postgres CR
+}
+
+func NewPostgresDumpBackupExecutor(namespace, resourceName string) BackupExecutor {
+	return &PostgresDumpBackupExecutor{
+		Namespace:    namespace,
+		ResourceName: resourceName,
+	}
+}
+
+// PerformBackup runs pg_dump against the Postgres instance with its connection
+// secret and waits for it to finish. The dumps are kept on the volume of the
+// instance, one file per backup
+func (e *PostgresDumpBackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]Artifact, error) {
+	log.Infof("Performing backup by dumping Postgres", l.Fields{"resourceName": e.ResourceName, "ns": e.Namespace})
+
+	postgres := &v1alpha1.Postgres{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: e.ResourceName, Namespace: e.Namespace}, postgres); err != nil {
+		return nil, fmt.Errorf("Error obtaining Postgres %s in namespace %s: %v", e.ResourceName, e.Namespace, err)
+	}
+	secretRef := postgres.Status.SecretRef
+	if secretRef == nil {
+		return nil, fmt.Errorf("Postgres %s has no connection secret yet", e.ResourceName)
+	}
+	if secretRef.Namespace != "" && secretRef.Namespace != e.Namespace {
+		return nil, fmt.Errorf("connection secret of Postgres %s isn't in namespace %s", e.ResourceName, e.Namespace)
+	}
+
+	volumeName, err := e.reconcileVolume(client)
+	if err != nil {
+		return nil, err
+	}
+
+	jobName := fmt.Sprintf("%s-preupgrade-dump-%s", e.ResourceName, time.Now().Format("2006-01-02-150405"))
+	dumpFile := jobName + ".dump"
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: e.Namespace,
+			Labels:    map[string]string{"integreatly": "yes"},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &[]int32{2}[0],
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "pg-dump",
+							Image:   postgresDumpImage,
+							Command: []string{"pg_dump", "--format=custom", "--file=" + postgresDumpMountPath + "/" + dumpFile},
+							Env: []corev1.EnvVar{
+								secretEnvVar("PGHOST", secretRef.Name, "host"),
+								secretEnvVar("PGPORT", secretRef.Name, "port"),
+								secretEnvVar("PGUSER", secretRef.Name, "username"),
+								secretEnvVar("PGPASSWORD", secretRef.Name, "password"),
+								secretEnvVar("PGDATABASE", secretRef.Name, "database"),
+							},
+							VolumeMounts: []corev1.VolumeMount{{Name: "backups", MountPath: postgresDumpMountPath}},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "backups",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: volumeName},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := client.Create(context.TODO(), job); err != nil {
+		return nil, fmt.Errorf("Error creating Job to dump Postgres %s in namespace %s: %v", e.ResourceName, e.Namespace, err)
+	}
+
+	if err := waitForJob(client, e.Namespace, jobName, timeout); err != nil {
+		return nil, err
+	}
+	return []Artifact{{
+		Kind:        PostgresDumpArtifactKind,
+		Namespace:   e.Namespace,
+		Name:        volumeName + "/" + dumpFile,
+		CompletedAt: time.Now(),
+	}}, nil
+}
+
+// reconcileVolume creates the volume the dumps of the instance are written
+// to, and returns its name
+func (e *PostgresDumpBackupExecutor) reconcileVolume(client k8sclient.Client) (string, error) {
+	volume := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-preupgrade-dumps", e.ResourceName),
+			Namespace: e.Namespace,
+			Labels:    map[string]string{"integreatly": "yes"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(postgresDumpVolumeSize)},
+			},
+		},
+	}
+	if err := client.Create(context.TODO(), volume); err != nil && !k8serr.IsAlreadyExists(err) {
+		return "", fmt.Errorf("Error creating volume for the dumps of Postgres %s in namespace %s: %v", e.ResourceName, e.Namespace, err)
+	}
+	return volume.Name, nil
+}
+
+func secretEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	crov1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croTypes "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPostgresDump(t *testing.T) {
+	var (
+		namespace    = "test-namespace"
+		resourceName = "threescale-postgres-rhoam"
+	)
+
+	postgres := &crov1.Postgres{
+		ObjectMeta: v1.ObjectMeta{Name: resourceName, Namespace: namespace},
+		Status: croTypes.ResourceTypeStatus{
+			SecretRef: &croTypes.SecretRef{Name: "threescale-postgres", Namespace: namespace},
+		},
+	}
+	client := createMockClientForCronJob(t, postgres)
+	executor := NewPostgresDumpBackupExecutor(namespace, resourceName)
+
+	// complete the dump Job once it's created, `PerformBackup` blocks until
+	// it finishes
+	go func() {
+		for {
+			jobs := &batchv1.JobList{}
+			if err := client.List(context.TODO(), jobs, k8sclient.InNamespace(namespace)); err != nil || len(jobs.Items) == 0 {
+				time.Sleep(pollInterval)
+				continue
+			}
+			job := jobs.Items[0]
+			job.Status.CompletionTime = &v1.Time{Time: time.Now()}
+			_ = client.Status().Update(context.TODO(), &job)
+			return
+		}
+	}()
+
+	artifacts, err := executor.PerformBackup(client, time.Second*10)
+	if err != nil {
+		t.Fatalf("Unexpected error dumping Postgres: %v", err)
+	}
+	if len(artifacts) != 1 || artifacts[0].Kind != PostgresDumpArtifactKind ||
+		!strings.HasPrefix(artifacts[0].Name, resourceName+"-preupgrade-dumps/"+resourceName+"-preupgrade-dump-") {
+		t.Errorf("Expected the dump to be returned as artifact, got %v", artifacts)
+	}
+
+	volume := &corev1.PersistentVolumeClaim{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: resourceName + "-preupgrade-dumps", Namespace: namespace}, volume); err != nil {
+		t.Errorf("Expected the dumps volume to be created: %v", err)
+	}
+	jobs := &batchv1.JobList{}
+	if err := client.List(context.TODO(), jobs, k8sclient.InNamespace(namespace)); err != nil || len(jobs.Items) != 1 {
+		t.Fatalf("Expected a single dump Job, got %v: %v", jobs.Items, err)
+	}
+	for _, env := range jobs.Items[0].Spec.Template.Spec.Containers[0].Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || env.ValueFrom.SecretKeyRef.Name != "threescale-postgres" {
+			t.Errorf("Expected %s to come from the connection secret", env.Name)
+		}
+	}
+}
+
+func TestPostgresDump_NoSecret(t *testing.T) {
+	postgres := &crov1.Postgres{
+		ObjectMeta: v1.ObjectMeta{Name: "rhsso-postgres-rhoam", Namespace: "test-namespace"},
+	}
+	client := createMockClientForCronJob(t, postgres)
+
+	_, err := NewPostgresDumpBackupExecutor("test-namespace", "rhsso-postgres-rhoam").PerformBackup(client, time.Second)
+	if err == nil {
+		t.Error("Expected the dump to fail as Postgres has no connection secret")
+	}
+}
@@ -0,0 +1,162 @@
+package buildinfo
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/version"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultCacheTTL is how long the build info of an installation is reused
+// before its pods are listed again
+const DefaultCacheTTL = 5 * time.Minute
+
+// BuildInfo describes the operator build and every operand image running
+// in the installation namespaces
+type BuildInfo struct {
+	Version    string           `json:"version"`
+	GitSHA     string           `json:"gitSHA"`
+	BuildDate  string           `json:"buildDate"`
+	GoVersion  string           `json:"goVersion"`
+	Components []ComponentImage `json:"components"`
+}
+
+// ComponentImage is an image running in a container of a component. Digest is
+// the digest resolved by the kubelet, which may differ from the image
+// reference when it's a tag
+type ComponentImage struct {
+	Component string `json:"component"`
+	Namespace string `json:"namespace"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Digest    string `json:"digest"`
+}
+
+// Get returns the build info of the operator and the operand images running
+// in the namespaces of the installation. Only the namespaces labelled as owned
+// by the installation are listed. Each component is named after its namespace
+// without the installation namespace prefix
+func Get(ctx context.Context, client k8sclient.Reader, installation *integreatlyv1alpha1.RHMI) (*BuildInfo, error) {
+	info := &BuildInfo{
+		Version:    version.GetVersion(),
+		GitSHA:     version.GetGitSHA(),
+		BuildDate:  version.GetBuildDate(),
+		GoVersion:  runtime.Version(),
+		Components: []ComponentImage{},
+	}
+	if installation == nil || installation.Spec.NamespacePrefix == "" {
+		return info, nil
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := client.List(ctx, namespaces, k8sclient.MatchingLabels{resources.OwnerLabelKey: string(installation.GetUID())}); err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	seen := map[ComponentImage]bool{}
+	for _, ns := range namespaces.Items {
+		if !strings.HasPrefix(ns.Name, installation.Spec.NamespacePrefix) {
+			continue
+		}
+
+		pods := &corev1.PodList{}
+		if err := client.List(ctx, pods, k8sclient.InNamespace(ns.Name)); err != nil {
+			return nil, fmt.Errorf("failed to list pods in namespace %s: %w", ns.Name, err)
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+				image := ComponentImage{
+					Component: strings.TrimPrefix(ns.Name, installation.Spec.NamespacePrefix),
+					Namespace: ns.Name,
+					Container: status.Name,
+					Image:     status.Image,
+					Digest:    imageDigest(status.ImageID),
+				}
+				if seen[image] {
+					continue
+				}
+				seen[image] = true
+				info.Components = append(info.Components, image)
+			}
+		}
+	}
+
+	sort.Slice(info.Components, func(i, j int) bool {
+		a, b := info.Components[i], info.Components[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.Digest < b.Digest
+	})
+
+	return info, nil
+}
+
+// imageDigest returns the digest of a container status image ID, which can be
+// prefixed with the runtime scheme, e.g. docker-pullable://quay.io/x@sha256:...
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if i := strings.LastIndex(imageID, "://"); i >= 0 {
+		return imageID[i+3:]
+	}
+	return imageID
+}
+
+// Cache keeps the build info of the installation, so frequent callers such as
+// the reconciles and the admin API don't list the pods every time
+type Cache struct {
+	client k8sclient.Reader
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	uid       types.UID
+	info      *BuildInfo
+	retrieved time.Time
+}
+
+// NewCache returns a Cache getting the build info with client at most once
+// per ttl
+func NewCache(client k8sclient.Reader, ttl time.Duration) *Cache {
+	return &Cache{client: client, ttl: ttl, now: time.Now}
+}
+
+// Get returns the build info of the installation, getting it again when the
+// cached one is older than the ttl or of another installation
+func (c *Cache) Get(ctx context.Context, installation *integreatlyv1alpha1.RHMI) (*BuildInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var uid types.UID
+	if installation != nil {
+		uid = installation.GetUID()
+	}
+	if c.info != nil && c.uid == uid && c.now().Sub(c.retrieved) < c.ttl {
+		return c.info, nil
+	}
+
+	info, err := Get(ctx, c.client, installation)
+	if err != nil {
+		return nil, err
+	}
+	c.info, c.uid, c.retrieved = info, uid, c.now()
+	return info, nil
+}
@@ -0,0 +1,134 @@
+package buildinfo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func pod(namespace, name string, phase corev1.PodPhase, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func TestGet(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := utils.NewTestManagedApiInstallation()
+	owned := map[string]string{resources.OwnerLabelKey: string(installation.UID)}
+	apicast := corev1.ContainerStatus{
+		Name:    "apicast",
+		Image:   "registry.redhat.io/3scale-amp2/apicast-gateway-rhel8:3scale2.14",
+		ImageID: "docker-pullable://registry.redhat.io/3scale-amp2/apicast-gateway-rhel8@sha256:aaa",
+	}
+
+	tests := []struct {
+		name           string
+		installation   *integreatlyv1alpha1.RHMI
+		initObjs       []runtime.Object
+		wantComponents []ComponentImage
+	}{
+		{
+			name:           "test only operator build info without an installation",
+			wantComponents: []ComponentImage{},
+		},
+		{
+			name:         "test running images in installation namespaces are listed once",
+			installation: installation,
+			initObjs: []runtime.Object{
+				namespace(utils.TestNamespacePrefix+"3scale", owned),
+				namespace(utils.TestNamespacePrefix+"unowned", nil),
+				namespace("openshift-monitoring", nil),
+				pod(utils.TestNamespacePrefix+"unowned", "unowned-1", corev1.PodRunning, apicast),
+				pod(utils.TestNamespacePrefix+"3scale", "apicast-production-1", corev1.PodRunning, apicast),
+				pod(utils.TestNamespacePrefix+"3scale", "apicast-production-2", corev1.PodRunning, apicast),
+				pod(utils.TestNamespacePrefix+"3scale", "apicast-staging-1", corev1.PodPending, corev1.ContainerStatus{Name: "pending"}),
+				pod("openshift-monitoring", "prometheus-0", corev1.PodRunning, corev1.ContainerStatus{Name: "prometheus", ImageID: "sha256:bbb"}),
+			},
+			wantComponents: []ComponentImage{
+				{
+					Component: "3scale",
+					Namespace: utils.TestNamespacePrefix + "3scale",
+					Container: "apicast",
+					Image:     apicast.Image,
+					Digest:    "sha256:aaa",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+
+			info, err := Get(context.TODO(), client, tt.installation)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.GitSHA == "" || info.BuildDate == "" || info.Version == "" {
+				t.Fatalf("expected operator build info, got %+v", info)
+			}
+			if !reflect.DeepEqual(info.Components, tt.wantComponents) {
+				t.Fatalf("expected components %+v, got %+v", tt.wantComponents, info.Components)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installation := utils.NewTestManagedApiInstallation()
+	ns := utils.TestNamespacePrefix + "3scale"
+	client := utils.NewTestClient(scheme,
+		namespace(ns, map[string]string{resources.OwnerLabelKey: string(installation.UID)}),
+		pod(ns, "apicast-production-1", corev1.PodRunning, corev1.ContainerStatus{Name: "apicast", ImageID: "sha256:aaa"}),
+	)
+
+	now := time.Now()
+	cache := NewCache(client, time.Minute)
+	cache.now = func() time.Time { return now }
+	get := func() []ComponentImage {
+		info, err := cache.Get(context.TODO(), installation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Components
+	}
+
+	if components := get(); len(components) != 1 {
+		t.Fatalf("expected the apicast image, got %+v", components)
+	}
+	if err := client.Create(context.TODO(), pod(ns, "backend-listener-1", corev1.PodRunning, corev1.ContainerStatus{Name: "backend-listener", ImageID: "sha256:bbb"})); err != nil {
+		t.Fatal(err)
+	}
+	if components := get(); len(components) != 1 {
+		t.Fatalf("expected the cached build info within the ttl, got %+v", components)
+	}
+	now = now.Add(time.Minute)
+	if components := get(); len(components) != 2 {
+		t.Fatalf("expected the build info to be refreshed after the ttl, got %+v", components)
+	}
+}
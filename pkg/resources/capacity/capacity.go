@@ -0,0 +1,165 @@
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ProfileConfigMapName is the name of the ConfigMap, in the installation
+	// namespace, that contains the capacity profile of the installation
+	ProfileConfigMapName = "rhoam-capacity-profile"
+	// ProfileQuotaKey is the key of the ConfigMap that contains the quota tier
+	// the profile was generated for
+	ProfileQuotaKey = "quota"
+	// ProfileKey is the key of the ConfigMap that contains the JSON profile
+	ProfileKey = "profile.json"
+)
+
+// Usage is the total of the resources claimed by a set of pods and PVCs
+type Usage struct {
+	CPURequests    resource.Quantity `json:"cpuRequests"`
+	CPULimits      resource.Quantity `json:"cpuLimits"`
+	MemoryRequests resource.Quantity `json:"memoryRequests"`
+	MemoryLimits   resource.Quantity `json:"memoryLimits"`
+	Storage        resource.Quantity `json:"storage"`
+}
+
+// Profile is the capacity claimed by an installation for its quota tier
+type Profile struct {
+	Quota      string            `json:"quota"`
+	Namespaces map[string]*Usage `json:"namespaces"`
+	Total      *Usage            `json:"total"`
+}
+
+func (u *Usage) add(other *Usage) {
+	u.CPURequests.Add(other.CPURequests)
+	u.CPULimits.Add(other.CPULimits)
+	u.MemoryRequests.Add(other.MemoryRequests)
+	u.MemoryLimits.Add(other.MemoryLimits)
+	u.Storage.Add(other.Storage)
+}
+
+func (u *Usage) addContainer(container corev1.Container) {
+	if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+		u.CPURequests.Add(cpu)
+	}
+	if cpu, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+		u.CPULimits.Add(cpu)
+	}
+	if memory, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+		u.MemoryRequests.Add(memory)
+	}
+	if memory, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+		u.MemoryLimits.Add(memory)
+	}
+}
+
+// GetProfile sums the requests and limits of the running containers and the
+// storage requested by the PVCs in every namespace owned by the installation
+func GetProfile(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI) (*Profile, error) {
+	nsList := &corev1.NamespaceList{}
+	if err := client.List(ctx, nsList, k8sclient.MatchingLabels{resources.OwnerLabelKey: string(installation.GetUID())}); err != nil {
+		return nil, fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+
+	profile := &Profile{
+		Quota:      installation.Status.Quota,
+		Namespaces: map[string]*Usage{},
+		Total:      &Usage{},
+	}
+
+	namespaces := []string{installation.Namespace}
+	for _, ns := range nsList.Items {
+		if ns.Name != installation.Namespace {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		usage, err := getNamespaceUsage(ctx, client, ns)
+		if err != nil {
+			return nil, err
+		}
+		profile.Namespaces[ns] = usage
+		profile.Total.add(usage)
+	}
+
+	return profile, nil
+}
+
+func getNamespaceUsage(ctx context.Context, client k8sclient.Client, namespace string) (*Usage, error) {
+	usage := &Usage{}
+
+	pods := &corev1.PodList{}
+	if err := client.List(ctx, pods, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+	}
+	for _, pod := range pods.Items {
+		// Completed pods no longer claim any capacity
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			usage.addContainer(container)
+		}
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := client.List(ctx, pvcs, k8sclient.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims in namespace %s: %w", namespace, err)
+	}
+	for _, pvc := range pvcs.Items {
+		if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			usage.Storage.Add(storage)
+		}
+	}
+
+	return usage, nil
+}
+
+// ReconcileProfileConfigMap generates the capacity profile of the installation
+// and stores it in the ProfileConfigMapName ConfigMap, so it can be used for
+// capacity planning without summing the resources of every workload
+func ReconcileProfileConfigMap(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI) error {
+	profile, err := GetProfile(ctx, client, installation)
+	if err != nil {
+		return err
+	}
+
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal capacity profile: %w", err)
+	}
+
+	cfgMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ProfileConfigMapName,
+			Namespace: installation.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, client, cfgMap, func() error {
+		resources.PrepareObjectLabels(cfgMap, installation, false, false, false)
+		cfgMap.Data = map[string]string{
+			ProfileQuotaKey: profile.Quota,
+			ProfileKey:      string(profileJSON),
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create or update capacity profile configmap: %w", err)
+	}
+
+	return nil
+}
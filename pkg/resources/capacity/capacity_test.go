@@ -0,0 +1,134 @@
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileProfileConfigMap(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rhoam",
+			Namespace: "redhat-rhoam-operator",
+			UID:       "installation-uid",
+		},
+		Status: integreatlyv1alpha1.RHMIStatus{
+			Quota: "100 Million",
+		},
+	}
+
+	ownedNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{resources.OwnerLabelKey: string(installation.UID)},
+			},
+		}
+	}
+	pod := func(name, namespace string, phase corev1.PodPhase, cpu, memory string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse(cpu),
+								corev1.ResourceMemory: resource.MustParse(memory),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse(cpu),
+								corev1.ResourceMemory: resource.MustParse(memory),
+							},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	initObjs := []runtime.Object{
+		ownedNamespace("redhat-rhoam-3scale"),
+		ownedNamespace("redhat-rhoam-rhsso"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "not-rhoam"}},
+		pod("apicast", "redhat-rhoam-3scale", corev1.PodRunning, "500m", "1Gi"),
+		pod("backend", "redhat-rhoam-3scale", corev1.PodRunning, "250m", "512Mi"),
+		pod("deploy", "redhat-rhoam-3scale", corev1.PodSucceeded, "1", "1Gi"),
+		pod("keycloak", "redhat-rhoam-rhsso", corev1.PodRunning, "1", "2Gi"),
+		pod("other", "not-rhoam", corev1.PodRunning, "4", "8Gi"),
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "system-storage",
+				Namespace: "redhat-rhoam-3scale",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("10Gi"),
+					},
+				},
+			},
+		},
+	}
+
+	client := utils.NewTestClient(scheme, initObjs...)
+	if err := ReconcileProfileConfigMap(context.TODO(), client, installation); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfgMap := &corev1.ConfigMap{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: ProfileConfigMapName, Namespace: installation.Namespace}, cfgMap); err != nil {
+		t.Fatalf("failed to get capacity profile configmap: %v", err)
+	}
+	if cfgMap.Data[ProfileQuotaKey] != "100 Million" {
+		t.Fatalf("expected quota 100 Million, got %s", cfgMap.Data[ProfileQuotaKey])
+	}
+
+	profile := &Profile{}
+	if err := json.Unmarshal([]byte(cfgMap.Data[ProfileKey]), profile); err != nil {
+		t.Fatalf("failed to unmarshal profile: %v", err)
+	}
+
+	if _, ok := profile.Namespaces["not-rhoam"]; ok {
+		t.Fatal("expected namespaces not owned by the installation to be excluded")
+	}
+
+	threescale := profile.Namespaces["redhat-rhoam-3scale"]
+	if threescale == nil {
+		t.Fatal("expected redhat-rhoam-3scale namespace in profile")
+	}
+	if threescale.CPURequests.Cmp(resource.MustParse("750m")) != 0 {
+		t.Fatalf("expected 750m cpu requests, got %s", threescale.CPURequests.String())
+	}
+	if threescale.Storage.Cmp(resource.MustParse("10Gi")) != 0 {
+		t.Fatalf("expected 10Gi storage, got %s", threescale.Storage.String())
+	}
+
+	if profile.Total.CPULimits.Cmp(resource.MustParse("1750m")) != 0 {
+		t.Fatalf("expected 1750m total cpu limits, got %s", profile.Total.CPULimits.String())
+	}
+	if profile.Total.MemoryRequests.Cmp(resource.MustParse("3584Mi")) != 0 {
+		t.Fatalf("expected 3584Mi total memory requests, got %s", profile.Total.MemoryRequests.String())
+	}
+}
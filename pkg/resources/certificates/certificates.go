@@ -0,0 +1,102 @@
+package certificates
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Products serving the customer provided certificates, reconciled as soon as
+// one of them is rotated
+var Products = []v1alpha1.ProductName{
+	v1alpha1.Product3Scale,
+	v1alpha1.ProductRHSSO,
+	v1alpha1.ProductRHSSOUser,
+}
+
+// SecretNames returns the names of the TLS secrets of the custom domain routes
+// of the installation
+func SecretNames(installation *v1alpha1.RHMI) []string {
+	if installation.Spec.CustomDomain == nil {
+		return nil
+	}
+	found := map[string]bool{}
+	var names []string
+	for _, route := range installation.Spec.CustomDomain.Routes {
+		if !found[route.TLSSecret] {
+			found[route.TLSSecret] = true
+			names = append(names, route.TLSSecret)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReconcileStatus reports the fingerprint and expiry of the customer provided
+// certificates in the installation status and the expiry metric, and returns
+// the secrets whose certificate changed since the previous reconcile
+func ReconcileStatus(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI, now time.Time) ([]string, error) {
+	previous := map[string]v1alpha1.CertificateStatus{}
+	for _, status := range installation.Status.Certificates {
+		previous[status.Secret] = status
+	}
+
+	var rotated []string
+	var statuses []v1alpha1.CertificateStatus
+	expiry := map[string]time.Time{}
+	for _, name := range SecretNames(installation) {
+		secret := &corev1.Secret{}
+		if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: name, Namespace: installation.Namespace}, secret); err != nil {
+			if k8serr.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get TLS secret %s: %w", name, err)
+		}
+		certificate, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			// the custom route reports the invalid certificate
+			continue
+		}
+
+		sum := sha256.Sum256(certificate.Raw)
+		status := v1alpha1.CertificateStatus{
+			Secret:      name,
+			Fingerprint: hex.EncodeToString(sum[:]),
+			NotAfter:    metav1.NewTime(certificate.NotAfter),
+		}
+		if prev, ok := previous[name]; ok {
+			status.RotatedAt = prev.RotatedAt
+			if prev.Fingerprint != status.Fingerprint {
+				rotatedAt := metav1.NewTime(now)
+				status.RotatedAt = &rotatedAt
+				rotated = append(rotated, name)
+			}
+		}
+		statuses = append(statuses, status)
+		expiry[name] = certificate.NotAfter
+	}
+
+	installation.Status.Certificates = statuses
+	metrics.SetCertificateExpiry(expiry)
+	return rotated, nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
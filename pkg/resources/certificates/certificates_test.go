@@ -0,0 +1,134 @@
+package certificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestCertificate(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sso.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestReconcileStatus(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	now := time.Now().Truncate(time.Second)
+	notAfter := now.Add(60 * 24 * time.Hour)
+	certificate := newTestCertificate(t, notAfter)
+	renewedNotAfter := now.Add(90 * 24 * time.Hour)
+	renewedCertificate := newTestCertificate(t, renewedNotAfter)
+
+	tlsSecret := func(certificate []byte) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sso-tls", Namespace: namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: certificate},
+		}
+	}
+	customDomain := &v1alpha1.CustomDomainSpec{
+		Routes: []v1alpha1.CustomRouteSpec{
+			{Route: v1alpha1.CustomRouteRHSSO, Hostname: "sso.example.com", TLSSecret: "sso-tls"},
+			{Route: v1alpha1.CustomRouteRHSSOUser, Hostname: "user-sso.example.com", TLSSecret: "sso-tls"},
+			{Route: v1alpha1.CustomRouteThreeScaleAdmin, Hostname: "admin.example.com", TLSSecret: "admin-tls"},
+		},
+	}
+	previousRotation := metav1.NewTime(now.Add(-time.Hour))
+
+	fingerprint := func(certificate []byte) string {
+		block, _ := pem.Decode(certificate)
+		sum := sha256.Sum256(block.Bytes)
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		previous     []v1alpha1.CertificateStatus
+		wantRotated  []string
+		wantRotation *metav1.Time
+		wantNotAfter time.Time
+	}{
+		{
+			name:         "first seen certificates aren't reported as rotated",
+			objects:      []runtime.Object{tlsSecret(certificate)},
+			wantNotAfter: notAfter,
+		},
+		{
+			name:         "unchanged certificates keep their rotation time",
+			objects:      []runtime.Object{tlsSecret(certificate)},
+			previous:     []v1alpha1.CertificateStatus{{Secret: "sso-tls", Fingerprint: fingerprint(certificate), RotatedAt: &previousRotation}},
+			wantRotation: &previousRotation,
+			wantNotAfter: notAfter,
+		},
+		{
+			name:         "replaced certificates are reported as rotated",
+			objects:      []runtime.Object{tlsSecret(renewedCertificate)},
+			previous:     []v1alpha1.CertificateStatus{{Secret: "sso-tls", Fingerprint: fingerprint(certificate)}},
+			wantRotated:  []string{"sso-tls"},
+			wantRotation: &metav1.Time{Time: now},
+			wantNotAfter: renewedNotAfter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{CustomDomain: customDomain},
+				Status:     v1alpha1.RHMIStatus{Certificates: tt.previous},
+			}
+
+			rotated, err := ReconcileStatus(context.TODO(), serverClient, installation, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rotated, tt.wantRotated) {
+				t.Errorf("expected rotated secrets %v, got %v", tt.wantRotated, rotated)
+			}
+			if len(installation.Status.Certificates) != 1 || installation.Status.Certificates[0].Secret != "sso-tls" {
+				t.Fatalf("expected the status of the sso-tls certificate only, got %v", installation.Status.Certificates)
+			}
+			if !installation.Status.Certificates[0].NotAfter.Time.Equal(tt.wantNotAfter) {
+				t.Errorf("expected expiry %s, got %s", tt.wantNotAfter, installation.Status.Certificates[0].NotAfter)
+			}
+			if !reflect.DeepEqual(installation.Status.Certificates[0].RotatedAt, tt.wantRotation) {
+				t.Errorf("expected rotation time %v, got %v", tt.wantRotation, installation.Status.Certificates[0].RotatedAt)
+			}
+		})
+	}
+}
@@ -112,3 +112,13 @@ func GetClusterVersion(cr *configv1.ClusterVersion) (string, error) {
 	}
 	return "", fmt.Errorf("dedired.version not set in status block")
 }
+
+// IsClusterUpgrading returns true while the cluster version operator is rolling out a release
+func IsClusterUpgrading(cr *configv1.ClusterVersion) bool {
+	for _, condition := range cr.Status.Conditions {
+		if condition.Type == configv1.OperatorProgressing {
+			return condition.Status == configv1.ConditionTrue
+		}
+	}
+	return false
+}
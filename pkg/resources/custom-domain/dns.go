@@ -0,0 +1,175 @@
+package custom_domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultDNSRecordTTL = int64(300)
+
+// DNSRecord is a record in the custom domain zone, names are fully qualified
+// without the trailing dot
+type DNSRecord struct {
+	Name  string
+	Type  string
+	Value string
+	TTL   int64
+}
+
+// DNSProvider manages the records of the zone hosting the custom domain
+type DNSProvider interface {
+	// GetRecord returns the record of the given name and type, or nil if it
+	// doesn't exist
+	GetRecord(ctx context.Context, name, recordType string) (*DNSRecord, error)
+	// UpsertRecord creates the record or replaces its value
+	UpsertRecord(ctx context.Context, record DNSRecord) error
+}
+
+// NewDNSProvider returns the provider configured in the custom domain DNS spec
+// of the installation, with the credentials of its secret
+func NewDNSProvider(ctx context.Context, serverClient client.Client, installation *v1alpha1.RHMI) (DNSProvider, error) {
+	spec := installation.Spec.CustomDomainDNS
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, client.ObjectKey{Name: spec.CredentialsSecret, Namespace: installation.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get dns provider credentials secret %s: %w", spec.CredentialsSecret, err)
+	}
+
+	switch spec.Provider {
+	case v1alpha1.DNSProviderRoute53:
+		accessKeyID, secretAccessKey := string(secret.Data["accessKeyID"]), string(secret.Data["secretAccessKey"])
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, fmt.Errorf("dns provider credentials secret %s must contain accessKeyID and secretAccessKey", spec.CredentialsSecret)
+		}
+		provider := NewRoute53Provider(spec.ZoneID, accessKeyID, secretAccessKey)
+		provider.httpClient.Transport = dryrun.Transport(installation, provider.httpClient.Transport)
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unsupported dns provider %q", spec.Provider)
+	}
+}
+
+// ReconcileDNS publishes the records the custom domain requires when the
+// installation manages its DNS, and reports the state of each record in the
+// custom domain status. Records are Pending until the provider returns them
+// with the expected value, and Failed when they can't be reconciled
+func ReconcileDNS(ctx context.Context, serverClient client.Client, installation *v1alpha1.RHMI, newProvider func(context.Context, client.Client, *v1alpha1.RHMI) (DNSProvider, error)) error {
+	if installation.Spec.CustomDomainDNS == nil || !IsCustomDomain(installation) {
+		return nil
+	}
+
+	records, err := desiredDNSRecords(ctx, serverClient, installation)
+	if err != nil {
+		installation.Status.CustomDomain.Records = failedDNSRecordStatuses(installation, err)
+		return err
+	}
+	provider, err := newProvider(ctx, serverClient, installation)
+	if err != nil {
+		installation.Status.CustomDomain.Records = failedDNSRecordStatuses(installation, err)
+		return err
+	}
+
+	statuses := make([]v1alpha1.DNSRecordStatus, 0, len(records))
+	for _, record := range records {
+		statuses = append(statuses, reconcileDNSRecord(ctx, provider, record))
+	}
+	installation.Status.CustomDomain.Records = statuses
+	return nil
+}
+
+func reconcileDNSRecord(ctx context.Context, provider DNSProvider, record DNSRecord) v1alpha1.DNSRecordStatus {
+	status := v1alpha1.DNSRecordStatus{Name: record.Name, Type: record.Type, Value: record.Value}
+
+	found, err := provider.GetRecord(ctx, record.Name, record.Type)
+	if err != nil {
+		status.State = v1alpha1.DNSRecordFailed
+		status.Message = err.Error()
+		return status
+	}
+	if found != nil && found.Value == record.Value {
+		status.State = v1alpha1.DNSRecordReady
+		return status
+	}
+
+	if err := provider.UpsertRecord(ctx, record); err != nil {
+		status.State = v1alpha1.DNSRecordFailed
+		status.Message = err.Error()
+		return status
+	}
+	status.State = v1alpha1.DNSRecordPending
+	return status
+}
+
+// failedDNSRecordStatuses reports every record of the custom domain as Failed
+// when they can't be reconciled at all. The value of the wildcard CNAME
+// isn't known without the ingress endpoint, so the last published value is
+// kept
+func failedDNSRecordStatuses(installation *v1alpha1.RHMI, err error) []v1alpha1.DNSRecordStatus {
+	cnameValue := ""
+	for _, status := range installation.Status.CustomDomain.Records {
+		if status.Type == "CNAME" {
+			cnameValue = status.Value
+		}
+	}
+
+	statuses := []v1alpha1.DNSRecordStatus{{
+		Name:    "*." + installation.Spec.RoutingSubdomain,
+		Type:    "CNAME",
+		Value:   cnameValue,
+		State:   v1alpha1.DNSRecordFailed,
+		Message: err.Error(),
+	}}
+	for _, txt := range installation.Spec.CustomDomainDNS.TXTRecords {
+		statuses = append(statuses, v1alpha1.DNSRecordStatus{
+			Name:    strings.TrimSuffix(txt.Name, "."),
+			Type:    "TXT",
+			Value:   txt.Value,
+			State:   v1alpha1.DNSRecordFailed,
+			Message: err.Error(),
+		})
+	}
+	return statuses
+}
+
+// desiredDNSRecords returns a wildcard CNAME pointing the gateway and portal
+// hosts of the custom domain to the endpoint of its ingress controller, and
+// the TXT records of the spec
+func desiredDNSRecords(ctx context.Context, serverClient client.Client, installation *v1alpha1.RHMI) ([]DNSRecord, error) {
+	domain := installation.Spec.RoutingSubdomain
+
+	customDomains := &customdomainv1alpha1.CustomDomainList{}
+	if err := serverClient.List(ctx, customDomains); err != nil {
+		return nil, err
+	}
+	endpoint := ""
+	for _, item := range customDomains.Items {
+		if item.Spec.Domain == domain {
+			endpoint = item.Status.Endpoint
+		}
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("no endpoint in the custom domain CR for: \"%s\"", domain)
+	}
+
+	records := []DNSRecord{{
+		Name:  "*." + domain,
+		Type:  "CNAME",
+		Value: strings.TrimSuffix(endpoint, "."),
+		TTL:   defaultDNSRecordTTL,
+	}}
+	for _, txt := range installation.Spec.CustomDomainDNS.TXTRecords {
+		records = append(records, DNSRecord{
+			Name:  strings.TrimSuffix(txt.Name, "."),
+			Type:  "TXT",
+			Value: txt.Value,
+			TTL:   defaultDNSRecordTTL,
+		})
+	}
+	return records, nil
+}
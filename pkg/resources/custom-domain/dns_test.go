@@ -0,0 +1,212 @@
+package custom_domain
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	customdomainv1alpha1 "github.com/openshift/custom-domains-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeDNSProvider struct {
+	records   map[string]DNSRecord
+	upsertErr error
+}
+
+func (p *fakeDNSProvider) GetRecord(_ context.Context, name, recordType string) (*DNSRecord, error) {
+	if record, ok := p.records[recordType+" "+name]; ok {
+		return &record, nil
+	}
+	return nil, nil
+}
+
+func (p *fakeDNSProvider) UpsertRecord(_ context.Context, record DNSRecord) error {
+	if p.upsertErr != nil {
+		return p.upsertErr
+	}
+	p.records[record.Type+" "+record.Name] = record
+	return nil
+}
+
+func TestReconcileDNS(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customDomainCR := &customdomainv1alpha1.CustomDomain{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam"},
+		Spec:       customdomainv1alpha1.CustomDomainSpec{Domain: "apps.example.com"},
+		Status:     customdomainv1alpha1.CustomDomainStatus{Endpoint: "rhoam.cluster.openshiftapps.com"},
+	}
+	installation := func(dns *v1alpha1.CustomDomainDNSSpec) *v1alpha1.RHMI {
+		return &v1alpha1.RHMI{
+			ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+			Spec:       v1alpha1.RHMISpec{RoutingSubdomain: "apps.example.com", CustomDomainDNS: dns},
+			Status:     v1alpha1.RHMIStatus{CustomDomain: &v1alpha1.CustomDomainStatus{Enabled: true}},
+		}
+	}
+	dnsSpec := &v1alpha1.CustomDomainDNSSpec{
+		Provider:   v1alpha1.DNSProviderRoute53,
+		TXTRecords: []v1alpha1.DNSTXTRecord{{Name: "_acme-challenge.apps.example.com.", Value: "token"}},
+	}
+
+	tests := []struct {
+		name         string
+		installation *v1alpha1.RHMI
+		initObjs     []runtime.Object
+		provider     *fakeDNSProvider
+		providerErr  error
+		wantErr      bool
+		want         []v1alpha1.DNSRecordStatus
+	}{
+		{
+			name:         "test nothing is done without a dns spec",
+			installation: installation(nil),
+			provider:     &fakeDNSProvider{records: map[string]DNSRecord{}},
+		},
+		{
+			name: "test records are failed without a custom domain endpoint",
+			installation: func() *v1alpha1.RHMI {
+				i := installation(dnsSpec)
+				i.Status.CustomDomain.Records = []v1alpha1.DNSRecordStatus{
+					{Name: "*.apps.example.com", Type: "CNAME", Value: "rhoam.cluster.openshiftapps.com", State: v1alpha1.DNSRecordReady},
+					{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", State: v1alpha1.DNSRecordReady},
+				}
+				return i
+			}(),
+			provider: &fakeDNSProvider{records: map[string]DNSRecord{}},
+			wantErr:  true,
+			want: []v1alpha1.DNSRecordStatus{
+				{Name: "*.apps.example.com", Type: "CNAME", Value: "rhoam.cluster.openshiftapps.com", State: v1alpha1.DNSRecordFailed, Message: "no endpoint in the custom domain CR for: \"apps.example.com\""},
+				{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", State: v1alpha1.DNSRecordFailed, Message: "no endpoint in the custom domain CR for: \"apps.example.com\""},
+			},
+		},
+		{
+			name:         "test records are failed when the provider can't be created",
+			installation: installation(dnsSpec),
+			initObjs:     []runtime.Object{customDomainCR},
+			providerErr:  errors.New("missing credentials"),
+			wantErr:      true,
+			want: []v1alpha1.DNSRecordStatus{
+				{Name: "*.apps.example.com", Type: "CNAME", State: v1alpha1.DNSRecordFailed, Message: "missing credentials"},
+				{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", State: v1alpha1.DNSRecordFailed, Message: "missing credentials"},
+			},
+		},
+		{
+			name:         "test missing records are created and pending",
+			installation: installation(dnsSpec),
+			initObjs:     []runtime.Object{customDomainCR},
+			provider:     &fakeDNSProvider{records: map[string]DNSRecord{}},
+			want: []v1alpha1.DNSRecordStatus{
+				{Name: "*.apps.example.com", Type: "CNAME", Value: "rhoam.cluster.openshiftapps.com", State: v1alpha1.DNSRecordPending},
+				{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", State: v1alpha1.DNSRecordPending},
+			},
+		},
+		{
+			name:         "test matching records are ready and failures are reported",
+			installation: installation(dnsSpec),
+			initObjs:     []runtime.Object{customDomainCR},
+			provider: &fakeDNSProvider{
+				records: map[string]DNSRecord{
+					"CNAME *.apps.example.com": {Name: "*.apps.example.com", Type: "CNAME", Value: "rhoam.cluster.openshiftapps.com"},
+				},
+				upsertErr: errors.New("access denied"),
+			},
+			want: []v1alpha1.DNSRecordStatus{
+				{Name: "*.apps.example.com", Type: "CNAME", Value: "rhoam.cluster.openshiftapps.com", State: v1alpha1.DNSRecordReady},
+				{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", State: v1alpha1.DNSRecordFailed, Message: "access denied"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.initObjs...)
+			newProvider := func(context.Context, client.Client, *v1alpha1.RHMI) (DNSProvider, error) {
+				if tt.providerErr != nil {
+					return nil, tt.providerErr
+				}
+				return tt.provider, nil
+			}
+
+			err := ReconcileDNS(context.TODO(), serverClient, tt.installation, newProvider)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReconcileDNS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.installation.Status.CustomDomain.Records; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected records %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRoute53Provider(t *testing.T) {
+	var upserted route53ChangeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/2013-04-01/hostedzone/Z123/rrset":
+			if req.URL.Query().Get("name") != `\052.apps.example.com` {
+				_, _ = io.WriteString(w, `<ListResourceRecordSetsResponse><ResourceRecordSets></ResourceRecordSets></ListResourceRecordSetsResponse>`)
+				return
+			}
+			_, _ = io.WriteString(w, `<ListResourceRecordSetsResponse><ResourceRecordSets><ResourceRecordSet>`+
+				`<Name>\052.apps.example.com.</Name><Type>CNAME</Type><TTL>300</TTL>`+
+				`<ResourceRecords><ResourceRecord><Value>rhoam.cluster.openshiftapps.com</Value></ResourceRecord></ResourceRecords>`+
+				`</ResourceRecordSet></ResourceRecordSets></ListResourceRecordSetsResponse>`)
+		case req.Method == http.MethodPost && req.URL.Path == "/2013-04-01/hostedzone/Z123/rrset/":
+			if err := xml.NewDecoder(req.Body).Decode(&upserted); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = io.WriteString(w, `<ChangeResourceRecordSetsResponse/>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<ErrorResponse><Error><Code>NoSuchHostedZone</Code><Message>not found</Message></Error></ErrorResponse>`)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewRoute53Provider("/hostedzone/Z123", "access", "secret")
+	provider.Endpoint = server.URL
+
+	record, err := provider.GetRecord(context.TODO(), "*.apps.example.com", "CNAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "rhoam.cluster.openshiftapps.com" {
+		t.Fatalf("expected wildcard cname record, got %v", record)
+	}
+
+	record, err = provider.GetRecord(context.TODO(), "_acme-challenge.apps.example.com", "TXT")
+	if err != nil || record != nil {
+		t.Fatalf("expected no txt record, got %v, %v", record, err)
+	}
+
+	if err := provider.UpsertRecord(context.TODO(), DNSRecord{Name: "_acme-challenge.apps.example.com", Type: "TXT", Value: "token", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if upserted.Action != "UPSERT" || upserted.Record.Name != "_acme-challenge.apps.example.com" || !reflect.DeepEqual(upserted.Record.ResourceRecords, []string{`"token"`}) {
+		t.Fatalf("unexpected change request %+v", upserted)
+	}
+
+	provider.ZoneID = "missing"
+	if _, err := provider.GetRecord(context.TODO(), "*.apps.example.com", "CNAME"); err == nil || !strings.Contains(err.Error(), "NoSuchHostedZone") {
+		t.Fatalf("expected route53 error, got %v", err)
+	}
+}
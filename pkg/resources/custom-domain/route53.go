@@ -0,0 +1,154 @@
+package custom_domain
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+const (
+	route53Endpoint   = "https://route53.amazonaws.com"
+	route53APIVersion = "2013-04-01"
+	// route53 is a global service signed in us-east-1
+	route53SigningRegion = "us-east-1"
+)
+
+// Route53Provider manages the records of a Route53 hosted zone with the REST
+// API, the Route53 client isn't part of the vendored AWS SDK
+type Route53Provider struct {
+	Endpoint   string
+	ZoneID     string
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+var _ DNSProvider = &Route53Provider{}
+
+func NewRoute53Provider(zoneID, accessKeyID, secretAccessKey string) *Route53Provider {
+	return &Route53Provider{
+		Endpoint:   route53Endpoint,
+		ZoneID:     strings.TrimPrefix(zoneID, "/hostedzone/"),
+		signer:     v4.NewSigner(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, "")),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type route53ResourceRecordSet struct {
+	Name            string   `xml:"Name"`
+	Type            string   `xml:"Type"`
+	TTL             int64    `xml:"TTL"`
+	ResourceRecords []string `xml:"ResourceRecords>ResourceRecord>Value"`
+}
+
+type route53ListResponse struct {
+	ResourceRecordSets []route53ResourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name                 `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Action  string                   `xml:"ChangeBatch>Changes>Change>Action"`
+	Record  route53ResourceRecordSet `xml:"ChangeBatch>Changes>Change>ResourceRecordSet"`
+}
+
+type route53Error struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func (p *Route53Provider) GetRecord(ctx context.Context, name, recordType string) (*DNSRecord, error) {
+	query := url.Values{}
+	query.Set("name", strings.ReplaceAll(name, "*", `\052`))
+	query.Set("type", recordType)
+	query.Set("maxitems", "1")
+
+	body, err := p.do(ctx, http.MethodGet, "/rrset?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response := &route53ListResponse{}
+	if err := xml.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("failed to decode route53 record sets: %w", err)
+	}
+
+	// record sets are listed from the given name onwards
+	for _, set := range response.ResourceRecordSets {
+		if normalizeRoute53Name(set.Name) != strings.ToLower(name) || set.Type != recordType || len(set.ResourceRecords) == 0 {
+			continue
+		}
+		value := set.ResourceRecords[0]
+		if recordType == "TXT" {
+			value = strings.Trim(value, `"`)
+		}
+		return &DNSRecord{Name: name, Type: recordType, Value: strings.TrimSuffix(value, "."), TTL: set.TTL}, nil
+	}
+	return nil, nil
+}
+
+func (p *Route53Provider) UpsertRecord(ctx context.Context, record DNSRecord) error {
+	value := record.Value
+	if record.Type == "TXT" {
+		value = fmt.Sprintf("%q", value)
+	}
+	request := route53ChangeRequest{
+		Action: "UPSERT",
+		Record: route53ResourceRecordSet{
+			Name:            record.Name,
+			Type:            record.Type,
+			TTL:             record.TTL,
+			ResourceRecords: []string{value},
+		},
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+	_, err = p.do(ctx, http.MethodPost, "/rrset/", body)
+	return err
+}
+
+func (p *Route53Provider) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	reader := bytes.NewReader(body)
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/%s/hostedzone/%s%s", p.Endpoint, route53APIVersion, p.ZoneID, path), reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/xml")
+	}
+	if _, err := p.signer.Sign(req, reader, "route53", route53SigningRegion, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign route53 request: %w", err)
+	}
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error performing route53 request: %w", err)
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := &route53Error{}
+		if err := xml.Unmarshal(resBody, apiErr); err == nil && apiErr.Code != "" {
+			return nil, fmt.Errorf("route53 request failed: %s: %s", apiErr.Code, apiErr.Message)
+		}
+		return nil, fmt.Errorf("route53 request failed: %s", res.Status)
+	}
+	return resBody, nil
+}
+
+// normalizeRoute53Name removes the trailing dot and octal escaping Route53
+// adds to record names, such as \052 for a wildcard
+func normalizeRoute53Name(name string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSuffix(name, "."), `\052`, "*"))
+}
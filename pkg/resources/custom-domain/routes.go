@@ -0,0 +1,201 @@
+package custom_domain
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const customRouteSuffix = "-custom"
+
+// HostResolver returns the addresses of a hostname
+type HostResolver func(ctx context.Context, host string) ([]string, error)
+
+// DefaultHostResolver resolves the hostnames with the resolver of the operator
+// pod
+var DefaultHostResolver HostResolver = net.DefaultResolver.LookupHost
+
+// ReconcileCustomRoute serves the product route on the hostname of the custom
+// domain spec, with a route next to it using the certificate of the spec.
+// The route is created once the hostname resolves to the router of the
+// product route, and its certificate is only replaced by one that is valid
+// for the hostname. The state of the route is reported in the installation
+// status
+func ReconcileCustomRoute(ctx context.Context, serverClient client.Client, installation *v1alpha1.RHMI, name v1alpha1.CustomRouteName, route *routev1.Route, resolve HostResolver) error {
+	customRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      route.Name + customRouteSuffix,
+			Namespace: route.Namespace,
+		},
+	}
+
+	spec := getCustomRouteSpec(installation, name)
+	if spec == nil {
+		removeCustomRouteStatus(installation, name)
+		if err := serverClient.Delete(ctx, customRoute); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete custom route %s: %w", customRoute.Name, err)
+		}
+		return nil
+	}
+
+	status := v1alpha1.CustomRouteStatus{Route: name, Hostname: spec.Hostname}
+	exists := true
+	if err := serverClient.Get(ctx, client.ObjectKeyFromObject(customRoute), customRoute); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to get custom route %s: %w", customRoute.Name, err)
+		}
+		exists = false
+	}
+
+	certificate, key, caCertificate, err := getValidCertificate(ctx, serverClient, installation.Namespace, spec, time.Now())
+	if err != nil {
+		// the route keeps serving the previous certificate
+		status.State = v1alpha1.CustomRouteFailed
+		if exists && customRoute.Spec.Host == spec.Hostname {
+			status.State = v1alpha1.CustomRouteRolledBack
+		}
+		status.Message = err.Error()
+		setCustomRouteStatus(installation, status)
+		return nil
+	}
+
+	// the hostname is switched once the DNS points it to the router
+	if !exists || customRoute.Spec.Host != spec.Hostname {
+		if err := checkHostnameResolution(ctx, route, spec.Hostname, resolve); err != nil {
+			status.State = v1alpha1.CustomRoutePending
+			status.Message = err.Error()
+			setCustomRouteStatus(installation, status)
+			return nil
+		}
+	}
+
+	termination := routev1.TLSTerminationEdge
+	if route.Spec.TLS != nil && route.Spec.TLS.Termination != "" {
+		termination = route.Spec.TLS.Termination
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, customRoute, func() error {
+		customRoute.Spec = routev1.RouteSpec{
+			Host: spec.Hostname,
+			To:   route.Spec.To,
+			Port: route.Spec.Port,
+			TLS: &routev1.TLSConfig{
+				Termination:                   termination,
+				Certificate:                   certificate,
+				Key:                           key,
+				CACertificate:                 caCertificate,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			},
+			WildcardPolicy: routev1.WildcardPolicyNone,
+		}
+		if route.Spec.TLS != nil {
+			customRoute.Spec.TLS.DestinationCACertificate = route.Spec.TLS.DestinationCACertificate
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile custom route %s: %w", customRoute.Name, err)
+	}
+
+	status.State = v1alpha1.CustomRouteReady
+	setCustomRouteStatus(installation, status)
+	return nil
+}
+
+// getValidCertificate returns the certificate, key and CA of the TLS secret
+// of the custom route, when the certificate matches the key and is valid for
+// the hostname at the given time
+func getValidCertificate(ctx context.Context, serverClient client.Client, namespace string, spec *v1alpha1.CustomRouteSpec, now time.Time) (string, string, string, error) {
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, client.ObjectKey{Name: spec.TLSSecret, Namespace: namespace}, secret); err != nil {
+		return "", "", "", fmt.Errorf("failed to get TLS secret %s: %w", spec.TLSSecret, err)
+	}
+	certificate, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+
+	pair, err := tls.X509KeyPair(certificate, key)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid certificate in TLS secret %s: %w", spec.TLSSecret, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid certificate in TLS secret %s: %w", spec.TLSSecret, err)
+	}
+	if err := leaf.VerifyHostname(spec.Hostname); err != nil {
+		return "", "", "", fmt.Errorf("certificate in TLS secret %s is not valid for %s: %w", spec.TLSSecret, spec.Hostname, err)
+	}
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return "", "", "", fmt.Errorf("certificate in TLS secret %s is only valid from %s to %s", spec.TLSSecret, leaf.NotBefore, leaf.NotAfter)
+	}
+
+	return string(certificate), string(key), string(secret.Data["ca.crt"]), nil
+}
+
+// checkHostnameResolution returns an error unless the hostname resolves to
+// an address of the router exposing the product route
+func checkHostnameResolution(ctx context.Context, route *routev1.Route, hostname string, resolve HostResolver) error {
+	if len(route.Status.Ingress) == 0 || route.Status.Ingress[0].RouterCanonicalHostname == "" {
+		return fmt.Errorf("route %s is not admitted by a router yet", route.Name)
+	}
+	routerHostname := route.Status.Ingress[0].RouterCanonicalHostname
+
+	routerAddresses, err := resolve(ctx, routerHostname)
+	if err != nil {
+		return fmt.Errorf("unable to resolve router hostname %s: %w", routerHostname, err)
+	}
+	addresses, err := resolve(ctx, hostname)
+	if err != nil {
+		return fmt.Errorf("unable to resolve %s: %w", hostname, err)
+	}
+	for _, address := range addresses {
+		for _, routerAddress := range routerAddresses {
+			if address == routerAddress {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s doesn't resolve to the router %s yet", hostname, routerHostname)
+}
+
+func getCustomRouteSpec(installation *v1alpha1.RHMI, name v1alpha1.CustomRouteName) *v1alpha1.CustomRouteSpec {
+	if installation.Spec.CustomDomain == nil {
+		return nil
+	}
+	for i := range installation.Spec.CustomDomain.Routes {
+		if installation.Spec.CustomDomain.Routes[i].Route == name {
+			return &installation.Spec.CustomDomain.Routes[i]
+		}
+	}
+	return nil
+}
+
+func setCustomRouteStatus(installation *v1alpha1.RHMI, status v1alpha1.CustomRouteStatus) {
+	for i := range installation.Status.CustomRoutes {
+		if installation.Status.CustomRoutes[i].Route == status.Route {
+			installation.Status.CustomRoutes[i] = status
+			return
+		}
+	}
+	installation.Status.CustomRoutes = append(installation.Status.CustomRoutes, status)
+}
+
+func removeCustomRouteStatus(installation *v1alpha1.RHMI, name v1alpha1.CustomRouteName) {
+	statuses := installation.Status.CustomRoutes[:0]
+	for _, status := range installation.Status.CustomRoutes {
+		if status.Route != name {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	installation.Status.CustomRoutes = statuses
+}
@@ -0,0 +1,190 @@
+package custom_domain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newTestCertificate(t *testing.T, hostname string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		DNSNames:     []string{hostname},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestReconcileCustomRoute(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		hostname     = "sso.example.com"
+		routerHost   = "router-default.apps.cluster.example.com"
+		namespace    = "redhat-rhoam-operator"
+		ssoNamespace = "redhat-rhoam-rhsso"
+	)
+	validCert, validKey := newTestCertificate(t, hostname, time.Now().Add(time.Hour))
+	otherCert, otherKey := newTestCertificate(t, "other.example.com", time.Now().Add(time.Hour))
+	expiredCert, expiredKey := newTestCertificate(t, hostname, time.Now().Add(-time.Minute))
+
+	tlsSecret := func(cert, key string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "sso-tls", Namespace: namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte(cert), corev1.TLSPrivateKeyKey: []byte(key)},
+		}
+	}
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "keycloak-edge", Namespace: ssoNamespace},
+		Spec: routev1.RouteSpec{
+			Host: "keycloak-edge.apps.cluster.example.com",
+			To:   routev1.RouteTargetReference{Kind: "Service", Name: "keycloak"},
+			TLS:  &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt},
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{{RouterCanonicalHostname: routerHost}},
+		},
+	}
+	existingCustomRoute := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "keycloak-edge-custom", Namespace: ssoNamespace},
+		Spec: routev1.RouteSpec{
+			Host: hostname,
+			TLS:  &routev1.TLSConfig{Termination: routev1.TLSTerminationReencrypt, Certificate: "previous"},
+		},
+	}
+	spec := &v1alpha1.CustomDomainSpec{
+		Routes: []v1alpha1.CustomRouteSpec{{Route: v1alpha1.CustomRouteRHSSO, Hostname: hostname, TLSSecret: "sso-tls"}},
+	}
+	resolveTo := func(address string) HostResolver {
+		return func(_ context.Context, host string) ([]string, error) {
+			if host == routerHost {
+				return []string{"10.0.0.1"}, nil
+			}
+			if address == "" {
+				return nil, fmt.Errorf("no such host")
+			}
+			return []string{address}, nil
+		}
+	}
+
+	tests := []struct {
+		name            string
+		spec            *v1alpha1.CustomDomainSpec
+		objects         []runtime.Object
+		resolve         HostResolver
+		wantState       v1alpha1.CustomRouteState
+		wantCertificate string
+		wantDeleted     bool
+	}{
+		{
+			name:            "serves the hostname once it resolves to the router",
+			spec:            spec,
+			objects:         []runtime.Object{tlsSecret(validCert, validKey)},
+			resolve:         resolveTo("10.0.0.1"),
+			wantState:       v1alpha1.CustomRouteReady,
+			wantCertificate: validCert,
+		},
+		{
+			name:        "waits for the hostname to resolve to the router",
+			spec:        spec,
+			objects:     []runtime.Object{tlsSecret(validCert, validKey)},
+			resolve:     resolveTo("192.0.2.1"),
+			wantState:   v1alpha1.CustomRoutePending,
+			wantDeleted: true,
+		},
+		{
+			name:        "fails when the certificate isn't valid for the hostname",
+			spec:        spec,
+			objects:     []runtime.Object{tlsSecret(otherCert, otherKey)},
+			resolve:     resolveTo("10.0.0.1"),
+			wantState:   v1alpha1.CustomRouteFailed,
+			wantDeleted: true,
+		},
+		{
+			name:            "keeps serving the previous certificate when the new one expired",
+			spec:            spec,
+			objects:         []runtime.Object{tlsSecret(expiredCert, expiredKey), existingCustomRoute.DeepCopy()},
+			resolve:         resolveTo("10.0.0.1"),
+			wantState:       v1alpha1.CustomRouteRolledBack,
+			wantCertificate: "previous",
+		},
+		{
+			name:        "removes the custom route once it's removed from the spec",
+			objects:     []runtime.Object{existingCustomRoute.DeepCopy()},
+			resolve:     resolveTo("10.0.0.1"),
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{CustomDomain: tt.spec},
+			}
+
+			if err := ReconcileCustomRoute(context.TODO(), serverClient, installation, v1alpha1.CustomRouteRHSSO, route, tt.resolve); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantState == "" {
+				if len(installation.Status.CustomRoutes) != 0 {
+					t.Errorf("expected no custom route status, got %v", installation.Status.CustomRoutes)
+				}
+			} else if len(installation.Status.CustomRoutes) != 1 || installation.Status.CustomRoutes[0].State != tt.wantState {
+				t.Errorf("expected the custom route to be %s, got %v", tt.wantState, installation.Status.CustomRoutes)
+			}
+
+			customRoute := &routev1.Route{}
+			err := serverClient.Get(context.TODO(), client.ObjectKey{Name: "keycloak-edge-custom", Namespace: ssoNamespace}, customRoute)
+			if tt.wantDeleted {
+				if !k8serr.IsNotFound(err) {
+					t.Fatalf("expected the custom route not to exist, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if customRoute.Spec.Host != hostname || customRoute.Spec.TLS.Certificate != tt.wantCertificate {
+				t.Errorf("expected the custom route to serve %s with the expected certificate, got %v", hostname, customRoute.Spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package custom_smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	smtpPort = "587"
+
+	sendGridEndpoint = "https://api.sendgrid.com"
+	sendGridSMTPHost = "smtp.sendgrid.net"
+
+	mailgunEndpoint   = "https://api.mailgun.net"
+	mailgunEUEndpoint = "https://api.eu.mailgun.net"
+	mailgunSMTPHost   = "smtp.mailgun.org"
+	mailgunEUSMTPHost = "smtp.eu.mailgun.org"
+
+	sesDefaultRegion = "us-east-1"
+
+	// ProbeInterval is how often the SMTP credentials are probed once a
+	// probe succeeded, failed probes are retried on every reconcile
+	ProbeInterval = time.Hour
+)
+
+// Provider is the email provider account the custom SMTP is configured from
+type Provider interface {
+	// SMTP returns the SMTP settings of the account
+	SMTP() *CustomSmtp
+	// VerifyDomain returns whether the provider verified the sending domain
+	VerifyDomain(ctx context.Context) (bool, error)
+}
+
+// Prober sends a test email through the SMTP server to the recipient, or only
+// authenticates when there's no recipient
+type Prober func(ctx context.Context, smtp *CustomSmtp, recipient string) error
+
+// DefaultProber probes the SMTP server over STARTTLS
+var DefaultProber Prober = probeSMTP
+
+// NewProvider returns the provider configured in the SMTP provider spec of the
+// installation, with the credentials of its secret
+func NewProvider(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI) (Provider, error) {
+	spec := installation.Spec.SMTPProvider
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: spec.CredentialsSecret, Namespace: installation.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get smtp provider credentials secret %s: %w", spec.CredentialsSecret, err)
+	}
+	fromAddress := spec.FromAddress
+	if fromAddress == "" {
+		fromAddress = "noreply@" + spec.Domain
+	}
+
+	switch spec.Provider {
+	case v1alpha1.SMTPProviderSendGrid:
+		apiKey := string(secret.Data["apiKey"])
+		if apiKey == "" {
+			return nil, fmt.Errorf("smtp provider credentials secret %s must contain apiKey", spec.CredentialsSecret)
+		}
+		provider := NewSendGridProvider(spec.Domain, fromAddress, apiKey)
+		provider.httpClient.Transport = dryrun.Transport(installation, provider.httpClient.Transport)
+		return provider, nil
+	case v1alpha1.SMTPProviderMailgun:
+		apiKey, smtpPassword := string(secret.Data["apiKey"]), string(secret.Data["smtpPassword"])
+		if apiKey == "" || smtpPassword == "" {
+			return nil, fmt.Errorf("smtp provider credentials secret %s must contain apiKey and smtpPassword", spec.CredentialsSecret)
+		}
+		provider := NewMailgunProvider(spec.Domain, fromAddress, spec.Region, apiKey, string(secret.Data["smtpUsername"]), smtpPassword)
+		provider.httpClient.Transport = dryrun.Transport(installation, provider.httpClient.Transport)
+		return provider, nil
+	case v1alpha1.SMTPProviderSES:
+		accessKeyID, secretAccessKey := string(secret.Data["accessKeyID"]), string(secret.Data["secretAccessKey"])
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, fmt.Errorf("smtp provider credentials secret %s must contain accessKeyID and secretAccessKey", spec.CredentialsSecret)
+		}
+		provider := NewSESProvider(spec.Domain, fromAddress, spec.Region, accessKeyID, secretAccessKey)
+		provider.httpClient.Transport = dryrun.Transport(installation, provider.httpClient.Transport)
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unsupported smtp provider %q", spec.Provider)
+	}
+}
+
+// ReconcileProvider configures the custom SMTP from the provider of the SMTP
+// provider spec. The custom SMTP secret is only written once the provider
+// verified the sending domain and the SMTP credentials passed the probe,
+// otherwise it's removed and the reason reported in the custom SMTP status
+func ReconcileProvider(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI, newProvider func(context.Context, k8sclient.Client, *v1alpha1.RHMI) (Provider, error), probe Prober, now time.Time) error {
+	spec := installation.Spec.SMTPProvider
+	status := &v1alpha1.CustomSmtpStatus{Provider: spec.Provider}
+	previous := installation.Status.CustomSmtp
+	if previous != nil && previous.Provider == spec.Provider {
+		status.Probe = previous.Probe
+	}
+	installation.Status.CustomSmtp = status
+
+	provider, err := newProvider(ctx, serverClient, installation)
+	if err != nil {
+		status.Error = err.Error()
+		return disableCustomSMTP(ctx, serverClient, installation.Namespace)
+	}
+
+	verified, err := provider.VerifyDomain(ctx)
+	if err != nil {
+		status.Error = fmt.Sprintf("failed to verify domain %s: %v", spec.Domain, err)
+		return disableCustomSMTP(ctx, serverClient, installation.Namespace)
+	}
+	status.DomainVerified = verified
+	if !verified {
+		status.Error = fmt.Sprintf("domain %s is not verified with %s yet", spec.Domain, spec.Provider)
+		return disableCustomSMTP(ctx, serverClient, installation.Namespace)
+	}
+
+	smtpSettings := provider.SMTP()
+	changed, err := customSMTPChanged(ctx, serverClient, installation.Namespace, smtpSettings)
+	if err != nil {
+		return err
+	}
+	if changed || status.Probe == nil || !status.Probe.Success || now.Sub(status.Probe.Time.Time) >= ProbeInterval {
+		status.Probe = &v1alpha1.SMTPProbeStatus{Time: metav1.NewTime(now), Success: true}
+		if err := probe(ctx, smtpSettings, spec.ProbeRecipient); err != nil {
+			status.Probe.Success = false
+			status.Probe.Message = err.Error()
+		}
+	}
+	if !status.Probe.Success {
+		status.Error = fmt.Sprintf("SMTP probe failed: %s", status.Probe.Message)
+		return disableCustomSMTP(ctx, serverClient, installation.Namespace)
+	}
+
+	if _, err := CreateOrUpdateCustomSMTPSecret(ctx, serverClient, smtpSettings, installation.Namespace); err != nil {
+		return err
+	}
+	status.Enabled = true
+	return nil
+}
+
+func disableCustomSMTP(ctx context.Context, serverClient k8sclient.Client, namespace string) error {
+	_, err := DeleteCustomSMTP(ctx, serverClient, namespace)
+	return err
+}
+
+// customSMTPChanged returns whether the custom SMTP secret doesn't exist yet
+// or holds other settings
+func customSMTPChanged(ctx context.Context, serverClient k8sclient.Client, namespace string, smtpSettings *CustomSmtp) (bool, error) {
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: CustomSecret, Namespace: namespace}, secret); err != nil {
+		if k8serr.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return string(secret.Data["from_address"]) != smtpSettings.FromAddress ||
+		string(secret.Data["host"]) != smtpSettings.Address ||
+		string(secret.Data["password"]) != smtpSettings.Password ||
+		string(secret.Data["port"]) != smtpSettings.Port ||
+		string(secret.Data["username"]) != smtpSettings.Username, nil
+}
+
+// SendGridProvider verifies the domains authenticated in a SendGrid account,
+// the SMTP credentials are the API key
+type SendGridProvider struct {
+	Endpoint    string
+	domain      string
+	fromAddress string
+	apiKey      string
+	httpClient  *http.Client
+}
+
+var _ Provider = &SendGridProvider{}
+
+func NewSendGridProvider(domain, fromAddress, apiKey string) *SendGridProvider {
+	return &SendGridProvider{
+		Endpoint:    sendGridEndpoint,
+		domain:      domain,
+		fromAddress: fromAddress,
+		apiKey:      apiKey,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *SendGridProvider) SMTP() *CustomSmtp {
+	return &CustomSmtp{FromAddress: p.fromAddress, Address: sendGridSMTPHost, Port: smtpPort, Username: "apikey", Password: p.apiKey}
+}
+
+func (p *SendGridProvider) VerifyDomain(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v3/whitelabel/domains?domain=%s", p.Endpoint, url.QueryEscape(p.domain)), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	var domains []struct {
+		Domain string `json:"domain"`
+		Valid  bool   `json:"valid"`
+	}
+	if err := doJSON(p.httpClient, req, &domains); err != nil {
+		return false, err
+	}
+	for _, domain := range domains {
+		if domain.Domain == p.domain && domain.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MailgunProvider verifies the domains of a Mailgun account, the SMTP
+// credentials are the ones of the domain
+type MailgunProvider struct {
+	Endpoint     string
+	domain       string
+	fromAddress  string
+	smtpHost     string
+	apiKey       string
+	smtpUsername string
+	smtpPassword string
+	httpClient   *http.Client
+}
+
+var _ Provider = &MailgunProvider{}
+
+func NewMailgunProvider(domain, fromAddress, region, apiKey, smtpUsername, smtpPassword string) *MailgunProvider {
+	endpoint, smtpHost := mailgunEndpoint, mailgunSMTPHost
+	if region == "eu" {
+		endpoint, smtpHost = mailgunEUEndpoint, mailgunEUSMTPHost
+	}
+	if smtpUsername == "" {
+		smtpUsername = "postmaster@" + domain
+	}
+	return &MailgunProvider{
+		Endpoint:     endpoint,
+		domain:       domain,
+		fromAddress:  fromAddress,
+		smtpHost:     smtpHost,
+		apiKey:       apiKey,
+		smtpUsername: smtpUsername,
+		smtpPassword: smtpPassword,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *MailgunProvider) SMTP() *CustomSmtp {
+	return &CustomSmtp{FromAddress: p.fromAddress, Address: p.smtpHost, Port: smtpPort, Username: p.smtpUsername, Password: p.smtpPassword}
+}
+
+func (p *MailgunProvider) VerifyDomain(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v3/domains/%s", p.Endpoint, url.PathEscape(p.domain)), nil)
+	if err != nil {
+		return false, err
+	}
+	req.SetBasicAuth("api", p.apiKey)
+
+	response := struct {
+		Domain struct {
+			State string `json:"state"`
+		} `json:"domain"`
+	}{}
+	if err := doJSON(p.httpClient, req, &response); err != nil {
+		return false, err
+	}
+	return response.Domain.State == "active", nil
+}
+
+// SESProvider verifies the domain identities of SES with the REST API, the
+// SES client isn't part of the vendored AWS SDK. The SMTP password is derived
+// from the secret access key of the IAM user
+type SESProvider struct {
+	Endpoint        string
+	domain          string
+	fromAddress     string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	signer          *v4.Signer
+	httpClient      *http.Client
+}
+
+var _ Provider = &SESProvider{}
+
+func NewSESProvider(domain, fromAddress, region, accessKeyID, secretAccessKey string) *SESProvider {
+	if region == "" {
+		region = sesDefaultRegion
+	}
+	return &SESProvider{
+		Endpoint:        fmt.Sprintf("https://email.%s.amazonaws.com", region),
+		domain:          domain,
+		fromAddress:     fromAddress,
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		signer:          v4.NewSigner(credentials.NewStaticCredentials(accessKeyID, secretAccessKey, "")),
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *SESProvider) SMTP() *CustomSmtp {
+	return &CustomSmtp{
+		FromAddress: p.fromAddress,
+		Address:     fmt.Sprintf("email-smtp.%s.amazonaws.com", p.region),
+		Port:        smtpPort,
+		Username:    p.accessKeyID,
+		Password:    SESSMTPPassword(p.secretAccessKey, p.region),
+	}
+}
+
+func (p *SESProvider) VerifyDomain(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v2/email/identities/%s", p.Endpoint, url.PathEscape(p.domain)), nil)
+	if err != nil {
+		return false, err
+	}
+	if _, err := p.signer.Sign(req, bytes.NewReader(nil), "ses", p.region, time.Now()); err != nil {
+		return false, fmt.Errorf("failed to sign ses request: %w", err)
+	}
+
+	response := struct {
+		VerifiedForSendingStatus bool `json:"VerifiedForSendingStatus"`
+	}{}
+	if err := doJSON(p.httpClient, req, &response); err != nil {
+		return false, err
+	}
+	return response.VerifiedForSendingStatus, nil
+}
+
+// SESSMTPPassword derives the SES SMTP password of the region from the secret
+// access key of an IAM user
+func SESSMTPPassword(secretAccessKey, region string) string {
+	sign := func(key []byte, message string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(message))
+		return mac.Sum(nil)
+	}
+	signature := sign([]byte("AWS4"+secretAccessKey), "11111111")
+	for _, message := range []string{region, "ses", "aws4_request", "SendRawEmail"} {
+		signature = sign(signature, message)
+	}
+	return base64.StdEncoding.EncodeToString(append([]byte{0x04}, signature...))
+}
+
+func doJSON(httpClient *http.Client, req *http.Request, out interface{}) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error performing request to %s: %w", req.URL.Host, err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("request to %s failed: %s", req.URL.Host, res.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
+
+func probeSMTP(ctx context.Context, smtpSettings *CustomSmtp, recipient string) error {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(smtpSettings.Address, smtpSettings.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", smtpSettings.Address, err)
+	}
+	client, err := smtp.NewClient(conn, smtpSettings.Address)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.StartTLS(&tls.Config{ServerName: smtpSettings.Address, MinVersion: tls.VersionTLS12}); err != nil {
+		return fmt.Errorf("failed to start TLS: %w", err)
+	}
+	if err := client.Auth(smtp.PlainAuth("", smtpSettings.Username, smtpSettings.Password, smtpSettings.Address)); err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+	if recipient != "" {
+		if err := client.Mail(smtpSettings.FromAddress); err != nil {
+			return fmt.Errorf("sender rejected: %w", err)
+		}
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient rejected: %w", err)
+		}
+		writer, err := client.Data()
+		if err != nil {
+			return err
+		}
+		message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: RHOAM SMTP probe\r\n\r\nThis email tests the SMTP settings of the RHOAM installation.\r\n", smtpSettings.FromAddress, recipient)
+		if _, err := writer.Write([]byte(message)); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to send the test email: %w", err)
+		}
+	}
+	return client.Quit()
+}
@@ -0,0 +1,226 @@
+package custom_smtp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type testProvider struct {
+	verified  bool
+	verifyErr error
+}
+
+func (p *testProvider) SMTP() *CustomSmtp {
+	return &CustomSmtp{FromAddress: "noreply@example.com", Address: "smtp.example.com", Port: "587", Username: "user", Password: "password"}
+}
+
+func (p *testProvider) VerifyDomain(_ context.Context) (bool, error) {
+	return p.verified, p.verifyErr
+}
+
+func TestReconcileProvider(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	now := time.Now().Truncate(time.Second)
+	existingSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: CustomSecret, Namespace: namespace},
+		Data: map[string][]byte{
+			"from_address": []byte("noreply@example.com"),
+			"host":         []byte("smtp.example.com"),
+			"port":         []byte("587"),
+			"username":     []byte("user"),
+			"password":     []byte("password"),
+		},
+	}
+	recentProbe := &v1alpha1.SMTPProbeStatus{Time: metav1.NewTime(now.Add(-time.Minute)), Success: true}
+
+	tests := []struct {
+		name        string
+		provider    *testProvider
+		objects     []runtime.Object
+		previous    *v1alpha1.CustomSmtpStatus
+		probeErr    error
+		wantProbed  bool
+		wantEnabled bool
+	}{
+		{
+			name:        "enables the custom SMTP once the domain is verified and the probe succeeds",
+			provider:    &testProvider{verified: true},
+			wantProbed:  true,
+			wantEnabled: true,
+		},
+		{
+			name:     "waits for the domain to be verified",
+			provider: &testProvider{verified: false},
+			objects:  []runtime.Object{existingSecret.DeepCopy()},
+		},
+		{
+			name:     "disables the custom SMTP when the domain can't be verified",
+			provider: &testProvider{verifyErr: fmt.Errorf("unauthorized")},
+			objects:  []runtime.Object{existingSecret.DeepCopy()},
+		},
+		{
+			name:       "disables the custom SMTP when the probe fails",
+			provider:   &testProvider{verified: true},
+			objects:    []runtime.Object{existingSecret.DeepCopy()},
+			previous:   &v1alpha1.CustomSmtpStatus{Provider: v1alpha1.SMTPProviderSendGrid, Enabled: true, Probe: &v1alpha1.SMTPProbeStatus{Time: metav1.NewTime(now.Add(-2 * time.Hour)), Success: true}},
+			probeErr:   fmt.Errorf("authentication failed"),
+			wantProbed: true,
+		},
+		{
+			name:        "skips the probe of unchanged settings until the interval passed",
+			provider:    &testProvider{verified: true},
+			objects:     []runtime.Object{existingSecret.DeepCopy()},
+			previous:    &v1alpha1.CustomSmtpStatus{Provider: v1alpha1.SMTPProviderSendGrid, Enabled: true, Probe: recentProbe},
+			probeErr:    fmt.Errorf("must not be probed"),
+			wantEnabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec: v1alpha1.RHMISpec{SMTPProvider: &v1alpha1.SMTPProviderSpec{
+					Provider: v1alpha1.SMTPProviderSendGrid, CredentialsSecret: "sendgrid", Domain: "example.com",
+				}},
+				Status: v1alpha1.RHMIStatus{CustomSmtp: tt.previous},
+			}
+			newProvider := func(context.Context, k8sclient.Client, *v1alpha1.RHMI) (Provider, error) {
+				return tt.provider, nil
+			}
+			probed := false
+			probe := func(context.Context, *CustomSmtp, string) error {
+				probed = true
+				return tt.probeErr
+			}
+
+			if err := ReconcileProvider(context.TODO(), serverClient, installation, newProvider, probe, now); err != nil {
+				t.Fatal(err)
+			}
+
+			status := installation.Status.CustomSmtp
+			if status.Enabled != tt.wantEnabled {
+				t.Errorf("expected enabled to be %t, got status %v", tt.wantEnabled, status)
+			}
+			if probed != tt.wantProbed {
+				t.Errorf("expected probed to be %t", tt.wantProbed)
+			}
+			if !tt.wantEnabled && status.Error == "" {
+				t.Errorf("expected the reason to be reported")
+			}
+			err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: CustomSecret, Namespace: namespace}, &corev1.Secret{})
+			if tt.wantEnabled && err != nil {
+				t.Errorf("expected the custom SMTP secret to exist, got %v", err)
+			}
+			if !tt.wantEnabled && !k8serr.IsNotFound(err) {
+				t.Errorf("expected the custom SMTP secret to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProvider_VerifyDomain(t *testing.T) {
+	tests := []struct {
+		name         string
+		newProvider  func(endpoint string) Provider
+		path         string
+		response     string
+		wantVerified bool
+	}{
+		{
+			name: "SendGrid authenticated domain",
+			newProvider: func(endpoint string) Provider {
+				p := NewSendGridProvider("example.com", "noreply@example.com", "key")
+				p.Endpoint = endpoint
+				return p
+			},
+			path:         "/v3/whitelabel/domains",
+			response:     `[{"domain": "example.com", "valid": true}]`,
+			wantVerified: true,
+		},
+		{
+			name: "SendGrid domain pending validation",
+			newProvider: func(endpoint string) Provider {
+				p := NewSendGridProvider("example.com", "noreply@example.com", "key")
+				p.Endpoint = endpoint
+				return p
+			},
+			path:     "/v3/whitelabel/domains",
+			response: `[{"domain": "example.com", "valid": false}]`,
+		},
+		{
+			name: "Mailgun active domain",
+			newProvider: func(endpoint string) Provider {
+				p := NewMailgunProvider("example.com", "noreply@example.com", "", "key", "", "password")
+				p.Endpoint = endpoint
+				return p
+			},
+			path:         "/v3/domains/example.com",
+			response:     `{"domain": {"state": "active"}}`,
+			wantVerified: true,
+		},
+		{
+			name: "SES identity verified for sending",
+			newProvider: func(endpoint string) Provider {
+				p := NewSESProvider("example.com", "noreply@example.com", "eu-west-1", "AKIA", "secret")
+				p.Endpoint = endpoint
+				return p
+			},
+			path:         "/v2/email/identities/example.com",
+			response:     `{"VerifiedForSendingStatus": true}`,
+			wantVerified: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path || r.Header.Get("Authorization") == "" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				fmt.Fprint(w, tt.response)
+			}))
+			defer server.Close()
+
+			verified, err := tt.newProvider(server.URL).VerifyDomain(context.TODO())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("expected verified to be %t", tt.wantVerified)
+			}
+		})
+	}
+}
+
+func TestSESSMTPPassword(t *testing.T) {
+	password := SESSMTPPassword("secret", "eu-west-1")
+	decoded, err := base64.StdEncoding.DecodeString(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 33 || decoded[0] != 0x04 {
+		t.Errorf("expected a version 4 SHA-256 signature, got %v", decoded)
+	}
+	if SESSMTPPassword("secret", "us-east-1") == password {
+		t.Errorf("expected the password to depend on the region")
+	}
+}
@@ -0,0 +1,124 @@
+package custom_smtp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// RelaySecret holds the SMTP settings of the relay in the format of the
+	// custom SMTP secret
+	RelaySecret = "rhoam-smtp-relay" // #nosec G101 -- This is a false positive
+	RelayName   = "rhoam-smtp-relay"
+	RelayPort   = int32(587)
+)
+
+// RelayEnabled returns whether the emails are sent through the SMTP relay
+func RelayEnabled(installation *v1alpha1.RHMI) bool {
+	return installation.Spec.SMTPRelay != nil && installation.Status.SMTPRelay != nil && installation.Status.SMTPRelay.Ready
+}
+
+// ReconcileRelay deploys the SMTP relay of the installation and writes its
+// settings to the relay secret, or removes them when the relay isn't in the
+// spec anymore. The relay is reported ready once its deployment is available
+func ReconcileRelay(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI) error {
+	objectMeta := metav1.ObjectMeta{Name: RelayName, Namespace: installation.Namespace}
+	spec := installation.Spec.SMTPRelay
+	if spec == nil {
+		installation.Status.SMTPRelay = nil
+		for _, obj := range []k8sclient.Object{
+			&appsv1.Deployment{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: RelaySecret, Namespace: installation.Namespace}},
+		} {
+			if err := serverClient.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+				return fmt.Errorf("failed to delete smtp relay %T: %w", obj, err)
+			}
+		}
+		return nil
+	}
+
+	labels := map[string]string{"app": RelayName}
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, deployment, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(deployment, installation)
+		deployment.Labels = labels
+		deployment.Spec.Replicas = &[]int32{1}[0]
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template.Labels = labels
+
+		container := corev1.Container{
+			Name:  "relay",
+			Image: spec.Image,
+			Env: []corev1.EnvVar{
+				{Name: "RELAYHOST", Value: spec.SmartHost},
+				{Name: "ALLOWED_SENDER_DOMAINS", Value: senderDomain(spec.FromAddress)},
+			},
+			Ports: []corev1.ContainerPort{{Name: "smtp", ContainerPort: RelayPort, Protocol: corev1.ProtocolTCP}},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(int(RelayPort))}},
+			},
+		}
+		if spec.Resources != nil {
+			container.Resources = *spec.Resources
+		}
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
+		return podsecurity.Mutate(installation)(deployment, &deployment.Spec.Template)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile smtp relay deployment: %w", err)
+	}
+
+	service := &corev1.Service{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, installation)
+		service.Labels = labels
+		service.Spec.Selector = labels
+		service.Spec.Ports = []corev1.ServicePort{{Name: "smtp", Port: RelayPort, TargetPort: intstr.FromInt(int(RelayPort)), Protocol: corev1.ProtocolTCP}}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile smtp relay service: %w", err)
+	}
+
+	host := fmt.Sprintf("%s.%s.svc", RelayName, installation.Namespace)
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: RelaySecret, Namespace: installation.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, secret, func() error {
+		secret.Data = map[string][]byte{
+			"from_address": []byte(spec.FromAddress),
+			"host":         []byte(host),
+			"port":         []byte(fmt.Sprint(RelayPort)),
+			"username":     []byte(""),
+			"password":     []byte(""),
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile smtp relay secret: %w", err)
+	}
+
+	status := &v1alpha1.SMTPRelayStatus{Host: host}
+	if deployment.Status.AvailableReplicas > 0 && deployment.Status.ObservedGeneration >= deployment.Generation {
+		status.Ready = true
+	} else {
+		status.Message = "waiting for the relay deployment to be available"
+	}
+	installation.Status.SMTPRelay = status
+	return nil
+}
+
+func senderDomain(fromAddress string) string {
+	if i := strings.LastIndex(fromAddress, "@"); i >= 0 {
+		return fromAddress[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package custom_smtp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileRelay(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	relaySpec := &v1alpha1.SMTPRelaySpec{Image: "registry.example.com/postfix:latest", FromAddress: "noreply@example.com", SmartHost: "mail.example.com:25"}
+	availableDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: RelayName, Namespace: namespace},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+	}
+
+	tests := []struct {
+		name       string
+		spec       *v1alpha1.SMTPRelaySpec
+		objects    []runtime.Object
+		wantStatus *v1alpha1.SMTPRelayStatus
+	}{
+		{
+			name:       "waits for the relay to be available",
+			spec:       relaySpec,
+			wantStatus: &v1alpha1.SMTPRelayStatus{Host: "rhoam-smtp-relay.redhat-rhoam-operator.svc", Message: "waiting for the relay deployment to be available"},
+		},
+		{
+			name:       "reports the relay ready once it's available",
+			spec:       relaySpec,
+			objects:    []runtime.Object{availableDeployment.DeepCopy()},
+			wantStatus: &v1alpha1.SMTPRelayStatus{Host: "rhoam-smtp-relay.redhat-rhoam-operator.svc", Ready: true},
+		},
+		{
+			name: "removes the relay once it's removed from the spec",
+			objects: []runtime.Object{
+				availableDeployment.DeepCopy(),
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: RelaySecret, Namespace: namespace}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{SMTPRelay: tt.spec},
+			}
+
+			if err := ReconcileRelay(context.TODO(), serverClient, installation); err != nil {
+				t.Fatal(err)
+			}
+
+			status := installation.Status.SMTPRelay
+			if (status == nil) != (tt.wantStatus == nil) || (status != nil && *status != *tt.wantStatus) {
+				t.Errorf("expected status %v, got %v", tt.wantStatus, status)
+			}
+
+			secret := &corev1.Secret{}
+			err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: RelaySecret, Namespace: namespace}, secret)
+			if tt.spec == nil {
+				if !k8serr.IsNotFound(err) {
+					t.Errorf("expected the relay secret to be removed, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(secret.Data["host"]) != tt.wantStatus.Host || string(secret.Data["from_address"]) != relaySpec.FromAddress {
+				t.Errorf("unexpected relay secret %v", secret.Data)
+			}
+
+			deployment := &appsv1.Deployment{}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: RelayName, Namespace: namespace}, deployment); err != nil {
+				t.Fatal(err)
+			}
+			env := deployment.Spec.Template.Spec.Containers[0].Env
+			if env[0].Value != relaySpec.SmartHost || env[1].Value != "example.com" {
+				t.Errorf("unexpected relay environment %v", env)
+			}
+		})
+	}
+}
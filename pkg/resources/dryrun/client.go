@@ -0,0 +1,231 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	ctrlmanager "sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// serverFields are set by the API server, they're left out of the diffs
+var serverFields = []string{"resourceVersion", "uid", "generation", "creationTimestamp", "managedFields", "selfLink"}
+
+type client struct {
+	k8sclient.Client
+	plan      *Plan
+	component string
+}
+
+// NewClient wraps c so the writes made through it are recorded in the plan
+// under the component instead of being sent, the reads are sent to c
+func NewClient(c k8sclient.Client, plan *Plan, component string) k8sclient.Client {
+	return &client{Client: c, plan: plan, component: component}
+}
+
+func (c *client) Create(_ context.Context, obj k8sclient.Object, _ ...k8sclient.CreateOption) error {
+	c.record("create", obj, nil, "")
+	return nil
+}
+
+func (c *client) Update(ctx context.Context, obj k8sclient.Object, _ ...k8sclient.UpdateOption) error {
+	c.record("update", obj, c.current(ctx, obj), "")
+	return nil
+}
+
+func (c *client) Patch(ctx context.Context, obj k8sclient.Object, _ k8sclient.Patch, _ ...k8sclient.PatchOption) error {
+	c.record("patch", obj, c.current(ctx, obj), "")
+	return nil
+}
+
+func (c *client) Delete(_ context.Context, obj k8sclient.Object, _ ...k8sclient.DeleteOption) error {
+	c.plan.Record(c.component, Operation{Verb: "delete", Kind: c.kind(obj), Namespace: obj.GetNamespace(), Name: obj.GetName()})
+	return nil
+}
+
+func (c *client) DeleteAllOf(_ context.Context, obj k8sclient.Object, opts ...k8sclient.DeleteAllOfOption) error {
+	listOpts := &k8sclient.DeleteAllOfOptions{}
+	listOpts.ApplyOptions(opts)
+	name := "*"
+	if listOpts.LabelSelector != nil {
+		name = listOpts.LabelSelector.String()
+	}
+	c.plan.Record(c.component, Operation{Verb: "delete all of", Kind: c.kind(obj), Namespace: listOpts.Namespace, Name: name})
+	return nil
+}
+
+func (c *client) Status() k8sclient.SubResourceWriter {
+	return &statusWriter{client: c}
+}
+
+type statusWriter struct {
+	client *client
+}
+
+func (w *statusWriter) Create(_ context.Context, obj k8sclient.Object, subResource k8sclient.Object, _ ...k8sclient.SubResourceCreateOption) error {
+	w.client.record("create status of", subResource, nil, "")
+	return nil
+}
+
+func (w *statusWriter) Update(ctx context.Context, obj k8sclient.Object, _ ...k8sclient.SubResourceUpdateOption) error {
+	w.client.record("update status of", obj, w.client.current(ctx, obj), "status")
+	return nil
+}
+
+func (w *statusWriter) Patch(ctx context.Context, obj k8sclient.Object, _ k8sclient.Patch, _ ...k8sclient.SubResourcePatchOption) error {
+	w.client.record("patch status of", obj, w.client.current(ctx, obj), "status")
+	return nil
+}
+
+// current returns the object as stored by the API server, or nil when it
+// can't be read
+func (c *client) current(ctx context.Context, obj k8sclient.Object) k8sclient.Object {
+	current, ok := obj.DeepCopyObject().(k8sclient.Object)
+	if !ok {
+		return nil
+	}
+	if err := c.Client.Get(ctx, k8sclient.ObjectKeyFromObject(obj), current); err != nil {
+		return nil
+	}
+	return current
+}
+
+// record adds the write of obj to the plan, with the diff of field, or of the
+// whole object when field is empty, against the current object. Writes that
+// change nothing aren't recorded
+func (c *client) record(verb string, obj, current k8sclient.Object, field string) {
+	kind := c.kind(obj)
+	desired := c.fields(obj, field)
+	var previous map[string]string
+	if current != nil {
+		previous = c.fields(current, field)
+	}
+	diff := diffFields(previous, desired, kind == "Secret")
+	if current != nil && diff == "" {
+		return
+	}
+	c.plan.Record(c.component, Operation{Verb: verb, Kind: kind, Namespace: obj.GetNamespace(), Name: obj.GetName(), Diff: diff})
+}
+
+func (c *client) kind(obj k8sclient.Object) string {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	return kind
+}
+
+// fields flattens field of obj, or the whole object without its status and
+// server set metadata when field is empty, to its values by path
+func (c *client) fields(obj k8sclient.Object, field string) map[string]string {
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.DeepCopy().Object
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return map[string]string{}
+		}
+	}
+
+	var value interface{}
+	if field != "" {
+		value = map[string]interface{}{field: content[field]}
+	} else {
+		// the type is part of the operation, and isn't always set on the
+		// typed objects
+		delete(content, "apiVersion")
+		delete(content, "kind")
+		delete(content, "status")
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			for _, serverField := range serverFields {
+				delete(metadata, serverField)
+			}
+		}
+		value = content
+	}
+
+	fields := map[string]string{}
+	flatten(value, "", fields)
+	return fields
+}
+
+func flatten(value interface{}, path string, fields map[string]string) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, item := range typed {
+			flatten(item, strings.TrimPrefix(path+"."+key, "."), fields)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			flatten(item, fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	case nil:
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			encoded = []byte(fmt.Sprintf("%v", typed))
+		}
+		fields[path] = string(encoded)
+	}
+}
+
+// diffFields returns the fields removed and added between previous and
+// desired, sorted by path. The data values of Secrets are redacted so they
+// don't end up in the plan
+func diffFields(previous, desired map[string]string, redact bool) string {
+	paths := map[string]bool{}
+	for path := range previous {
+		paths[path] = true
+	}
+	for path := range desired {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, path := range sorted {
+		before, hadBefore := previous[path]
+		after, hasAfter := desired[path]
+		if hadBefore && hasAfter && before == after {
+			continue
+		}
+		if redact && (strings.HasPrefix(path, "data.") || strings.HasPrefix(path, "stringData.")) {
+			before, after = "<redacted>", "<redacted>"
+		}
+		if hadBefore {
+			fmt.Fprintf(&b, "- %s: %s\n", path, before)
+		}
+		if hasAfter {
+			fmt.Fprintf(&b, "+ %s: %s\n", path, after)
+		}
+	}
+	return b.String()
+}
+
+type manager struct {
+	ctrlmanager.Manager
+}
+
+// NewManager wraps mgr so the reconcilers built with it emit no events on the
+// installation during a dry run
+func NewManager(mgr ctrlmanager.Manager) ctrlmanager.Manager {
+	return &manager{Manager: mgr}
+}
+
+func (m *manager) GetEventRecorderFor(_ string) record.EventRecorder {
+	return &record.FakeRecorder{}
+}
@@ -0,0 +1,147 @@
+package dryrun
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestClient(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectMeta := metav1.ObjectMeta{Name: "test", Namespace: "redhat-rhoam-operator"}
+	existing := &corev1.ConfigMap{ObjectMeta: objectMeta, Data: map[string]string{"kept": "value", "changed": "before"}}
+	secret := &corev1.Secret{ObjectMeta: objectMeta, Data: map[string][]byte{"password": []byte("before")}}
+
+	tests := []struct {
+		name      string
+		write     func(c k8sclient.Client) error
+		want      []Operation
+		wantDiffs []string
+	}{
+		{
+			name: "test create is recorded with the fields of the object",
+			write: func(c k8sclient.Client) error {
+				return c.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "redhat-rhoam-operator"}, Data: map[string]string{"key": "value"}})
+			},
+			want:      []Operation{{Verb: "create", Kind: "ConfigMap", Namespace: "redhat-rhoam-operator", Name: "new"}},
+			wantDiffs: []string{`+ data.key: "value"`},
+		},
+		{
+			name: "test update is recorded with the changed fields",
+			write: func(c k8sclient.Client) error {
+				updated := existing.DeepCopy()
+				updated.Data["changed"] = "after"
+				return c.Update(context.TODO(), updated)
+			},
+			want:      []Operation{{Verb: "update", Kind: "ConfigMap", Namespace: "redhat-rhoam-operator", Name: "test"}},
+			wantDiffs: []string{"- data.changed: \"before\"\n+ data.changed: \"after\"\n"},
+		},
+		{
+			name: "test update without changes isn't recorded",
+			write: func(c k8sclient.Client) error {
+				return c.Update(context.TODO(), existing.DeepCopy())
+			},
+		},
+		{
+			name: "test delete is recorded",
+			write: func(c k8sclient.Client) error {
+				return c.Delete(context.TODO(), existing.DeepCopy())
+			},
+			want: []Operation{{Verb: "delete", Kind: "ConfigMap", Namespace: "redhat-rhoam-operator", Name: "test"}},
+		},
+		{
+			name: "test repeated writes of an object keep the last one",
+			write: func(c k8sclient.Client) error {
+				updated := existing.DeepCopy()
+				updated.Data["changed"] = "first"
+				if err := c.Update(context.TODO(), updated); err != nil {
+					return err
+				}
+				updated.Data["changed"] = "last"
+				return c.Update(context.TODO(), updated)
+			},
+			want:      []Operation{{Verb: "update", Kind: "ConfigMap", Namespace: "redhat-rhoam-operator", Name: "test"}},
+			wantDiffs: []string{`+ data.changed: "last"`},
+		},
+		{
+			name: "test the values of secrets are redacted",
+			write: func(c k8sclient.Client) error {
+				updated := secret.DeepCopy()
+				updated.Data["password"] = []byte("after")
+				return c.Update(context.TODO(), updated)
+			},
+			want:      []Operation{{Verb: "update", Kind: "Secret", Namespace: "redhat-rhoam-operator", Name: "test"}},
+			wantDiffs: []string{"- data.password: <redacted>\n+ data.password: <redacted>\n"},
+		},
+		{
+			name: "test status update is recorded with the status fields",
+			write: func(c k8sclient.Client) error {
+				installation := &integreatlyv1alpha1.RHMI{ObjectMeta: objectMeta, Status: integreatlyv1alpha1.RHMIStatus{Version: "1.2.0"}}
+				return c.Status().Update(context.TODO(), installation)
+			},
+			want:      []Operation{{Verb: "update status of", Kind: "RHMI", Namespace: "redhat-rhoam-operator", Name: "test"}},
+			wantDiffs: []string{"- status.version: \"1.1.0\"\n+ status.version: \"1.2.0\"\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{ObjectMeta: objectMeta, Status: integreatlyv1alpha1.RHMIStatus{Version: "1.1.0"}}
+			serverClient := utils.NewTestClient(scheme, existing.DeepCopy(), secret.DeepCopy(), installation)
+			plan := NewPlan()
+
+			if err := tt.write(NewClient(serverClient, plan, "3scale")); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			got := plan.Operations("3scale")
+			if len(got) != len(tt.want) {
+				t.Fatalf("recorded %v, want %v", got, tt.want)
+			}
+			for i, operation := range got {
+				if operation.Verb != tt.want[i].Verb || operation.Kind != tt.want[i].Kind || operation.Namespace != tt.want[i].Namespace || operation.Name != tt.want[i].Name {
+					t.Errorf("recorded %v, want %v", operation, tt.want[i])
+				}
+				if i < len(tt.wantDiffs) && !strings.Contains(operation.Diff, tt.wantDiffs[i]) {
+					t.Errorf("diff = %q, want it to contain %q", operation.Diff, tt.wantDiffs[i])
+				}
+			}
+
+			// the server objects are left as they were
+			cfgMap := &corev1.ConfigMap{}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(existing), cfgMap); err != nil {
+				t.Fatalf("expected the config map to be kept, got %v", err)
+			}
+			if cfgMap.Data["changed"] != "before" {
+				t.Errorf("expected the config map not to be updated, got %v", cfgMap.Data)
+			}
+		})
+	}
+}
+
+func TestPlan_Data(t *testing.T) {
+	plan := NewPlan()
+	plan.Record("3scale", Operation{Verb: "create", Kind: "ConfigMap", Namespace: "redhat-rhoam-3scale", Name: "config", Diff: "+ data.key: \"value\"\n"})
+	plan.SetResult("3scale", integreatlyv1alpha1.PhaseInProgress, &SkippedError{Operation: "POST https://3scale-admin.example.com/admin/api/accounts.json"})
+	plan.SetResult("grafana", integreatlyv1alpha1.PhaseCompleted, nil)
+
+	data := plan.Data()
+	want := "# phase: in progress\n# error: dry run: POST https://3scale-admin.example.com/admin/api/accounts.json skipped\ncreate ConfigMap redhat-rhoam-3scale/config\n+ data.key: \"value\"\n"
+	if data["3scale"] != want {
+		t.Errorf("3scale plan = %q, want %q", data["3scale"], want)
+	}
+	if data["grafana"] != "# phase: completed\n# no changes\n" {
+		t.Errorf("grafana plan = %q", data["grafana"])
+	}
+	if plan.Len() != 1 || plan.Summary() != "3scale: 1" {
+		t.Errorf("Len() = %d, Summary() = %s", plan.Len(), plan.Summary())
+	}
+}
@@ -0,0 +1,153 @@
+// Package dryrun records the writes the reconcilers of an installation would
+// make instead of sending them, so the impact of a change of the installation
+// spec or of an operator upgrade can be previewed
+package dryrun
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+)
+
+// PlanConfigMapName is the ConfigMap in the installation namespace the plan
+// of the last dry run is written to, with a key per stage or product
+const PlanConfigMapName = "rhoam-dry-run-plan"
+
+// maxComponentData keeps the plan ConfigMap under its size limit, the writes
+// of a component past it are left out
+const maxComponentData = 64 * 1024
+
+// Operation is a write a reconciler would have made
+type Operation struct {
+	Verb      string
+	Kind      string
+	Namespace string
+	Name      string
+	// Diff of the fields the write changes, one "- " or "+ " prefixed line
+	// per field
+	Diff string
+}
+
+func (o Operation) key() string {
+	return strings.Join([]string{o.Verb, o.Kind, o.Namespace, o.Name}, "/")
+}
+
+// Result is the phase a stage or product reconcile returned in the dry run
+type Result struct {
+	Phase integreatlyv1alpha1.StatusPhase
+	Err   error
+}
+
+// Plan is the writes of each stage or product of a dry run
+type Plan struct {
+	mu         sync.Mutex
+	operations map[string][]Operation
+	results    map[string]Result
+}
+
+func NewPlan() *Plan {
+	return &Plan{
+		operations: map[string][]Operation{},
+		results:    map[string]Result{},
+	}
+}
+
+// Record adds the write of the component to the plan. Repeated writes of the
+// same object replace the previous one, so the plan has the final state
+func (p *Plan) Record(component string, operation Operation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, recorded := range p.operations[component] {
+		if recorded.key() == operation.key() {
+			p.operations[component][i] = operation
+			return
+		}
+	}
+	p.operations[component] = append(p.operations[component], operation)
+}
+
+// SetResult records the phase the reconcile of the component returned
+func (p *Plan) SetResult(component string, phase integreatlyv1alpha1.StatusPhase, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[component] = Result{Phase: phase, Err: err}
+}
+
+// Operations returns the writes recorded for the component
+func (p *Plan) Operations(component string) []Operation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Operation{}, p.operations[component]...)
+}
+
+// Len returns the number of writes in the plan
+func (p *Plan) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count := 0
+	for _, operations := range p.operations {
+		count += len(operations)
+	}
+	return count
+}
+
+// Data renders the plan as the data of the plan ConfigMap
+func (p *Plan) Data() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	components := map[string]bool{}
+	for component := range p.operations {
+		components[component] = true
+	}
+	for component := range p.results {
+		components[component] = true
+	}
+
+	data := map[string]string{}
+	for component := range components {
+		var b strings.Builder
+		if result, ok := p.results[component]; ok {
+			fmt.Fprintf(&b, "# phase: %s\n", result.Phase)
+			if result.Err != nil {
+				fmt.Fprintf(&b, "# error: %s\n", result.Err.Error())
+			}
+		}
+		operations := p.operations[component]
+		if len(operations) == 0 {
+			b.WriteString("# no changes\n")
+		}
+		for i, operation := range operations {
+			rendered := fmt.Sprintf("%s %s %s\n%s", operation.Verb, operation.Kind, objectName(operation.Namespace, operation.Name), operation.Diff)
+			if b.Len()+len(rendered) > maxComponentData {
+				fmt.Fprintf(&b, "# %d more writes left out\n", len(operations)-i)
+				break
+			}
+			b.WriteString(rendered)
+		}
+		data[component] = b.String()
+	}
+	return data
+}
+
+// Summary lists the number of writes of each component
+func (p *Plan) Summary() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var components []string
+	for component, operations := range p.operations {
+		components = append(components, fmt.Sprintf("%s: %d", component, len(operations)))
+	}
+	sort.Strings(components)
+	return strings.Join(components, ", ")
+}
+
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
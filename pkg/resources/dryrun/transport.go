@@ -0,0 +1,103 @@
+package dryrun
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"k8s.io/client-go/rest"
+)
+
+// SkippedError is returned for the writes a dry run can't record, such as the
+// requests to the product APIs, which ends the reconcile that made them
+type SkippedError struct {
+	Operation string
+}
+
+func (e *SkippedError) Error() string {
+	return fmt.Sprintf("dry run: %s skipped", e.Operation)
+}
+
+// connectSubresources run commands or open connections in the pods, the API
+// server can't dry run them
+var connectSubresources = []string{"/exec", "/attach", "/portforward", "/proxy"}
+
+func isRead(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// RestConfig returns a copy of rc whose clients send their writes to the API
+// server as dry run requests, recorded in the plan under the component. The
+// requests to the connect subresources of the pods fail with a SkippedError
+func RestConfig(rc *rest.Config, plan *Plan, component string) *rest.Config {
+	config := rest.CopyConfig(rc)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &apiServerTransport{next: rt, plan: plan, component: component}
+	})
+	return config
+}
+
+type apiServerTransport struct {
+	next      http.RoundTripper
+	plan      *Plan
+	component string
+}
+
+func (t *apiServerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isRead(req.Method) {
+		return t.next.RoundTrip(req)
+	}
+	for _, subresource := range connectSubresources {
+		if strings.HasSuffix(req.URL.Path, subresource) {
+			return nil, &SkippedError{Operation: fmt.Sprintf("%s %s", req.Method, req.URL.Path)}
+		}
+	}
+
+	dryRunReq := req.Clone(req.Context())
+	query := dryRunReq.URL.Query()
+	query.Set("dryRun", "All")
+	dryRunReq.URL.RawQuery = query.Encode()
+	t.plan.Record(t.component, Operation{Verb: strings.ToLower(req.Method), Kind: "request", Name: req.URL.Path})
+	return t.next.RoundTrip(dryRunReq)
+}
+
+// Transport returns rt, or the default transport when rt is nil, and only its
+// reads in dry run. The writes fail with a SkippedError as the product APIs
+// and cloud provider APIs have no dry run
+func Transport(installation *integreatlyv1alpha1.RHMI, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if installation == nil || !installation.IsDryRun() {
+		return rt
+	}
+	return &readOnlyTransport{next: rt}
+}
+
+type readOnlyTransport struct {
+	next http.RoundTripper
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRead(req.Method) {
+		return nil, &SkippedError{Operation: fmt.Sprintf("%s %s://%s%s", req.Method, req.URL.Scheme, req.URL.Host, req.URL.Path)}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// PodExecutor runs no commands in the pods, as their effects can't be
+// previewed. It's used in dry run in place of the executor of the reconcilers
+type PodExecutor struct{}
+
+func (PodExecutor) ExecuteRemoteCommand(ns string, podName string, command []string) (string, string, error) {
+	return "", "", &SkippedError{Operation: fmt.Sprintf("command in pod %s/%s", ns, podName)}
+}
+
+func (PodExecutor) ExecuteRemoteContainerCommand(ns string, podName string, container string, command []string) (string, string, error) {
+	return "", "", &SkippedError{Operation: fmt.Sprintf("command in container %s of pod %s/%s", container, ns, podName)}
+}
+
+func (PodExecutor) ExecuteRemoteContainerCommandWithStdin(ns string, podName string, container string, command []string, stdin string) (string, string, error) {
+	return "", "", &SkippedError{Operation: fmt.Sprintf("command in container %s of pod %s/%s", container, ns, podName)}
+}
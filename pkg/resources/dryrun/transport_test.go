@@ -0,0 +1,131 @@
+package dryrun
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestRestConfig(t *testing.T) {
+	var dryRunParam string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dryRunParam = r.URL.Query().Get("dryRun")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	plan := NewPlan()
+	config := RestConfig(&rest.Config{Host: server.URL}, plan, "3scale")
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantDryRun  string
+		wantSkipped bool
+	}{
+		{
+			name:   "test reads are sent as they are",
+			method: http.MethodGet,
+			path:   "/apis/apps.openshift.io/v1/namespaces/redhat-rhoam-3scale/deploymentconfigs",
+		},
+		{
+			name:       "test writes are sent as dry run requests",
+			method:     http.MethodPost,
+			path:       "/apis/apps.openshift.io/v1/namespaces/redhat-rhoam-3scale/deploymentconfigs/system-app/instantiate",
+			wantDryRun: "All",
+		},
+		{
+			name:        "test commands in pods are skipped",
+			method:      http.MethodPost,
+			path:        "/api/v1/namespaces/redhat-rhoam-3scale/pods/system-app/exec",
+			wantSkipped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRunParam = ""
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			var skipped *SkippedError
+			if tt.wantSkipped {
+				if !errors.As(err, &skipped) {
+					t.Fatalf("expected a skipped error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if dryRunParam != tt.wantDryRun {
+				t.Errorf("dryRun = %q, want %q", dryRunParam, tt.wantDryRun)
+			}
+		})
+	}
+	if plan.Len() != 1 {
+		t.Errorf("expected the dry run write to be recorded, got %v", plan.Operations("3scale"))
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	dryRun := &integreatlyv1alpha1.RHMI{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{integreatlyv1alpha1.DryRunAnnotation: "true"}}}
+	tests := []struct {
+		name         string
+		installation *integreatlyv1alpha1.RHMI
+		method       string
+		wantSkipped  bool
+	}{
+		{
+			name:         "test writes are sent outside of a dry run",
+			installation: &integreatlyv1alpha1.RHMI{},
+			method:       http.MethodPost,
+		},
+		{
+			name:         "test reads are sent in dry run",
+			installation: dryRun,
+			method:       http.MethodGet,
+		},
+		{
+			name:         "test writes are skipped in dry run",
+			installation: dryRun,
+			method:       http.MethodPut,
+			wantSkipped:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: Transport(tt.installation, nil)}
+			req, err := http.NewRequest(tt.method, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			var skipped *SkippedError
+			if errors.As(err, &skipped) != tt.wantSkipped {
+				t.Fatalf("Do() error = %v, want skipped %v", err, tt.wantSkipped)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
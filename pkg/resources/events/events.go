@@ -30,3 +30,46 @@ func HandleError(recorder record.EventRecorder, installation *integreatlyv1alpha
 		recorder.Event(installation, "Warning", integreatlyv1alpha1.EventProcessingError, fmt.Sprintf("%s:\n%s", errorMessage, err.Error()))
 	}
 }
+
+// HandleStageStarted emits a normal event when a stage starts reconciling, on
+// install and when a complete stage is reconciled again such as on upgrades
+func HandleStageStarted(recorder record.EventRecorder, installation *integreatlyv1alpha1.RHMI, stageName integreatlyv1alpha1.StageName, phase integreatlyv1alpha1.StatusPhase) {
+	previous := installation.Status.Stages[stageName].Phase
+	if phase != integreatlyv1alpha1.PhaseCompleted && (previous == integreatlyv1alpha1.PhaseNone || previous == integreatlyv1alpha1.PhaseCompleted) {
+		recorder.Event(installation, "Normal", integreatlyv1alpha1.EventStageStarted, fmt.Sprintf("%s stage has started reconciling", stageName))
+	}
+}
+
+// HandleProductFailed emits a warning event with the reconcile error when a
+// product moves to the failed phase
+func HandleProductFailed(recorder record.EventRecorder, installation *integreatlyv1alpha1.RHMI, stageName integreatlyv1alpha1.StageName, productName integreatlyv1alpha1.ProductName, phase integreatlyv1alpha1.StatusPhase, err error) {
+	previous := installation.Status.Stages[stageName].Products[productName].Phase
+	if phase != integreatlyv1alpha1.PhaseFailed || previous == integreatlyv1alpha1.PhaseFailed {
+		return
+	}
+	message := fmt.Sprintf("%s failed to reconcile", productName)
+	if err != nil {
+		message = fmt.Sprintf("%s: %s", message, err.Error())
+	}
+	recorder.Event(installation, "Warning", integreatlyv1alpha1.EventProductFailed, message)
+}
+
+// HandleUpgradeStarted emits a normal event when the installation starts
+// installing or upgrading to a version
+func HandleUpgradeStarted(recorder record.EventRecorder, installation *integreatlyv1alpha1.RHMI) {
+	if installation.Status.Version == "" {
+		recorder.Event(installation, "Normal", integreatlyv1alpha1.EventInstallationStarted, fmt.Sprintf("Installing version %s", installation.Status.ToVersion))
+		return
+	}
+	recorder.Event(installation, "Normal", integreatlyv1alpha1.EventUpgradeStarted, fmt.Sprintf("Upgrading from version %s to %s", installation.Status.Version, installation.Status.ToVersion))
+}
+
+// HandleUpgradeCompleted emits a normal event when the installation finished
+// installing or upgrading to a version
+func HandleUpgradeCompleted(recorder record.EventRecorder, installation *integreatlyv1alpha1.RHMI, previousVersion string) {
+	if previousVersion == "" {
+		recorder.Event(installation, "Normal", integreatlyv1alpha1.EventInstallationCompleted, fmt.Sprintf("Version %s was installed successfully", installation.Status.Version))
+		return
+	}
+	recorder.Event(installation, "Normal", integreatlyv1alpha1.EventUpgradeCompleted, fmt.Sprintf("Upgraded from version %s to %s successfully", previousVersion, installation.Status.Version))
+}
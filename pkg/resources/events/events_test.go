@@ -2,6 +2,7 @@ package events
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
@@ -190,3 +191,146 @@ func TestHandleError(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleStageStarted(t *testing.T) {
+	stageInPhase := func(phase integreatlyv1alpha1.StatusPhase) *integreatlyv1alpha1.RHMI {
+		return &integreatlyv1alpha1.RHMI{
+			Status: integreatlyv1alpha1.RHMIStatus{
+				Stages: map[integreatlyv1alpha1.StageName]integreatlyv1alpha1.RHMIStageStatus{
+					stageName: {Name: stageName, Phase: phase},
+				},
+			},
+		}
+	}
+	cases := []EventsScenario{
+		{
+			Name:               "test stage started event handler on a stage thats unavailable",
+			Installation:       &integreatlyv1alpha1.RHMI{},
+			StatusPhase:        integreatlyv1alpha1.PhaseInProgress,
+			ExpectedEventCount: 1,
+		},
+		{
+			Name:               "test stage started event handler on a completed stage reconciling again",
+			Installation:       stageInPhase(integreatlyv1alpha1.PhaseCompleted),
+			StatusPhase:        integreatlyv1alpha1.PhaseInProgress,
+			ExpectedEventCount: 1,
+		},
+		{
+			Name:               "test stage started event handler on a stage thats in progress",
+			Installation:       stageInPhase(integreatlyv1alpha1.PhaseInProgress),
+			StatusPhase:        integreatlyv1alpha1.PhaseInProgress,
+			ExpectedEventCount: 0,
+		},
+		{
+			Name:               "test stage started event handler on a stage completed in a single reconcile",
+			Installation:       &integreatlyv1alpha1.RHMI{},
+			StatusPhase:        integreatlyv1alpha1.PhaseCompleted,
+			ExpectedEventCount: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			HandleStageStarted(recorder, tc.Installation, stageName, tc.StatusPhase)
+
+			if len(recorder.Events) != tc.ExpectedEventCount {
+				t.Fatalf("Expected event count %d but got %d", tc.ExpectedEventCount, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestHandleProductFailed(t *testing.T) {
+	productInPhase := func(phase integreatlyv1alpha1.StatusPhase) *integreatlyv1alpha1.RHMI {
+		return &integreatlyv1alpha1.RHMI{
+			Status: integreatlyv1alpha1.RHMIStatus{
+				Stages: map[integreatlyv1alpha1.StageName]integreatlyv1alpha1.RHMIStageStatus{
+					stageName: {
+						Name: stageName,
+						Products: map[integreatlyv1alpha1.ProductName]integreatlyv1alpha1.RHMIProductStatus{
+							productName: {Name: productName, Phase: phase},
+						},
+					},
+				},
+			},
+		}
+	}
+	cases := []EventsScenario{
+		{
+			Name:               "test product failed event handler on a product that failed",
+			Installation:       productInPhase(integreatlyv1alpha1.PhaseInProgress),
+			StatusPhase:        integreatlyv1alpha1.PhaseFailed,
+			Error:              errors.New("an error occurred"),
+			ExpectedEventCount: 1,
+		},
+		{
+			Name:               "test product failed event handler on a product that already failed",
+			Installation:       productInPhase(integreatlyv1alpha1.PhaseFailed),
+			StatusPhase:        integreatlyv1alpha1.PhaseFailed,
+			Error:              errors.New("an error occurred"),
+			ExpectedEventCount: 0,
+		},
+		{
+			Name:               "test product failed event handler on a product in progress",
+			Installation:       productInPhase(integreatlyv1alpha1.PhaseFailed),
+			StatusPhase:        integreatlyv1alpha1.PhaseInProgress,
+			ExpectedEventCount: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			HandleProductFailed(recorder, tc.Installation, stageName, productName, tc.StatusPhase, tc.Error)
+
+			if len(recorder.Events) != tc.ExpectedEventCount {
+				t.Fatalf("Expected event count %d but got %d", tc.ExpectedEventCount, len(recorder.Events))
+			}
+			if tc.ExpectedEventCount > 0 && !strings.Contains(<-recorder.Events, tc.Error.Error()) {
+				t.Fatalf("Expected the event to contain the error %v", tc.Error)
+			}
+		})
+	}
+}
+
+func TestHandleUpgrade(t *testing.T) {
+	cases := []struct {
+		Name            string
+		Installation    *integreatlyv1alpha1.RHMI
+		PreviousVersion string
+		ExpectedStarted string
+		ExpectedDone    string
+	}{
+		{
+			Name:            "test upgrade event handlers on install",
+			Installation:    &integreatlyv1alpha1.RHMI{Status: integreatlyv1alpha1.RHMIStatus{ToVersion: "1.2.0", Version: ""}},
+			ExpectedStarted: integreatlyv1alpha1.EventInstallationStarted,
+			ExpectedDone:    integreatlyv1alpha1.EventInstallationCompleted,
+		},
+		{
+			Name:            "test upgrade event handlers on upgrade",
+			Installation:    &integreatlyv1alpha1.RHMI{Status: integreatlyv1alpha1.RHMIStatus{ToVersion: "1.2.0", Version: "1.1.0"}},
+			PreviousVersion: "1.1.0",
+			ExpectedStarted: integreatlyv1alpha1.EventUpgradeStarted,
+			ExpectedDone:    integreatlyv1alpha1.EventUpgradeCompleted,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(2)
+			HandleUpgradeStarted(recorder, tc.Installation)
+			tc.Installation.Status.Version = tc.Installation.Status.ToVersion
+			tc.Installation.Status.ToVersion = ""
+			HandleUpgradeCompleted(recorder, tc.Installation, tc.PreviousVersion)
+
+			if started := <-recorder.Events; !strings.Contains(started, tc.ExpectedStarted) {
+				t.Fatalf("Expected a %s event but got %s", tc.ExpectedStarted, started)
+			}
+			if done := <-recorder.Events; !strings.Contains(done, tc.ExpectedDone) {
+				t.Fatalf("Expected a %s event but got %s", tc.ExpectedDone, done)
+			}
+		})
+	}
+}
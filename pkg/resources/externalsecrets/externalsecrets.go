@@ -0,0 +1,187 @@
+package externalsecrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ManagedLabel marks the ExternalSecrets of the installation, the ones
+	// not wanted anymore are removed
+	ManagedLabel = "integreatly.org/external-secret"
+
+	DefaultRefreshInterval = time.Hour
+)
+
+// ExternalSecretGVK isn't vendored, the External Secrets Operator is optional
+// so the ExternalSecrets are reconciled as unstructured
+var ExternalSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}
+
+// ReconcileExternalSecrets creates an ExternalSecret per secret of the
+// external secrets spec, syncing it into the installation namespace under its
+// own name, and reports their sync in the installation status. The synced
+// secrets are orphaned and retained, an ExternalSecret removed from the spec
+// leaves its secret with the last synced values for the admins to maintain
+func ReconcileExternalSecrets(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI) error {
+	wanted := map[string]bool{}
+	var statuses []v1alpha1.ExternalSecretStatus
+	if spec := installation.Spec.ExternalSecrets; spec != nil {
+		for _, secret := range spec.Secrets {
+			wanted[secret.Name] = true
+			externalSecret := newExternalSecret(secret.Name, installation.Namespace)
+			externalSecretSpec := ExternalSecretSpec(spec, secret)
+			if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, externalSecret, func() error {
+				owner.AddIntegreatlyOwnerAnnotations(externalSecret, installation)
+				labels := externalSecret.GetLabels()
+				if labels == nil {
+					labels = map[string]string{}
+				}
+				labels[ManagedLabel] = "true"
+				externalSecret.SetLabels(labels)
+				return unstructured.SetNestedField(externalSecret.Object, externalSecretSpec, "spec")
+			}); err != nil {
+				if meta.IsNoMatchError(err) {
+					return fmt.Errorf("the ExternalSecret API isn't available, the External Secrets Operator is required for external secrets: %w", err)
+				}
+				return fmt.Errorf("failed to reconcile external secret %s: %w", secret.Name, err)
+			}
+			statuses = append(statuses, Status(externalSecret))
+		}
+	}
+
+	externalSecrets := &unstructured.UnstructuredList{}
+	externalSecrets.SetGroupVersionKind(ExternalSecretGVK)
+	if err := serverClient.List(ctx, externalSecrets, k8sclient.InNamespace(installation.Namespace), k8sclient.MatchingLabels{ManagedLabel: "true"}); err != nil {
+		if meta.IsNoMatchError(err) {
+			installation.Status.ExternalSecrets = nil
+			return nil
+		}
+		return fmt.Errorf("failed to list external secrets: %w", err)
+	}
+	for i := range externalSecrets.Items {
+		if wanted[externalSecrets.Items[i].GetName()] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, &externalSecrets.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete external secret %s: %w", externalSecrets.Items[i].GetName(), err)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	installation.Status.ExternalSecrets = statuses
+	return nil
+}
+
+// SecretNames returns the names of the secrets synced from the external
+// secret manager, the installation is reconciled when they change
+func SecretNames(installation *v1alpha1.RHMI) []string {
+	if installation.Spec.ExternalSecrets == nil {
+		return nil
+	}
+	names := make([]string, 0, len(installation.Spec.ExternalSecrets.Secrets))
+	for _, secret := range installation.Spec.ExternalSecrets.Secrets {
+		names = append(names, secret.Name)
+	}
+	return names
+}
+
+// ExternalSecretSpec returns the ExternalSecret spec syncing the secret from
+// the secret store of the spec. The secret is created by the External Secrets
+// Operator without owner, so it's kept when the ExternalSecret is deleted
+func ExternalSecretSpec(spec *v1alpha1.ExternalSecretsSpec, secret v1alpha1.ExternalSecretSpec) map[string]interface{} {
+	refreshInterval := DefaultRefreshInterval
+	if spec.RefreshInterval != nil {
+		refreshInterval = spec.RefreshInterval.Duration
+	}
+	storeKind := spec.SecretStore.Kind
+	if storeKind == "" {
+		storeKind = v1alpha1.ExternalSecretStore
+	}
+
+	target := map[string]interface{}{
+		"name":           secret.Name,
+		"creationPolicy": "Orphan",
+		"deletionPolicy": "Retain",
+	}
+	if secret.Type != "" {
+		target["template"] = map[string]interface{}{"type": string(secret.Type)}
+	}
+
+	externalSecretSpec := map[string]interface{}{
+		"refreshInterval": refreshInterval.String(),
+		"secretStoreRef": map[string]interface{}{
+			"name": spec.SecretStore.Name,
+			"kind": string(storeKind),
+		},
+		"target": target,
+	}
+
+	if len(secret.Properties) == 0 {
+		externalSecretSpec["dataFrom"] = []interface{}{
+			map[string]interface{}{"extract": map[string]interface{}{"key": secret.Key}},
+		}
+		return externalSecretSpec
+	}
+
+	keys := make([]string, 0, len(secret.Properties))
+	for key := range secret.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, map[string]interface{}{
+			"secretKey": key,
+			"remoteRef": map[string]interface{}{
+				"key":      secret.Key,
+				"property": secret.Properties[key],
+			},
+		})
+	}
+	externalSecretSpec["data"] = data
+	return externalSecretSpec
+}
+
+// Status returns the sync of the ExternalSecret from its Ready condition
+func Status(externalSecret *unstructured.Unstructured) v1alpha1.ExternalSecretStatus {
+	status := v1alpha1.ExternalSecretStatus{Name: externalSecret.GetName(), Message: "not synced yet"}
+	if refreshTime, ok, _ := unstructured.NestedString(externalSecret.Object, "status", "refreshTime"); ok {
+		if t, err := time.Parse(time.RFC3339, refreshTime); err == nil {
+			lastSynced := metav1.NewTime(t)
+			status.LastSynced = &lastSynced
+		}
+	}
+	conditions, _, _ := unstructured.NestedSlice(externalSecret.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		status.Synced = condition["status"] == string(metav1.ConditionTrue)
+		status.Message, _ = condition["message"].(string)
+		if status.Synced {
+			status.Message = ""
+		}
+	}
+	return status
+}
+
+func newExternalSecret(name, namespace string) *unstructured.Unstructured {
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetGroupVersionKind(ExternalSecretGVK)
+	externalSecret.SetName(name)
+	externalSecret.SetNamespace(namespace)
+	return externalSecret
+}
@@ -0,0 +1,187 @@
+package externalsecrets
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const namespace = "redhat-rhoam-operator"
+
+func TestReconcileExternalSecrets(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := newExternalSecret("old-smtp", namespace)
+	stale.SetLabels(map[string]string{ManagedLabel: "true"})
+	unmanaged := newExternalSecret("customer-secret", namespace)
+
+	tests := []struct {
+		name            string
+		externalSecrets *v1alpha1.ExternalSecretsSpec
+		want            []string
+	}{
+		{
+			name: "syncs the secrets of the spec and removes the stale ones",
+			externalSecrets: &v1alpha1.ExternalSecretsSpec{
+				SecretStore: v1alpha1.ExternalSecretStoreRef{Name: "vault", Kind: v1alpha1.ExternalClusterSecretStore},
+				Secrets:     []v1alpha1.ExternalSecretSpec{{Name: "redhat-rhoam-smtp", Key: "rhoam/smtp"}},
+			},
+			want: []string{"redhat-rhoam-smtp"},
+		},
+		{
+			name: "removes the external secrets without external secrets spec",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, []runtime.Object{stale.DeepCopy(), unmanaged.DeepCopy()}...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{SMTPSecret: "redhat-rhoam-smtp", ExternalSecrets: tt.externalSecrets},
+			}
+
+			if err := ReconcileExternalSecrets(context.TODO(), serverClient, installation); err != nil {
+				t.Fatalf("ReconcileExternalSecrets() error = %v", err)
+			}
+
+			for _, name := range tt.want {
+				externalSecret := newExternalSecret(name, namespace)
+				if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(externalSecret), externalSecret); err != nil {
+					t.Fatalf("expected external secret %s: %v", name, err)
+				}
+				target, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "target", "name")
+				if target != name {
+					t.Errorf("external secret %s targets %q", name, target)
+				}
+			}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(stale), newExternalSecret("old-smtp", namespace)); !k8serr.IsNotFound(err) {
+				t.Errorf("expected the stale external secret to be removed, got %v", err)
+			}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(unmanaged), newExternalSecret("customer-secret", namespace)); err != nil {
+				t.Errorf("expected the unmanaged external secret to be kept, got %v", err)
+			}
+			if len(installation.Status.ExternalSecrets) != len(tt.want) {
+				t.Errorf("status = %v, want %d secrets", installation.Status.ExternalSecrets, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestExternalSecretSpec(t *testing.T) {
+	spec := &v1alpha1.ExternalSecretsSpec{
+		SecretStore:     v1alpha1.ExternalSecretStoreRef{Name: "aws"},
+		RefreshInterval: &metav1.Duration{Duration: 15 * time.Minute},
+	}
+
+	tests := []struct {
+		name   string
+		secret v1alpha1.ExternalSecretSpec
+		path   []string
+		want   interface{}
+	}{
+		{
+			name:   "secret store defaults to the namespace one",
+			secret: v1alpha1.ExternalSecretSpec{Name: "smtp", Key: "rhoam/smtp"},
+			path:   []string{"secretStoreRef"},
+			want:   map[string]interface{}{"name": "aws", "kind": "SecretStore"},
+		},
+		{
+			name:   "every property extracted without properties",
+			secret: v1alpha1.ExternalSecretSpec{Name: "smtp", Key: "rhoam/smtp"},
+			path:   []string{"dataFrom"},
+			want:   []interface{}{map[string]interface{}{"extract": map[string]interface{}{"key": "rhoam/smtp"}}},
+		},
+		{
+			name: "properties mapped to the keys of the secret",
+			secret: v1alpha1.ExternalSecretSpec{Name: "custom-tls", Key: "rhoam/tls", Type: corev1.SecretTypeTLS, Properties: map[string]string{
+				"tls.key": "key",
+				"tls.crt": "certificate",
+			}},
+			path: []string{"data"},
+			want: []interface{}{
+				map[string]interface{}{"secretKey": "tls.crt", "remoteRef": map[string]interface{}{"key": "rhoam/tls", "property": "certificate"}},
+				map[string]interface{}{"secretKey": "tls.key", "remoteRef": map[string]interface{}{"key": "rhoam/tls", "property": "key"}},
+			},
+		},
+		{
+			name:   "secret type set by the template",
+			secret: v1alpha1.ExternalSecretSpec{Name: "custom-tls", Key: "rhoam/tls", Type: corev1.SecretTypeTLS},
+			path:   []string{"target", "template"},
+			want:   map[string]interface{}{"type": "kubernetes.io/tls"},
+		},
+		{
+			name:   "refresh interval of the spec",
+			secret: v1alpha1.ExternalSecretSpec{Name: "smtp", Key: "rhoam/smtp"},
+			path:   []string{"refreshInterval"},
+			want:   "15m0s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			externalSecretSpec := ExternalSecretSpec(spec, tt.secret)
+			got, _, err := unstructured.NestedFieldNoCopy(externalSecretSpec, tt.path...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		want   v1alpha1.ExternalSecretStatus
+	}{
+		{
+			name: "not synced yet without status",
+			want: v1alpha1.ExternalSecretStatus{Name: "smtp", Message: "not synced yet"},
+		},
+		{
+			name: "synced",
+			status: map[string]interface{}{
+				"refreshTime": "2026-10-17T12:00:00Z",
+				"conditions":  []interface{}{map[string]interface{}{"type": "Ready", "status": "True", "message": "Secret was synced"}},
+			},
+			want: v1alpha1.ExternalSecretStatus{Name: "smtp", Synced: true, LastSynced: &metav1.Time{Time: time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "sync error",
+			status: map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False", "message": "could not get secret data from provider"}},
+			},
+			want: v1alpha1.ExternalSecretStatus{Name: "smtp", Message: "could not get secret data from provider"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			externalSecret := newExternalSecret("smtp", namespace)
+			if tt.status != nil {
+				externalSecret.Object["status"] = tt.status
+			}
+			got := Status(externalSecret)
+			if got.Name != tt.want.Name || got.Synced != tt.want.Synced || got.Message != tt.want.Message {
+				t.Errorf("Status() = %+v, want %+v", got, tt.want)
+			}
+			if (got.LastSynced == nil) != (tt.want.LastSynced == nil) || (got.LastSynced != nil && !got.LastSynced.Equal(tt.want.LastSynced)) {
+				t.Errorf("Status() LastSynced = %v, want %v", got.LastSynced, tt.want.LastSynced)
+			}
+		})
+	}
+}
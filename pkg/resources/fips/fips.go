@@ -0,0 +1,130 @@
+package fips
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// OpenSSLEnvName forces the OpenSSL of the RHEL images, such as the
+	// one of APIcast, in FIPS mode, which only negotiates the approved
+	// ciphers
+	OpenSSLEnvName = "OPENSSL_FORCE_FIPS_MODE"
+	// KeycloakJavaOptsEnvName appends the options of the JDK of the SSO
+	// instances
+	KeycloakJavaOptsEnvName = "JAVA_OPTS_APPEND"
+	// keycloakFIPSJavaOpt switches the JDK to the crypto provider of the
+	// FIPS mode, backed by the NSS of the image
+	keycloakFIPSJavaOpt = "-Dcom.redhat.fips=true"
+
+	// validatedRegistry hosts the images built with the FIPS validated
+	// crypto libraries of RHEL
+	validatedRegistry = "registry.redhat.io/"
+)
+
+// Ciphers are the TLS 1.2 cipher suites approved by FIPS 140, TLS 1.3
+// only has approved ones
+var Ciphers = []string{
+	"ECDHE-ECDSA-AES128-GCM-SHA256",
+	"ECDHE-RSA-AES128-GCM-SHA256",
+	"ECDHE-ECDSA-AES256-GCM-SHA384",
+	"ECDHE-RSA-AES256-GCM-SHA384",
+}
+
+// Curves are the elliptic curves approved by FIPS 140
+var Curves = []string{"P-256", "P-384"}
+
+// Enabled returns whether the installation runs in FIPS mode
+func Enabled(installation *integreatlyv1alpha1.RHMI) bool {
+	return installation.Spec.FIPS != nil
+}
+
+// Image returns the FIPS validated variant of the image when the
+// installation runs in FIPS mode and the variant is set, the image otherwise
+func Image(installation *integreatlyv1alpha1.RHMI, image string) string {
+	if !Enabled(installation) {
+		return image
+	}
+	if variant, ok := installation.Spec.FIPS.Images[image]; ok && variant != "" {
+		return variant
+	}
+	return image
+}
+
+// ProductStatus returns the FIPS compliance of a product running the images,
+// after their replacement by their variants, or nil when the installation
+// doesn't run in FIPS mode. The images outside of registry.redhat.io aren't
+// built with validated crypto libraries
+func ProductStatus(installation *integreatlyv1alpha1.RHMI, images ...string) *integreatlyv1alpha1.FIPSStatus {
+	if !Enabled(installation) {
+		return nil
+	}
+	var unvalidated []string
+	for _, image := range images {
+		if image = Image(installation, image); !strings.HasPrefix(image, validatedRegistry) {
+			unvalidated = append(unvalidated, image)
+		}
+	}
+	if len(unvalidated) == 0 {
+		return &integreatlyv1alpha1.FIPSStatus{Compliant: true}
+	}
+	sort.Strings(unvalidated)
+	return &integreatlyv1alpha1.FIPSStatus{
+		Message: fmt.Sprintf("images without FIPS validated variant: %s", strings.Join(unvalidated, ", ")),
+	}
+}
+
+// TLSParameters restricts the TLS of envoy to the FIPS approved versions,
+// ciphers and curves when the installation runs in FIPS mode, nil otherwise
+func TLSParameters(installation *integreatlyv1alpha1.RHMI) *tlsv3.TlsParameters {
+	if !Enabled(installation) {
+		return nil
+	}
+	return &tlsv3.TlsParameters{
+		TlsMinimumProtocolVersion: tlsv3.TlsParameters_TLSv1_2,
+		TlsMaximumProtocolVersion: tlsv3.TlsParameters_TLSv1_3,
+		CipherSuites:              Ciphers,
+		EcdhCurves:                Curves,
+	}
+}
+
+// Mutate returns a pod template mutation that forces the OpenSSL of the
+// containers in FIPS mode when the installation runs in FIPS mode, or
+// removes it otherwise
+func Mutate(installation *integreatlyv1alpha1.RHMI) func(metav1.Object, *corev1.PodTemplateSpec) error {
+	return func(_ metav1.Object, podTemplate *corev1.PodTemplateSpec) error {
+		for i := range podTemplate.Spec.Containers {
+			container := &podTemplate.Spec.Containers[i]
+			env := make([]corev1.EnvVar, 0, len(container.Env)+1)
+			for _, envVar := range container.Env {
+				if envVar.Name != OpenSSLEnvName {
+					env = append(env, envVar)
+				}
+			}
+			if Enabled(installation) {
+				env = append(env, corev1.EnvVar{Name: OpenSSLEnvName, Value: "1"})
+			}
+			if len(env) == 0 {
+				env = nil
+			}
+			container.Env = env
+		}
+		return nil
+	}
+}
+
+// KeycloakEnv returns the environment variables switching the SSO instances
+// to the FIPS crypto provider, nil when the installation doesn't run in FIPS
+// mode
+func KeycloakEnv(installation *integreatlyv1alpha1.RHMI) []corev1.EnvVar {
+	if !Enabled(installation) {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: KeycloakJavaOptsEnvName, Value: keycloakFIPSJavaOpt}}
+}
@@ -0,0 +1,140 @@
+package fips
+
+import (
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	limitadorImage = "quay.io/3scale/limitador:v0.5.1"
+	marin3rImage   = "quay.io/3scale/marin3r:v0.10.0"
+)
+
+func TestProductStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		fips   *integreatlyv1alpha1.FIPSSpec
+		images []string
+		want   *integreatlyv1alpha1.FIPSStatus
+	}{
+		{
+			name:   "no status without fips mode",
+			images: []string{limitadorImage},
+		},
+		{
+			name: "compliant without images",
+			fips: &integreatlyv1alpha1.FIPSSpec{},
+			want: &integreatlyv1alpha1.FIPSStatus{Compliant: true},
+		},
+		{
+			name:   "images without validated variant",
+			fips:   &integreatlyv1alpha1.FIPSSpec{},
+			images: []string{marin3rImage, limitadorImage},
+			want: &integreatlyv1alpha1.FIPSStatus{
+				Message: "images without FIPS validated variant: " + limitadorImage + ", " + marin3rImage,
+			},
+		},
+		{
+			name: "images replaced by their validated variants",
+			fips: &integreatlyv1alpha1.FIPSSpec{Images: map[string]string{
+				limitadorImage: "registry.redhat.io/3scale-amp2/limitador-rhel8:1.0",
+				marin3rImage:   "registry.redhat.io/3scale-amp2/marin3r-rhel8:1.0",
+			}},
+			images: []string{limitadorImage, marin3rImage},
+			want:   &integreatlyv1alpha1.FIPSStatus{Compliant: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{FIPS: tt.fips}}
+			if got := ProductStatus(installation, tt.images...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProductStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImage(t *testing.T) {
+	variant := "registry.redhat.io/3scale-amp2/limitador-rhel8:1.0"
+	tests := []struct {
+		name string
+		fips *integreatlyv1alpha1.FIPSSpec
+		want string
+	}{
+		{
+			name: "image kept without fips mode",
+			want: limitadorImage,
+		},
+		{
+			name: "image kept without variant",
+			fips: &integreatlyv1alpha1.FIPSSpec{Images: map[string]string{marin3rImage: variant}},
+			want: limitadorImage,
+		},
+		{
+			name: "image replaced by its variant",
+			fips: &integreatlyv1alpha1.FIPSSpec{Images: map[string]string{limitadorImage: variant}},
+			want: variant,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{FIPS: tt.fips}}
+			if got := Image(installation, limitadorImage); got != tt.want {
+				t.Errorf("Image() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutate(t *testing.T) {
+	fipsEnv := corev1.EnvVar{Name: OpenSSLEnvName, Value: "1"}
+	appEnv := corev1.EnvVar{Name: "APP", Value: "true"}
+	tests := []struct {
+		name string
+		fips *integreatlyv1alpha1.FIPSSpec
+		env  []corev1.EnvVar
+		want []corev1.EnvVar
+	}{
+		{
+			name: "env unchanged without fips mode",
+			env:  []corev1.EnvVar{appEnv},
+			want: []corev1.EnvVar{appEnv},
+		},
+		{
+			name: "openssl forced in fips mode",
+			fips: &integreatlyv1alpha1.FIPSSpec{},
+			env:  []corev1.EnvVar{appEnv, fipsEnv},
+			want: []corev1.EnvVar{appEnv, fipsEnv},
+		},
+		{
+			name: "openssl env removed once fips mode is unset",
+			env:  []corev1.EnvVar{fipsEnv},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{FIPS: tt.fips}}
+			podTemplate := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "apicast", Env: tt.env}}}}
+			if err := Mutate(installation)(&metav1.ObjectMeta{}, podTemplate); err != nil {
+				t.Fatalf("Mutate() error = %v", err)
+			}
+			if got := podTemplate.Spec.Containers[0].Env; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mutate() env = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSParameters(t *testing.T) {
+	if params := TLSParameters(&integreatlyv1alpha1.RHMI{}); params != nil {
+		t.Errorf("TLSParameters() = %v without fips mode, want nil", params)
+	}
+	params := TLSParameters(&integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{FIPS: &integreatlyv1alpha1.FIPSSpec{}}})
+	if params == nil || !reflect.DeepEqual(params.CipherSuites, Ciphers) || !reflect.DeepEqual(params.EcdhCurves, Curves) {
+		t.Fatalf("TLSParameters() = %v, want the FIPS ciphers and curves", params)
+	}
+}
@@ -23,7 +23,7 @@ func upgradeApproval(ctx context.Context, preUpgradeBackupExecutor backup.Backup
 		if ip.Generation > 1 {
 			backupTimeout := time.Minute * 20
 			log.Infof("Triggering pre-upgrade backups", l.Fields{"backupTimeout": backupTimeout})
-			if err := preUpgradeBackupExecutor.PerformBackup(client, backupTimeout); err != nil {
+			if _, err := preUpgradeBackupExecutor.PerformBackup(client, backupTimeout); err != nil {
 				return fmt.Errorf("error performing pre-upgrade backup: %w", err)
 			}
 		}
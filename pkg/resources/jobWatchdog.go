@@ -0,0 +1,199 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultJobPendingTimeout = 15 * time.Minute
+	DefaultJobActiveTimeout  = time.Hour
+	DefaultJobMaxRetries     = 3
+
+	// JobRetriesAnnotation counts the times a stuck Job was recreated
+	JobRetriesAnnotation = "integreatly.org/job-retries"
+
+	// jobRetryBackoff is doubled on every retry of the same Job
+	jobRetryBackoff = time.Minute
+)
+
+// jobControllerLabels are set by the Job controller and must be removed
+// before a Job is recreated so the new Job gets its own selector
+var jobControllerLabels = []string{
+	"controller-uid",
+	"job-name",
+	"batch.kubernetes.io/controller-uid",
+	"batch.kubernetes.io/job-name",
+}
+
+// RemediateStuckJobs cleans up the Jobs in the namespaces owned by the
+// installation that failed, ran past their deadline or whose pods stayed
+// Pending. Jobs created by the operator are recreated with an exponential
+// backoff, Jobs owned by another resource, such as a CronJob, are only deleted
+// so their owner runs them again. It returns a description of the Jobs still
+// stuck after the retries are exhausted
+func RemediateStuckJobs(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI, log l.Logger, now time.Time) ([]string, error) {
+	pendingTimeout, activeTimeout, maxRetries := jobWatchdogSettings(inst.Spec.JobWatchdog)
+
+	nsList := &corev1.NamespaceList{}
+	if err := client.List(ctx, nsList, k8sclient.MatchingLabels{OwnerLabelKey: string(inst.GetUID())}); err != nil {
+		return nil, fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+
+	var stuck []string
+	for _, ns := range nsList.Items {
+		jobs := &batchv1.JobList{}
+		if err := client.List(ctx, jobs, k8sclient.InNamespace(ns.Name)); err != nil {
+			return nil, fmt.Errorf("failed to list jobs in namespace %s: %w", ns.Name, err)
+		}
+
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			if job.DeletionTimestamp != nil {
+				continue
+			}
+			reason, err := getJobStuckReason(ctx, client, job, pendingTimeout, activeTimeout, now)
+			if err != nil {
+				return nil, err
+			}
+			if reason == "" {
+				continue
+			}
+
+			if len(job.OwnerReferences) > 0 {
+				// Failed Jobs are kept by their owner for its history
+				if isJobFailed(job) {
+					continue
+				}
+				log.Warningf("Deleting stuck job", l.Fields{"job": job.Name, "ns": job.Namespace, "reason": reason})
+				if err := deleteJob(ctx, client, job); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			retries, _ := strconv.Atoi(job.Annotations[JobRetriesAnnotation])
+			if retries >= maxRetries {
+				stuck = append(stuck, fmt.Sprintf("%s/%s: %s", job.Namespace, job.Name, reason))
+				continue
+			}
+			if now.Sub(job.CreationTimestamp.Time) < jobRetryBackoff<<retries {
+				continue
+			}
+
+			log.Warningf("Retrying stuck job", l.Fields{"job": job.Name, "ns": job.Namespace, "reason": reason, "retry": retries + 1})
+			if err := deleteJob(ctx, client, job); err != nil {
+				return nil, err
+			}
+			if err := client.Create(ctx, retryJob(job, retries+1)); err != nil {
+				return nil, fmt.Errorf("failed to recreate job %s/%s: %w", job.Namespace, job.Name, err)
+			}
+		}
+	}
+
+	return stuck, nil
+}
+
+func jobWatchdogSettings(spec *integreatlyv1alpha1.JobWatchdogSpec) (time.Duration, time.Duration, int) {
+	pendingTimeout, activeTimeout, maxRetries := DefaultJobPendingTimeout, DefaultJobActiveTimeout, DefaultJobMaxRetries
+	if spec == nil {
+		return pendingTimeout, activeTimeout, maxRetries
+	}
+	if spec.PendingTimeoutSeconds > 0 {
+		pendingTimeout = time.Duration(spec.PendingTimeoutSeconds) * time.Second
+	}
+	if spec.ActiveTimeoutSeconds > 0 {
+		activeTimeout = time.Duration(spec.ActiveTimeoutSeconds) * time.Second
+	}
+	if spec.MaxRetries != nil {
+		maxRetries = int(*spec.MaxRetries)
+	}
+	return pendingTimeout, activeTimeout, maxRetries
+}
+
+// getJobStuckReason returns why the Job is considered stuck, or an empty
+// string when it completed or is still within its deadlines
+func getJobStuckReason(ctx context.Context, client k8sclient.Client, job *batchv1.Job, pendingTimeout, activeTimeout time.Duration, now time.Time) (string, error) {
+	if job.Status.CompletionTime != nil {
+		return "", nil
+	}
+	if isJobFailed(job) {
+		return "failed", nil
+	}
+	if job.Status.StartTime != nil && now.Sub(job.Status.StartTime.Time) > activeTimeout {
+		return fmt.Sprintf("active for more than %s", activeTimeout), nil
+	}
+	if now.Sub(job.CreationTimestamp.Time) <= pendingTimeout {
+		return "", nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := client.List(ctx, pods, k8sclient.InNamespace(job.Namespace), k8sclient.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", fmt.Errorf("failed to list pods of job %s/%s: %w", job.Namespace, job.Name, err)
+	}
+	if len(pods.Items) == 0 {
+		return "", nil
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("pods pending for more than %s", pendingTimeout), nil
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteJob(ctx context.Context, client k8sclient.Client, job *batchv1.Job) error {
+	if err := client.Delete(ctx, job, k8sclient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		return fmt.Errorf("failed to delete job %s/%s: %w", job.Namespace, job.Name, err)
+	}
+	return nil
+}
+
+// retryJob returns a copy of the Job to be created again once it's deleted
+func retryJob(job *batchv1.Job, retries int) *batchv1.Job {
+	retry := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        job.Name,
+			Namespace:   job.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: *job.Spec.DeepCopy(),
+	}
+	for key, value := range job.Labels {
+		retry.Labels[key] = value
+	}
+	for key, value := range job.Annotations {
+		retry.Annotations[key] = value
+	}
+	retry.Annotations[JobRetriesAnnotation] = strconv.Itoa(retries)
+
+	for _, label := range jobControllerLabels {
+		delete(retry.Labels, label)
+	}
+	if retry.Spec.ManualSelector == nil || !*retry.Spec.ManualSelector {
+		retry.Spec.Selector = nil
+		for _, label := range jobControllerLabels {
+			delete(retry.Spec.Template.Labels, label)
+		}
+	}
+	return retry
+}
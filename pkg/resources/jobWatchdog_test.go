@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRemediateStuckJobs(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rhoam",
+			Namespace: "redhat-rhoam-operator",
+			UID:       "installation-uid",
+		},
+	}
+	productNamespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "redhat-rhoam-3scale",
+			Labels: map[string]string{OwnerLabelKey: string(installation.UID)},
+		},
+	}
+	now := time.Now()
+	longAgo := metav1.NewTime(now.Add(-2 * DefaultJobActiveTimeout))
+
+	job := func(name string, created metav1.Time, retries string, conditions ...batchv1.JobCondition) *batchv1.Job {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         productNamespace.Name,
+				CreationTimestamp: created,
+				Labels:            map[string]string{"app": name, "controller-uid": "uid"},
+				Annotations:       map[string]string{},
+			},
+			Spec: batchv1.JobSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"controller-uid": "uid"}},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"controller-uid": "uid", "job-name": name}},
+				},
+			},
+			Status: batchv1.JobStatus{Conditions: conditions},
+		}
+		if retries != "" {
+			job.Annotations[JobRetriesAnnotation] = retries
+		}
+		return job
+	}
+	failed := batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}
+	pendingPod := func(jobName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: jobName + "-abcde", Namespace: productNamespace.Name, Labels: map[string]string{"job-name": jobName}},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		}
+	}
+
+	pendingJob := job("system-app-migration", longAgo, "")
+	runningJob := job("backup", longAgo, "")
+	runningJob.Status.StartTime = &longAgo
+	completedJob := job("completed", longAgo, "")
+	completedJob.Status.CompletionTime = &longAgo
+	cronJobJob := job("cronjob-run", longAgo, "")
+	cronJobJob.Status.StartTime = &longAgo
+	cronJobJob.OwnerReferences = []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "cronjob", UID: "cronjob-uid"}}
+
+	tests := []struct {
+		name        string
+		initObjs    []runtime.Object
+		spec        *integreatlyv1alpha1.JobWatchdogSpec
+		wantStuck   int
+		wantRetries map[string]string
+		wantDeleted []string
+	}{
+		{
+			name:        "test completed and recent jobs are left alone",
+			initObjs:    []runtime.Object{productNamespace, completedJob, job("recent", metav1.NewTime(now), "", failed)},
+			wantRetries: map[string]string{"completed": "", "recent": ""},
+		},
+		{
+			name:        "test job with pending pods is retried",
+			initObjs:    []runtime.Object{productNamespace, pendingJob, pendingPod(pendingJob.Name)},
+			wantRetries: map[string]string{pendingJob.Name: "1"},
+		},
+		{
+			name:        "test job past its active deadline is retried",
+			initObjs:    []runtime.Object{productNamespace, runningJob},
+			wantRetries: map[string]string{runningJob.Name: "1"},
+		},
+		{
+			name:        "test pending timeout is configurable",
+			initObjs:    []runtime.Object{productNamespace, job("slow", metav1.NewTime(now.Add(-2*time.Minute)), ""), pendingPod("slow")},
+			spec:        &integreatlyv1alpha1.JobWatchdogSpec{PendingTimeoutSeconds: 60},
+			wantRetries: map[string]string{"slow": "1"},
+		},
+		{
+			name:        "test failed job waits for its backoff",
+			initObjs:    []runtime.Object{productNamespace, job("flaky", metav1.NewTime(now.Add(-3*time.Minute)), "2", failed)},
+			wantRetries: map[string]string{"flaky": "2"},
+		},
+		{
+			name:      "test job is reported once its retries are exhausted",
+			initObjs:  []runtime.Object{productNamespace, job("broken", longAgo, "3", failed)},
+			wantStuck: 1,
+		},
+		{
+			name:        "test jobs owned by a cron job are only deleted",
+			initObjs:    []runtime.Object{productNamespace, cronJobJob},
+			wantDeleted: []string{cronJobJob.Name},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, tt.initObjs...)
+			inst := installation.DeepCopy()
+			inst.Spec.JobWatchdog = tt.spec
+
+			stuck, err := RemediateStuckJobs(context.TODO(), client, inst, l.NewLogger(), now)
+			if err != nil {
+				t.Fatalf("RemediateStuckJobs() error = %v", err)
+			}
+			if len(stuck) != tt.wantStuck {
+				t.Fatalf("expected %d stuck jobs, got %v", tt.wantStuck, stuck)
+			}
+
+			for name, retries := range tt.wantRetries {
+				got := &batchv1.Job{}
+				if err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: productNamespace.Name}, got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Annotations[JobRetriesAnnotation] != retries {
+					t.Errorf("expected job %s to have %q retries, got %q", name, retries, got.Annotations[JobRetriesAnnotation])
+				}
+				if retries == "1" && (got.Spec.Selector != nil || got.Labels["controller-uid"] != "" || got.Spec.Template.Labels["job-name"] != "") {
+					t.Errorf("expected job controller labels to be removed from the retried job, got %+v", got)
+				}
+			}
+			for _, name := range tt.wantDeleted {
+				err := client.Get(context.TODO(), k8sclient.ObjectKey{Name: name, Namespace: productNamespace.Name}, &batchv1.Job{})
+				if !k8serr.IsNotFound(err) {
+					t.Errorf("expected job %s to be deleted, got %v", name, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package logforwarding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/quota"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ForwarderName is the name of the ClusterLogForwarder and of its
+	// collector service account
+	ForwarderName = "rhoam-log-forwarder"
+
+	// collectorClusterRole is the role of the OpenShift Logging operator
+	// allowing a collector to read the application logs
+	collectorClusterRole = "collect-application-logs"
+)
+
+// ClusterLogForwarderGVK isn't vendored, the OpenShift Logging operator is
+// optional so the forwarder is reconciled as unstructured
+var ClusterLogForwarderGVK = schema.GroupVersionKind{Group: "logging.openshift.io", Version: "v1", Kind: "ClusterLogForwarder"}
+
+// source is where the logs of a LogSource are collected from
+type source struct {
+	namespaces []string
+	labels     map[string]string
+}
+
+func sources(namespacePrefix string) map[v1alpha1.LogSource]source {
+	return map[v1alpha1.LogSource]source{
+		v1alpha1.LogSourceAPIcastAccess: {
+			namespaces: []string{namespacePrefix + "3scale"},
+			labels:     map[string]string{"threescale_component": "apicast"},
+		},
+		v1alpha1.LogSourceKeycloakAudit: {
+			namespaces: []string{namespacePrefix + "rhsso", namespacePrefix + "user-sso"},
+			labels:     map[string]string{"app": "keycloak"},
+		},
+		v1alpha1.LogSourceRateLimitDecisions: {
+			namespaces: []string{namespacePrefix + "marin3r"},
+			labels:     map[string]string{"app": quota.RateLimitName},
+		},
+	}
+}
+
+// ReconcileLogForwarding creates the ClusterLogForwarder shipping the product
+// logs of the log forwarding spec of the installation to its outputs, with a
+// collector service account allowed to read them. They're removed when the
+// log forwarding isn't in the spec anymore
+func ReconcileLogForwarding(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI) error {
+	objectMeta := metav1.ObjectMeta{Name: ForwarderName, Namespace: installation.Namespace}
+	spec := installation.Spec.LogForwarding
+	if spec == nil {
+		forwarder := &unstructured.Unstructured{}
+		forwarder.SetGroupVersionKind(ClusterLogForwarderGVK)
+		forwarder.SetName(ForwarderName)
+		forwarder.SetNamespace(installation.Namespace)
+		if err := serverClient.Delete(ctx, forwarder); err != nil && !k8serr.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete log forwarder: %w", err)
+		}
+		for _, obj := range []k8sclient.Object{
+			&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: ForwarderName}},
+			&corev1.ServiceAccount{ObjectMeta: objectMeta},
+		} {
+			if err := serverClient.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+				return fmt.Errorf("failed to delete log forwarder %T: %w", obj, err)
+			}
+		}
+		return nil
+	}
+
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, serviceAccount, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(serviceAccount, installation)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile log collector service account: %w", err)
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: ForwarderName}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, binding, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(binding, installation)
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: collectorClusterRole}
+		binding.Subjects = []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: ForwarderName, Namespace: installation.Namespace}}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile log collector cluster role binding: %w", err)
+	}
+
+	forwarder := &unstructured.Unstructured{}
+	forwarder.SetGroupVersionKind(ClusterLogForwarderGVK)
+	forwarder.SetName(ForwarderName)
+	forwarder.SetNamespace(installation.Namespace)
+	forwarderSpec := ForwarderSpec(installation.Spec.NamespacePrefix, spec)
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, forwarder, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(forwarder, installation)
+		return unstructured.SetNestedField(forwarder.Object, forwarderSpec, "spec")
+	}); err != nil {
+		if meta.IsNoMatchError(err) {
+			return fmt.Errorf("the ClusterLogForwarder API isn't available, the OpenShift Logging operator is required for log forwarding: %w", err)
+		}
+		return fmt.Errorf("failed to reconcile log forwarder: %w", err)
+	}
+	return nil
+}
+
+// ForwarderSpec returns the ClusterLogForwarder spec of the log forwarding
+// spec, with an input and a pipeline per source
+func ForwarderSpec(namespacePrefix string, spec *v1alpha1.LogForwardingSpec) map[string]interface{} {
+	outputs := make([]interface{}, 0, len(spec.Outputs))
+	for _, output := range spec.Outputs {
+		o := map[string]interface{}{"name": output.Name}
+		switch output.Type {
+		case v1alpha1.LogOutputCloudWatch:
+			cloudwatch := map[string]interface{}{
+				"groupBy": "namespaceName",
+				"region":  output.Region,
+			}
+			if output.GroupPrefix != "" {
+				cloudwatch["groupPrefix"] = output.GroupPrefix
+			}
+			o["type"] = "cloudwatch"
+			o["cloudwatch"] = cloudwatch
+		case v1alpha1.LogOutputLoki:
+			o["type"] = "loki"
+			o["url"] = output.URL
+		case v1alpha1.LogOutputSplunk:
+			o["type"] = "splunk"
+			o["url"] = output.URL
+		}
+		if output.Secret != "" {
+			o["secret"] = map[string]interface{}{"name": output.Secret}
+		}
+		outputs = append(outputs, o)
+	}
+
+	productSources := sources(namespacePrefix)
+	inputs := make([]interface{}, 0, len(spec.Sources))
+	pipelines := make([]interface{}, 0, len(spec.Sources))
+	for _, s := range spec.Sources {
+		productSource, ok := productSources[s.Source]
+		if !ok {
+			continue
+		}
+		name := inputName(s.Source)
+
+		namespaces := make([]interface{}, 0, len(productSource.namespaces))
+		for _, ns := range productSource.namespaces {
+			namespaces = append(namespaces, ns)
+		}
+		labels := map[string]interface{}{}
+		for k, v := range productSource.labels {
+			labels[k] = v
+		}
+		inputs = append(inputs, map[string]interface{}{
+			"name": name,
+			"application": map[string]interface{}{
+				"namespaces": namespaces,
+				"selector":   map[string]interface{}{"matchLabels": labels},
+			},
+		})
+
+		outputRefs := make([]interface{}, 0, len(s.Outputs))
+		for _, output := range s.Outputs {
+			outputRefs = append(outputRefs, output)
+		}
+		pipelines = append(pipelines, map[string]interface{}{
+			"name":       name,
+			"inputRefs":  []interface{}{name},
+			"outputRefs": outputRefs,
+		})
+	}
+
+	return map[string]interface{}{
+		"serviceAccountName": ForwarderName,
+		"inputs":             inputs,
+		"outputs":            outputs,
+		"pipelines":          pipelines,
+	}
+}
+
+// inputName turns a LogSource such as APIcastAccess into apicast-access
+func inputName(logSource v1alpha1.LogSource) string {
+	var name strings.Builder
+	runes := []rune(string(logSource))
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 && runes[i-1] >= 'a' && runes[i-1] <= 'z' {
+			name.WriteRune('-')
+		}
+		name.WriteString(strings.ToLower(string(r)))
+	}
+	return name.String()
+}
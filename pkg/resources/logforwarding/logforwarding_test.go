@@ -0,0 +1,122 @@
+package logforwarding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileLogForwarding(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	existingForwarder := &unstructured.Unstructured{}
+	existingForwarder.SetGroupVersionKind(ClusterLogForwarderGVK)
+	existingForwarder.SetName(ForwarderName)
+	existingForwarder.SetNamespace(namespace)
+
+	tests := []struct {
+		name          string
+		logForwarding *v1alpha1.LogForwardingSpec
+		objects       []runtime.Object
+		wantForwarder bool
+	}{
+		{
+			name: "creates the forwarder and its collector",
+			logForwarding: &v1alpha1.LogForwardingSpec{
+				Outputs: []v1alpha1.LogOutputSpec{{Name: "loki", Type: v1alpha1.LogOutputLoki, URL: "https://loki.example.com"}},
+				Sources: []v1alpha1.LogSourceSpec{{Source: v1alpha1.LogSourceAPIcastAccess, Outputs: []string{"loki"}}},
+			},
+			wantForwarder: true,
+		},
+		{
+			name: "removes the forwarder and its collector without log forwarding spec",
+			objects: []runtime.Object{
+				existingForwarder.DeepCopy(),
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: ForwarderName, Namespace: namespace}},
+				&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: ForwarderName}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{NamespacePrefix: "redhat-rhoam-", LogForwarding: tt.logForwarding},
+			}
+
+			if err := ReconcileLogForwarding(context.TODO(), serverClient, installation); err != nil {
+				t.Fatal(err)
+			}
+
+			forwarder := &unstructured.Unstructured{}
+			forwarder.SetGroupVersionKind(ClusterLogForwarderGVK)
+			forwarderErr := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: ForwarderName, Namespace: namespace}, forwarder)
+			bindingErr := serverClient.Get(context.TODO(), k8sclient.ObjectKey{Name: ForwarderName}, &rbacv1.ClusterRoleBinding{})
+			if tt.wantForwarder {
+				if forwarderErr != nil || bindingErr != nil {
+					t.Fatalf("expected the forwarder and its collector binding, got %v and %v", forwarderErr, bindingErr)
+				}
+				serviceAccount, _, _ := unstructured.NestedString(forwarder.Object, "spec", "serviceAccountName")
+				if serviceAccount != ForwarderName {
+					t.Errorf("expected the forwarder to use the collector service account, got %q", serviceAccount)
+				}
+				return
+			}
+			if !k8serr.IsNotFound(forwarderErr) || !k8serr.IsNotFound(bindingErr) {
+				t.Errorf("expected the forwarder and its collector binding to be removed, got %v and %v", forwarderErr, bindingErr)
+			}
+		})
+	}
+}
+
+func TestForwarderSpec(t *testing.T) {
+	spec := ForwarderSpec("redhat-rhoam-", &v1alpha1.LogForwardingSpec{
+		Outputs: []v1alpha1.LogOutputSpec{
+			{Name: "cloudwatch", Type: v1alpha1.LogOutputCloudWatch, Region: "eu-west-1", GroupPrefix: "rhoam", Secret: "cloudwatch-credentials"},
+			{Name: "splunk", Type: v1alpha1.LogOutputSplunk, URL: "https://splunk.example.com:8088", Secret: "splunk-hec"},
+		},
+		Sources: []v1alpha1.LogSourceSpec{
+			{Source: v1alpha1.LogSourceKeycloakAudit, Outputs: []string{"splunk"}},
+			{Source: v1alpha1.LogSourceRateLimitDecisions, Outputs: []string{"cloudwatch", "splunk"}},
+		},
+	})
+	forwarder := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+
+	pipelines, _, _ := unstructured.NestedSlice(forwarder.Object, "spec", "pipelines")
+	if len(pipelines) != 2 {
+		t.Fatalf("expected a pipeline per source, got %v", pipelines)
+	}
+	if name := pipelines[1].(map[string]interface{})["name"]; name != "rate-limit-decisions" {
+		t.Errorf("expected the pipeline to be named after the source, got %v", name)
+	}
+
+	inputs, _, _ := unstructured.NestedSlice(forwarder.Object, "spec", "inputs")
+	namespaces, _, _ := unstructured.NestedStringSlice(inputs[0].(map[string]interface{}), "application", "namespaces")
+	if len(namespaces) != 2 || namespaces[0] != "redhat-rhoam-rhsso" || namespaces[1] != "redhat-rhoam-user-sso" {
+		t.Errorf("expected the keycloak audit logs of both SSO namespaces, got %v", namespaces)
+	}
+
+	outputs, _, _ := unstructured.NestedSlice(forwarder.Object, "spec", "outputs")
+	region, _, _ := unstructured.NestedString(outputs[0].(map[string]interface{}), "cloudwatch", "region")
+	if region != "eu-west-1" {
+		t.Errorf("expected the cloudwatch region to be set, got %q", region)
+	}
+	secret, _, _ := unstructured.NestedString(outputs[1].(map[string]interface{}), "secret", "name")
+	if secret != "splunk-hec" {
+		t.Errorf("expected the splunk secret to be set, got %q", secret)
+	}
+}
@@ -0,0 +1,239 @@
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
+	prometheusApi "github.com/prometheus/client_golang/api"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ConfigMapName is the config map in the installation namespace the
+	// usage reports are kept in, one key per day
+	ConfigMapName = "rhoam-usage-reports"
+	// ExportKeyPrefix is the prefix of the usage reports in the export bucket
+	ExportKeyPrefix = "usage-reports"
+
+	// MaxReports keeps the reports of the last month in the config map, the
+	// oldest reports are dropped first
+	MaxReports = 31
+
+	Period       = 24 * time.Hour
+	reportFormat = "2006-01-02"
+
+	prometheusServiceName = "rhoam-prometheus"
+	// tenantClientPrefix is the prefix of the SSO clients of the tenants
+	tenantClientPrefix = "rhoam-mt-"
+)
+
+var log = l.NewLoggerWithContext(l.Fields{l.ComponentLogContext: "metering"})
+
+// Report is the usage of the tenants over a period
+type Report struct {
+	Start   time.Time     `json:"start"`
+	End     time.Time     `json:"end"`
+	Tenants []TenantUsage `json:"tenants"`
+}
+
+// TenantUsage is the usage of a tenant. APICalls are the calls to the
+// gateways of the tenant, RejectedCalls the ones rejected with a 4xx
+// response such as the rate limited ones, and SSOLogins the logins to the
+// 3scale account of the tenant
+type TenantUsage struct {
+	Tenant        string `json:"tenant"`
+	APICalls      int64  `json:"apiCalls"`
+	RejectedCalls int64  `json:"rejectedCalls"`
+	SSOLogins     int64  `json:"ssoLogins"`
+}
+
+// Reconcile reports the usage of the tenants of a multitenant installation
+// over the last complete period, once. The report is queried from the RHOAM
+// Prometheus, kept in the usage reports config map, and exported to the
+// bucket of the metering spec
+func Reconcile(ctx context.Context, client k8sclient.Client, installation *integreatlyv1alpha1.RHMI, now time.Time) error {
+	if !integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(installation.Spec.Type)) {
+		return nil
+	}
+
+	end := now.UTC().Truncate(Period)
+	start := end.Add(-Period)
+	reported, err := isReported(ctx, client, installation.Namespace, start)
+	if err != nil || reported {
+		return err
+	}
+
+	users, err := userHelper.GetMultiTenantUsers(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to get the tenants: %w", err)
+	}
+	var tenants []string
+	for _, user := range users {
+		tenants = append(tenants, user.TenantName)
+	}
+
+	api, err := prometheusAPI(ctx, client, config.GetOboNamespace(installation.Namespace))
+	if err != nil {
+		return err
+	}
+	report, err := Generate(ctx, api, installation.Spec.NamespacePrefix, tenants, start, end)
+	if err != nil {
+		return err
+	}
+
+	// the report is exported first, so a failed export is retried until
+	// the report is recorded
+	if installation.Spec.Metering != nil && installation.Spec.Metering.Export != nil {
+		store, err := objectstore.NewS3ObjectStore(ctx, client, installation.Namespace, installation.Spec.Metering.Export)
+		if err != nil {
+			return err
+		}
+		if err := Export(ctx, store, report); err != nil {
+			return err
+		}
+	}
+	if err := Record(ctx, client, installation.Namespace, report); err != nil {
+		return err
+	}
+	log.Infof("Reported tenant usage", l.Fields{"start": start, "tenants": len(report.Tenants)})
+	return nil
+}
+
+// Generate queries the usage of the tenants over the period. The calls are
+// counted by the router on the routes of the gateways of the tenant, and
+// the logins by the SSO client of the tenant
+func Generate(ctx context.Context, api prometheusv1.API, namespacePrefix string, tenants []string, start, end time.Time) (*Report, error) {
+	window := model.Duration(end.Sub(start)).String()
+	gatewayCalls := `sum by (tenant) (label_replace(increase(haproxy_backend_http_responses_total{exported_namespace="%s3scale", route=~"zync-.+-api-.+"%s}[%s]), "tenant", "$1", "route", "zync-(.+)-api-.+"))`
+	queries := []string{
+		fmt.Sprintf(gatewayCalls, namespacePrefix, "", window),
+		fmt.Sprintf(gatewayCalls, namespacePrefix, `, code="4xx"`, window),
+		fmt.Sprintf(`sum by (tenant) (label_replace(increase(keycloak_logins{namespace="%srhsso", client_id=~"%s.+"}[%s]), "tenant", "$1", "client_id", "%s(.+)"))`, namespacePrefix, tenantClientPrefix, window, tenantClientPrefix),
+	}
+
+	var results []map[string]int64
+	for _, query := range queries {
+		value, _, err := api.Query(ctx, query, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", query, err)
+		}
+		vector, ok := value.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %s of %s", value.Type(), query)
+		}
+		byTenant := map[string]int64{}
+		for _, sample := range vector {
+			byTenant[string(sample.Metric["tenant"])] = int64(float64(sample.Value) + 0.5)
+		}
+		results = append(results, byTenant)
+	}
+
+	sort.Strings(tenants)
+	report := &Report{Start: start.UTC(), End: end.UTC(), Tenants: []TenantUsage{}}
+	for _, tenant := range tenants {
+		report.Tenants = append(report.Tenants, TenantUsage{
+			Tenant:        tenant,
+			APICalls:      results[0][tenant],
+			RejectedCalls: results[1][tenant],
+			SSOLogins:     results[2][tenant],
+		})
+	}
+	return report, nil
+}
+
+// Record keeps the report in the usage reports config map
+func Record(ctx context.Context, client k8sclient.Client, namespace string, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, client, cm, func() error {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[reportKey(report.Start)] = string(data)
+
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for len(keys) > MaxReports {
+			delete(cm.Data, keys[0])
+			keys = keys[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record usage report: %w", err)
+	}
+	return nil
+}
+
+// Export writes the report to the bucket
+func Export(ctx context.Context, store objectstore.ObjectStore, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, fmt.Sprintf("%s/%s", ExportKeyPrefix, reportKey(report.Start)), data)
+}
+
+func isReported(ctx context.Context, client k8sclient.Client, namespace string, start time.Time) (bool, error) {
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: ConfigMapName, Namespace: namespace}, cm); err != nil {
+		return false, k8sclient.IgnoreNotFound(err)
+	}
+	_, ok := cm.Data[reportKey(start)]
+	return ok, nil
+}
+
+func reportKey(start time.Time) string {
+	return start.UTC().Format(reportFormat) + ".json"
+}
+
+// prometheusAPI returns the client of the RHOAM Prometheus
+func prometheusAPI(ctx context.Context, client k8sclient.Client, namespace string) (prometheusv1.API, error) {
+	prometheusService := &corev1.Service{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: prometheusServiceName, Namespace: namespace}, prometheusService); err != nil {
+		return nil, fmt.Errorf("failed to get prometheus service: %w", err)
+	}
+
+	var port int32
+	for _, servicePort := range prometheusService.Spec.Ports {
+		if servicePort.Name == "web" {
+			port = servicePort.Port
+		}
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("failed to find web port of prometheus service")
+	}
+
+	apiClient, err := prometheusApi.NewClient(prometheusApi.Config{
+		Address: fmt.Sprintf("http://%s.%s.svc:%d", prometheusService.Name, prometheusService.Namespace, port),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prometheusv1.NewAPI(apiClient), nil
+}
@@ -0,0 +1,155 @@
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	prometheusv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeQueryResult struct {
+	contains string
+	vector   model.Vector
+}
+
+// fakePrometheus answers a query with the vector of the first result it
+// contains the text of
+type fakePrometheus struct {
+	prometheusv1.API
+	results []fakeQueryResult
+	queries []string
+}
+
+func (f *fakePrometheus) Query(_ context.Context, query string, _ time.Time, _ ...prometheusv1.Option) (model.Value, prometheusv1.Warnings, error) {
+	f.queries = append(f.queries, query)
+	for _, result := range f.results {
+		if strings.Contains(query, result.contains) {
+			return result.vector, nil, nil
+		}
+	}
+	return model.Vector{}, nil, nil
+}
+
+type fakeObjectStore map[string][]byte
+
+func (f fakeObjectStore) Get(_ context.Context, key string) ([]byte, error) {
+	return f[key], nil
+}
+
+func (f fakeObjectStore) Put(_ context.Context, key string, data []byte) error {
+	f[key] = data
+	return nil
+}
+
+func tenantSample(tenant string, value float64) *model.Sample {
+	return &model.Sample{Metric: model.Metric{"tenant": model.LabelValue(tenant)}, Value: model.SampleValue(value)}
+}
+
+func TestGenerate(t *testing.T) {
+	end := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	api := &fakePrometheus{results: []fakeQueryResult{
+		{contains: `code="4xx"`, vector: model.Vector{tenantSample("tenant-a", 12)}},
+		{contains: "haproxy", vector: model.Vector{tenantSample("tenant-a", 1000.4), tenantSample("tenant-b", 20)}},
+		{contains: "keycloak_logins", vector: model.Vector{tenantSample("tenant-b", 3)}},
+	}}
+
+	report, err := Generate(context.TODO(), api, "redhat-rhoam-", []string{"tenant-c", "tenant-b", "tenant-a"}, end.Add(-Period), end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TenantUsage{
+		{Tenant: "tenant-a", APICalls: 1000, RejectedCalls: 12},
+		{Tenant: "tenant-b", APICalls: 20, SSOLogins: 3},
+		{Tenant: "tenant-c"},
+	}
+	if fmt.Sprint(report.Tenants) != fmt.Sprint(want) {
+		t.Errorf("expected the usage %v, got %v", want, report.Tenants)
+	}
+	if !report.Start.Equal(end.Add(-Period)) || !report.End.Equal(end) {
+		t.Errorf("expected the report of the day before %s, got %s - %s", end, report.Start, report.End)
+	}
+	for _, query := range api.queries {
+		if !strings.Contains(query, "[1d]") || !strings.Contains(query, `namespace="redhat-rhoam-`) {
+			t.Errorf("expected the query to cover the period in the namespaces of the installation, got %s", query)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: "redhat-rhoam-operator"},
+		Data:       map[string]string{},
+	}
+	start := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= MaxReports; i++ {
+		existing.Data[reportKey(start.AddDate(0, 0, -i))] = "{}"
+	}
+	client := utils.NewTestClient(scheme, existing)
+
+	report := &Report{Start: start, End: start.Add(Period), Tenants: []TenantUsage{{Tenant: "tenant-a", APICalls: 5}}}
+	if err := Record(context.TODO(), client, "redhat-rhoam-operator", report); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(context.TODO(), k8sclient.ObjectKeyFromObject(existing), cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Data) != MaxReports {
+		t.Errorf("expected %d reports, got %d", MaxReports, len(cm.Data))
+	}
+	if _, ok := cm.Data[reportKey(start.AddDate(0, 0, -MaxReports))]; ok {
+		t.Error("expected the oldest report to be dropped")
+	}
+	recorded := &Report{}
+	if err := json.Unmarshal([]byte(cm.Data["2024-03-05.json"]), recorded); err != nil || len(recorded.Tenants) != 1 || recorded.Tenants[0].APICalls != 5 {
+		t.Errorf("expected the report to be recorded, got %v, %v", cm.Data["2024-03-05.json"], err)
+	}
+
+	reported, err := isReported(context.TODO(), client, "redhat-rhoam-operator", start)
+	if err != nil || !reported {
+		t.Errorf("expected the period to be reported, got %v, %v", reported, err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	store := fakeObjectStore{}
+	start := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	if err := Export(context.TODO(), store, &Report{Start: start, End: start.Add(Period)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["usage-reports/2024-03-05.json"]; !ok {
+		t.Errorf("expected the report to be exported, got %v", store)
+	}
+}
+
+func TestReconcile_SingleTenant(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+		Spec:       integreatlyv1alpha1.RHMISpec{Type: string(integreatlyv1alpha1.InstallationTypeManagedApi)},
+	}
+	// the prometheus service is missing, so any attempt to report fails
+	if err := Reconcile(context.TODO(), utils.NewTestClient(scheme), installation, time.Now()); err != nil {
+		t.Errorf("expected single tenant installations not to be metered, got %v", err)
+	}
+}
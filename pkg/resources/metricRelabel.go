@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"regexp"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+const (
+	// ProductMetricLabel is added to every series scraped from a product so
+	// dashboards and alerts can select metrics by product
+	ProductMetricLabel = "rhoam_product"
+	// TenantMetricLabel is added, in multitenant installations, to every
+	// series that references a tenant namespace
+	TenantMetricLabel = "tenant"
+	// OperatorMetricProduct is the ProductMetricLabel of the series scraped
+	// from the RHOAM operator itself
+	OperatorMetricProduct integreatlyv1alpha1.ProductName = "rhoam-operator"
+
+	// tenantNamespaceLabel is the label that holds the namespace a series
+	// refers to when it's scraped from a product namespace
+	tenantNamespaceLabel = "exported_namespace"
+)
+
+// tenantNamespaceRegex matches the dev and stage namespaces of a tenant,
+// capturing the tenant username
+var tenantNamespaceRegex = regexp.MustCompile(`^(.+)-(dev|stage)$`)
+
+// ProductRelabelConfigs returns the scrape time relabeling that sets the
+// ProductMetricLabel of every target scraped from productName
+func ProductRelabelConfigs(productName integreatlyv1alpha1.ProductName) []*prometheus.RelabelConfig {
+	return []*prometheus.RelabelConfig{
+		{
+			Action:      "replace",
+			TargetLabel: ProductMetricLabel,
+			Replacement: string(productName),
+		},
+	}
+}
+
+// TenantMetricRelabelConfigs returns the metric relabeling that sets the
+// TenantMetricLabel of series that reference a tenant namespace. Nothing is
+// returned for installations that aren't multitenant
+func TenantMetricRelabelConfigs(installationType string) []*prometheus.RelabelConfig {
+	if !integreatlyv1alpha1.IsRHOAMMultitenant(integreatlyv1alpha1.InstallationType(installationType)) {
+		return nil
+	}
+
+	return []*prometheus.RelabelConfig{
+		{
+			Action:       "replace",
+			SourceLabels: []prometheus.LabelName{tenantNamespaceLabel},
+			Regex:        tenantNamespaceRegex.String(),
+			TargetLabel:  TenantMetricLabel,
+			Replacement:  "$1",
+		},
+	}
+}
+
+// SetMetricRelabelConfigs sets the product and tenant relabeling on every
+// endpoint of a ServiceMonitor, replacing any relabeling previously added for
+// the same target labels
+func SetMetricRelabelConfigs(endpoints []prometheus.Endpoint, productName integreatlyv1alpha1.ProductName, installationType string) {
+	for i := range endpoints {
+		endpoints[i].RelabelConfigs = mergeRelabelConfigs(endpoints[i].RelabelConfigs, ProductRelabelConfigs(productName))
+		endpoints[i].MetricRelabelConfigs = mergeRelabelConfigs(endpoints[i].MetricRelabelConfigs, TenantMetricRelabelConfigs(installationType))
+	}
+}
+
+// SetPodMetricRelabelConfigs sets the product and tenant relabeling on every
+// endpoint of a PodMonitor, like SetMetricRelabelConfigs
+func SetPodMetricRelabelConfigs(endpoints []prometheus.PodMetricsEndpoint, productName integreatlyv1alpha1.ProductName, installationType string) {
+	for i := range endpoints {
+		endpoints[i].RelabelConfigs = mergeRelabelConfigs(endpoints[i].RelabelConfigs, ProductRelabelConfigs(productName))
+		endpoints[i].MetricRelabelConfigs = mergeRelabelConfigs(endpoints[i].MetricRelabelConfigs, TenantMetricRelabelConfigs(installationType))
+	}
+}
+
+func mergeRelabelConfigs(existing, managed []*prometheus.RelabelConfig) []*prometheus.RelabelConfig {
+	merged := []*prometheus.RelabelConfig{}
+	for _, config := range existing {
+		if config.TargetLabel == ProductMetricLabel || config.TargetLabel == TenantMetricLabel {
+			continue
+		}
+		merged = append(merged, config)
+	}
+	merged = append(merged, managed...)
+
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
@@ -0,0 +1,118 @@
+package resources
+
+import (
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	prometheus "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+)
+
+func TestSetMetricRelabelConfigs(t *testing.T) {
+	scenarios := []struct {
+		Name                       string
+		InstallationType           string
+		Endpoints                  []prometheus.Endpoint
+		ExpectedRelabelings        int
+		ExpectedMetricRelabelings  int
+		ExpectedTenantRelabelingOn bool
+	}{
+		{
+			Name:                "test product label is added for single tenant installations",
+			InstallationType:    string(integreatlyv1alpha1.InstallationTypeManagedApi),
+			Endpoints:           []prometheus.Endpoint{{Port: "metrics"}},
+			ExpectedRelabelings: 1,
+		},
+		{
+			Name:                       "test tenant label is added for multitenant installations",
+			InstallationType:           string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+			Endpoints:                  []prometheus.Endpoint{{Port: "metrics"}},
+			ExpectedRelabelings:        1,
+			ExpectedMetricRelabelings:  1,
+			ExpectedTenantRelabelingOn: true,
+		},
+		{
+			Name:             "test existing relabelings are kept and managed relabelings are not duplicated",
+			InstallationType: string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi),
+			Endpoints: []prometheus.Endpoint{
+				{
+					Port: "metrics",
+					RelabelConfigs: []*prometheus.RelabelConfig{
+						{Action: "drop", SourceLabels: []prometheus.LabelName{"__name__"}, Regex: "go_.*"},
+						{Action: "replace", TargetLabel: ProductMetricLabel, Replacement: "stale"},
+					},
+					MetricRelabelConfigs: []*prometheus.RelabelConfig{
+						{Action: "replace", TargetLabel: TenantMetricLabel, Replacement: "stale"},
+					},
+				},
+			},
+			ExpectedRelabelings:        2,
+			ExpectedMetricRelabelings:  1,
+			ExpectedTenantRelabelingOn: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			SetMetricRelabelConfigs(scenario.Endpoints, integreatlyv1alpha1.Product3Scale, scenario.InstallationType)
+
+			endpoint := scenario.Endpoints[0]
+			if len(endpoint.RelabelConfigs) != scenario.ExpectedRelabelings {
+				t.Fatalf("expected %d relabelings, got %d", scenario.ExpectedRelabelings, len(endpoint.RelabelConfigs))
+			}
+			product := endpoint.RelabelConfigs[len(endpoint.RelabelConfigs)-1]
+			if product.TargetLabel != ProductMetricLabel || product.Replacement != string(integreatlyv1alpha1.Product3Scale) {
+				t.Fatalf("unexpected product relabeling %+v", product)
+			}
+
+			if len(endpoint.MetricRelabelConfigs) != scenario.ExpectedMetricRelabelings {
+				t.Fatalf("expected %d metric relabelings, got %d", scenario.ExpectedMetricRelabelings, len(endpoint.MetricRelabelConfigs))
+			}
+			if scenario.ExpectedTenantRelabelingOn {
+				tenant := endpoint.MetricRelabelConfigs[0]
+				if tenant.TargetLabel != TenantMetricLabel || tenant.Replacement != "$1" {
+					t.Fatalf("unexpected tenant relabeling %+v", tenant)
+				}
+			}
+		})
+	}
+}
+
+func TestSetPodMetricRelabelConfigs(t *testing.T) {
+	endpoints := []prometheus.PodMetricsEndpoint{
+		{
+			Port: "metrics",
+			RelabelConfigs: []*prometheus.RelabelConfig{
+				{Action: "replace", TargetLabel: ProductMetricLabel, Replacement: "stale"},
+			},
+		},
+	}
+
+	SetPodMetricRelabelConfigs(endpoints, integreatlyv1alpha1.ProductCloudResources, string(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi))
+
+	if len(endpoints[0].RelabelConfigs) != 1 || endpoints[0].RelabelConfigs[0].Replacement != string(integreatlyv1alpha1.ProductCloudResources) {
+		t.Fatalf("unexpected relabelings %+v", endpoints[0].RelabelConfigs)
+	}
+	if len(endpoints[0].MetricRelabelConfigs) != 1 || endpoints[0].MetricRelabelConfigs[0].TargetLabel != TenantMetricLabel {
+		t.Fatalf("unexpected metric relabelings %+v", endpoints[0].MetricRelabelConfigs)
+	}
+}
+
+func TestTenantNamespaceRegex(t *testing.T) {
+	scenarios := map[string]string{
+		"alice-dev":           "alice",
+		"alice-stage":         "alice",
+		"team-a-dev":          "team-a",
+		"redhat-rhoam-3scale": "",
+	}
+
+	for namespace, expected := range scenarios {
+		matches := tenantNamespaceRegex.FindStringSubmatch(namespace)
+		got := ""
+		if matches != nil {
+			got = matches[1]
+		}
+		if got != expected {
+			t.Fatalf("expected tenant %q for namespace %s, got %q", expected, namespace, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespaceTerminationGracePeriod is the time a namespace is allowed to be
+// Terminating before it is considered stuck
+const NamespaceTerminationGracePeriod = 10 * time.Minute
+
+// SafeFinalizerCleanupKinds are the product custom resources owned by RHOAM
+// whose finalizers only clean up in-cluster state managed by the product
+// operators. Once the product namespace is Terminating the operators are gone
+// and these finalizers can never be handled, so they are safe to remove.
+// Resources that manage external state, such as the cloud resources created by
+// CRO, must never be added here as removing their finalizers leaks the
+// external resources
+var SafeFinalizerCleanupKinds = []schema.GroupVersionKind{
+	{Group: "keycloak.org", Version: "v1alpha1", Kind: "Keycloak"},
+	{Group: "keycloak.org", Version: "v1alpha1", Kind: "KeycloakRealm"},
+	{Group: "keycloak.org", Version: "v1alpha1", Kind: "KeycloakClient"},
+	{Group: "keycloak.org", Version: "v1alpha1", Kind: "KeycloakUser"},
+	{Group: "keycloak.org", Version: "v1alpha1", Kind: "KeycloakBackup"},
+	{Group: "apps.3scale.net", Version: "v1alpha1", Kind: "APIManager"},
+	{Group: "marin3r.3scale.net", Version: "v1alpha1", Kind: "EnvoyConfig"},
+	{Group: "marin3r.3scale.net", Version: "v1alpha1", Kind: "EnvoyConfigRevision"},
+	{Group: "operator.marin3r.3scale.net", Version: "v1alpha1", Kind: "DiscoveryService"},
+	{Group: "operator.marin3r.3scale.net", Version: "v1alpha1", Kind: "DiscoveryServiceCertificate"},
+}
+
+// IsNamespaceStuckTerminating checks if the namespace has been Terminating for
+// longer than the NamespaceTerminationGracePeriod
+func IsNamespaceStuckTerminating(ns *corev1.Namespace, now time.Time) bool {
+	if ns.Status.Phase != corev1.NamespaceTerminating || ns.DeletionTimestamp == nil {
+		return false
+	}
+	return now.Sub(ns.DeletionTimestamp.Time) > NamespaceTerminationGracePeriod
+}
+
+// RemediateStuckNamespaces removes the orphaned finalizers of the known product
+// resources left in the namespaces owned by the installation that are stuck
+// Terminating. It returns a description of the namespaces that are still
+// blocked by resources that can't be safely cleaned up
+func RemediateStuckNamespaces(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI, log l.Logger) ([]string, error) {
+	nsList := &corev1.NamespaceList{}
+	if err := client.List(ctx, nsList, k8sclient.MatchingLabels{OwnerLabelKey: string(inst.GetUID())}); err != nil {
+		return nil, fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+
+	var blocked []string
+	now := time.Now()
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+		if !IsNamespaceStuckTerminating(ns, now) {
+			continue
+		}
+
+		log.Warningf("Namespace stuck terminating", l.Fields{"ns": ns.Name, "deletionTimestamp": ns.DeletionTimestamp})
+		removed, err := removeOrphanedFinalizers(ctx, client, ns.Name, log)
+		if err != nil {
+			return nil, err
+		}
+
+		// Give the namespace controller a chance to finish the deletion
+		// before reporting the namespace as blocked
+		if removed > 0 {
+			continue
+		}
+
+		blocked = append(blocked, fmt.Sprintf("%s: %s", ns.Name, getNamespaceBlockingResources(ns)))
+	}
+
+	return blocked, nil
+}
+
+func removeOrphanedFinalizers(ctx context.Context, client k8sclient.Client, namespace string, log l.Logger) (int, error) {
+	removed := 0
+	for _, gvk := range SafeFinalizerCleanupKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := client.List(ctx, list, k8sclient.InNamespace(namespace)); err != nil {
+			// The CRD is removed with the product, nothing left to clean up
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return removed, fmt.Errorf("failed to list %s in namespace %s: %w", gvk.Kind, namespace, err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() == nil || len(obj.GetFinalizers()) == 0 {
+				continue
+			}
+
+			log.Infof("Removing orphaned finalizers", l.Fields{"kind": gvk.Kind, "name": obj.GetName(), "ns": namespace, "finalizers": obj.GetFinalizers()})
+			patch := k8sclient.MergeFrom(obj.DeepCopy())
+			obj.SetFinalizers(nil)
+			if err := client.Patch(ctx, obj, patch); err != nil {
+				return removed, fmt.Errorf("failed to remove finalizers from %s %s/%s: %w", gvk.Kind, namespace, obj.GetName(), err)
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// getNamespaceBlockingResources returns the resources reported by the
+// namespace controller as preventing the namespace deletion
+func getNamespaceBlockingResources(ns *corev1.Namespace) string {
+	var messages []string
+	for _, condition := range ns.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case corev1.NamespaceFinalizersRemaining, corev1.NamespaceContentRemaining:
+			messages = append(messages, condition.Message)
+		}
+	}
+
+	if len(messages) == 0 {
+		return "unknown resources remaining"
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,136 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRemediateStuckNamespaces(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rhoam",
+			Namespace: "redhat-rhoam-operator",
+			UID:       "installation-uid",
+		},
+	}
+	stuckSince := metav1.NewTime(time.Now().Add(-2 * NamespaceTerminationGracePeriod))
+	recentlyDeleted := metav1.NewTime(time.Now())
+
+	terminatingNamespace := func(name string, deletionTimestamp *metav1.Time) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{OwnerLabelKey: string(installation.UID)},
+				DeletionTimestamp: deletionTimestamp,
+				Finalizers:        []string{"kubernetes"},
+			},
+			Status: corev1.NamespaceStatus{
+				Phase: corev1.NamespaceTerminating,
+				Conditions: []corev1.NamespaceCondition{
+					{
+						Type:    corev1.NamespaceFinalizersRemaining,
+						Status:  corev1.ConditionTrue,
+						Message: "Some content in the namespace has finalizers remaining: example.com/finalizer in 1 resource instances",
+					},
+				},
+			},
+		}
+	}
+
+	orphanedRealm := &keycloak.KeycloakRealm{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rhoam-realm",
+			Namespace:         "redhat-rhoam-rhsso",
+			DeletionTimestamp: &stuckSince,
+			Finalizers:        []string{"realm.cleanup"},
+		},
+	}
+
+	scenarios := []struct {
+		Name            string
+		InitObjs        []runtime.Object
+		ExpectedBlocked []string
+		Assert          func(k8sclient.Client) error
+	}{
+		{
+			Name:     "test namespace terminating within grace period is ignored",
+			InitObjs: []runtime.Object{terminatingNamespace("redhat-rhoam-rhsso", &recentlyDeleted), orphanedRealm.DeepCopy()},
+			Assert: func(c k8sclient.Client) error {
+				realm := &keycloak.KeycloakRealm{}
+				if err := c.Get(context.TODO(), k8sclient.ObjectKeyFromObject(orphanedRealm), realm); err != nil {
+					return err
+				}
+				if len(realm.Finalizers) == 0 {
+					return errors.New("expected finalizers to be kept")
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "test orphaned finalizers are removed from known product resources",
+			InitObjs: []runtime.Object{terminatingNamespace("redhat-rhoam-rhsso", &stuckSince), orphanedRealm.DeepCopy()},
+			Assert: func(c k8sclient.Client) error {
+				realm := &keycloak.KeycloakRealm{}
+				err := c.Get(context.TODO(), k8sclient.ObjectKeyFromObject(orphanedRealm), realm)
+				if k8serr.IsNotFound(err) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if len(realm.Finalizers) != 0 {
+					return fmt.Errorf("expected finalizers to be removed, got %v", realm.Finalizers)
+				}
+				return nil
+			},
+		},
+		{
+			Name:            "test namespace blocked by unknown resources is reported",
+			InitObjs:        []runtime.Object{terminatingNamespace("redhat-rhoam-3scale", &stuckSince)},
+			ExpectedBlocked: []string{"redhat-rhoam-3scale: Some content in the namespace has finalizers remaining"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, scenario.InitObjs...)
+
+			blocked, err := RemediateStuckNamespaces(context.TODO(), client, installation, l.NewLogger())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(blocked) != len(scenario.ExpectedBlocked) {
+				t.Fatalf("expected blocked namespaces %v, got %v", scenario.ExpectedBlocked, blocked)
+			}
+			for i := range blocked {
+				if !strings.HasPrefix(blocked[i], scenario.ExpectedBlocked[i]) {
+					t.Fatalf("expected blocked namespaces %v, got %v", scenario.ExpectedBlocked, blocked)
+				}
+			}
+			if scenario.Assert != nil {
+				if err := scenario.Assert(client); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package networkpolicy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/config"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// ManagedLabel marks the policies of the installation, the ones not
+	// wanted anymore are removed
+	ManagedLabel = "integreatly.org/network-policy"
+
+	DefaultDenyName = "rhoam-default-deny"
+
+	// the labels OpenShift sets on the namespaces of the ingress
+	// controller, the cluster monitoring and the host network pods, such as
+	// the API server calling the webhooks
+	policyGroupLabel      = "network.openshift.io/policy-group"
+	hostNetworkGroupLabel = "policy-group.network.openshift.io/host-network"
+	namespaceNameLabel    = "kubernetes.io/metadata.name"
+
+	// the namespaces of the products, without the namespace prefix
+	threescaleNamespace    = "3scale"
+	marin3rNamespace       = "marin3r"
+	rhssoNamespace         = "rhsso"
+	userSSONamespace       = "user-sso"
+	mcgNamespace           = "mcg"
+	grafanaNamespace       = "customer-monitoring-operator"
+	observabilityNamespace = "observability"
+
+	// the cluster and user workload monitoring scraping the products
+	clusterMonitoringNamespace      = "openshift-monitoring"
+	userWorkloadMonitoringNamespace = "openshift-user-workload-monitoring"
+)
+
+// Level returns the strictness of the policies of the installation, or ""
+// when the namespaces aren't isolated
+func Level(installation *integreatlyv1alpha1.RHMI) integreatlyv1alpha1.NetworkPolicyLevel {
+	if installation.Spec.NetworkPolicy == nil {
+		return ""
+	}
+	if installation.Spec.NetworkPolicy.Level == "" {
+		return integreatlyv1alpha1.NetworkPolicyStrict
+	}
+	return installation.Spec.NetworkPolicy.Level
+}
+
+// ReconcileNetworkPolicies isolates the namespaces of the products of the
+// installation with a default deny policy and the policies allowing the
+// traffic the products need. The policies are removed when the network
+// policy isn't in the spec anymore. The installation namespace is left open,
+// it runs the webhooks and the metrics of the operator. The namespaces
+// created later by the products are isolated on the next reconcile
+func ReconcileNetworkPolicies(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI) error {
+	namespaces := &corev1.NamespaceList{}
+	if err := serverClient.List(ctx, namespaces, k8sclient.MatchingLabels{resources.OwnerLabelKey: string(installation.GetUID())}); err != nil {
+		return fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+
+	for _, namespace := range namespaces.Items {
+		if namespace.Name == installation.Namespace {
+			continue
+		}
+		if err := reconcileNamespace(ctx, serverClient, installation, namespace.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reconcileNamespace(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, namespace string) error {
+	wanted := map[string]bool{}
+	for _, desired := range Policies(installation, namespace) {
+		wanted[desired.Name] = true
+		policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: desired.Name, Namespace: namespace}}
+		if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, policy, func() error {
+			resources.PrepareObjectLabels(policy, installation, false, false, false)
+			policy.Labels[ManagedLabel] = "true"
+			policy.Spec = desired.Spec
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to reconcile network policy %s in %s: %w", desired.Name, namespace, err)
+		}
+	}
+
+	policies := &networkingv1.NetworkPolicyList{}
+	if err := serverClient.List(ctx, policies, k8sclient.InNamespace(namespace), k8sclient.MatchingLabels{ManagedLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list network policies in %s: %w", namespace, err)
+	}
+	for i := range policies.Items {
+		if wanted[policies.Items[i].Name] {
+			continue
+		}
+		if err := serverClient.Delete(ctx, &policies.Items[i]); err != nil && !k8serr.IsNotFound(err) {
+			return fmt.Errorf("failed to delete network policy %s in %s: %w", policies.Items[i].Name, namespace, err)
+		}
+	}
+	return nil
+}
+
+// Policies returns the policies of a namespace of the installation. Every
+// namespace denies the ingress traffic except from its own pods, the host
+// network, the operator and the monitoring stacks. The Permissive level
+// allows the traffic from every namespace of the installation, the Strict
+// level only from the products the namespace serves
+func Policies(installation *integreatlyv1alpha1.RHMI, namespace string) []*networkingv1.NetworkPolicy {
+	level := Level(installation)
+	if level == "" {
+		return nil
+	}
+
+	policies := []*networkingv1.NetworkPolicy{
+		policy(DefaultDenyName, namespace),
+		policy("rhoam-allow-same-namespace", namespace, networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}),
+		policy("rhoam-allow-host-network", namespace, namespaceLabelPeer(hostNetworkGroupLabel, "")),
+		policy("rhoam-allow-operator", namespace, namespacePeers(installation.Namespace)...),
+		policy("rhoam-allow-monitoring", namespace, append(
+			[]networkingv1.NetworkPolicyPeer{namespaceLabelPeer(policyGroupLabel, "monitoring")},
+			namespacePeers(monitoringNamespaces(installation)...)...)...),
+	}
+
+	if level == integreatlyv1alpha1.NetworkPolicyPermissive || namespace == installation.ProductNamespace(observabilityNamespace) {
+		policies = append(policies, policy("rhoam-allow-installation", namespace, namespaceLabelPeer(resources.OwnerLabelKey, string(installation.GetUID()))))
+	}
+
+	if level == integreatlyv1alpha1.NetworkPolicyStrict {
+		if routedNamespaces(installation)[namespace] {
+			policies = append(policies, policy("rhoam-allow-ingress", namespace, namespaceLabelPeer(policyGroupLabel, "ingress")))
+		}
+		if peers := peerNamespaces(installation)[namespace]; len(peers) > 0 {
+			policies = append(policies, policy("rhoam-allow-products", namespace, namespacePeers(peers...)...))
+		}
+	}
+
+	if allowed := installation.Spec.NetworkPolicy.AllowedNamespaces; len(allowed) > 0 {
+		policies = append(policies, policy("rhoam-allow-namespaces", namespace, namespacePeers(allowed...)...))
+	}
+	return policies
+}
+
+// routedNamespaces are the namespaces of the products exposed by routes
+func routedNamespaces(installation *integreatlyv1alpha1.RHMI) map[string]bool {
+	return map[string]bool{
+		installation.ProductNamespace(threescaleNamespace):    true,
+		installation.ProductNamespace(rhssoNamespace):         true,
+		installation.ProductNamespace(userSSONamespace):       true,
+		installation.ProductNamespace(grafanaNamespace):       true,
+		installation.ProductNamespace(observabilityNamespace): true,
+	}
+}
+
+// monitoringNamespaces are the namespaces of the monitoring stacks scraping
+// the products, the observability namespace of the installation and the
+// namespace of its observability operator stack
+func monitoringNamespaces(installation *integreatlyv1alpha1.RHMI) []string {
+	return []string{
+		installation.ProductNamespace(observabilityNamespace),
+		config.GetOboNamespace(installation.Namespace),
+		clusterMonitoringNamespace,
+		userWorkloadMonitoringNamespace,
+	}
+}
+
+// peerNamespaces are the namespaces of the products calling the services of
+// each namespace. Each product is called by its operator, such as the
+// keycloak operator calling the keycloak service. 3scale calls the rate
+// limiting of marin3r, which discovers the gateways of 3scale, and stores
+// its objects in mcg
+func peerNamespaces(installation *integreatlyv1alpha1.RHMI) map[string][]string {
+	threescale := installation.ProductNamespace(threescaleNamespace)
+	marin3r := installation.ProductNamespace(marin3rNamespace)
+	mcg := installation.ProductNamespace(mcgNamespace)
+	peers := map[string][]string{
+		threescale:                          {marin3r},
+		marin3r:                             {threescale},
+		mcg:                                 {threescale},
+		installation.OperatorNamespace(mcg): {threescale},
+	}
+
+	for _, product := range []string{threescaleNamespace, marin3rNamespace, rhssoNamespace, userSSONamespace, mcgNamespace, observabilityNamespace} {
+		namespace := installation.ProductNamespace(product)
+		if operatorNamespace := installation.OperatorNamespace(namespace); operatorNamespace != namespace {
+			peers[namespace] = append(peers[namespace], operatorNamespace)
+		}
+	}
+	return peers
+}
+
+// policy denies the ingress traffic to the pods of the namespace except from
+// the peers
+func policy(name, namespace string, peers ...networkingv1.NetworkPolicyPeer) *networkingv1.NetworkPolicy {
+	np := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	if len(peers) > 0 {
+		np.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+	}
+	return np
+}
+
+func namespaceLabelPeer(key, value string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{key: value}},
+	}
+}
+
+func namespacePeers(namespaces ...string) []networkingv1.NetworkPolicyPeer {
+	sorted := append([]string{}, namespaces...)
+	sort.Strings(sorted)
+	var peers []networkingv1.NetworkPolicyPeer
+	for i, namespace := range sorted {
+		if i > 0 && namespace == sorted[i-1] {
+			continue
+		}
+		peers = append(peers, namespaceLabelPeer(namespaceNameLabel, namespace))
+	}
+	return peers
+}
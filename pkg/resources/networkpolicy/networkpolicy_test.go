@@ -0,0 +1,257 @@
+package networkpolicy
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	"github.com/integr8ly/integreatly-operator/utils"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	operatorNamespace = "redhat-rhoam-operator"
+	installationUID   = "installation-uid"
+)
+
+func testInstallation(networkPolicy *v1alpha1.NetworkPolicySpec) *v1alpha1.RHMI {
+	return &v1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: operatorNamespace, UID: types.UID(installationUID)},
+		Spec:       v1alpha1.RHMISpec{NamespacePrefix: "redhat-rhoam-", NetworkPolicy: networkPolicy},
+	}
+}
+
+func installationNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{resources.OwnerLabelKey: installationUID},
+	}}
+}
+
+func policyNames(policies []*networkingv1.NetworkPolicy) []string {
+	var names []string
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPolicies(t *testing.T) {
+	common := []string{DefaultDenyName, "rhoam-allow-host-network", "rhoam-allow-monitoring", "rhoam-allow-operator", "rhoam-allow-same-namespace"}
+	with := func(names ...string) []string {
+		all := append(append([]string{}, common...), names...)
+		sort.Strings(all)
+		return all
+	}
+
+	tests := []struct {
+		name          string
+		networkPolicy *v1alpha1.NetworkPolicySpec
+		namespace     string
+		want          []string
+	}{
+		{
+			name:      "no policies without network policy spec",
+			namespace: "redhat-rhoam-3scale",
+		},
+		{
+			name:          "strict by default",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{},
+			namespace:     "redhat-rhoam-3scale",
+			want:          with("rhoam-allow-ingress", "rhoam-allow-products"),
+		},
+		{
+			name:          "strict sso is reached by the ingress controller and its operator",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyStrict},
+			namespace:     "redhat-rhoam-rhsso",
+			want:          with("rhoam-allow-ingress", "rhoam-allow-products"),
+		},
+		{
+			name:          "strict marin3r is reached by 3scale",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyStrict},
+			namespace:     "redhat-rhoam-marin3r",
+			want:          with("rhoam-allow-products"),
+		},
+		{
+			name:          "strict observability is reached by the products",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyStrict},
+			namespace:     "redhat-rhoam-observability",
+			want:          with("rhoam-allow-ingress", "rhoam-allow-installation", "rhoam-allow-products"),
+		},
+		{
+			name:          "strict operator namespace only allows the common traffic",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyStrict},
+			namespace:     "redhat-rhoam-rhsso-operator",
+			want:          with(),
+		},
+		{
+			name:          "permissive allows the namespaces of the installation",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyPermissive},
+			namespace:     "redhat-rhoam-marin3r",
+			want:          with("rhoam-allow-installation"),
+		},
+		{
+			name:          "allowed namespaces",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyPermissive, AllowedNamespaces: []string{"customer-monitoring"}},
+			namespace:     "redhat-rhoam-marin3r",
+			want:          with("rhoam-allow-installation", "rhoam-allow-namespaces"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policyNames(Policies(testInstallation(tt.networkPolicy), tt.namespace))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Policies() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Policies() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// peerNamespaceNames returns the namespaces allowed by the policy of the name
+func peerNamespaceNames(policies []*networkingv1.NetworkPolicy, name string) []string {
+	var namespaces []string
+	for _, policy := range policies {
+		if policy.Name != name {
+			continue
+		}
+		for _, peer := range policy.Spec.Ingress[0].From {
+			if namespace := peer.NamespaceSelector.MatchLabels[namespaceNameLabel]; namespace != "" {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func TestPolicies_peers(t *testing.T) {
+	installation := testInstallation(&v1alpha1.NetworkPolicySpec{})
+
+	tests := []struct {
+		namespace string
+		policy    string
+		want      []string
+	}{
+		{
+			namespace: "redhat-rhoam-marin3r",
+			policy:    "rhoam-allow-products",
+			want:      []string{"redhat-rhoam-3scale", "redhat-rhoam-marin3r-operator"},
+		},
+		{
+			namespace: "redhat-rhoam-rhsso",
+			policy:    "rhoam-allow-products",
+			want:      []string{"redhat-rhoam-rhsso-operator"},
+		},
+		{
+			namespace: "redhat-rhoam-user-sso",
+			policy:    "rhoam-allow-products",
+			want:      []string{"redhat-rhoam-user-sso-operator"},
+		},
+		{
+			namespace: "redhat-rhoam-3scale",
+			policy:    "rhoam-allow-products",
+			want:      []string{"redhat-rhoam-3scale-operator", "redhat-rhoam-marin3r"},
+		},
+		{
+			namespace: "redhat-rhoam-3scale",
+			policy:    "rhoam-allow-monitoring",
+			want:      []string{"openshift-monitoring", "openshift-user-workload-monitoring", "redhat-rhoam-observability", "redhat-rhoam-operator-observability"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace+" "+tt.policy, func(t *testing.T) {
+			got := peerNamespaceNames(Policies(installation, tt.namespace), tt.policy)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%s allows traffic from %v, want %v", tt.namespace, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("%s allows traffic from %v, want %v", tt.namespace, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileNetworkPolicies(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{
+		Name:      "rhoam-allow-installation",
+		Namespace: "redhat-rhoam-3scale",
+		Labels:    map[string]string{ManagedLabel: "true"},
+	}}
+	unmanaged := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "customer-policy", Namespace: "redhat-rhoam-3scale"}}
+
+	tests := []struct {
+		name          string
+		networkPolicy *v1alpha1.NetworkPolicySpec
+		want          []string
+	}{
+		{
+			name:          "isolates the product namespaces and removes the stale policies",
+			networkPolicy: &v1alpha1.NetworkPolicySpec{Level: v1alpha1.NetworkPolicyStrict},
+			want:          append(policyNames(Policies(testInstallation(&v1alpha1.NetworkPolicySpec{}), "redhat-rhoam-3scale")), "customer-policy"),
+		},
+		{
+			name: "removes the policies without network policy spec",
+			want: []string{"customer-policy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{
+				installationNamespace(operatorNamespace),
+				installationNamespace("redhat-rhoam-3scale"),
+				stale.DeepCopy(),
+				unmanaged.DeepCopy(),
+			}
+			serverClient := utils.NewTestClient(scheme, objects...)
+			if err := ReconcileNetworkPolicies(context.TODO(), serverClient, testInstallation(tt.networkPolicy)); err != nil {
+				t.Fatalf("ReconcileNetworkPolicies() error = %v", err)
+			}
+
+			policies := &networkingv1.NetworkPolicyList{}
+			if err := serverClient.List(context.TODO(), policies, k8sclient.InNamespace("redhat-rhoam-3scale")); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, policy := range policies.Items {
+				got = append(got, policy.Name)
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if len(got) != len(tt.want) {
+				t.Fatalf("policies = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("policies = %v, want %v", got, tt.want)
+				}
+			}
+
+			operatorPolicies := &networkingv1.NetworkPolicyList{}
+			if err := serverClient.List(context.TODO(), operatorPolicies, k8sclient.InNamespace(operatorNamespace)); err != nil {
+				t.Fatal(err)
+			}
+			if len(operatorPolicies.Items) != 0 {
+				t.Errorf("the installation namespace has %d policies, want none", len(operatorPolicies.Items))
+			}
+		})
+	}
+}
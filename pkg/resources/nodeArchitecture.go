@@ -0,0 +1,189 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ArchitectureLabel is the label that specifies the CPU architecture of a node
+	ArchitectureLabel = "kubernetes.io/arch"
+	// WorkerNodeRoleLabel is the label present on worker nodes
+	WorkerNodeRoleLabel = "node-role.kubernetes.io/worker"
+
+	ArchitectureAMD64 = "amd64"
+	ArchitectureARM64 = "arm64"
+
+	// csvArchitectureLabelPrefix is the prefix of the OLM labels used by a CSV
+	// to declare the architectures its images are available for
+	csvArchitectureLabelPrefix = "operatorframework.io/arch."
+	csvArchitectureSupported   = "supported"
+)
+
+// ProductArchitectures maps each product to the architectures that images
+// are published for across all of its components. Products missing from the
+// map are assumed to only be available for amd64
+var ProductArchitectures = map[integreatlyv1alpha1.ProductName][]string{
+	integreatlyv1alpha1.Product3Scale:         {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductRHSSO:          {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductRHSSOUser:      {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductMarin3r:        {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductCloudResources: {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductGrafana:        {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductObservability:  {ArchitectureAMD64},
+	integreatlyv1alpha1.ProductMCG:            {ArchitectureAMD64},
+}
+
+// GetProductArchitectures returns the architectures the images of a product
+// are available for
+func GetProductArchitectures(productName integreatlyv1alpha1.ProductName) []string {
+	if archs, ok := ProductArchitectures[productName]; ok {
+		return archs
+	}
+	return []string{ArchitectureAMD64}
+}
+
+// GetWorkerNodeArchitectures returns the sorted list of distinct architectures
+// across the worker nodes of the cluster
+func GetWorkerNodeArchitectures(ctx context.Context, client k8sclient.Client) ([]string, error) {
+	nodeList := &corev1.NodeList{}
+	if err := client.List(ctx, nodeList, k8sclient.HasLabels{WorkerNodeRoleLabel}); err != nil {
+		return nil, fmt.Errorf("failed to list worker nodes: %w", err)
+	}
+
+	found := map[string]bool{}
+	for _, node := range nodeList.Items {
+		if arch, ok := node.Labels[ArchitectureLabel]; ok && arch != "" {
+			found[arch] = true
+		}
+	}
+
+	archs := make([]string, 0, len(found))
+	for arch := range found {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+
+	return archs, nil
+}
+
+// GetCSVArchitectures returns the architectures declared as supported by the
+// CSV through the operatorframework.io/arch.<arch> labels. As with OLM, a CSV
+// without any architecture label is considered to only support amd64
+func GetCSVArchitectures(csv *operatorsv1alpha1.ClusterServiceVersion) []string {
+	var archs []string
+	for label, value := range csv.Labels {
+		if strings.HasPrefix(label, csvArchitectureLabelPrefix) && value == csvArchitectureSupported {
+			archs = append(archs, strings.TrimPrefix(label, csvArchitectureLabelPrefix))
+		}
+	}
+
+	if len(archs) == 0 {
+		return []string{ArchitectureAMD64}
+	}
+	sort.Strings(archs)
+
+	return archs
+}
+
+// ValidateArchitectures checks that the images are available for at least
+// one of the node architectures, and returns whether pods must be restricted
+// to a subset of the nodes
+func ValidateArchitectures(nodeArchs, supportedArchs []string) (restrict bool, err error) {
+	supported := map[string]bool{}
+	for _, arch := range supportedArchs {
+		supported[arch] = true
+	}
+
+	available := false
+	for _, arch := range nodeArchs {
+		if supported[arch] {
+			available = true
+		} else {
+			restrict = true
+		}
+	}
+
+	if len(nodeArchs) > 0 && !available {
+		return false, fmt.Errorf("images are only available for %s, no worker nodes found with these architectures, found %s", strings.Join(supportedArchs, ", "), strings.Join(nodeArchs, ", "))
+	}
+
+	return restrict, nil
+}
+
+// NodeArchitectureAffinity returns the node affinity that requires pods to be
+// scheduled in nodes of one of the given architectures
+func NodeArchitectureAffinity(archs []string) *corev1.NodeAffinity {
+	return &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      ArchitectureLabel,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   archs,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// GetNodeArchitectureAffinity returns the node affinity required to schedule
+// pods whose images are available for supportedArchs. It returns nil when all
+// the worker nodes are of a supported architecture
+func GetNodeArchitectureAffinity(ctx context.Context, client k8sclient.Client, supportedArchs []string) (*corev1.NodeAffinity, error) {
+	nodeArchs, err := GetWorkerNodeArchitectures(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	restrict, err := ValidateArchitectures(nodeArchs, supportedArchs)
+	if err != nil || !restrict {
+		return nil, err
+	}
+
+	return NodeArchitectureAffinity(supportedArchs), nil
+}
+
+// GetProductNodeArchitectureAffinity returns the node affinity required to
+// schedule the components of a product on a cluster with mixed architectures
+func GetProductNodeArchitectureAffinity(ctx context.Context, client k8sclient.Client, productName integreatlyv1alpha1.ProductName) (*corev1.NodeAffinity, error) {
+	affinity, err := GetNodeArchitectureAffinity(ctx, client, GetProductArchitectures(productName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node architecture affinity for %s: %w", productName, err)
+	}
+	return affinity, nil
+}
+
+// MutateNodeArchitectureAffinity returns a PodTemplateMutation that keeps the
+// pods of a product on nodes with an architecture its images are available
+// for. It must be applied after any mutation that replaces the pod affinity
+func MutateNodeArchitectureAffinity(ctx context.Context, client k8sclient.Client, productName integreatlyv1alpha1.ProductName) PodTemplateMutation {
+	return func(obj metav1.Object, podTemplate *corev1.PodTemplateSpec) error {
+		nodeAffinity, err := GetProductNodeArchitectureAffinity(ctx, client, productName)
+		if err != nil {
+			return err
+		}
+
+		if podTemplate.Spec.Affinity == nil {
+			if nodeAffinity == nil {
+				return nil
+			}
+			podTemplate.Spec.Affinity = &corev1.Affinity{}
+		}
+		podTemplate.Spec.Affinity.NodeAffinity = nodeAffinity
+
+		return nil
+	}
+}
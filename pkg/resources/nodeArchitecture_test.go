@@ -0,0 +1,126 @@
+package resources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/utils"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func workerNode(name, arch string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				WorkerNodeRoleLabel: "",
+				ArchitectureLabel:   arch,
+			},
+		},
+	}
+}
+
+func TestGetNodeArchitectureAffinity(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		Name           string
+		InitObjs       []runtime.Object
+		SupportedArchs []string
+		Expected       *corev1.NodeAffinity
+		ExpectError    bool
+	}{
+		{
+			Name:           "test no affinity when all workers are supported",
+			InitObjs:       []runtime.Object{workerNode("worker-0", ArchitectureAMD64), workerNode("worker-1", ArchitectureAMD64)},
+			SupportedArchs: []string{ArchitectureAMD64},
+		},
+		{
+			Name:           "test no affinity when images are available for every worker architecture",
+			InitObjs:       []runtime.Object{workerNode("worker-0", ArchitectureAMD64), workerNode("worker-1", ArchitectureARM64)},
+			SupportedArchs: []string{ArchitectureAMD64, ArchitectureARM64},
+		},
+		{
+			Name:           "test affinity to amd64 on mixed architecture cluster",
+			InitObjs:       []runtime.Object{workerNode("worker-0", ArchitectureAMD64), workerNode("worker-1", ArchitectureARM64)},
+			SupportedArchs: []string{ArchitectureAMD64},
+			Expected:       NodeArchitectureAffinity([]string{ArchitectureAMD64}),
+		},
+		{
+			Name:           "test error when images are not available for any worker architecture",
+			InitObjs:       []runtime.Object{workerNode("worker-0", ArchitectureARM64)},
+			SupportedArchs: []string{ArchitectureAMD64},
+			ExpectError:    true,
+		},
+		{
+			Name: "test non worker nodes are ignored",
+			InitObjs: []runtime.Object{workerNode("worker-0", ArchitectureAMD64), &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "master-0",
+					Labels: map[string]string{ArchitectureLabel: ArchitectureARM64},
+				},
+			}},
+			SupportedArchs: []string{ArchitectureAMD64},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			client := utils.NewTestClient(scheme, scenario.InitObjs...)
+
+			affinity, err := GetNodeArchitectureAffinity(context.TODO(), client, scenario.SupportedArchs)
+			if scenario.ExpectError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !scenario.ExpectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(affinity, scenario.Expected) {
+				t.Fatalf("expected affinity %v, got %v", scenario.Expected, affinity)
+			}
+		})
+	}
+}
+
+func TestGetCSVArchitectures(t *testing.T) {
+	scenarios := []struct {
+		Name     string
+		Labels   map[string]string
+		Expected []string
+	}{
+		{
+			Name:     "test csv without architecture labels defaults to amd64",
+			Expected: []string{ArchitectureAMD64},
+		},
+		{
+			Name: "test supported architectures are returned",
+			Labels: map[string]string{
+				"operatorframework.io/arch.arm64": "supported",
+				"operatorframework.io/arch.amd64": "supported",
+				"operatorframework.io/arch.s390x": "unsupported",
+				"operatorframework.io/os.linux":   "supported",
+			},
+			Expected: []string{ArchitectureAMD64, ArchitectureARM64},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			csv := &operatorsv1alpha1.ClusterServiceVersion{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: scenario.Labels,
+				},
+			}
+			if archs := GetCSVArchitectures(csv); !reflect.DeepEqual(archs, scenario.Expected) {
+				t.Fatalf("expected %v, got %v", scenario.Expected, archs)
+			}
+		})
+	}
+}
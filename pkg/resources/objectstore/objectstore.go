@@ -0,0 +1,121 @@
+// Package objectstore stores objects in S3 compatible buckets
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// keys of the S3 compatible storage credentials secret
+	s3AccessKeyID     = "accessKeyID"
+	s3SecretAccessKey = "secretAccessKey"
+	s3BucketName      = "bucketName"
+	s3BucketRegion    = "bucketRegion"
+	s3CABundle        = "ca.crt"
+
+	defaultS3BucketRegion = "us-east-1"
+)
+
+// ErrObjectNotFound is returned by ObjectStore.Get for missing keys
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore stores objects by key
+type ObjectStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+type s3ObjectStore struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3ObjectStore returns the store of the S3 compatible bucket, the
+// credentials secret is read from namespace
+func NewS3ObjectStore(ctx context.Context, serverClient k8sclient.Client, namespace string, storage *integreatlyv1alpha1.S3CompatibleStorageSpec) (ObjectStore, error) {
+	storageSec := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: storage.CredentialsSecret, Namespace: namespace}, storageSec); err != nil {
+		return nil, fmt.Errorf("failed to get object storage secret %s: %w", storage.CredentialsSecret, err)
+	}
+	for _, key := range []string{s3AccessKeyID, s3SecretAccessKey, s3BucketName} {
+		if len(storageSec.Data[key]) == 0 {
+			return nil, fmt.Errorf("object storage secret %s is missing %s", storage.CredentialsSecret, key)
+		}
+	}
+	if endpoint, err := url.Parse(storage.Endpoint); err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid object storage endpoint %q", storage.Endpoint)
+	}
+
+	region := defaultS3BucketRegion
+	if len(storageSec.Data[s3BucketRegion]) > 0 {
+		region = string(storageSec.Data[s3BucketRegion])
+	}
+	awsConfig := &aws.Config{
+		Credentials:      credentials.NewStaticCredentials(string(storageSec.Data[s3AccessKeyID]), string(storageSec.Data[s3SecretAccessKey]), ""),
+		Endpoint:         aws.String(storage.Endpoint),
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(storage.PathStyle),
+	}
+	if caBundle := storageSec.Data[s3CABundle]; len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("invalid %s in object storage secret %s", s3CABundle, storage.CredentialsSecret)
+		}
+		awsConfig.HTTPClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create object storage session: %w", err)
+	}
+
+	return &s3ObjectStore{
+		client: s3.New(sess),
+		bucket: string(storageSec.Data[s3BucketName]),
+	}, nil
+}
+
+func (s *s3ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to get %s from bucket %s: %w", key, s.bucket, err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3ObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put %s in bucket %s: %w", key, s.bucket, err)
+	}
+	return nil
+}
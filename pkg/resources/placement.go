@@ -0,0 +1,196 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PlacementAnnotation records on a pod template the placement applied to it,
+// so the settings removed from the installation are removed from the pods
+const PlacementAnnotation = "integreatly.org/placement"
+
+// MutatePlacement returns a PodTemplateMutation that schedules the pods on the
+// nodes selected by the placement of the installation. It must be applied
+// after any mutation that replaces the topology spread constraints
+func MutatePlacement(placement *integreatlyv1alpha1.PlacementSpec) PodTemplateMutation {
+	return func(_ metav1.Object, podTemplate *corev1.PodTemplateSpec) error {
+		if applied, ok := podTemplate.Annotations[PlacementAnnotation]; ok {
+			previous := &integreatlyv1alpha1.PlacementSpec{}
+			if err := json.Unmarshal([]byte(applied), previous); err != nil {
+				return fmt.Errorf("failed to read the placement applied to the pods: %w", err)
+			}
+			removePlacement(&podTemplate.Spec, podTemplate.Labels, previous)
+			delete(podTemplate.Annotations, PlacementAnnotation)
+		}
+		if placement == nil {
+			return nil
+		}
+
+		applyPlacement(&podTemplate.Spec, podTemplate.Labels, placement)
+		applied, err := json.Marshal(placement)
+		if err != nil {
+			return fmt.Errorf("failed to record the placement applied to the pods: %w", err)
+		}
+		if podTemplate.Annotations == nil {
+			podTemplate.Annotations = map[string]string{}
+		}
+		podTemplate.Annotations[PlacementAnnotation] = string(applied)
+
+		return nil
+	}
+}
+
+// PlacementTolerations returns the tolerations of the placement, nil when
+// there's no placement
+func PlacementTolerations(placement *integreatlyv1alpha1.PlacementSpec) []corev1.Toleration {
+	if placement == nil {
+		return nil
+	}
+	return placement.Tolerations
+}
+
+// PlacementNodeSelector returns the node selector of the placement, nil when
+// there's no placement
+func PlacementNodeSelector(placement *integreatlyv1alpha1.PlacementSpec) map[string]string {
+	if placement == nil {
+		return nil
+	}
+	return placement.NodeSelector
+}
+
+// PlacementNodeAffinity adds the node selector of the placement to a node
+// affinity, for the workloads that can only be placed through their affinity
+func PlacementNodeAffinity(nodeAffinity *corev1.NodeAffinity, placement *integreatlyv1alpha1.PlacementSpec) *corev1.NodeAffinity {
+	nodeSelector := PlacementNodeSelector(placement)
+	if len(nodeSelector) == 0 {
+		return nodeAffinity
+	}
+
+	keys := make([]string, 0, len(nodeSelector))
+	for key := range nodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	requirements := make([]corev1.NodeSelectorRequirement, 0, len(keys))
+	for _, key := range keys {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{nodeSelector[key]},
+		})
+	}
+
+	if nodeAffinity == nil {
+		nodeAffinity = &corev1.NodeAffinity{}
+	} else {
+		nodeAffinity = nodeAffinity.DeepCopy()
+	}
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		terms = []corev1.NodeSelectorTerm{{}}
+	}
+	// The terms are ORed, every one of them must require the node selector
+	for i := range terms {
+		terms[i].MatchExpressions = append(terms[i].MatchExpressions, requirements...)
+	}
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+
+	return nodeAffinity
+}
+
+func applyPlacement(podSpec *corev1.PodSpec, labels map[string]string, placement *integreatlyv1alpha1.PlacementSpec) {
+	if len(placement.NodeSelector) > 0 && podSpec.NodeSelector == nil {
+		podSpec.NodeSelector = map[string]string{}
+	}
+	for key, value := range placement.NodeSelector {
+		podSpec.NodeSelector[key] = value
+	}
+
+	for _, toleration := range placement.Tolerations {
+		if indexOfToleration(podSpec.Tolerations, toleration) == -1 {
+			podSpec.Tolerations = append(podSpec.Tolerations, toleration)
+		}
+	}
+
+	// A constraint replaces the one the pods have for the same topology, as
+	// there can only be one
+	for _, constraint := range placement.TopologySpreadConstraints {
+		constraint = placementConstraint(constraint, labels)
+		if i := indexOfConstraint(podSpec.TopologySpreadConstraints, constraint); i != -1 {
+			podSpec.TopologySpreadConstraints[i] = constraint
+		} else {
+			podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, constraint)
+		}
+	}
+}
+
+func removePlacement(podSpec *corev1.PodSpec, labels map[string]string, placement *integreatlyv1alpha1.PlacementSpec) {
+	for key, value := range placement.NodeSelector {
+		if podSpec.NodeSelector[key] == value {
+			delete(podSpec.NodeSelector, key)
+		}
+	}
+	if len(podSpec.NodeSelector) == 0 {
+		podSpec.NodeSelector = nil
+	}
+
+	for _, toleration := range placement.Tolerations {
+		if i := indexOfToleration(podSpec.Tolerations, toleration); i != -1 {
+			podSpec.Tolerations = append(podSpec.Tolerations[:i], podSpec.Tolerations[i+1:]...)
+		}
+	}
+	if len(podSpec.Tolerations) == 0 {
+		podSpec.Tolerations = nil
+	}
+
+	for _, constraint := range placement.TopologySpreadConstraints {
+		constraint = placementConstraint(constraint, labels)
+		i := indexOfConstraint(podSpec.TopologySpreadConstraints, constraint)
+		if i != -1 && equality.Semantic.DeepEqual(podSpec.TopologySpreadConstraints[i], constraint) {
+			podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints[:i], podSpec.TopologySpreadConstraints[i+1:]...)
+		}
+	}
+	if len(podSpec.TopologySpreadConstraints) == 0 {
+		podSpec.TopologySpreadConstraints = nil
+	}
+}
+
+// placementConstraint returns the constraint spreading the pods with the given
+// labels when it has no label selector
+func placementConstraint(constraint corev1.TopologySpreadConstraint, labels map[string]string) corev1.TopologySpreadConstraint {
+	if constraint.LabelSelector == nil && len(labels) > 0 {
+		matchLabels := make(map[string]string, len(labels))
+		for key, value := range labels {
+			matchLabels[key] = value
+		}
+		constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	}
+	return constraint
+}
+
+func indexOfToleration(tolerations []corev1.Toleration, toleration corev1.Toleration) int {
+	for i := range tolerations {
+		if equality.Semantic.DeepEqual(tolerations[i], toleration) {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOfConstraint(constraints []corev1.TopologySpreadConstraint, constraint corev1.TopologySpreadConstraint) int {
+	for i := range constraints {
+		if constraints[i].TopologyKey == constraint.TopologyKey && constraints[i].WhenUnsatisfiable == constraint.WhenUnsatisfiable {
+			return i
+		}
+	}
+	return -1
+}
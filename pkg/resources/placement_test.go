@@ -0,0 +1,167 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMutatePlacement(t *testing.T) {
+	infraToleration := corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	productToleration := corev1.Toleration{Key: "product", Operator: corev1.TolerationOpExists}
+	zoneConstraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       ZoneLabel,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "keycloak"}},
+	}
+	hostConstraint := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       "kubernetes.io/hostname",
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+	}
+	placement := &integreatlyv1alpha1.PlacementSpec{
+		NodeSelector:              map[string]string{"node-role.kubernetes.io/infra": ""},
+		Tolerations:               []corev1.Toleration{infraToleration},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{hostConstraint},
+	}
+	podTemplate := func() *corev1.PodTemplateSpec {
+		return &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "keycloak"}},
+			Spec: corev1.PodSpec{
+				NodeSelector:              map[string]string{"kubernetes.io/os": "linux"},
+				Tolerations:               []corev1.Toleration{productToleration},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{zoneConstraint},
+			},
+		}
+	}
+	placedHostConstraint := hostConstraint
+	placedHostConstraint.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "keycloak"}}
+
+	t.Run("test placement applied to the pods", func(t *testing.T) {
+		template := podTemplate()
+		if err := MutatePlacement(placement)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"kubernetes.io/os": "linux", "node-role.kubernetes.io/infra": ""}; !reflect.DeepEqual(template.Spec.NodeSelector, want) {
+			t.Errorf("expected node selector %v, got %v", want, template.Spec.NodeSelector)
+		}
+		if want := []corev1.Toleration{productToleration, infraToleration}; !reflect.DeepEqual(template.Spec.Tolerations, want) {
+			t.Errorf("expected tolerations %v, got %v", want, template.Spec.Tolerations)
+		}
+		if want := []corev1.TopologySpreadConstraint{zoneConstraint, placedHostConstraint}; !reflect.DeepEqual(template.Spec.TopologySpreadConstraints, want) {
+			t.Errorf("expected topology spread constraints %v, got %v", want, template.Spec.TopologySpreadConstraints)
+		}
+		if _, ok := template.Annotations[PlacementAnnotation]; !ok {
+			t.Errorf("expected the applied placement to be recorded")
+		}
+
+		// Applying it again doesn't change the pods
+		placed := template.DeepCopy()
+		if err := MutatePlacement(placement)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(template, placed) {
+			t.Errorf("expected the placement to be applied once, got %v", template)
+		}
+	})
+
+	t.Run("test placement removed from the pods", func(t *testing.T) {
+		template := podTemplate()
+		if err := MutatePlacement(placement)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		if err := MutatePlacement(nil)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		want := podTemplate()
+		want.Annotations = map[string]string{}
+		if !reflect.DeepEqual(template, want) {
+			t.Errorf("expected the pods to be restored, got %v", template)
+		}
+	})
+
+	t.Run("test constraint of the same topology replaced", func(t *testing.T) {
+		template := podTemplate()
+		zonePlacement := &integreatlyv1alpha1.PlacementSpec{
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 2, TopologyKey: ZoneLabel, WhenUnsatisfiable: corev1.ScheduleAnyway},
+			},
+		}
+		if err := MutatePlacement(zonePlacement)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		if len(template.Spec.TopologySpreadConstraints) != 1 || template.Spec.TopologySpreadConstraints[0].MaxSkew != 2 {
+			t.Errorf("expected the zone constraint to be replaced, got %v", template.Spec.TopologySpreadConstraints)
+		}
+	})
+
+	t.Run("test pods without placement left as is", func(t *testing.T) {
+		template := podTemplate()
+		if err := MutatePlacement(nil)(nil, template); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(template, podTemplate()) {
+			t.Errorf("expected the pods to be left as is, got %v", template)
+		}
+	})
+}
+
+func TestPlacementNodeAffinity(t *testing.T) {
+	archAffinity := NodeArchitectureAffinity([]string{ArchitectureAMD64})
+	placement := &integreatlyv1alpha1.PlacementSpec{NodeSelector: map[string]string{"pool": "rhoam", "node-role.kubernetes.io/infra": ""}}
+	placementRequirements := []corev1.NodeSelectorRequirement{
+		{Key: "node-role.kubernetes.io/infra", Operator: corev1.NodeSelectorOpIn, Values: []string{""}},
+		{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"rhoam"}},
+	}
+
+	tests := []struct {
+		name         string
+		nodeAffinity *corev1.NodeAffinity
+		placement    *integreatlyv1alpha1.PlacementSpec
+		want         *corev1.NodeAffinity
+	}{
+		{
+			name:         "test affinity kept without placement",
+			nodeAffinity: archAffinity,
+			want:         archAffinity,
+		},
+		{
+			name:      "test affinity created from the node selector",
+			placement: placement,
+			want: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: placementRequirements}},
+				},
+			},
+		},
+		{
+			name:         "test node selector added to the affinity",
+			nodeAffinity: archAffinity,
+			placement:    placement,
+			want: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: append(
+							[]corev1.NodeSelectorRequirement{archAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]},
+							placementRequirements...,
+						),
+					}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PlacementNodeAffinity(tt.nodeAffinity, tt.placement); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlacementNodeAffinity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if len(archAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions) != 1 {
+		t.Errorf("expected the node affinity not to be modified")
+	}
+}
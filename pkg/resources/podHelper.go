@@ -3,7 +3,10 @@ package resources
 import (
 	"bytes"
 	"context"
+	"strings"
 
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/dryrun"
 	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
@@ -18,6 +21,7 @@ import (
 type PodExecutorInterface interface {
 	ExecuteRemoteCommand(ns string, podName string, command []string) (string, string, error)
 	ExecuteRemoteContainerCommand(ns string, podName string, container string, command []string) (string, string, error)
+	ExecuteRemoteContainerCommandWithStdin(ns string, podName string, container string, command []string, stdin string) (string, string, error)
 }
 
 type PodExecutor struct {
@@ -32,6 +36,15 @@ func NewPodExecutor(log l.Logger) *PodExecutor {
 	}
 }
 
+// NewInstallationPodExecutor returns the pod executor of the reconcilers of
+// the installation, which runs no commands during a dry run
+func NewInstallationPodExecutor(installation *integreatlyv1alpha1.RHMI, log l.Logger) PodExecutorInterface {
+	if installation.IsDryRun() {
+		return dryrun.PodExecutor{}
+	}
+	return NewPodExecutor(log)
+}
+
 // ExecuteRemoteCommand exec command on specific pod and wait the command's output.
 func (p PodExecutor) ExecuteRemoteCommand(ns string, podName string, command []string) (string, string, error) {
 
@@ -118,6 +131,52 @@ func (p PodExecutor) ExecuteRemoteContainerCommand(ns string, podName string, co
 	return buf.String(), errBuf.String(), nil
 }
 
+// ExecuteRemoteContainerCommandWithStdin exec command on specific pod, writing
+// stdin to the command's standard input, and wait the command's output. Values
+// passed through stdin aren't visible in the command line of the process
+func (p PodExecutor) ExecuteRemoteContainerCommandWithStdin(ns string, podName string, container string, command []string, stdin string) (string, string, error) {
+
+	kubeClient, restConfig, err := getClient()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Failed to get client")
+	}
+
+	req := kubeClient.CoreV1().RESTClient().Post().Resource("pods").Name(podName).
+		Namespace(ns).SubResource("exec")
+	option := &v1.PodExecOptions{
+		Command:   command,
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    true,
+		TTY:       false,
+		Container: container,
+	}
+	req.VersionedParams(
+		option,
+		scheme.ParameterCodec,
+	)
+	exec, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Failed executing command %s on %s/%s", command, ns, podName)
+	}
+
+	buf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+
+	p.Log.Infof("Executing", l.Fields{"command": command, "pod": podName})
+
+	err = exec.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: buf,
+		Stderr: errBuf,
+	})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Failed executing command %s on %s/%s", command, ns, podName)
+	}
+
+	return buf.String(), errBuf.String(), nil
+}
+
 func getClient() (*kube.Clientset, *restclient.Config, error) {
 
 	kubeCfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -23,6 +23,9 @@ var _ PodExecutorInterface = &PodExecutorInterfaceMock{}
 //			ExecuteRemoteContainerCommandFunc: func(ns string, podName string, container string, command []string) (string, string, error) {
 //				panic("mock out the ExecuteRemoteContainerCommand method")
 //			},
+//			ExecuteRemoteContainerCommandWithStdinFunc: func(ns string, podName string, container string, command []string, stdin string) (string, string, error) {
+//				panic("mock out the ExecuteRemoteContainerCommandWithStdin method")
+//			},
 //		}
 //
 //		// use mockedPodExecutorInterface in code that requires PodExecutorInterface
@@ -36,6 +39,9 @@ type PodExecutorInterfaceMock struct {
 	// ExecuteRemoteContainerCommandFunc mocks the ExecuteRemoteContainerCommand method.
 	ExecuteRemoteContainerCommandFunc func(ns string, podName string, container string, command []string) (string, string, error)
 
+	// ExecuteRemoteContainerCommandWithStdinFunc mocks the ExecuteRemoteContainerCommandWithStdin method.
+	ExecuteRemoteContainerCommandWithStdinFunc func(ns string, podName string, container string, command []string, stdin string) (string, string, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// ExecuteRemoteCommand holds details about calls to the ExecuteRemoteCommand method.
@@ -58,9 +64,23 @@ type PodExecutorInterfaceMock struct {
 			// Command is the command argument value.
 			Command []string
 		}
+		// ExecuteRemoteContainerCommandWithStdin holds details about calls to the ExecuteRemoteContainerCommandWithStdin method.
+		ExecuteRemoteContainerCommandWithStdin []struct {
+			// Ns is the ns argument value.
+			Ns string
+			// PodName is the podName argument value.
+			PodName string
+			// Container is the container argument value.
+			Container string
+			// Command is the command argument value.
+			Command []string
+			// Stdin is the stdin argument value.
+			Stdin string
+		}
 	}
-	lockExecuteRemoteCommand          sync.RWMutex
-	lockExecuteRemoteContainerCommand sync.RWMutex
+	lockExecuteRemoteCommand                   sync.RWMutex
+	lockExecuteRemoteContainerCommand          sync.RWMutex
+	lockExecuteRemoteContainerCommandWithStdin sync.RWMutex
 }
 
 // ExecuteRemoteCommand calls ExecuteRemoteCommandFunc.
@@ -146,3 +166,51 @@ func (mock *PodExecutorInterfaceMock) ExecuteRemoteContainerCommandCalls() []str
 	mock.lockExecuteRemoteContainerCommand.RUnlock()
 	return calls
 }
+
+// ExecuteRemoteContainerCommandWithStdin calls ExecuteRemoteContainerCommandWithStdinFunc.
+func (mock *PodExecutorInterfaceMock) ExecuteRemoteContainerCommandWithStdin(ns string, podName string, container string, command []string, stdin string) (string, string, error) {
+	if mock.ExecuteRemoteContainerCommandWithStdinFunc == nil {
+		panic("PodExecutorInterfaceMock.ExecuteRemoteContainerCommandWithStdinFunc: method is nil but PodExecutorInterface.ExecuteRemoteContainerCommandWithStdin was just called")
+	}
+	callInfo := struct {
+		Ns        string
+		PodName   string
+		Container string
+		Command   []string
+		Stdin     string
+	}{
+		Ns:        ns,
+		PodName:   podName,
+		Container: container,
+		Command:   command,
+		Stdin:     stdin,
+	}
+	mock.lockExecuteRemoteContainerCommandWithStdin.Lock()
+	mock.calls.ExecuteRemoteContainerCommandWithStdin = append(mock.calls.ExecuteRemoteContainerCommandWithStdin, callInfo)
+	mock.lockExecuteRemoteContainerCommandWithStdin.Unlock()
+	return mock.ExecuteRemoteContainerCommandWithStdinFunc(ns, podName, container, command, stdin)
+}
+
+// ExecuteRemoteContainerCommandWithStdinCalls gets all the calls that were made to ExecuteRemoteContainerCommandWithStdin.
+// Check the length with:
+//
+//	len(mockedPodExecutorInterface.ExecuteRemoteContainerCommandWithStdinCalls())
+func (mock *PodExecutorInterfaceMock) ExecuteRemoteContainerCommandWithStdinCalls() []struct {
+	Ns        string
+	PodName   string
+	Container string
+	Command   []string
+	Stdin     string
+} {
+	var calls []struct {
+		Ns        string
+		PodName   string
+		Container string
+		Command   []string
+		Stdin     string
+	}
+	mock.lockExecuteRemoteContainerCommandWithStdin.RLock()
+	calls = mock.calls.ExecuteRemoteContainerCommandWithStdin
+	mock.lockExecuteRemoteContainerCommandWithStdin.RUnlock()
+	return calls
+}
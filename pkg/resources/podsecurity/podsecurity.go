@@ -0,0 +1,138 @@
+package podsecurity
+
+import (
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	EnforceLabel = "pod-security.kubernetes.io/enforce"
+	AuditLabel   = "pod-security.kubernetes.io/audit"
+	WarnLabel    = "pod-security.kubernetes.io/warn"
+	// LabelSyncLabel stops OpenShift from replacing the pod
+	// security labels of the namespace with the ones of its SCCs
+	LabelSyncLabel = "security.openshift.io/scc.podSecurityLabelSync"
+	// ManagedLabel marks the namespaces labelled by the
+	// operator, so their labels are removed with the pod security of the
+	// installation
+	ManagedLabel = "integreatly.org/pod-security"
+)
+
+// Enforced returns the Pod Security Admission level enforced on
+// the namespaces of the products, or "" when the installation doesn't
+// enforce one. Baseline is enforced by default, as some of the workloads,
+// such as the in-cluster databases of the cloud resources operator and the
+// operators installed by OLM, don't run with the restricted security
+// contexts. Their restricted violations are still audited and warned
+func Enforced(installation *integreatlyv1alpha1.RHMI) integreatlyv1alpha1.PodSecurityLevel {
+	if installation.Spec.PodSecurity == nil {
+		return ""
+	}
+	if installation.Spec.PodSecurity.Enforce == "" {
+		return integreatlyv1alpha1.PodSecurityBaseline
+	}
+	return installation.Spec.PodSecurity.Enforce
+}
+
+// PrepareNamespaceLabels labels the namespace with the Pod Security
+// Admission level of the installation, or removes the labels set before
+// once the installation doesn't enforce one
+func PrepareNamespaceLabels(ns *corev1.Namespace, installation *integreatlyv1alpha1.RHMI) {
+	level := Enforced(installation)
+	if level == "" {
+		if ns.Labels[ManagedLabel] == "true" {
+			for _, label := range []string{EnforceLabel, AuditLabel, WarnLabel, LabelSyncLabel, ManagedLabel} {
+				delete(ns.Labels, label)
+			}
+		}
+		return
+	}
+
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	ns.Labels[EnforceLabel] = string(level)
+	ns.Labels[AuditLabel] = string(integreatlyv1alpha1.PodSecurityRestricted)
+	ns.Labels[WarnLabel] = string(integreatlyv1alpha1.PodSecurityRestricted)
+	ns.Labels[LabelSyncLabel] = "false"
+	ns.Labels[ManagedLabel] = "true"
+}
+
+// Mutate returns a pod template mutation that sets the security
+// contexts required by the restricted profile on the pods when the
+// installation enforces a level. The security contexts are kept once the
+// level isn't enforced anymore, they're the defaults of the restricted-v2 SCC
+func Mutate(installation *integreatlyv1alpha1.RHMI) func(metav1.Object, *corev1.PodTemplateSpec) error {
+	return func(_ metav1.Object, podTemplate *corev1.PodTemplateSpec) error {
+		if Enforced(installation) == "" {
+			return nil
+		}
+		ApplyRestricted(&podTemplate.Spec)
+		return nil
+	}
+}
+
+// ApplyRestricted sets the security contexts required by the
+// restricted profile on the pod and its containers, keeping the settings of
+// the workload that already comply with it
+func ApplyRestricted(podSpec *corev1.PodSpec) {
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	podSpec.SecurityContext.RunAsNonRoot = &[]bool{true}[0]
+	if podSpec.SecurityContext.SeccompProfile == nil {
+		podSpec.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			applySecurityContext(&containers[i])
+		}
+	}
+}
+
+func applySecurityContext(container *corev1.Container) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	securityContext := container.SecurityContext
+	securityContext.AllowPrivilegeEscalation = &[]bool{false}[0]
+	securityContext.Privileged = nil
+	if securityContext.Capabilities == nil {
+		securityContext.Capabilities = &corev1.Capabilities{}
+	}
+	// the restricted profile only allows adding NET_BIND_SERVICE
+	var added []corev1.Capability
+	for _, capability := range securityContext.Capabilities.Add {
+		if capability == "NET_BIND_SERVICE" {
+			added = append(added, capability)
+		}
+	}
+	securityContext.Capabilities.Add = added
+	securityContext.Capabilities.Drop = []corev1.Capability{"ALL"}
+}
+
+// PodSecurityContext returns the security context of the pods the
+// operator deploys itself when the installation enforces a level, nil
+// otherwise
+func PodSecurityContext(installation *integreatlyv1alpha1.RHMI) *corev1.PodSecurityContext {
+	if Enforced(installation) == "" {
+		return nil
+	}
+	podSpec := &corev1.PodSpec{}
+	ApplyRestricted(podSpec)
+	return podSpec.SecurityContext
+}
+
+// SecurityContext returns the security context of the containers
+// the operator deploys itself when the installation enforces a level, nil
+// otherwise
+func SecurityContext(installation *integreatlyv1alpha1.RHMI) *corev1.SecurityContext {
+	if Enforced(installation) == "" {
+		return nil
+	}
+	container := &corev1.Container{}
+	applySecurityContext(container)
+	return container.SecurityContext
+}
@@ -0,0 +1,163 @@
+package podsecurity
+
+import (
+	"reflect"
+	"testing"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrepareNamespaceLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		podSecurity *integreatlyv1alpha1.PodSecuritySpec
+		labels      map[string]string
+		want        map[string]string
+	}{
+		{
+			name:   "namespace not labelled without pod security",
+			labels: map[string]string{"integreatly": "true"},
+			want:   map[string]string{"integreatly": "true"},
+		},
+		{
+			name:        "baseline enforced and restricted audited by default",
+			podSecurity: &integreatlyv1alpha1.PodSecuritySpec{},
+			want: map[string]string{
+				EnforceLabel:   "baseline",
+				AuditLabel:     "restricted",
+				WarnLabel:      "restricted",
+				LabelSyncLabel: "false",
+				ManagedLabel:   "true",
+			},
+		},
+		{
+			name:        "restricted enforced",
+			podSecurity: &integreatlyv1alpha1.PodSecuritySpec{Enforce: integreatlyv1alpha1.PodSecurityRestricted},
+			want: map[string]string{
+				EnforceLabel:   "restricted",
+				AuditLabel:     "restricted",
+				WarnLabel:      "restricted",
+				LabelSyncLabel: "false",
+				ManagedLabel:   "true",
+			},
+		},
+		{
+			name:        "baseline enforced, restricted audited",
+			podSecurity: &integreatlyv1alpha1.PodSecuritySpec{Enforce: integreatlyv1alpha1.PodSecurityBaseline},
+			labels:      map[string]string{EnforceLabel: "privileged"},
+			want: map[string]string{
+				EnforceLabel:   "baseline",
+				AuditLabel:     "restricted",
+				WarnLabel:      "restricted",
+				LabelSyncLabel: "false",
+				ManagedLabel:   "true",
+			},
+		},
+		{
+			name: "labels removed once pod security is unset",
+			labels: map[string]string{
+				"integreatly":  "true",
+				EnforceLabel:   "restricted",
+				AuditLabel:     "restricted",
+				WarnLabel:      "restricted",
+				LabelSyncLabel: "false",
+				ManagedLabel:   "true",
+			},
+			want: map[string]string{"integreatly": "true"},
+		},
+		{
+			name:   "labels set by others kept",
+			labels: map[string]string{EnforceLabel: "privileged"},
+			want:   map[string]string{EnforceLabel: "privileged"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-3scale", Labels: tt.labels}}
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{PodSecurity: tt.podSecurity}}
+			PrepareNamespaceLabels(ns, installation)
+			if len(ns.Labels) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(ns.Labels, tt.want)) {
+				t.Errorf("labels = %v, want %v", ns.Labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutate(t *testing.T) {
+	tests := []struct {
+		name        string
+		podSecurity *integreatlyv1alpha1.PodSecuritySpec
+		podSpec     corev1.PodSpec
+		want        corev1.PodSpec
+	}{
+		{
+			name:    "pods unchanged without pod security",
+			podSpec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			want:    corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+		{
+			name:        "restricted security contexts set",
+			podSecurity: &integreatlyv1alpha1.PodSecuritySpec{},
+			podSpec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init"}},
+				Containers: []corev1.Container{{
+					Name: "app",
+					SecurityContext: &corev1.SecurityContext{
+						Privileged:   &[]bool{true}[0],
+						Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_ADMIN", "NET_BIND_SERVICE"}},
+					},
+				}},
+			},
+			want: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   &[]bool{true}[0],
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				InitContainers: []corev1.Container{{
+					Name: "init",
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					},
+				}},
+				Containers: []corev1.Container{{
+					Name: "app",
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						Capabilities: &corev1.Capabilities{
+							Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+				}},
+			},
+		},
+		{
+			name:        "seccomp profile of the workload kept",
+			podSecurity: &integreatlyv1alpha1.PodSecuritySpec{Enforce: integreatlyv1alpha1.PodSecurityBaseline},
+			podSpec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost}},
+			},
+			want: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   &[]bool{true}[0],
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{PodSecurity: tt.podSecurity}}
+			podTemplate := &corev1.PodTemplateSpec{Spec: tt.podSpec}
+			if err := Mutate(installation)(&metav1.ObjectMeta{}, podTemplate); err != nil {
+				t.Fatalf("Mutate() error = %v", err)
+			}
+			if !reflect.DeepEqual(podTemplate.Spec, tt.want) {
+				t.Errorf("Mutate() = %+v, want %+v", podTemplate.Spec, tt.want)
+			}
+		})
+	}
+}
@@ -68,6 +68,12 @@ type Quota struct {
 	productConfigs  map[v1alpha1.ProductName]QuotaProductConfig
 	isUpdated       bool
 	rateLimitConfig marin3rconfig.RateLimitConfig
+	// forcedResources are the workloads whose requests and limits are set
+	// even when they're lower than the current ones
+	forcedResources map[string]bool
+	// transitioning is true while an intermediate step of a transition to
+	// the quota is applied
+	transitioning bool
 }
 
 //go:generate moq -out product_config_moq.go . ProductConfig
@@ -100,6 +106,27 @@ type quotaConfigReceiver struct {
 }
 
 func GetQuota(ctx context.Context, c client.Client, quotaParam string, QuotaConfig *corev1.ConfigMap, retQuota *Quota) error {
+	return GetQuotaWithCustomResources(ctx, c, quotaParam, QuotaConfig, nil, retQuota)
+}
+
+// GetQuotaWithCustomResources finds the quota of the quotaParam in the Quota
+// custom resources, and falls back to the quota config map when none of them
+// defines it
+func GetQuotaWithCustomResources(ctx context.Context, c client.Client, quotaParam string, QuotaConfig *corev1.ConfigMap, quotaCRs []v1alpha1.Quota, retQuota *Quota) error {
+	return getQuota(ctx, c, QuotaConfig, quotaCRs, retQuota, func(quota quotaConfigReceiver) bool {
+		return quota.Param == quotaParam
+	}, fmt.Sprintf("the '%s' quota parameter", quotaParam))
+}
+
+// GetQuotaByName finds the quota of the name reported in the installation
+// status, in the Quota custom resources or the quota config map
+func GetQuotaByName(ctx context.Context, c client.Client, quotaName string, QuotaConfig *corev1.ConfigMap, quotaCRs []v1alpha1.Quota, retQuota *Quota) error {
+	return getQuota(ctx, c, QuotaConfig, quotaCRs, retQuota, func(quota quotaConfigReceiver) bool {
+		return quota.Name == quotaName
+	}, fmt.Sprintf("the '%s' quota name", quotaName))
+}
+
+func getQuota(ctx context.Context, c client.Client, QuotaConfig *corev1.ConfigMap, quotaCRs []v1alpha1.Quota, retQuota *Quota, matches func(quotaConfigReceiver) bool, description string) error {
 	allQuotas := &[]quotaConfigReceiver{}
 	err := json.Unmarshal([]byte(QuotaConfig.Data[ConfigMapData]), allQuotas)
 	if err != nil {
@@ -107,8 +134,14 @@ func GetQuota(ctx context.Context, c client.Client, quotaParam string, QuotaConf
 	}
 	quotaReceiver := quotaConfigReceiver{}
 
-	for _, quota := range *allQuotas {
-		if quota.Param == quotaParam {
+	receivers := []quotaConfigReceiver{}
+	for _, quotaCR := range quotaCRs {
+		receivers = append(receivers, receiverFromQuota(quotaCR))
+	}
+	receivers = append(receivers, *allQuotas...)
+
+	for _, quota := range receivers {
+		if matches(quota) {
 			quotaReceiver = quota
 			break
 		}
@@ -116,7 +149,7 @@ func GetQuota(ctx context.Context, c client.Client, quotaParam string, QuotaConf
 	// if the quota receiver is empty at this point we haven't found a quota which matches the config
 	// return in progress
 	if quotaReceiver.Name == "" {
-		return fmt.Errorf("wasn't able to find a quota in the quota config which matches the '%s' quota parameter", quotaParam)
+		return fmt.Errorf("wasn't able to find a quota in the quota config which matches %s", description)
 	}
 
 	retQuota.name = quotaReceiver.Name
@@ -154,6 +187,65 @@ func GetQuota(ctx context.Context, c client.Client, quotaParam string, QuotaConf
 	return nil
 }
 
+func receiverFromQuota(quotaCR v1alpha1.Quota) quotaConfigReceiver {
+	receiver := quotaConfigReceiver{
+		Name:  quotaCR.Spec.DisplayName,
+		Param: quotaCR.Spec.Param,
+		RateLimit: marin3rconfig.RateLimitConfig{
+			Unit:            quotaCR.Spec.RateLimit.Unit,
+			RequestsPerUnit: quotaCR.Spec.RateLimit.RequestsPerUnit,
+		},
+		Resources: map[string]ResourceConfig{},
+	}
+	for _, resource := range quotaCR.Spec.Resources {
+		receiver.Resources[resource.Name] = ResourceConfig{
+			Replicas:  resource.Replicas,
+			Resources: *resource.Resources.DeepCopy(),
+		}
+	}
+	return receiver
+}
+
+// ApplyResourceOverrides replaces the requests and limits of the quota with the
+// ones of the overrides. The overridden workloads, and the reset ones whose
+// override was removed, are set to the values even when they're lower than the
+// current ones
+func (s *Quota) ApplyResourceOverrides(overrides []v1alpha1.ResourceOverrideSpec, reset []string) {
+	s.forcedResources = map[string]bool{}
+	for _, name := range reset {
+		s.forcedResources[name] = true
+	}
+	for _, override := range overrides {
+		for _, pc := range s.productConfigs {
+			config, ok := pc.resourceConfigs[override.Name]
+			if !ok {
+				continue
+			}
+			config.Resources = overrideResources(config.Resources, override.Resources)
+			pc.resourceConfigs[override.Name] = config
+			s.forcedResources[override.Name] = true
+		}
+	}
+}
+
+func overrideResources(resources, override corev1.ResourceRequirements) corev1.ResourceRequirements {
+	result := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	for _, list := range []corev1.ResourceList{resources.Requests, override.Requests} {
+		for name, quantity := range list {
+			result.Requests[name] = quantity.DeepCopy()
+		}
+	}
+	for _, list := range []corev1.ResourceList{resources.Limits, override.Limits} {
+		for name, quantity := range list {
+			result.Limits[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
 func (s *Quota) GetProduct(productName v1alpha1.ProductName) QuotaProductConfig {
 	// handle product not found e.g. return nil?
 	return s.productConfigs[productName]
@@ -216,8 +308,8 @@ func (p QuotaProductConfig) Configure(obj metav1.Object) error {
 		}
 		resources := p.resourceConfigs[KeycloakName].Resources
 		checkResourceBlock(&t.Spec.KeycloakDeploymentSpec.Resources)
-		p.mutateResources(t.Spec.KeycloakDeploymentSpec.Resources.Requests, resources.Requests)
-		p.mutateResources(t.Spec.KeycloakDeploymentSpec.Resources.Limits, resources.Limits)
+		p.mutateResources(t.Spec.KeycloakDeploymentSpec.Resources.Requests, resources.Requests, KeycloakName)
+		p.mutateResources(t.Spec.KeycloakDeploymentSpec.Resources.Limits, resources.Limits, KeycloakName)
 	case *threescalev1.APIManager:
 		checkApiManager(t)
 
@@ -280,20 +372,21 @@ func (p QuotaProductConfig) mutateResourcesRequirement(resourceRequirements *cor
 	}
 	checkResourceBlock(resourceRequirements)
 
-	p.mutateResources(resourceRequirements.Limits, resources.Limits)
-	p.mutateResources(resourceRequirements.Requests, resources.Requests)
+	p.mutateResources(resourceRequirements.Limits, resources.Limits, name)
+	p.mutateResources(resourceRequirements.Requests, resources.Requests, name)
 }
 
-func (p QuotaProductConfig) mutateResources(pod, cfg corev1.ResourceList) {
+func (p QuotaProductConfig) mutateResources(pod, cfg corev1.ResourceList, name string) {
+	force := p.quota.isUpdated || p.quota.forcedResources[name]
 	podcpu := pod[corev1.ResourceCPU]
 	//Cmp returns -1 if the quantity is less than y (passed value) so if podcpu is less than cfg cpu
-	if p.quota.isUpdated || podcpu.Cmp(cfg[corev1.ResourceCPU]) == -1 || podcpu.IsZero() {
+	if force || podcpu.Cmp(cfg[corev1.ResourceCPU]) == -1 || podcpu.IsZero() {
 		quantity := cfg[corev1.ResourceCPU]
 		pod[corev1.ResourceCPU] = resource.MustParse(quantity.String())
 	}
 	podmem := pod[corev1.ResourceMemory]
 	//Cmp returns -1 if the quantity is less than y (passed value) so if podmem is less than cfg memory
-	if p.quota.isUpdated || podmem.Cmp(cfg[corev1.ResourceMemory]) == -1 || podmem.IsZero() {
+	if force || podmem.Cmp(cfg[corev1.ResourceMemory]) == -1 || podmem.IsZero() {
 		quantity := cfg[corev1.ResourceMemory]
 		pod[corev1.ResourceMemory] = resource.MustParse(quantity.String())
 	}
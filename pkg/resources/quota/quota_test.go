@@ -322,6 +322,102 @@ func TestGetQuota(t *testing.T) {
 	}
 }
 
+func TestGetQuotaWithCustomResources(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customQuota := v1alpha1.Quota{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom", Namespace: "test"},
+		Spec: v1alpha1.QuotaSpec{
+			DisplayName: "Custom",
+			Param:       DEVQUOTAPARAM,
+			RateLimit: v1alpha1.QuotaRateLimitSpec{
+				Unit:            "hour",
+				RequestsPerUnit: 500,
+			},
+			Resources: []v1alpha1.QuotaResourceSpec{
+				{
+					Name:     ApicastProductionName,
+					Replicas: 3,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		quotaParam   string
+		quotaCRs     []v1alpha1.Quota
+		wantErr      bool
+		wantName     string
+		wantReplicas int32
+		wantUnit     string
+	}{
+		{
+			name:       "falls back to the config map without a quota custom resource",
+			quotaParam: DEVQUOTAPARAM,
+			wantName:   OneHundredThousandQuotaName,
+			// the value of the config map
+			wantReplicas: 1,
+			wantUnit:     "minute",
+		},
+		{
+			name:         "the quota custom resource takes precedence over the config map",
+			quotaParam:   DEVQUOTAPARAM,
+			quotaCRs:     []v1alpha1.Quota{customQuota},
+			wantName:     "Custom",
+			wantReplicas: 3,
+			wantUnit:     "hour",
+		},
+		{
+			name:       "a new quota tier can be defined by a custom resource",
+			quotaParam: "custom",
+			quotaCRs: []v1alpha1.Quota{func() v1alpha1.Quota {
+				quotaCR := *customQuota.DeepCopy()
+				quotaCR.Spec.Param = "custom"
+				return quotaCR
+			}()},
+			wantName:     "Custom",
+			wantReplicas: 3,
+			wantUnit:     "hour",
+		},
+		{
+			name:       "error when neither the custom resources nor the config map define the param",
+			quotaParam: "QUOTA_NOT_PRESENT_QUOTA",
+			quotaCRs:   []v1alpha1.Quota{customQuota},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(buildTestInfra(configv1.AWSPlatformType)).Build()
+			got := &Quota{}
+			err := GetQuotaWithCustomResources(context.TODO(), c, tt.quotaParam, getQuotaConfig(nil), tt.quotaCRs, got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetQuotaWithCustomResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.GetName() != tt.wantName {
+				t.Errorf("expected quota name %s, got %s", tt.wantName, got.GetName())
+			}
+			product := got.GetProduct(v1alpha1.Product3Scale)
+			if replicas := product.GetReplicas(ApicastProductionName); replicas != tt.wantReplicas {
+				t.Errorf("expected %d apicast_production replicas, got %d", tt.wantReplicas, replicas)
+			}
+			if unit := product.GetRateLimitConfig().Unit; unit != tt.wantUnit {
+				t.Errorf("expected rate limit unit %s, got %s", tt.wantUnit, unit)
+			}
+		})
+	}
+}
+
 func TestProductConfig_Configure(t *testing.T) {
 	type fields struct {
 		productName     v1alpha1.ProductName
@@ -955,6 +1051,57 @@ func TestProductConfig_Configure(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "validate that overridden deployment grafana Resource Requests and Limits get updated when they are lower",
+			fields: fields{
+				productName: v1alpha1.ProductGrafana,
+				resourceConfigs: getResourceConfig(func(rcs map[string]ResourceConfig) {
+					rcs[GrafanaName] = ResourceConfig{
+						Replicas: int32(1),
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("0.1"),
+								corev1.ResourceMemory: resource.MustParse("100"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("0.2"),
+								corev1.ResourceMemory: resource.MustParse("200"),
+							},
+						},
+					}
+				}),
+				quota: &Quota{
+					forcedResources: map[string]bool{GrafanaName: true},
+				},
+			},
+			args: args{obj: getDeployment(GrafanaName, func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers = []corev1.Container{
+					{
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("0.5"),
+								corev1.ResourceMemory: resource.MustParse("500"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("1000"),
+							},
+						},
+					},
+				}
+			}),
+			},
+			validate: func(obj metav1.Object, r map[string]ResourceConfig, t *testing.T) {
+				resources := obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Resources
+				config := r[GrafanaName].Resources
+				if resources.Limits.Cpu().Cmp(*config.Limits.Cpu()) != 0 || resources.Limits.Memory().Cmp(*config.Limits.Memory()) != 0 {
+					t.Errorf("deployment limits not as expected, they should get updated when overridden, \n got = %v, \n want= %v ", resources.Limits, config.Limits)
+				}
+				if resources.Requests.Cpu().Cmp(*config.Requests.Cpu()) != 0 || resources.Requests.Memory().Cmp(*config.Requests.Memory()) != 0 {
+					t.Errorf("deployment requests not as expected, they should get updated when overridden, \n got = %v, \n want= %v ", resources.Requests, config.Requests)
+				}
+			},
+		},
 		{
 			name: "validate error returned on non deployment deploymentConfig or StatefulSet Object passed",
 			args: args{obj: &corev1.ConfigMap{}},
@@ -1085,3 +1232,70 @@ func buildTestInfra(platformType configv1.PlatformType) *configv1.Infrastructure
 		},
 	}
 }
+
+func TestQuota_ApplyResourceOverrides(t *testing.T) {
+	quota := &Quota{
+		productConfigs: map[v1alpha1.ProductName]QuotaProductConfig{
+			v1alpha1.Product3Scale: {
+				productName: v1alpha1.Product3Scale,
+				resourceConfigs: map[string]ResourceConfig{
+					ApicastProductionName: {
+						Replicas: 2,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("250m"),
+								corev1.ResourceMemory: resource.MustParse("250Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("500m"),
+								corev1.ResourceMemory: resource.MustParse("500Mi"),
+							},
+						},
+					},
+					BackendListenerName: {Replicas: 2},
+				},
+			},
+		},
+	}
+
+	quota.ApplyResourceOverrides([]v1alpha1.ResourceOverrideSpec{
+		{
+			Name: ApicastProductionName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+	}, []string{GrafanaName})
+
+	apicast, _ := quota.GetProduct(v1alpha1.Product3Scale).GetResourceConfig(ApicastProductionName)
+	want := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("250Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	for name, quantity := range want.Requests {
+		if got := apicast.Requests[name]; got.Cmp(quantity) != 0 {
+			t.Errorf("expected %s request %s, got %s", name, quantity.String(), got.String())
+		}
+	}
+	for name, quantity := range want.Limits {
+		if got := apicast.Limits[name]; got.Cmp(quantity) != 0 {
+			t.Errorf("expected %s limit %s, got %s", name, quantity.String(), got.String())
+		}
+	}
+	if replicas := quota.GetProduct(v1alpha1.Product3Scale).GetReplicas(ApicastProductionName); replicas != 2 {
+		t.Errorf("expected the replicas to be kept, got %d", replicas)
+	}
+
+	for name, want := range map[string]bool{ApicastProductionName: true, GrafanaName: true, BackendListenerName: false} {
+		if got := quota.forcedResources[name]; got != want {
+			t.Errorf("expected %s forced to be %v, got %v", name, want, got)
+		}
+	}
+}
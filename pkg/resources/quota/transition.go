@@ -0,0 +1,67 @@
+package quota
+
+import (
+	marin3rconfig "github.com/integr8ly/integreatly-operator/pkg/products/marin3r/config"
+)
+
+var secondsPerUnit = map[string]uint64{
+	"second": 1,
+	"minute": 60,
+	"hour":   60 * 60,
+	"day":    24 * 60 * 60,
+}
+
+// StepFrom sets the replicas and the rate limit of the quota to the ones of
+// the step of a transition from another quota. The values move linearly from
+// the ones of the other quota, and are the ones of the quota on the last step
+func (s *Quota) StepFrom(from *Quota, step, steps int32) {
+	if step >= steps {
+		s.transitioning = false
+		return
+	}
+	s.transitioning = true
+
+	for productName, pc := range s.productConfigs {
+		fromPC := from.productConfigs[productName]
+		for name, config := range pc.resourceConfigs {
+			config.Replicas = stepReplicas(fromPC.resourceConfigs[name].Replicas, config.Replicas, step, steps)
+			pc.resourceConfigs[name] = config
+		}
+	}
+	s.rateLimitConfig = stepRateLimit(from.rateLimitConfig, s.rateLimitConfig, step, steps)
+}
+
+// IsTransitioning returns true while an intermediate step of a transition to
+// the quota is applied, so the quota isn't reported as the installation quota
+func (s *Quota) IsTransitioning() bool {
+	return s.transitioning
+}
+
+func stepReplicas(from, to, step, steps int32) int32 {
+	// the workloads without replicas in the quota keep their current ones
+	if from == 0 || to == 0 {
+		return to
+	}
+	return from + (to-from)*step/steps
+}
+
+func stepRateLimit(from, to marin3rconfig.RateLimitConfig, step, steps int32) marin3rconfig.RateLimitConfig {
+	fromSeconds, fromOK := secondsPerUnit[from.Unit]
+	toSeconds, toOK := secondsPerUnit[to.Unit]
+	if !fromOK || !toOK || from.RequestsPerUnit == 0 {
+		return to
+	}
+
+	// express the limit of the other quota in the unit of the quota
+	fromRequests := int64(uint64(from.RequestsPerUnit) * toSeconds / fromSeconds)
+	toRequests := int64(to.RequestsPerUnit)
+	requests := fromRequests + (toRequests-fromRequests)*int64(step)/int64(steps)
+	if requests < 1 {
+		requests = 1
+	}
+
+	return marin3rconfig.RateLimitConfig{
+		Unit:            to.Unit,
+		RequestsPerUnit: uint32(requests),
+	}
+}
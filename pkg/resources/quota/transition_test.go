@@ -0,0 +1,96 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	marin3rconfig "github.com/integr8ly/integreatly-operator/pkg/products/marin3r/config"
+)
+
+func TestQuota_StepFrom(t *testing.T) {
+	newQuota := func(replicas int32, rateLimit marin3rconfig.RateLimitConfig) *Quota {
+		return &Quota{
+			productConfigs: map[v1alpha1.ProductName]QuotaProductConfig{
+				v1alpha1.Product3Scale: {
+					productName: v1alpha1.Product3Scale,
+					resourceConfigs: map[string]ResourceConfig{
+						BackendListenerName: {Replicas: replicas},
+						ApicastStagingName:  {},
+					},
+				},
+			},
+			rateLimitConfig: rateLimit,
+		}
+	}
+
+	tests := []struct {
+		name              string
+		from              *Quota
+		to                *Quota
+		step              int32
+		steps             int32
+		wantReplicas      int32
+		wantRateLimit     marin3rconfig.RateLimitConfig
+		wantTransitioning bool
+	}{
+		{
+			name:              "first step of a scale up",
+			from:              newQuota(2, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 100}),
+			to:                newQuota(10, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 500}),
+			step:              1,
+			steps:             4,
+			wantReplicas:      4,
+			wantRateLimit:     marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 200},
+			wantTransitioning: true,
+		},
+		{
+			name:              "intermediate step of a scale down",
+			from:              newQuota(10, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 500}),
+			to:                newQuota(2, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 100}),
+			step:              2,
+			steps:             4,
+			wantReplicas:      6,
+			wantRateLimit:     marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 300},
+			wantTransitioning: true,
+		},
+		{
+			name:              "the rate limit of the other quota is converted to the unit of the quota",
+			from:              newQuota(2, marin3rconfig.RateLimitConfig{Unit: "second", RequestsPerUnit: 1}),
+			to:                newQuota(2, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 180}),
+			step:              1,
+			steps:             2,
+			wantReplicas:      2,
+			wantRateLimit:     marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 120},
+			wantTransitioning: true,
+		},
+		{
+			name:              "the last step applies the quota",
+			from:              newQuota(2, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 100}),
+			to:                newQuota(10, marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 500}),
+			step:              4,
+			steps:             4,
+			wantReplicas:      10,
+			wantRateLimit:     marin3rconfig.RateLimitConfig{Unit: "minute", RequestsPerUnit: 500},
+			wantTransitioning: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.to.StepFrom(tt.from, tt.step, tt.steps)
+
+			product := tt.to.GetProduct(v1alpha1.Product3Scale)
+			if replicas := product.GetReplicas(BackendListenerName); replicas != tt.wantReplicas {
+				t.Errorf("expected %d backend_listener replicas, got %d", tt.wantReplicas, replicas)
+			}
+			if replicas := product.GetReplicas(ApicastStagingName); replicas != 0 {
+				t.Errorf("expected the apicast_staging replicas to be left unset, got %d", replicas)
+			}
+			if rateLimit := tt.to.GetRateLimitConfig(); rateLimit != tt.wantRateLimit {
+				t.Errorf("expected rate limit %v, got %v", tt.wantRateLimit, rateLimit)
+			}
+			if tt.to.IsTransitioning() != tt.wantTransitioning {
+				t.Errorf("expected transitioning to be %t", tt.wantTransitioning)
+			}
+		})
+	}
+}
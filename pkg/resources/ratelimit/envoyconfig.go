@@ -2,6 +2,9 @@ package ratelimit
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -34,6 +37,10 @@ const (
 	RateLimitDomain          = "apicast-ratelimit"
 	RateLimitDescriptorValue = "slowpath"
 	TransportSocketName      = "envoy.transport_sockets.tls"
+
+	// EnvoyResourcesHashAnnotation holds the hash of the envoy resources of
+	// an EnvoyConfig
+	EnvoyResourcesHashAnnotation = "integreatly.org/envoy-resources-hash"
 )
 
 func DeleteEnvoyConfigsInNamespaces(ctx context.Context, client k8sclient.Client, namespaces ...string) (integreatlyv1alpha1.StatusPhase, error) {
@@ -106,6 +113,15 @@ func NewEnvoyConfig(name, namespace, nodeID string) *EnvoyConfig {
 *
 */
 func (ec *EnvoyConfig) CreateEnvoyConfig(ctx context.Context, client k8sclient.Client, clusterResources []*envoyclusterv3.Cluster, listenerResources []*envoylistenerv3.Listener, runtimes *envoy_runtime.Runtime, installation *integreatlyv1alpha1.RHMI) error {
+	envoyResources, err := NewEnvoyResources(clusterResources, listenerResources, runtimes)
+	if err != nil {
+		return err
+	}
+	hash, err := EnvoyResourcesHash(envoyResources)
+	if err != nil {
+		return err
+	}
+
 	envoyconfig := &marin3rv1alpha1.EnvoyConfig{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ec.name,
@@ -113,16 +129,38 @@ func (ec *EnvoyConfig) CreateEnvoyConfig(ctx context.Context, client k8sclient.C
 		},
 	}
 
+	_, err = controllerutil.CreateOrUpdate(ctx, client, envoyconfig, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(envoyconfig, installation)
+		if envoyconfig.Annotations == nil {
+			envoyconfig.Annotations = map[string]string{}
+		}
+		envoyconfig.Annotations[EnvoyResourcesHashAnnotation] = hash
+		serialization := envoyserializer.YAML
+		envoyAPIVersion := envoy.APIv3
+		envoyconfig.Spec.NodeID = ec.nodeID
+		envoyconfig.Spec.EnvoyAPI = &envoyAPIVersion
+		envoyconfig.Spec.Serialization = &serialization
+		envoyconfig.Spec.EnvoyResources = envoyResources
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create envoy config CR %v", err)
+	}
+	return nil
+}
+
+// NewEnvoyResources returns the YAML serialized resources of an EnvoyConfig
+func NewEnvoyResources(clusterResources []*envoyclusterv3.Cluster, listenerResources []*envoylistenerv3.Listener, runtimes *envoy_runtime.Runtime) (*marin3rv1alpha1.EnvoyResources, error) {
 	envoyClusterResource := []marin3rv1alpha1.EnvoyResource{}
 	for _, cluster := range clusterResources {
 		jsonClusterResource, err := ResourcesToJSON(cluster)
 		if err != nil {
-			return fmt.Errorf("failed to convert envoy rate limiting cluster configuration to JSON %v", err)
+			return nil, fmt.Errorf("failed to convert envoy rate limiting cluster configuration to JSON %v", err)
 		}
 
 		yamlClusterResource, err := yaml.JSONToYAML(jsonClusterResource)
 		if err != nil {
-			return fmt.Errorf("failed to convert envoy rate limiting cluster JSON configuration to YAML %v", err)
+			return nil, fmt.Errorf("failed to convert envoy rate limiting cluster JSON configuration to YAML %v", err)
 		}
 		envoyClusterResource = append(envoyClusterResource,
 			marin3rv1alpha1.EnvoyResource{
@@ -136,12 +174,12 @@ func (ec *EnvoyConfig) CreateEnvoyConfig(ctx context.Context, client k8sclient.C
 	for _, listener := range listenerResources {
 		jsonListenerResource, err := ResourcesToJSON(listener)
 		if err != nil {
-			return fmt.Errorf("failed to convert envoy rate limiting listeners configuration to JSON %v", err)
+			return nil, fmt.Errorf("failed to convert envoy rate limiting listeners configuration to JSON %v", err)
 		}
 
 		yamlListenerResource, err := yaml.JSONToYAML(jsonListenerResource)
 		if err != nil {
-			return fmt.Errorf("failed to convert envoy rate limiting listener JSON configuration to YAML %v", err)
+			return nil, fmt.Errorf("failed to convert envoy rate limiting listener JSON configuration to YAML %v", err)
 		}
 		envoyListenerResource = append(envoyListenerResource,
 			marin3rv1alpha1.EnvoyResource{
@@ -154,12 +192,12 @@ func (ec *EnvoyConfig) CreateEnvoyConfig(ctx context.Context, client k8sclient.C
 	envoyRuntimeResource := []marin3rv1alpha1.EnvoyResource{}
 	jsonRuntimeResource, err := ResourcesToJSON(runtimes)
 	if err != nil {
-		return fmt.Errorf("failed to convert envoy rate limiting runtimes configuration to JSON %v", err)
+		return nil, fmt.Errorf("failed to convert envoy rate limiting runtimes configuration to JSON %v", err)
 	}
 
 	yamlRuntimeResource, err := yaml.JSONToYAML(jsonRuntimeResource)
 	if err != nil {
-		return fmt.Errorf("failed to convert envoy rate limiting runtimes JSON configuration to YAML %v", err)
+		return nil, fmt.Errorf("failed to convert envoy rate limiting runtimes JSON configuration to YAML %v", err)
 	}
 
 	envoyRuntimeResource = append(envoyRuntimeResource, marin3rv1alpha1.EnvoyResource{
@@ -167,24 +205,22 @@ func (ec *EnvoyConfig) CreateEnvoyConfig(ctx context.Context, client k8sclient.C
 		Value: string(yamlRuntimeResource),
 	})
 
-	_, err = controllerutil.CreateOrUpdate(ctx, client, envoyconfig, func() error {
-		owner.AddIntegreatlyOwnerAnnotations(envoyconfig, installation)
-		serialization := envoyserializer.YAML
-		envoyAPIVersion := envoy.APIv3
-		envoyconfig.Spec.NodeID = ec.nodeID
-		envoyconfig.Spec.EnvoyAPI = &envoyAPIVersion
-		envoyconfig.Spec.Serialization = &serialization
-		envoyconfig.Spec.EnvoyResources = &marin3rv1alpha1.EnvoyResources{
-			Clusters:  envoyClusterResource,
-			Listeners: envoyListenerResource,
-			Runtimes:  envoyRuntimeResource,
-		}
-		return nil
-	})
+	return &marin3rv1alpha1.EnvoyResources{
+		Clusters:  envoyClusterResource,
+		Listeners: envoyListenerResource,
+		Runtimes:  envoyRuntimeResource,
+	}, nil
+}
+
+// EnvoyResourcesHash returns a short hash identifying the envoy resources, it's
+// stored in the EnvoyResourcesHashAnnotation of the EnvoyConfigs
+func EnvoyResourcesHash(envoyResources *marin3rv1alpha1.EnvoyResources) (string, error) {
+	serialized, err := json.Marshal(envoyResources)
 	if err != nil {
-		return fmt.Errorf("failed to create envoy config CR %v", err)
+		return "", fmt.Errorf("failed to serialize envoy resources: %w", err)
 	}
-	return nil
+	sum := sha256.Sum256(serialized)
+	return hex.EncodeToString(sum[:])[:16], nil
 }
 
 /*
@@ -340,9 +376,10 @@ func CreateTypedExtensionProtocol() (*anypb.Any, error) {
 
 *
 */
-func CreateApicastTransportSocketConfig() (*anypb.Any, error) {
+func CreateApicastTransportSocketConfig(tlsParams *transport_sockets.TlsParameters) (*anypb.Any, error) {
 	serial, err := anypb.New(&transport_sockets.UpstreamTlsContext{
 		CommonTlsContext: &transport_sockets.CommonTlsContext{
+			TlsParams: tlsParams,
 			ValidationContextType: &transport_sockets.CommonTlsContext_ValidationContext{
 				ValidationContext: &transport_sockets.CertificateValidationContext{
 					TrustChainVerification: transport_sockets.CertificateValidationContext_ACCEPT_UNTRUSTED,
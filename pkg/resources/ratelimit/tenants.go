@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	userHelper "github.com/integr8ly/integreatly-operator/pkg/resources/user"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TenantDescriptorValue is the descriptor of the requests to the gateways
+// of the tenants overriding the rate limit per tenant
+const TenantDescriptorValue = "per-tenant-limit"
+
+// TenantRateLimit is the rate limit override of a tenant, the tenant is the
+// value of the tenant header of its requests
+type TenantRateLimit struct {
+	Tenant string
+	integreatlyv1alpha1.TenantRateLimitSpec
+}
+
+// GetTenantRateLimits returns the rate limit overrides of the provisioned
+// tenants, sorted by tenant
+func GetTenantRateLimits(ctx context.Context, client k8sclient.Client) ([]TenantRateLimit, error) {
+	tenants := &integreatlyv1alpha1.APIManagementTenantList{}
+	if err := client.List(ctx, tenants); err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var rateLimits []TenantRateLimit
+	for _, tenant := range tenants.Items {
+		if tenant.Spec.RateLimit == nil || tenant.Status.Phase == "" || tenant.Status.Phase == integreatlyv1alpha1.TenantPhaseDeprovisioning {
+			continue
+		}
+		tenantName, err := userHelper.SanitiseTenantUserName(tenant.GetUsername())
+		if err != nil {
+			return nil, err
+		}
+		rateLimits = append(rateLimits, TenantRateLimit{
+			Tenant:              tenantName,
+			TenantRateLimitSpec: *tenant.Spec.RateLimit,
+		})
+	}
+
+	sort.Slice(rateLimits, func(i, j int) bool {
+		return rateLimits[i].Tenant < rateLimits[j].Tenant
+	})
+	return rateLimits, nil
+}
@@ -0,0 +1,69 @@
+package realmexport
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/backup"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ArtifactKind is the kind of the artifacts of the BackupExecutor
+const ArtifactKind = "RealmExport"
+
+// BackupExecutor exports the realms of a keycloak instance to the bucket of
+// the realm export spec, outside of the export interval
+type BackupExecutor struct {
+	Spec                  *integreatlyv1alpha1.RealmExportSpec
+	KeycloakName          string
+	Namespace             string // Namespace of the keycloak instance
+	InstallationNamespace string // Namespace of the storage credentials secret
+}
+
+func NewBackupExecutor(spec *integreatlyv1alpha1.RealmExportSpec, keycloakName, namespace, installationNamespace string) backup.BackupExecutor {
+	return &BackupExecutor{
+		Spec:                  spec,
+		KeycloakName:          keycloakName,
+		Namespace:             namespace,
+		InstallationNamespace: installationNamespace,
+	}
+}
+
+// PerformBackup exports the realms of the keycloak instance under an export
+// ID of the current time, which can be selected for an import
+func (e *BackupExecutor) PerformBackup(client k8sclient.Client, timeout time.Duration) ([]backup.Artifact, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	kc := &keycloak.Keycloak{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: e.KeycloakName, Namespace: e.Namespace}, kc); err != nil {
+		return nil, fmt.Errorf("failed to get keycloak %s: %w", e.KeycloakName, err)
+	}
+	admin, err := NewKeycloakAdmin(ctx, client, kc)
+	if err != nil {
+		return nil, err
+	}
+	store, err := objectstore.NewS3ObjectStore(ctx, client, e.InstallationNamespace, &e.Spec.Storage)
+	if err != nil {
+		return nil, err
+	}
+	return exportBackup(ctx, admin, store, kc, time.Now())
+}
+
+func exportBackup(ctx context.Context, admin KeycloakAdmin, store objectstore.ObjectStore, kc *keycloak.Keycloak, now time.Time) ([]backup.Artifact, error) {
+	exportID := now.UTC().Format(exportIDFormat)
+	if err := exportRealms(ctx, admin, store, kc.Name, exportID); err != nil {
+		return nil, fmt.Errorf("failed to export the realms of keycloak %s: %w", kc.Name, err)
+	}
+	return []backup.Artifact{{
+		Kind:        ArtifactKind,
+		Namespace:   kc.Namespace,
+		Name:        path.Join(KeyPrefix, kc.Name, exportID),
+		CompletedAt: time.Now(),
+	}}, nil
+}
@@ -0,0 +1,194 @@
+package realmexport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	model "github.com/integr8ly/keycloak-client/pkg"
+	corev1 "k8s.io/api/core/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maskedSecret replaces the secrets of the clients, identity providers and
+// user federation providers in partial exports
+const maskedSecret = "**********"
+
+// KeycloakAdmin is the part of the keycloak admin API used to export and
+// import realms, the keycloak client doesn't support partial exports
+type KeycloakAdmin interface {
+	ListRealms() ([]string, error)
+	// PartialExport returns the realm with its clients, groups and roles but
+	// without users. Secrets are masked
+	PartialExport(realm string) ([]byte, error)
+	// PartialImport imports the clients, groups, roles and identity providers
+	// of an export into an existing realm
+	PartialImport(realm string, export []byte, ifResourceExists string) error
+	// CreateRealm creates the realm of an export
+	CreateRealm(export []byte) error
+}
+
+type keycloakAdmin struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+// NewKeycloakAdmin logs in to the admin API of the keycloak instance with the
+// admin credentials created by the keycloak operator
+func NewKeycloakAdmin(ctx context.Context, serverClient k8sclient.Client, kc *keycloak.Keycloak) (KeycloakAdmin, error) {
+	adminCreds := &corev1.Secret{}
+	if err := serverClient.Get(ctx, k8sclient.ObjectKey{Name: kc.Status.CredentialSecret, Namespace: kc.Namespace}, adminCreds); err != nil {
+		return nil, fmt.Errorf("failed to get the admin credentials of keycloak %s: %w", kc.Name, err)
+	}
+
+	admin := &keycloakAdmin{
+		url: strings.TrimSuffix(kc.Status.ExternalURL, "/"),
+		http: &http.Client{
+			// same as the keycloak client, the route may be signed by the
+			// cluster's ingress CA
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, // nolint
+			Timeout:   time.Minute,
+		},
+	}
+
+	form := url.Values{}
+	form.Add("username", string(adminCreds.Data[model.AdminUsernameProperty]))
+	form.Add("password", string(adminCreds.Data[model.AdminPasswordProperty]))
+	form.Add("client_id", "admin-cli")
+	form.Add("grant_type", "password")
+	res, err := admin.http.PostForm(admin.url+"/auth/realms/master/protocol/openid-connect/token", form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in to keycloak %s: %w", kc.Name, err)
+	}
+	defer res.Body.Close()
+	token := &keycloak.TokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to decode the token of keycloak %s: %w", kc.Name, err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("failed to log in to keycloak %s: %s %s", kc.Name, token.Error, token.ErrorDescription)
+	}
+	admin.token = token.AccessToken
+
+	return admin, nil
+}
+
+func (k *keycloakAdmin) ListRealms() ([]string, error) {
+	body, err := k.do(http.MethodGet, "/auth/admin/realms", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list realms: %w", err)
+	}
+	var realms []struct {
+		Realm string `json:"realm"`
+	}
+	if err := json.Unmarshal(body, &realms); err != nil {
+		return nil, fmt.Errorf("failed to decode realms: %w", err)
+	}
+	names := make([]string, 0, len(realms))
+	for _, realm := range realms {
+		names = append(names, realm.Realm)
+	}
+	return names, nil
+}
+
+func (k *keycloakAdmin) PartialExport(realm string) ([]byte, error) {
+	body, err := k.do(http.MethodPost, fmt.Sprintf("/auth/admin/realms/%s/partial-export?exportClients=true&exportGroupsAndRoles=true", url.PathEscape(realm)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export realm %s: %w", realm, err)
+	}
+	return body, nil
+}
+
+func (k *keycloakAdmin) PartialImport(realm string, export []byte, ifResourceExists string) error {
+	representation, err := unmaskedRepresentation(export)
+	if err != nil {
+		return err
+	}
+	representation["ifResourceExists"] = ifResourceExists
+	body, err := json.Marshal(representation)
+	if err != nil {
+		return err
+	}
+	if _, err := k.do(http.MethodPost, fmt.Sprintf("/auth/admin/realms/%s/partialImport", url.PathEscape(realm)), body); err != nil {
+		return fmt.Errorf("failed to import realm %s: %w", realm, err)
+	}
+	return nil
+}
+
+func (k *keycloakAdmin) CreateRealm(export []byte) error {
+	representation, err := unmaskedRepresentation(export)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(representation)
+	if err != nil {
+		return err
+	}
+	if _, err := k.do(http.MethodPost, "/auth/admin/realms", body); err != nil {
+		return fmt.Errorf("failed to create realm %v: %w", representation["realm"], err)
+	}
+	return nil
+}
+
+func (k *keycloakAdmin) do(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, k.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := k.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %d: %s", res.StatusCode, resBody)
+	}
+	return resBody, nil
+}
+
+// unmaskedRepresentation decodes an export without its masked secrets, so
+// keycloak generates new client secrets instead of setting them to the mask.
+// The secrets of identity providers and user federation providers have to be
+// set again after the import
+func unmaskedRepresentation(export []byte) (map[string]interface{}, error) {
+	representation := map[string]interface{}{}
+	if err := json.Unmarshal(export, &representation); err != nil {
+		return nil, fmt.Errorf("failed to decode realm export: %w", err)
+	}
+	removeMaskedSecrets(representation)
+	return representation, nil
+}
+
+func removeMaskedSecrets(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			if field == maskedSecret {
+				delete(typed, key)
+				continue
+			}
+			removeMaskedSecrets(field)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			removeMaskedSecrets(item)
+		}
+	}
+}
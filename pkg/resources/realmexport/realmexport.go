@@ -0,0 +1,151 @@
+package realmexport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KeyPrefix is the prefix of the exports in the bucket, each export is
+	// written to <prefix>/<keycloak>/<export id>/
+	KeyPrefix = "realm-exports"
+	// ImportedAnnotation records the last export imported into a keycloak
+	// instance, so it's only imported once
+	ImportedAnnotation = "integreatly.org/imported-realm-export"
+
+	DefaultInterval = 24 * time.Hour
+	exportIDFormat  = "20060102T150405Z"
+	manifestName    = "manifest.json"
+)
+
+// manifest is written after the realms of an export, an export without a
+// manifest is incomplete
+type manifest struct {
+	Realms []string `json:"realms"`
+}
+
+// Reconcile imports the export selected in the spec into the keycloak
+// instance once, and exports its realms once per interval. The export ID is
+// the start of the interval so a failed export is retried until the next one
+func Reconcile(ctx context.Context, serverClient k8sclient.Client, spec *integreatlyv1alpha1.RealmExportSpec, kc *keycloak.Keycloak, admin KeycloakAdmin, store objectstore.ObjectStore, now time.Time, log l.Logger) error {
+	if spec.Import != nil && kc.Annotations[ImportedAnnotation] != spec.Import.ExportID {
+		if err := importRealms(ctx, admin, store, kc.Name, spec.Import); err != nil {
+			return err
+		}
+		if kc.Annotations == nil {
+			kc.Annotations = map[string]string{}
+		}
+		kc.Annotations[ImportedAnnotation] = spec.Import.ExportID
+		if err := serverClient.Update(ctx, kc); err != nil {
+			return fmt.Errorf("failed to record the realm import of keycloak %s: %w", kc.Name, err)
+		}
+		log.Infof("Imported realm export", l.Fields{"keycloak": kc.Name, "exportID": spec.Import.ExportID})
+	}
+
+	interval := DefaultInterval
+	if spec.Interval != nil {
+		interval = spec.Interval.Duration
+	}
+	if interval < time.Hour {
+		return fmt.Errorf("realm export interval %s is under 1h", interval)
+	}
+	exportID := now.UTC().Truncate(interval).Format(exportIDFormat)
+
+	_, err := store.Get(ctx, manifestKey(kc.Name, exportID))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, objectstore.ErrObjectNotFound) {
+		return err
+	}
+	if err := exportRealms(ctx, admin, store, kc.Name, exportID); err != nil {
+		return err
+	}
+	log.Infof("Exported realms", l.Fields{"keycloak": kc.Name, "exportID": exportID})
+	return nil
+}
+
+func exportRealms(ctx context.Context, admin KeycloakAdmin, store objectstore.ObjectStore, keycloakName, exportID string) error {
+	realms, err := admin.ListRealms()
+	if err != nil {
+		return err
+	}
+	for _, realm := range realms {
+		export, err := admin.PartialExport(realm)
+		if err != nil {
+			return err
+		}
+		if err := store.Put(ctx, realmKey(keycloakName, exportID, realm), export); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(manifest{Realms: realms})
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, manifestKey(keycloakName, exportID), data)
+}
+
+// importRealms creates the realms of the export that don't exist, the other
+// realms are partially imported
+func importRealms(ctx context.Context, admin KeycloakAdmin, store objectstore.ObjectStore, keycloakName string, spec *integreatlyv1alpha1.RealmImportSpec) error {
+	data, err := store.Get(ctx, manifestKey(keycloakName, spec.ExportID))
+	if errors.Is(err, objectstore.ErrObjectNotFound) {
+		return fmt.Errorf("realm export %s of keycloak %s not found", spec.ExportID, keycloakName)
+	}
+	if err != nil {
+		return err
+	}
+	exported := manifest{}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return fmt.Errorf("failed to decode manifest of realm export %s: %w", spec.ExportID, err)
+	}
+
+	existing, err := admin.ListRealms()
+	if err != nil {
+		return err
+	}
+	existingRealms := map[string]bool{}
+	for _, realm := range existing {
+		existingRealms[realm] = true
+	}
+
+	ifResourceExists := "SKIP"
+	if spec.IfResourceExists == integreatlyv1alpha1.RealmImportOverwrite {
+		ifResourceExists = "OVERWRITE"
+	}
+	for _, realm := range exported.Realms {
+		export, err := store.Get(ctx, realmKey(keycloakName, spec.ExportID, realm))
+		if err != nil {
+			return fmt.Errorf("failed to get realm %s of export %s: %w", realm, spec.ExportID, err)
+		}
+		if existingRealms[realm] {
+			err = admin.PartialImport(realm, export, ifResourceExists)
+		} else {
+			err = admin.CreateRealm(export)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func realmKey(keycloakName, exportID, realm string) string {
+	return path.Join(KeyPrefix, keycloakName, exportID, realm+".json")
+}
+
+func manifestKey(keycloakName, exportID string) string {
+	return path.Join(KeyPrefix, keycloakName, exportID, manifestName)
+}
@@ -0,0 +1,239 @@
+package realmexport
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/objectstore"
+	"github.com/integr8ly/integreatly-operator/utils"
+	keycloak "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type fakeKeycloakAdmin struct {
+	realms          []string
+	partialImported map[string]string
+	created         []string
+}
+
+func (f *fakeKeycloakAdmin) ListRealms() ([]string, error) {
+	return f.realms, nil
+}
+
+func (f *fakeKeycloakAdmin) PartialExport(realm string) ([]byte, error) {
+	return json.Marshal(map[string]string{"realm": realm})
+}
+
+func (f *fakeKeycloakAdmin) PartialImport(realm string, _ []byte, ifResourceExists string) error {
+	f.partialImported[realm] = ifResourceExists
+	return nil
+}
+
+func (f *fakeKeycloakAdmin) CreateRealm(export []byte) error {
+	representation := map[string]string{}
+	if err := json.Unmarshal(export, &representation); err != nil {
+		return err
+	}
+	f.created = append(f.created, representation["realm"])
+	return nil
+}
+
+type fakeObjectStore map[string][]byte
+
+func (f fakeObjectStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, ok := f[key]
+	if !ok {
+		return nil, objectstore.ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (f fakeObjectStore) Put(_ context.Context, key string, data []byte) error {
+	f[key] = data
+	return nil
+}
+
+func (f fakeObjectStore) keys() []string {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestReconcile(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 5, 17, 30, 0, 0, time.UTC)
+	previousExport := fakeObjectStore{
+		"realm-exports/rhssouser/20240304T000000Z/master.json":   []byte(`{"realm": "master"}`),
+		"realm-exports/rhssouser/20240304T000000Z/customer.json": []byte(`{"realm": "customer"}`),
+		"realm-exports/rhssouser/20240304T000000Z/manifest.json": []byte(`{"realms": ["master", "customer"]}`),
+	}
+
+	tests := []struct {
+		name                string
+		spec                *integreatlyv1alpha1.RealmExportSpec
+		annotations         map[string]string
+		store               fakeObjectStore
+		wantErr             bool
+		wantKeys            []string
+		wantPartialImported map[string]string
+		wantCreated         []string
+		wantAnnotation      string
+	}{
+		{
+			name:  "test realms are exported at the start of the interval",
+			spec:  &integreatlyv1alpha1.RealmExportSpec{},
+			store: fakeObjectStore{},
+			wantKeys: []string{
+				"realm-exports/rhssouser/20240305T000000Z/manifest.json",
+				"realm-exports/rhssouser/20240305T000000Z/master.json",
+			},
+			wantPartialImported: map[string]string{},
+		},
+		{
+			name:  "test realms are exported for each interval",
+			spec:  &integreatlyv1alpha1.RealmExportSpec{Interval: &metav1.Duration{Duration: 6 * time.Hour}},
+			store: fakeObjectStore{"realm-exports/rhssouser/20240305T000000Z/manifest.json": []byte(`{"realms": []}`)},
+			wantKeys: []string{
+				"realm-exports/rhssouser/20240305T000000Z/manifest.json",
+				"realm-exports/rhssouser/20240305T120000Z/manifest.json",
+				"realm-exports/rhssouser/20240305T120000Z/master.json",
+			},
+			wantPartialImported: map[string]string{},
+		},
+		{
+			name:                "test completed export isn't repeated",
+			spec:                &integreatlyv1alpha1.RealmExportSpec{},
+			store:               fakeObjectStore{"realm-exports/rhssouser/20240305T000000Z/manifest.json": []byte(`{"realms": []}`)},
+			wantKeys:            []string{"realm-exports/rhssouser/20240305T000000Z/manifest.json"},
+			wantPartialImported: map[string]string{},
+		},
+		{
+			name:    "test interval under 1h fails",
+			spec:    &integreatlyv1alpha1.RealmExportSpec{Interval: &metav1.Duration{Duration: time.Minute}},
+			store:   fakeObjectStore{},
+			wantErr: true,
+		},
+		{
+			name: "test export is imported",
+			spec: &integreatlyv1alpha1.RealmExportSpec{
+				Import: &integreatlyv1alpha1.RealmImportSpec{ExportID: "20240304T000000Z", IfResourceExists: integreatlyv1alpha1.RealmImportOverwrite},
+			},
+			store:               previousExport,
+			wantPartialImported: map[string]string{"master": "OVERWRITE"},
+			wantCreated:         []string{"customer"},
+			wantAnnotation:      "20240304T000000Z",
+		},
+		{
+			name: "test export is only imported once",
+			spec: &integreatlyv1alpha1.RealmExportSpec{
+				Import: &integreatlyv1alpha1.RealmImportSpec{ExportID: "20240304T000000Z"},
+			},
+			annotations:         map[string]string{ImportedAnnotation: "20240304T000000Z"},
+			store:               previousExport,
+			wantPartialImported: map[string]string{},
+			wantAnnotation:      "20240304T000000Z",
+		},
+		{
+			name: "test importing a missing export fails",
+			spec: &integreatlyv1alpha1.RealmExportSpec{
+				Import: &integreatlyv1alpha1.RealmImportSpec{ExportID: "20240101T000000Z"},
+			},
+			store:   fakeObjectStore{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := fakeObjectStore{}
+			for key, data := range tt.store {
+				store[key] = data
+			}
+			kc := &keycloak.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "rhssouser", Namespace: "user-sso", Annotations: tt.annotations}}
+			serverClient := utils.NewTestClient(scheme, kc)
+			admin := &fakeKeycloakAdmin{realms: []string{"master"}, partialImported: map[string]string{}}
+
+			err := Reconcile(context.TODO(), serverClient, tt.spec, kc, admin, store, now, l.NewLogger())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.wantKeys != nil && !reflect.DeepEqual(store.keys(), tt.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tt.wantKeys, store.keys())
+			}
+			if !reflect.DeepEqual(admin.partialImported, tt.wantPartialImported) {
+				t.Errorf("expected partial imports %v, got %v", tt.wantPartialImported, admin.partialImported)
+			}
+			if !reflect.DeepEqual(admin.created, tt.wantCreated) {
+				t.Errorf("expected created realms %v, got %v", tt.wantCreated, admin.created)
+			}
+
+			updated := &keycloak.Keycloak{}
+			if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(kc), updated); err != nil {
+				t.Fatal(err)
+			}
+			if updated.Annotations[ImportedAnnotation] != tt.wantAnnotation {
+				t.Errorf("expected imported annotation %q, got %q", tt.wantAnnotation, updated.Annotations[ImportedAnnotation])
+			}
+		})
+	}
+}
+
+func TestUnmaskedRepresentation(t *testing.T) {
+	export := []byte(`{
+		"realm": "customer",
+		"clients": [{"clientId": "app", "secret": "**********", "publicClient": false}],
+		"identityProviders": [{"alias": "github", "config": {"clientId": "id", "clientSecret": "**********"}}]
+	}`)
+
+	got, err := unmaskedRepresentation(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"realm":             "customer",
+		"clients":           []interface{}{map[string]interface{}{"clientId": "app", "publicClient": false}},
+		"identityProviders": []interface{}{map[string]interface{}{"alias": "github", "config": map[string]interface{}{"clientId": "id"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unmaskedRepresentation() got = %v, want %v", got, want)
+	}
+}
+
+func TestExportBackup(t *testing.T) {
+	now := time.Date(2024, 3, 5, 17, 30, 0, 0, time.UTC)
+	kc := &keycloak.Keycloak{ObjectMeta: metav1.ObjectMeta{Name: "rhssouser", Namespace: "rhssouser-ns"}}
+	store := fakeObjectStore{}
+
+	artifacts, err := exportBackup(context.TODO(), &fakeKeycloakAdmin{realms: []string{"master"}}, store, kc, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantKeys := []string{
+		"realm-exports/rhssouser/20240305T173000Z/manifest.json",
+		"realm-exports/rhssouser/20240305T173000Z/master.json",
+	}
+	if !reflect.DeepEqual(store.keys(), wantKeys) {
+		t.Errorf("expected keys %v, got %v", wantKeys, store.keys())
+	}
+	if len(artifacts) != 1 || artifacts[0].Kind != ArtifactKind || artifacts[0].Namespace != "rhssouser-ns" ||
+		artifacts[0].Name != "realm-exports/rhssouser/20240305T173000Z" {
+		t.Errorf("unexpected artifacts %+v", artifacts)
+	}
+}
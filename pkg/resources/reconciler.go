@@ -6,6 +6,7 @@ import (
 	"fmt"
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
 	"github.com/integr8ly/integreatly-operator/pkg/resources/k8s"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
 	projectv1 "github.com/openshift/api/project/v1"
 	k8sappsv1 "k8s.io/api/apps/v1"
 
@@ -17,6 +18,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -109,6 +111,7 @@ func CreateNSWithProjectRequest(ctx context.Context, namespace string, client k8
 	}
 
 	PrepareObjectLabels(ns, inst, addRHMIMonitoringLabels, addClusterMonitoringLabel, disableUserAlerting)
+	podsecurity.PrepareNamespaceLabels(ns, inst)
 
 	if err := client.Update(ctx, ns); err != nil {
 		return nil, fmt.Errorf("failed to update the %s namespace definition: %v", ns.Name, err)
@@ -142,6 +145,7 @@ func (r *Reconciler) ReconcileNamespace(ctx context.Context, namespace string, i
 	}
 
 	PrepareObjectLabels(ns, inst, true, false, true)
+	podsecurity.PrepareNamespaceLabels(ns, inst)
 
 	if err := client.Update(ctx, ns); err != nil {
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("failed to update the ns definition: %w", err)
@@ -242,6 +246,17 @@ func (r *Reconciler) ReconcileSubscription(ctx context.Context, target marketpla
 		return integreatlyv1alpha1.PhaseInProgress, nil
 	}
 
+	// Never approve an operator version outside the supported skew for this
+	// version of RHOAM
+	if !ip.Spec.Approved {
+		for _, csvName := range ip.Spec.ClusterServiceVersionNames {
+			if err := CheckOperatorVersionSkew(target.SubscriptionName, csvName); err != nil {
+				log.Warningf("Blocking installplan approval", l.Fields{"install plan": ip.Name, "error": err})
+				return integreatlyv1alpha1.PhaseFailed, err
+			}
+		}
+	}
+
 	err = upgradeApproval(ctx, preUpgradeBackupExecutor, client, ip, log)
 	if err != nil {
 		return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("error approving installplan for %v: %w", target.SubscriptionName, err)
@@ -286,6 +301,10 @@ func (r *Reconciler) ReconcileSubscription(ctx context.Context, target marketpla
 			log.Warningf("CSV failed validation. Retrying operator installation", l.Fields{"error": err, "install plan": target.SubscriptionName})
 			return retryInstallation(ctx, client, log, target, ipCSV, sub)
 		}
+
+		if err := reconcileSubscriptionArchitecture(ctx, client, sub, ipCSV); err != nil {
+			return integreatlyv1alpha1.PhaseFailed, fmt.Errorf("error reconciling node architecture for %v: %w", target.SubscriptionName, err)
+		}
 	}
 
 	return integreatlyv1alpha1.PhaseCompleted, nil
@@ -300,6 +319,34 @@ func (r *Reconciler) GetProductDeclaration() *marketplace.ProductDeclaration {
 	return r.productDeclaration
 }
 
+// reconcileSubscriptionArchitecture validates that the operator images are
+// available for the architecture of the worker nodes and, on clusters with
+// mixed architectures, keeps the operator pods on the supported nodes
+func reconcileSubscriptionArchitecture(ctx context.Context, client k8sclient.Client, sub *operatorsv1alpha1.Subscription, csv *operatorsv1alpha1.ClusterServiceVersion) error {
+	nodeAffinity, err := GetNodeArchitectureAffinity(ctx, client, GetCSVArchitectures(csv))
+	if err != nil {
+		return err
+	}
+
+	var currentNodeAffinity *corev1.NodeAffinity
+	if sub.Spec.Config != nil && sub.Spec.Config.Affinity != nil {
+		currentNodeAffinity = sub.Spec.Config.Affinity.NodeAffinity
+	}
+	if equality.Semantic.DeepEqual(currentNodeAffinity, nodeAffinity) {
+		return nil
+	}
+
+	if sub.Spec.Config == nil {
+		sub.Spec.Config = &operatorsv1alpha1.SubscriptionConfig{}
+	}
+	if sub.Spec.Config.Affinity == nil {
+		sub.Spec.Config.Affinity = &corev1.Affinity{}
+	}
+	sub.Spec.Config.Affinity.NodeAffinity = nodeAffinity
+
+	return client.Update(ctx, sub)
+}
+
 func validateCSV(csv *operatorsv1alpha1.ClusterServiceVersion) error {
 	if csv.Spec.InstallStrategy.StrategyName == operatorsv1alpha1.InstallStrategyNameDeployment && len(csv.Spec.InstallStrategy.StrategySpec.DeploymentSpecs) == 0 {
 		return errors.New("no Deployment found in install strategy")
@@ -0,0 +1,207 @@
+// Package rotation rotates the credentials the operator generates, on a
+// schedule or on demand, and rolls out the workloads reading them
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RotateAnnotation on a secret rotates its credential on the next
+	// reconcile of its product
+	RotateAnnotation = "integreatly.org/rotate"
+	// RotatedAtAnnotation records the last rotation of a secret. It's
+	// also set on the pod templates of the dependents, suffixed with the
+	// name of the credential, to roll them out once per rotation
+	RotatedAtAnnotation = "integreatly.org/rotated-at"
+
+	DefaultInterval = 90 * 24 * time.Hour
+
+	passwordLength = 32
+)
+
+// Credential is a credential generated by the operator and stored in a
+// secret
+type Credential struct {
+	// Name identifies the credential in the status of its product
+	Name   string
+	Secret types.NamespacedName
+	// Keys of the secret regenerated by a rotation
+	Keys []string
+	// Apply sets the rotated credential in the product before the secret
+	// is updated, the secret keeps the working credential when it fails.
+	// The keys it sets on the rotated secret are written along with the
+	// regenerated ones, so a copy of a credential the operator doesn't
+	// generate, such as the SMTP settings, is refreshed from its source
+	Apply func(ctx context.Context, rotated *corev1.Secret) error
+	// Dependents are the workloads reading the credential, rolled out
+	// once it's rotated
+	Dependents []Dependent
+}
+
+// Dependent is a workload reading a credential
+type Dependent struct {
+	Object   k8sclient.Object
+	Template resources.PodTemplateSelector
+}
+
+// Interval returns the interval between the rotations of the installation,
+// or 0 when the credentials are only rotated on demand
+func Interval(installation *integreatlyv1alpha1.RHMI) time.Duration {
+	if installation.Spec.SecretRotation == nil {
+		return 0
+	}
+	if installation.Spec.SecretRotation.Interval == nil {
+		return DefaultInterval
+	}
+	return installation.Spec.SecretRotation.Interval.Duration
+}
+
+// Reconcile rotates the credentials whose secret is annotated with
+// RotateAnnotation or whose last rotation, or creation, is older than the
+// interval of the installation. The dependents of a rotated credential are
+// rolled out and the rotation is recorded in the status of the product. The
+// credentials whose secret doesn't exist yet are skipped
+func Reconcile(ctx context.Context, serverClient k8sclient.Client, installation *integreatlyv1alpha1.RHMI, productStatus *integreatlyv1alpha1.RHMIProductStatus, now time.Time, log l.Logger, credentials ...Credential) error {
+	for _, credential := range credentials {
+		secret := &corev1.Secret{}
+		if err := serverClient.Get(ctx, credential.Secret, secret); err != nil {
+			if k8serr.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get secret %s of credential %s: %w", credential.Secret, credential.Name, err)
+		}
+
+		if due(installation, secret, now) {
+			if err := rotate(ctx, serverClient, credential, secret, now); err != nil {
+				return err
+			}
+			log.Infof("Rotated credential", l.Fields{"credential": credential.Name, "secret": credential.Secret.String()})
+		}
+
+		rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[RotatedAtAnnotation])
+		if err != nil {
+			continue
+		}
+		for _, dependent := range credential.Dependents {
+			if err := rollOut(ctx, serverClient, credential.Name, dependent, rotatedAt); err != nil {
+				return err
+			}
+		}
+		setStatus(productStatus, credential, metav1.NewTime(rotatedAt))
+	}
+	return nil
+}
+
+func due(installation *integreatlyv1alpha1.RHMI, secret *corev1.Secret, now time.Time) bool {
+	if _, ok := secret.Annotations[RotateAnnotation]; ok {
+		return true
+	}
+	interval := Interval(installation)
+	if interval == 0 {
+		return false
+	}
+	last := secret.CreationTimestamp.Time
+	if rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[RotatedAtAnnotation]); err == nil {
+		last = rotatedAt
+	}
+	return !now.Before(last.Add(interval))
+}
+
+// rotate regenerates the keys of the credential, applies them to the product
+// and updates the secret, retrying on conflicts so the applied credential
+// isn't lost
+func rotate(ctx context.Context, serverClient k8sclient.Client, credential Credential, secret *corev1.Secret, now time.Time) error {
+	rotated := map[string][]byte{}
+	for _, key := range credential.Keys {
+		password := resources.GenerateRandomPassword(passwordLength, 0, 4, 4)
+		if password == "" {
+			return fmt.Errorf("failed to generate %s of credential %s", key, credential.Name)
+		}
+		rotated[key] = []byte(password)
+	}
+
+	if credential.Apply != nil {
+		applied := secret.DeepCopy()
+		if applied.Data == nil {
+			applied.Data = map[string][]byte{}
+		}
+		for key, value := range rotated {
+			applied.Data[key] = value
+		}
+		if err := credential.Apply(ctx, applied); err != nil {
+			return fmt.Errorf("failed to apply rotated credential %s: %w", credential.Name, err)
+		}
+		for key, value := range applied.Data {
+			if !bytes.Equal(secret.Data[key], value) {
+				rotated[key] = value
+			}
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := serverClient.Get(ctx, credential.Secret, secret); err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		for key, value := range rotated {
+			secret.Data[key] = value
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		delete(secret.Annotations, RotateAnnotation)
+		secret.Annotations[RotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+		return serverClient.Update(ctx, secret)
+	})
+}
+
+// rollOut annotates the pod template of the dependent with the rotation, the
+// dependents that don't exist are skipped
+func rollOut(ctx context.Context, serverClient k8sclient.Client, name string, dependent Dependent, rotatedAt time.Time) error {
+	annotation := RotatedAtAnnotation + "-" + name
+	if _, err := resources.UpdatePodTemplateIfExists(ctx, serverClient, dependent.Template, func(_ metav1.Object, podTemplate *corev1.PodTemplateSpec) error {
+		if podTemplate.Annotations == nil {
+			podTemplate.Annotations = map[string]string{}
+		}
+		podTemplate.Annotations[annotation] = rotatedAt.Format(time.RFC3339)
+		return nil
+	}, dependent.Object); err != nil {
+		return fmt.Errorf("failed to roll out %s for credential %s: %w", dependent.Object.GetName(), name, err)
+	}
+	return nil
+}
+
+func setStatus(productStatus *integreatlyv1alpha1.RHMIProductStatus, credential Credential, rotatedAt metav1.Time) {
+	rotation := integreatlyv1alpha1.SecretRotationStatus{
+		Name:        credential.Name,
+		Secret:      credential.Secret.String(),
+		LastRotated: rotatedAt,
+	}
+	for i := range productStatus.SecretRotations {
+		if productStatus.SecretRotations[i].Name == credential.Name {
+			productStatus.SecretRotations[i] = rotation
+			return
+		}
+	}
+	productStatus.SecretRotations = append(productStatus.SecretRotations, rotation)
+	sort.Slice(productStatus.SecretRotations, func(i, j int) bool {
+		return productStatus.SecretRotations[i].Name < productStatus.SecretRotations[j].Name
+	})
+}
@@ -0,0 +1,236 @@
+package rotation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	namespace  = "redhat-rhoam-3scale"
+	secretName = "system-seed"
+	password   = "current-password"
+)
+
+func TestReconcile(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	day := &metav1.Duration{Duration: 24 * time.Hour}
+
+	seed := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace, Annotations: annotations},
+			Data:       map[string][]byte{"ADMIN_USER": []byte("admin"), "ADMIN_PASSWORD": []byte(password)},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		secretRotation *integreatlyv1alpha1.SecretRotationSpec
+		objects        []runtime.Object
+		applyErr       error
+		wantErr        bool
+		wantRotated    bool
+		wantRotatedAt  time.Time
+	}{
+		{
+			name:    "missing secret skipped",
+			objects: []runtime.Object{},
+		},
+		{
+			name:    "not rotated without rotation spec",
+			objects: []runtime.Object{seed(nil)},
+		},
+		{
+			name:          "rotated on demand",
+			objects:       []runtime.Object{seed(map[string]string{RotateAnnotation: "true"})},
+			wantRotated:   true,
+			wantRotatedAt: now,
+		},
+		{
+			name:           "rotated once the interval is over",
+			secretRotation: &integreatlyv1alpha1.SecretRotationSpec{Interval: day},
+			objects:        []runtime.Object{seed(map[string]string{RotatedAtAnnotation: now.Add(-25 * time.Hour).Format(time.RFC3339)})},
+			wantRotated:    true,
+			wantRotatedAt:  now,
+		},
+		{
+			name:           "last rotation recorded within the interval",
+			secretRotation: &integreatlyv1alpha1.SecretRotationSpec{Interval: day},
+			objects:        []runtime.Object{seed(map[string]string{RotatedAtAnnotation: now.Add(-time.Hour).Format(time.RFC3339)})},
+			wantRotatedAt:  now.Add(-time.Hour),
+		},
+		{
+			name:     "secret kept when the product rejects the credential",
+			objects:  []runtime.Object{seed(map[string]string{RotateAnnotation: "true"})},
+			applyErr: errors.New("unauthorized"),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemApp := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "system-app", Namespace: namespace}}
+			serverClient := utils.NewTestClient(scheme, append(tt.objects, systemApp)...)
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{SecretRotation: tt.secretRotation}}
+			productStatus := &integreatlyv1alpha1.RHMIProductStatus{}
+
+			var applied string
+			credential := Credential{
+				Name:   "3scale-admin",
+				Secret: types.NamespacedName{Name: secretName, Namespace: namespace},
+				Keys:   []string{"ADMIN_PASSWORD"},
+				Apply: func(_ context.Context, rotated *corev1.Secret) error {
+					applied = string(rotated.Data["ADMIN_PASSWORD"])
+					return tt.applyErr
+				},
+				Dependents: []Dependent{{
+					Object:   &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "system-app", Namespace: namespace}},
+					Template: resources.SelectFromDeployment,
+				}},
+			}
+
+			err := Reconcile(context.TODO(), serverClient, installation, productStatus, now, l.NewLogger(), credential)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			secret := &corev1.Secret{}
+			if err := serverClient.Get(context.TODO(), credential.Secret, secret); err != nil {
+				if len(tt.objects) == 0 {
+					return
+				}
+				t.Fatal(err)
+			}
+			rotated := string(secret.Data["ADMIN_PASSWORD"]) != password
+			if rotated != tt.wantRotated {
+				t.Fatalf("rotated = %v, want %v", rotated, tt.wantRotated)
+			}
+			if tt.wantRotated {
+				if applied != string(secret.Data["ADMIN_PASSWORD"]) {
+					t.Errorf("applied password differs from the secret")
+				}
+				if _, ok := secret.Annotations[RotateAnnotation]; ok {
+					t.Errorf("rotate annotation kept after the rotation")
+				}
+				if string(secret.Data["ADMIN_USER"]) != "admin" {
+					t.Errorf("keys outside of the credential changed")
+				}
+			}
+
+			if tt.wantRotatedAt.IsZero() {
+				if len(productStatus.SecretRotations) != 0 {
+					t.Errorf("status = %v, want no rotation", productStatus.SecretRotations)
+				}
+				return
+			}
+			if len(productStatus.SecretRotations) != 1 || !productStatus.SecretRotations[0].LastRotated.Time.Equal(tt.wantRotatedAt) {
+				t.Fatalf("status = %v, want a rotation at %s", productStatus.SecretRotations, tt.wantRotatedAt)
+			}
+			if productStatus.SecretRotations[0].Secret != namespace+"/"+secretName {
+				t.Errorf("status secret = %s, want %s/%s", productStatus.SecretRotations[0].Secret, namespace, secretName)
+			}
+
+			deployment := &appsv1.Deployment{}
+			if err := serverClient.Get(context.TODO(), types.NamespacedName{Name: "system-app", Namespace: namespace}, deployment); err != nil {
+				t.Fatal(err)
+			}
+			if got := deployment.Spec.Template.Annotations[RotatedAtAnnotation+"-3scale-admin"]; got != tt.wantRotatedAt.Format(time.RFC3339) {
+				t.Errorf("dependent rotation = %q, want %q", got, tt.wantRotatedAt.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestReconcile_RefreshedCopy(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	smtp := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "system-smtp", Namespace: namespace, Annotations: map[string]string{RotateAnnotation: "true"}},
+		Data:       map[string][]byte{"address": []byte("smtp.example.com"), "password": []byte("old-password")},
+	}
+	systemSidekiq := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "system-sidekiq", Namespace: namespace}}
+	serverClient := utils.NewTestClient(scheme, smtp, systemSidekiq)
+
+	credential := Credential{
+		Name:   "3scale-smtp",
+		Secret: types.NamespacedName{Name: "system-smtp", Namespace: namespace},
+		Apply: func(_ context.Context, rotated *corev1.Secret) error {
+			rotated.Data["password"] = []byte("new-password")
+			return nil
+		},
+		Dependents: []Dependent{{
+			Object:   &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "system-sidekiq", Namespace: namespace}},
+			Template: resources.SelectFromDeployment,
+		}},
+	}
+	if err := Reconcile(context.TODO(), serverClient, &integreatlyv1alpha1.RHMI{}, &integreatlyv1alpha1.RHMIProductStatus{}, now, l.NewLogger(), credential); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := serverClient.Get(context.TODO(), credential.Secret, secret); err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["password"]) != "new-password" {
+		t.Errorf("password = %s, want the refreshed password", secret.Data["password"])
+	}
+	if string(secret.Data["address"]) != "smtp.example.com" {
+		t.Errorf("keys the refresh didn't set changed")
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := serverClient.Get(context.TODO(), types.NamespacedName{Name: "system-sidekiq", Namespace: namespace}, deployment); err != nil {
+		t.Fatal(err)
+	}
+	if got := deployment.Spec.Template.Annotations[RotatedAtAnnotation+"-3scale-smtp"]; got != now.Format(time.RFC3339) {
+		t.Errorf("dependent rotation = %q, want %q", got, now.Format(time.RFC3339))
+	}
+}
+
+func TestInterval(t *testing.T) {
+	tests := []struct {
+		name           string
+		secretRotation *integreatlyv1alpha1.SecretRotationSpec
+		want           time.Duration
+	}{
+		{
+			name: "on demand only without rotation spec",
+		},
+		{
+			name:           "default interval",
+			secretRotation: &integreatlyv1alpha1.SecretRotationSpec{},
+			want:           DefaultInterval,
+		},
+		{
+			name:           "interval of the spec",
+			secretRotation: &integreatlyv1alpha1.SecretRotationSpec{Interval: &metav1.Duration{Duration: 720 * time.Hour}},
+			want:           720 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installation := &integreatlyv1alpha1.RHMI{Spec: integreatlyv1alpha1.RHMISpec{SecretRotation: tt.secretRotation}}
+			if got := Interval(installation); got != tt.want {
+				t.Errorf("Interval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
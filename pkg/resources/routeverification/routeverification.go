@@ -0,0 +1,288 @@
+package routeverification
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ClusterResolver is the name of the resolver used by the operator pod
+	ClusterResolver = "cluster"
+	// ExternalResolver is the name of the resolver configured by the
+	// ExternalResolverParam addon parameter
+	ExternalResolver = "external"
+	// ExternalResolverParam is the addon parameter that sets the address of
+	// the DNS server used to verify routes from outside the cluster,
+	// e.g. 1.1.1.1:53
+	ExternalResolverParam = "dns-verification-resolver"
+
+	// wildcardProbeLabel is resolved in place of the wildcard of routes with
+	// a Subdomain wildcard policy
+	wildcardProbeLabel = "rhoam-dns-probe"
+	lookupTimeout      = 5 * time.Second
+
+	// DefaultInterval is the minimum time between two verifications of the
+	// same routes
+	DefaultInterval = 10 * time.Minute
+	// maxConcurrentVerifications bounds the routes verified at the same time
+	maxConcurrentVerifications = 10
+	// verificationTimeout bounds a whole verification run in the background
+	verificationTimeout = 5 * time.Minute
+)
+
+// DefaultScheduler is shared by the product reconcilers, which are created
+// again on every reconcile
+var DefaultScheduler = NewScheduler(DefaultInterval)
+
+// Resolver looks up the addresses of a host. It's satisfied by net.Resolver
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// CertificateFetcher returns the certificate served for host
+type CertificateFetcher func(ctx context.Context, host string) (*x509.Certificate, error)
+
+// Verifier checks that route hosts resolve through every resolver and serve a
+// certificate that matches the host
+type Verifier struct {
+	Resolvers        map[string]Resolver
+	FetchCertificate CertificateFetcher
+	Now              func() time.Time
+}
+
+// NewVerifier returns a Verifier that uses the cluster resolver and, when
+// externalResolver is set, the DNS server at that address
+func NewVerifier(externalResolver string) *Verifier {
+	resolvers := map[string]Resolver{
+		ClusterResolver: net.DefaultResolver,
+	}
+	if externalResolver != "" {
+		resolvers[ExternalResolver] = NewResolver(externalResolver)
+	}
+
+	return &Verifier{
+		Resolvers:        resolvers,
+		FetchCertificate: FetchCertificate,
+		Now:              time.Now,
+	}
+}
+
+// NewResolver returns a resolver that sends every query to the DNS server at
+// address, defaulting to port 53
+func NewResolver(address string) Resolver {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: lookupTimeout}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+// FetchCertificate returns the leaf certificate served on port 443 of host.
+// The certificate chain isn't verified as clusters may use self signed
+// certificates, only whether it was issued for host is checked by the Verifier
+func FetchCertificate(ctx context.Context, host string) (*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: lookupTimeout},
+		// #nosec G402 -- the hostname is verified by the caller
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "443"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate served")
+	}
+	return certs[0], nil
+}
+
+// VerifyRoutes returns a description of every problem found resolving or
+// connecting to the admitted routes. Routes that aren't admitted yet are
+// skipped as their DNS records aren't expected to exist. Up to
+// maxConcurrentVerifications routes are verified at the same time, the
+// problems are returned in the order of the routes
+func (v *Verifier) VerifyRoutes(ctx context.Context, routes []routev1.Route) []string {
+	resolverNames := make([]string, 0, len(v.Resolvers))
+	for name := range v.Resolvers {
+		resolverNames = append(resolverNames, name)
+	}
+	sort.Strings(resolverNames)
+
+	routeProblems := make([][]string, len(routes))
+	semaphore := make(chan struct{}, maxConcurrentVerifications)
+	var wg sync.WaitGroup
+	for i, route := range routes {
+		if !isAdmitted(route) {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int, host string) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			routeProblems[i] = v.verifyHost(ctx, resolverNames, host)
+		}(i, probeHost(route))
+	}
+	wg.Wait()
+
+	var problems []string
+	for _, hostProblems := range routeProblems {
+		problems = append(problems, hostProblems...)
+	}
+	return problems
+}
+
+func (v *Verifier) verifyHost(ctx context.Context, resolverNames []string, host string) []string {
+	var problems []string
+
+	resolvable := true
+	for _, name := range resolverNames {
+		if err := v.lookup(ctx, v.Resolvers[name], host); err != nil {
+			problems = append(problems, fmt.Sprintf("%s not resolvable by %s resolver: %v", host, name, err))
+			resolvable = false
+		}
+	}
+	if !resolvable || v.FetchCertificate == nil {
+		return problems
+	}
+
+	if problem := v.verifyCertificate(ctx, host); problem != "" {
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// Result of a verification of routes
+type Result struct {
+	Time     time.Time
+	Problems []string
+}
+
+// Scheduler runs the verifications in the background, so slow lookups and
+// handshakes don't hold up the reconciles, and keeps their last result
+type Scheduler struct {
+	Interval time.Duration
+	Now      func() time.Time
+
+	mu      sync.Mutex
+	running map[string]bool
+	results map[string]Result
+}
+
+// NewScheduler returns a Scheduler verifying the same routes at most once per
+// interval
+func NewScheduler(interval time.Duration) *Scheduler {
+	return &Scheduler{
+		Interval: interval,
+		Now:      time.Now,
+		running:  map[string]bool{},
+		results:  map[string]Result{},
+	}
+}
+
+// Verify returns the last result of the verification of key and starts a new
+// one in the background when none is running and the last one is older than
+// the interval. ok is false until the first verification of key completes
+func (s *Scheduler) Verify(key string, verify func(ctx context.Context) []string) (result Result, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, ok = s.results[key]
+	if s.running[key] || (ok && s.Now().Sub(result.Time) < s.Interval) {
+		return result, ok
+	}
+
+	s.running[key] = true
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), verificationTimeout)
+		defer cancel()
+		problems := verify(ctx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.results[key] = Result{Time: s.Now(), Problems: problems}
+		delete(s.running, key)
+	}()
+	return result, ok
+}
+
+func (v *Verifier) lookup(ctx context.Context, resolver Resolver, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	addresses, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no addresses returned")
+	}
+	return nil
+}
+
+func (v *Verifier) verifyCertificate(ctx context.Context, host string) string {
+	cert, err := v.FetchCertificate(ctx, host)
+	if err != nil {
+		return fmt.Sprintf("%s certificate could not be retrieved: %v", host, err)
+	}
+	if err := cert.VerifyHostname(host); err != nil {
+		return fmt.Sprintf("%s certificate mismatch: %v", host, err)
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Sprintf("%s certificate expired at %s", host, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+func isAdmitted(route routev1.Route) bool {
+	for _, ingress := range route.Status.Ingress {
+		for _, condition := range ingress.Conditions {
+			if condition.Type == routev1.RouteAdmitted && condition.Status == corev1.ConditionTrue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probeHost returns the host to verify for a route. For wildcard routes a
+// host under the wildcard domain is used, as the route host itself may not
+// have a record of its own
+func probeHost(route routev1.Route) string {
+	host := route.Spec.Host
+	if route.Spec.WildcardPolicy != routev1.WildcardPolicySubdomain {
+		return host
+	}
+	if parts := strings.SplitN(host, ".", 2); len(parts) == 2 {
+		return fmt.Sprintf("%s.%s", wildcardProbeLabel, parts[1])
+	}
+	return host
+}
@@ -0,0 +1,242 @@
+package routeverification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addresses, ok := f[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addresses, nil
+}
+
+func newCertificate(t *testing.T, dnsName string, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsName},
+		DNSNames:     []string{dnsName},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func admittedRoute(host string, wildcardPolicy routev1.WildcardPolicyType) routev1.Route {
+	return routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: host},
+		Spec: routev1.RouteSpec{
+			Host:           host,
+			WildcardPolicy: wildcardPolicy,
+		},
+		Status: routev1.RouteStatus{
+			Ingress: []routev1.RouteIngress{
+				{
+					Host: host,
+					Conditions: []routev1.RouteIngressCondition{
+						{Type: routev1.RouteAdmitted, Status: corev1.ConditionTrue},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestVerifier_VerifyRoutes(t *testing.T) {
+	now := time.Now()
+	validCert := newCertificate(t, "*.apps.example.com", now.Add(time.Hour))
+	certificates := map[string]*x509.Certificate{
+		"3scale-admin.apps.example.com":            validCert,
+		"rhoam-dns-probe.gateway.apps.example.com": newCertificate(t, "*.gateway.apps.example.com", now.Add(time.Hour)),
+		"mismatch.apps.example.com":                newCertificate(t, "other.example.com", now.Add(time.Hour)),
+		"expired.apps.example.com":                 newCertificate(t, "*.apps.example.com", now.Add(-time.Hour)),
+	}
+	fetch := func(_ context.Context, host string) (*x509.Certificate, error) {
+		cert, ok := certificates[host]
+		if !ok {
+			return nil, errors.New("connection refused")
+		}
+		return cert, nil
+	}
+	clusterResolver := fakeResolver{
+		"3scale-admin.apps.example.com":            {"10.0.0.1"},
+		"rhoam-dns-probe.gateway.apps.example.com": {"10.0.0.1"},
+		"mismatch.apps.example.com":                {"10.0.0.1"},
+		"expired.apps.example.com":                 {"10.0.0.1"},
+		"unpropagated.apps.example.com":            {"10.0.0.1"},
+	}
+	externalResolver := fakeResolver{
+		"3scale-admin.apps.example.com": {"203.0.113.1"},
+	}
+
+	scenarios := []struct {
+		Name             string
+		Routes           []routev1.Route
+		Resolvers        map[string]Resolver
+		ExpectedProblems []string
+	}{
+		{
+			Name:      "test no problems for resolvable routes with matching certificates",
+			Routes:    []routev1.Route{admittedRoute("3scale-admin.apps.example.com", routev1.WildcardPolicyNone)},
+			Resolvers: map[string]Resolver{ClusterResolver: clusterResolver, ExternalResolver: externalResolver},
+		},
+		{
+			Name:      "test routes that aren't admitted are skipped",
+			Routes:    []routev1.Route{{Spec: routev1.RouteSpec{Host: "pending.apps.example.com"}}},
+			Resolvers: map[string]Resolver{ClusterResolver: clusterResolver},
+		},
+		{
+			Name:      "test wildcard routes are verified with a host under the wildcard domain",
+			Routes:    []routev1.Route{admittedRoute("wildcard.gateway.apps.example.com", routev1.WildcardPolicySubdomain)},
+			Resolvers: map[string]Resolver{ClusterResolver: clusterResolver},
+		},
+		{
+			Name:             "test route not propagated to external resolver is reported",
+			Routes:           []routev1.Route{admittedRoute("unpropagated.apps.example.com", routev1.WildcardPolicyNone)},
+			Resolvers:        map[string]Resolver{ClusterResolver: clusterResolver, ExternalResolver: externalResolver},
+			ExpectedProblems: []string{"unpropagated.apps.example.com not resolvable by external resolver"},
+		},
+		{
+			Name: "test certificate problems are reported",
+			Routes: []routev1.Route{
+				admittedRoute("mismatch.apps.example.com", routev1.WildcardPolicyNone),
+				admittedRoute("expired.apps.example.com", routev1.WildcardPolicyNone),
+			},
+			Resolvers: map[string]Resolver{ClusterResolver: clusterResolver},
+			ExpectedProblems: []string{
+				"mismatch.apps.example.com certificate mismatch",
+				"expired.apps.example.com certificate expired",
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			verifier := &Verifier{
+				Resolvers:        scenario.Resolvers,
+				FetchCertificate: fetch,
+				Now:              func() time.Time { return now },
+			}
+
+			problems := verifier.VerifyRoutes(context.TODO(), scenario.Routes)
+			if len(problems) != len(scenario.ExpectedProblems) {
+				t.Fatalf("expected problems %v, got %v", scenario.ExpectedProblems, problems)
+			}
+			for i := range problems {
+				if !strings.HasPrefix(problems[i], scenario.ExpectedProblems[i]) {
+					t.Fatalf("expected problems %v, got %v", scenario.ExpectedProblems, problems)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifier_VerifyRoutesKeepsRouteOrder(t *testing.T) {
+	var routes []routev1.Route
+	var expected []string
+	for i := 0; i < 3*maxConcurrentVerifications; i++ {
+		host := fmt.Sprintf("route-%d.apps.example.com", i)
+		routes = append(routes, admittedRoute(host, routev1.WildcardPolicyNone))
+		expected = append(expected, host+" not resolvable by cluster resolver")
+	}
+	verifier := &Verifier{Resolvers: map[string]Resolver{ClusterResolver: fakeResolver{}}}
+
+	problems := verifier.VerifyRoutes(context.TODO(), routes)
+	if len(problems) != len(expected) {
+		t.Fatalf("expected a problem per route, got %v", problems)
+	}
+	for i := range problems {
+		if !strings.HasPrefix(problems[i], expected[i]) {
+			t.Fatalf("expected problem %q at %d, got %q", expected[i], i, problems[i])
+		}
+	}
+}
+
+func TestScheduler_Verify(t *testing.T) {
+	now := time.Now()
+	scheduler := NewScheduler(time.Minute)
+	scheduler.Now = func() time.Time { return now }
+
+	runs := 0
+	release := make(chan struct{})
+	done := make(chan struct{})
+	verify := func(context.Context) []string {
+		runs++
+		<-release
+		defer func() { done <- struct{}{} }()
+		return []string{fmt.Sprintf("run %d", runs)}
+	}
+
+	if _, ok := scheduler.Verify("3scale", verify); ok {
+		t.Fatal("expected no result before the first verification completes")
+	}
+	if _, ok := scheduler.Verify("3scale", verify); ok {
+		t.Fatal("expected no result while the first verification is running")
+	}
+	release <- struct{}{}
+	<-done
+	// wait for the result to be stored after the verification returned
+	waitForResult := func() Result {
+		for i := 0; i < 100; i++ {
+			scheduler.mu.Lock()
+			running := scheduler.running["3scale"]
+			scheduler.mu.Unlock()
+			if !running {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		return scheduler.results["3scale"]
+	}
+	if result := waitForResult(); result.Problems[0] != "run 1" || !result.Time.Equal(now) {
+		t.Fatalf("unexpected result %v", result)
+	}
+
+	result, ok := scheduler.Verify("3scale", verify)
+	if !ok || result.Problems[0] != "run 1" || runs != 1 {
+		t.Fatalf("expected the last result within the interval without verifying again, got %v after %d runs", result, runs)
+	}
+
+	now = now.Add(time.Minute)
+	result, ok = scheduler.Verify("3scale", verify)
+	if !ok || result.Problems[0] != "run 1" {
+		t.Fatalf("expected the last result while verifying again, got %v", result)
+	}
+	release <- struct{}{}
+	<-done
+	if result := waitForResult(); result.Problems[0] != "run 2" || runs != 2 {
+		t.Fatalf("expected a verification once the interval passed, got %v after %d runs", result, runs)
+	}
+}
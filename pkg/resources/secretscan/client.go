@@ -0,0 +1,197 @@
+// Package secretscan blocks writes of credentials into resources that aren't
+// Secrets, as a safeguard against leaking them through ConfigMaps, custom
+// resource specs or status fields that are readable by more users
+package secretscan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/integr8ly/integreatly-operator/pkg/metrics"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+type credentialPattern struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// credentialPatterns only match values that are credentials in any context,
+// so the legitimate writes of the operator aren't blocked
+var credentialPatterns = []credentialPattern{
+	{name: "aws-access-key-id", regex: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "aws-secret-access-key", regex: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{name: "bearer-token", regex: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{20,}=*`)},
+	{name: "private-key", regex: regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY-----`)},
+	{name: "smtp-password", regex: regexp.MustCompile(`(?i)(smtps?://[^\s:/@]+:[^\s@/]+@|smtp_?pass(word)?["']?\s*[:=]\s*["']?[^\s"']+)`)},
+}
+
+// LeakError is returned instead of writing an object containing a credential,
+// it doesn't include the credential so it can be logged and put in events
+type LeakError struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Path      string
+	Pattern   string
+}
+
+func (e *LeakError) Error() string {
+	return fmt.Sprintf("blocked write of %s %s/%s: %s matches the %s credential pattern, credentials must be stored in a Secret", e.Kind, e.Namespace, e.Name, e.Path, e.Pattern)
+}
+
+// IsLeakError returns true when err was returned for a blocked write
+func IsLeakError(err error) bool {
+	_, ok := err.(*LeakError)
+	return ok
+}
+
+type client struct {
+	k8sclient.Client
+	log l.Logger
+}
+
+// NewClient wraps c so the objects written through it, except Secrets, are
+// scanned for credentials. Writes containing a credential fail with a
+// LeakError and are counted in the rhoam_credential_leak_blocked_total metric
+func NewClient(c k8sclient.Client, log l.Logger) k8sclient.Client {
+	return &client{Client: c, log: log}
+}
+
+func (c *client) Create(ctx context.Context, obj k8sclient.Object, opts ...k8sclient.CreateOption) error {
+	if err := c.scan(obj, ""); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *client) Update(ctx context.Context, obj k8sclient.Object, opts ...k8sclient.UpdateOption) error {
+	if err := c.scan(obj, ""); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *client) Patch(ctx context.Context, obj k8sclient.Object, patch k8sclient.Patch, opts ...k8sclient.PatchOption) error {
+	if err := c.scan(obj, ""); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *client) Status() k8sclient.SubResourceWriter {
+	return &statusWriter{SubResourceWriter: c.Client.Status(), client: c}
+}
+
+type statusWriter struct {
+	k8sclient.SubResourceWriter
+	client *client
+}
+
+func (w *statusWriter) Update(ctx context.Context, obj k8sclient.Object, opts ...k8sclient.SubResourceUpdateOption) error {
+	if err := w.client.scan(obj, "status"); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func (w *statusWriter) Patch(ctx context.Context, obj k8sclient.Object, patch k8sclient.Patch, opts ...k8sclient.SubResourcePatchOption) error {
+	if err := w.client.scan(obj, "status"); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// scan looks for credentials in the field of obj, or the whole object when
+// field is empty
+func (c *client) scan(obj k8sclient.Object, field string) error {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+			kind = gvk.Kind
+		}
+	}
+	if kind == "Secret" {
+		return nil
+	}
+
+	var content map[string]interface{}
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.Object
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			// the write isn't blocked when the object can't be scanned
+			c.log.Warningf("Failed to scan object for credentials", l.Fields{"kind": kind, "name": obj.GetName(), "error": err.Error()})
+			return nil
+		}
+	}
+
+	var value interface{} = content
+	path := ""
+	if field != "" {
+		value, path = content[field], field
+	} else if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		metadata = copyWithout(metadata, "managedFields")
+		content = copyWithout(content, "metadata")
+		content["metadata"] = metadata
+		value = content
+	}
+
+	leakPath, pattern := findCredential(value, path)
+	if pattern == "" {
+		return nil
+	}
+	err := &LeakError{Kind: kind, Namespace: obj.GetNamespace(), Name: obj.GetName(), Path: leakPath, Pattern: pattern}
+	metrics.IncCredentialLeakBlocked(kind, pattern)
+	c.log.Error("Blocked write of a credential", err)
+	return err
+}
+
+// findCredential returns the path of the first string of value matching a
+// credential pattern, map keys are walked in order so the result is stable
+func findCredential(value interface{}, path string) (string, string) {
+	switch typed := value.(type) {
+	case string:
+		for _, pattern := range credentialPatterns {
+			if pattern.regex.MatchString(typed) {
+				return path, pattern.name
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if leakPath, pattern := findCredential(typed[key], strings.TrimPrefix(path+"."+key, ".")); pattern != "" {
+				return leakPath, pattern
+			}
+		}
+	case []interface{}:
+		for i, item := range typed {
+			if leakPath, pattern := findCredential(item, fmt.Sprintf("%s[%d]", path, i)); pattern != "" {
+				return leakPath, pattern
+			}
+		}
+	}
+	return "", ""
+}
+
+func copyWithout(m map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
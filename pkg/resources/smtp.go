@@ -16,7 +16,7 @@ const (
 )
 
 // GetSMTPFromAddress returns the correct from address depending on how the operator is configured
-// For addon installs returns the address stated in the alertmanger.yaml or the address configured by the custom SMTP feature in ocm
+// For addon installs returns the address of the SMTP relay, the address stated in the alertmanger.yaml or the address configured by the custom SMTP feature in ocm
 // For sandbox it returns the default hardcoded value of: test@rhmw.io
 func GetSMTPFromAddress(ctx context.Context, serverClient k8sclient.Client, log logger.Logger, installation *v1alpha1.RHMI) (string, error) {
 
@@ -31,7 +31,9 @@ func GetSMTPFromAddress(ctx context.Context, serverClient k8sclient.Client, log
 	var existingSMTPFromAddress string
 	var err error
 
-	if installation.Status.CustomSmtp != nil && installation.Status.CustomSmtp.Enabled {
+	if custom_smtp.RelayEnabled(installation) {
+		existingSMTPFromAddress = installation.Spec.SMTPRelay.FromAddress
+	} else if installation.Status.CustomSmtp != nil && installation.Status.CustomSmtp.Enabled {
 		existingSMTPFromAddress, err = custom_smtp.GetFromAddress(ctx, serverClient, installation.Namespace)
 
 		if err != nil {
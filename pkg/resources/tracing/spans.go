@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+)
+
+const (
+	operatorServiceName = "rhoam-operator"
+
+	// the OTLP span kind and status codes
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// ReconcileSpansEnabled returns whether the operator exports the spans of the
+// product reconciles
+func ReconcileSpansEnabled(installation *v1alpha1.RHMI) bool {
+	return installation.Spec.Tracing != nil && installation.Spec.Tracing.ReconcileSpans
+}
+
+// SpansEndpoint is the OTLP/HTTP endpoint of the collector the operator
+// exports its spans to
+func SpansEndpoint(installation *v1alpha1.RHMI) string {
+	return fmt.Sprintf("http://%s:%d/v1/traces", CollectorHost(installation), OTLPHTTPPort)
+}
+
+// Trace records the spans of a reconcile. A nil trace records nothing, so the
+// reconcilers can start spans whether the spans are exported or not
+type Trace struct {
+	mu    sync.Mutex
+	id    string
+	spans []*Span
+}
+
+// Span is an operation of a reconcile, such as the reconcile of a product
+type Span struct {
+	trace      *Trace
+	id         string
+	parentID   string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	err        error
+}
+
+// NewReconcileTrace returns the trace of a reconcile of the installation, or
+// nil when its spans aren't exported
+func NewReconcileTrace(installation *v1alpha1.RHMI) *Trace {
+	if !ReconcileSpansEnabled(installation) {
+		return nil
+	}
+	return &Trace{id: randomID(16)}
+}
+
+// StartSpan starts a root span of the trace
+func (t *Trace) StartSpan(name string, attributes map[string]string) *Span {
+	return t.startSpan(name, "", attributes)
+}
+
+// StartChild starts a span of the same trace within the span
+func (s *Span) StartChild(name string, attributes map[string]string) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.trace.startSpan(name, s.id, attributes)
+}
+
+func (t *Trace) startSpan(name, parentID string, attributes map[string]string) *Span {
+	if t == nil {
+		return nil
+	}
+	span := &Span{
+		trace:      t,
+		id:         randomID(8),
+		parentID:   parentID,
+		name:       name,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, span)
+	return span
+}
+
+// End ends the span, the span is marked as failed when err is set
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.end = time.Now()
+	s.err = err
+}
+
+// Export sends the ended spans of the trace to the OTLP/HTTP endpoint, in the
+// JSON encoding of OTLP
+func (t *Trace) Export(ctx context.Context, client *http.Client, endpoint string) error {
+	if t == nil {
+		return nil
+	}
+	body, err := json.Marshal(t.otlp())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to export spans to %s: unexpected status %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+// otlp returns the ExportTraceServiceRequest of the ended spans of the trace
+func (t *Trace) otlp() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := []otlpSpan{}
+	for _, span := range t.spans {
+		if span.end.IsZero() {
+			continue
+		}
+		status := otlpStatus{Code: statusCodeOK}
+		if span.err != nil {
+			status = otlpStatus{Code: statusCodeError, Message: span.err.Error()}
+		}
+		spans = append(spans, otlpSpan{
+			TraceID:           t.id,
+			SpanID:            span.id,
+			ParentSpanID:      span.parentID,
+			Name:              span.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.attributes),
+			Status:            status,
+		})
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": otlpAttributes(map[string]string{"service.name": operatorServiceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": "github.com/integr8ly/integreatly-operator"},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func otlpAttributes(attributes map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var otlpAttributes []otlpAttribute
+	for _, key := range keys {
+		attribute := otlpAttribute{Key: key}
+		attribute.Value.StringValue = attributes[key]
+		otlpAttributes = append(otlpAttributes, attribute)
+	}
+	return otlpAttributes
+}
+
+// randomID returns a random hex encoded trace or span ID of n bytes
+func randomID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
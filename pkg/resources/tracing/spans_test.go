@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+)
+
+func TestNewReconcileTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracing   *v1alpha1.TracingSpec
+		wantTrace bool
+	}{
+		{
+			name: "test no trace without tracing",
+		},
+		{
+			name:    "test no trace without reconcile spans",
+			tracing: &v1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317"},
+		},
+		{
+			name:      "test trace with reconcile spans",
+			tracing:   &v1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317", ReconcileSpans: true},
+			wantTrace: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := NewReconcileTrace(&v1alpha1.RHMI{Spec: v1alpha1.RHMISpec{Tracing: tt.tracing}})
+			if (trace != nil) != tt.wantTrace {
+				t.Fatalf("NewReconcileTrace() = %v, want trace %v", trace, tt.wantTrace)
+			}
+
+			// the spans of a nil trace are no-ops
+			span := trace.StartSpan("stage", nil)
+			span.StartChild("product", nil).End(nil)
+			span.End(nil)
+		})
+	}
+}
+
+func TestTrace_Export(t *testing.T) {
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(body, &request)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}))
+	defer server.Close()
+
+	trace := &Trace{id: randomID(16)}
+	stage := trace.StartSpan("reconcile installation stage", map[string]string{"stage": "installation"})
+	stage.StartChild("reconcile 3scale", map[string]string{"product": "3scale"}).End(errors.New("failed"))
+	stage.StartChild("reconcile grafana", nil)
+	stage.End(nil)
+
+	if err := trace.Export(context.TODO(), server.Client(), server.URL+"/v1/traces"); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected export request %v", request)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want the 2 ended spans", len(spans))
+	}
+	stageSpan, productSpan := spans[0], spans[1]
+	if stageSpan.TraceID != trace.id || productSpan.TraceID != trace.id || len(trace.id) != 32 {
+		t.Errorf("trace IDs = %s, %s, want %s", stageSpan.TraceID, productSpan.TraceID, trace.id)
+	}
+	if stageSpan.ParentSpanID != "" || productSpan.ParentSpanID != stageSpan.SpanID {
+		t.Errorf("product span parent = %s, want the stage span %s", productSpan.ParentSpanID, stageSpan.SpanID)
+	}
+	if stageSpan.Status.Code != statusCodeOK {
+		t.Errorf("stage span status = %v, want ok", stageSpan.Status)
+	}
+	if productSpan.Status.Code != statusCodeError || productSpan.Status.Message != "failed" {
+		t.Errorf("product span status = %v, want the error", productSpan.Status)
+	}
+	if len(productSpan.Attributes) != 1 || productSpan.Attributes[0].Key != "product" || productSpan.Attributes[0].Value.StringValue != "3scale" {
+		t.Errorf("product span attributes = %v", productSpan.Attributes)
+	}
+
+	if err := trace.Export(context.TODO(), server.Client(), server.URL+"/invalid"); err == nil {
+		t.Errorf("Export() expected an error on an unexpected status")
+	}
+}
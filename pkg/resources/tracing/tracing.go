@@ -0,0 +1,205 @@
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/podsecurity"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	CollectorName = "rhoam-otel-collector"
+	// OTLPPort receives the spans of envoy
+	OTLPPort = 4317
+	// OTLPHTTPPort receives the spans of the operator
+	OTLPHTTPPort = 4318
+	// JaegerPort receives the spans of APIcast, its OpenTracing module only
+	// supports the jaeger tracer
+	JaegerPort = 14268
+
+	DefaultCollectorImage     = "registry.redhat.io/rhosdt/opentelemetry-collector-rhel8:0.81.0"
+	defaultSamplingPercentage = int32(10)
+
+	collectorConfigKey = "collector.yaml"
+	// APIcastConfigKey is the key of the OpenTracing configuration in the
+	// secret referenced by the APIManager
+	APIcastConfigKey = "config"
+)
+
+// Enabled returns whether the spans of the installation are collected
+func Enabled(installation *v1alpha1.RHMI) bool {
+	return installation.Spec.Tracing != nil
+}
+
+// CollectorHost is the host of the collector service
+func CollectorHost(installation *v1alpha1.RHMI) string {
+	return fmt.Sprintf("%s.%s.svc", CollectorName, installation.Namespace)
+}
+
+// SamplingPercentage of the requests traced by the gateways
+func SamplingPercentage(installation *v1alpha1.RHMI) int32 {
+	if installation.Spec.Tracing == nil || installation.Spec.Tracing.SamplingPercentage == nil {
+		return defaultSamplingPercentage
+	}
+	return *installation.Spec.Tracing.SamplingPercentage
+}
+
+// ReconcileCollector deploys the OpenTelemetry collector exporting the spans
+// of the installation to the tracing endpoint, or removes it when tracing
+// isn't in the spec anymore
+func ReconcileCollector(ctx context.Context, serverClient k8sclient.Client, installation *v1alpha1.RHMI) error {
+	objectMeta := metav1.ObjectMeta{Name: CollectorName, Namespace: installation.Namespace}
+	spec := installation.Spec.Tracing
+	if spec == nil {
+		for _, obj := range []k8sclient.Object{
+			&appsv1.Deployment{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&corev1.ConfigMap{ObjectMeta: objectMeta},
+		} {
+			if err := serverClient.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+				return fmt.Errorf("failed to delete tracing collector %T: %w", obj, err)
+			}
+		}
+		return nil
+	}
+
+	collectorConfig, err := CollectorConfig(spec)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, configMap, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(configMap, installation)
+		configMap.Data = map[string]string{collectorConfigKey: string(collectorConfig)}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile tracing collector config map: %w", err)
+	}
+
+	image := spec.CollectorImage
+	if image == "" {
+		image = DefaultCollectorImage
+	}
+	labels := map[string]string{"app": CollectorName}
+	deployment := &appsv1.Deployment{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, deployment, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(deployment, installation)
+		deployment.Labels = labels
+		deployment.Spec.Replicas = &[]int32{1}[0]
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template.Labels = labels
+		// roll the collector out again when its configuration changes
+		deployment.Spec.Template.Annotations = map[string]string{"integreatly.org/config-hash": fmt.Sprintf("%x", sha256.Sum256(collectorConfig))}
+
+		container := corev1.Container{
+			Name:  "collector",
+			Image: image,
+			Args:  []string{"--config=/conf/" + collectorConfigKey},
+			Ports: []corev1.ContainerPort{
+				{Name: "otlp-grpc", ContainerPort: OTLPPort, Protocol: corev1.ProtocolTCP},
+				{Name: "otlp-http", ContainerPort: OTLPHTTPPort, Protocol: corev1.ProtocolTCP},
+				{Name: "jaeger-http", ContainerPort: JaegerPort, Protocol: corev1.ProtocolTCP},
+			},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(OTLPPort)}},
+			},
+			VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/conf"}},
+		}
+		if spec.Resources != nil {
+			container.Resources = *spec.Resources
+		}
+		deployment.Spec.Template.Spec.Containers = []corev1.Container{container}
+		deployment.Spec.Template.Spec.Volumes = []corev1.Volume{{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: CollectorName}},
+			},
+		}}
+		return podsecurity.Mutate(installation)(deployment, &deployment.Spec.Template)
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile tracing collector deployment: %w", err)
+	}
+
+	service := &corev1.Service{ObjectMeta: objectMeta}
+	if _, err := controllerutil.CreateOrUpdate(ctx, serverClient, service, func() error {
+		owner.AddIntegreatlyOwnerAnnotations(service, installation)
+		service.Labels = labels
+		service.Spec.Selector = labels
+		service.Spec.Ports = []corev1.ServicePort{
+			{Name: "otlp-grpc", Port: OTLPPort, TargetPort: intstr.FromInt(OTLPPort), Protocol: corev1.ProtocolTCP},
+			{Name: "otlp-http", Port: OTLPHTTPPort, TargetPort: intstr.FromInt(OTLPHTTPPort), Protocol: corev1.ProtocolTCP},
+			{Name: "jaeger-http", Port: JaegerPort, TargetPort: intstr.FromInt(JaegerPort), Protocol: corev1.ProtocolTCP},
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to reconcile tracing collector service: %w", err)
+	}
+	return nil
+}
+
+// CollectorConfig returns the configuration of the collector, receiving the
+// spans over OTLP and jaeger and exporting them to the tracing endpoint
+func CollectorConfig(spec *v1alpha1.TracingSpec) ([]byte, error) {
+	return yaml.Marshal(map[string]interface{}{
+		"receivers": map[string]interface{}{
+			"otlp": map[string]interface{}{
+				"protocols": map[string]interface{}{
+					"grpc": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", OTLPPort)},
+					"http": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", OTLPHTTPPort)},
+				},
+			},
+			"jaeger": map[string]interface{}{
+				"protocols": map[string]interface{}{
+					"thrift_http": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", JaegerPort)},
+				},
+			},
+		},
+		"processors": map[string]interface{}{
+			"batch": map[string]interface{}{},
+		},
+		"exporters": map[string]interface{}{
+			"otlp": map[string]interface{}{
+				"endpoint": spec.Endpoint,
+				"tls":      map[string]interface{}{"insecure": spec.Insecure},
+			},
+		},
+		"service": map[string]interface{}{
+			"pipelines": map[string]interface{}{
+				"traces": map[string]interface{}{
+					"receivers":  []string{"otlp", "jaeger"},
+					"processors": []string{"batch"},
+					"exporters":  []string{"otlp"},
+				},
+			},
+		},
+	})
+}
+
+// APIcastConfig returns the jaeger configuration of the OpenTracing module of
+// APIcast. The spans are propagated with the W3C trace context, like envoy
+// does
+func APIcastConfig(installation *v1alpha1.RHMI, serviceName string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"service_name":       serviceName,
+		"propagation_format": "w3c",
+		"sampler": map[string]interface{}{
+			"type":  "probabilistic",
+			"param": float64(SamplingPercentage(installation)) / 100,
+		},
+		"reporter": map[string]interface{}{
+			"endpoint": fmt.Sprintf("http://%s:%d/api/traces", CollectorHost(installation), JaegerPort),
+		},
+	})
+}
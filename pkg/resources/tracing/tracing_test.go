@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReconcileCollector(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const namespace = "redhat-rhoam-operator"
+	objectMeta := metav1.ObjectMeta{Name: CollectorName, Namespace: namespace}
+
+	tests := []struct {
+		name           string
+		tracing        *v1alpha1.TracingSpec
+		objects        []runtime.Object
+		wantCollector  bool
+		wantImage      string
+		wantConfigPart string
+	}{
+		{
+			name:           "deploys the collector exporting to the tracing endpoint",
+			tracing:        &v1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317"},
+			wantCollector:  true,
+			wantImage:      DefaultCollectorImage,
+			wantConfigPart: "endpoint: tempo.example.com:4317",
+		},
+		{
+			name:           "uses the collector image of the spec",
+			tracing:        &v1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317", Insecure: true, CollectorImage: "mirror.example.com/otel-collector:latest"},
+			wantCollector:  true,
+			wantImage:      "mirror.example.com/otel-collector:latest",
+			wantConfigPart: "insecure: true",
+		},
+		{
+			name: "removes the collector without tracing spec",
+			objects: []runtime.Object{
+				&appsv1.Deployment{ObjectMeta: objectMeta},
+				&corev1.Service{ObjectMeta: objectMeta},
+				&corev1.ConfigMap{ObjectMeta: objectMeta},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverClient := utils.NewTestClient(scheme, tt.objects...)
+			installation := &v1alpha1.RHMI{
+				ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: namespace},
+				Spec:       v1alpha1.RHMISpec{Tracing: tt.tracing},
+			}
+
+			if err := ReconcileCollector(context.TODO(), serverClient, installation); err != nil {
+				t.Fatal(err)
+			}
+
+			key := k8sclient.ObjectKey{Name: CollectorName, Namespace: namespace}
+			deployment := &appsv1.Deployment{}
+			deploymentErr := serverClient.Get(context.TODO(), key, deployment)
+			configMap := &corev1.ConfigMap{}
+			configMapErr := serverClient.Get(context.TODO(), key, configMap)
+			if !tt.wantCollector {
+				if !k8serr.IsNotFound(deploymentErr) || !k8serr.IsNotFound(configMapErr) {
+					t.Errorf("expected the collector to be removed, got %v and %v", deploymentErr, configMapErr)
+				}
+				return
+			}
+			if deploymentErr != nil || configMapErr != nil {
+				t.Fatalf("expected the collector to be deployed, got %v and %v", deploymentErr, configMapErr)
+			}
+			if image := deployment.Spec.Template.Spec.Containers[0].Image; image != tt.wantImage {
+				t.Errorf("expected image %s, got %s", tt.wantImage, image)
+			}
+			if config := configMap.Data[collectorConfigKey]; !strings.Contains(config, tt.wantConfigPart) {
+				t.Errorf("expected the collector config to contain %q, got %s", tt.wantConfigPart, config)
+			}
+		})
+	}
+}
+
+func TestAPIcastConfig(t *testing.T) {
+	percentage := int32(25)
+	installation := &v1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator"},
+		Spec:       v1alpha1.RHMISpec{Tracing: &v1alpha1.TracingSpec{Endpoint: "tempo.example.com:4317", SamplingPercentage: &percentage}},
+	}
+
+	data, err := APIcastConfig(installation, "apicast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		Sampler struct {
+			Param float64 `json:"param"`
+		} `json:"sampler"`
+		Reporter struct {
+			Endpoint string `json:"endpoint"`
+		} `json:"reporter"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Sampler.Param != 0.25 {
+		t.Errorf("expected a sampling ratio of 0.25, got %v", config.Sampler.Param)
+	}
+	if config.Reporter.Endpoint != "http://rhoam-otel-collector.redhat-rhoam-operator.svc:14268/api/traces" {
+		t.Errorf("expected the spans to be reported to the collector, got %s", config.Reporter.Endpoint)
+	}
+}
@@ -0,0 +1,241 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	croAWS "github.com/integr8ly/cloud-resource-operator/pkg/providers/aws"
+	croResources "github.com/integr8ly/cloud-resource-operator/pkg/resources"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	consolev1 "github.com/openshift/api/console/v1"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+const (
+	OrphanLocationCluster = "cluster"
+	OrphanLocationAWS     = "aws"
+)
+
+// OrphanScanner finds the resources of an installation outside of the
+// cluster that are left behind by its uninstall. They're only reported, as
+// they're removed by their own operator
+type OrphanScanner interface {
+	Scan(ctx context.Context) ([]integreatlyv1alpha1.OrphanedResource, error)
+}
+
+// ReapOrphanedResources deletes the resources of the installation left behind
+// once its products are uninstalled: the namespaces, OAuthClients and
+// ConsoleLinks labelled with its UID, and the cloud resources it owns. The
+// resources already being deleted, or that failed to be deleted, are
+// returned as remaining
+func ReapOrphanedResources(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI, log l.Logger) (removed, remaining []integreatlyv1alpha1.OrphanedResource, err error) {
+	owned := k8sclient.MatchingLabels{OwnerLabelKey: string(inst.GetUID())}
+	var objects []k8sclient.Object
+
+	nsList := &corev1.NamespaceList{}
+	if err := client.List(ctx, nsList, owned); err != nil {
+		return nil, nil, fmt.Errorf("failed to list installation namespaces: %w", err)
+	}
+	for i := range nsList.Items {
+		// the installation namespace is removed with the operator
+		if nsList.Items[i].Name != inst.Namespace {
+			objects = append(objects, &nsList.Items[i])
+		}
+	}
+
+	oauthClients := &oauthv1.OAuthClientList{}
+	if err := client.List(ctx, oauthClients, owned); err != nil {
+		return nil, nil, fmt.Errorf("failed to list installation oauth clients: %w", err)
+	}
+	for i := range oauthClients.Items {
+		objects = append(objects, &oauthClients.Items[i])
+	}
+
+	consoleLinks := &consolev1.ConsoleLinkList{}
+	if err := client.List(ctx, consoleLinks, owned); err != nil && !meta.IsNoMatchError(err) {
+		return nil, nil, fmt.Errorf("failed to list installation console links: %w", err)
+	}
+	for i := range consoleLinks.Items {
+		objects = append(objects, &consoleLinks.Items[i])
+	}
+
+	cloudResources, err := ownedCloudResources(ctx, client, inst)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects = append(objects, cloudResources...)
+
+	for _, obj := range objects {
+		orphan := integreatlyv1alpha1.OrphanedResource{
+			Kind:      kindOf(client, obj),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Location:  OrphanLocationCluster,
+		}
+		if obj.GetDeletionTimestamp() != nil {
+			orphan.Message = "deletion in progress"
+			remaining = append(remaining, orphan)
+			continue
+		}
+		if err := client.Delete(ctx, obj); err != nil && !k8serr.IsNotFound(err) {
+			orphan.Message = err.Error()
+			remaining = append(remaining, orphan)
+			continue
+		}
+		log.Infof("Removed resource left behind by the uninstall", l.Fields{"kind": orphan.Kind, "ns": orphan.Namespace, "name": orphan.Name})
+		removed = append(removed, orphan)
+	}
+	return removed, remaining, nil
+}
+
+// ownedCloudResources returns the cloud resources and snapshots in the
+// installation namespace that are annotated with the installation as owner
+func ownedCloudResources(ctx context.Context, client k8sclient.Client, inst *integreatlyv1alpha1.RHMI) ([]k8sclient.Object, error) {
+	lists := []k8sclient.ObjectList{
+		&crov1alpha1.PostgresList{},
+		&crov1alpha1.RedisList{},
+		&crov1alpha1.BlobStorageList{},
+		&crov1alpha1.PostgresSnapshotList{},
+		&crov1alpha1.RedisSnapshotList{},
+	}
+	var objects []k8sclient.Object
+	for _, list := range lists {
+		if err := client.List(ctx, list, k8sclient.InNamespace(inst.Namespace)); err != nil {
+			// CRO and its CRDs are uninstalled with the cloud resources
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list cloud resources: %w", err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj, ok := item.(k8sclient.Object)
+			if !ok {
+				continue
+			}
+			annotations := obj.GetAnnotations()
+			// the snapshots aren't annotated, they're owned by the
+			// installation namespace
+			if _, annotated := annotations[owner.IntegreatlyOwnerName]; annotated &&
+				(annotations[owner.IntegreatlyOwnerName] != inst.Name || annotations[owner.IntegreatlyOwnerNamespace] != inst.Namespace) {
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+func kindOf(client k8sclient.Client, obj k8sclient.Object) string {
+	gvk, err := apiutil.GVKForObject(obj, client.Scheme())
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+	return gvk.Kind
+}
+
+// AWSOrphanScanner finds the RDS instances and ElastiCache replication groups
+// tagged with the cluster ID by CRO
+type AWSOrphanScanner struct {
+	ClusterID   string
+	TagKey      string
+	RDS         rdsiface.RDSAPI
+	ElastiCache elasticacheiface.ElastiCacheAPI
+}
+
+var _ OrphanScanner = &AWSOrphanScanner{}
+
+// NewAWSOrphanScanner builds the scanner with the credentials CRO uses for
+// the cloud resources of the namespace
+func NewAWSOrphanScanner(ctx context.Context, client k8sclient.Client, namespace string) (*AWSOrphanScanner, error) {
+	credentialManager, err := croAWS.NewCredentialManager(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build aws credential manager: %w", err)
+	}
+	credentials, err := credentialManager.ReconcileProviderCredentials(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aws credentials: %w", err)
+	}
+	sess, err := croAWS.CreateSessionFromStrategy(ctx, client, credentials, &croAWS.StrategyConfig{})
+	if err != nil {
+		return nil, err
+	}
+	clusterID, err := croResources.GetClusterID(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster id: %w", err)
+	}
+	return &AWSOrphanScanner{
+		ClusterID:   clusterID,
+		TagKey:      croResources.GetOrganizationTag() + "clusterID",
+		RDS:         rds.New(sess),
+		ElastiCache: elasticache.New(sess),
+	}, nil
+}
+
+func (s *AWSOrphanScanner) Scan(ctx context.Context) ([]integreatlyv1alpha1.OrphanedResource, error) {
+	var found []integreatlyv1alpha1.OrphanedResource
+
+	err := s.RDS.DescribeDBInstancesPagesWithContext(ctx, &rds.DescribeDBInstancesInput{}, func(page *rds.DescribeDBInstancesOutput, _ bool) bool {
+		for _, instance := range page.DBInstances {
+			for _, tag := range instance.TagList {
+				if aws.StringValue(tag.Key) == s.TagKey && aws.StringValue(tag.Value) == s.ClusterID {
+					found = append(found, integreatlyv1alpha1.OrphanedResource{
+						Kind:     "RDSInstance",
+						Name:     aws.StringValue(instance.DBInstanceIdentifier),
+						Location: OrphanLocationAWS,
+						Message:  "status " + aws.StringValue(instance.DBInstanceStatus),
+					})
+					break
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe rds instances: %w", err)
+	}
+
+	var groups []*elasticache.ReplicationGroup
+	err = s.ElastiCache.DescribeReplicationGroupsPagesWithContext(ctx, &elasticache.DescribeReplicationGroupsInput{}, func(page *elasticache.DescribeReplicationGroupsOutput, _ bool) bool {
+		groups = append(groups, page.ReplicationGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe elasticache replication groups: %w", err)
+	}
+	// replication groups are described without their tags
+	for _, group := range groups {
+		tags, err := s.ElastiCache.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{ResourceName: group.ARN})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags of elasticache replication group %s: %w", aws.StringValue(group.ReplicationGroupId), err)
+		}
+		for _, tag := range tags.TagList {
+			if aws.StringValue(tag.Key) == s.TagKey && aws.StringValue(tag.Value) == s.ClusterID {
+				found = append(found, integreatlyv1alpha1.OrphanedResource{
+					Kind:     "ElastiCacheReplicationGroup",
+					Name:     aws.StringValue(group.ReplicationGroupId),
+					Location: OrphanLocationAWS,
+					Message:  "status " + aws.StringValue(group.Status),
+				})
+				break
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticache/elasticacheiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	crov1alpha1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	l "github.com/integr8ly/integreatly-operator/pkg/resources/logger"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/owner"
+	"github.com/integr8ly/integreatly-operator/utils"
+	consolev1 "github.com/openshift/api/console/v1"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReapOrphanedResources(t *testing.T) {
+	scheme, err := utils.NewTestScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation := &integreatlyv1alpha1.RHMI{
+		ObjectMeta: metav1.ObjectMeta{Name: "rhoam", Namespace: "redhat-rhoam-operator", UID: "installation-uid"},
+	}
+	owned := map[string]string{OwnerLabelKey: "installation-uid"}
+	deleting := metav1.Now()
+	postgres := func(name, ownerName string) *crov1alpha1.Postgres {
+		return &crov1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: installation.Namespace,
+			Annotations: map[string]string{
+				owner.IntegreatlyOwnerName:      ownerName,
+				owner.IntegreatlyOwnerNamespace: installation.Namespace,
+			},
+		}}
+	}
+
+	serverClient := utils.NewTestClient(scheme,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-operator", Labels: owned}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-3scale", Labels: owned}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-rhsso", Labels: owned, DeletionTimestamp: &deleting, Finalizers: []string{"kubernetes"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-3scale", Labels: map[string]string{OwnerLabelKey: "other-uid"}}},
+		&oauthv1.OAuthClient{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-3scale", Labels: owned}},
+		&consolev1.ConsoleLink{ObjectMeta: metav1.ObjectMeta{Name: "rhmi-3scale-console-link", Labels: owned}},
+		&consolev1.ConsoleLink{ObjectMeta: metav1.ObjectMeta{Name: "unrelated-console-link"}},
+		postgres("threescale-postgres-rhoam", "rhoam"),
+		postgres("threescale-postgres-other", "other"),
+		&crov1alpha1.RedisSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "threescale-redis-rhoam-snapshot", Namespace: installation.Namespace}},
+	)
+
+	removed, remaining, err := ReapOrphanedResources(context.TODO(), serverClient, installation, l.NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(orphans []integreatlyv1alpha1.OrphanedResource) []string {
+		var names []string
+		for _, orphan := range orphans {
+			names = append(names, orphan.Kind+"/"+orphan.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	expectedRemoved := []string{
+		"ConsoleLink/rhmi-3scale-console-link",
+		"Namespace/redhat-rhoam-3scale",
+		"OAuthClient/redhat-rhoam-3scale",
+		"Postgres/threescale-postgres-rhoam",
+		"RedisSnapshot/threescale-redis-rhoam-snapshot",
+	}
+	if !reflect.DeepEqual(names(removed), expectedRemoved) {
+		t.Errorf("expected %v to be removed, got %v", expectedRemoved, names(removed))
+	}
+	if !reflect.DeepEqual(names(remaining), []string{"Namespace/redhat-rhoam-rhsso"}) || remaining[0].Message == "" {
+		t.Errorf("expected the terminating namespace to remain, got %+v", remaining)
+	}
+
+	kept := []k8sclient.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "redhat-rhoam-operator"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-3scale"}},
+		&consolev1.ConsoleLink{ObjectMeta: metav1.ObjectMeta{Name: "unrelated-console-link"}},
+		&crov1alpha1.Postgres{ObjectMeta: metav1.ObjectMeta{Name: "threescale-postgres-other", Namespace: installation.Namespace}},
+	}
+	for _, obj := range kept {
+		if err := serverClient.Get(context.TODO(), k8sclient.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Errorf("expected %s to be kept: %v", obj.GetName(), err)
+		}
+	}
+}
+
+type mockRDS struct {
+	rdsiface.RDSAPI
+	instances []*rds.DBInstance
+}
+
+func (m *mockRDS) DescribeDBInstancesPagesWithContext(_ aws.Context, _ *rds.DescribeDBInstancesInput, fn func(*rds.DescribeDBInstancesOutput, bool) bool, _ ...request.Option) error {
+	fn(&rds.DescribeDBInstancesOutput{DBInstances: m.instances}, true)
+	return nil
+}
+
+type mockElastiCache struct {
+	elasticacheiface.ElastiCacheAPI
+	groups []*elasticache.ReplicationGroup
+	tags   map[string][]*elasticache.Tag
+}
+
+func (m *mockElastiCache) DescribeReplicationGroupsPagesWithContext(_ aws.Context, _ *elasticache.DescribeReplicationGroupsInput, fn func(*elasticache.DescribeReplicationGroupsOutput, bool) bool, _ ...request.Option) error {
+	fn(&elasticache.DescribeReplicationGroupsOutput{ReplicationGroups: m.groups}, true)
+	return nil
+}
+
+func (m *mockElastiCache) ListTagsForResourceWithContext(_ aws.Context, input *elasticache.ListTagsForResourceInput, _ ...request.Option) (*elasticache.TagListMessage, error) {
+	return &elasticache.TagListMessage{TagList: m.tags[aws.StringValue(input.ResourceName)]}, nil
+}
+
+func TestAWSOrphanScanner(t *testing.T) {
+	clusterTag := func(clusterID string) []*rds.Tag {
+		return []*rds.Tag{{Key: aws.String("integreatly.org/clusterID"), Value: aws.String(clusterID)}}
+	}
+	scanner := &AWSOrphanScanner{
+		ClusterID: "cluster-a",
+		TagKey:    "integreatly.org/clusterID",
+		RDS: &mockRDS{instances: []*rds.DBInstance{
+			{DBInstanceIdentifier: aws.String("threescale-postgres"), DBInstanceStatus: aws.String("deleting"), TagList: clusterTag("cluster-a")},
+			{DBInstanceIdentifier: aws.String("other-cluster-postgres"), DBInstanceStatus: aws.String("available"), TagList: clusterTag("cluster-b")},
+		}},
+		ElastiCache: &mockElastiCache{
+			groups: []*elasticache.ReplicationGroup{
+				{ReplicationGroupId: aws.String("threescale-redis"), ARN: aws.String("arn:redis-a"), Status: aws.String("available")},
+				{ReplicationGroupId: aws.String("untagged-redis"), ARN: aws.String("arn:redis-b"), Status: aws.String("available")},
+			},
+			tags: map[string][]*elasticache.Tag{
+				"arn:redis-a": {{Key: aws.String("integreatly.org/clusterID"), Value: aws.String("cluster-a")}},
+			},
+		},
+	}
+
+	found, err := scanner.Scan(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []integreatlyv1alpha1.OrphanedResource{
+		{Kind: "RDSInstance", Name: "threescale-postgres", Location: OrphanLocationAWS, Message: "status deleting"},
+		{Kind: "ElastiCacheReplicationGroup", Name: "threescale-redis", Location: OrphanLocationAWS, Message: "status available"},
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("expected %+v, got %+v", expected, found)
+	}
+}
@@ -0,0 +1,126 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
+)
+
+// OperatorVersionSkew is the range of product operator versions supported by
+// the running RHOAM operator. Min is inclusive and Max is exclusive
+type OperatorVersionSkew struct {
+	Min integreatlyv1alpha1.OperatorVersion
+	Max integreatlyv1alpha1.OperatorVersion
+}
+
+// OperatorCompatibilityMatrix maps the subscription of each product operator
+// to the operator versions this version of RHOAM has been validated against.
+// The upper bound is the next minor version after the one pinned for the
+// product, so z-stream releases of the pinned minor are accepted, and the
+// lower bound allows the previous minor versions to be upgraded from.
+var OperatorCompatibilityMatrix = map[string]OperatorVersionSkew{
+	constants.CloudResourceSubscriptionName: {Min: "1.0.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionCloudResources)},
+	constants.RHSSOSubscriptionName:         {Min: "7.6.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionRHSSO)},
+	constants.ThreeScaleSubscriptionName:    {Min: "0.10.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersion3Scale)},
+	constants.GrafanaSubscriptionName:       {Min: "4.1.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionGrafana)},
+	constants.Marin3rSubscriptionName:       {Min: "0.10.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionMarin3r)},
+	constants.ObservabilitySubscriptionName: {Min: "4.1.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionObservability)},
+	constants.MCGSubscriptionName:           {Min: "4.11.0", Max: nextMinorVersion(integreatlyv1alpha1.OperatorVersionMCG)},
+}
+
+var operatorVersionRegexp = regexp.MustCompile(`([0-9]+)\.([0-9]+)\.([0-9]+)`)
+
+// VersionSkewError is returned when a product operator version falls outside
+// of the supported version skew
+type VersionSkewError struct {
+	SubscriptionName string
+	CSVName          string
+	Skew             OperatorVersionSkew
+}
+
+func (e *VersionSkewError) Error() string {
+	return fmt.Sprintf("%s from subscription %s is outside the supported operator version range >= %s, < %s", e.CSVName, e.SubscriptionName, e.Skew.Min, e.Skew.Max)
+}
+
+// IsVersionSkewError checks if any error in the chain is a VersionSkewError
+func IsVersionSkewError(err error) bool {
+	var skewErr *VersionSkewError
+	return errors.As(err, &skewErr)
+}
+
+// CheckOperatorVersionSkew validates the version of the CSV against the
+// compatibility matrix entry for the subscription. Subscriptions without an
+// entry in the matrix are not restricted
+func CheckOperatorVersionSkew(subscriptionName, csvName string) error {
+	skew, ok := OperatorCompatibilityMatrix[subscriptionName]
+	if !ok {
+		return nil
+	}
+
+	csvVersion, err := parseOperatorVersion(csvName)
+	if err != nil {
+		return fmt.Errorf("failed to parse version of %s: %w", csvName, err)
+	}
+	minVersion, err := parseOperatorVersion(string(skew.Min))
+	if err != nil {
+		return fmt.Errorf("failed to parse minimum version for %s: %w", subscriptionName, err)
+	}
+	maxVersion, err := parseOperatorVersion(string(skew.Max))
+	if err != nil {
+		return fmt.Errorf("failed to parse maximum version for %s: %w", subscriptionName, err)
+	}
+
+	if minVersion.IsNewerThan(csvVersion) || !maxVersion.IsNewerThan(csvVersion) {
+		return &VersionSkewError{
+			SubscriptionName: subscriptionName,
+			CSVName:          csvName,
+			Skew:             skew,
+		}
+	}
+
+	return nil
+}
+
+// nextMinorVersion returns the first version of the minor release following
+// the version, e.g. 0.12.0 for 0.11.6-mas. An unparsable version is returned
+// as is
+func nextMinorVersion(version integreatlyv1alpha1.OperatorVersion) integreatlyv1alpha1.OperatorVersion {
+	v, err := parseOperatorVersion(string(version))
+	if err != nil {
+		return version
+	}
+	return integreatlyv1alpha1.OperatorVersion(fmt.Sprintf("%d.%d.0", v.Major, v.Minor+1))
+}
+
+// parseOperatorVersion extracts the first major.minor.patch sequence found in
+// the value, ignoring any prefix or build suffix such as in
+// "3scale-operator.v0.11.6-mas"
+func parseOperatorVersion(value string) (*Version, error) {
+	matches := operatorVersionRegexp.FindStringSubmatch(value)
+	if len(matches) < 4 {
+		return nil, errors.New("invalid version")
+	}
+
+	major, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, err
+	}
+	minor, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return nil, err
+	}
+	patch, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Version{
+		Major: major,
+		Minor: minor,
+		Patch: patch,
+	}, nil
+}
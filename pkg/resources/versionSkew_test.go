@@ -0,0 +1,84 @@
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/integr8ly/integreatly-operator/pkg/resources/constants"
+)
+
+func TestCheckOperatorVersionSkew(t *testing.T) {
+	scenarios := []struct {
+		Name             string
+		SubscriptionName string
+		CSVName          string
+		ExpectSkewError  bool
+		ExpectError      bool
+	}{
+		{
+			Name:             "test pinned version is within skew",
+			SubscriptionName: constants.ThreeScaleSubscriptionName,
+			CSVName:          "3scale-operator.v0.11.6-mas",
+		},
+		{
+			Name:             "test previous minor version is within skew",
+			SubscriptionName: constants.ThreeScaleSubscriptionName,
+			CSVName:          "3scale-operator.v0.10.0-mas",
+		},
+		{
+			Name:             "test z-stream release of the pinned version is within skew",
+			SubscriptionName: constants.ThreeScaleSubscriptionName,
+			CSVName:          "3scale-operator.v0.11.8-mas",
+		},
+		{
+			Name:             "test z-stream release of pinned version with build suffix is within skew",
+			SubscriptionName: constants.RHSSOSubscriptionName,
+			CSVName:          "rhsso-operator.7.6.5-opr-003",
+		},
+		{
+			Name:             "test newer minor version than pinned is outside skew",
+			SubscriptionName: constants.ThreeScaleSubscriptionName,
+			CSVName:          "3scale-operator.v0.12.0-mas",
+			ExpectSkewError:  true,
+			ExpectError:      true,
+		},
+		{
+			Name:             "test older version than minimum is outside skew",
+			SubscriptionName: constants.Marin3rSubscriptionName,
+			CSVName:          "marin3r.v0.8.0",
+			ExpectSkewError:  true,
+			ExpectError:      true,
+		},
+		{
+			Name:             "test csv names without v prefix are parsed",
+			SubscriptionName: constants.RHSSOSubscriptionName,
+			CSVName:          "rhsso-operator.7.6.3-opr-001",
+		},
+		{
+			Name:             "test subscription not in matrix is not restricted",
+			SubscriptionName: "rhmi-unknown",
+			CSVName:          "unknown.v99.0.0",
+		},
+		{
+			Name:             "test invalid csv version returns error",
+			SubscriptionName: constants.GrafanaSubscriptionName,
+			CSVName:          "grafana-operator",
+			ExpectError:      true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			err := CheckOperatorVersionSkew(scenario.SubscriptionName, scenario.CSVName)
+			if scenario.ExpectError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !scenario.ExpectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if IsVersionSkewError(fmt.Errorf("wrapped: %w", err)) != scenario.ExpectSkewError {
+				t.Fatalf("expected version skew error to be %t, got %v", scenario.ExpectSkewError, err)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	pkgerr "github.com/pkg/errors"
 
 	admissionv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -186,3 +187,49 @@ func (reconciler *ValidatingWebhookReconciler) SetRule(rule RuleWithOperations)
 func (reconciler *MutatingWebhookReconciler) SetRule(rule RuleWithOperations) {
 	reconciler.rule = rule
 }
+
+// ConversionWebhookReconciler sets the conversion strategy of a CRD to the
+// conversion webhook of the operator
+type ConversionWebhookReconciler struct {
+	CRDName string
+	Path    string
+}
+
+// SetName does nothing, the conversion is configured in the CRD
+func (reconciler *ConversionWebhookReconciler) SetName(_ string) {}
+
+// SetRule does nothing, all the versions of the CRD are converted
+func (reconciler *ConversionWebhookReconciler) SetRule(_ RuleWithOperations) {}
+
+func (reconciler *ConversionWebhookReconciler) Reconcile(ctx context.Context, client k8sclient.Client, caBundle []byte) error {
+	port := int32(servicePort)
+	watchNS, err := k8s.GetWatchNamespace()
+	if err != nil {
+		return pkgerr.Wrap(err, "could not get watch namespace from operator_webhooks reconcile")
+	}
+	namespaceSegments := strings.Split(watchNS, "-")
+	namespacePrefix := strings.Join(namespaceSegments[0:2], "-") + "-"
+
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := client.Get(ctx, k8sclient.ObjectKey{Name: reconciler.CRDName}, crd); err != nil {
+		return pkgerr.Wrapf(err, "could not get CRD %s", reconciler.CRDName)
+	}
+
+	patch := k8sclient.MergeFrom(crd.DeepCopy())
+	crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				CABundle: caBundle,
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: namespacePrefix + "operator",
+					Name:      operatorPodServiceName,
+					Path:      &reconciler.Path,
+					Port:      &port,
+				},
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+	return client.Patch(ctx, crd, patch)
+}
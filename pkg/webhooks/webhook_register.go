@@ -10,6 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 )
 
 // WebhookRegister knows how the register a webhook into the server. Either by
@@ -165,3 +166,35 @@ func (awr AdmissionWebhookRegister) GetReconciler(_ *runtime.Scheme) (WebhookRec
 
 	return nil, fmt.Errorf("Unsupported type for AdmissionWebhookRegister: %s", awr.Type)
 }
+
+// ConversionWebhookRegister registers the conversion webhook of the types in
+// the scheme that implement the Hub or Convertible interfaces, and points the
+// CRD to it
+type ConversionWebhookRegister struct {
+	CRDName string
+	Path    string
+}
+
+// RegisterToBuilder does not mutate the WebhookBuilder
+func (cwr ConversionWebhookRegister) RegisterToBuilder(bldr *builder.WebhookBuilder) *builder.WebhookBuilder {
+	return bldr
+}
+
+// RegisterToServer registers the conversion webhook to the path of `cwr`
+func (cwr ConversionWebhookRegister) RegisterToServer(scheme *runtime.Scheme, srv *webhook.Server) {
+	hook := &conversion.Webhook{}
+	if err := hook.InjectScheme(scheme); err != nil {
+		fmt.Printf("failed to inject scheme into the conversion webhook with error: %v", err)
+		return
+	}
+
+	srv.Register(cwr.Path, hook)
+}
+
+// GetReconciler creates a reconciler for the conversion of the CRD of `cwr`
+func (cwr ConversionWebhookRegister) GetReconciler(_ *runtime.Scheme) (WebhookReconciler, error) {
+	return &ConversionWebhookReconciler{
+		CRDName: cwr.CRDName,
+		Path:    cwr.Path,
+	}, nil
+}
@@ -133,15 +133,20 @@ func ec2TagsContains(tags []*ec2.Tag, key, value string) bool {
 	return false
 }
 
+// describeSubnets returns the subnets matching the input's filters from all result pages
 func describeSubnets(ec2svc *ec2.EC2, input *ec2.DescribeSubnetsInput) ([]*ec2.Subnet, error) {
-	describeSubnetsOutput, err := ec2svc.DescribeSubnets(input)
+	var subnets []*ec2.Subnet
+	err := ec2svc.DescribeSubnetsPages(input, func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		subnets = append(subnets, page.Subnets...)
+		return true
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not describe subnets: %w", err)
 	}
-	if len(describeSubnetsOutput.Subnets) == 0 {
+	if len(subnets) == 0 {
 		return nil, fmt.Errorf("could not find any subnets")
 	}
-	return describeSubnetsOutput.Subnets, nil
+	return subnets, nil
 }
 
 func getAwsClusterSubnets(ec2svc *ec2.EC2, clusterID string) ([]*ec2.Subnet, error) {
@@ -177,15 +182,20 @@ func getStandaloneSubnets(ec2svc *ec2.EC2, clusterID string) ([]*ec2.Subnet, err
 	return subnets, nil
 }
 
+// describeVpcs returns the vpcs matching the input's filters from all result pages
 func describeVpcs(ec2svc *ec2.EC2, input *ec2.DescribeVpcsInput) ([]*ec2.Vpc, error) {
-	describeVpcsOutput, err := ec2svc.DescribeVpcs(input)
+	var vpcs []*ec2.Vpc
+	err := ec2svc.DescribeVpcsPages(input, func(page *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		vpcs = append(vpcs, page.Vpcs...)
+		return true
+	})
 	if err != nil {
 		return nil, fmt.Errorf("could not describe vpcs: %w", err)
 	}
-	if len(describeVpcsOutput.Vpcs) == 0 {
+	if len(vpcs) == 0 {
 		return nil, fmt.Errorf("could not find any vpcs")
 	}
-	return describeVpcsOutput.Vpcs, nil
+	return vpcs, nil
 }
 
 func getAwsClusterVpc(ec2svc *ec2.EC2, clusterID string) (*ec2.Vpc, error) {
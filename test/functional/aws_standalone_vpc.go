@@ -329,21 +329,25 @@ func verifySecurityGroup(session *ec2.EC2, clusterTag string) error {
 	}
 
 	// filter security groups by integreatly cluster id tag
-	describeGroups, err := session.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+	var secGroups []*ec2.SecurityGroup
+	err := session.DescribeSecurityGroupsPages(&ec2.DescribeSecurityGroupsInput{
 		Filters: []*ec2.Filter{
 			{
 				Name:   aws.String("tag:" + standaloneResourceTagKey),
 				Values: []*string{aws.String(clusterTag)},
 			},
 		},
+	}, func(page *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+		secGroups = append(secGroups, page.SecurityGroups...)
+		return true
 	})
 	if err != nil {
 		errMsg := fmt.Errorf("could not find vpc security group: %v", err)
 		newErr.securityGroupError = append(newErr.securityGroupError, errMsg)
+		return newErr
 	}
 
 	// expect 1 security group
-	secGroups := describeGroups.SecurityGroups
 	if len(secGroups) != 1 {
 		errMsg := fmt.Errorf("unexpected number of security groups: %d", len(secGroups))
 		newErr.securityGroupError = append(newErr.securityGroupError, errMsg)
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	TestInstallationName      = "rhoam"
+	TestNamespacePrefix       = "redhat-rhoam-"
+	TestInstallationNamespace = TestNamespacePrefix + "operator"
+	TestRoutingSubdomain      = "apps.example.com"
+)
+
+// InstallationOption modifies an RHMI CR built by NewTestInstallation
+type InstallationOption func(*integreatlyv1alpha1.RHMI)
+
+// NewTestInstallation returns an RHMI CR of the given installation type with
+// the fields that reconcilers commonly read already set
+func NewTestInstallation(installationType integreatlyv1alpha1.InstallationType, opts ...InstallationOption) *integreatlyv1alpha1.RHMI {
+	installation := &integreatlyv1alpha1.RHMI{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RHMI",
+			APIVersion: integreatlyv1alpha1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       TestInstallationName,
+			Namespace:  TestInstallationNamespace,
+			UID:        "test-installation-uid",
+			Finalizers: []string{"foo.example.com"},
+		},
+		Spec: integreatlyv1alpha1.RHMISpec{
+			Type:             string(installationType),
+			NamespacePrefix:  TestNamespacePrefix,
+			RoutingSubdomain: TestRoutingSubdomain,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(installation)
+	}
+
+	return installation
+}
+
+// NewTestManagedApiInstallation returns a managed-api RHMI CR
+func NewTestManagedApiInstallation(opts ...InstallationOption) *integreatlyv1alpha1.RHMI {
+	return NewTestInstallation(integreatlyv1alpha1.InstallationTypeManagedApi, opts...)
+}
+
+// NewTestMultitenantInstallation returns a multitenant-managed-api RHMI CR
+func NewTestMultitenantInstallation(opts ...InstallationOption) *integreatlyv1alpha1.RHMI {
+	return NewTestInstallation(integreatlyv1alpha1.InstallationTypeMultitenantManagedApi, opts...)
+}
+
+// WithUninstall marks the installation as being deleted
+func WithUninstall() InstallationOption {
+	return func(installation *integreatlyv1alpha1.RHMI) {
+		now := metav1.Now()
+		installation.DeletionTimestamp = &now
+	}
+}
+
+// WithStage sets the stage the installation is in
+func WithStage(stage integreatlyv1alpha1.StageName) InstallationOption {
+	return func(installation *integreatlyv1alpha1.RHMI) {
+		installation.Status.Stage = stage
+	}
+}
+
+// WithQuota sets the active quota of the installation
+func WithQuota(quota string) InstallationOption {
+	return func(installation *integreatlyv1alpha1.RHMI) {
+		installation.Status.Quota = quota
+	}
+}
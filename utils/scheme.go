@@ -7,6 +7,7 @@ import (
 	grafanav1alpha1 "github.com/grafana-operator/grafana-operator/v4/api/integreatly/v1alpha1"
 	crov1 "github.com/integr8ly/cloud-resource-operator/apis/integreatly/v1alpha1"
 	integreatlyv1alpha1 "github.com/integr8ly/integreatly-operator/apis/v1alpha1"
+	integreatlyv1alpha2 "github.com/integr8ly/integreatly-operator/apis/v1alpha2"
 	keycloakv1alpha1 "github.com/integr8ly/keycloak-client/apis/keycloak/v1alpha1"
 	obv1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	noobaav1 "github.com/noobaa/noobaa-operator/v5/pkg/apis/noobaa/v1alpha1"
@@ -29,6 +30,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -49,6 +51,7 @@ func NewTestScheme() (*runtime.Scheme, error) {
 		threescaleAppsv1.AddToScheme,
 		keycloakv1alpha1.AddToScheme,
 		integreatlyv1alpha1.AddToScheme,
+		integreatlyv1alpha2.AddToScheme,
 		operatorsv1.AddToScheme,
 		operatorsv1alpha1.AddToScheme,
 		usersv1.Install,
@@ -74,6 +77,7 @@ func NewTestScheme() (*runtime.Scheme, error) {
 		noobaav1.SchemeBuilder.AddToScheme,
 		obv1.SchemeBuilder.AddToScheme,
 		storagev1.AddToScheme,
+		networkingv1.AddToScheme,
 		addonv1alpha1.AddToScheme,
 		packageOperatorv1alpha1.AddToScheme,
 	)
@@ -0,0 +1,47 @@
+package version
+
+import (
+	"runtime/debug"
+)
+
+const unknown = "unknown"
+
+// GitSHA and BuildDate are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/integr8ly/integreatly-operator/version.GitSHA=$(git rev-parse HEAD)"
+//
+// When they aren't set the VCS information embedded by the go toolchain is used
+var (
+	GitSHA    = ""
+	BuildDate = ""
+)
+
+// GetGitSHA returns the commit the operator was built from
+func GetGitSHA() string {
+	if GitSHA != "" {
+		return GitSHA
+	}
+	return vcsSetting("vcs.revision")
+}
+
+// GetBuildDate returns when the operator was built, falling back to the time
+// of the commit it was built from
+func GetBuildDate() string {
+	if BuildDate != "" {
+		return BuildDate
+	}
+	return vcsSetting("vcs.time")
+}
+
+func vcsSetting(key string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return unknown
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == key && setting.Value != "" {
+			return setting.Value
+		}
+	}
+	return unknown
+}